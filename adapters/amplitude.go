@@ -0,0 +1,160 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultAmplitudeEndpoint = "https://api2.amplitude.com/batch"
+	defaultAmplitudeTimeout  = 30 * time.Second
+
+	//Amplitude Batch API limits: events per request and min user_id/device_id length
+	AmplitudeMaxBatchSize = 2000
+	AmplitudeMinIDLength  = 5
+
+	amplitudeRetries   = 3
+	amplitudeRetryWait = time.Second
+)
+
+//AmplitudeConfig dto for deserialized Amplitude destination config
+//user_id_field, device_id_field, event_type_field: flattened event fields which are sent as Amplitude user_id, device_id and event_type
+//other event fields are sent as event_properties
+type AmplitudeConfig struct {
+	APIKey         string        `mapstructure:"api_key"`
+	Endpoint       string        `mapstructure:"endpoint"`
+	UserIDField    string        `mapstructure:"user_id_field"`
+	DeviceIDField  string        `mapstructure:"device_id_field"`
+	EventTypeField string        `mapstructure:"event_type_field"`
+	BatchSize      int           `mapstructure:"batch_size"`
+	FlushEvery     time.Duration `mapstructure:"flush_every"`
+	Timeout        time.Duration `mapstructure:"timeout"`
+}
+
+//Validate required fields in AmplitudeConfig
+func (ac *AmplitudeConfig) Validate() error {
+	if ac == nil {
+		return errors.New("Amplitude config is required")
+	}
+	if ac.APIKey == "" {
+		return errors.New("Amplitude api_key is required parameter")
+	}
+	if ac.BatchSize < 0 || ac.FlushEvery < 0 || ac.Timeout < 0 {
+		return errors.New("Amplitude batch_size, flush_every and timeout can't be negative")
+	}
+	if ac.BatchSize > AmplitudeMaxBatchSize {
+		return fmt.Errorf("Amplitude batch_size can't be greater than %d", AmplitudeMaxBatchSize)
+	}
+
+	return nil
+}
+
+//AmplitudeEvent is an event in Amplitude HTTP API format
+type AmplitudeEvent struct {
+	UserID          string                 `json:"user_id,omitempty"`
+	DeviceID        string                 `json:"device_id,omitempty"`
+	EventType       string                 `json:"event_type"`
+	Time            int64                  `json:"time"`
+	InsertID        string                 `json:"insert_id,omitempty"`
+	EventProperties map[string]interface{} `json:"event_properties,omitempty"`
+}
+
+type amplitudeRequest struct {
+	APIKey string            `json:"api_key"`
+	Events []*AmplitudeEvent `json:"events"`
+}
+
+//Amplitude is adapter for sending events to Amplitude Batch API
+type Amplitude struct {
+	apiKey   string
+	endpoint string
+	client   *http.Client
+}
+
+//NewAmplitude return configured Amplitude adapter instance
+func NewAmplitude(config *AmplitudeConfig) *Amplitude {
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = defaultAmplitudeEndpoint
+	}
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = defaultAmplitudeTimeout
+	}
+
+	return &Amplitude{apiKey: config.APIKey, endpoint: endpoint, client: &http.Client{Timeout: timeout}}
+}
+
+func (Amplitude) Name() string {
+	return "Amplitude"
+}
+
+//Send events in one request with retries on network errors, 429 and 5xx responses
+//events are split in halves if request payload is too large
+func (a *Amplitude) Send(events []*AmplitudeEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(&amplitudeRequest{APIKey: a.apiKey, Events: events})
+	if err != nil {
+		return err
+	}
+
+	wait := amplitudeRetryWait
+	for i := 1; ; i++ {
+		code, err := a.doRequest(body)
+		if err == nil {
+			return nil
+		}
+
+		if code == http.StatusRequestEntityTooLarge && len(events) > 1 {
+			if err := a.Send(events[:len(events)/2]); err != nil {
+				return err
+			}
+			return a.Send(events[len(events)/2:])
+		}
+
+		retry := code == 0 || code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+		if !retry || i >= amplitudeRetries {
+			return fmt.Errorf("Error sending %d events to Amplitude: %v", len(events), err)
+		}
+
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+//return response code (0 on network errors) and error if request wasn't successful
+func (a *Amplitude) doRequest(body []byte) (int, error) {
+	request, err := http.NewRequest(http.MethodPost, a.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := a.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusOK {
+		io.Copy(ioutil.Discard, response.Body)
+		return response.StatusCode, nil
+	}
+
+	responseBody, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+	return response.StatusCode, fmt.Errorf("response code: %d body: %s", response.StatusCode, string(responseBody))
+}
+
+func (a *Amplitude) Close() error {
+	a.client.CloseIdleConnections()
+	return nil
+}
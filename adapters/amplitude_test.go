@@ -0,0 +1,51 @@
+package adapters
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAmplitudeSend(t *testing.T) {
+	tests := []struct {
+		name            string
+		maxEvents       int
+		responseCode    int
+		expectedBatches []int
+		expectedErr     bool
+	}{
+		{"One request", 10, http.StatusOK, []int{4}, false},
+		{"Split too large payload", 1, http.StatusOK, []int{1, 1, 1, 1}, false},
+		{"Invalid request", 10, http.StatusBadRequest, []int{4}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var batches []int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				request := &amplitudeRequest{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(request))
+				require.Equal(t, "key1", request.APIKey)
+				if len(request.Events) > tt.maxEvents {
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+					return
+				}
+
+				batches = append(batches, len(request.Events))
+				w.WriteHeader(tt.responseCode)
+			}))
+			defer server.Close()
+
+			amplitude := NewAmplitude(&AmplitudeConfig{APIKey: "key1", Endpoint: server.URL})
+			events := []*AmplitudeEvent{{EventType: "a"}, {EventType: "b"}, {EventType: "c"}, {EventType: "d"}}
+			err := amplitude.Send(events)
+			if tt.expectedErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.expectedBatches, batches)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
 	_ "github.com/lib/pq"
 )
 
@@ -32,6 +33,8 @@ func NewAwsRedshift(ctx context.Context, dsConfig *DataSourceConfig, s3Config *S
 		return nil, err
 	}
 
+	postgres.reservedWords = redshiftReservedWords
+
 	return &AwsRedshift{dataSourceProxy: postgres, s3Config: s3Config}, nil
 }
 
@@ -39,6 +42,11 @@ func (AwsRedshift) Name() string {
 	return "Redshift"
 }
 
+//Location return database and schema where tables are created
+func (ar *AwsRedshift) Location() (string, string) {
+	return ar.dataSourceProxy.Location()
+}
+
 //OpenTx open underline sql transaction and return wrapped instance
 func (ar *AwsRedshift) OpenTx() (*Transaction, error) {
 	tx, err := ar.dataSourceProxy.dataSource.BeginTx(ar.dataSourceProxy.ctx, nil)
@@ -97,6 +105,11 @@ func (ar *AwsRedshift) GetTableSchema(tableName string) (*schema.Table, error) {
 	return ar.dataSourceProxy.GetTableSchema(tableName)
 }
 
+//NumericBounds return value range of the redshift column which is created for the data type
+func (ar *AwsRedshift) NumericBounds(dataType typing.DataType) *schema.NumericBounds {
+	return ar.dataSourceProxy.NumericBounds(dataType)
+}
+
 //CreateTable create database table with name,columns provided in schema.Table representation
 func (ar *AwsRedshift) CreateTable(tableSchema *schema.Table) error {
 	wrappedTx, err := ar.OpenTx()
@@ -107,6 +120,36 @@ func (ar *AwsRedshift) CreateTable(tableSchema *schema.Table) error {
 	return ar.dataSourceProxy.createTableInTransaction(wrappedTx, tableSchema)
 }
 
+//CreateTableDDL return statements which create the table (see Postgres.CreateTableDDL)
+func (ar *AwsRedshift) CreateTableDDL(tableSchema *schema.Table) []string {
+	return ar.dataSourceProxy.CreateTableDDL(tableSchema)
+}
+
+//PatchTableDDL return statements which add new columns to existing table (see Postgres.PatchTableDDL)
+func (ar *AwsRedshift) PatchTableDDL(patchSchema *schema.Table) []string {
+	return ar.dataSourceProxy.PatchTableDDL(patchSchema)
+}
+
+//DropColumn drop the column of existing table (admin API only)
+func (ar *AwsRedshift) DropColumn(tableName, columnName string) error {
+	return ar.dataSourceProxy.DropColumn(tableName, columnName)
+}
+
+//DropColumnDDL return ALTER TABLE DROP COLUMN statement (see Postgres.DropColumnDDL)
+func (ar *AwsRedshift) DropColumnDDL(tableName, columnName string) []string {
+	return ar.dataSourceProxy.DropColumnDDL(tableName, columnName)
+}
+
+//SelectLast return last limit rows of the table where keyColumn equals value ordered by orderColumn desc
+func (ar *AwsRedshift) SelectLast(tableName, keyColumn, orderColumn string, value interface{}, limit int) ([]map[string]interface{}, error) {
+	return ar.dataSourceProxy.SelectLast(tableName, keyColumn, orderColumn, value, limit)
+}
+
+//SelectMetric return rows of predefined aggregate query
+func (ar *AwsRedshift) SelectMetric(query *MetricQuery) ([]map[string]interface{}, error) {
+	return ar.dataSourceProxy.SelectMetric(query)
+}
+
 //Close underlying sql.DB
 func (ar *AwsRedshift) Close() error {
 	return ar.dataSourceProxy.Close()
@@ -10,6 +10,13 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"time"
+)
+
+const (
+	stagingTableBQInfix      = "_staging_"
+	stagingTableBQExpiration = time.Hour
+	rowNumberBQColumn        = "_eventnative_row_number"
 )
 
 var (
@@ -18,6 +25,8 @@ var (
 		typing.INT64:     bigquery.IntegerFieldType,
 		typing.FLOAT64:   bigquery.FloatFieldType,
 		typing.TIMESTAMP: bigquery.TimestampFieldType,
+		typing.JSON:      bigquery.StringFieldType,
+		typing.DECIMAL:   bigquery.NumericFieldType,
 	}
 
 	BigQueryToSchema = map[bigquery.FieldType]typing.DataType{
@@ -25,7 +34,11 @@ var (
 		bigquery.IntegerFieldType:   typing.INT64,
 		bigquery.FloatFieldType:     typing.FLOAT64,
 		bigquery.TimestampFieldType: typing.TIMESTAMP,
+		bigquery.NumericFieldType:   typing.DECIMAL,
 	}
+
+	//NUMERIC type has fixed precision and scale
+	bigQueryNumericType = &schema.DecimalType{Precision: bigquery.NumericPrecisionDigits, Scale: bigquery.NumericScaleDigits}
 )
 
 type BigQuery struct {
@@ -45,7 +58,60 @@ func NewBigQuery(ctx context.Context, config *GoogleConfig) (*BigQuery, error) {
 
 //Transfer data from google cloud storage file to google BigQuery table as one batch
 func (bq *BigQuery) Copy(fileKey, tableName string) error {
-	table := bq.client.Dataset(bq.config.Dataset).Table(tableName)
+	return bq.load(fileKey, bq.client.Dataset(bq.config.Dataset).Table(tableName))
+}
+
+//Merge transfer data from google cloud storage file to google BigQuery table as one batch with replacing rows
+//which have the same upsert keys values:
+//1. load file into temporary staging table with the same schema
+//2. MERGE the latest (by timeColumn: _timestamp or its destination name) staging row per keys into the table
+//3. delete staging table (it expires anyway)
+func (bq *BigQuery) Merge(fileKey, tableName string, upsertKeys []string, timeColumn string) error {
+	dataset := bq.client.Dataset(bq.config.Dataset)
+	metadata, err := dataset.Table(tableName).Metadata(bq.ctx)
+	if err != nil {
+		return fmt.Errorf("Error getting table %s metadata: %v", tableName, err)
+	}
+
+	stagingTableName := fmt.Sprintf("%s%s%d", tableName, stagingTableBQInfix, time.Now().UTC().UnixNano())
+	stagingTable := dataset.Table(stagingTableName)
+	if err := stagingTable.Create(bq.ctx, &bigquery.TableMetadata{Schema: metadata.Schema, ExpirationTime: time.Now().Add(stagingTableBQExpiration)}); err != nil {
+		return fmt.Errorf("Error creating BigQuery staging table %s: %v", stagingTableName, err)
+	}
+	defer func() {
+		if err := stagingTable.Delete(bq.ctx); err != nil && !isNotFoundErr(err) {
+			log.Printf("Error deleting BigQuery staging table %s: %v", stagingTableName, err)
+		}
+	}()
+
+	if err := bq.load(fileKey, stagingTable); err != nil {
+		return err
+	}
+
+	var columns []string
+	for _, field := range metadata.Schema {
+		columns = append(columns, field.Name)
+	}
+	statement := mergeStatement(bq.tableID(tableName), bq.tableID(stagingTableName), columns, upsertKeys, timeColumn)
+
+	job, err := bq.client.Query(statement).Run(bq.ctx)
+	if err != nil {
+		return fmt.Errorf("Error running merging BigQuery staging table %s into table %s: %v", stagingTableName, tableName, err)
+	}
+	jobStatus, err := job.Wait(bq.ctx)
+	if err != nil {
+		return fmt.Errorf("Error waiting merging job of BigQuery staging table %s into table %s: %v", stagingTableName, tableName, err)
+	}
+	if jobStatus.Err() != nil {
+		return fmt.Errorf("Error merging BigQuery staging table %s into table %s with statement [%s]: %v", stagingTableName, tableName, statement, jobStatus.Err())
+	}
+
+	return nil
+}
+
+//load google cloud storage file into existing BigQuery table
+func (bq *BigQuery) load(fileKey string, table *bigquery.Table) error {
+	tableName := table.TableID
 
 	gcsRef := bigquery.NewGCSReference(fmt.Sprintf("gs://%s/%s", bq.config.Bucket, fileKey))
 	gcsRef.SourceFormat = bigquery.JSON
@@ -96,6 +162,10 @@ func (bq *BigQuery) GetTableSchema(tableName string) (*schema.Table, error) {
 			log.Println("Unknown BigQuery column type:", field.Type)
 			mappedType = typing.STRING
 		}
+		if mappedType == typing.DECIMAL {
+			table.Columns[field.Name] = schema.NewDecimalColumn(bigQueryNumericType, "")
+			continue
+		}
 		table.Columns[field.Name] = schema.NewColumn(mappedType)
 	}
 
@@ -123,7 +193,7 @@ func (bq *BigQuery) CreateTable(tableSchema *schema.Table) error {
 			log.Println("Unknown BigQuery schema type:", column.GetType())
 			mappedType = SchemaToBigQuery[typing.STRING]
 		}
-		bqSchema = append(bqSchema, &bigquery.FieldSchema{Name: columnName, Type: mappedType})
+		bqSchema = append(bqSchema, &bigquery.FieldSchema{Name: columnName, Type: mappedType, Description: column.Description()})
 	}
 
 	if err := bqTable.Create(bq.ctx, &bigquery.TableMetadata{Name: tableSchema.Name, Schema: bqSchema}); err != nil {
@@ -163,7 +233,7 @@ func (bq *BigQuery) PatchTableSchema(patchSchema *schema.Table) error {
 			log.Println("Unknown BigQuery schema type:", column.GetType().String())
 			mappedColumnType = SchemaToBigQuery[typing.STRING]
 		}
-		metadata.Schema = append(metadata.Schema, &bigquery.FieldSchema{Name: columnName, Type: mappedColumnType})
+		metadata.Schema = append(metadata.Schema, &bigquery.FieldSchema{Name: columnName, Type: mappedColumnType, Description: column.Description()})
 	}
 
 	updateReq := bigquery.TableMetadataToUpdate{Schema: metadata.Schema}
@@ -178,6 +248,16 @@ func (bq *BigQuery) PatchTableSchema(patchSchema *schema.Table) error {
 	return nil
 }
 
+//DestructiveChanges return nil: tables are created and fields are added with BigQuery API, existing fields are never changed
+func (bq *BigQuery) DestructiveChanges(tableSchema *schema.Table, patch bool) []string {
+	return nil
+}
+
+//Location return project and dataset where tables are created
+func (bq *BigQuery) Location() (string, string) {
+	return bq.config.Project, bq.config.Dataset
+}
+
 func (bq *BigQuery) Close() error {
 	if err := bq.client.Close(); err != nil {
 		return fmt.Errorf("Error closing BigQuery client: %v", err)
@@ -186,6 +266,47 @@ func (bq *BigQuery) Close() error {
 	return nil
 }
 
+//return fully qualified table name for using in queries
+func (bq *BigQuery) tableID(tableName string) string {
+	return fmt.Sprintf("`%s.%s.%s`", bq.config.Project, bq.config.Dataset, tableName)
+}
+
+//return MERGE statement which updates target rows or inserts new ones with the latest staging row per upsert keys
+//staging rows are ordered by timeColumn if the table has it
+func mergeStatement(target, staging string, columns, upsertKeys []string, timeColumn string) string {
+	keys := map[string]bool{}
+	var conditions []string
+	for _, key := range upsertKeys {
+		keys[key] = true
+		conditions = append(conditions, fmt.Sprintf("T.`%s` = S.`%s`", key, key))
+	}
+
+	var orderBy string
+	var updates []string
+	for _, column := range columns {
+		if column == timeColumn {
+			orderBy = fmt.Sprintf(" ORDER BY `%s` DESC", timeColumn)
+		}
+		if !keys[column] {
+			updates = append(updates, fmt.Sprintf("`%s` = S.`%s`", column, column))
+		}
+	}
+
+	var partitionBy []string
+	for _, key := range upsertKeys {
+		partitionBy = append(partitionBy, "`"+key+"`")
+	}
+
+	source := fmt.Sprintf("SELECT * EXCEPT(%s) FROM (SELECT *, ROW_NUMBER() OVER (PARTITION BY %s%s) AS %s FROM %s) WHERE %s = 1",
+		rowNumberBQColumn, strings.Join(partitionBy, ", "), orderBy, rowNumberBQColumn, staging, rowNumberBQColumn)
+	statement := fmt.Sprintf("MERGE %s T USING (%s) S ON %s", target, source, strings.Join(conditions, " AND "))
+	if len(updates) > 0 {
+		statement += " WHEN MATCHED THEN UPDATE SET " + strings.Join(updates, ", ")
+	}
+
+	return statement + " WHEN NOT MATCHED THEN INSERT ROW"
+}
+
 //Return true if google err is 404
 func isNotFoundErr(err error) bool {
 	e, ok := err.(*googleapi.Error)
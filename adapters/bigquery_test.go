@@ -0,0 +1,33 @@
+package adapters
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMergeStatement(t *testing.T) {
+	tests := []struct {
+		name       string
+		columns    []string
+		upsertKeys []string
+		expected   string
+	}{
+		{
+			"Table with _timestamp",
+			[]string{"_timestamp", "user_id", "email"},
+			[]string{"user_id"},
+			"MERGE `p.d.users` T USING (SELECT * EXCEPT(_eventnative_row_number) FROM (SELECT *, ROW_NUMBER() OVER (PARTITION BY `user_id` ORDER BY `_timestamp` DESC) AS _eventnative_row_number FROM `p.d.users_staging`) WHERE _eventnative_row_number = 1) S ON T.`user_id` = S.`user_id` WHEN MATCHED THEN UPDATE SET `_timestamp` = S.`_timestamp`, `email` = S.`email` WHEN NOT MATCHED THEN INSERT ROW",
+		},
+		{
+			"Table with composite key only",
+			[]string{"project_id", "user_id"},
+			[]string{"project_id", "user_id"},
+			"MERGE `p.d.users` T USING (SELECT * EXCEPT(_eventnative_row_number) FROM (SELECT *, ROW_NUMBER() OVER (PARTITION BY `project_id`, `user_id`) AS _eventnative_row_number FROM `p.d.users_staging`) WHERE _eventnative_row_number = 1) S ON T.`project_id` = S.`project_id` AND T.`user_id` = S.`user_id` WHEN NOT MATCHED THEN INSERT ROW",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, mergeStatement("`p.d.users`", "`p.d.users_staging`", tt.columns, tt.upsertKeys, "_timestamp"))
+		})
+	}
+}
@@ -5,46 +5,122 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"github.com/ClickHouse/clickhouse-go/lib/data"
+	"github.com/ksensehq/eventnative/reports"
 	"github.com/ksensehq/eventnative/schema"
 	"github.com/ksensehq/eventnative/typing"
 	"github.com/mailru/go-clickhouse"
 	"io/ioutil"
 	"log"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
 	tableSchemaCHQuery        = `SELECT name, type FROM system.columns WHERE database = ? and table = ?`
 	createCHDBTemplate        = `CREATE DATABASE IF NOT EXISTS %s %s`
-	addColumnCHTemplate       = `ALTER TABLE "%s"."%s" %s ADD COLUMN %s Nullable(%s)`
-	insertCHTemplate          = `INSERT INTO "%s"."%s" (%s) VALUES (%s)`
+	addColumnCHTemplate       = `ALTER TABLE "%s"."%s" %s ADD COLUMN IF NOT EXISTS %s`
+	modifyTTLCHTemplate       = `ALTER TABLE "%s"."%s" %s MODIFY TTL %s`
+	insertCHTemplate          = `INSERT INTO "%s"."%s" (%s)%s VALUES (%s)`
+	deleteCHTemplate          = `ALTER TABLE "%s"."%s" %s DELETE WHERE %s`
+	selectLastCHTemplate      = `SELECT * FROM "%s"."%s" WHERE %s = ? ORDER BY %s DESC LIMIT ?`
 	onClusterCHClauseTemplate = ` ON CLUSTER %s `
-	columnCHNullableTemplate  = ` Nullable(%s) `
-
-	createTableCHTemplate            = `CREATE TABLE "%s"."%s" %s (%s) %s %s %s %s`
-	createDistributedTableCHTemplate = `CREATE TABLE "%s"."dist_%s" %s AS "%s"."%s" ENGINE = Distributed(%s,%s,%s,rand())`
-	dropDistributedTableCHTemplate   = `DROP TABLE "%s"."dist_%s" %s`
+	codecCHClauseTemplate     = ` CODEC(%s)`
+	commentCHClauseTemplate   = ` COMMENT %s`
+	nullableCHTypePrefix      = "Nullable("
+	lowCardinalityCHPrefix    = "LowCardinality("
+	arrayCHTypePrefix         = "Array("
+
+	createTableCHTemplate            = `CREATE TABLE %s"%s"."%s" %s (%s) %s %s %s %s %s`
+	createDistributedTableCHTemplate = `CREATE TABLE IF NOT EXISTS "%s"."%s" %s AS "%s"."%s" ENGINE = Distributed(%s,%s,%s,%s)`
+	dropDistributedTableCHTemplate   = `DROP TABLE "%s"."%s" %s`
+	distributedTableCHPrefix         = "dist_"
+	defaultShardingKey               = "rand()"
+	createBufferTableCHTemplate      = `CREATE TABLE IF NOT EXISTS "%s"."%s" %s AS "%s"."%s" ENGINE = Buffer('%s', '%s', %d, %d, %d, %d, %d, %d, %d)`
+	dropBufferTableCHTemplate        = `DROP TABLE IF EXISTS "%s"."%s" %s`
+	bufferTableCHPrefix              = "buffer_"
+	createStagingTableCHTemplate     = `CREATE TABLE "%s"."%s" AS "%s"."%s" ENGINE = MergeTree() ORDER BY tuple()`
+	moveStagingTableCHTemplate       = `INSERT INTO "%s"."%s" SELECT * FROM "%s"."%s"`
+	dropStagingTableCHTemplate       = `DROP TABLE IF EXISTS "%s"."%s"`
+	stagingTableCHInfix              = "_staging_"
+	ifNotExistsCHClause              = `IF NOT EXISTS `
+
+	replicatedEngineCHTemplate = `ENGINE = ReplicatedReplacingMergeTree('%s', '%s', _timestamp)`
+	defaultZookeeperPath       = `/clickhouse/tables/{shard}/{database}/{table}`
+	defaultReplicaName         = `{replica}`
+	databasePlaceholder        = "{database}"
+	tablePlaceholder           = "{table}"
+
+	ReplacingEngine                 = "replacing"
+	CollapsingEngine                = "collapsing"
+	tableEngineCHTemplate           = `ENGINE = %sMergeTree(%s)`
+	replicatedTableEngineCHTemplate = `ENGINE = Replicated%sMergeTree('%s', '%s', %s)`
+	defaultVersionColumn            = "_version"
+	defaultSignColumn               = "_sign"
+	versionColumnCHType             = "UInt64"
+	signColumnCHType                = "Int8"
 
 	defaultPartition  = `PARTITION BY (toYYYYMM(_timestamp))`
 	defaultOrderBy    = `ORDER BY (eventn_ctx_event_id)`
 	defaultPrimaryKey = ``
+
+	RoundRobinStrategy      = "round_robin"
+	LeastErrorsStrategy     = "least_errors"
+	defaultHealthCheckEvery = 10 * time.Second
+	defaultMaxNodeErrors    = 3
+
+	//default values from ClickHouse Buffer engine documentation
+	defaultBufferNumLayers = 16
+	defaultBufferMinTime   = 10 * time.Second
+	defaultBufferMaxTime   = 100 * time.Second
+	defaultBufferMinRows   = 10000
+	defaultBufferMaxRows   = 1000000
+	defaultBufferMinBytes  = 10000000
+	defaultBufferMaxBytes  = 100000000
 )
 
 var (
+	//identifier and optional opening bracket (if identifier is a function name)
+	expressionIdentifier = regexp.MustCompile(`\b([A-Za-z_][A-Za-z0-9_]*)\s*(\()?`)
+	stringLiteral        = regexp.MustCompile(`'[^']*'`)
+
+	//metrics queries templates: time column, aggregated column, database, table, time column
+	clickhouseMetricsTemplates = map[string]string{
+		EventsPerHourMetric: `SELECT toStartOfHour(%s) AS hour, %s AS event_type, count() AS events FROM "%s"."%s" WHERE %s >= ? GROUP BY hour, event_type ORDER BY hour, event_type`,
+		UniquesPerDayMetric: `SELECT toStartOfDay(%s) AS day, uniqExact(%s) AS uniques FROM "%s"."%s" WHERE %s >= ? GROUP BY day ORDER BY day`,
+	}
+
 	schemaToClickhouse = map[typing.DataType]string{
 		typing.STRING:    "String",
 		typing.INT64:     "Int64",
 		typing.FLOAT64:   "Float64",
 		typing.TIMESTAMP: "DateTime",
+
+		typing.ARRAY_STRING:  "Array(String)",
+		typing.ARRAY_INT64:   "Array(Int64)",
+		typing.ARRAY_FLOAT64: "Array(Float64)",
+
+		//JSON documents are kept in String columns
+		typing.JSON: "String",
 	}
 
 	clickhouseToSchema = map[string]typing.DataType{
 		"String":             typing.STRING,
 		"Nullable(String)":   typing.STRING,
+		"Int8":               typing.INT64,
+		"Int16":              typing.INT64,
+		"Int32":              typing.INT64,
 		"Int64":              typing.INT64,
+		"UInt8":              typing.INT64,
+		"UInt16":             typing.INT64,
+		"UInt32":             typing.INT64,
+		"UInt64":             typing.INT64,
 		"Nullable(Int64)":    typing.INT64,
 		"Float64":            typing.FLOAT64,
 		"Nullable(Float64)":  typing.FLOAT64,
@@ -54,21 +130,264 @@ var (
 )
 
 //ClickHouseConfig dto for deserialized clickhouse config
+//staging: in batch mode every table data is inserted into staging table and moved into the main one with INSERT SELECT
+//after all file tables have been loaded (see ClickHouse.CreateStagingTable)
 type ClickHouseConfig struct {
-	Dsns     []string          `mapstructure:"dsns"`
-	Database string            `mapstructure:"db"`
-	Tls      map[string]string `mapstructure:"tls"`
-	Cluster  string            `mapstructure:"cluster"`
-	Engine   *EngineConfig     `mapstructure:"engine"`
+	Dsns        []string                `mapstructure:"dsns"`
+	Database    string                  `mapstructure:"db"`
+	Tls         map[string]string       `mapstructure:"tls"`
+	Cluster     string                  `mapstructure:"cluster"`
+	Engine      *EngineConfig           `mapstructure:"engine"`
+	Columns     map[string]ColumnConfig `mapstructure:"columns"`
+	Buffer      *BufferConfig           `mapstructure:"buffer"`
+	Balancer    *BalancerConfig         `mapstructure:"balancer"`
+	Native      *NativeConfig           `mapstructure:"native"`
+	Distributed *DistributedConfig      `mapstructure:"distributed"`
+	AsyncInsert *AsyncInsertConfig      `mapstructure:"async_insert"`
+	Staging     bool                    `mapstructure:"staging"`
+}
+
+//DistributedConfig dto for deserialized clickhouse distributed config
+//if provided - all rows are inserted into dist_$table tables (Distributed engine over per-shard local tables ON CLUSTER)
+//so ClickHouse spreads them across shards by sharding_key regardless of the dsn node which has been chosen by the balancer
+//sharding_key: Distributed engine sharding expression. Its fields are created as non-null columns. Default: rand()
+type DistributedConfig struct {
+	ShardingKey string `mapstructure:"sharding_key"`
+}
+
+//Validate DistributedConfig values and set default ones
+func (dc *DistributedConfig) Validate() error {
+	if dc.ShardingKey == "" {
+		dc.ShardingKey = defaultShardingKey
+	}
+
+	return nil
+}
+
+//KeyFields return fields of sharding key expression. Distributed engine doesn't allow Nullable sharding key
+func (dc *DistributedConfig) KeyFields() []string {
+	if dc == nil {
+		return nil
+	}
+
+	return expressionFields(dc.ShardingKey)
+}
+
+//BalancerConfig dto for deserialized clickhouse nodes (dsns) balancing config
+//strategy: round_robin or least_errors (node with the least errors since the last health check). Default: round_robin
+//health_check_every: every node is pinged with this interval. Failed nodes are excluded from rotation until successful ping. Default: 10s
+//max_errors: consecutive write errors after which node is excluded from rotation until successful ping. Default: 3
+type BalancerConfig struct {
+	Strategy         string        `mapstructure:"strategy"`
+	HealthCheckEvery time.Duration `mapstructure:"health_check_every"`
+	MaxErrors        int           `mapstructure:"max_errors"`
+}
+
+//Validate BalancerConfig values and set default ones
+func (bc *BalancerConfig) Validate() error {
+	switch bc.Strategy {
+	case "":
+		bc.Strategy = RoundRobinStrategy
+	case RoundRobinStrategy, LeastErrorsStrategy:
+	default:
+		return fmt.Errorf("Unknown balancer strategy: %s. Supported: %s, %s", bc.Strategy, RoundRobinStrategy, LeastErrorsStrategy)
+	}
+	if bc.HealthCheckEvery < 0 || bc.MaxErrors < 0 {
+		return errors.New("balancer health_check_every and max_errors can't be negative")
+	}
+	if bc.HealthCheckEvery == 0 {
+		bc.HealthCheckEvery = defaultHealthCheckEvery
+	}
+	if bc.MaxErrors == 0 {
+		bc.MaxErrors = defaultMaxNodeErrors
+	}
+
+	return nil
+}
+
+//BufferConfig dto for deserialized clickhouse Buffer engine config
+//if provided - in stream mode every table gets buffer_$table table with Buffer engine and events are inserted into it.
+//Buffer is flushed to the main table when all min_* or any max_* thresholds are reached (see ClickHouse Buffer engine docs)
+type BufferConfig struct {
+	NumLayers int           `mapstructure:"num_layers"`
+	MinTime   time.Duration `mapstructure:"min_time"`
+	MaxTime   time.Duration `mapstructure:"max_time"`
+	MinRows   int64         `mapstructure:"min_rows"`
+	MaxRows   int64         `mapstructure:"max_rows"`
+	MinBytes  int64         `mapstructure:"min_bytes"`
+	MaxBytes  int64         `mapstructure:"max_bytes"`
+}
+
+//Validate BufferConfig values and set default ones
+func (bc *BufferConfig) Validate() error {
+	if bc.NumLayers < 0 || bc.MinTime < 0 || bc.MaxTime < 0 || bc.MinRows < 0 || bc.MaxRows < 0 || bc.MinBytes < 0 || bc.MaxBytes < 0 {
+		return errors.New("buffer values can't be negative")
+	}
+	if bc.NumLayers == 0 {
+		bc.NumLayers = defaultBufferNumLayers
+	}
+	if bc.MinTime == 0 {
+		bc.MinTime = defaultBufferMinTime
+	}
+	if bc.MaxTime == 0 {
+		bc.MaxTime = defaultBufferMaxTime
+	}
+	if bc.MinRows == 0 {
+		bc.MinRows = defaultBufferMinRows
+	}
+	if bc.MaxRows == 0 {
+		bc.MaxRows = defaultBufferMaxRows
+	}
+	if bc.MinBytes == 0 {
+		bc.MinBytes = defaultBufferMinBytes
+	}
+	if bc.MaxBytes == 0 {
+		bc.MaxBytes = defaultBufferMaxBytes
+	}
+	if bc.MinTime > bc.MaxTime || bc.MinRows > bc.MaxRows || bc.MinBytes > bc.MaxBytes {
+		return errors.New("buffer min_* values must be less than or equal to max_* ones")
+	}
+
+	return nil
+}
+
+//AsyncInsertConfig dto for deserialized clickhouse async_insert config
+//if provided - in stream mode events are inserted with async_insert setting: ClickHouse collects them into batches on the server side
+//so single event inserts don't create tiny parts (requires ClickHouse 21.11+)
+//wait: wait_for_async_insert setting. If true - insert returns after the batch has been flushed (flush errors are returned),
+//otherwise right after buffering (lower latency but errors are lost). Default: true
+//busy_timeout: async_insert_busy_timeout_ms setting: max time of collecting a batch. Default: server setting
+//max_data_size: async_insert_max_data_size setting: max batch size in bytes. Default: server setting
+type AsyncInsertConfig struct {
+	Wait        *bool         `mapstructure:"wait"`
+	BusyTimeout time.Duration `mapstructure:"busy_timeout"`
+	MaxDataSize int64         `mapstructure:"max_data_size"`
+}
+
+//Validate AsyncInsertConfig values and set default ones
+func (aic *AsyncInsertConfig) Validate() error {
+	if aic.BusyTimeout < 0 || aic.MaxDataSize < 0 {
+		return errors.New("async_insert busy_timeout and max_data_size can't be negative")
+	}
+	if aic.Wait == nil {
+		wait := true
+		aic.Wait = &wait
+	}
+
+	return nil
+}
+
+//settingsClause return SETTINGS clause of INSERT statement. Config must be validated
+func (aic *AsyncInsertConfig) settingsClause() string {
+	wait := 0
+	if *aic.Wait {
+		wait = 1
+	}
+
+	settings := fmt.Sprintf(" SETTINGS async_insert=1, wait_for_async_insert=%d", wait)
+	if aic.BusyTimeout > 0 {
+		settings += fmt.Sprintf(", async_insert_busy_timeout_ms=%d", aic.BusyTimeout.Milliseconds())
+	}
+	if aic.MaxDataSize > 0 {
+		settings += fmt.Sprintf(", async_insert_max_data_size=%d", aic.MaxDataSize)
+	}
+
+	return settings
+}
+
+//ColumnConfig dto for deserialized clickhouse column options
+//type: column type instead of the default one (e.g. LowCardinality(String)). Nullable is added automatically to nullable fields
+//codec: column compression codec (e.g. ZSTD(1) or Delta, LZ4)
+type ColumnConfig struct {
+	Type  string `mapstructure:"type"`
+	Codec string `mapstructure:"codec"`
 }
 
 //EngineConfig dto for deserialized clickhouse engine config
+//replicated: use ReplicatedReplacingMergeTree engine (default: true if cluster is provided)
+//zookeeper_path: replicated table path in ZooKeeper. {database} and {table} are replaced with actual values,
+//other placeholders (e.g. {shard}) are ClickHouse macros. Default: /clickhouse/tables/{shard}/{database}/{table}
+//replica_name: replica name (ClickHouse macros can be used). Default: {replica}
+//partition_by, order_by: raw expressions (e.g. toYYYYMMDD(_timestamp), (event_type, _timestamp)). They are alternatives of partition_fields, order_fields
+//ttl: TTL expression (e.g. _timestamp + INTERVAL 90 DAY) of new tables. It is applied to existing tables only explicitly
+//with admin API (ALTER TABLE ... MODIFY TTL deletes expired rows)
 type EngineConfig struct {
-	RawStatement    string        `mapstructure:"raw_statement"`
-	NonNullFields   []string      `mapstructure:"non_null_fields"`
-	PartitionFields []FieldConfig `mapstructure:"partition_fields"`
-	OrderFields     []FieldConfig `mapstructure:"order_fields"`
-	PrimaryKeys     []string      `mapstructure:"primary_keys"`
+	RawStatement    string               `mapstructure:"raw_statement"`
+	NonNullFields   []string             `mapstructure:"non_null_fields"`
+	PartitionFields []FieldConfig        `mapstructure:"partition_fields"`
+	OrderFields     []FieldConfig        `mapstructure:"order_fields"`
+	PartitionBy     string               `mapstructure:"partition_by"`
+	OrderBy         string               `mapstructure:"order_by"`
+	PrimaryKeys     []string             `mapstructure:"primary_keys"`
+	Replicated      *bool                `mapstructure:"replicated"`
+	ZookeeperPath   string               `mapstructure:"zookeeper_path"`
+	ReplicaName     string               `mapstructure:"replica_name"`
+	TTL             string               `mapstructure:"ttl"`
+	Tables          []*TableEngineConfig `mapstructure:"tables"`
+}
+
+//TableEngineConfig dto for deserialized clickhouse engine.tables item: MergeTree family engine of new table
+//table: table name (after table_name_template is applied)
+//engine: replacing (rows with the same sorting key are replaced with the row with max version on merges)
+//or collapsing (rows with the same sorting key and opposite signs are collapsed on merges)
+//version_column: ReplacingMergeTree version column. Default: _version
+//sign_column: CollapsingMergeTree sign column. Default: _sign
+//version and sign columns values are put into objects by schema.Processor (see schema.EngineColumns)
+type TableEngineConfig struct {
+	Table         string `mapstructure:"table"`
+	Engine        string `mapstructure:"engine"`
+	VersionColumn string `mapstructure:"version_column"`
+	SignColumn    string `mapstructure:"sign_column"`
+}
+
+//Validate required fields in TableEngineConfig and set default values
+func (tec *TableEngineConfig) Validate() error {
+	if tec == nil {
+		return errors.New("engine.tables item can't be empty")
+	}
+	if tec.Table == "" {
+		return errors.New("engine.tables table is required parameter")
+	}
+
+	switch tec.Engine {
+	case ReplacingEngine:
+		if tec.SignColumn != "" {
+			return fmt.Errorf("engine.tables sign_column can't be used with %s engine of table [%s]", tec.Engine, tec.Table)
+		}
+		if tec.VersionColumn == "" {
+			tec.VersionColumn = defaultVersionColumn
+		}
+	case CollapsingEngine:
+		if tec.VersionColumn != "" {
+			return fmt.Errorf("engine.tables version_column can't be used with %s engine of table [%s]", tec.Engine, tec.Table)
+		}
+		if tec.SignColumn == "" {
+			tec.SignColumn = defaultSignColumn
+		}
+	default:
+		return fmt.Errorf("Unknown engine of table [%s]: %s. Supported: [%s, %s]", tec.Table, tec.Engine, ReplacingEngine, CollapsingEngine)
+	}
+
+	return nil
+}
+
+//EngineColumns return version or sign column which is maintained by schema.Processor
+func (tec *TableEngineConfig) EngineColumns() *schema.EngineColumns {
+	return &schema.EngineColumns{Version: tec.VersionColumn, Sign: tec.SignColumn}
+}
+
+//return engine statement (with {table} placeholder if replicated) and engine column name with its type
+func (tec *TableEngineConfig) engine(replicated bool, zookeeperPath, replicaName string) (string, string, string) {
+	family, column, columnType := "Replacing", tec.VersionColumn, versionColumnCHType
+	if tec.Engine == CollapsingEngine {
+		family, column, columnType = "Collapsing", tec.SignColumn, signColumnCHType
+	}
+
+	if replicated {
+		return fmt.Sprintf(replicatedTableEngineCHTemplate, family, zookeeperPath, replicaName, column), column, columnType
+	}
+
+	return fmt.Sprintf(tableEngineCHTemplate, family, column), column, columnType
 }
 
 //FieldConfig dto for deserialized clickhouse engine fields
@@ -77,6 +396,30 @@ type FieldConfig struct {
 	Field    string `mapstructure:"field"`
 }
 
+//KeyFields return fields which are used in partition, order and primary key clauses
+//ClickHouse doesn't allow Nullable columns in these clauses so the fields must be created as non-null
+//fields from raw statement aren't parsed (engine.non_null_fields is required in this case)
+func (ec *EngineConfig) KeyFields() []string {
+	if ec == nil || ec.RawStatement != "" {
+		return nil
+	}
+
+	var fields []string
+	for _, fieldConfig := range ec.PartitionFields {
+		fields = append(fields, fieldConfig.Field)
+	}
+	for _, fieldConfig := range ec.OrderFields {
+		fields = append(fields, fieldConfig.Field)
+	}
+	fields = append(fields, expressionFields(ec.PartitionBy)...)
+	fields = append(fields, expressionFields(ec.OrderBy)...)
+	for _, primaryKey := range ec.PrimaryKeys {
+		fields = append(fields, expressionFields(primaryKey)...)
+	}
+
+	return fields
+}
+
 //Validate required fields in ClickHouseConfig
 func (chc *ClickHouseConfig) Validate() error {
 	if chc == nil {
@@ -99,62 +442,198 @@ func (chc *ClickHouseConfig) Validate() error {
 		if chc.Engine.RawStatement != "" && len(chc.Engine.NonNullFields) == 0 {
 			return errors.New("engine.non_null_fields is required parameter if engine.raw_statement is provided")
 		}
+
+		if chc.Engine.Replicated != nil && *chc.Engine.Replicated && chc.Cluster == "" {
+			return errors.New("cluster is required parameter if engine.replicated is true")
+		}
+
+		if chc.Engine.PartitionBy != "" && len(chc.Engine.PartitionFields) > 0 {
+			return errors.New("engine.partition_by and engine.partition_fields can't be used together")
+		}
+
+		if chc.Engine.OrderBy != "" && len(chc.Engine.OrderFields) > 0 {
+			return errors.New("engine.order_by and engine.order_fields can't be used together")
+		}
+
+		if chc.Engine.RawStatement != "" && len(chc.Engine.Tables) > 0 {
+			return errors.New("engine.raw_statement and engine.tables can't be used together")
+		}
+
+		tables := map[string]bool{}
+		for _, tableConfig := range chc.Engine.Tables {
+			if err := tableConfig.Validate(); err != nil {
+				return err
+			}
+			if tables[tableConfig.Table] {
+				return fmt.Errorf("engine.tables table [%s] is configured more than once", tableConfig.Table)
+			}
+			tables[tableConfig.Table] = true
+		}
+	}
+
+	if chc.Buffer != nil {
+		if err := chc.Buffer.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if chc.Native != nil {
+		if err := chc.Native.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if chc.AsyncInsert != nil {
+		if chc.Buffer != nil {
+			return errors.New("buffer and async_insert can't be used together")
+		}
+		if err := chc.AsyncInsert.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if chc.Distributed != nil {
+		if chc.Cluster == "" {
+			return errors.New("cluster is required parameter if distributed is provided")
+		}
+		if err := chc.Distributed.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if chc.Balancer == nil {
+		chc.Balancer = &BalancerConfig{}
+	}
+	if err := chc.Balancer.Validate(); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+//EngineColumns return table name - engine columns map of engine.tables tables. Config must be validated
+func (chc *ClickHouseConfig) EngineColumns() map[string]*schema.EngineColumns {
+	if chc.Engine == nil || len(chc.Engine.Tables) == 0 {
+		return nil
+	}
+
+	engineColumns := map[string]*schema.EngineColumns{}
+	for _, tableConfig := range chc.Engine.Tables {
+		engineColumns[tableConfig.Table] = tableConfig.EngineColumns()
+	}
+
+	return engineColumns
+}
+
+//tableEngine is engine statement of engine.tables table with engine column (version or sign) and its type
+type tableEngine struct {
+	statement  string
+	column     string
+	columnType string
+}
+
 //TableStatementFactory is used for creating CREATE TABLE statements depends on config
 type TableStatementFactory struct {
-	engineStatement string
-	database        string
-	onClusterClause string
+	engineStatement   string
+	database          string
+	cluster           string
+	onClusterClause   string
+	ifNotExistsClause string
+	//Distributed engine sharding key and whether rows are inserted through distributed tables
+	shardingKey string
+	distributed bool
 
 	partitionClause  string
 	orderByClause    string
 	primaryKeyClause string
+	ttlClause        string
 
 	engineStatementFormat bool
+	tableEngines          map[string]*tableEngine
+
+	buffer *BufferConfig
 }
 
 func NewTableStatementFactory(config *ClickHouseConfig) (*TableStatementFactory, error) {
 	if config == nil {
 		return nil, errors.New("Clickhouse config can't be nil")
 	}
-	var onClusterClause string
+	//ON CLUSTER DDL is executed on all nodes so the table might be already created by another node
+	var onClusterClause, ifNotExistsClause string
 	if config.Cluster != "" {
 		onClusterClause = fmt.Sprintf(onClusterCHClauseTemplate, config.Cluster)
+		ifNotExistsClause = ifNotExistsCHClause
 	}
 
+	shardingKey := defaultShardingKey
+	if config.Distributed != nil {
+		shardingKey = config.Distributed.ShardingKey
+	}
+
+	replicated := config.Cluster != ""
+	zookeeperPath := defaultZookeeperPath
+	replicaName := defaultReplicaName
+
 	partitionClause := defaultPartition
 	orderByClause := defaultOrderBy
 	primaryKeyClause := defaultPrimaryKey
+	var ttlClause string
 	if config.Engine != nil {
 		//raw statement overrides all provided config parameters
 		if config.Engine.RawStatement != "" {
 			return &TableStatementFactory{
-				engineStatement: config.Engine.RawStatement,
-				database:        config.Database,
-				onClusterClause: onClusterClause,
+				engineStatement:   config.Engine.RawStatement,
+				database:          config.Database,
+				cluster:           config.Cluster,
+				onClusterClause:   onClusterClause,
+				ifNotExistsClause: ifNotExistsClause,
+				shardingKey:       shardingKey,
+				distributed:       config.Distributed != nil,
+				buffer:            config.Buffer,
 			}, nil
 		}
 
+		if config.Engine.Replicated != nil {
+			replicated = *config.Engine.Replicated
+		}
+		if config.Engine.ZookeeperPath != "" {
+			zookeeperPath = config.Engine.ZookeeperPath
+		}
+		if config.Engine.ReplicaName != "" {
+			replicaName = config.Engine.ReplicaName
+		}
+
 		if len(config.Engine.PartitionFields) > 0 {
 			partitionClause = "PARTITION BY (" + extractStatement(config.Engine.PartitionFields) + ")"
+		} else if config.Engine.PartitionBy != "" {
+			partitionClause = "PARTITION BY (" + config.Engine.PartitionBy + ")"
 		}
 		if len(config.Engine.OrderFields) > 0 {
 			orderByClause = "ORDER BY (" + extractStatement(config.Engine.OrderFields) + ")"
+		} else if config.Engine.OrderBy != "" {
+			orderByClause = "ORDER BY (" + config.Engine.OrderBy + ")"
 		}
 		if len(config.Engine.PrimaryKeys) > 0 {
 			primaryKeyClause = "PRIMARY KEY (" + strings.Join(config.Engine.PrimaryKeys, ", ") + ")"
 		}
+		if config.Engine.TTL != "" {
+			ttlClause = "TTL " + config.Engine.TTL
+		}
+	}
+
+	tableEngines := map[string]*tableEngine{}
+	if config.Engine != nil {
+		for _, tableConfig := range config.Engine.Tables {
+			statement, column, columnType := tableConfig.engine(replicated, strings.ReplaceAll(zookeeperPath, databasePlaceholder, config.Database), replicaName)
+			tableEngines[tableConfig.Table] = &tableEngine{statement: statement, column: column, columnType: columnType}
+		}
 	}
 
 	var engineStatement string
 	var engineStatementFormat bool
-	if config.Cluster != "" {
-		//create engine statement with ReplicatedReplacingMergeTree() engine. We need to replace %s with tableName on creating statement
-		engineStatement = `ENGINE = ReplicatedReplacingMergeTree('/clickhouse/tables/{shard}/` + config.Database + `/%s', '{replica}', _timestamp)`
+	if replicated {
+		//create engine statement with ReplicatedReplacingMergeTree() engine. We need to replace {table} with tableName on creating statement
+		engineStatement = fmt.Sprintf(replicatedEngineCHTemplate, strings.ReplaceAll(zookeeperPath, databasePlaceholder, config.Database), replicaName)
 		engineStatementFormat = true
 	} else {
 		//create table template with ReplacingMergeTree() engine
@@ -164,22 +643,115 @@ func NewTableStatementFactory(config *ClickHouseConfig) (*TableStatementFactory,
 	return &TableStatementFactory{
 		engineStatement:       engineStatement,
 		database:              config.Database,
+		cluster:               config.Cluster,
 		onClusterClause:       onClusterClause,
+		ifNotExistsClause:     ifNotExistsClause,
+		shardingKey:           shardingKey,
+		distributed:           config.Distributed != nil,
 		partitionClause:       partitionClause,
 		orderByClause:         orderByClause,
 		primaryKeyClause:      primaryKeyClause,
+		ttlClause:             ttlClause,
 		engineStatementFormat: engineStatementFormat,
+		tableEngines:          tableEngines,
+		buffer:                config.Buffer,
 	}, nil
 }
 
 //CreateTableStatement return clickhouse DDL for creating table statement
+//engine.tables table is created with its own engine
 func (tsf TableStatementFactory) CreateTableStatement(tableName, columnsClause string) string {
 	engineStatement := tsf.engineStatement
+	if tableEngine, ok := tsf.tableEngines[tableName]; ok {
+		engineStatement = tableEngine.statement
+	}
 	if tsf.engineStatementFormat {
-		engineStatement = fmt.Sprintf(engineStatement, tableName)
+		engineStatement = strings.ReplaceAll(engineStatement, tablePlaceholder, tableName)
+	}
+	return fmt.Sprintf(createTableCHTemplate, tsf.ifNotExistsClause, tsf.database, tableName, tsf.onClusterClause, columnsClause, engineStatement,
+		tsf.partitionClause, tsf.orderByClause, tsf.primaryKeyClause, tsf.ttlClause)
+}
+
+//CreateUpsertTableStatement return clickhouse DDL for creating table statement where rows with the same upsert keys are replaced
+//on merges by ReplacingMergeTree engine: ORDER BY upsert keys without primary key and default partitioning
+//(rows are replaced only within one partition). Raw engine statement is used as is
+func (tsf TableStatementFactory) CreateUpsertTableStatement(tableName, columnsClause string, upsertKeys []string) string {
+	//raw engine statement
+	if tsf.orderByClause == "" {
+		return tsf.CreateTableStatement(tableName, columnsClause)
+	}
+
+	upsertFactory := tsf
+	upsertFactory.orderByClause = "ORDER BY (" + strings.Join(clickhouseReservedWords.quoteAll(upsertKeys, "`"), ", ") + ")"
+	upsertFactory.primaryKeyClause = ""
+	if upsertFactory.partitionClause == defaultPartition {
+		upsertFactory.partitionClause = ""
+	}
+
+	return upsertFactory.CreateTableStatement(tableName, columnsClause)
+}
+
+//EngineColumn return engine column (version or sign) of engine.tables table with its type or empty strings
+func (tsf TableStatementFactory) EngineColumn(tableName string) (string, string) {
+	tableEngine, ok := tsf.tableEngines[tableName]
+	if !ok {
+		return "", ""
+	}
+
+	return tableEngine.column, tableEngine.columnType
+}
+
+//CreateBufferTableStatement return clickhouse DDL for creating buffer table with the same structure as tableName one
+//buffer is flushed into tableName distributed table if distributed is configured
+//return empty string if buffer isn't configured
+func (tsf TableStatementFactory) CreateBufferTableStatement(tableName string) string {
+	if tsf.buffer == nil {
+		return ""
+	}
+
+	return fmt.Sprintf(createBufferTableCHTemplate, tsf.database, BufferTableName(tableName), tsf.onClusterClause, tsf.database, tableName,
+		tsf.database, tsf.InsertTableName(tableName), tsf.buffer.NumLayers, int64(tsf.buffer.MinTime.Seconds()), int64(tsf.buffer.MaxTime.Seconds()),
+		tsf.buffer.MinRows, tsf.buffer.MaxRows, tsf.buffer.MinBytes, tsf.buffer.MaxBytes)
+}
+
+//DropBufferTableStatement return clickhouse DDL for dropping buffer table of tableName (buffered data is flushed on dropping)
+func (tsf TableStatementFactory) DropBufferTableStatement(tableName string) string {
+	return fmt.Sprintf(dropBufferTableCHTemplate, tsf.database, BufferTableName(tableName), tsf.onClusterClause)
+}
+
+//BufferTableName return name of table with Buffer engine which is created in front of tableName
+func BufferTableName(tableName string) string {
+	return bufferTableCHPrefix + tableName
+}
+
+//CreateDistributedTableStatement return clickhouse DDL for creating table with Distributed engine over tableName tables of all cluster shards
+func (tsf TableStatementFactory) CreateDistributedTableStatement(tableName string) string {
+	return fmt.Sprintf(createDistributedTableCHTemplate, tsf.database, DistributedTableName(tableName), tsf.onClusterClause, tsf.database, tableName,
+		tsf.cluster, tsf.database, tableName, tsf.shardingKey)
+}
+
+//DropDistributedTableStatement return clickhouse DDL for dropping distributed table of tableName
+func (tsf TableStatementFactory) DropDistributedTableStatement(tableName string) string {
+	return fmt.Sprintf(dropDistributedTableCHTemplate, tsf.database, DistributedTableName(tableName), tsf.onClusterClause)
+}
+
+//Distributed return true if rows are inserted through distributed tables
+func (tsf TableStatementFactory) Distributed() bool {
+	return tsf.distributed
+}
+
+//InsertTableName return table which rows of tableName are inserted into: distributed one if distributed is configured
+func (tsf TableStatementFactory) InsertTableName(tableName string) string {
+	if tsf.distributed {
+		return DistributedTableName(tableName)
 	}
-	return fmt.Sprintf(createTableCHTemplate, tsf.database, tableName, tsf.onClusterClause, columnsClause, engineStatement,
-		tsf.partitionClause, tsf.orderByClause, tsf.primaryKeyClause)
+
+	return tableName
+}
+
+//DistributedTableName return name of table with Distributed engine which is created over tableName
+func DistributedTableName(tableName string) string {
+	return distributedTableCHPrefix + tableName
 }
 
 //ClickHouse is adapter for creating,patching (schema or table), inserting data to clickhouse
@@ -190,13 +762,26 @@ type ClickHouse struct {
 	dataSource            *sql.DB
 	tableStatementFactory *TableStatementFactory
 	nonNullFields         map[string]bool
+	columns               map[string]ColumnConfig
+	buffered              bool
+	native                *nativeEndpoint
+	blockSize             int
+	//SETTINGS clause of stream inserts (empty if async insert isn't configured)
+	insertSettings string
 }
 
 //NewClickHouse return configured ClickHouse adapter instance
+//columns are optional per field column options
+//if buffered - Insert writes into buffer tables (see BufferConfig) which are created and patched together with main ones
+//if native is provided - InsertBlock is available (see NativeConfig)
+//if asyncInsert is provided - Insert is executed with async_insert settings (see AsyncInsertConfig)
 func NewClickHouse(ctx context.Context, connectionString, database, cluster string, tlsConfig map[string]string,
-	tableStatementFactory *TableStatementFactory, nonNullFields map[string]bool) (*ClickHouse, error) {
+	tableStatementFactory *TableStatementFactory, nonNullFields map[string]bool, columns map[string]ColumnConfig, buffered bool,
+	native *NativeConfig, asyncInsert *AsyncInsertConfig) (*ClickHouse, error) {
 	//configure tls
+	var nativeTLSConfig *tls.Config
 	if strings.Contains(connectionString, "https://") && tlsConfig != nil {
+		nativeCertPool := x509.NewCertPool()
 		for tlsName, crtPath := range tlsConfig {
 			caCert, err := ioutil.ReadFile(crtPath)
 			if err != nil {
@@ -208,8 +793,27 @@ func NewClickHouse(ctx context.Context, connectionString, database, cluster stri
 			if err := clickhouse.RegisterTLSConfig(tlsName, &tls.Config{RootCAs: caCertPool}); err != nil {
 				return nil, err
 			}
+			nativeCertPool.AppendCertsFromPEM(caCert)
 		}
+		nativeTLSConfig = &tls.Config{RootCAs: nativeCertPool}
 	}
+
+	var endpoint *nativeEndpoint
+	var blockSize int
+	if native != nil {
+		var err error
+		endpoint, err = newNativeEndpoint(connectionString, native.Port, nativeTLSConfig)
+		if err != nil {
+			return nil, err
+		}
+		blockSize = native.BlockSize
+	}
+
+	var insertSettings string
+	if asyncInsert != nil {
+		insertSettings = asyncInsert.settingsClause()
+	}
+
 	//connect
 	dataSource, err := sql.Open("clickhouse", connectionString)
 	if err != nil {
@@ -226,6 +830,11 @@ func NewClickHouse(ctx context.Context, connectionString, database, cluster stri
 		dataSource:            dataSource,
 		tableStatementFactory: tableStatementFactory,
 		nonNullFields:         nonNullFields,
+		columns:               columns,
+		buffered:              buffered,
+		native:                endpoint,
+		blockSize:             blockSize,
+		insertSettings:        insertSettings,
 	}, nil
 }
 
@@ -233,6 +842,11 @@ func (ClickHouse) Name() string {
 	return "ClickHouse"
 }
 
+//Location return database where tables are created (ClickHouse database is a schema in terms of SQL tools)
+func (ch *ClickHouse) Location() (string, string) {
+	return "", ch.database
+}
+
 //OpenTx open underline sql transaction and return wrapped instance
 func (ch *ClickHouse) OpenTx() (*Transaction, error) {
 	tx, err := ch.dataSource.BeginTx(ch.ctx, nil)
@@ -266,32 +880,15 @@ func (ch *ClickHouse) CreateDB(dbName string) error {
 }
 
 //CreateTable create database table with name,columns provided in schema.Table representation
-//New tables will have MergeTree() or ReplicatedMergeTree() engine depends on config.cluster empty or not
+//New tables will have ReplacingMergeTree() or ReplicatedReplacingMergeTree() engine depends on config (replicated by default if config.cluster isn't empty)
+//engine.tables tables have their own (Replicated)ReplacingMergeTree(version) or (Replicated)CollapsingMergeTree(sign) engine
 func (ch *ClickHouse) CreateTable(tableSchema *schema.Table) error {
 	wrappedTx, err := ch.OpenTx()
 	if err != nil {
 		return err
 	}
 
-	var columnsDDL []string
-	for columnName, column := range tableSchema.Columns {
-		mappedType, ok := schemaToClickhouse[column.GetType()]
-		if !ok {
-			log.Println("Unknown clickhouse schema type:", column.GetType())
-			mappedType = schemaToClickhouse[typing.STRING]
-		}
-		var addColumnDDL string
-		if _, ok := ch.nonNullFields[columnName]; ok {
-			addColumnDDL = columnName + " " + mappedType
-		} else {
-			addColumnDDL = columnName + fmt.Sprintf(columnCHNullableTemplate, mappedType)
-		}
-		columnsDDL = append(columnsDDL, addColumnDDL)
-	}
-
-	//sorting columns asc
-	sort.Strings(columnsDDL)
-	statementStr := ch.tableStatementFactory.CreateTableStatement(tableSchema.Name, strings.Join(columnsDDL, ","))
+	statementStr := ch.createTableStatement(tableSchema)
 	createStmt, err := wrappedTx.tx.PrepareContext(ch.ctx, statementStr)
 	if err != nil {
 		return fmt.Errorf("Error preparing create table [%s] statement [%s]: %v", tableSchema.Name, statementStr, err)
@@ -303,7 +900,41 @@ func (ch *ClickHouse) CreateTable(tableSchema *schema.Table) error {
 
 	//create distributed table if ReplicatedMergeTree engine
 	if ch.cluster != "" {
-		ch.createDistributedTableInTransaction(wrappedTx, tableSchema.Name)
+		if err := ch.createDistributedTableInTransaction(wrappedTx, tableSchema.Name); err != nil {
+			wrappedTx.Rollback()
+			return err
+		}
+	}
+
+	if ch.buffered {
+		if err := ch.execInTransaction(wrappedTx, ch.tableStatementFactory.CreateBufferTableStatement(tableSchema.Name)); err != nil {
+			wrappedTx.Rollback()
+			return fmt.Errorf("Error creating buffer table for [%s]: %v", tableSchema.Name, err)
+		}
+	}
+
+	return wrappedTx.tx.Commit()
+}
+
+//CreateDistributedTable create distributed table of existing tableName table if it doesn't exist
+func (ch *ClickHouse) CreateDistributedTable(tableName string) error {
+	if err := ch.exec(ch.tableStatementFactory.CreateDistributedTableStatement(tableName)); err != nil {
+		return fmt.Errorf("Error creating distributed table for [%s]: %v", tableName, err)
+	}
+
+	return nil
+}
+
+//CreateBufferTable create buffer table of existing tableName table if it doesn't exist
+func (ch *ClickHouse) CreateBufferTable(tableName string) error {
+	wrappedTx, err := ch.OpenTx()
+	if err != nil {
+		return err
+	}
+
+	if err := ch.execInTransaction(wrappedTx, ch.tableStatementFactory.CreateBufferTableStatement(tableName)); err != nil {
+		wrappedTx.Rollback()
+		return fmt.Errorf("Error creating buffer table for [%s]: %v", tableName, err)
 	}
 
 	return wrappedTx.tx.Commit()
@@ -324,7 +955,11 @@ func (ch *ClickHouse) GetTableSchema(tableName string) (*schema.Table, error) {
 			return nil, fmt.Errorf("Error scanning result: %v", err)
 		}
 
-		mappedType, ok := clickhouseToSchema[columnClickhouseType]
+		if decimalType, ok := schema.ParseDecimalType(baseType(columnClickhouseType)); ok {
+			table.Columns[columnName] = schema.NewDecimalColumn(decimalType, "")
+			continue
+		}
+		mappedType, ok := clickhouseSchemaType(columnClickhouseType)
 		if !ok {
 			log.Println("Unknown clickhouse column type:", columnClickhouseType)
 			mappedType = typing.STRING
@@ -339,20 +974,25 @@ func (ch *ClickHouse) GetTableSchema(tableName string) (*schema.Table, error) {
 }
 
 //PatchTableSchema add new columns(from provided schema.Table) to existing table
-//drop and create distributed table
+//drop and create distributed table or add the columns to it if rows are inserted through it (distributed is configured)
+//buffer table is dropped (buffered data is flushed) before altering and is recreated with the new structure after it
 func (ch *ClickHouse) PatchTableSchema(patchSchema *schema.Table) error {
 	wrappedTx, err := ch.OpenTx()
 	if err != nil {
 		return err
 	}
 
-	for columnName, column := range patchSchema.Columns {
-		mappedColumnType, ok := schemaToClickhouse[column.GetType()]
-		if !ok {
-			log.Println("Unknown clickhouse schema type:", column.GetType().String())
-			mappedColumnType = schemaToClickhouse[typing.STRING]
+	if ch.buffered {
+		if err := ch.execInTransaction(wrappedTx, ch.tableStatementFactory.DropBufferTableStatement(patchSchema.Name)); err != nil {
+			wrappedTx.Rollback()
+			return fmt.Errorf("Error dropping buffer table for [%s]: %v", patchSchema.Name, err)
 		}
-		alterStmt, err := wrappedTx.tx.PrepareContext(ch.ctx, fmt.Sprintf(addColumnCHTemplate, ch.database, patchSchema.Name, ch.getOnClusterClause(), columnName, mappedColumnType))
+	}
+
+	for columnName, column := range patchSchema.Columns {
+		//new columns are always nullable because existing rows don't have values
+		columnDDL := ch.columnDDL(columnName, column, true)
+		alterStmt, err := wrappedTx.tx.PrepareContext(ch.ctx, ch.addColumnStatement(patchSchema.Name, columnDDL))
 		if err != nil {
 			wrappedTx.Rollback()
 			return fmt.Errorf("Error preparing patching table %s schema statement: %v", patchSchema.Name, err)
@@ -361,27 +1001,207 @@ func (ch *ClickHouse) PatchTableSchema(patchSchema *schema.Table) error {
 		_, err = alterStmt.ExecContext(ch.ctx)
 		if err != nil {
 			wrappedTx.Rollback()
-			return fmt.Errorf("Error patching %s table with '%s' - %s column schema: %v", patchSchema.Name, columnName, mappedColumnType, err)
+			return fmt.Errorf("Error patching %s table with '%s' column: %v", patchSchema.Name, columnDDL, err)
+		}
+
+		//distributed table isn't recreated because concurrent inserts would fail without it
+		if ch.tableStatementFactory.Distributed() {
+			distributedTableName := DistributedTableName(patchSchema.Name)
+			if err := ch.execInTransaction(wrappedTx, ch.addColumnStatement(distributedTableName, columnDDL)); err != nil {
+				wrappedTx.Rollback()
+				return fmt.Errorf("Error patching %s table with '%s' column: %v", distributedTableName, columnDDL, err)
+			}
 		}
 	}
 
 	//drop and create distributed table if ReplicatedMergeTree engine
-	if ch.cluster != "" {
+	if ch.cluster != "" && !ch.tableStatementFactory.Distributed() {
 		ch.dropDistributedTableInTransaction(wrappedTx, patchSchema.Name)
 		ch.createDistributedTableInTransaction(wrappedTx, patchSchema.Name)
 	}
 
+	if ch.buffered {
+		if err := ch.execInTransaction(wrappedTx, ch.tableStatementFactory.CreateBufferTableStatement(patchSchema.Name)); err != nil {
+			wrappedTx.Rollback()
+			return fmt.Errorf("Error creating buffer table for [%s]: %v", patchSchema.Name, err)
+		}
+	}
+
 	return wrappedTx.tx.Commit()
 }
 
-//Insert provided object in ClickHouse in stream mode
+//DestructiveChanges return statements of creating (patch is false) or patching the table which can lose data or narrow
+//existing columns (see IsDestructiveDDL). Statements of buffer and distributed tables aren't classified: they don't keep data
+//(buffer tables are flushed on dropping) so PatchTableSchema drops and recreates them
+func (ch *ClickHouse) DestructiveChanges(tableSchema *schema.Table, patch bool) []string {
+	if !patch {
+		return DestructiveStatements([]string{ch.createTableStatement(tableSchema)})
+	}
+
+	var statements []string
+	for columnName, column := range tableSchema.Columns {
+		statements = append(statements, ch.addColumnStatement(tableSchema.Name, ch.columnDDL(columnName, column, true)))
+	}
+
+	return DestructiveStatements(statements)
+}
+
+//CreateStagingTable create table with tableName structure for two-phase loading and return its name
+//Staging table is created without ON CLUSTER clause on the dsn node and has plain MergeTree engine without partitioning,
+//so readers of tableName don't see staging data until MoveStagingTable
+func (ch *ClickHouse) CreateStagingTable(tableName string) (string, error) {
+	stagingTableName := tableName + stagingTableCHInfix + strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := ch.exec(fmt.Sprintf(createStagingTableCHTemplate, ch.database, stagingTableName, ch.database, tableName)); err != nil {
+		return "", fmt.Errorf("Error creating staging table for [%s]: %v", tableName, err)
+	}
+
+	return stagingTableName, nil
+}
+
+//MoveStagingTable insert all staging table rows into tableName (or its distributed table) with one INSERT SELECT query
+//ClickHouse inserts them atomically if they fit into one block (min_insert_block_size_rows, min_insert_block_size_bytes settings)
+func (ch *ClickHouse) MoveStagingTable(stagingTableName, tableName string) error {
+	if err := ch.exec(fmt.Sprintf(moveStagingTableCHTemplate, ch.database, ch.tableStatementFactory.InsertTableName(tableName), ch.database, stagingTableName)); err != nil {
+		return fmt.Errorf("Error moving staging table [%s] data into [%s]: %v", stagingTableName, tableName, err)
+	}
+
+	return nil
+}
+
+//DropStagingTable drop staging table if it exists
+func (ch *ClickHouse) DropStagingTable(stagingTableName string) error {
+	if err := ch.exec(fmt.Sprintf(dropStagingTableCHTemplate, ch.database, stagingTableName)); err != nil {
+		return fmt.Errorf("Error dropping staging table [%s]: %v", stagingTableName, err)
+	}
+
+	return nil
+}
+
+//ModifyTTLStatement return statement which ModifyTTL executes
+func (ch *ClickHouse) ModifyTTLStatement(tableName, ttl string) string {
+	return fmt.Sprintf(modifyTTLCHTemplate, ch.database, tableName, ch.getOnClusterClause(), ttl)
+}
+
+//ModifyTTL set TTL expression to existing table. Expired rows are deleted so it is executed only explicitly (admin API)
+func (ch *ClickHouse) ModifyTTL(tableName, ttl string) error {
+	wrappedTx, err := ch.OpenTx()
+	if err != nil {
+		return err
+	}
+
+	statementStr := ch.ModifyTTLStatement(tableName, ttl)
+	alterStmt, err := wrappedTx.tx.PrepareContext(ch.ctx, statementStr)
+	if err != nil {
+		wrappedTx.Rollback()
+		return fmt.Errorf("Error preparing modify table [%s] TTL statement [%s]: %v", tableName, statementStr, err)
+	}
+
+	if _, err = alterStmt.ExecContext(ch.ctx); err != nil {
+		wrappedTx.Rollback()
+		return fmt.Errorf("Error modifying [%s] table TTL with statement [%s]: %v", tableName, statementStr, err)
+	}
+
+	return wrappedTx.tx.Commit()
+}
+
+//Delete rows with the same keys values as objects have from table with schema.DeletionKeys in one mutation
+//ClickHouse applies mutations asynchronously: rows disappear after the mutation is finished
+func (ch *ClickHouse) Delete(schema *schema.Table, objects []map[string]interface{}) error {
+	if len(objects) == 0 {
+		return nil
+	}
+
+	var values []interface{}
+	for _, object := range objects {
+		for _, key := range schema.DeletionKeys {
+			values = append(values, object[key])
+		}
+	}
+
+	statement := fmt.Sprintf(deleteCHTemplate, ch.database, schema.Name, ch.getOnClusterClause(), deleteCHCondition(clickhouseReservedWords.quoteAll(schema.DeletionKeys, "`"), len(objects)))
+	if err := ch.exec(statement, values...); err != nil {
+		return fmt.Errorf("Error deleting from [%s] table with keys %v: %v", schema.Name, schema.DeletionKeys, err)
+	}
+
+	return nil
+}
+
+//return CREATE TABLE statement of the table (upsert one if the table has upsert keys) with sorted columns definitions
+func (ch *ClickHouse) createTableStatement(tableSchema *schema.Table) string {
+	//upsert keys are sorting key columns so they can't be nullable
+	upsertKeys := map[string]bool{}
+	for _, key := range tableSchema.UpsertKeys {
+		upsertKeys[key] = true
+	}
+
+	engineColumn, engineColumnType := ch.tableStatementFactory.EngineColumn(tableSchema.Name)
+	var columnsDDL []string
+	for columnName, column := range tableSchema.Columns {
+		//engine columns have the engine required type and can't be nullable
+		if columnName == engineColumn {
+			columnsDDL = append(columnsDDL, clickhouseQuote(columnName)+" "+engineColumnType)
+			continue
+		}
+		_, nonNull := ch.nonNullFields[columnName]
+		columnsDDL = append(columnsDDL, ch.columnDDL(columnName, column, !nonNull && !upsertKeys[columnName]))
+	}
+
+	//sorting columns asc
+	sort.Strings(columnsDDL)
+	if len(tableSchema.UpsertKeys) > 0 {
+		return ch.tableStatementFactory.CreateUpsertTableStatement(tableSchema.Name, strings.Join(columnsDDL, ","), tableSchema.UpsertKeys)
+	}
+
+	return ch.tableStatementFactory.CreateTableStatement(tableSchema.Name, strings.Join(columnsDDL, ","))
+}
+
+//return ALTER TABLE ADD COLUMN statement of the table with column definition (see columnDDL)
+func (ch *ClickHouse) addColumnStatement(tableName, columnDDL string) string {
+	return fmt.Sprintf(addColumnCHTemplate, ch.database, tableName, ch.getOnClusterClause(), columnDDL)
+}
+
+//columnDDL return column definition: name, type (from column config or mapped from schema type), comment (column description) and codec
+func (ch *ClickHouse) columnDDL(name string, column schema.Column, nullable bool) string {
+	columnType, ok := schemaToClickhouse[column.GetType()]
+	if decimal := column.Decimal(); decimal != nil {
+		columnType = fmt.Sprintf("Decimal(%d,%d)", decimal.Precision, decimal.Scale)
+	} else if !ok {
+		log.Println("Unknown clickhouse schema type:", column.GetType().String())
+		columnType = schemaToClickhouse[typing.STRING]
+	}
+
+	columnConfig := ch.columns[name]
+	if columnConfig.Type != "" {
+		columnType = columnConfig.Type
+	}
+	if nullable {
+		columnType = nullableType(columnType)
+	}
+
+	columnDDL := clickhouseQuote(name) + " " + columnType
+	if column.Description() != "" {
+		columnDDL += fmt.Sprintf(commentCHClauseTemplate, escapedLiteral(column.Description()))
+	}
+	if columnConfig.Codec != "" {
+		columnDDL += fmt.Sprintf(codecCHClauseTemplate, columnConfig.Codec)
+	}
+
+	return columnDDL
+}
+
+//Insert provided object in ClickHouse in stream mode (into buffer table if adapter is buffered or into distributed table if it is configured)
 func (ch *ClickHouse) Insert(schema *schema.Table, valuesMap map[string]interface{}) error {
 	wrappedTx, err := ch.OpenTx()
 	if err != nil {
 		return err
 	}
 
-	if err := ch.InsertInTransaction(wrappedTx, schema, valuesMap); err != nil {
+	tableName := ch.tableStatementFactory.InsertTableName(schema.Name)
+	if ch.buffered {
+		tableName = BufferTableName(tableName)
+	}
+
+	if err := ch.insertInTransaction(wrappedTx, tableName, ch.insertSettings, valuesMap); err != nil {
 		wrappedTx.Rollback()
 		return err
 	}
@@ -391,29 +1211,161 @@ func (ch *ClickHouse) Insert(schema *schema.Table, valuesMap map[string]interfac
 
 //Insert provided object in ClickHouse in transaction
 func (ch *ClickHouse) InsertInTransaction(wrappedTx *Transaction, schema *schema.Table, valuesMap map[string]interface{}) error {
+	return ch.insertInTransaction(wrappedTx, schema.Name, "", valuesMap)
+}
+
+func (ch *ClickHouse) insertInTransaction(wrappedTx *Transaction, tableName, settings string, valuesMap map[string]interface{}) error {
+	statement, header, values := ch.insertStatement(tableName, settings, valuesMap)
+
+	insertStmt, err := wrappedTx.tx.PrepareContext(ch.ctx, statement)
+	if err != nil {
+		return fmt.Errorf("Error preparing insert table %s statement: %v", tableName, err)
+	}
+
+	_, err = insertStmt.ExecContext(ch.ctx, values...)
+	if err != nil {
+		return fmt.Errorf("Error inserting in %s table with statement: %s values: %v: %v", tableName, header, values, err)
+	}
+
+	return nil
+}
+
+//Native return true if InsertBlock is available
+func (ch *ClickHouse) Native() bool {
+	return ch.native != nil
+}
+
+//InsertBlock insert objects into table with one INSERT query over native protocol: objects are sent as columnar data blocks
+//of block_size rows. Objects which can't be converted to table columns types are skipped with warning (and counted in report)
+//if skipMalformed, otherwise the whole insert is failed. Note: blocks which have been sent before an error might be already written
+func (ch *ClickHouse) InsertBlock(tableName string, objects []map[string]interface{}, skipMalformed bool, report *reports.LoadReport) error {
+	if ch.native == nil {
+		return errors.New("ClickHouse native protocol isn't configured")
+	}
+	if len(objects) == 0 {
+		return nil
+	}
+
+	header := map[string]bool{}
+	for _, object := range objects {
+		for name := range object {
+			header[name] = true
+		}
+	}
+	var columnNames []string
+	for name := range header {
+		columnNames = append(columnNames, name)
+	}
+	sort.Strings(columnNames)
+
+	conn, err := dialNative(ch.ctx, ch.native, ch.database)
+	if err != nil {
+		return fmt.Errorf("Error connecting to ClickHouse native protocol %s: %v", ch.native.address, err)
+	}
+	defer conn.Close()
+
+	if err := conn.sendQuery(fmt.Sprintf(nativeInsertCHTemplate, ch.database, tableName, strings.Join(clickhouseReservedWords.quoteAll(columnNames, "`"), ","))); err != nil {
+		return fmt.Errorf("Error sending insert into %s table query: %v", tableName, err)
+	}
+	block, err := conn.readStructure()
+	if err != nil {
+		return fmt.Errorf("Error inserting into %s table: %v", tableName, err)
+	}
+
+	row := make([]driver.Value, len(block.Columns))
+	for _, object := range objects {
+		if err := nativeRow(block.Columns, object, row); err != nil {
+			if !skipMalformed {
+				return err
+			}
+			log.Printf("Warn: unable to insert object %v reason: %v. This line will be skipped", object, err)
+			report.Skip(reports.InsertReason, err)
+			continue
+		}
+		if err := block.AppendRow(row); err != nil {
+			return fmt.Errorf("Error appending object %v to %s table block: %v", object, tableName, err)
+		}
+		if block.NumRows >= uint64(ch.blockSize) {
+			if err := conn.writeBlock(block); err != nil {
+				return fmt.Errorf("Error writing block into %s table: %v", tableName, err)
+			}
+		}
+	}
+	if block.NumRows > 0 {
+		if err := conn.writeBlock(block); err != nil {
+			return fmt.Errorf("Error writing block into %s table: %v", tableName, err)
+		}
+	}
+
+	//empty block is a marker of the end of data
+	if err := conn.writeBlock(&data.Block{}); err != nil {
+		return fmt.Errorf("Error writing block into %s table: %v", tableName, err)
+	}
+	if err := conn.encoder.Flush(); err != nil {
+		return fmt.Errorf("Error writing block into %s table: %v", tableName, err)
+	}
+	if err := conn.waitEndOfStream(); err != nil {
+		return fmt.Errorf("Error inserting into %s table: %v", tableName, err)
+	}
+
+	return nil
+}
+
+//return INSERT statement with placeholders and optional SETTINGS clause, columns header and values in the same order
+func (ch *ClickHouse) insertStatement(tableName, settings string, valuesMap map[string]interface{}) (string, string, []interface{}) {
 	var header, placeholders string
 	var values []interface{}
 	for name, value := range valuesMap {
-		header += name + ","
+		header += clickhouseQuote(name) + ","
 		//?, ?, ?, etc
 		placeholders += "?,"
+		//arrays are passed through database/sql with driver wrapper
+		if array, ok := value.([]interface{}); ok {
+			value = clickhouse.Array(array)
+		}
 		values = append(values, value)
 	}
 
 	header = removeLastComma(header)
 	placeholders = removeLastComma(placeholders)
 
-	insertStmt, err := wrappedTx.tx.PrepareContext(ch.ctx, fmt.Sprintf(insertCHTemplate, ch.database, schema.Name, header, placeholders))
+	return fmt.Sprintf(insertCHTemplate, ch.database, tableName, header, settings, placeholders), header, values
+}
+
+//Ping check connection to ClickHouse node
+func (ch *ClickHouse) Ping() error {
+	return ch.dataSource.PingContext(ch.ctx)
+}
+
+//SelectLast return last limit rows of the table where keyColumn equals value ordered by orderColumn desc
+//distributed table is queried if it is configured so rows of all shards are returned
+func (ch *ClickHouse) SelectLast(tableName, keyColumn, orderColumn string, value interface{}, limit int) ([]map[string]interface{}, error) {
+	tableName = ch.tableStatementFactory.InsertTableName(tableName)
+	query := fmt.Sprintf(selectLastCHTemplate, ch.database, tableName, clickhouseQuote(keyColumn), clickhouseQuote(orderColumn))
+	rows, err := ch.dataSource.QueryContext(ch.ctx, query, value, limit)
 	if err != nil {
-		return fmt.Errorf("Error preparing insert table %s statement: %v", schema.Name, err)
+		return nil, fmt.Errorf("Error querying table [%s]: %v", tableName, err)
 	}
 
-	_, err = insertStmt.ExecContext(ch.ctx, values...)
+	return scanRows(rows)
+}
+
+//SelectMetric return rows of predefined aggregate query (distributed table is queried if it is configured)
+func (ch *ClickHouse) SelectMetric(query *MetricQuery) ([]map[string]interface{}, error) {
+	template, ok := clickhouseMetricsTemplates[query.Metric]
+	if !ok {
+		return nil, fmt.Errorf("Unknown metric: %s", query.Metric)
+	}
+
+	tableName := ch.tableStatementFactory.InsertTableName(query.Table)
+	timeColumn := clickhouseQuote(query.TimeColumn)
+	statement := fmt.Sprintf(template, timeColumn, clickhouseQuote(query.column()), ch.database, tableName, timeColumn)
+	rows, err := ch.dataSource.QueryContext(ch.ctx, statement, query.Since)
 	if err != nil {
-		return fmt.Errorf("Error inserting in %s table with statement: %s values: %v: %v", schema.Name, header, values, err)
+		return nil, fmt.Errorf("Error querying %s of table [%s]: %v", query.Metric, tableName, err)
 	}
 
-	return nil
+	return scanRows(rows)
 }
 
 //Close underlying sql.DB
@@ -434,24 +1386,51 @@ func (ch *ClickHouse) getOnClusterClause() string {
 	return fmt.Sprintf(onClusterCHClauseTemplate, ch.cluster)
 }
 
-//create distributed table, ignore errors
-func (ch *ClickHouse) createDistributedTableInTransaction(wrappedTx *Transaction, originTableName string) {
-	createStmt, err := wrappedTx.tx.PrepareContext(ch.ctx, fmt.Sprintf(createDistributedTableCHTemplate,
-		ch.database, originTableName, ch.getOnClusterClause(), ch.database, originTableName, ch.cluster, ch.database, originTableName))
+//prepare and execute statement with args in a new transaction
+func (ch *ClickHouse) exec(statement string, args ...interface{}) error {
+	wrappedTx, err := ch.OpenTx()
 	if err != nil {
-		log.Printf("Error preparing create distributed table statement for [%s] : %v", originTableName, err)
-		return
+		return err
 	}
 
-	if _, err = createStmt.ExecContext(ch.ctx); err != nil {
+	if err := ch.execInTransaction(wrappedTx, statement, args...); err != nil {
+		wrappedTx.Rollback()
+		return err
+	}
+
+	return wrappedTx.DirectCommit()
+}
+
+//prepare and execute statement with args in transaction
+func (ch *ClickHouse) execInTransaction(wrappedTx *Transaction, statement string, args ...interface{}) error {
+	stmt, err := wrappedTx.tx.PrepareContext(ch.ctx, statement)
+	if err != nil {
+		return fmt.Errorf("Error preparing statement [%s]: %v", statement, err)
+	}
+
+	if _, err := stmt.ExecContext(ch.ctx, args...); err != nil {
+		return fmt.Errorf("Error executing statement [%s]: %v", statement, err)
+	}
+
+	return nil
+}
+
+//create distributed table. Errors are returned only if rows are inserted through it (distributed is configured)
+//otherwise they are logged
+func (ch *ClickHouse) createDistributedTableInTransaction(wrappedTx *Transaction, originTableName string) error {
+	if err := ch.execInTransaction(wrappedTx, ch.tableStatementFactory.CreateDistributedTableStatement(originTableName)); err != nil {
+		if ch.tableStatementFactory.Distributed() {
+			return fmt.Errorf("Error creating distributed table for [%s]: %v", originTableName, err)
+		}
 		log.Printf("Error creating distributed table for [%s] : %v", originTableName, err)
 	}
+
+	return nil
 }
 
 //drop distributed table, ignore errors
 func (ch *ClickHouse) dropDistributedTableInTransaction(wrappedTx *Transaction, originTableName string) {
-	createStmt, err := wrappedTx.tx.PrepareContext(ch.ctx, fmt.Sprintf(dropDistributedTableCHTemplate,
-		ch.database, originTableName, ch.getOnClusterClause()))
+	createStmt, err := wrappedTx.tx.PrepareContext(ch.ctx, ch.tableStatementFactory.DropDistributedTableStatement(originTableName))
 	if err != nil {
 		log.Printf("Error preparing drop distributed table statement for [%s] : %v", originTableName, err)
 		return
@@ -473,3 +1452,68 @@ func extractStatement(fieldConfigs []FieldConfig) string {
 	}
 	return strings.Join(parameters, ",")
 }
+
+//expressionFields return column names from ClickHouse expression: identifiers which aren't function names
+//e.g. (event_type, toDate(_timestamp)) -> [event_type, _timestamp]
+func expressionFields(expression string) []string {
+	var fields []string
+	for _, match := range expressionIdentifier.FindAllStringSubmatch(stringLiteral.ReplaceAllString(expression, ""), -1) {
+		//function call
+		if match[2] != "" {
+			continue
+		}
+		fields = append(fields, match[1])
+	}
+
+	return fields
+}
+
+//return column name in backticks if it is a reserved word
+func clickhouseQuote(name string) string {
+	return clickhouseReservedWords.quote(name, "`")
+}
+
+//nullableType wrap type into Nullable. LowCardinality can't be inside Nullable so it is wrapped into LowCardinality(Nullable(...))
+func nullableType(columnType string) string {
+	//arrays can't be Nullable: missing values are written as empty arrays
+	if strings.HasPrefix(columnType, nullableCHTypePrefix) || strings.HasPrefix(columnType, arrayCHTypePrefix) {
+		return columnType
+	}
+	if strings.HasPrefix(columnType, lowCardinalityCHPrefix) && strings.HasSuffix(columnType, ")") {
+		return lowCardinalityCHPrefix + nullableType(columnType[len(lowCardinalityCHPrefix):len(columnType)-1]) + ")"
+	}
+
+	return nullableCHTypePrefix + columnType + ")"
+}
+
+//baseType return type without Nullable and LowCardinality wrappers e.g. LowCardinality(Nullable(String)) -> String
+func baseType(columnType string) string {
+	for _, prefix := range []string{lowCardinalityCHPrefix, nullableCHTypePrefix} {
+		if strings.HasPrefix(columnType, prefix) && strings.HasSuffix(columnType, ")") {
+			columnType = columnType[len(prefix) : len(columnType)-1]
+		}
+	}
+
+	return columnType
+}
+
+//clickhouseSchemaType return schema type of ClickHouse column type. Array(T) columns are arrays of T schema type
+//(arrays of strings if T isn't a known scalar type). Return false if the type is unknown
+func clickhouseSchemaType(columnType string) (typing.DataType, bool) {
+	base := baseType(columnType)
+	if strings.HasPrefix(base, arrayCHTypePrefix) && strings.HasSuffix(base, ")") {
+		elementType, _ := clickhouseSchemaType(base[len(arrayCHTypePrefix) : len(base)-1])
+		return typing.ArrayOf(elementType), true
+	}
+
+	schemaType, ok := clickhouseToSchema[base]
+	return schemaType, ok
+}
+
+//deleteCHCondition return WHERE condition with placeholders of rows keys values: (key1,key2) IN ((?,?),(?,?))
+func deleteCHCondition(keys []string, rows int) string {
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?,", len(keys)), ",") + ")"
+	tuples := strings.TrimSuffix(strings.Repeat(placeholders+",", rows), ",")
+
+	return "(" + strings.Join(keys, ",") + ") IN (" + tuples + ")"
+}
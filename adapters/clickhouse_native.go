@@ -0,0 +1,499 @@
+package adapters
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/ClickHouse/clickhouse-go/lib/binary"
+	"github.com/ClickHouse/clickhouse-go/lib/column"
+	"github.com/ClickHouse/clickhouse-go/lib/data"
+	"github.com/ClickHouse/clickhouse-go/lib/protocol"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	nativeInsertCHTemplate = `INSERT INTO "%s"."%s" (%s) VALUES`
+
+	defaultNativePort        = 9000
+	defaultNativeBlockSize   = 100000
+	defaultNativeDialTimeout = 10 * time.Second
+	defaultNativeUser        = "default"
+)
+
+var hostname, _ = os.Hostname()
+
+//NativeConfig dto for deserialized clickhouse native protocol config
+//if provided - batch Store() sends data as columnar blocks over native TCP protocol instead of per-row SQL INSERT over HTTP.
+//host, user and password are taken from dsns
+//port: native protocol port (tcp_port or tcp_port_secure if dsn is https). Default: 9000
+//block_size: max rows in one data block. Default: 100000
+type NativeConfig struct {
+	Port      int `mapstructure:"port"`
+	BlockSize int `mapstructure:"block_size"`
+}
+
+//Validate NativeConfig values and set default ones
+func (nc *NativeConfig) Validate() error {
+	if nc.Port < 0 || nc.BlockSize < 0 {
+		return errors.New("native port and block_size can't be negative")
+	}
+	if nc.Port == 0 {
+		nc.Port = defaultNativePort
+	}
+	if nc.BlockSize == 0 {
+		nc.BlockSize = defaultNativeBlockSize
+	}
+
+	return nil
+}
+
+//nativeEndpoint is a ClickHouse node native protocol connection parameters
+type nativeEndpoint struct {
+	address   string
+	username  string
+	password  string
+	tlsConfig *tls.Config
+}
+
+//newNativeEndpoint return nativeEndpoint with host and credentials from HTTP(S) dsn and native port
+//tlsConfig is used only if dsn is https
+func newNativeEndpoint(dsn string, port int, tlsConfig *tls.Config) (*nativeEndpoint, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing dsn: %v", err)
+	}
+	if u.Hostname() == "" {
+		return nil, errors.New("dsn host is required for native protocol")
+	}
+
+	endpoint := &nativeEndpoint{address: net.JoinHostPort(u.Hostname(), strconv.Itoa(port)), username: defaultNativeUser}
+	if u.User != nil {
+		endpoint.username = u.User.Username()
+		endpoint.password, _ = u.User.Password()
+	}
+	if u.Scheme == "https" {
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		endpoint.tlsConfig = tlsConfig.Clone()
+		endpoint.tlsConfig.ServerName = u.Hostname()
+	}
+
+	return endpoint, nil
+}
+
+//nativeConnection is a ClickHouse native TCP protocol connection which supports only INSERT queries
+//with columnar data blocks. Packets format is the same as in github.com/ClickHouse/clickhouse-go driver
+//(the driver itself isn't used because it registers 'clickhouse' sql driver name as github.com/mailru/go-clickhouse does)
+type nativeConnection struct {
+	conn       net.Conn
+	writer     *bufio.Writer
+	encoder    *binary.Encoder
+	decoder    *binary.Decoder
+	serverInfo data.ServerInfo
+}
+
+//dialNative open connection to endpoint and make handshake
+func dialNative(ctx context.Context, endpoint *nativeEndpoint, database string) (*nativeConnection, error) {
+	dialer := &net.Dialer{Timeout: defaultNativeDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", endpoint.address)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.tlsConfig != nil {
+		tlsConn := tls.Client(conn, endpoint.tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	writer := bufio.NewWriter(conn)
+	nc := &nativeConnection{
+		conn:    conn,
+		writer:  writer,
+		encoder: binary.NewEncoder(writer),
+		decoder: binary.NewDecoder(bufio.NewReader(conn)),
+	}
+	if err := nc.hello(database, endpoint.username, endpoint.password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return nc, nil
+}
+
+func (nc *nativeConnection) hello(database, username, password string) error {
+	nc.encoder.Uvarint(protocol.ClientHello)
+	data.ClientInfo{}.Write(nc.encoder)
+	nc.encoder.String(database)
+	nc.encoder.String(username)
+	nc.encoder.String(password)
+	if err := nc.encoder.Flush(); err != nil {
+		return err
+	}
+
+	packet, err := nc.decoder.Uvarint()
+	if err != nil {
+		return err
+	}
+	switch packet {
+	case protocol.ServerHello:
+		return nc.serverInfo.Read(nc.decoder)
+	case protocol.ServerException:
+		return nc.exception()
+	default:
+		return fmt.Errorf("Unexpected packet [%d] from server on hello", packet)
+	}
+}
+
+//sendQuery send query packet with client info and without settings
+func (nc *nativeConnection) sendQuery(query string) error {
+	nc.encoder.Uvarint(protocol.ClientQuery)
+	//query id
+	nc.encoder.String("")
+	//client info: initial query, initial user, initial query id, initial address, TCP interface, OS user, client hostname
+	nc.encoder.Uvarint(1)
+	nc.encoder.String("")
+	nc.encoder.String("")
+	nc.encoder.String("[::ffff:127.0.0.1]:0")
+	nc.encoder.Uvarint(1)
+	nc.encoder.String(hostname)
+	nc.encoder.String(hostname)
+	data.ClientInfo{}.Write(nc.encoder)
+	if nc.serverInfo.Revision >= protocol.DBMS_MIN_REVISION_WITH_QUOTA_KEY_IN_CLIENT_INFO {
+		nc.encoder.String("")
+	}
+	//empty settings
+	nc.encoder.String("")
+	nc.encoder.Uvarint(protocol.StateComplete)
+	nc.encoder.Uvarint(protocol.CompressDisable)
+	nc.encoder.String(query)
+	if err := nc.writeBlock(&data.Block{}); err != nil {
+		return err
+	}
+
+	return nc.encoder.Flush()
+}
+
+func (nc *nativeConnection) writeBlock(block *data.Block) error {
+	nc.encoder.Uvarint(protocol.ClientData)
+	//temporary table name
+	nc.encoder.String("")
+
+	return block.Write(&nc.serverInfo, nc.encoder)
+}
+
+//readStructure return empty block with table columns which server sends in response to INSERT query
+func (nc *nativeConnection) readStructure() (*data.Block, error) {
+	for {
+		packet, err := nc.decoder.Uvarint()
+		if err != nil {
+			return nil, err
+		}
+		switch packet {
+		case protocol.ServerData:
+			return nc.readBlock()
+		case protocol.ServerException:
+			return nil, nc.exception()
+		case protocol.ServerProgress:
+			if err := nc.skipProgress(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("Unexpected packet [%d] from server on reading table structure", packet)
+		}
+	}
+}
+
+//waitEndOfStream read server packets until the end of stream or exception
+func (nc *nativeConnection) waitEndOfStream() error {
+	for {
+		packet, err := nc.decoder.Uvarint()
+		if err != nil {
+			return err
+		}
+		switch packet {
+		case protocol.ServerEndOfStream:
+			return nil
+		case protocol.ServerException:
+			return nc.exception()
+		case protocol.ServerProgress:
+			if err := nc.skipProgress(); err != nil {
+				return err
+			}
+		case protocol.ServerProfileInfo:
+			if err := nc.skipProfileInfo(); err != nil {
+				return err
+			}
+		case protocol.ServerData:
+			if _, err := nc.readBlock(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("Unexpected packet [%d] from server on waiting end of stream", packet)
+		}
+	}
+}
+
+func (nc *nativeConnection) readBlock() (*data.Block, error) {
+	//temporary table name
+	if _, err := nc.decoder.String(); err != nil {
+		return nil, err
+	}
+
+	block := &data.Block{}
+	if err := block.Read(&nc.serverInfo, nc.decoder); err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
+
+//skipProgress read rows, bytes and total rows
+func (nc *nativeConnection) skipProgress() error {
+	for i := 0; i < 3; i++ {
+		if _, err := nc.decoder.Uvarint(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//skipProfileInfo read rows, blocks, bytes, applied limit, rows before limit, calculated rows before limit
+func (nc *nativeConnection) skipProfileInfo() error {
+	for i := 0; i < 3; i++ {
+		if _, err := nc.decoder.Uvarint(); err != nil {
+			return err
+		}
+	}
+	if _, err := nc.decoder.Bool(); err != nil {
+		return err
+	}
+	if _, err := nc.decoder.Uvarint(); err != nil {
+		return err
+	}
+	_, err := nc.decoder.Bool()
+	return err
+}
+
+//exception read server exception and return it as error (nested exceptions are skipped)
+func (nc *nativeConnection) exception() error {
+	code, err := nc.decoder.Int32()
+	if err != nil {
+		return err
+	}
+	name, err := nc.decoder.String()
+	if err != nil {
+		return err
+	}
+	message, err := nc.decoder.String()
+	if err != nil {
+		return err
+	}
+	//stack trace
+	if _, err := nc.decoder.String(); err != nil {
+		return err
+	}
+	hasNested, err := nc.decoder.Bool()
+	if err != nil {
+		return err
+	}
+	if hasNested {
+		if err := nc.exception(); err != nil {
+			if _, ok := err.(*nativeException); !ok {
+				return err
+			}
+		}
+	}
+
+	return &nativeException{code: code, message: strings.TrimSpace(strings.TrimPrefix(message, name+":"))}
+}
+
+func (nc *nativeConnection) Close() error {
+	return nc.conn.Close()
+}
+
+//nativeException is ClickHouse server exception
+type nativeException struct {
+	code    int32
+	message string
+}
+
+func (ne *nativeException) Error() string {
+	return fmt.Sprintf("code: %d, message: %s", ne.code, ne.message)
+}
+
+//nativeRow fill row with object values converted to columns types in columns order
+func nativeRow(columns []column.Column, object map[string]interface{}, row []driver.Value) error {
+	for i, c := range columns {
+		value, err := nativeValue(c.CHType(), object[c.Name()])
+		if err != nil {
+			return fmt.Errorf("Error converting [%s] field with [%v] value to %s: %v", c.Name(), object[c.Name()], c.CHType(), err)
+		}
+		row[i] = value
+	}
+
+	return nil
+}
+
+//nativeValue return value converted to the Go type which is expected by clickhouse-go column of chType
+//nil values of not Nullable columns are replaced with zero values
+func nativeValue(chType string, value interface{}) (interface{}, error) {
+	if value == nil && strings.HasPrefix(chType, nullableCHTypePrefix) {
+		return nil, nil
+	}
+
+	switch base := baseType(chType); base {
+	case "String":
+		switch v := value.(type) {
+		case nil:
+			return "", nil
+		case string:
+			return v, nil
+		case time.Time:
+			return v.Format(time.RFC3339Nano), nil
+		default:
+			return fmt.Sprint(v), nil
+		}
+	case "Int8", "Int16", "Int32", "Int64", "UInt8", "UInt16", "UInt32", "UInt64":
+		n, err := nativeInt(value)
+		if err != nil {
+			return nil, err
+		}
+		switch base {
+		case "Int8":
+			return int8(n), nil
+		case "Int16":
+			return int16(n), nil
+		case "Int32":
+			return int32(n), nil
+		case "Int64":
+			return n, nil
+		case "UInt8":
+			return uint8(n), nil
+		case "UInt16":
+			return uint16(n), nil
+		case "UInt32":
+			return uint32(n), nil
+		default:
+			return uint64(n), nil
+		}
+	case "Float32", "Float64":
+		f, err := nativeFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		if base == "Float32" {
+			return float32(f), nil
+		}
+		return f, nil
+	default:
+		if strings.HasPrefix(base, arrayCHTypePrefix) && strings.HasSuffix(base, ")") {
+			return nativeArray(base[len(arrayCHTypePrefix):len(base)-1], value)
+		}
+		if strings.HasPrefix(base, "DateTime") || base == "Date" {
+			if value == nil {
+				return time.Time{}, nil
+			}
+			if f, ok := value.(float64); ok {
+				return int64(f), nil
+			}
+			return value, nil
+		}
+		if value == nil {
+			return nil, fmt.Errorf("null value can't be written into not Nullable %s column", chType)
+		}
+		return value, nil
+	}
+}
+
+//nativeArray return array elements converted to elementType Go type. nil values are written as empty arrays
+func nativeArray(elementType string, value interface{}) (interface{}, error) {
+	if value == nil {
+		return []interface{}{}, nil
+	}
+	array, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %T", value)
+	}
+
+	converted := make([]interface{}, len(array))
+	for i, element := range array {
+		nativeElement, err := nativeValue(elementType, element)
+		if err != nil {
+			return nil, err
+		}
+		converted[i] = nativeElement
+	}
+
+	return converted, nil
+}
+
+func nativeInt(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		return int64(v), nil
+	case float32:
+		return int64(v), nil
+	case float64:
+		if math.Trunc(v) != v {
+			return 0, fmt.Errorf("%v isn't integer", v)
+		}
+		return int64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+func nativeFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		n, err := nativeInt(v)
+		return float64(n), err
+	}
+}
@@ -1,12 +1,18 @@
 package adapters
 
 import (
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/test"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/mailru/go-clickhouse"
 	"github.com/stretchr/testify/require"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestTableStatementFactory(t *testing.T) {
+	notReplicated := false
 	tests := []struct {
 		name                   string
 		inputConfig            *ClickHouseConfig
@@ -83,7 +89,7 @@ func TestTableStatementFactory(t *testing.T) {
 				Database: "db1",
 				Cluster:  "cluster1",
 			},
-			"CREATE TABLE \"db1\".\"test_table\"  ON CLUSTER cluster1  (a String,b String,c String,d String) ENGINE = ReplicatedReplacingMergeTree('/clickhouse/tables/{shard}/db1/test_table', '{replica}', _timestamp) PARTITION BY (toYYYYMM(_timestamp)) ORDER BY (eventn_ctx_event_id)",
+			"CREATE TABLE IF NOT EXISTS \"db1\".\"test_table\"  ON CLUSTER cluster1  (a String,b String,c String,d String) ENGINE = ReplicatedReplacingMergeTree('/clickhouse/tables/{shard}/db1/test_table', '{replica}', _timestamp) PARTITION BY (toYYYYMM(_timestamp)) ORDER BY (eventn_ctx_event_id)",
 		},
 		{
 			"Input config with cluster with overrides",
@@ -101,7 +107,7 @@ func TestTableStatementFactory(t *testing.T) {
 					PrimaryKeys: []string{"id", "b"},
 				},
 			},
-			"CREATE TABLE \"db1\".\"test_table\"  ON CLUSTER cluster1  (a String,b String,c String,d String) ENGINE = ReplicatedReplacingMergeTree('/clickhouse/tables/{shard}/db1/test_table', '{replica}', _timestamp) PARTITION BY (toYYYYMMDD(_timestamp)) ORDER BY (id,a) PRIMARY KEY (id, b)",
+			"CREATE TABLE IF NOT EXISTS \"db1\".\"test_table\"  ON CLUSTER cluster1  (a String,b String,c String,d String) ENGINE = ReplicatedReplacingMergeTree('/clickhouse/tables/{shard}/db1/test_table', '{replica}', _timestamp) PARTITION BY (toYYYYMMDD(_timestamp)) ORDER BY (id,a) PRIMARY KEY (id, b)",
 		},
 		{
 			"Input config with cluster without overrides with raw statement",
@@ -113,7 +119,56 @@ func TestTableStatementFactory(t *testing.T) {
 					RawStatement: "ENGINE = ReplacingMergeTree(d) ORDER BY (e) PRIMARY KEY (a)",
 				},
 			},
-			"CREATE TABLE \"db1\".\"test_table\"  ON CLUSTER cluster1  (a String,b String,c String,d String) ENGINE = ReplacingMergeTree(d) ORDER BY (e) PRIMARY KEY (a)",
+			"CREATE TABLE IF NOT EXISTS \"db1\".\"test_table\"  ON CLUSTER cluster1  (a String,b String,c String,d String) ENGINE = ReplacingMergeTree(d) ORDER BY (e) PRIMARY KEY (a)",
+		},
+		{
+			"Input config without cluster with partition_by and order_by expressions",
+			&ClickHouseConfig{
+				Dsns:     []string{},
+				Database: "db1",
+				Engine: &EngineConfig{
+					PartitionBy: "toYYYYMMDD(_timestamp), event_type",
+					OrderBy:     "event_type, intHash32(eventn_ctx_user_anonymous_id)",
+				},
+			},
+			"CREATE TABLE \"db1\".\"test_table\"  (a String,b String,c String,d String) ENGINE = ReplacingMergeTree(_timestamp) PARTITION BY (toYYYYMMDD(_timestamp), event_type) ORDER BY (event_type, intHash32(eventn_ctx_user_anonymous_id))",
+		},
+		{
+			"Input config with cluster with ttl",
+			&ClickHouseConfig{
+				Dsns:     []string{},
+				Database: "db1",
+				Cluster:  "cluster1",
+				Engine: &EngineConfig{
+					TTL: "_timestamp + INTERVAL 90 DAY",
+				},
+			},
+			"CREATE TABLE IF NOT EXISTS \"db1\".\"test_table\"  ON CLUSTER cluster1  (a String,b String,c String,d String) ENGINE = ReplicatedReplacingMergeTree('/clickhouse/tables/{shard}/db1/test_table', '{replica}', _timestamp) PARTITION BY (toYYYYMM(_timestamp)) ORDER BY (eventn_ctx_event_id)  TTL _timestamp + INTERVAL 90 DAY",
+		},
+		{
+			"Input config with cluster with custom replication",
+			&ClickHouseConfig{
+				Dsns:     []string{},
+				Database: "db1",
+				Cluster:  "cluster1",
+				Engine: &EngineConfig{
+					ZookeeperPath: "/clickhouse/{cluster}/tables/{shard}/{database}/{table}_v2",
+					ReplicaName:   "{replica}_{shard}",
+				},
+			},
+			"CREATE TABLE IF NOT EXISTS \"db1\".\"test_table\"  ON CLUSTER cluster1  (a String,b String,c String,d String) ENGINE = ReplicatedReplacingMergeTree('/clickhouse/{cluster}/tables/{shard}/db1/test_table_v2', '{replica}_{shard}', _timestamp) PARTITION BY (toYYYYMM(_timestamp)) ORDER BY (eventn_ctx_event_id)",
+		},
+		{
+			"Input config with cluster without replication",
+			&ClickHouseConfig{
+				Dsns:     []string{},
+				Database: "db1",
+				Cluster:  "cluster1",
+				Engine: &EngineConfig{
+					Replicated: &notReplicated,
+				},
+			},
+			"CREATE TABLE IF NOT EXISTS \"db1\".\"test_table\"  ON CLUSTER cluster1  (a String,b String,c String,d String) ENGINE = ReplacingMergeTree(_timestamp) PARTITION BY (toYYYYMM(_timestamp)) ORDER BY (eventn_ctx_event_id)",
 		},
 		{
 			"Input config with cluster with overrides with raw statement",
@@ -132,7 +187,7 @@ func TestTableStatementFactory(t *testing.T) {
 					PrimaryKeys: []string{"id", "b"},
 				},
 			},
-			"CREATE TABLE \"db1\".\"test_table\"  ON CLUSTER cluster1  (a String,b String,c String,d String) ENGINE = ReplacingMergeTree(d) ORDER BY (e) PRIMARY KEY (a)",
+			"CREATE TABLE IF NOT EXISTS \"db1\".\"test_table\"  ON CLUSTER cluster1  (a String,b String,c String,d String) ENGINE = ReplacingMergeTree(d) ORDER BY (e) PRIMARY KEY (a)",
 		},
 	}
 	for _, tt := range tests {
@@ -149,3 +204,454 @@ func TestTableStatementFactory(t *testing.T) {
 		})
 	}
 }
+
+func TestEngineKeyFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		engine   *EngineConfig
+		expected []string
+	}{
+		{
+			"Nil engine",
+			nil,
+			nil,
+		},
+		{
+			"Raw statement fields aren't parsed",
+			&EngineConfig{RawStatement: "ENGINE = ReplacingMergeTree(d) ORDER BY (e)", OrderFields: []FieldConfig{{Field: "id"}}},
+			nil,
+		},
+		{
+			"Fields configs",
+			&EngineConfig{
+				PartitionFields: []FieldConfig{{Function: "toYYYYMMDD", Field: "_timestamp"}},
+				OrderFields:     []FieldConfig{{Field: "id"}, {Function: "intHash32", Field: "user_id"}},
+				PrimaryKeys:     []string{"id"},
+			},
+			[]string{"_timestamp", "id", "user_id", "id"},
+		},
+		{
+			"Expressions",
+			&EngineConfig{
+				PartitionBy: "formatDateTime(_timestamp, '%Y_%m'), event_type",
+				OrderBy:     "(event_type, intHash32 (eventn_ctx_user_anonymous_id), 1e3)",
+			},
+			[]string{"_timestamp", "event_type", "event_type", "eventn_ctx_user_anonymous_id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, tt.engine.KeyFields())
+		})
+	}
+}
+
+func TestClickHouseConfigValidate(t *testing.T) {
+	config := &ClickHouseConfig{Dsns: []string{"http://localhost:8123"}, Database: "db1", Engine: &EngineConfig{
+		PartitionBy:     "toYYYYMM(_timestamp)",
+		PartitionFields: []FieldConfig{{Field: "_timestamp"}},
+	}}
+	require.EqualError(t, config.Validate(), "engine.partition_by and engine.partition_fields can't be used together")
+
+	config.Engine = &EngineConfig{OrderBy: "id", OrderFields: []FieldConfig{{Field: "id"}}}
+	require.EqualError(t, config.Validate(), "engine.order_by and engine.order_fields can't be used together")
+
+	config.Engine = &EngineConfig{PartitionBy: "toYYYYMM(_timestamp)", OrderBy: "id"}
+	require.NoError(t, config.Validate())
+	require.Equal(t, &BalancerConfig{Strategy: RoundRobinStrategy, HealthCheckEvery: 10 * time.Second, MaxErrors: 3}, config.Balancer)
+
+	config.Balancer = &BalancerConfig{Strategy: "random"}
+	require.EqualError(t, config.Validate(), "Unknown balancer strategy: random. Supported: round_robin, least_errors")
+}
+
+func TestAsyncInsertConfig(t *testing.T) {
+	config := &ClickHouseConfig{Dsns: []string{"http://localhost:8123"}, Database: "db1", Buffer: &BufferConfig{}, AsyncInsert: &AsyncInsertConfig{}}
+	require.EqualError(t, config.Validate(), "buffer and async_insert can't be used together")
+
+	config = &ClickHouseConfig{Dsns: []string{"http://localhost:8123"}, Database: "db1", AsyncInsert: &AsyncInsertConfig{BusyTimeout: -time.Second}}
+	require.EqualError(t, config.Validate(), "async_insert busy_timeout and max_data_size can't be negative")
+
+	tests := []struct {
+		name     string
+		config   *AsyncInsertConfig
+		expected string
+	}{
+		{
+			"default",
+			&AsyncInsertConfig{},
+			" SETTINGS async_insert=1, wait_for_async_insert=1",
+		},
+		{
+			"without wait",
+			&AsyncInsertConfig{Wait: new(bool)},
+			" SETTINGS async_insert=1, wait_for_async_insert=0",
+		},
+		{
+			"with limits",
+			&AsyncInsertConfig{BusyTimeout: 200 * time.Millisecond, MaxDataSize: 1000000},
+			" SETTINGS async_insert=1, wait_for_async_insert=1, async_insert_busy_timeout_ms=200, async_insert_max_data_size=1000000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.config.Validate())
+			require.Equal(t, tt.expected, tt.config.settingsClause())
+		})
+	}
+
+	ch := &ClickHouse{database: "db1", insertSettings: (&AsyncInsertConfig{Wait: new(bool)}).settingsClause()}
+	statement, header, values := ch.insertStatement("events", ch.insertSettings, map[string]interface{}{"id": 1})
+	require.Equal(t, `INSERT INTO "db1"."events" (id) SETTINGS async_insert=1, wait_for_async_insert=0 VALUES (?)`, statement)
+	require.Equal(t, "id", header)
+	require.Equal(t, []interface{}{1}, values)
+
+	_, _, values = ch.insertStatement("events", "", map[string]interface{}{"tags": []interface{}{"a", "b"}})
+	require.Equal(t, []interface{}{clickhouse.Array([]interface{}{"a", "b"})}, values)
+}
+
+func TestBufferTableStatements(t *testing.T) {
+	config := &ClickHouseConfig{Dsns: []string{"http://host1:8123"}, Database: "db1", Cluster: "cluster1", Buffer: &BufferConfig{MaxTime: time.Minute, MinRows: 1000}}
+	require.NoError(t, config.Validate())
+	require.Equal(t, &BufferConfig{
+		NumLayers: 16,
+		MinTime:   10 * time.Second,
+		MaxTime:   time.Minute,
+		MinRows:   1000,
+		MaxRows:   1000000,
+		MinBytes:  10000000,
+		MaxBytes:  100000000,
+	}, config.Buffer)
+
+	factory, err := NewTableStatementFactory(config)
+	require.NoError(t, err)
+	require.Equal(t, `CREATE TABLE IF NOT EXISTS "db1"."buffer_events"  ON CLUSTER cluster1  AS "db1"."events" ENGINE = Buffer('db1', 'events', 16, 10, 60, 1000, 1000000, 10000000, 100000000)`,
+		factory.CreateBufferTableStatement("events"))
+	require.Equal(t, `DROP TABLE IF EXISTS "db1"."buffer_events"  ON CLUSTER cluster1 `, factory.DropBufferTableStatement("events"))
+
+	config.Buffer = &BufferConfig{MinTime: 2 * time.Minute}
+	require.EqualError(t, config.Validate(), "buffer min_* values must be less than or equal to max_* ones")
+
+	config.Buffer = nil
+	factory, err = NewTableStatementFactory(config)
+	require.NoError(t, err)
+	require.Equal(t, "", factory.CreateBufferTableStatement("events"))
+}
+
+func TestDistributedTableStatements(t *testing.T) {
+	config := &ClickHouseConfig{Dsns: []string{"http://host1:8123"}, Database: "db1", Distributed: &DistributedConfig{}}
+	require.EqualError(t, config.Validate(), "cluster is required parameter if distributed is provided")
+
+	config.Cluster = "cluster1"
+	require.NoError(t, config.Validate())
+	require.Equal(t, "rand()", config.Distributed.ShardingKey)
+	require.Empty(t, config.Distributed.KeyFields())
+
+	config.Distributed.ShardingKey = "cityHash64(eventn_ctx_user_id)"
+	config.Buffer = &BufferConfig{}
+	require.NoError(t, config.Validate())
+	require.Equal(t, []string{"eventn_ctx_user_id"}, config.Distributed.KeyFields())
+
+	factory, err := NewTableStatementFactory(config)
+	require.NoError(t, err)
+	require.True(t, factory.Distributed())
+	require.Equal(t, "dist_events", factory.InsertTableName("events"))
+	require.Equal(t, `CREATE TABLE IF NOT EXISTS "db1"."dist_events"  ON CLUSTER cluster1  AS "db1"."events" ENGINE = Distributed(cluster1,db1,events,cityHash64(eventn_ctx_user_id))`,
+		factory.CreateDistributedTableStatement("events"))
+	require.Equal(t, `DROP TABLE "db1"."dist_events"  ON CLUSTER cluster1 `, factory.DropDistributedTableStatement("events"))
+	//buffer is flushed into the distributed table
+	require.Equal(t, `CREATE TABLE IF NOT EXISTS "db1"."buffer_events"  ON CLUSTER cluster1  AS "db1"."events" ENGINE = Buffer('db1', 'dist_events', 16, 10, 100, 10000, 1000000, 10000000, 100000000)`,
+		factory.CreateBufferTableStatement("events"))
+
+	//distributed table is created with random sharding but rows are inserted into local tables
+	config.Distributed = nil
+	factory, err = NewTableStatementFactory(config)
+	require.NoError(t, err)
+	require.False(t, factory.Distributed())
+	require.Equal(t, "events", factory.InsertTableName("events"))
+	require.Equal(t, `CREATE TABLE IF NOT EXISTS "db1"."dist_events"  ON CLUSTER cluster1  AS "db1"."events" ENGINE = Distributed(cluster1,db1,events,rand())`,
+		factory.CreateDistributedTableStatement("events"))
+}
+
+func TestUpsertTableStatement(t *testing.T) {
+	tests := []struct {
+		name                   string
+		inputConfig            *ClickHouseConfig
+		expectedTableStatement string
+	}{
+		{
+			"Default engine",
+			&ClickHouseConfig{Dsns: []string{}, Database: "db1"},
+			"CREATE TABLE \"db1\".\"users\"  (a String,user_id String) ENGINE = ReplacingMergeTree(_timestamp)  ORDER BY (project_id, user_id)",
+		},
+		{
+			"Engine with partition_by and primary keys",
+			&ClickHouseConfig{
+				Dsns:     []string{},
+				Database: "db1",
+				Cluster:  "cluster1",
+				Engine: &EngineConfig{
+					PartitionBy: "project_id",
+					OrderBy:     "eventn_ctx_event_id",
+					PrimaryKeys: []string{"eventn_ctx_event_id"},
+				},
+			},
+			"CREATE TABLE IF NOT EXISTS \"db1\".\"users\"  ON CLUSTER cluster1  (a String,user_id String) ENGINE = ReplicatedReplacingMergeTree('/clickhouse/tables/{shard}/db1/users', '{replica}', _timestamp) PARTITION BY (project_id) ORDER BY (project_id, user_id)",
+		},
+		{
+			"Raw statement",
+			&ClickHouseConfig{
+				Dsns:     []string{},
+				Database: "db1",
+				Engine:   &EngineConfig{RawStatement: "ENGINE = ReplacingMergeTree() ORDER BY (user_id)"},
+			},
+			"CREATE TABLE \"db1\".\"users\"  (a String,user_id String) ENGINE = ReplacingMergeTree() ORDER BY (user_id)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory, err := NewTableStatementFactory(tt.inputConfig)
+			require.NoError(t, err)
+
+			actual := factory.CreateUpsertTableStatement("users", "a String,user_id String", []string{"project_id", "user_id"})
+			require.Equal(t, tt.expectedTableStatement, strings.TrimSpace(actual), "Statements aren't equal")
+		})
+	}
+}
+
+func TestTableEngineStatement(t *testing.T) {
+	tests := []struct {
+		name                   string
+		inputConfig            *ClickHouseConfig
+		tableName              string
+		expectedTableStatement string
+		expectedColumn         string
+		expectedColumnType     string
+	}{
+		{
+			"Replacing engine",
+			&ClickHouseConfig{Dsns: []string{"http://host1:8123"}, Database: "db1", Engine: &EngineConfig{Tables: []*TableEngineConfig{{Table: "users", Engine: ReplacingEngine}}}},
+			"users",
+			"CREATE TABLE \"db1\".\"users\"  (a String) ENGINE = ReplacingMergeTree(_version) PARTITION BY (toYYYYMM(_timestamp)) ORDER BY (eventn_ctx_event_id)",
+			"_version",
+			"UInt64",
+		},
+		{
+			"Replicated collapsing engine",
+			&ClickHouseConfig{
+				Dsns:     []string{"http://host1:8123"},
+				Database: "db1",
+				Cluster:  "cluster1",
+				Engine:   &EngineConfig{OrderBy: "user_id", Tables: []*TableEngineConfig{{Table: "balances", Engine: CollapsingEngine, SignColumn: "sign"}}},
+			},
+			"balances",
+			"CREATE TABLE IF NOT EXISTS \"db1\".\"balances\"  ON CLUSTER cluster1  (a String) ENGINE = ReplicatedCollapsingMergeTree('/clickhouse/tables/{shard}/db1/balances', '{replica}', sign) PARTITION BY (toYYYYMM(_timestamp)) ORDER BY (user_id)",
+			"sign",
+			"Int8",
+		},
+		{
+			"Table without engine config",
+			&ClickHouseConfig{Dsns: []string{"http://host1:8123"}, Database: "db1", Engine: &EngineConfig{Tables: []*TableEngineConfig{{Table: "users", Engine: ReplacingEngine}}}},
+			"events",
+			"CREATE TABLE \"db1\".\"events\"  (a String) ENGINE = ReplacingMergeTree(_timestamp) PARTITION BY (toYYYYMM(_timestamp)) ORDER BY (eventn_ctx_event_id)",
+			"",
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.inputConfig.Validate())
+			factory, err := NewTableStatementFactory(tt.inputConfig)
+			require.NoError(t, err)
+
+			actual := factory.CreateTableStatement(tt.tableName, "a String")
+			require.Equal(t, tt.expectedTableStatement, strings.TrimSpace(actual), "Statements aren't equal")
+
+			column, columnType := factory.EngineColumn(tt.tableName)
+			require.Equal(t, tt.expectedColumn, column)
+			require.Equal(t, tt.expectedColumnType, columnType)
+		})
+	}
+}
+
+func TestTableEngineConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *TableEngineConfig
+		expected    *schema.EngineColumns
+		expectedErr string
+	}{
+		{"Replacing engine", &TableEngineConfig{Table: "users", Engine: ReplacingEngine}, &schema.EngineColumns{Version: "_version"}, ""},
+		{"Collapsing engine", &TableEngineConfig{Table: "users", Engine: CollapsingEngine, SignColumn: "sign"}, &schema.EngineColumns{Sign: "sign"}, ""},
+		{"Without table", &TableEngineConfig{Engine: ReplacingEngine}, nil, "engine.tables table is required parameter"},
+		{"Unknown engine", &TableEngineConfig{Table: "users", Engine: "summing"}, nil, "Unknown engine of table [users]: summing. Supported: [replacing, collapsing]"},
+		{
+			"Sign column with replacing engine",
+			&TableEngineConfig{Table: "users", Engine: ReplacingEngine, SignColumn: "sign"},
+			nil,
+			"engine.tables sign_column can't be used with replacing engine of table [users]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, tt.config.EngineColumns())
+		})
+	}
+}
+
+func TestColumnDDL(t *testing.T) {
+	ch := &ClickHouse{columns: map[string]ColumnConfig{
+		"event_type": {Type: "LowCardinality(String)", Codec: "ZSTD(1)"},
+		"_timestamp": {Codec: "Delta, LZ4"},
+		"user_id":    {Type: "Nullable(UInt64)"},
+	}}
+	tests := []struct {
+		name     string
+		column   string
+		dataType typing.DataType
+		nullable bool
+		expected string
+	}{
+		{
+			"Default nullable column",
+			"field1",
+			typing.INT64,
+			true,
+			"field1 Nullable(Int64)",
+		},
+		{
+			"Default not null column",
+			"field1",
+			typing.STRING,
+			false,
+			"field1 String",
+		},
+		{
+			"LowCardinality with codec",
+			"event_type",
+			typing.STRING,
+			true,
+			"event_type LowCardinality(Nullable(String)) CODEC(ZSTD(1))",
+		},
+		{
+			"Codec only",
+			"_timestamp",
+			typing.TIMESTAMP,
+			false,
+			"_timestamp DateTime CODEC(Delta, LZ4)",
+		},
+		{
+			"Already nullable type",
+			"user_id",
+			typing.INT64,
+			true,
+			"user_id Nullable(UInt64)",
+		},
+		{
+			"Array column isn't nullable",
+			"tags",
+			typing.ARRAY_STRING,
+			true,
+			"tags Array(String)",
+		},
+		{
+			"JSON column",
+			"properties",
+			typing.JSON,
+			true,
+			"properties Nullable(String)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, ch.columnDDL(tt.column, schema.NewColumn(tt.dataType), tt.nullable))
+		})
+	}
+}
+
+func TestDescribedColumnDDL(t *testing.T) {
+	ch := &ClickHouse{columns: map[string]ColumnConfig{"event_type": {Codec: "ZSTD(1)"}}}
+	require.Equal(t, `event_type Nullable(String) COMMENT 'Event type: \'pageview\' or \'identify\'' CODEC(ZSTD(1))`,
+		ch.columnDDL("event_type", schema.NewDescribedColumn(typing.STRING, "Event type: 'pageview' or 'identify'"), true))
+}
+
+func TestDecimalColumnDDL(t *testing.T) {
+	ch := &ClickHouse{}
+	require.Equal(t, "order_total Nullable(Decimal(18,2))", ch.columnDDL("order_total", schema.NewDecimalColumn(&schema.DecimalType{Precision: 18, Scale: 2}, ""), true))
+}
+
+func TestClickHouseDestructiveChanges(t *testing.T) {
+	tableStatementFactory, err := NewTableStatementFactory(&ClickHouseConfig{Database: "db1", Engine: &EngineConfig{TTL: "_timestamp + INTERVAL 90 DAY"}})
+	require.NoError(t, err)
+	ch := &ClickHouse{database: "db1", tableStatementFactory: tableStatementFactory}
+	table := &schema.Table{Name: "events", Columns: schema.Columns{
+		"id":    schema.NewColumn(typing.INT64),
+		"price": schema.NewDescribedColumn(typing.FLOAT64, "price, drop it after checkout"),
+	}}
+
+	require.Empty(t, ch.DestructiveChanges(table, false))
+	require.Empty(t, ch.DestructiveChanges(table, true))
+
+	statement := ch.ModifyTTLStatement("events", "_timestamp + INTERVAL 90 DAY")
+	require.Equal(t, `ALTER TABLE "db1"."events"  MODIFY TTL _timestamp + INTERVAL 90 DAY`, statement)
+	require.True(t, IsDestructiveDDL(statement), "expired rows are deleted")
+}
+
+func TestBaseType(t *testing.T) {
+	require.Equal(t, "String", baseType("String"))
+	require.Equal(t, "String", baseType("Nullable(String)"))
+	require.Equal(t, "String", baseType("LowCardinality(String)"))
+	require.Equal(t, "String", baseType("LowCardinality(Nullable(String))"))
+	require.Equal(t, "DateTime64(3)", baseType("Nullable(DateTime64(3))"))
+}
+
+func TestClickHouseSchemaType(t *testing.T) {
+	tests := []struct {
+		chType   string
+		expected typing.DataType
+		known    bool
+	}{
+		{"LowCardinality(Nullable(String))", typing.STRING, true},
+		{"UInt32", typing.INT64, true},
+		{"Array(Int64)", typing.ARRAY_INT64, true},
+		{"Array(Nullable(Float64))", typing.ARRAY_FLOAT64, true},
+		{"Array(LowCardinality(String))", typing.ARRAY_STRING, true},
+		{"Array(UUID)", typing.ARRAY_STRING, true},
+		{"UUID", typing.UNKNOWN, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.chType, func(t *testing.T) {
+			actual, ok := clickhouseSchemaType(tt.chType)
+			require.Equal(t, tt.known, ok)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestDeleteCHCondition(t *testing.T) {
+	require.Equal(t, "(user_id) IN ((?))", deleteCHCondition([]string{"user_id"}, 1))
+	require.Equal(t, "(project_id,user_id) IN ((?,?),(?,?))", deleteCHCondition([]string{"project_id", "user_id"}, 2))
+}
+
+func BenchmarkClickHouseInsertStatement(b *testing.B) {
+	flattener := schema.NewFlattener(nil, false, nil, nil, nil)
+	var objects []map[string]interface{}
+	for _, object := range test.ReadObjects(b, test.BenchEventsPath) {
+		flatObject, err := flattener.FlattenObject(object)
+		require.NoError(b, err)
+		objects = append(objects, flatObject)
+	}
+	ch := &ClickHouse{database: "db1"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ch.insertStatement("events", "", objects[i%len(objects)])
+	}
+}
@@ -0,0 +1,49 @@
+package adapters
+
+import (
+	"regexp"
+)
+
+const (
+	//quoted ("schema", [schema], `schema`) or unquoted identifier
+	identifierPattern = "(\"[^\"]*\"|\\[[^\\]]*\\]|`[^`]*`|[\\w$]+)"
+	//ALTER TABLE actions which drop data or narrow columns: DROP COLUMN, RENAME COLUMN, ALTER COLUMN ... TYPE, MODIFY COLUMN, etc.
+	destructiveActionPattern = `(drop|rename|alter|modify|change|replace)\b`
+)
+
+var (
+	//string literals ('it''s', 'it\'s') and quoted identifiers: their content can contain action words (e.g. column comments)
+	quotedRegex = regexp.MustCompile(`'(\\.|''|[^'\\])*'|"[^"]*"|\[[^\]]*\]|` + "`[^`]*`")
+	//statements which drop or truncate whole tables, schemas or databases (CREATE OR REPLACE TABLE recreates table without data)
+	destructiveStatementRegex = regexp.MustCompile(`(?is)^\s*(drop\s+(table|schema|database)\b|truncate\b|create\s+or\s+replace\s+table\b)`)
+	//ALTER TABLE [IF EXISTS] [ONLY] schema.table [ON CLUSTER cluster] <destructive action>
+	destructiveAlterRegex = regexp.MustCompile(`(?is)^\s*alter\s+table\s+(if\s+exists\s+)?(only\s+)?` + identifierPattern + `(\.` + identifierPattern + `)*` +
+		`\s+(on\s+cluster\s+\S+\s+)?` + destructiveActionPattern)
+	//the next action of multi actions ALTER TABLE statement: ADD COLUMN a bigint, DROP COLUMN b
+	destructiveNextActionRegex = regexp.MustCompile(`(?is)^\s*alter\s+table\s.*,\s*` + destructiveActionPattern)
+)
+
+//IsDestructiveDDL return true if the statement can lose data or narrow existing columns: it drops or truncates tables,
+//drops, renames or changes columns. Such statements are never executed by automatic schema changes (see storages.TableHelper)
+//and columns are dropped only explicitly with admin API
+//content of string literals and quoted identifiers isn't classified: ADD COLUMN ... COMMENT 'price, change since checkout'
+func IsDestructiveDDL(statement string) bool {
+	statement = quotedRegex.ReplaceAllStringFunc(statement, func(quoted string) string {
+		return quoted[:1] + quoted[len(quoted)-1:]
+	})
+
+	return destructiveStatementRegex.MatchString(statement) || destructiveAlterRegex.MatchString(statement) ||
+		destructiveNextActionRegex.MatchString(statement)
+}
+
+//DestructiveStatements return statements which are destructive (see IsDestructiveDDL)
+func DestructiveStatements(statements []string) []string {
+	var destructive []string
+	for _, statement := range statements {
+		if IsDestructiveDDL(statement) {
+			destructive = append(destructive, statement)
+		}
+	}
+
+	return destructive
+}
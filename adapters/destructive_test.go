@@ -0,0 +1,39 @@
+package adapters
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestIsDestructiveDDL(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement string
+		expected  bool
+	}{
+		{"create table", `CREATE TABLE "public"."events" (drop_count bigint,"order" text)`, false},
+		{"add column", `ALTER TABLE "public"."events" ADD COLUMN drop_count bigint`, false},
+		{"add reserved column", `ALTER TABLE [dbo].[events] ADD [drop] nvarchar(max)`, false},
+		{"add column named as action", `alter table events add column rename text`, false},
+		{"add column with comment", `ALTER TABLE "public"."events" ADD COLUMN "amount" VARCHAR COMMENT 'Order amount, change since checkout'`, false},
+		{"add column with escaped comment", `ALTER TABLE "public"."events" ADD COLUMN "amount" VARCHAR COMMENT 'it\'s amount, drop it'`, false},
+		{"add quoted column named as action", `ALTER TABLE "public"."events" ADD COLUMN "price, drop" text`, false},
+		{"comment", `COMMENT ON COLUMN "public"."events".url IS 'dropped url'`, false},
+		{"upsert index", `CREATE UNIQUE INDEX IF NOT EXISTS "events_upsert_keys" ON "public"."events" (id)`, false},
+		{"drop column", `ALTER TABLE "public"."events" DROP COLUMN url`, true},
+		{"mssql drop column", `ALTER TABLE [dbo].[events] DROP COLUMN [url]`, true},
+		{"alter column type", `alter table if exists only public.events alter column id type integer`, true},
+		{"modify column", "ALTER TABLE `db`.`events` ON CLUSTER main MODIFY COLUMN id Int32", true},
+		{"rename column", `ALTER TABLE "events" RENAME COLUMN url TO page_url`, true},
+		{"multi actions", `ALTER TABLE "public"."events" ADD COLUMN price numeric(18,2), DROP COLUMN url`, true},
+		{"multi actions after comment", `ALTER TABLE "public"."events" ADD COLUMN price text COMMENT 'it''s price', DROP COLUMN url`, true},
+		{"drop table", `  DROP TABLE IF EXISTS "public"."events"`, true},
+		{"truncate", `TRUNCATE "public"."events"`, true},
+		{"replace table", `CREATE OR REPLACE TABLE "public"."events" (id bigint)`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, IsDestructiveDDL(tt.statement))
+		})
+	}
+}
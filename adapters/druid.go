@@ -0,0 +1,326 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	druidTaskPath       = "/druid/indexer/v1/task"
+	druidTaskStatusPath = "/druid/indexer/v1/task/%s/status"
+
+	druidTaskSuccess = "SUCCESS"
+	druidTaskFailed  = "FAILED"
+
+	defaultDruidSegmentGranularity = "day"
+	defaultDruidQueryGranularity   = "none"
+	defaultDruidTaskTimeout        = 10 * time.Minute
+	defaultDruidFlushEvery         = time.Minute
+	defaultDruidMaxRows            = 100000
+	defaultDruidTimeout            = 30 * time.Second
+	druidTaskPollInterval          = time.Second
+
+	//rows count metric of rollup datasources
+	druidCountMetric = "count"
+)
+
+var (
+	schemaToDruid = map[typing.DataType]string{
+		typing.STRING:    "string",
+		typing.INT64:     "long",
+		typing.FLOAT64:   "double",
+		typing.TIMESTAMP: "string",
+	}
+
+	druidAggregators = []string{"longSum", "longMin", "longMax", "doubleSum", "doubleMin", "doubleMax"}
+)
+
+//DruidConfig dto for deserialized Apache Druid destination config
+//url: Druid router or overlord url e.g. http://druid-router:8888
+//segment_granularity, query_granularity: Druid granularities of datasources (table = datasource). Default: day and none
+//rollup: pre-aggregate rows with the same dimensions values and truncated timestamp (count metric is added automatically)
+//metrics: numeric columns which are ingested as metrics instead of dimensions
+//task_timeout: max time of waiting for ingestion task completion. Default: 10m
+//flush_every, max_rows: stream mode ingestion task is submitted every flush_every or by max_rows objects. Default: 1m and 100000
+type DruidConfig struct {
+	URL                string               `mapstructure:"url"`
+	Username           string               `mapstructure:"username"`
+	Password           string               `mapstructure:"password"`
+	SegmentGranularity string               `mapstructure:"segment_granularity"`
+	QueryGranularity   string               `mapstructure:"query_granularity"`
+	Rollup             bool                 `mapstructure:"rollup"`
+	Metrics            []*DruidMetricConfig `mapstructure:"metrics"`
+	TaskTimeout        time.Duration        `mapstructure:"task_timeout"`
+	FlushEvery         time.Duration        `mapstructure:"flush_every"`
+	MaxRows            int                  `mapstructure:"max_rows"`
+}
+
+//DruidMetricConfig dto for deserialized druid.metrics item
+//column: flattened numeric field name (it is also metric name)
+//aggregator: longSum, longMin, longMax, doubleSum, doubleMin or doubleMax
+type DruidMetricConfig struct {
+	Column     string `mapstructure:"column"`
+	Aggregator string `mapstructure:"aggregator"`
+}
+
+//Validate required fields in DruidConfig and set default values
+func (dc *DruidConfig) Validate() error {
+	if dc == nil {
+		return errors.New("Druid config is required")
+	}
+	if dc.URL == "" {
+		return errors.New("Druid url is required parameter")
+	}
+	if dc.TaskTimeout < 0 || dc.FlushEvery < 0 || dc.MaxRows < 0 {
+		return errors.New("Druid task_timeout, flush_every and max_rows can't be negative")
+	}
+
+	columns := map[string]bool{}
+	for _, metric := range dc.Metrics {
+		if metric == nil || metric.Column == "" {
+			return errors.New("Druid metrics column is required parameter")
+		}
+		if metric.Column == druidCountMetric && dc.Rollup {
+			return fmt.Errorf("Druid metrics column [%s] is reserved for rollup rows count", druidCountMetric)
+		}
+		if columns[metric.Column] {
+			return fmt.Errorf("Druid metrics column [%s] is configured more than once", metric.Column)
+		}
+		columns[metric.Column] = true
+
+		known := false
+		for _, aggregator := range druidAggregators {
+			if aggregator == metric.Aggregator {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("Unknown Druid aggregator [%s] of metrics column [%s]. Available aggregators: %v", metric.Aggregator, metric.Column, druidAggregators)
+		}
+	}
+
+	if dc.SegmentGranularity == "" {
+		dc.SegmentGranularity = defaultDruidSegmentGranularity
+	}
+	if dc.QueryGranularity == "" {
+		dc.QueryGranularity = defaultDruidQueryGranularity
+	}
+	if dc.TaskTimeout == 0 {
+		dc.TaskTimeout = defaultDruidTaskTimeout
+	}
+	if dc.FlushEvery == 0 {
+		dc.FlushEvery = defaultDruidFlushEvery
+	}
+	if dc.MaxRows == 0 {
+		dc.MaxRows = defaultDruidMaxRows
+	}
+
+	return nil
+}
+
+//Druid is adapter for ingesting objects into Apache Druid datasources with native batch (index_parallel) tasks
+//with inline input source via Druid HTTP API. Every task appends rows to existing segments
+type Druid struct {
+	config  *DruidConfig
+	client  *http.Client
+	metrics map[string]string
+}
+
+type druidTaskResponse struct {
+	Task string `json:"task"`
+}
+
+type druidTaskStatusResponse struct {
+	Status struct {
+		Status   string `json:"status"`
+		ErrorMsg string `json:"errorMsg"`
+	} `json:"status"`
+}
+
+//NewDruid return configured Druid adapter instance and check connection
+func NewDruid(config *DruidConfig) (*Druid, error) {
+	metrics := map[string]string{}
+	for _, metric := range config.Metrics {
+		metrics[metric.Column] = metric.Aggregator
+	}
+
+	d := &Druid{config: config, client: &http.Client{Timeout: defaultDruidTimeout}, metrics: metrics}
+	if _, err := d.request(http.MethodGet, "/status", nil); err != nil {
+		return nil, fmt.Errorf("Error connecting to Druid: %v", err)
+	}
+
+	return d, nil
+}
+
+func (Druid) Name() string {
+	return "Druid"
+}
+
+//Ingest submit ingestion task of objects into table datasource and wait for its completion
+//timestampColumn is Druid __time column source. Columns are mapped to dimensions (string, long, double) or metrics
+func (d *Druid) Ingest(table *schema.Table, timestampColumn string, objects []map[string]interface{}) error {
+	if len(objects) == 0 {
+		return nil
+	}
+
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	for _, object := range objects {
+		if err := encoder.Encode(object); err != nil {
+			return fmt.Errorf("Error serializing object %v: %v", object, err)
+		}
+	}
+
+	spec, err := json.Marshal(d.ingestionSpec(table, timestampColumn, data.String()))
+	if err != nil {
+		return fmt.Errorf("Error serializing Druid ingestion spec of %s datasource: %v", table.Name, err)
+	}
+
+	respBody, err := d.request(http.MethodPost, druidTaskPath, spec)
+	if err != nil {
+		return fmt.Errorf("Error submitting Druid ingestion task of %s datasource: %v", table.Name, err)
+	}
+	task := &druidTaskResponse{}
+	if err := json.Unmarshal(respBody, task); err != nil || task.Task == "" {
+		return fmt.Errorf("Error parsing Druid ingestion task response %s: %v", string(respBody), err)
+	}
+
+	return d.waitForTask(task.Task)
+}
+
+//Close underlying http connections
+func (d *Druid) Close() error {
+	d.client.CloseIdleConnections()
+	return nil
+}
+
+//poll task status until it is completed or task timeout is exceeded
+func (d *Druid) waitForTask(taskID string) error {
+	deadline := time.Now().Add(d.config.TaskTimeout)
+	for {
+		respBody, err := d.request(http.MethodGet, fmt.Sprintf(druidTaskStatusPath, url.PathEscape(taskID)), nil)
+		if err != nil {
+			return fmt.Errorf("Error getting Druid ingestion task [%s] status: %v", taskID, err)
+		}
+		status := &druidTaskStatusResponse{}
+		if err := json.Unmarshal(respBody, status); err != nil {
+			return fmt.Errorf("Error parsing Druid ingestion task [%s] status %s: %v", taskID, string(respBody), err)
+		}
+
+		switch status.Status.Status {
+		case druidTaskSuccess:
+			return nil
+		case druidTaskFailed:
+			return fmt.Errorf("Druid ingestion task [%s] failed: %s", taskID, status.Status.ErrorMsg)
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Druid ingestion task [%s] isn't completed in %s", taskID, d.config.TaskTimeout)
+		}
+		time.Sleep(druidTaskPollInterval)
+	}
+}
+
+//return index_parallel task spec with inline newline delimited json data
+func (d *Druid) ingestionSpec(table *schema.Table, timestampColumn, data string) map[string]interface{} {
+	dimensions, metrics := d.columnsSpec(table, timestampColumn)
+
+	return map[string]interface{}{
+		"type": "index_parallel",
+		"spec": map[string]interface{}{
+			"dataSchema": map[string]interface{}{
+				"dataSource":      table.Name,
+				"timestampSpec":   map[string]interface{}{"column": timestampColumn, "format": "auto"},
+				"dimensionsSpec":  map[string]interface{}{"dimensions": dimensions},
+				"metricsSpec":     metrics,
+				"granularitySpec": map[string]interface{}{"segmentGranularity": d.config.SegmentGranularity, "queryGranularity": d.config.QueryGranularity, "rollup": d.config.Rollup},
+			},
+			"ioConfig": map[string]interface{}{
+				"type":             "index_parallel",
+				"inputSource":      map[string]interface{}{"type": "inline", "data": data},
+				"inputFormat":      map[string]interface{}{"type": "json"},
+				"appendToExisting": true,
+			},
+			"tuningConfig": map[string]interface{}{"type": "index_parallel"},
+		},
+	}
+}
+
+//return dimensions and metrics specs of table columns sorted by name
+//configured metrics columns are metrics, other columns (except timestamp one) are typed dimensions
+func (d *Druid) columnsSpec(table *schema.Table, timestampColumn string) ([]map[string]string, []map[string]string) {
+	var names []string
+	for name := range table.Columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	dimensions := []map[string]string{}
+	metrics := []map[string]string{}
+	if d.config.Rollup {
+		metrics = append(metrics, map[string]string{"type": druidCountMetric, "name": druidCountMetric})
+	}
+	for _, name := range names {
+		if name == timestampColumn {
+			continue
+		}
+		if aggregator, ok := d.metrics[name]; ok {
+			metrics = append(metrics, map[string]string{"type": aggregator, "name": name, "fieldName": name})
+			continue
+		}
+
+		column := table.Columns[name]
+		dimensionType, ok := schemaToDruid[column.GetType()]
+		if !ok {
+			dimensionType = schemaToDruid[typing.STRING]
+		}
+		dimensions = append(dimensions, map[string]string{"type": dimensionType, "name": name})
+	}
+
+	return dimensions, metrics
+}
+
+func (d *Druid) request(method, path string, body []byte) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, strings.TrimRight(d.config.URL, "/")+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if d.config.Username != "" {
+		req.SetBasicAuth(d.config.Username, d.config.Password)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s returned status %d: %s", method, path, resp.StatusCode, string(respBody))
+	}
+
+	return respBody, nil
+}
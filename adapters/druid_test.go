@@ -0,0 +1,101 @@
+package adapters
+
+import (
+	"encoding/json"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDruidIngest(t *testing.T) {
+	var spec map[string]interface{}
+	taskStatus := druidTaskSuccess
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/status":
+			w.Write([]byte(`{"version":"0.20.0"}`))
+		case r.URL.Path == druidTaskPath && r.Method == http.MethodPost:
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(body, &spec))
+			w.Write([]byte(`{"task":"index_parallel_events_1"}`))
+		case r.URL.Path == "/druid/indexer/v1/task/index_parallel_events_1/status":
+			w.Write([]byte(`{"task":"index_parallel_events_1","status":{"status":"` + taskStatus + `","errorMsg":"parse error"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	config := &DruidConfig{URL: server.URL, Rollup: true, Metrics: []*DruidMetricConfig{{Column: "revenue", Aggregator: "doubleSum"}}}
+	require.NoError(t, config.Validate())
+	d, err := NewDruid(config)
+	require.NoError(t, err)
+	defer d.Close()
+
+	table := &schema.Table{Name: "events", Columns: schema.Columns{
+		"_timestamp": schema.NewColumn(typing.TIMESTAMP),
+		"user_id":    schema.NewColumn(typing.STRING),
+		"count_pv":   schema.NewColumn(typing.INT64),
+		"revenue":    schema.NewColumn(typing.FLOAT64),
+		"utc_time":   schema.NewColumn(typing.TIMESTAMP),
+	}}
+	ts := time.Date(2020, 12, 1, 10, 0, 0, 0, time.UTC)
+	objects := []map[string]interface{}{
+		{"_timestamp": ts, "user_id": "u1", "count_pv": 1, "revenue": 9.5},
+		{"_timestamp": ts, "user_id": "u2"},
+	}
+	require.NoError(t, d.Ingest(table, "_timestamp", objects))
+
+	dataSchema := spec["spec"].(map[string]interface{})["dataSchema"].(map[string]interface{})
+	require.Equal(t, "events", dataSchema["dataSource"])
+	require.Equal(t, map[string]interface{}{"column": "_timestamp", "format": "auto"}, dataSchema["timestampSpec"])
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"type": "long", "name": "count_pv"},
+		map[string]interface{}{"type": "string", "name": "user_id"},
+		map[string]interface{}{"type": "string", "name": "utc_time"},
+	}, dataSchema["dimensionsSpec"].(map[string]interface{})["dimensions"])
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"type": "count", "name": "count"},
+		map[string]interface{}{"type": "doubleSum", "name": "revenue", "fieldName": "revenue"},
+	}, dataSchema["metricsSpec"])
+	require.Equal(t, map[string]interface{}{"segmentGranularity": "day", "queryGranularity": "none", "rollup": true}, dataSchema["granularitySpec"])
+
+	ioConfig := spec["spec"].(map[string]interface{})["ioConfig"].(map[string]interface{})
+	require.Equal(t, true, ioConfig["appendToExisting"])
+	data := ioConfig["inputSource"].(map[string]interface{})["data"].(string)
+	require.Equal(t, `{"_timestamp":"2020-12-01T10:00:00Z","count_pv":1,"revenue":9.5,"user_id":"u1"}`+"\n"+
+		`{"_timestamp":"2020-12-01T10:00:00Z","user_id":"u2"}`+"\n", data)
+
+	taskStatus = druidTaskFailed
+	err = d.Ingest(table, "_timestamp", objects)
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "parse error"), err.Error())
+}
+
+func TestDruidConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *DruidConfig
+		expectedErr string
+	}{
+		{"Empty url", &DruidConfig{}, "Druid url is required parameter"},
+		{"Unknown aggregator", &DruidConfig{URL: "http://druid:8888", Metrics: []*DruidMetricConfig{{Column: "revenue", Aggregator: "sum"}}},
+			"Unknown Druid aggregator [sum] of metrics column [revenue]. Available aggregators: [longSum longMin longMax doubleSum doubleMin doubleMax]"},
+		{"Duplicated metric", &DruidConfig{URL: "http://druid:8888", Metrics: []*DruidMetricConfig{{Column: "revenue", Aggregator: "doubleSum"}, {Column: "revenue", Aggregator: "doubleMax"}}},
+			"Druid metrics column [revenue] is configured more than once"},
+		{"Reserved count metric", &DruidConfig{URL: "http://druid:8888", Rollup: true, Metrics: []*DruidMetricConfig{{Column: "count", Aggregator: "longSum"}}},
+			"Druid metrics column [count] is reserved for rollup rows count"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.EqualError(t, tt.config.Validate(), tt.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,408 @@
+package adapters
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultElasticsearchTimeout   = 30 * time.Second
+	defaultElasticsearchDateIndex = "2006.01.02"
+
+	//max error details in bulk error message
+	elasticsearchMaxBulkErrors = 5
+)
+
+var (
+	schemaToElasticsearch = map[typing.DataType]string{
+		typing.STRING:    "keyword",
+		typing.INT64:     "long",
+		typing.FLOAT64:   "double",
+		typing.TIMESTAMP: "date",
+	}
+
+	elasticsearchToSchema = map[string]typing.DataType{
+		"keyword":          typing.STRING,
+		"text":             typing.STRING,
+		"long":             typing.INT64,
+		"integer":          typing.INT64,
+		"short":            typing.INT64,
+		"byte":             typing.INT64,
+		"double":           typing.FLOAT64,
+		"float":            typing.FLOAT64,
+		"half_float":       typing.FLOAT64,
+		"scaled_float":     typing.FLOAT64,
+		"date":             typing.TIMESTAMP,
+		"date_nanos":       typing.TIMESTAMP,
+		"boolean":          typing.STRING,
+		"ip":               typing.STRING,
+		"constant_keyword": typing.STRING,
+	}
+)
+
+//ElasticsearchConfig dto for deserialized Elasticsearch (or OpenSearch) destination config
+//events are indexed into daily indices: <table>-<date> (date_layout is Go time layout, default: 2006.01.02)
+//index templates (<table>-* pattern) are created and patched according to events schema
+type ElasticsearchConfig struct {
+	Hosts              []string      `mapstructure:"hosts"`
+	Username           string        `mapstructure:"username"`
+	Password           string        `mapstructure:"password"`
+	APIKey             string        `mapstructure:"api_key"`
+	DateLayout         string        `mapstructure:"date_layout"`
+	IDField            string        `mapstructure:"id_field"`
+	Shards             int           `mapstructure:"shards"`
+	Replicas           *int          `mapstructure:"replicas"`
+	BulkSize           int           `mapstructure:"bulk_size"`
+	FlushEvery         time.Duration `mapstructure:"flush_every"`
+	Timeout            time.Duration `mapstructure:"timeout"`
+	InsecureSkipVerify bool          `mapstructure:"insecure_skip_verify"`
+}
+
+//Validate required fields in ElasticsearchConfig
+func (ec *ElasticsearchConfig) Validate() error {
+	if ec == nil {
+		return errors.New("Elasticsearch config is required")
+	}
+	if len(ec.Hosts) == 0 {
+		return errors.New("Elasticsearch hosts is required parameter")
+	}
+	if ec.APIKey != "" && ec.Username != "" {
+		return errors.New("Elasticsearch api_key and username can't be used together")
+	}
+	if ec.Shards < 0 || ec.BulkSize < 0 || ec.FlushEvery < 0 || ec.Timeout < 0 || (ec.Replicas != nil && *ec.Replicas < 0) {
+		return errors.New("Elasticsearch shards, replicas, bulk_size, flush_every and timeout can't be negative")
+	}
+
+	return nil
+}
+
+//ElasticsearchDocument is a document which will be indexed into the index
+//ID is optional (Elasticsearch generates it if empty)
+type ElasticsearchDocument struct {
+	Index  string
+	ID     string
+	Source map[string]interface{}
+}
+
+//Elasticsearch is adapter for managing index templates and bulk indexing via Elasticsearch REST API
+//Index templates work like tables: template name is a table name and it is applied to all <table>-* indices
+type Elasticsearch struct {
+	config *ElasticsearchConfig
+	client *http.Client
+	next   uint32
+}
+
+//NewElasticsearch return configured Elasticsearch adapter instance and check connection
+func NewElasticsearch(config *ElasticsearchConfig) (*Elasticsearch, error) {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = defaultElasticsearchTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	es := &Elasticsearch{config: config, client: &http.Client{Timeout: timeout, Transport: transport}}
+	if _, err := es.request(http.MethodGet, "/", nil, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("Error connecting to Elasticsearch: %v", err)
+	}
+
+	return es, nil
+}
+
+func (Elasticsearch) Name() string {
+	return "Elasticsearch"
+}
+
+//IndexName return daily index name of the table
+func (es *Elasticsearch) IndexName(tableName string, t time.Time) string {
+	layout := es.config.DateLayout
+	if layout == "" {
+		layout = defaultElasticsearchDateIndex
+	}
+
+	return strings.ToLower(tableName) + "-" + t.UTC().Format(layout)
+}
+
+//GetTableSchema return index template mapping as a table representation (empty table if template doesn't exist)
+func (es *Elasticsearch) GetTableSchema(tableName string) (*schema.Table, error) {
+	table := &schema.Table{Name: tableName, Columns: schema.Columns{}}
+
+	properties, err := es.templateProperties(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, property := range properties {
+		esType, _ := property["type"].(string)
+		mappedType, ok := elasticsearchToSchema[esType]
+		if !ok {
+			log.Println("Unknown elasticsearch field type:", esType)
+			mappedType = typing.STRING
+		}
+		table.Columns[name] = schema.NewColumn(mappedType)
+	}
+
+	return table, nil
+}
+
+//CreateTable create index template for all table indices
+func (es *Elasticsearch) CreateTable(tableSchema *schema.Table) error {
+	if err := es.putTemplate(tableSchema.Name, es.properties(tableSchema)); err != nil {
+		return fmt.Errorf("Error creating [%s] index template: %v", tableSchema.Name, err)
+	}
+
+	return nil
+}
+
+//PatchTableSchema add new fields into index template and into mappings of already existing table indices
+func (es *Elasticsearch) PatchTableSchema(patchSchema *schema.Table) error {
+	properties, err := es.templateProperties(patchSchema.Name)
+	if err != nil {
+		return err
+	}
+
+	newProperties := es.properties(patchSchema)
+	for name, property := range newProperties {
+		properties[name] = property
+	}
+
+	if err := es.putTemplate(patchSchema.Name, properties); err != nil {
+		return fmt.Errorf("Error patching [%s] index template: %v", patchSchema.Name, err)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"properties": newProperties})
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/%s-*/_mapping?allow_no_indices=true&ignore_unavailable=true", strings.ToLower(patchSchema.Name))
+	if _, err := es.request(http.MethodPut, path, body, http.StatusOK); err != nil {
+		return fmt.Errorf("Error patching [%s] indices mappings: %v", patchSchema.Name, err)
+	}
+
+	return nil
+}
+
+//DestructiveChanges return nil: index templates and mappings are only extended with new fields, existing fields are never changed
+func (es *Elasticsearch) DestructiveChanges(tableSchema *schema.Table, patch bool) []string {
+	return nil
+}
+
+//Bulk index documents with one bulk request
+//return error if request failed or any document wasn't indexed
+func (es *Elasticsearch) Bulk(documents []*ElasticsearchDocument) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	for _, document := range documents {
+		action := map[string]interface{}{"_index": document.Index}
+		if document.ID != "" {
+			action["_id"] = document.ID
+		}
+		if err := encoder.Encode(map[string]interface{}{"index": action}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(document.Source); err != nil {
+			return fmt.Errorf("Error serializing document %v: %v", document.Source, err)
+		}
+	}
+
+	respBody, err := es.request(http.MethodPost, "/_bulk", buf.Bytes(), http.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	resp := &elasticsearchBulkResponse{}
+	if err := json.Unmarshal(respBody, resp); err != nil {
+		return fmt.Errorf("Error parsing bulk response: %v", err)
+	}
+	if !resp.Errors {
+		return nil
+	}
+
+	var failed int
+	var messages []string
+	for _, item := range resp.Items {
+		result := item["index"]
+		if result == nil || result.Error == nil {
+			continue
+		}
+		failed++
+		if len(messages) < elasticsearchMaxBulkErrors {
+			messages = append(messages, fmt.Sprintf("[%s] %s: %s", result.Index, result.Error.Type, result.Error.Reason))
+		}
+	}
+
+	return fmt.Errorf("%d of %d documents weren't indexed: %s", failed, len(documents), strings.Join(messages, "; "))
+}
+
+//Close underlying http connections
+func (es *Elasticsearch) Close() error {
+	es.client.CloseIdleConnections()
+	return nil
+}
+
+type elasticsearchBulkResponse struct {
+	Errors bool                                      `json:"errors"`
+	Items  []map[string]*elasticsearchBulkItemResult `json:"items"`
+}
+
+type elasticsearchBulkItemResult struct {
+	Index string                  `json:"_index"`
+	Error *elasticsearchItemError `json:"error"`
+}
+
+type elasticsearchItemError struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+//return index template mapping properties or empty map if template doesn't exist
+func (es *Elasticsearch) templateProperties(tableName string) (map[string]map[string]interface{}, error) {
+	properties := map[string]map[string]interface{}{}
+
+	body, err := es.request(http.MethodGet, "/_index_template/"+strings.ToLower(tableName), nil, http.StatusOK, http.StatusNotFound)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting [%s] index template: %v", tableName, err)
+	}
+
+	resp := &struct {
+		IndexTemplates []struct {
+			IndexTemplate struct {
+				Template struct {
+					Mappings struct {
+						Properties map[string]map[string]interface{} `json:"properties"`
+					} `json:"mappings"`
+				} `json:"template"`
+			} `json:"index_template"`
+		} `json:"index_templates"`
+	}{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, fmt.Errorf("Error parsing [%s] index template: %v", tableName, err)
+	}
+
+	for _, template := range resp.IndexTemplates {
+		for name, property := range template.IndexTemplate.Template.Mappings.Properties {
+			properties[name] = property
+		}
+	}
+
+	return properties, nil
+}
+
+func (es *Elasticsearch) putTemplate(tableName string, properties map[string]map[string]interface{}) error {
+	template := map[string]interface{}{
+		"mappings": map[string]interface{}{"properties": properties},
+	}
+
+	settings := map[string]interface{}{}
+	if es.config.Shards > 0 {
+		settings["number_of_shards"] = es.config.Shards
+	}
+	if es.config.Replicas != nil {
+		settings["number_of_replicas"] = *es.config.Replicas
+	}
+	if len(settings) > 0 {
+		template["settings"] = settings
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"index_patterns": []string{strings.ToLower(tableName) + "-*"},
+		"template":       template,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = es.request(http.MethodPut, "/_index_template/"+strings.ToLower(tableName), body, http.StatusOK)
+	return err
+}
+
+//return mapping properties of table columns
+func (es *Elasticsearch) properties(table *schema.Table) map[string]map[string]interface{} {
+	properties := map[string]map[string]interface{}{}
+	for name, column := range table.Columns {
+		mappedType, ok := schemaToElasticsearch[column.GetType()]
+		if !ok {
+			log.Println("Unknown elasticsearch schema type:", column.GetType().String())
+			mappedType = schemaToElasticsearch[typing.STRING]
+		}
+		properties[name] = map[string]interface{}{"type": mappedType}
+	}
+
+	return properties
+}
+
+//send request to hosts one by one (round robin) until response is received
+//return response body or error if response status isn't one of expected
+func (es *Elasticsearch) request(method, path string, body []byte, expectedStatuses ...int) ([]byte, error) {
+	hosts := es.config.Hosts
+	start := atomic.AddUint32(&es.next, 1)
+
+	var lastErr error
+	for i := 0; i < len(hosts); i++ {
+		host := strings.TrimRight(hosts[(int(start)+i)%len(hosts)], "/")
+
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequest(method, host+path, reader)
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			contentType := "application/json"
+			if strings.HasPrefix(path, "/_bulk") {
+				contentType = "application/x-ndjson"
+			}
+			req.Header.Set("Content-Type", contentType)
+		}
+		if es.config.APIKey != "" {
+			req.Header.Set("Authorization", "ApiKey "+es.config.APIKey)
+		} else if es.config.Username != "" {
+			req.SetBasicAuth(es.config.Username, es.config.Password)
+		}
+
+		resp, err := es.client.Do(req)
+		if err != nil {
+			//try the next host
+			lastErr = err
+			continue
+		}
+
+		respBody, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Error reading response from %s: %v", host, err)
+		}
+
+		for _, status := range expectedStatuses {
+			if resp.StatusCode == status {
+				return respBody, nil
+			}
+		}
+
+		return nil, fmt.Errorf("%s %s returned status %d: %s", method, path, resp.StatusCode, string(respBody))
+	}
+
+	return nil, lastErr
+}
@@ -0,0 +1,138 @@
+package adapters
+
+import (
+	"encoding/json"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestElasticsearchIndexTemplates(t *testing.T) {
+	var mutex sync.Mutex
+	templates := map[string][]byte{}
+	var patchedMappings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		switch {
+		case r.URL.Path == "/":
+			w.Write([]byte(`{"version": {"number": "7.10.0"}}`))
+		case strings.HasPrefix(r.URL.Path, "/_index_template/") && r.Method == http.MethodPut:
+			templates[strings.TrimPrefix(r.URL.Path, "/_index_template/")] = body
+			w.Write([]byte(`{"acknowledged": true}`))
+		case strings.HasPrefix(r.URL.Path, "/_index_template/"):
+			template, ok := templates[strings.TrimPrefix(r.URL.Path, "/_index_template/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error": "not found"}`))
+				return
+			}
+			w.Write([]byte(`{"index_templates": [{"index_template": ` + string(template) + `}]}`))
+		case strings.HasSuffix(r.URL.Path, "/_mapping"):
+			patchedMappings = append(patchedMappings, r.URL.Path)
+			w.Write([]byte(`{"acknowledged": true}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	es, err := NewElasticsearch(&ElasticsearchConfig{Hosts: []string{server.URL}})
+	require.NoError(t, err)
+
+	table, err := es.GetTableSchema("Events")
+	require.NoError(t, err)
+	require.False(t, table.Exists())
+
+	require.NoError(t, es.CreateTable(&schema.Table{Name: "Events", Columns: schema.Columns{
+		"field1":     schema.NewColumn(typing.STRING),
+		"_timestamp": schema.NewColumn(typing.TIMESTAMP),
+	}}))
+	require.NoError(t, es.PatchTableSchema(&schema.Table{Name: "Events", Columns: schema.Columns{
+		"field2": schema.NewColumn(typing.INT64),
+	}}))
+
+	template := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(templates["events"], &template))
+	require.Equal(t, []interface{}{"events-*"}, template["index_patterns"])
+	require.Equal(t, []string{"/events-*/_mapping"}, patchedMappings)
+
+	table, err = es.GetTableSchema("Events")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(table.Columns))
+	require.Equal(t, typing.STRING, table.Columns["field1"].GetType())
+	require.Equal(t, typing.INT64, table.Columns["field2"].GetType())
+	require.Equal(t, typing.TIMESTAMP, table.Columns["_timestamp"].GetType())
+
+	require.Equal(t, "events-2020.06.16", es.IndexName("Events", time.Date(2020, 6, 16, 23, 0, 0, 0, time.UTC)))
+}
+
+func TestElasticsearchBulk(t *testing.T) {
+	var bulkBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		if r.URL.Path == "/_bulk" {
+			bulkBody = string(body)
+			require.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+			w.Write([]byte(`{"errors": true, "items": [
+{"index": {"_index": "events-2020.06.16", "status": 201}},
+{"index": {"_index": "events-2020.06.16", "status": 400, "error": {"type": "mapper_parsing_exception", "reason": "failed to parse field [field2]"}}}]}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	es, err := NewElasticsearch(&ElasticsearchConfig{Hosts: []string{server.URL}})
+	require.NoError(t, err)
+
+	err = es.Bulk([]*ElasticsearchDocument{
+		{Index: "events-2020.06.16", ID: "id1", Source: map[string]interface{}{"field1": "a"}},
+		{Index: "events-2020.06.16", Source: map[string]interface{}{"field2": "b"}},
+	})
+	require.EqualError(t, err, "1 of 2 documents weren't indexed: [events-2020.06.16] mapper_parsing_exception: failed to parse field [field2]")
+	require.Equal(t, `{"index":{"_id":"id1","_index":"events-2020.06.16"}}
+{"field1":"a"}
+{"index":{"_index":"events-2020.06.16"}}
+{"field2":"b"}
+`, bulkBody)
+}
+
+func TestElasticsearchBulkUnicode(t *testing.T) {
+	var bulkBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		if r.URL.Path == "/_bulk" {
+			bulkBody = body
+			w.Write([]byte(`{"errors": false, "items": []}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	es, err := NewElasticsearch(&ElasticsearchConfig{Hosts: []string{server.URL}})
+	require.NoError(t, err)
+
+	require.NoError(t, es.Bulk([]*ElasticsearchDocument{
+		{Index: "events-2020.06.16", ID: "🎉", Source: map[string]interface{}{"title": "Привет 👋🏽", "note": "𝄞"}},
+	}))
+	require.Equal(t, `{"index":{"_id":"🎉","_index":"events-2020.06.16"}}
+{"note":"𝄞","title":"Привет 👋🏽"}
+`, string(bulkBody))
+}
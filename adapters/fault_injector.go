@@ -0,0 +1,74 @@
+package adapters
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+//ErrInjectedFault is returned by FaultInjector instead of a real destination error
+var ErrInjectedFault = errors.New("Injected fault")
+
+//FaultInjectionConfig dto for deserialized destination fault injection config (for testing purposes only!)
+//error_rate: probability [0, 1] of ErrInjectedFault on every write operation
+//latency: delay which is added to write operations with latency_rate probability [0, 1] (default: 1 if latency is provided)
+type FaultInjectionConfig struct {
+	ErrorRate   float64       `mapstructure:"error_rate"`
+	Latency     time.Duration `mapstructure:"latency"`
+	LatencyRate float64       `mapstructure:"latency_rate"`
+}
+
+//Validate required fields in FaultInjectionConfig and set default values
+func (fic *FaultInjectionConfig) Validate() error {
+	if fic == nil {
+		return errors.New("Fault injection config is required")
+	}
+	if fic.ErrorRate < 0 || fic.ErrorRate > 1 {
+		return errors.New("fault_injection.error_rate must be in [0, 1] range")
+	}
+	if fic.LatencyRate < 0 || fic.LatencyRate > 1 {
+		return errors.New("fault_injection.latency_rate must be in [0, 1] range")
+	}
+	if fic.Latency < 0 {
+		return errors.New("fault_injection.latency can't be negative")
+	}
+	if fic.Latency > 0 && fic.LatencyRate == 0 {
+		fic.LatencyRate = 1
+	}
+
+	return nil
+}
+
+//FaultInjector emulates slow and failing destinations for verifying queue and retry behavior
+type FaultInjector struct {
+	errorRate   float64
+	latency     time.Duration
+	latencyRate float64
+}
+
+//NewFaultInjector return FaultInjector configured with validated config
+func NewFaultInjector(config *FaultInjectionConfig) (*FaultInjector, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &FaultInjector{errorRate: config.ErrorRate, latency: config.Latency, latencyRate: config.LatencyRate}, nil
+}
+
+//Inject sleep and/or return ErrInjectedFault according to configured rates
+//Nil FaultInjector doesn't inject anything
+func (fi *FaultInjector) Inject() error {
+	if fi == nil {
+		return nil
+	}
+
+	if fi.latency > 0 && rand.Float64() < fi.latencyRate {
+		time.Sleep(fi.latency)
+	}
+
+	if rand.Float64() < fi.errorRate {
+		return ErrInjectedFault
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package adapters
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestFaultInjector(t *testing.T) {
+	var injector *FaultInjector
+	require.NoError(t, injector.Inject(), "Nil injector doesn't inject faults")
+
+	injector, err := NewFaultInjector(&FaultInjectionConfig{})
+	require.NoError(t, err)
+	require.NoError(t, injector.Inject())
+
+	injector, err = NewFaultInjector(&FaultInjectionConfig{ErrorRate: 1, Latency: 10 * time.Millisecond})
+	require.NoError(t, err)
+	start := time.Now()
+	require.Equal(t, ErrInjectedFault, injector.Inject())
+	require.True(t, time.Since(start) >= 10*time.Millisecond, "Latency must be injected with default latency_rate")
+
+	_, err = NewFaultInjector(&FaultInjectionConfig{ErrorRate: 1.5})
+	require.EqualError(t, err, "fault_injection.error_rate must be in [0, 1] range")
+	_, err = NewFaultInjector(&FaultInjectionConfig{LatencyRate: -1})
+	require.EqualError(t, err, "fault_injection.latency_rate must be in [0, 1] range")
+}
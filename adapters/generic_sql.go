@@ -0,0 +1,344 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"log"
+	"sort"
+	"strings"
+)
+
+const (
+	genericInsertTemplate = `INSERT INTO %s (%s) VALUES (%s)`
+	genericDeleteTemplate = `DELETE FROM %s WHERE %s`
+)
+
+//SQLDialect is a descriptor of SQL database specifics which are used by GenericSQL adapter
+//new SQL destination is added with a dialect descriptor (see MSSQLDialect) instead of a new adapter
+type SQLDialect struct {
+	//destination name (is used in logs and errors) and database/sql driver name
+	Name       string
+	DriverName string
+	//datasource port and schema which are used if they aren't configured
+	DefaultPort   int
+	DefaultSchema string
+	//return driver connection string of datasource config
+	ConnectionString func(config *DataSourceConfig) string
+	//return quoted identifier (schema, table or column name)
+	Quote func(identifier string) string
+	//return bind parameter placeholder of 1-based parameter index e.g. $1, @p1 or ?
+	Placeholder func(index int) string
+
+	//column types of created tables
+	ColumnTypes map[typing.DataType]string
+	//DECIMAL column type with precision and scale placeholders e.g. decimal(%d,%d). DECIMAL columns are string ones if it is empty
+	DecimalTemplate string
+	//lowercased DB types of existing columns (types with parameters are also looked up without them: decimal(18,2) -> decimal)
+	SchemaTypes map[string]typing.DataType
+	//value ranges of numeric DB types which are limited (see schema.NumericBounds)
+	NumericBounds map[string]*schema.NumericBounds
+
+	//query of existing table columns (name, type) with schema name and table name parameters
+	TableSchemaQuery string
+	//create schema if it doesn't exist: %[1]s - schema name, %[2]s - quoted schema name
+	CreateSchemaTemplate string
+	//%s - quoted schema.table, %s - columns definitions
+	CreateTableTemplate string
+	//%s - quoted schema.table, %s - column definition
+	AddColumnTemplate string
+	//%s - quoted schema.table, %s - quoted column name
+	DropColumnTemplate string
+	//return upsert statement of one row into quoted schema.table with quoted columns and upsert keys and values placeholders
+	//destination doesn't support upsert if it is nil
+	UpsertStatement func(table string, columns, placeholders, upsertKeys []string) string
+}
+
+//GenericSQL is adapter for creating, patching (schema or table), inserting and deleting data in SQL databases
+//which are described with SQLDialect
+type GenericSQL struct {
+	ctx        context.Context
+	dialect    *SQLDialect
+	config     *DataSourceConfig
+	dataSource *sql.DB
+}
+
+//NewGenericSQL return configured GenericSQL adapter instance of the dialect database and check connection
+func NewGenericSQL(ctx context.Context, dialect *SQLDialect, config *DataSourceConfig) (*GenericSQL, error) {
+	dataSource, err := sql.Open(dialect.DriverName, dialect.ConnectionString(config))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dataSource.Ping(); err != nil {
+		dataSource.Close()
+		return nil, err
+	}
+
+	return &GenericSQL{ctx: ctx, dialect: dialect, config: config, dataSource: dataSource}, nil
+}
+
+func (g *GenericSQL) Name() string {
+	return g.dialect.Name
+}
+
+//Location return database and schema where tables are created
+func (g *GenericSQL) Location() (string, string) {
+	return g.config.Db, g.config.Schema
+}
+
+//OpenTx open underline sql transaction and return wrapped instance
+func (g *GenericSQL) OpenTx() (*Transaction, error) {
+	tx, err := g.dataSource.BeginTx(g.ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transaction{tx: tx, dbType: g.Name()}, nil
+}
+
+//CreateDbSchema create database schema instance if doesn't exist
+func (g *GenericSQL) CreateDbSchema(dbSchemaName string) error {
+	statement := fmt.Sprintf(g.dialect.CreateSchemaTemplate, dbSchemaName, g.dialect.Quote(dbSchemaName))
+	if _, err := g.dataSource.ExecContext(g.ctx, statement); err != nil {
+		return fmt.Errorf("Error creating [%s] db schema: %v", dbSchemaName, err)
+	}
+
+	return nil
+}
+
+//GetTableSchema return table (name,columns with name and types) representation wrapped in schema.Table struct
+func (g *GenericSQL) GetTableSchema(tableName string) (*schema.Table, error) {
+	table := &schema.Table{Name: tableName, Columns: schema.Columns{}}
+	rows, err := g.dataSource.QueryContext(g.ctx, g.dialect.TableSchemaQuery, g.config.Schema, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("Error querying table [%s] schema: %v", tableName, err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var columnName, columnDBType string
+		if err := rows.Scan(&columnName, &columnDBType); err != nil {
+			return nil, fmt.Errorf("Error scanning result: %v", err)
+		}
+		table.Columns[columnName] = g.column(columnDBType)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Last rows.Err: %v", err)
+	}
+
+	return table, nil
+}
+
+//NumericBounds return value range of the column which is created for the data type
+func (g *GenericSQL) NumericBounds(dataType typing.DataType) *schema.NumericBounds {
+	return g.dialect.NumericBounds[g.columnType(dataType)]
+}
+
+//CreateTable create database table with name,columns provided in schema.Table representation
+func (g *GenericSQL) CreateTable(tableSchema *schema.Table) error {
+	for _, statement := range g.CreateTableDDL(tableSchema) {
+		if _, err := g.dataSource.ExecContext(g.ctx, statement); err != nil {
+			return fmt.Errorf("Error creating [%s] table: %v", tableSchema.Name, err)
+		}
+	}
+
+	return nil
+}
+
+//PatchTableSchema add new columns(from provided schema.Table) to existing table in one transaction
+func (g *GenericSQL) PatchTableSchema(patchSchema *schema.Table) error {
+	wrappedTx, err := g.OpenTx()
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range g.PatchTableDDL(patchSchema) {
+		if _, err := wrappedTx.tx.ExecContext(g.ctx, statement); err != nil {
+			wrappedTx.Rollback()
+			return fmt.Errorf("Error patching %s table with statement [%s]: %v", patchSchema.Name, statement, err)
+		}
+	}
+
+	return wrappedTx.DirectCommit()
+}
+
+//CreateTableDDL return CREATE TABLE statement with columns sorted by name
+func (g *GenericSQL) CreateTableDDL(tableSchema *schema.Table) []string {
+	return []string{fmt.Sprintf(g.dialect.CreateTableTemplate, g.tableName(tableSchema.Name), strings.Join(g.columnsDDL(tableSchema), ","))}
+}
+
+//PatchTableDDL return ALTER TABLE statements of new columns sorted by name
+func (g *GenericSQL) PatchTableDDL(patchSchema *schema.Table) []string {
+	var statements []string
+	for _, columnDDL := range g.columnsDDL(patchSchema) {
+		statements = append(statements, fmt.Sprintf(g.dialect.AddColumnTemplate, g.tableName(patchSchema.Name), columnDDL))
+	}
+
+	return statements
+}
+
+//DropColumn drop the column of existing table (admin API only)
+func (g *GenericSQL) DropColumn(tableName, columnName string) error {
+	for _, statement := range g.DropColumnDDL(tableName, columnName) {
+		if _, err := g.dataSource.ExecContext(g.ctx, statement); err != nil {
+			return fmt.Errorf("Error dropping column [%s] of [%s] table with statement [%s]: %v", columnName, tableName, statement, err)
+		}
+	}
+
+	return nil
+}
+
+//DropColumnDDL return ALTER TABLE DROP COLUMN statement
+func (g *GenericSQL) DropColumnDDL(tableName, columnName string) []string {
+	return []string{fmt.Sprintf(g.dialect.DropColumnTemplate, g.tableName(tableName), g.dialect.Quote(columnName))}
+}
+
+//Insert provided object
+func (g *GenericSQL) Insert(table *schema.Table, valuesMap map[string]interface{}) error {
+	wrappedTx, err := g.OpenTx()
+	if err != nil {
+		return err
+	}
+
+	if err := g.InsertInTransaction(wrappedTx, table, valuesMap); err != nil {
+		wrappedTx.Rollback()
+		return err
+	}
+
+	return wrappedTx.DirectCommit()
+}
+
+//InsertInTransaction insert provided object (or upsert it if table has schema.UpsertKeys)
+func (g *GenericSQL) InsertInTransaction(wrappedTx *Transaction, table *schema.Table, valuesMap map[string]interface{}) error {
+	statement, values := g.insertStatement(table, valuesMap)
+	if _, err := wrappedTx.tx.ExecContext(g.ctx, statement, values...); err != nil {
+		return fmt.Errorf("Error inserting in %s table with statement: %s values: %v: %v", table.Name, statement, values, err)
+	}
+
+	return nil
+}
+
+//Delete rows with the same keys values as provided object has from table with schema.DeletionKeys
+func (g *GenericSQL) Delete(table *schema.Table, valuesMap map[string]interface{}) error {
+	wrappedTx, err := g.OpenTx()
+	if err != nil {
+		return err
+	}
+
+	if err := g.DeleteInTransaction(wrappedTx, table, valuesMap); err != nil {
+		wrappedTx.Rollback()
+		return err
+	}
+
+	return wrappedTx.DirectCommit()
+}
+
+func (g *GenericSQL) DeleteInTransaction(wrappedTx *Transaction, table *schema.Table, valuesMap map[string]interface{}) error {
+	statement, values := g.deleteStatement(table, valuesMap)
+	if _, err := wrappedTx.tx.ExecContext(g.ctx, statement, values...); err != nil {
+		return fmt.Errorf("Error deleting from %s table with keys: %v values: %v: %v", table.Name, table.DeletionKeys, values, err)
+	}
+
+	return nil
+}
+
+//Close underlying sql.DB
+func (g *GenericSQL) Close() error {
+	return g.dataSource.Close()
+}
+
+//return quoted schema.table
+func (g *GenericSQL) tableName(table string) string {
+	return g.dialect.Quote(g.config.Schema) + "." + g.dialect.Quote(table)
+}
+
+//return DB column type which is created for the data type (string one for unknown types)
+func (g *GenericSQL) columnType(dataType typing.DataType) string {
+	columnType, ok := g.dialect.ColumnTypes[dataType]
+	if !ok {
+		log.Printf("Unknown %s schema type: %s", g.dialect.Name, dataType)
+		return g.dialect.ColumnTypes[typing.STRING]
+	}
+
+	return columnType
+}
+
+//return column of existing DB type: types with parameters are looked up as is and without parameters
+//decimal types which aren't created for FLOAT64 type are DECIMAL columns (if dialect supports them). Unknown types are string ones
+func (g *GenericSQL) column(columnDBType string) schema.Column {
+	columnType := strings.ToLower(strings.TrimSpace(columnDBType))
+	if decimalType, ok := schema.ParseDecimalType(columnType); ok && g.dialect.DecimalTemplate != "" && g.dialect.NumericBounds[columnType] == nil {
+		return schema.NewDecimalColumn(decimalType, "")
+	}
+	dataType, ok := g.dialect.SchemaTypes[columnType]
+	if !ok {
+		if i := strings.Index(columnType, "("); i > 0 {
+			dataType, ok = g.dialect.SchemaTypes[strings.TrimSpace(columnType[:i])]
+		}
+	}
+	if !ok {
+		log.Printf("Unknown %s column type: %s", g.dialect.Name, columnDBType)
+		dataType = typing.STRING
+	}
+
+	return schema.NewBoundedColumn(dataType, g.dialect.NumericBounds[columnType])
+}
+
+//return sorted quoted columns definitions: "name type"
+func (g *GenericSQL) columnsDDL(table *schema.Table) []string {
+	var columnsDDL []string
+	for name, column := range table.Columns {
+		var columnType string
+		if decimal := column.Decimal(); decimal != nil && g.dialect.DecimalTemplate != "" {
+			columnType = fmt.Sprintf(g.dialect.DecimalTemplate, decimal.Precision, decimal.Scale)
+		} else {
+			columnType = g.columnType(column.GetType())
+		}
+		columnsDDL = append(columnsDDL, g.dialect.Quote(name)+" "+columnType)
+	}
+	sort.Strings(columnsDDL)
+
+	return columnsDDL
+}
+
+//return INSERT (or dialect upsert statement if table has upsert keys) with values in the same order as columns sorted by name
+func (g *GenericSQL) insertStatement(table *schema.Table, valuesMap map[string]interface{}) (string, []interface{}) {
+	var names []string
+	for name := range valuesMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var columns, placeholders []string
+	var values []interface{}
+	for i, name := range names {
+		columns = append(columns, g.dialect.Quote(name))
+		placeholders = append(placeholders, g.dialect.Placeholder(i+1))
+		values = append(values, valuesMap[name])
+	}
+
+	if len(table.UpsertKeys) > 0 && g.dialect.UpsertStatement != nil {
+		var keys []string
+		for _, key := range table.UpsertKeys {
+			keys = append(keys, g.dialect.Quote(key))
+		}
+		return g.dialect.UpsertStatement(g.tableName(table.Name), columns, placeholders, keys), values
+	}
+
+	return fmt.Sprintf(genericInsertTemplate, g.tableName(table.Name), strings.Join(columns, ","), strings.Join(placeholders, ",")), values
+}
+
+//return DELETE statement with keys condition and keys values
+func (g *GenericSQL) deleteStatement(table *schema.Table, valuesMap map[string]interface{}) (string, []interface{}) {
+	var conditions []string
+	var values []interface{}
+	for i, key := range table.DeletionKeys {
+		conditions = append(conditions, g.dialect.Quote(key)+"="+g.dialect.Placeholder(i+1))
+		values = append(values, valuesMap[key])
+	}
+
+	return fmt.Sprintf(genericDeleteTemplate, g.tableName(table.Name), strings.Join(conditions, " AND ")), values
+}
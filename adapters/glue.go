@@ -0,0 +1,296 @@
+package adapters
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/glue"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"log"
+	"sort"
+	"strings"
+)
+
+const (
+	glueTableType       = "EXTERNAL_TABLE"
+	glueInputFormat     = "org.apache.hadoop.mapred.TextInputFormat"
+	glueOutputFormat    = "org.apache.hadoop.hive.ql.io.HiveIgnoreKeyTextOutputFormat"
+	glueJSONSerDe       = "org.openx.data.jsonserde.JsonSerDe"
+	gluePartitionColumn = "string"
+)
+
+var (
+	//timestamps are stored as ISO 8601 strings in NDJSON files (use from_iso8601_timestamp() in Athena)
+	schemaToGlue = map[typing.DataType]string{
+		typing.STRING:    "string",
+		typing.INT64:     "bigint",
+		typing.FLOAT64:   "double",
+		typing.TIMESTAMP: "string",
+	}
+
+	glueToSchema = map[string]typing.DataType{
+		"string": typing.STRING,
+		"bigint": typing.INT64,
+		"double": typing.FLOAT64,
+	}
+)
+
+//GlueConfig dto for deserialized aws Glue data catalog config
+//aws credentials and region are taken from s3 config (region can be overridden)
+type GlueConfig struct {
+	Database  string `mapstructure:"database"`
+	CatalogID string `mapstructure:"catalog_id"`
+	Region    string `mapstructure:"region"`
+}
+
+//Validate required fields in GlueConfig
+func (gc *GlueConfig) Validate() error {
+	if gc == nil {
+		return errors.New("Glue config is required")
+	}
+	if gc.Database == "" {
+		return errors.New("Glue database is required parameter")
+	}
+
+	return nil
+}
+
+//Glue is adapter for creating,patching external NDJSON tables and registering their partitions in aws Glue data catalog
+//Tables are located in s3 bucket by path: s3://bucket/tablesPrefix/table_name/ and partitioned by partitionKeys (Hive style)
+type Glue struct {
+	config        *GlueConfig
+	client        *glue.Glue
+	bucket        string
+	tablesPrefix  string
+	partitionKeys []string
+}
+
+//NewGlue return configured Glue adapter instance
+func NewGlue(config *GlueConfig, s3Config *S3Config, tablesPrefix string, partitionKeys []string) (*Glue, error) {
+	region := config.Region
+	if region == "" {
+		region = s3Config.Region
+	}
+
+	awsConfig := aws.NewConfig().
+		WithCredentials(credentials.NewStaticCredentials(s3Config.AccessKeyID, s3Config.SecretKey, "")).
+		WithRegion(region)
+	glueSession, err := session.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("Error creating aws glue session: %v", err)
+	}
+
+	return &Glue{
+		config:        config,
+		client:        glue.New(glueSession, awsConfig),
+		bucket:        s3Config.Bucket,
+		tablesPrefix:  tablesPrefix,
+		partitionKeys: partitionKeys,
+	}, nil
+}
+
+func (Glue) Name() string {
+	return "Glue"
+}
+
+//GetTableSchema return table (name,columns with name and types) representation wrapped in schema.Table struct
+//return empty table if it doesn't exist
+func (g *Glue) GetTableSchema(tableName string) (*schema.Table, error) {
+	table := &schema.Table{Name: tableName, Columns: schema.Columns{}}
+	glueTable, err := g.getTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+	if glueTable == nil || glueTable.StorageDescriptor == nil {
+		return table, nil
+	}
+
+	for _, column := range glueTable.StorageDescriptor.Columns {
+		columnType := aws.StringValue(column.Type)
+		mappedType, ok := glueToSchema[columnType]
+		if !ok {
+			mappedType = typing.STRING
+		}
+		table.Columns[aws.StringValue(column.Name)] = schema.NewColumn(mappedType)
+	}
+	//partition keys are string columns
+	for _, column := range glueTable.PartitionKeys {
+		table.Columns[aws.StringValue(column.Name)] = schema.NewColumn(typing.STRING)
+	}
+
+	return table, nil
+}
+
+//CreateTable create external NDJSON table in Glue data catalog with columns provided in schema.Table representation
+func (g *Glue) CreateTable(tableSchema *schema.Table) error {
+	var partitionColumns []*glue.Column
+	for _, key := range g.partitionKeys {
+		partitionColumns = append(partitionColumns, &glue.Column{Name: aws.String(key), Type: aws.String(gluePartitionColumn)})
+	}
+
+	_, err := g.client.CreateTable(&glue.CreateTableInput{
+		CatalogId:    g.catalogID(),
+		DatabaseName: aws.String(g.config.Database),
+		TableInput: &glue.TableInput{
+			Name:      aws.String(tableSchema.Name),
+			TableType: aws.String(glueTableType),
+			StorageDescriptor: &glue.StorageDescriptor{
+				Columns:      g.columns(tableSchema.Columns),
+				Location:     aws.String(g.TableLocation(tableSchema.Name)),
+				InputFormat:  aws.String(glueInputFormat),
+				OutputFormat: aws.String(glueOutputFormat),
+				SerdeInfo:    &glue.SerDeInfo{SerializationLibrary: aws.String(glueJSONSerDe)},
+			},
+			PartitionKeys: partitionColumns,
+			Parameters:    map[string]*string{"classification": aws.String("json")},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("Error creating glue table [%s]: %v", tableSchema.Name, err)
+	}
+
+	return nil
+}
+
+//PatchTableSchema add new columns(from provided schema.Table) to existing Glue table
+func (g *Glue) PatchTableSchema(patchSchema *schema.Table) error {
+	glueTable, err := g.getTable(patchSchema.Name)
+	if err != nil {
+		return err
+	}
+	if glueTable == nil {
+		return fmt.Errorf("Glue table [%s] doesn't exist", patchSchema.Name)
+	}
+
+	newColumns := g.columns(patchSchema.Columns)
+	if len(newColumns) == 0 {
+		return nil
+	}
+
+	storageDescriptor := glueTable.StorageDescriptor
+	storageDescriptor.Columns = append(storageDescriptor.Columns, newColumns...)
+
+	_, err = g.client.UpdateTable(&glue.UpdateTableInput{
+		CatalogId:    g.catalogID(),
+		DatabaseName: aws.String(g.config.Database),
+		TableInput: &glue.TableInput{
+			Name:              glueTable.Name,
+			Description:       glueTable.Description,
+			Owner:             glueTable.Owner,
+			Retention:         glueTable.Retention,
+			TableType:         glueTable.TableType,
+			Parameters:        glueTable.Parameters,
+			PartitionKeys:     glueTable.PartitionKeys,
+			StorageDescriptor: storageDescriptor,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("Error patching glue table [%s] with columns %v: %v", patchSchema.Name, patchSchema.Columns.Header(), err)
+	}
+
+	return nil
+}
+
+//DestructiveChanges return nil: tables are created and columns are appended with Glue API, existing columns are never changed
+func (g *Glue) DestructiveChanges(tableSchema *schema.Table, patch bool) []string {
+	return nil
+}
+
+//CreatePartition register partition with values (in partition keys order) located by partitionPath in table location
+//do nothing if partition already exists
+func (g *Glue) CreatePartition(tableName, partitionPath string, values []string) error {
+	_, err := g.client.CreatePartition(&glue.CreatePartitionInput{
+		CatalogId:    g.catalogID(),
+		DatabaseName: aws.String(g.config.Database),
+		TableName:    aws.String(tableName),
+		PartitionInput: &glue.PartitionInput{
+			Values: aws.StringSlice(values),
+			StorageDescriptor: &glue.StorageDescriptor{
+				Location:     aws.String(g.TableLocation(tableName) + partitionPath + "/"),
+				InputFormat:  aws.String(glueInputFormat),
+				OutputFormat: aws.String(glueOutputFormat),
+				SerdeInfo:    &glue.SerDeInfo{SerializationLibrary: aws.String(glueJSONSerDe)},
+			},
+		},
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == glue.ErrCodeAlreadyExistsException {
+			return nil
+		}
+		return fmt.Errorf("Error creating glue table [%s] partition %s: %v", tableName, partitionPath, err)
+	}
+
+	return nil
+}
+
+//TableLocation return s3 location of table files
+func (g *Glue) TableLocation(tableName string) string {
+	return fmt.Sprintf("s3://%s/%s%s/", g.bucket, g.tablesPrefix, tableName)
+}
+
+//return glue table or nil if it doesn't exist
+func (g *Glue) getTable(tableName string) (*glue.TableData, error) {
+	output, err := g.client.GetTable(&glue.GetTableInput{
+		CatalogId:    g.catalogID(),
+		DatabaseName: aws.String(g.config.Database),
+		Name:         aws.String(tableName),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == glue.ErrCodeEntityNotFoundException {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Error getting glue table [%s]: %v", tableName, err)
+	}
+
+	return output.Table, nil
+}
+
+//return sorted glue columns without partition keys (they can't be table columns at the same time)
+func (g *Glue) columns(columns schema.Columns) []*glue.Column {
+	var names []string
+	for name := range columns {
+		if !g.isPartitionKey(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var glueColumns []*glue.Column
+	for _, name := range names {
+		glueColumns = append(glueColumns, &glue.Column{Name: aws.String(name), Type: aws.String(g.columnType(columns[name]))})
+	}
+
+	return glueColumns
+}
+
+func (g *Glue) isPartitionKey(name string) bool {
+	for _, key := range g.partitionKeys {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g *Glue) columnType(column schema.Column) string {
+	mappedType, ok := schemaToGlue[column.GetType()]
+	if !ok {
+		log.Println("Unknown glue schema type:", column.GetType().String())
+		mappedType = schemaToGlue[typing.STRING]
+	}
+
+	return mappedType
+}
+
+func (g *Glue) catalogID() *string {
+	if g.config.CatalogID == "" {
+		return nil
+	}
+
+	return aws.String(g.config.CatalogID)
+}
@@ -6,9 +6,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
+	"log"
+	"net"
+	"net/http"
 	"strings"
+	"time"
+)
+
+const (
+	uploadRetries   = 3
+	uploadRetryWait = time.Second
 )
 
 type GoogleCloudStorage struct {
@@ -39,32 +49,52 @@ func (gc *GoogleConfig) Validate(streamMode bool) error {
 		return errors.New("BigQuery project(bq_project) is required parameter")
 	}
 
-	switch gc.KeyFile.(type) {
+	return gc.validateKeyFile()
+}
+
+//ValidateStorage validate only google cloud storage parameters (without BigQuery ones)
+func (gc *GoogleConfig) ValidateStorage() error {
+	if gc == nil {
+		return errors.New("Google config is required")
+	}
+	if gc.Bucket == "" {
+		return errors.New("Google cloud storage bucket(gcs_bucket) is required parameter")
+	}
+
+	return gc.validateKeyFile()
+}
+
+//parse key_file and set credentials
+func (gc *GoogleConfig) validateKeyFile() (err error) {
+	gc.credentials, err = googleCredentials(gc.KeyFile)
+	return
+}
+
+//return google client credentials option from key_file value: json object, json string or file path
+func googleCredentials(keyFile interface{}) (option.ClientOption, error) {
+	switch keyFile.(type) {
 	case map[string]interface{}:
-		keyFileObject := gc.KeyFile.(map[string]interface{})
+		keyFileObject := keyFile.(map[string]interface{})
 		if len(keyFileObject) == 0 {
-			return errors.New("Google key_file is required parameter")
+			return nil, errors.New("Google key_file is required parameter")
 		}
 		b, err := json.Marshal(keyFileObject)
 		if err != nil {
-			return fmt.Errorf("Malformed google key_file: %v", err)
+			return nil, fmt.Errorf("Malformed google key_file: %v", err)
 		}
-		gc.credentials = option.WithCredentialsJSON(b)
+		return option.WithCredentialsJSON(b), nil
 	case string:
-		keyFile := gc.KeyFile.(string)
-		if keyFile == "" {
-			return errors.New("Google key file is required parameter")
+		keyFileStr := keyFile.(string)
+		if keyFileStr == "" {
+			return nil, errors.New("Google key file is required parameter")
 		}
-		if strings.Contains(keyFile, "{") {
-			gc.credentials = option.WithCredentialsJSON([]byte(keyFile))
-		} else {
-			gc.credentials = option.WithCredentialsFile(keyFile)
+		if strings.Contains(keyFileStr, "{") {
+			return option.WithCredentialsJSON([]byte(keyFileStr)), nil
 		}
+		return option.WithCredentialsFile(keyFileStr), nil
 	default:
-		return errors.New("Google key_file must be string or json object")
+		return nil, errors.New("Google key_file must be string or json object")
 	}
-
-	return nil
 }
 
 func NewGoogleCloudStorage(ctx context.Context, config *GoogleConfig) (*GoogleCloudStorage, error) {
@@ -77,17 +107,36 @@ func NewGoogleCloudStorage(ctx context.Context, config *GoogleConfig) (*GoogleCl
 }
 
 //Create named file on google cloud storage with payload
-func (gcs *GoogleCloudStorage) UploadBytes(fileName string, fileBytes []byte) error {
+//retry uploading with exponential backoff if google cloud storage returns transient error
+func (gcs *GoogleCloudStorage) UploadBytes(fileName string, fileBytes []byte) (err error) {
+	wait := uploadRetryWait
+	for i := 0; i <= uploadRetries; i++ {
+		if i > 0 {
+			log.Printf("Retrying uploading file %s to google cloud storage after transient error: %v", fileName, err)
+			time.Sleep(wait)
+			wait *= 2
+		}
+
+		err = gcs.uploadBytes(fileName, fileBytes)
+		if err == nil || !isTransientErr(err) {
+			return
+		}
+	}
+
+	return
+}
+
+func (gcs *GoogleCloudStorage) uploadBytes(fileName string, fileBytes []byte) error {
 	bucket := gcs.client.Bucket(gcs.config.Bucket)
 	object := bucket.Object(fileName)
 	w := object.NewWriter(gcs.ctx)
 
 	if _, err := w.Write(fileBytes); err != nil {
-		return fmt.Errorf("Error writing file to google cloud storage: %v", err)
+		return &uploadError{msg: "Error writing file to google cloud storage", err: err}
 	}
 
 	if err := w.Close(); err != nil {
-		return fmt.Errorf("Error closing file writer to google cloud storage: %v", err)
+		return &uploadError{msg: "Error closing file writer to google cloud storage", err: err}
 	}
 
 	return nil
@@ -124,6 +173,30 @@ func (gcs *GoogleCloudStorage) DeleteObject(key string) error {
 	return nil
 }
 
+//uploadError keeps underlying error for checking if it is transient
+type uploadError struct {
+	msg string
+	err error
+}
+
+func (ue *uploadError) Error() string {
+	return fmt.Sprintf("%s: %v", ue.msg, ue.err)
+}
+
+//Return true if err is google api 429, 5xx error or network error
+func isTransientErr(err error) bool {
+	if ue, ok := err.(*uploadError); ok {
+		err = ue.err
+	}
+
+	if e, ok := err.(*googleapi.Error); ok {
+		return e.Code == http.StatusTooManyRequests || e.Code >= http.StatusInternalServerError
+	}
+
+	_, ok := err.(net.Error)
+	return ok
+}
+
 func (gcs *GoogleCloudStorage) Close() error {
 	if err := gcs.client.Close(); err != nil {
 		return fmt.Errorf("Error closing google cloud storage client: %v", err)
@@ -0,0 +1,271 @@
+package adapters
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/Shopify/sarama"
+	"github.com/xdg/scram"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	kafkaAcksAll    = "all"
+	kafkaAcksLeader = "leader"
+	kafkaAcksNone   = "none"
+
+	kafkaClientID = "eventnative"
+
+	KafkaJSONFormat     = "json"
+	KafkaAvroFormat     = "avro"
+	KafkaProtobufFormat = "protobuf"
+
+	//protobuf message of events which event types don't have own messages
+	KafkaProtobufDefaultMessage = "*"
+)
+
+var kafkaAcks = map[string]sarama.RequiredAcks{
+	kafkaAcksAll:    sarama.WaitForAll,
+	kafkaAcksLeader: sarama.WaitForLocal,
+	kafkaAcksNone:   sarama.NoResponse,
+}
+
+//KafkaConfig dto for deserialized Kafka destination config
+//topic_template: topic name with {table} placeholder e.g. events_{table}
+//partition_key: event_id, user_id or any flattened event field name. Messages are distributed randomly if empty
+//format: json (default), avro (see AvroConfig, schema registry is required) or protobuf (see KafkaProtobufConfig)
+type KafkaConfig struct {
+	Brokers       []string             `mapstructure:"brokers"`
+	TopicTemplate string               `mapstructure:"topic_template"`
+	PartitionKey  string               `mapstructure:"partition_key"`
+	Acks          string               `mapstructure:"acks"`
+	SASL          *KafkaSASLConfig     `mapstructure:"sasl"`
+	TLS           *KafkaTLSConfig      `mapstructure:"tls"`
+	Format        string               `mapstructure:"format"`
+	Avro          *AvroConfig          `mapstructure:"avro"`
+	Protobuf      *KafkaProtobufConfig `mapstructure:"protobuf"`
+}
+
+//KafkaSASLConfig dto for deserialized Kafka SASL auth config
+//mechanism: PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512
+type KafkaSASLConfig struct {
+	Mechanism string `mapstructure:"mechanism"`
+	Username  string `mapstructure:"username"`
+	Password  string `mapstructure:"password"`
+}
+
+//KafkaProtobufConfig dto for deserialized Kafka protobuf config
+//descriptor_set: path to FileDescriptorSet file with all imports (protoc --include_imports --descriptor_set_out=...)
+//messages: event type -> fully-qualified message name. * is a message of other event types (events without message are skipped)
+type KafkaProtobufConfig struct {
+	DescriptorSet string            `mapstructure:"descriptor_set"`
+	Messages      map[string]string `mapstructure:"messages"`
+}
+
+//KafkaTLSConfig dto for deserialized Kafka TLS config
+type KafkaTLSConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	CAFile             string `mapstructure:"ca_file"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+//Validate required fields in KafkaConfig and set default values
+func (kc *KafkaConfig) Validate() error {
+	if kc == nil {
+		return errors.New("Kafka config is required")
+	}
+	if len(kc.Brokers) == 0 {
+		return errors.New("Kafka brokers is required parameter")
+	}
+	if kc.Acks != "" {
+		if _, ok := kafkaAcks[kc.Acks]; !ok {
+			return fmt.Errorf("Unknown Kafka acks value: %s. Available values: [%s, %s, %s]", kc.Acks, kafkaAcksAll, kafkaAcksLeader, kafkaAcksNone)
+		}
+	}
+	if kc.SASL != nil {
+		switch strings.ToUpper(kc.SASL.Mechanism) {
+		case "", sarama.SASLTypePlaintext, sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512:
+		default:
+			return fmt.Errorf("Unknown Kafka sasl mechanism: %s. Available mechanisms: [%s, %s, %s]", kc.SASL.Mechanism,
+				sarama.SASLTypePlaintext, sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512)
+		}
+		if kc.SASL.Username == "" {
+			return errors.New("Kafka sasl username is required parameter")
+		}
+	}
+
+	switch kc.Format {
+	case "":
+		kc.Format = KafkaJSONFormat
+	case KafkaJSONFormat:
+	case KafkaAvroFormat:
+		if kc.Avro == nil || kc.Avro.SchemaRegistry == nil {
+			return errors.New("Kafka avro.schema_registry is required parameter if format is avro")
+		}
+		if err := kc.Avro.Validate(); err != nil {
+			return err
+		}
+	case KafkaProtobufFormat:
+		if kc.Protobuf == nil || kc.Protobuf.DescriptorSet == "" {
+			return errors.New("Kafka protobuf.descriptor_set is required parameter if format is protobuf")
+		}
+		if len(kc.Protobuf.Messages) == 0 {
+			return errors.New("Kafka protobuf.messages is required parameter if format is protobuf")
+		}
+	default:
+		return fmt.Errorf("Unknown Kafka format: %s. Available: [%s, %s, %s]", kc.Format, KafkaJSONFormat, KafkaAvroFormat, KafkaProtobufFormat)
+	}
+
+	return nil
+}
+
+//KafkaMessage is a message payload with topic and partitioning key
+//message without payload is a tombstone (null value)
+type KafkaMessage struct {
+	Topic   string
+	Key     string
+	Payload []byte
+}
+
+//Kafka is adapter for publishing messages to Kafka topics via sync producer
+type Kafka struct {
+	producer sarama.SyncProducer
+}
+
+//NewKafka return configured Kafka adapter instance
+func NewKafka(config *KafkaConfig) (*Kafka, error) {
+	saramaConfig, err := NewSaramaConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	producer, err := sarama.NewSyncProducer(config.Brokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating Kafka producer: %v", err)
+	}
+
+	return &Kafka{producer: producer}, nil
+}
+
+//NewSaramaConfig return sarama client config with acks, SASL and TLS settings from KafkaConfig
+func NewSaramaConfig(config *KafkaConfig) (*sarama.Config, error) {
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.ClientID = kafkaClientID
+	saramaConfig.Producer.Return.Successes = true
+	if config.Acks != "" {
+		saramaConfig.Producer.RequiredAcks = kafkaAcks[config.Acks]
+	}
+
+	if config.SASL != nil {
+		saramaConfig.Net.SASL.Enable = true
+		saramaConfig.Net.SASL.User = config.SASL.Username
+		saramaConfig.Net.SASL.Password = config.SASL.Password
+
+		switch strings.ToUpper(config.SASL.Mechanism) {
+		case sarama.SASLTypeSCRAMSHA256:
+			saramaConfig.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+			saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+				return &scramClient{hashGenerator: sha256.New}
+			}
+		case sarama.SASLTypeSCRAMSHA512:
+			saramaConfig.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+			saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+				return &scramClient{hashGenerator: sha512.New}
+			}
+		default:
+			saramaConfig.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		}
+	}
+
+	if config.TLS != nil && config.TLS.Enabled {
+		tlsConfig := &tls.Config{InsecureSkipVerify: config.TLS.InsecureSkipVerify}
+		if config.TLS.CAFile != "" {
+			caCert, err := ioutil.ReadFile(config.TLS.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("Error reading Kafka tls ca_file [%s]: %v", config.TLS.CAFile, err)
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+				return nil, fmt.Errorf("Error parsing Kafka tls ca_file [%s]: no certificates found", config.TLS.CAFile)
+			}
+		}
+
+		saramaConfig.Net.TLS.Enable = true
+		saramaConfig.Net.TLS.Config = tlsConfig
+	}
+
+	return saramaConfig, nil
+}
+
+func (Kafka) Name() string {
+	return "Kafka"
+}
+
+//Send publish one message and wait for acks
+func (k *Kafka) Send(message *KafkaMessage) error {
+	if _, _, err := k.producer.SendMessage(toProducerMessage(message)); err != nil {
+		return fmt.Errorf("Error sending message to Kafka topic [%s]: %v", message.Topic, err)
+	}
+
+	return nil
+}
+
+//SendBatch publish messages and wait for acks of all of them
+func (k *Kafka) SendBatch(messages []*KafkaMessage) error {
+	var producerMessages []*sarama.ProducerMessage
+	for _, message := range messages {
+		producerMessages = append(producerMessages, toProducerMessage(message))
+	}
+
+	if err := k.producer.SendMessages(producerMessages); err != nil {
+		return fmt.Errorf("Error sending %d messages to Kafka: %v", len(messages), err)
+	}
+
+	return nil
+}
+
+//Close underlying producer
+func (k *Kafka) Close() error {
+	return k.producer.Close()
+}
+
+//empty key means random partition
+func toProducerMessage(message *KafkaMessage) *sarama.ProducerMessage {
+	producerMessage := &sarama.ProducerMessage{Topic: message.Topic}
+	if message.Payload != nil {
+		producerMessage.Value = sarama.ByteEncoder(message.Payload)
+	}
+	if message.Key != "" {
+		producerMessage.Key = sarama.StringEncoder(message.Key)
+	}
+
+	return producerMessage
+}
+
+//scramClient is sarama.SCRAMClient implementation for SCRAM-SHA-256/512 SASL auth
+type scramClient struct {
+	hashGenerator scram.HashGeneratorFcn
+	conversation  *scram.ClientConversation
+}
+
+func (sc *scramClient) Begin(userName, password, authzID string) error {
+	client, err := sc.hashGenerator.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+
+	sc.conversation = client.NewConversation()
+	return nil
+}
+
+func (sc *scramClient) Step(challenge string) (string, error) {
+	return sc.conversation.Step(challenge)
+}
+
+func (sc *scramClient) Done() bool {
+	return sc.conversation.Done()
+}
@@ -0,0 +1,156 @@
+package adapters
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"log"
+	"time"
+)
+
+const (
+	//aws Kinesis PutRecords limits
+	KinesisMaxBatchRecords = 500
+	kinesisMaxBatchBytes   = 5 * 1024 * 1024
+
+	kinesisRetries   = 5
+	kinesisRetryWait = 100 * time.Millisecond
+)
+
+//KinesisConfig dto for deserialized aws Kinesis Data Streams destination config
+//partition_key: event field for records partition key. Random key is used if it is empty or event doesn't have the field
+type KinesisConfig struct {
+	AccessKeyID  string        `mapstructure:"access_key_id"`
+	SecretKey    string        `mapstructure:"secret_access_key"`
+	Region       string        `mapstructure:"region"`
+	Endpoint     string        `mapstructure:"endpoint"`
+	Stream       string        `mapstructure:"stream"`
+	PartitionKey string        `mapstructure:"partition_key"`
+	FlushEvery   time.Duration `mapstructure:"flush_every"`
+}
+
+//Validate required fields in KinesisConfig
+func (kc *KinesisConfig) Validate() error {
+	if kc == nil {
+		return errors.New("Kinesis config is required")
+	}
+	if kc.AccessKeyID == "" {
+		return errors.New("Kinesis access_key_id is required parameter")
+	}
+	if kc.SecretKey == "" {
+		return errors.New("Kinesis secret_access_key is required parameter")
+	}
+	if kc.Region == "" {
+		return errors.New("Kinesis region is required parameter")
+	}
+	if kc.Stream == "" {
+		return errors.New("Kinesis stream is required parameter")
+	}
+	if kc.FlushEvery < 0 {
+		return errors.New("Kinesis flush_every can't be negative")
+	}
+
+	return nil
+}
+
+//KinesisRecord is a record payload with partition key
+type KinesisRecord struct {
+	PartitionKey string
+	Data         []byte
+}
+
+//kinesisClient is the part of aws Kinesis client which is used by the adapter
+type kinesisClient interface {
+	PutRecords(input *kinesis.PutRecordsInput) (*kinesis.PutRecordsOutput, error)
+}
+
+//Kinesis is adapter for putting records into aws Kinesis data stream
+type Kinesis struct {
+	config    *KinesisConfig
+	client    kinesisClient
+	retryWait time.Duration
+}
+
+//NewKinesis return configured Kinesis adapter instance
+func NewKinesis(config *KinesisConfig) (*Kinesis, error) {
+	awsConfig := aws.NewConfig().
+		WithCredentials(credentials.NewStaticCredentials(config.AccessKeyID, config.SecretKey, "")).
+		WithRegion(config.Region)
+	if config.Endpoint != "" {
+		awsConfig.WithEndpoint(config.Endpoint)
+	}
+	kinesisSession, err := session.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("Error creating aws kinesis session: %v", err)
+	}
+
+	return &Kinesis{config: config, client: kinesis.New(kinesisSession, awsConfig), retryWait: kinesisRetryWait}, nil
+}
+
+func (Kinesis) Name() string {
+	return "Kinesis"
+}
+
+//PutRecords put records into stream with PutRecords requests (split by aws limits)
+func (k *Kinesis) PutRecords(records []*KinesisRecord) error {
+	var batch []*kinesis.PutRecordsRequestEntry
+	batchBytes := 0
+	for _, record := range records {
+		recordBytes := len(record.Data) + len(record.PartitionKey)
+		if len(batch) == KinesisMaxBatchRecords || (len(batch) > 0 && batchBytes+recordBytes > kinesisMaxBatchBytes) {
+			if err := k.putBatch(batch); err != nil {
+				return err
+			}
+			batch = nil
+			batchBytes = 0
+		}
+
+		batch = append(batch, &kinesis.PutRecordsRequestEntry{PartitionKey: aws.String(record.PartitionKey), Data: record.Data})
+		batchBytes += recordBytes
+	}
+
+	if len(batch) > 0 {
+		return k.putBatch(batch)
+	}
+
+	return nil
+}
+
+//put batch and retry failed records (e.g. throttled with ProvisionedThroughputExceededException) with exponential backoff
+//aws sdk retries throttled requests itself but partially failed PutRecords responses must be retried by client
+func (k *Kinesis) putBatch(entries []*kinesis.PutRecordsRequestEntry) error {
+	wait := k.retryWait
+	for i := 0; ; i++ {
+		output, err := k.client.PutRecords(&kinesis.PutRecordsInput{StreamName: aws.String(k.config.Stream), Records: entries})
+		if err != nil {
+			return fmt.Errorf("Error putting %d records to kinesis stream [%s]: %v", len(entries), k.config.Stream, err)
+		}
+
+		failedCount := aws.Int64Value(output.FailedRecordCount)
+		if failedCount == 0 {
+			return nil
+		}
+
+		//response records are in the same order as request ones
+		var failed []*kinesis.PutRecordsRequestEntry
+		var lastErr string
+		for j, result := range output.Records {
+			if result.ErrorCode != nil {
+				failed = append(failed, entries[j])
+				lastErr = aws.StringValue(result.ErrorCode) + ": " + aws.StringValue(result.ErrorMessage)
+			}
+		}
+
+		if i == kinesisRetries {
+			return fmt.Errorf("Error putting %d records to kinesis stream [%s] after %d retries. Last error: %s", len(failed), k.config.Stream, i, lastErr)
+		}
+
+		log.Printf("%d records weren't put to kinesis stream [%s]: %s. They will be retried in %s", failedCount, k.config.Stream, lastErr, wait)
+		time.Sleep(wait)
+		wait *= 2
+		entries = failed
+	}
+}
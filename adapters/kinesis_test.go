@@ -0,0 +1,90 @@
+package adapters
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const throttlingErrorCode = "ProvisionedThroughputExceededException"
+
+//kinesisClient mock: records are throttled by data the configured number of times and other ones are put
+type kinesisClientMock struct {
+	throttled map[string]int
+	err       error
+
+	calls   []time.Time
+	batches [][]string
+}
+
+func (kcm *kinesisClientMock) PutRecords(input *kinesis.PutRecordsInput) (*kinesis.PutRecordsOutput, error) {
+	kcm.calls = append(kcm.calls, time.Now())
+	if kcm.err != nil {
+		return nil, kcm.err
+	}
+
+	var batch []string
+	var failed int64
+	output := &kinesis.PutRecordsOutput{}
+	for _, entry := range input.Records {
+		data := string(entry.Data)
+		batch = append(batch, data)
+		if kcm.throttled[data] > 0 {
+			kcm.throttled[data]--
+			failed++
+			output.Records = append(output.Records, &kinesis.PutRecordsResultEntry{ErrorCode: aws.String(throttlingErrorCode),
+				ErrorMessage: aws.String("Rate exceeded for shard shardId-000000000000")})
+		} else {
+			output.Records = append(output.Records, &kinesis.PutRecordsResultEntry{SequenceNumber: aws.String("1"), ShardId: aws.String("shardId-000000000000")})
+		}
+	}
+	kcm.batches = append(kcm.batches, batch)
+	output.FailedRecordCount = aws.Int64(failed)
+
+	return output, nil
+}
+
+func TestKinesisPutRecordsThrottling(t *testing.T) {
+	records := []*KinesisRecord{{PartitionKey: "1", Data: []byte("a")}, {PartitionKey: "2", Data: []byte("b")}, {PartitionKey: "3", Data: []byte("c")}}
+	retryWait := 20 * time.Millisecond
+
+	//throttled records are retried with exponential backoff
+	client := &kinesisClientMock{throttled: map[string]int{"a": 1, "c": 2}}
+	k := &Kinesis{config: &KinesisConfig{Stream: "events"}, client: client, retryWait: retryWait}
+	require.NoError(t, k.PutRecords(records))
+
+	require.Equal(t, [][]string{{"a", "b", "c"}, {"a", "c"}, {"c"}}, client.batches)
+	require.True(t, client.calls[1].Sub(client.calls[0]) >= retryWait)
+	require.True(t, client.calls[2].Sub(client.calls[1]) >= 2*retryWait)
+
+	//error after all retries
+	client = &kinesisClientMock{throttled: map[string]int{"b": kinesisRetries + 1}}
+	k = &Kinesis{config: &KinesisConfig{Stream: "events"}, client: client, retryWait: time.Millisecond}
+	require.EqualError(t, k.PutRecords(records), "Error putting 1 records to kinesis stream [events] after 5 retries. "+
+		"Last error: ProvisionedThroughputExceededException: Rate exceeded for shard shardId-000000000000")
+	require.Len(t, client.calls, kinesisRetries+1)
+
+	//request errors aren't retried by the adapter (aws sdk retries them)
+	client = &kinesisClientMock{err: errors.New("stream not found")}
+	k = &Kinesis{config: &KinesisConfig{Stream: "events"}, client: client, retryWait: time.Millisecond}
+	require.EqualError(t, k.PutRecords(records), "Error putting 3 records to kinesis stream [events]: stream not found")
+	require.Len(t, client.calls, 1)
+}
+
+func TestKinesisPutRecordsBatches(t *testing.T) {
+	var records []*KinesisRecord
+	for i := 0; i < KinesisMaxBatchRecords+1; i++ {
+		records = append(records, &KinesisRecord{PartitionKey: "key", Data: []byte("a")})
+	}
+
+	client := &kinesisClientMock{}
+	k := &Kinesis{config: &KinesisConfig{Stream: "events"}, client: client, retryWait: time.Millisecond}
+	require.NoError(t, k.PutRecords(records))
+
+	require.Len(t, client.batches, 2)
+	require.Len(t, client.batches[0], KinesisMaxBatchRecords)
+	require.Len(t, client.batches[1], 1)
+}
@@ -0,0 +1,34 @@
+package adapters
+
+import "time"
+
+//predefined aggregate queries of the metrics API
+const (
+	//events count per hour and event type
+	EventsPerHourMetric = "events_per_hour"
+	//distinct users count per day
+	UniquesPerDayMetric = "uniques_per_day"
+)
+
+//Metrics are available metrics names
+var Metrics = []string{EventsPerHourMetric, UniquesPerDayMetric}
+
+//MetricQuery is parameters of predefined aggregate query
+//columns are put into the query as is. Only Since is passed as a query parameter
+type MetricQuery struct {
+	Metric          string
+	Table           string
+	TimeColumn      string
+	EventTypeColumn string
+	UserIDColumn    string
+	Since           time.Time
+}
+
+//return column which is aggregated or grouped by in addition to time column
+func (mq *MetricQuery) column() string {
+	if mq.Metric == UniquesPerDayMetric {
+		return mq.UserIDColumn
+	}
+
+	return mq.EventTypeColumn
+}
@@ -0,0 +1,227 @@
+package adapters
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	MixpanelGzip = "gzip"
+
+	defaultMixpanelEndpoint = "https://api.mixpanel.com"
+	defaultMixpanelTimeout  = 30 * time.Second
+
+	//Mixpanel API limits: events per request of /import and /track endpoints
+	MixpanelMaxImportBatchSize = 2000
+	MixpanelMaxTrackBatchSize  = 50
+
+	mixpanelRetries   = 3
+	mixpanelRetryWait = time.Second
+)
+
+//MixpanelConfig dto for deserialized Mixpanel destination config
+//token: project token which is sent in every event properties
+//api_secret: if provided, events are sent to /import endpoint with basic auth (historical events are accepted)
+//otherwise to /track endpoint (only events of the last 5 days are accepted)
+//distinct_id_field, event_field: flattened event fields which are sent as Mixpanel distinct_id and event name
+//other event fields are sent as properties
+type MixpanelConfig struct {
+	Token           string        `mapstructure:"token"`
+	APISecret       string        `mapstructure:"api_secret"`
+	Endpoint        string        `mapstructure:"endpoint"`
+	DistinctIDField string        `mapstructure:"distinct_id_field"`
+	EventField      string        `mapstructure:"event_field"`
+	Compression     string        `mapstructure:"compression"`
+	BatchSize       int           `mapstructure:"batch_size"`
+	FlushEvery      time.Duration `mapstructure:"flush_every"`
+	Timeout         time.Duration `mapstructure:"timeout"`
+}
+
+//Validate required fields in MixpanelConfig
+func (mc *MixpanelConfig) Validate() error {
+	if mc == nil {
+		return errors.New("Mixpanel config is required")
+	}
+	if mc.Token == "" {
+		return errors.New("Mixpanel token is required parameter")
+	}
+	if mc.Compression != "" && mc.Compression != MixpanelGzip {
+		return fmt.Errorf("Unknown Mixpanel compression: %s. Supported: %s", mc.Compression, MixpanelGzip)
+	}
+	if mc.BatchSize < 0 || mc.FlushEvery < 0 || mc.Timeout < 0 {
+		return errors.New("Mixpanel batch_size, flush_every and timeout can't be negative")
+	}
+	if mc.BatchSize > mc.MaxBatchSize() {
+		return fmt.Errorf("Mixpanel batch_size can't be greater than %d", mc.MaxBatchSize())
+	}
+
+	return nil
+}
+
+//MaxBatchSize return events limit per request of the endpoint (/import if api_secret is provided, otherwise /track)
+func (mc *MixpanelConfig) MaxBatchSize() int {
+	if mc.APISecret != "" {
+		return MixpanelMaxImportBatchSize
+	}
+
+	return MixpanelMaxTrackBatchSize
+}
+
+//MixpanelEvent is an event in Mixpanel HTTP API format
+type MixpanelEvent struct {
+	Event      string                 `json:"event"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+//response body of /track?verbose=1 and /import endpoints
+type mixpanelResponse struct {
+	Status interface{} `json:"status"`
+	Error  string      `json:"error"`
+}
+
+//Mixpanel is adapter for sending events to Mixpanel /import or /track API
+type Mixpanel struct {
+	token     string
+	apiSecret string
+	url       string
+	gzip      bool
+	client    *http.Client
+}
+
+//NewMixpanel return configured Mixpanel adapter instance
+func NewMixpanel(config *MixpanelConfig) *Mixpanel {
+	endpoint := strings.TrimSuffix(config.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = defaultMixpanelEndpoint
+	}
+	url := endpoint + "/track?verbose=1&ip=0"
+	if config.APISecret != "" {
+		url = endpoint + "/import?strict=1"
+	}
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = defaultMixpanelTimeout
+	}
+
+	return &Mixpanel{
+		token:     config.Token,
+		apiSecret: config.APISecret,
+		url:       url,
+		gzip:      config.Compression == MixpanelGzip,
+		client:    &http.Client{Timeout: timeout},
+	}
+}
+
+func (Mixpanel) Name() string {
+	return "Mixpanel"
+}
+
+//Send events with project token in one request with retries on network errors, 429 and 5xx responses
+//events are split in halves if request payload is too large
+func (m *Mixpanel) Send(events []*MixpanelEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	for _, event := range events {
+		event.Properties["token"] = m.token
+	}
+
+	body, err := m.marshal(events)
+	if err != nil {
+		return err
+	}
+
+	wait := mixpanelRetryWait
+	for i := 1; ; i++ {
+		code, err := m.doRequest(body)
+		if err == nil {
+			return nil
+		}
+
+		if code == http.StatusRequestEntityTooLarge && len(events) > 1 {
+			if err := m.Send(events[:len(events)/2]); err != nil {
+				return err
+			}
+			return m.Send(events[len(events)/2:])
+		}
+
+		retry := code == 0 || code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+		if !retry || i >= mixpanelRetries {
+			return fmt.Errorf("Error sending %d events to Mixpanel: %v", len(events), err)
+		}
+
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+//return JSON array of events (gzipped if compression is configured)
+func (m *Mixpanel) marshal(events []*MixpanelEvent) ([]byte, error) {
+	b, err := json.Marshal(events)
+	if err != nil {
+		return nil, err
+	}
+	if !m.gzip {
+		return b, nil
+	}
+
+	buf := &bytes.Buffer{}
+	writer := gzip.NewWriter(buf)
+	if _, err := writer.Write(b); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+//return response code (0 on network errors) and error if request wasn't successful
+//Mixpanel can respond 200 with status 0 (track endpoint) if events weren't accepted
+func (m *Mixpanel) doRequest(body []byte) (int, error) {
+	request, err := http.NewRequest(http.MethodPost, m.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	if m.gzip {
+		request.Header.Set("Content-Encoding", MixpanelGzip)
+	}
+	if m.apiSecret != "" {
+		request.SetBasicAuth(m.apiSecret, "")
+	}
+
+	response, err := m.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	responseBody, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+	if response.StatusCode != http.StatusOK {
+		return response.StatusCode, fmt.Errorf("response code: %d body: %s", response.StatusCode, string(responseBody))
+	}
+
+	mr := &mixpanelResponse{}
+	if err := json.Unmarshal(responseBody, mr); err == nil && (mr.Status == float64(0) || mr.Error != "") {
+		return response.StatusCode, fmt.Errorf("events weren't accepted: %s", string(responseBody))
+	}
+
+	return response.StatusCode, nil
+}
+
+func (m *Mixpanel) Close() error {
+	m.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,81 @@
+package adapters
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMixpanelSend(t *testing.T) {
+	tests := []struct {
+		name            string
+		config          *MixpanelConfig
+		maxEvents       int
+		response        string
+		expectedPath    string
+		expectedBatches []int
+		expectedErr     bool
+	}{
+		{"Track", &MixpanelConfig{Token: "token1"}, 10, `{"status":1,"error":null}`, "/track", []int{4}, false},
+		{"Import with gzip", &MixpanelConfig{Token: "token1", APISecret: "secret1", Compression: MixpanelGzip}, 10, `{"code":200,"status":"OK"}`, "/import", []int{4}, false},
+		{"Split too large payload", &MixpanelConfig{Token: "token1"}, 1, `{"status":1}`, "/track", []int{1, 1, 1, 1}, false},
+		{"Not accepted events", &MixpanelConfig{Token: "token1"}, 10, `{"status":0,"error":"some data fields are invalid"}`, "/track", []int{4}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var batches []int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, tt.expectedPath, r.URL.Path)
+				username, _, ok := r.BasicAuth()
+				require.Equal(t, tt.config.APISecret != "", ok)
+				require.Equal(t, tt.config.APISecret, username)
+
+				var body io.Reader = r.Body
+				if r.Header.Get("Content-Encoding") == MixpanelGzip {
+					reader, err := gzip.NewReader(r.Body)
+					require.NoError(t, err)
+					body = reader
+				}
+				var events []*MixpanelEvent
+				require.NoError(t, json.NewDecoder(body).Decode(&events))
+				for _, event := range events {
+					require.Equal(t, "token1", event.Properties["token"])
+				}
+				if len(events) > tt.maxEvents {
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+					return
+				}
+
+				batches = append(batches, len(events))
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			tt.config.Endpoint = server.URL
+			require.NoError(t, tt.config.Validate())
+
+			var events []*MixpanelEvent
+			for _, name := range []string{"a", "b", "c", "d"} {
+				events = append(events, &MixpanelEvent{Event: name, Properties: map[string]interface{}{"distinct_id": "user1"}})
+			}
+			err := NewMixpanel(tt.config).Send(events)
+			if tt.expectedErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.expectedBatches, batches)
+		})
+	}
+}
+
+func TestMixpanelConfigValidate(t *testing.T) {
+	require.EqualError(t, (&MixpanelConfig{}).Validate(), "Mixpanel token is required parameter")
+	require.EqualError(t, (&MixpanelConfig{Token: "token1", Compression: "zstd"}).Validate(), "Unknown Mixpanel compression: zstd. Supported: gzip")
+	require.EqualError(t, (&MixpanelConfig{Token: "token1", BatchSize: 100}).Validate(), "Mixpanel batch_size can't be greater than 50")
+	require.NoError(t, (&MixpanelConfig{Token: "token1", APISecret: "secret1", BatchSize: 100}).Validate())
+}
@@ -0,0 +1,201 @@
+package adapters
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"strings"
+	"time"
+)
+
+const (
+	natsClientName = "eventnative"
+
+	defaultNATSAckTimeout = 5 * time.Second
+	defaultNATSMaxPending = 256
+
+	natsRetries   = 3
+	natsRetryWait = 100 * time.Millisecond
+)
+
+//NATSConfig dto for deserialized NATS JetStream destination config
+//subject_template: subject with {table} placeholder e.g. events.{table}. Subjects must be bound to a JetStream stream
+//msg_id_field: flattened event field which is used as Nats-Msg-Id header, so retried messages are deduplicated by the stream
+//credentials: token, username and password or creds_file (user JWT and NKey seed)
+type NATSConfig struct {
+	Servers         []string      `mapstructure:"servers"`
+	SubjectTemplate string        `mapstructure:"subject_template"`
+	MsgIDField      string        `mapstructure:"msg_id_field"`
+	Token           string        `mapstructure:"token"`
+	Username        string        `mapstructure:"username"`
+	Password        string        `mapstructure:"password"`
+	CredsFile       string        `mapstructure:"creds_file"`
+	AckTimeout      time.Duration `mapstructure:"ack_timeout"`
+	MaxPending      int           `mapstructure:"max_pending"`
+}
+
+//Validate required fields in NATSConfig and set default values
+func (nc *NATSConfig) Validate() error {
+	if nc == nil {
+		return errors.New("NATS config is required")
+	}
+	if len(nc.Servers) == 0 {
+		return errors.New("NATS servers is required parameter")
+	}
+	if nc.AckTimeout < 0 {
+		return errors.New("NATS ack_timeout can't be negative")
+	}
+	if nc.MaxPending < 0 {
+		return errors.New("NATS max_pending can't be negative")
+	}
+
+	if nc.AckTimeout == 0 {
+		nc.AckTimeout = defaultNATSAckTimeout
+	}
+	if nc.MaxPending == 0 {
+		nc.MaxPending = defaultNATSMaxPending
+	}
+
+	return nil
+}
+
+//NATSMessage is a message payload with subject and optional deduplication id
+type NATSMessage struct {
+	Subject string
+	MsgID   string
+	Payload []byte
+}
+
+//NATS is adapter for publishing messages to NATS JetStream with acknowledgements (at-least-once)
+type NATS struct {
+	conn       *nats.Conn
+	js         nats.JetStreamContext
+	ackTimeout time.Duration
+}
+
+//NewNATS return configured NATS adapter instance connected to servers
+func NewNATS(config *NATSConfig) (*NATS, error) {
+	options := []nats.Option{nats.Name(natsClientName), nats.MaxReconnects(-1)}
+	switch {
+	case config.CredsFile != "":
+		options = append(options, nats.UserCredentials(config.CredsFile))
+	case config.Token != "":
+		options = append(options, nats.Token(config.Token))
+	case config.Username != "":
+		options = append(options, nats.UserInfo(config.Username, config.Password))
+	}
+
+	conn, err := nats.Connect(strings.Join(config.Servers, ","), options...)
+	if err != nil {
+		return nil, fmt.Errorf("Error connecting to NATS: %v", err)
+	}
+
+	js, err := conn.JetStream(nats.PublishAsyncMaxPending(config.MaxPending))
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("Error creating NATS JetStream context: %v", err)
+	}
+
+	return &NATS{conn: conn, js: js, ackTimeout: config.AckTimeout}, nil
+}
+
+func (NATS) Name() string {
+	return "NATS"
+}
+
+//Publish publish one message and wait for JetStream acknowledgement (with retries)
+func (n *NATS) Publish(message *NATSMessage) error {
+	wait := natsRetryWait
+	for i := 0; ; i++ {
+		_, err := n.js.Publish(message.Subject, message.Payload, append(publishOptions(message), nats.AckWait(n.ackTimeout))...)
+		if err == nil {
+			return nil
+		}
+		if i == natsRetries {
+			return fmt.Errorf("Error publishing message to NATS subject [%s]: %v", message.Subject, err)
+		}
+
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+//PublishBatch publish messages asynchronously and wait for acknowledgements of all of them
+//not acknowledged messages are republished with exponential backoff
+func (n *NATS) PublishBatch(messages []*NATSMessage) error {
+	wait := natsRetryWait
+	for i := 0; ; i++ {
+		failed, err := n.publishAsync(messages)
+		if len(failed) == 0 {
+			return nil
+		}
+		if i == natsRetries {
+			return fmt.Errorf("Error publishing %d of %d messages to NATS: %v", len(failed), len(messages), err)
+		}
+
+		messages = failed
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+//Close flush pending messages and close connection
+func (n *NATS) Close() error {
+	if err := n.conn.Drain(); err != nil {
+		n.conn.Close()
+		return err
+	}
+
+	return nil
+}
+
+//return messages which weren't acknowledged in ackTimeout and the last error
+func (n *NATS) publishAsync(messages []*NATSMessage) ([]*NATSMessage, error) {
+	var failed []*NATSMessage
+	var lastErr error
+	futures := make([]nats.PubAckFuture, len(messages))
+	for i, message := range messages {
+		future, err := n.js.PublishAsync(message.Subject, message.Payload, publishOptions(message)...)
+		if err != nil {
+			failed = append(failed, message)
+			lastErr = err
+			continue
+		}
+		futures[i] = future
+	}
+
+	timeout := time.NewTimer(n.ackTimeout)
+	defer timeout.Stop()
+	for i, future := range futures {
+		if future == nil {
+			continue
+		}
+
+		select {
+		case <-future.Ok():
+		case err := <-future.Err():
+			failed = append(failed, messages[i])
+			lastErr = err
+		case <-timeout.C:
+			//the rest of messages aren't acknowledged in time
+			for j := i; j < len(futures); j++ {
+				if futures[j] != nil {
+					failed = append(failed, messages[j])
+				}
+			}
+			return failed, nats.ErrTimeout
+		}
+	}
+
+	return failed, lastErr
+}
+
+//return Nats-Msg-Id option if message has id (async publishing doesn't accept ack timeout option)
+func publishOptions(message *NATSMessage) []nats.PubOpt {
+	var options []nats.PubOpt
+	if message.MsgID != "" {
+		options = append(options, nats.MsgId(message.MsgID))
+	}
+
+	return options
+}
@@ -0,0 +1,59 @@
+package adapters
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestNATSConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *NATSConfig
+		expected    *NATSConfig
+		expectedErr string
+	}{
+		{
+			"Nil config",
+			nil,
+			nil,
+			"NATS config is required",
+		},
+		{
+			"Empty servers",
+			&NATSConfig{},
+			nil,
+			"NATS servers is required parameter",
+		},
+		{
+			"Negative ack timeout",
+			&NATSConfig{Servers: []string{"nats://localhost:4222"}, AckTimeout: -time.Second},
+			nil,
+			"NATS ack_timeout can't be negative",
+		},
+		{
+			"Default values",
+			&NATSConfig{Servers: []string{"nats://localhost:4222"}},
+			&NATSConfig{Servers: []string{"nats://localhost:4222"}, AckTimeout: 5 * time.Second, MaxPending: 256},
+			"",
+		},
+		{
+			"Custom values",
+			&NATSConfig{Servers: []string{"nats://localhost:4222"}, AckTimeout: time.Minute, MaxPending: 10},
+			&NATSConfig{Servers: []string{"nats://localhost:4222"}, AckTimeout: time.Minute, MaxPending: 10},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, tt.config)
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package adapters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ParquetSnappy       = "snappy"
+	ParquetGzip         = "gzip"
+	ParquetZstd         = "zstd"
+	ParquetUncompressed = "none"
+
+	parquetWriterParallelism = 4
+	parquetTmpExtension      = ".tmp"
+)
+
+var (
+	parquetCompressionCodecs = map[string]parquet.CompressionCodec{
+		ParquetSnappy:       parquet.CompressionCodec_SNAPPY,
+		ParquetGzip:         parquet.CompressionCodec_GZIP,
+		ParquetZstd:         parquet.CompressionCodec_ZSTD,
+		ParquetUncompressed: parquet.CompressionCodec_UNCOMPRESSED,
+	}
+
+	//all columns are optional: objects don't have values of all table columns
+	schemaToParquet = map[typing.DataType]string{
+		typing.STRING:    "type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL",
+		typing.INT64:     "type=INT64, repetitiontype=OPTIONAL",
+		typing.FLOAT64:   "type=DOUBLE, repetitiontype=OPTIONAL",
+		typing.TIMESTAMP: "type=INT64, convertedtype=TIMESTAMP_MILLIS, repetitiontype=OPTIONAL",
+	}
+)
+
+//ParquetConfig dto for deserialized Parquet destination config
+//dir: local directory where Parquet files are written
+//compression: snappy (default), gzip, zstd or none
+type ParquetConfig struct {
+	Dir         string `mapstructure:"dir"`
+	Compression string `mapstructure:"compression"`
+}
+
+//Validate required fields in ParquetConfig and set default values
+func (pc *ParquetConfig) Validate() error {
+	if pc == nil {
+		return errors.New("Parquet config is required")
+	}
+	if pc.Dir == "" {
+		return errors.New("Parquet dir is required parameter")
+	}
+
+	if pc.Compression == "" {
+		pc.Compression = ParquetSnappy
+	}
+	if _, ok := parquetCompressionCodecs[pc.Compression]; !ok {
+		return fmt.Errorf("Unknown Parquet compression: %s. Supported: [%s, %s, %s, %s]", pc.Compression, ParquetSnappy, ParquetGzip, ParquetZstd, ParquetUncompressed)
+	}
+
+	return nil
+}
+
+//Parquet is adapter for writing objects into local Parquet files
+type Parquet struct {
+	dir         string
+	compression parquet.CompressionCodec
+}
+
+//NewParquet return Parquet adapter and create dir if it doesn't exist
+func NewParquet(config *ParquetConfig) (*Parquet, error) {
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("Error creating Parquet dir [%s]: %v", config.Dir, err)
+	}
+
+	return &Parquet{dir: config.Dir, compression: parquetCompressionCodecs[config.Compression]}, nil
+}
+
+func (Parquet) Name() string {
+	return "Parquet"
+}
+
+//Write objects with table schema into dir/fileName Parquet file
+//objects values must be converted into table columns types (see ParquetValue)
+//file is written under temporary name and renamed after that, so readers don't see incomplete files
+func (p *Parquet) Write(fileName string, table *schema.Table, objects []map[string]interface{}) error {
+	filePath := filepath.Join(p.dir, fileName)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("Error creating Parquet file [%s] dir: %v", filePath, err)
+	}
+
+	parquetSchema, err := ParquetSchema(table)
+	if err != nil {
+		return err
+	}
+
+	tmpFilePath := filePath + parquetTmpExtension
+	if err := p.write(tmpFilePath, parquetSchema, objects); err != nil {
+		if err := os.Remove(tmpFilePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("Error removing temporary Parquet file [%s]: %v", tmpFilePath, err)
+		}
+		return fmt.Errorf("Error writing Parquet file [%s]: %v", filePath, err)
+	}
+
+	if err := os.Rename(tmpFilePath, filePath); err != nil {
+		return fmt.Errorf("Error renaming Parquet file [%s]: %v", tmpFilePath, err)
+	}
+
+	return nil
+}
+
+func (p *Parquet) write(filePath, parquetSchema string, objects []map[string]interface{}) error {
+	file, err := local.NewLocalFileWriter(filePath)
+	if err != nil {
+		return err
+	}
+
+	parquetWriter, err := writer.NewJSONWriter(parquetSchema, file, parquetWriterParallelism)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	parquetWriter.CompressionType = p.compression
+
+	for _, object := range objects {
+		b, err := json.Marshal(object)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		if err := parquetWriter.Write(string(b)); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	if err := parquetWriter.WriteStop(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func (p *Parquet) Close() error {
+	return nil
+}
+
+//ParquetSchema return parquet-go JSON schema definition of table with optional columns sorted by name
+//return err if any column name can't be used in the definition
+func ParquetSchema(table *schema.Table) (string, error) {
+	var columnNames []string
+	for name := range table.Columns {
+		if strings.ContainsAny(name, ",= ") {
+			return "", fmt.Errorf("Column name [%s] of table [%s] can't be used in Parquet schema", name, table.Name)
+		}
+		columnNames = append(columnNames, name)
+	}
+	sort.Strings(columnNames)
+
+	var fields []map[string]string
+	for _, name := range columnNames {
+		parquetType, ok := schemaToParquet[table.Columns[name].GetType()]
+		if !ok {
+			log.Println("Unknown Parquet schema type:", table.Columns[name].GetType())
+			parquetType = schemaToParquet[typing.STRING]
+		}
+		fields = append(fields, map[string]string{"Tag": "name=" + name + ", " + parquetType})
+	}
+
+	b, err := json.Marshal(map[string]interface{}{"Tag": "name=parquet_go_root, repetitiontype=REQUIRED", "Fields": fields})
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+//ParquetValue return value converted into column type in Parquet JSON writer format (timestamps as unix milliseconds)
+func ParquetValue(column schema.Column, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	columnType := column.GetType()
+	if _, ok := schemaToParquet[columnType]; !ok {
+		columnType = typing.STRING
+	}
+
+	converted, err := typing.Convert(columnType, value)
+	if err != nil {
+		return nil, err
+	}
+
+	if columnType == typing.TIMESTAMP {
+		return converted.(time.Time).UnixNano() / int64(time.Millisecond), nil
+	}
+
+	return converted, nil
+}
@@ -0,0 +1,61 @@
+package adapters
+
+import (
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParquetSchema(t *testing.T) {
+	table := &schema.Table{Name: "events", Columns: schema.Columns{
+		"_timestamp": schema.NewColumn(typing.TIMESTAMP),
+		"id":         schema.NewColumn(typing.INT64),
+		"amount":     schema.NewColumn(typing.FLOAT64),
+		"title":      schema.NewColumn(typing.STRING),
+	}}
+
+	actual, err := ParquetSchema(table)
+	require.NoError(t, err)
+	require.Equal(t, `{"Fields":[`+
+		`{"Tag":"name=_timestamp, type=INT64, convertedtype=TIMESTAMP_MILLIS, repetitiontype=OPTIONAL"},`+
+		`{"Tag":"name=amount, type=DOUBLE, repetitiontype=OPTIONAL"},`+
+		`{"Tag":"name=id, type=INT64, repetitiontype=OPTIONAL"},`+
+		`{"Tag":"name=title, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"}],`+
+		`"Tag":"name=parquet_go_root, repetitiontype=REQUIRED"}`, actual)
+
+	table.Columns["a,b"] = schema.NewColumn(typing.STRING)
+	_, err = ParquetSchema(table)
+	require.EqualError(t, err, "Column name [a,b] of table [events] can't be used in Parquet schema")
+}
+
+func TestParquetValue(t *testing.T) {
+	ts := time.Date(2020, 8, 2, 18, 23, 59, 757000000, time.UTC)
+	tests := []struct {
+		name        string
+		column      schema.Column
+		value       interface{}
+		expected    interface{}
+		expectedErr string
+	}{
+		{"Null", schema.NewColumn(typing.STRING), nil, nil, ""},
+		{"Timestamp", schema.NewColumn(typing.TIMESTAMP), ts, int64(1596392639757), ""},
+		{"Timestamp string", schema.NewColumn(typing.TIMESTAMP), "2020-08-02T18:23:59.757000Z", int64(1596392639757), ""},
+		{"Int to float", schema.NewColumn(typing.FLOAT64), 10, float64(10), ""},
+		{"Int to string", schema.NewColumn(typing.STRING), 10, "10", ""},
+		{"String to int", schema.NewColumn(typing.INT64), "abc", nil, "No rule for converting STRING to INT64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := ParquetValue(tt.column, tt.value)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
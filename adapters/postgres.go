@@ -30,8 +30,14 @@ const (
   							AND pg_attribute.attnum > 0`
 	createDbSchemaIfNotExistsTemplate = `CREATE SCHEMA IF NOT EXISTS "%s"`
 	addColumnTemplate                 = `ALTER TABLE "%s"."%s" ADD COLUMN %s %s`
+	dropColumnTemplate                = `ALTER TABLE "%s"."%s" DROP COLUMN %s`
 	createTableTemplate               = `CREATE TABLE "%s"."%s" (%s)`
 	insertTemplate                    = `INSERT INTO "%s"."%s" (%s) VALUES (%s)`
+	createUpsertIndexTemplate         = `CREATE UNIQUE INDEX IF NOT EXISTS "%s_upsert_keys" ON "%s"."%s" (%s)`
+	onConflictClauseTemplate          = ` ON CONFLICT (%s) DO %s`
+	deleteTemplate                    = `DELETE FROM "%s"."%s" WHERE %s`
+	commentColumnTemplate             = `COMMENT ON COLUMN "%s"."%s".%s IS %s`
+	selectLastTemplate                = `SELECT * FROM "%s"."%s" WHERE %s = $1 ORDER BY %s DESC LIMIT $2`
 )
 
 var (
@@ -40,6 +46,7 @@ var (
 		typing.INT64:     "bigint",
 		typing.FLOAT64:   "numeric(38,18)",
 		typing.TIMESTAMP: "timestamp",
+		typing.JSON:      "jsonb",
 	}
 
 	postgresToSchema = map[string]typing.DataType{
@@ -49,6 +56,19 @@ var (
 		"numeric(40,20)":              typing.FLOAT64,
 		"numeric(38,18)":              typing.FLOAT64,
 		"timestamp without time zone": typing.TIMESTAMP,
+		"jsonb":                       typing.JSON,
+	}
+
+	//metrics queries templates: time column, aggregated column, schema, table, time column
+	postgresMetricsTemplates = map[string]string{
+		EventsPerHourMetric: `SELECT date_trunc('hour', %s) AS hour, %s AS event_type, count(*) AS events FROM "%s"."%s" WHERE %s >= $1 GROUP BY 1, 2 ORDER BY 1, 2`,
+		UniquesPerDayMetric: `SELECT date_trunc('day', %s) AS day, count(DISTINCT %s) AS uniques FROM "%s"."%s" WHERE %s >= $1 GROUP BY 1 ORDER BY 1`,
+	}
+
+	//value ranges of decimal types (bigint range is the default range of INT64 columns)
+	postgresNumericBounds = map[string]*schema.NumericBounds{
+		"numeric(40,20)": schema.DecimalBounds(40, 20),
+		"numeric(38,18)": schema.DecimalBounds(38, 18),
 	}
 )
 
@@ -61,6 +81,7 @@ type DataSourceConfig struct {
 	Username   string            `mapstructure:"username"`
 	Password   string            `mapstructure:"password"`
 	Parameters map[string]string `mapstructure:"parameters"`
+	Timescale  *TimescaleConfig  `mapstructure:"timescale"`
 }
 
 //Validate required fields in DataSourceConfig
@@ -81,7 +102,10 @@ func (dsc *DataSourceConfig) Validate() error {
 	if dsc.Parameters == nil {
 		dsc.Parameters = map[string]string{}
 	}
-	return nil
+	if dsc.Timescale == nil {
+		dsc.Timescale = &TimescaleConfig{}
+	}
+	return dsc.Timescale.Validate()
 }
 
 //Postgres is adapter for creating,patching (schema or table), inserting data to postgres
@@ -89,6 +113,11 @@ type Postgres struct {
 	ctx        context.Context
 	config     *DataSourceConfig
 	dataSource *sql.DB
+	//not nil if tables are created as TimescaleDB hypertables (see EnableTimescale)
+	timescale           *TimescaleConfig
+	timescaleTimeColumn string
+	//column names which are quoted in statements (Redshift has its own ones)
+	reservedWords ReservedWords
 }
 
 //NewPostgres return configured Postgres adapter instance
@@ -108,13 +137,18 @@ func NewPostgres(ctx context.Context, config *DataSourceConfig) (*Postgres, erro
 		return nil, err
 	}
 
-	return &Postgres{ctx: ctx, config: config, dataSource: dataSource}, nil
+	return &Postgres{ctx: ctx, config: config, dataSource: dataSource, reservedWords: postgresReservedWords}, nil
 }
 
 func (Postgres) Name() string {
 	return "Postgres"
 }
 
+//Location return database and schema where tables are created
+func (p *Postgres) Location() (string, string) {
+	return p.config.Db, p.config.Schema
+}
+
 //OpenTx open underline sql transaction and return wrapped instance
 func (p *Postgres) OpenTx() (*Transaction, error) {
 	tx, err := p.dataSource.BeginTx(p.ctx, nil)
@@ -155,6 +189,23 @@ func (p *Postgres) PatchTableSchema(patchSchema *schema.Table) error {
 	return p.patchTableSchemaInTransaction(wrappedTx, patchSchema)
 }
 
+//DropColumn drop the column of existing table (admin API only)
+func (p *Postgres) DropColumn(tableName, columnName string) error {
+	wrappedTx, err := p.OpenTx()
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range p.DropColumnDDL(tableName, columnName) {
+		if _, err := wrappedTx.tx.ExecContext(p.ctx, statement); err != nil {
+			wrappedTx.Rollback()
+			return fmt.Errorf("Error dropping column [%s] of [%s] table with statement [%s]: %v", columnName, tableName, statement, err)
+		}
+	}
+
+	return wrappedTx.tx.Commit()
+}
+
 func (p *Postgres) createDbSchemaInTransaction(wrappedTx *Transaction, dbSchemaName string) error {
 	createStmt, err := wrappedTx.tx.PrepareContext(p.ctx, fmt.Sprintf(createDbSchemaIfNotExistsTemplate, dbSchemaName))
 	if err != nil {
@@ -185,12 +236,18 @@ func (p *Postgres) GetTableSchema(tableName string) (*schema.Table, error) {
 		if err := rows.Scan(&columnName, &columnPostgresType); err != nil {
 			return nil, fmt.Errorf("Error scanning result: %v", err)
 		}
-		mappedType, ok := postgresToSchema[strings.ToLower(columnPostgresType)]
+		columnType := strings.ToLower(columnPostgresType)
+		mappedType, ok := postgresToSchema[columnType]
 		if !ok {
+			//numeric(p,s) columns which aren't created for FLOAT64 type
+			if decimalType, ok := schema.ParseDecimalType(columnType); ok {
+				table.Columns[columnName] = schema.NewDecimalColumn(decimalType, "")
+				continue
+			}
 			log.Println("Unknown postgres column type:", columnPostgresType)
 			mappedType = typing.STRING
 		}
-		table.Columns[columnName] = schema.NewColumn(mappedType)
+		table.Columns[columnName] = schema.NewBoundedColumn(mappedType, postgresNumericBounds[columnType])
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("Last rows.Err: %v", err)
@@ -199,55 +256,112 @@ func (p *Postgres) GetTableSchema(tableName string) (*schema.Table, error) {
 	return table, nil
 }
 
+//NumericBounds return value range of the postgres column which is created for the data type
+func (p *Postgres) NumericBounds(dataType typing.DataType) *schema.NumericBounds {
+	return postgresNumericBounds[schemaToPostgres[dataType]]
+}
+
 func (p *Postgres) createTableInTransaction(wrappedTx *Transaction, tableSchema *schema.Table) error {
+	for _, statement := range p.CreateTableDDL(tableSchema) {
+		if _, err := wrappedTx.tx.ExecContext(p.ctx, statement); err != nil {
+			wrappedTx.Rollback()
+			return fmt.Errorf("Error creating [%s] table with statement [%s]: %v", tableSchema.Name, statement, err)
+		}
+	}
+
+	return wrappedTx.tx.Commit()
+}
+
+func (p *Postgres) patchTableSchemaInTransaction(wrappedTx *Transaction, patchSchema *schema.Table) error {
+	for _, statement := range p.PatchTableDDL(patchSchema) {
+		if _, err := wrappedTx.tx.ExecContext(p.ctx, statement); err != nil {
+			wrappedTx.Rollback()
+			return fmt.Errorf("Error patching %s table with statement [%s]: %v", patchSchema.Name, statement, err)
+		}
+	}
+
+	return wrappedTx.tx.Commit()
+}
+
+//CreateTableDDL return statements which create the table: CREATE TABLE, column comments,
+//hypertable creation (if TimescaleDB is used) and upsert keys unique index
+func (p *Postgres) CreateTableDDL(tableSchema *schema.Table) []string {
 	var columnsDDL []string
 	for columnName, column := range tableSchema.Columns {
-		mappedType, ok := schemaToPostgres[column.GetType()]
-		if !ok {
-			log.Println("Unknown postgres schema type:", column.GetType())
-			mappedType = schemaToPostgres[typing.STRING]
-		}
-		columnsDDL = append(columnsDDL, fmt.Sprintf(`%s %s`, columnName, mappedType))
+		columnsDDL = append(columnsDDL, fmt.Sprintf(`%s %s`, p.quote(columnName), p.columnType(column)))
 	}
 
 	//sorting columns asc
 	sort.Strings(columnsDDL)
-	createStmt, err := wrappedTx.tx.PrepareContext(p.ctx, fmt.Sprintf(createTableTemplate, p.config.Schema, tableSchema.Name, strings.Join(columnsDDL, ",")))
-	if err != nil {
-		wrappedTx.Rollback()
-		return fmt.Errorf("Error preparing create table %s statement: %v", tableSchema.Name, err)
+	statements := []string{fmt.Sprintf(createTableTemplate, p.config.Schema, tableSchema.Name, strings.Join(columnsDDL, ","))}
+	statements = append(statements, p.commentColumnsDDL(tableSchema)...)
+
+	if p.timescale != nil {
+		if statement := p.hypertableDDL(tableSchema); statement != "" {
+			statements = append(statements, statement)
+		}
 	}
 
-	_, err = createStmt.ExecContext(p.ctx)
+	//ON CONFLICT clause requires unique index on upsert keys
+	if len(tableSchema.UpsertKeys) > 0 {
+		statements = append(statements, fmt.Sprintf(createUpsertIndexTemplate, tableSchema.Name, p.config.Schema, tableSchema.Name, strings.Join(p.reservedWords.quoteAll(tableSchema.UpsertKeys, `"`), ",")))
+	}
 
-	if err != nil {
-		wrappedTx.Rollback()
-		return fmt.Errorf("Error creating [%s] table: %v", tableSchema.Name, err)
+	return statements
+}
+
+//PatchTableDDL return statements which add new columns (sorted by name) with their comments to existing table
+func (p *Postgres) PatchTableDDL(patchSchema *schema.Table) []string {
+	var columnNames []string
+	for columnName := range patchSchema.Columns {
+		columnNames = append(columnNames, columnName)
 	}
-	return wrappedTx.tx.Commit()
+	sort.Strings(columnNames)
+
+	var statements []string
+	for _, columnName := range columnNames {
+		statements = append(statements, fmt.Sprintf(addColumnTemplate, p.config.Schema, patchSchema.Name, p.quote(columnName), p.columnType(patchSchema.Columns[columnName])))
+	}
+
+	return append(statements, p.commentColumnsDDL(patchSchema)...)
 }
 
-func (p *Postgres) patchTableSchemaInTransaction(wrappedTx *Transaction, patchSchema *schema.Table) error {
-	for columnName, column := range patchSchema.Columns {
-		mappedColumnType, ok := schemaToPostgres[column.GetType()]
-		if !ok {
-			log.Println("Unknown postgres schema type:", column.GetType().String())
-			mappedColumnType = schemaToPostgres[typing.STRING]
-		}
-		alterStmt, err := wrappedTx.tx.PrepareContext(p.ctx, fmt.Sprintf(addColumnTemplate, p.config.Schema, patchSchema.Name, columnName, mappedColumnType))
-		if err != nil {
-			wrappedTx.Rollback()
-			return fmt.Errorf("Error preparing patching table %s schema statement: %v", patchSchema.Name, err)
-		}
+//DropColumnDDL return ALTER TABLE DROP COLUMN statement
+func (p *Postgres) DropColumnDDL(tableName, columnName string) []string {
+	return []string{fmt.Sprintf(dropColumnTemplate, p.config.Schema, tableName, p.quote(columnName))}
+}
 
-		_, err = alterStmt.ExecContext(p.ctx)
-		if err != nil {
-			wrappedTx.Rollback()
-			return fmt.Errorf("Error patching %s table with '%s' - %s column schema: %v", patchSchema.Name, columnName, mappedColumnType, err)
+//return postgres type of the column (string one for unknown types)
+func (p *Postgres) columnType(column schema.Column) string {
+	if decimal := column.Decimal(); decimal != nil {
+		return fmt.Sprintf("numeric(%d,%d)", decimal.Precision, decimal.Scale)
+	}
+
+	mappedType, ok := schemaToPostgres[column.GetType()]
+	if !ok {
+		log.Println("Unknown postgres schema type:", column.GetType())
+		return schemaToPostgres[typing.STRING]
+	}
+
+	return mappedType
+}
+
+//return statements which set descriptions of table columns as column comments
+func (p *Postgres) commentColumnsDDL(table *schema.Table) []string {
+	var columnNames []string
+	for columnName, column := range table.Columns {
+		if column.Description() != "" {
+			columnNames = append(columnNames, columnName)
 		}
 	}
+	sort.Strings(columnNames)
 
-	return wrappedTx.tx.Commit()
+	var statements []string
+	for _, columnName := range columnNames {
+		statements = append(statements, fmt.Sprintf(commentColumnTemplate, p.config.Schema, table.Name, p.quote(columnName), quotedLiteral(table.Columns[columnName].Description())))
+	}
+
+	return statements
 }
 
 //Insert provided object in postgres
@@ -267,12 +381,14 @@ func (p *Postgres) Insert(schema *schema.Table, valuesMap map[string]interface{}
 
 func (p *Postgres) InsertInTransaction(wrappedTx *Transaction, schema *schema.Table, valuesMap map[string]interface{}) error {
 	var header, placeholders string
+	var columns []string
 	var values []interface{}
 	i := 1
 	for name, value := range valuesMap {
-		header += name + ","
+		header += p.quote(name) + ","
 		//$1, $2, $3, etc
 		placeholders += "$" + strconv.Itoa(i) + ","
+		columns = append(columns, p.quote(name))
 		values = append(values, value)
 		i++
 	}
@@ -280,7 +396,8 @@ func (p *Postgres) InsertInTransaction(wrappedTx *Transaction, schema *schema.Ta
 	header = removeLastComma(header)
 	placeholders = removeLastComma(placeholders)
 
-	insertStmt, err := wrappedTx.tx.PrepareContext(p.ctx, fmt.Sprintf(insertTemplate, p.config.Schema, schema.Name, header, placeholders))
+	statement := fmt.Sprintf(insertTemplate, p.config.Schema, schema.Name, header, placeholders) + onConflictClause(p.reservedWords.quoteAll(schema.UpsertKeys, `"`), columns)
+	insertStmt, err := wrappedTx.tx.PrepareContext(p.ctx, statement)
 	if err != nil {
 		return fmt.Errorf("Error preparing insert table %s statement: %v", schema.Name, err)
 	}
@@ -293,6 +410,41 @@ func (p *Postgres) InsertInTransaction(wrappedTx *Transaction, schema *schema.Ta
 	return nil
 }
 
+//Delete rows with the same keys values as provided object has from table with schema.DeletionKeys
+func (p *Postgres) Delete(schema *schema.Table, valuesMap map[string]interface{}) error {
+	wrappedTx, err := p.OpenTx()
+	if err != nil {
+		return err
+	}
+
+	if err := p.DeleteInTransaction(wrappedTx, schema, valuesMap); err != nil {
+		wrappedTx.Rollback()
+		return err
+	}
+
+	return wrappedTx.DirectCommit()
+}
+
+func (p *Postgres) DeleteInTransaction(wrappedTx *Transaction, schema *schema.Table, valuesMap map[string]interface{}) error {
+	var values []interface{}
+	for _, key := range schema.DeletionKeys {
+		values = append(values, valuesMap[key])
+	}
+
+	statement := fmt.Sprintf(deleteTemplate, p.config.Schema, schema.Name, deleteCondition(p.reservedWords.quoteAll(schema.DeletionKeys, `"`)))
+	deleteStmt, err := wrappedTx.tx.PrepareContext(p.ctx, statement)
+	if err != nil {
+		return fmt.Errorf("Error preparing delete from table %s statement: %v", schema.Name, err)
+	}
+
+	_, err = deleteStmt.ExecContext(p.ctx, values...)
+	if err != nil {
+		return fmt.Errorf("Error deleting from %s table with keys: %v values: %v: %v", schema.Name, schema.DeletionKeys, values, err)
+	}
+
+	return nil
+}
+
 //TablesList return slice of postgres table names
 func (p *Postgres) TablesList() ([]string, error) {
 	var tableNames []string
@@ -316,6 +468,34 @@ func (p *Postgres) TablesList() ([]string, error) {
 	return tableNames, nil
 }
 
+//SelectLast return last limit rows of the table where keyColumn equals value ordered by orderColumn desc
+func (p *Postgres) SelectLast(tableName, keyColumn, orderColumn string, value interface{}, limit int) ([]map[string]interface{}, error) {
+	query := fmt.Sprintf(selectLastTemplate, p.config.Schema, tableName, p.quote(keyColumn), p.quote(orderColumn))
+	rows, err := p.dataSource.QueryContext(p.ctx, query, value, limit)
+	if err != nil {
+		return nil, fmt.Errorf("Error querying table [%s]: %v", tableName, err)
+	}
+
+	return scanRows(rows)
+}
+
+//SelectMetric return rows of predefined aggregate query
+func (p *Postgres) SelectMetric(query *MetricQuery) ([]map[string]interface{}, error) {
+	template, ok := postgresMetricsTemplates[query.Metric]
+	if !ok {
+		return nil, fmt.Errorf("Unknown metric: %s", query.Metric)
+	}
+
+	timeColumn := p.quote(query.TimeColumn)
+	statement := fmt.Sprintf(template, timeColumn, p.quote(query.column()), p.config.Schema, query.Table, timeColumn)
+	rows, err := p.dataSource.QueryContext(p.ctx, statement, query.Since)
+	if err != nil {
+		return nil, fmt.Errorf("Error querying %s of table [%s]: %v", query.Metric, query.Table, err)
+	}
+
+	return scanRows(rows)
+}
+
 //Close underlying sql.DB
 func (p *Postgres) Close() error {
 	return p.dataSource.Close()
@@ -349,6 +529,93 @@ func (t *Transaction) Rollback() {
 	}
 }
 
+//return column name in double quotes if it is a reserved word
+func (p *Postgres) quote(name string) string {
+	return p.reservedWords.quote(name, `"`)
+}
+
+//return ON CONFLICT clause which updates all not key columns of existing row or empty string if there are no upsert keys
+func onConflictClause(upsertKeys, columns []string) string {
+	if len(upsertKeys) == 0 {
+		return ""
+	}
+
+	keys := map[string]bool{}
+	for _, key := range upsertKeys {
+		keys[key] = true
+	}
+
+	var updates []string
+	for _, column := range columns {
+		if !keys[column] {
+			updates = append(updates, column+"=EXCLUDED."+column)
+		}
+	}
+	if len(updates) == 0 {
+		return fmt.Sprintf(onConflictClauseTemplate, strings.Join(upsertKeys, ","), "NOTHING")
+	}
+
+	sort.Strings(updates)
+	return fmt.Sprintf(onConflictClauseTemplate, strings.Join(upsertKeys, ","), "UPDATE SET "+strings.Join(updates, ","))
+}
+
+//return WHERE condition with $1, $2, etc placeholders of keys values: key1=$1 AND key2=$2
+func deleteCondition(keys []string) string {
+	var conditions []string
+	for i, key := range keys {
+		conditions = append(conditions, key+"=$"+strconv.Itoa(i+1))
+	}
+
+	return strings.Join(conditions, " AND ")
+}
+
+//return SQL string literal with doubled single quotes: it's -> 'it''s'
+func quotedLiteral(str string) string {
+	return "'" + strings.ReplaceAll(str, "'", "''") + "'"
+}
+
+//return SQL string literal with backslash escaped single quotes and backslashes: it's -> 'it\'s'
+func escapedLiteral(str string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(str) + "'"
+}
+
+//read all rows into maps column name -> value and close rows. Bytes values are converted into strings
+func scanRows(rows *sql.Rows) ([]map[string]interface{}, error) {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("Error getting result columns: %v", err)
+	}
+
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("Error scanning result: %v", err)
+		}
+
+		row := map[string]interface{}{}
+		for i, column := range columns {
+			if bytes, ok := values[i].([]byte); ok {
+				row[column] = string(bytes)
+			} else {
+				row[column] = values[i]
+			}
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Last rows.Err: %v", err)
+	}
+
+	return result, nil
+}
+
 func removeLastComma(str string) string {
 	if last := len(str) - 1; last >= 0 && str[last] == ',' {
 		str = str[:last]
@@ -0,0 +1,60 @@
+package adapters
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestOnConflictClause(t *testing.T) {
+	tests := []struct {
+		name       string
+		upsertKeys []string
+		columns    []string
+		expected   string
+	}{
+		{"Append-only table", nil, []string{"user_id", "email"}, ""},
+		{"Upsert table", []string{"user_id"}, []string{"user_id", "name", "email"}, " ON CONFLICT (user_id) DO UPDATE SET email=EXCLUDED.email,name=EXCLUDED.name"},
+		{"Upsert table with composite key", []string{"project_id", "user_id"}, []string{"user_id", "project_id", "email"}, " ON CONFLICT (project_id,user_id) DO UPDATE SET email=EXCLUDED.email"},
+		{"Only keys", []string{"user_id"}, []string{"user_id"}, " ON CONFLICT (user_id) DO NOTHING"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, onConflictClause(tt.upsertKeys, tt.columns))
+		})
+	}
+}
+
+func TestDeleteCondition(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []string
+		expected string
+	}{
+		{"One key", []string{"user_id"}, "user_id=$1"},
+		{"Composite key", []string{"project_id", "user_id"}, "project_id=$1 AND user_id=$2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, deleteCondition(tt.keys))
+		})
+	}
+}
+
+func TestLiterals(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		expectedQuoted  string
+		expectedEscaped string
+	}{
+		{"Plain text", "User id", "'User id'", "'User id'"},
+		{"Single quotes", "It's user's id", "'It''s user''s id'", `'It\'s user\'s id'`},
+		{"Backslash", `C:\path`, `'C:\path'`, `'C:\\path'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expectedQuoted, quotedLiteral(tt.input))
+			require.Equal(t, tt.expectedEscaped, escapedLiteral(tt.input))
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package adapters
+
+import (
+	"cloud.google.com/go/pubsub"
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/api/option"
+	"time"
+)
+
+//PubSubConfig dto for deserialized Google Pub/Sub destination config
+//ordering_key: event field for message ordering key. Messages aren't ordered if it is empty
+//attributes: message attribute name -> event field
+type PubSubConfig struct {
+	Project        string            `mapstructure:"project"`
+	Topic          string            `mapstructure:"topic"`
+	KeyFile        interface{}       `mapstructure:"key_file"`
+	OrderingKey    string            `mapstructure:"ordering_key"`
+	Attributes     map[string]string `mapstructure:"attributes"`
+	DelayThreshold time.Duration     `mapstructure:"delay_threshold"`
+	CountThreshold int               `mapstructure:"count_threshold"`
+
+	//will be set on validation
+	credentials option.ClientOption
+}
+
+//Validate required fields in PubSubConfig and parse credentials
+func (psc *PubSubConfig) Validate() (err error) {
+	if psc == nil {
+		return errors.New("Pub/Sub config is required")
+	}
+	if psc.Project == "" {
+		return errors.New("Pub/Sub project is required parameter")
+	}
+	if psc.Topic == "" {
+		return errors.New("Pub/Sub topic is required parameter")
+	}
+	if psc.DelayThreshold < 0 || psc.CountThreshold < 0 {
+		return errors.New("Pub/Sub delay_threshold and count_threshold can't be negative")
+	}
+
+	psc.credentials, err = googleCredentials(psc.KeyFile)
+	return
+}
+
+//PubSubMessage is a message payload with ordering key and attributes
+type PubSubMessage struct {
+	OrderingKey string
+	Attributes  map[string]string
+	Data        []byte
+}
+
+//PubSub is adapter for publishing messages to Google Pub/Sub topic
+//messages are batched by the client publisher according to delay and count thresholds
+type PubSub struct {
+	ctx    context.Context
+	client *pubsub.Client
+	topic  *pubsub.Topic
+}
+
+//NewPubSub return configured PubSub adapter instance
+func NewPubSub(ctx context.Context, config *PubSubConfig) (*PubSub, error) {
+	client, err := pubsub.NewClient(ctx, config.Project, config.credentials)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating Pub/Sub client: %v", err)
+	}
+
+	topic := client.Topic(config.Topic)
+	exists, err := topic.Exists(ctx)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("Error checking Pub/Sub topic [%s] existence: %v", config.Topic, err)
+	}
+	if !exists {
+		client.Close()
+		return nil, fmt.Errorf("Pub/Sub topic [%s] doesn't exist", config.Topic)
+	}
+
+	topic.EnableMessageOrdering = config.OrderingKey != ""
+	if config.DelayThreshold > 0 {
+		topic.PublishSettings.DelayThreshold = config.DelayThreshold
+	}
+	if config.CountThreshold > 0 {
+		topic.PublishSettings.CountThreshold = config.CountThreshold
+	}
+
+	return &PubSub{ctx: ctx, client: client, topic: topic}, nil
+}
+
+func (PubSub) Name() string {
+	return "PubSub"
+}
+
+//Publish put message into publisher batch and wait for the result
+func (ps *PubSub) Publish(message *PubSubMessage) error {
+	return ps.wait(ps.publish(message), message)
+}
+
+//PublishBatch publish all messages and wait for results
+func (ps *PubSub) PublishBatch(messages []*PubSubMessage) error {
+	var results []*pubsub.PublishResult
+	for _, message := range messages {
+		results = append(results, ps.publish(message))
+	}
+
+	var firstErr error
+	for i, result := range results {
+		if err := ps.wait(result, messages[i]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+//Close flush publisher batches and close client
+func (ps *PubSub) Close() error {
+	ps.topic.Stop()
+	return ps.client.Close()
+}
+
+func (ps *PubSub) publish(message *PubSubMessage) *pubsub.PublishResult {
+	return ps.topic.Publish(ps.ctx, &pubsub.Message{
+		Data:        message.Data,
+		Attributes:  message.Attributes,
+		OrderingKey: message.OrderingKey,
+	})
+}
+
+//wait for message publishing result
+//publisher pauses ordering key after error so it is resumed for next messages
+func (ps *PubSub) wait(result *pubsub.PublishResult, message *PubSubMessage) error {
+	if _, err := result.Get(ps.ctx); err != nil {
+		if message.OrderingKey != "" {
+			ps.topic.ResumePublish(message.OrderingKey)
+		}
+		return fmt.Errorf("Error publishing message to Pub/Sub topic [%s]: %v", ps.topic.ID(), err)
+	}
+
+	return nil
+}
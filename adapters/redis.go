@@ -0,0 +1,121 @@
+package adapters
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v7"
+)
+
+//payload of stream entry is put into this field as json
+const redisPayloadField = "data"
+
+//RedisConfig dto for deserialized Redis Streams destination config
+//url: redis://[:password@]host:port[/db] or rediss:// for TLS connection
+//stream_template: stream key with {table} placeholder e.g. events:{table}
+//max_len: streams are trimmed to max_len entries on every XADD (0 - streams aren't trimmed)
+//exact_trimming: trim streams to exactly max_len entries (MAXLEN N). By default streams are trimmed approximately (MAXLEN ~ N) which is much more efficient
+type RedisConfig struct {
+	URL            string `mapstructure:"url"`
+	StreamTemplate string `mapstructure:"stream_template"`
+	MaxLen         int64  `mapstructure:"max_len"`
+	ExactTrimming  bool   `mapstructure:"exact_trimming"`
+}
+
+//Validate required fields in RedisConfig
+func (rc *RedisConfig) Validate() error {
+	if rc == nil {
+		return errors.New("Redis config is required")
+	}
+	if rc.URL == "" {
+		return errors.New("Redis url is required parameter")
+	}
+	if rc.MaxLen < 0 {
+		return errors.New("Redis max_len can't be negative")
+	}
+	if rc.ExactTrimming && rc.MaxLen == 0 {
+		return errors.New("Redis exact_trimming requires max_len")
+	}
+
+	return nil
+}
+
+//RedisMessage is a stream entry payload with stream key
+type RedisMessage struct {
+	Stream  string
+	Payload []byte
+}
+
+//Redis is adapter for adding entries into Redis Streams with XADD command
+type Redis struct {
+	client        *redis.Client
+	maxLen        int64
+	exactTrimming bool
+}
+
+//NewRedis return configured Redis adapter instance connected to server
+func NewRedis(config *RedisConfig) (*Redis, error) {
+	options, err := redis.ParseURL(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing Redis url: %v", err)
+	}
+
+	client := redis.NewClient(options)
+	if err := client.Ping().Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("Error connecting to Redis: %v", err)
+	}
+
+	return &Redis{client: client, maxLen: config.MaxLen, exactTrimming: config.ExactTrimming}, nil
+}
+
+func (Redis) Name() string {
+	return "Redis"
+}
+
+//Add add one entry into stream
+func (r *Redis) Add(message *RedisMessage) error {
+	if err := r.client.XAdd(r.xAddArgs(message)).Err(); err != nil {
+		return fmt.Errorf("Error adding entry to Redis stream [%s]: %v", message.Stream, err)
+	}
+
+	return nil
+}
+
+//AddBatch add all entries with one pipeline
+func (r *Redis) AddBatch(messages []*RedisMessage) error {
+	pipeline := r.client.Pipeline()
+	defer pipeline.Close()
+
+	for _, message := range messages {
+		pipeline.XAdd(r.xAddArgs(message))
+	}
+
+	cmds, err := pipeline.Exec()
+	if err != nil {
+		var failed int
+		for _, cmd := range cmds {
+			if cmd.Err() != nil {
+				failed++
+			}
+		}
+		return fmt.Errorf("Error adding %d of %d entries to Redis streams: %v", failed, len(messages), err)
+	}
+
+	return nil
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+//return XADD arguments with auto generated entry id and MAXLEN (approximate by default) if it is configured
+func (r *Redis) xAddArgs(message *RedisMessage) *redis.XAddArgs {
+	args := &redis.XAddArgs{Stream: message.Stream, Values: map[string]interface{}{redisPayloadField: message.Payload}}
+	if r.exactTrimming {
+		args.MaxLen = r.maxLen
+	} else {
+		args.MaxLenApprox = r.maxLen
+	}
+
+	return args
+}
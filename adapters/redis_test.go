@@ -0,0 +1,51 @@
+package adapters
+
+import (
+	"github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRedisConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *RedisConfig
+		expectedErr string
+	}{
+		{"Nil config", nil, "Redis config is required"},
+		{"Empty url", &RedisConfig{}, "Redis url is required parameter"},
+		{"Negative max len", &RedisConfig{URL: "redis://localhost:6379", MaxLen: -1}, "Redis max_len can't be negative"},
+		{"Exact trimming without max len", &RedisConfig{URL: "redis://localhost:6379", ExactTrimming: true}, "Redis exact_trimming requires max_len"},
+		{"Valid", &RedisConfig{URL: "redis://localhost:6379", MaxLen: 1000, ExactTrimming: true}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRedisXAddArgs(t *testing.T) {
+	message := &RedisMessage{Stream: "events:pageview", Payload: []byte(`{"id":1}`)}
+	values := map[string]interface{}{"data": []byte(`{"id":1}`)}
+	tests := []struct {
+		name     string
+		adapter  *Redis
+		expected *redis.XAddArgs
+	}{
+		{"Without trimming", &Redis{}, &redis.XAddArgs{Stream: "events:pageview", Values: values}},
+		{"Approximate trimming", &Redis{maxLen: 1000}, &redis.XAddArgs{Stream: "events:pageview", MaxLenApprox: 1000, Values: values}},
+		{"Exact trimming", &Redis{maxLen: 1000, exactTrimming: true}, &redis.XAddArgs{Stream: "events:pageview", MaxLen: 1000, Values: values}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, tt.adapter.xAddArgs(message))
+		})
+	}
+}
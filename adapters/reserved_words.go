@@ -0,0 +1,125 @@
+package adapters
+
+import (
+	"sort"
+	"strings"
+)
+
+//ReservedWords is a set of lowercased keywords of SQL dialect which can't be used as unquoted column names
+//(e.g. order, select or table). Adapters quote such column names in generated statements
+type ReservedWords map[string]bool
+
+var (
+	postgresReservedWords = newReservedWords(`all analyse analyze and any array as asc asymmetric authorization binary both case cast
+		check collate collation column concurrently constraint create cross current_catalog current_date current_role current_schema
+		current_time current_timestamp current_user default deferrable desc distinct do else end except false fetch for foreign
+		freeze from full grant group having ilike in initially inner intersect into is isnull join lateral leading left like limit
+		localtime localtimestamp natural not notnull null offset on only or order outer overlaps placing primary references
+		returning right select session_user similar some symmetric table tablesample then to trailing true union unique user
+		using variadic verbose when where window with`)
+
+	redshiftReservedWords = newReservedWords(`aes128 aes256 all allowoverwrite analyse analyze and any array as asc authorization
+		az64 backup between binary blanksasnull both bytedict bzip2 case cast check collate column constraint create credentials
+		cross current_date current_time current_timestamp current_user current_user_id default deferrable deflate defrag delta
+		delta32k desc disable distinct do else emptyasnull enable encode encrypt encryption end except explicit false for foreign
+		freeze from full globaldict256 globaldict64k grant group gzip having identity ignore ilike in initially inner intersect
+		interval into is isnull join leading left like limit localtime localtimestamp lun luns lzo lzop minus mostly16 mostly32
+		mostly8 natural new not notnull null nulls off offline offset oid old on only open or order outer overlaps parallel
+		partition percent permissions pivot placing primary raw readratio recover references rejectlog resort respect restore
+		right select session_user similar snapshot some sysdate system table tag tdes text255 text32k then timestamp to top
+		trailing true truncatecolumns union unique unnest unpivot user using verbose wallet when where with without`)
+
+	snowflakeReservedWords = newReservedWords(`account all alter and any as between by case cast check column connect connection
+		constraint create cross current current_date current_time current_timestamp current_user database delete distinct drop
+		else exists false following for from full grant group gscluster having ilike in increment inner insert intersect into
+		is issue join lateral left like localtime localtimestamp minus natural not null of on or order organization qualify
+		regexp revoke right rlike row rows sample schema select set some start table tablesample then to trigger true try_cast
+		union unique update using values view when whenever where with`)
+
+	mssqlReservedWords = newReservedWords(`add all alter and any as asc authorization backup begin between break browse bulk by
+		cascade case check checkpoint close clustered coalesce collate column commit compute constraint contains containstable
+		continue convert create cross current current_date current_time current_timestamp current_user cursor database dbcc
+		deallocate declare default delete deny desc disk distinct distributed double drop dump else end errlvl escape except
+		exec execute exists exit external fetch file fillfactor for foreign freetext freetexttable from full function goto grant
+		group having holdlock identity identity_insert identitycol if in index inner insert intersect into is join key kill left
+		like lineno load merge national nocheck nonclustered not null nullif of off offsets on open opendatasource openquery
+		openrowset openxml option or order outer over percent pivot plan precision primary print proc procedure public raiserror
+		read readtext reconfigure references replication restore restrict return revert revoke right rollback rowcount rowguidcol
+		rule save schema securityaudit select semantickeyphrasetable semanticsimilaritydetailstable semanticsimilaritytable
+		session_user set setuser shutdown some statistics system_user table tablesample textsize then to top tran transaction
+		trigger truncate try_convert tsequal union unique unpivot update updatetext use user values varying view waitfor when
+		where while with within writetext`)
+
+	bigQueryReservedWords = newReservedWords(`all and any array as asc assert_rows_modified at between by case cast collate contains
+		create cross cube current default define desc distinct else end enum escape except exclude exists extract false fetch
+		following for from full group grouping groups hash having if ignore in inner intersect interval into is join lateral left
+		like limit lookup merge natural new no not null nulls of on or order outer over partition preceding proto qualify range
+		recursive respect right rollup rows select set some struct tablesample then to treat true unbounded union unnest using
+		when where window with within`)
+
+	//ClickHouse keywords aren't reserved, but clause keywords break column lists of generated statements
+	clickhouseReservedWords = newReservedWords(`all and any array as asc between by case cast cross database default desc distinct
+		else end except exists false final format from full global group having if ilike in index inner insert intersect
+		interval into is join key left like limit not null offset on or order outer over partition prewhere primary projection
+		right sample select settings table then to totals true ttl union using values when where window with`)
+
+	//destination type: reserved words of its dialect
+	destinationReservedWords = map[string]ReservedWords{
+		"postgres":   postgresReservedWords,
+		"redshift":   redshiftReservedWords,
+		"snowflake":  snowflakeReservedWords,
+		"mssql":      mssqlReservedWords,
+		"bigquery":   bigQueryReservedWords,
+		"clickhouse": clickhouseReservedWords,
+	}
+)
+
+func newReservedWords(words string) ReservedWords {
+	rw := ReservedWords{}
+	for _, word := range strings.Fields(words) {
+		rw[word] = true
+	}
+
+	return rw
+}
+
+//DestinationReservedWords return sorted reserved words of the destination type dialect or nil if it isn't a SQL one
+func DestinationReservedWords(destinationType string) []string {
+	rw, ok := destinationReservedWords[destinationType]
+	if !ok {
+		return nil
+	}
+
+	words := make([]string, 0, len(rw))
+	for word := range rw {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	return words
+}
+
+//Contains return true if name is a reserved word (case insensitive)
+func (rw ReservedWords) Contains(name string) bool {
+	return rw[strings.ToLower(name)]
+}
+
+//quote return name enclosed in quote characters if it is a reserved word or name as is: order -> "order"
+//reserved words consist of letters and underscores only, so they don't need escaping
+func (rw ReservedWords) quote(name, quote string) string {
+	if !rw.Contains(name) {
+		return name
+	}
+
+	return quote + name + quote
+}
+
+//quoteAll return names with quoted reserved words (see quote)
+func (rw ReservedWords) quoteAll(names []string, quote string) []string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, rw.quote(name, quote))
+	}
+
+	return quoted
+}
@@ -0,0 +1,46 @@
+package adapters
+
+import (
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestReservedWordsQuote(t *testing.T) {
+	tests := []struct {
+		name     string
+		column   string
+		expected string
+	}{
+		{"Not reserved", "order_id", "order_id"},
+		{"Reserved", "order", `"order"`},
+		{"Reserved in other case", "Select", `"Select"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, postgresReservedWords.quote(tt.column, `"`))
+		})
+	}
+
+	require.Equal(t, []string{"user_id", `"table"`}, postgresReservedWords.quoteAll([]string{"user_id", "table"}, `"`))
+}
+
+func TestDestinationReservedWords(t *testing.T) {
+	require.Contains(t, DestinationReservedWords("redshift"), "timestamp")
+	require.NotContains(t, DestinationReservedWords("postgres"), "timestamp")
+	require.Nil(t, DestinationReservedWords("s3"))
+}
+
+func TestReservedColumnsDDL(t *testing.T) {
+	p := &Postgres{config: &DataSourceConfig{Schema: "public"}, reservedWords: postgresReservedWords}
+	require.Equal(t, []string{`ALTER TABLE "public"."orders" ADD COLUMN "order" bigint`, `ALTER TABLE "public"."orders" ADD COLUMN total bigint`},
+		p.PatchTableDDL(&schema.Table{Name: "orders", Columns: schema.Columns{"order": schema.NewColumn(typing.INT64), "total": schema.NewColumn(typing.INT64)}}))
+
+	s := &Snowflake{config: &SnowflakeConfig{Schema: "PUBLIC"}}
+	require.Equal(t, []string{`ALTER TABLE "PUBLIC"."orders" ADD COLUMN "ORDER" bigint`},
+		s.PatchTableDDL(&schema.Table{Name: "orders", Columns: schema.Columns{"order": schema.NewColumn(typing.INT64)}}))
+
+	ch := &ClickHouse{}
+	require.Equal(t, "`select` Nullable(String)", ch.columnDDL("select", schema.NewColumn(typing.STRING), true))
+}
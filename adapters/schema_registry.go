@@ -0,0 +1,172 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSchemaRegistryTimeout = 10 * time.Second
+	schemaRegistryContentType    = "application/vnd.schemaregistry.v1+json"
+
+	AvroNullCodec    = "null"
+	AvroDeflateCodec = "deflate"
+	AvroSnappyCodec  = "snappy"
+)
+
+//AvroConfig dto for deserialized avro config of kafka and file destinations (s3, gcs)
+//codec: compression codec of avro object container files: null (default), deflate or snappy. Files only
+//schema_registry: Confluent-compatible schema registry. Required for kafka (messages are in Confluent wire format:
+//magic byte, schema id, avro binary), optional for files
+type AvroConfig struct {
+	Codec          string                `mapstructure:"codec"`
+	SchemaRegistry *SchemaRegistryConfig `mapstructure:"schema_registry"`
+}
+
+//SchemaRegistryConfig dto for deserialized schema registry config
+//username and password are optional basic auth credentials
+type SchemaRegistryConfig struct {
+	URL      string        `mapstructure:"url"`
+	Username string        `mapstructure:"username"`
+	Password string        `mapstructure:"password"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+}
+
+//Validate AvroConfig values and set default ones
+func (ac *AvroConfig) Validate() error {
+	switch ac.Codec {
+	case "":
+		ac.Codec = AvroNullCodec
+	case AvroNullCodec, AvroDeflateCodec, AvroSnappyCodec:
+	default:
+		return fmt.Errorf("Unknown avro codec: %s. Available: [%s, %s, %s]", ac.Codec, AvroNullCodec, AvroDeflateCodec, AvroSnappyCodec)
+	}
+
+	if ac.SchemaRegistry != nil {
+		return ac.SchemaRegistry.Validate()
+	}
+
+	return nil
+}
+
+//Validate required fields in SchemaRegistryConfig
+func (src *SchemaRegistryConfig) Validate() error {
+	if src.URL == "" {
+		return errors.New("avro schema_registry url is required parameter")
+	}
+	if _, err := url.Parse(src.URL); err != nil {
+		return fmt.Errorf("Error parsing avro schema_registry url: %v", err)
+	}
+	if src.Timeout < 0 {
+		return errors.New("avro schema_registry timeout can't be negative")
+	}
+
+	return nil
+}
+
+type registerSchemaRequest struct {
+	Schema string `json:"schema"`
+}
+
+type registerSchemaResponse struct {
+	ID int `json:"id"`
+}
+
+type schemaRegistryError struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+//SchemaRegistry is adapter for registering avro schemas in a Confluent-compatible schema registry
+//registered schemas ids are cached per subject, so every schema version is registered once
+type SchemaRegistry struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+
+	mutex sync.Mutex
+	//subject -> schema -> id
+	ids map[string]map[string]int
+}
+
+//NewSchemaRegistry return configured SchemaRegistry adapter instance
+func NewSchemaRegistry(config *SchemaRegistryConfig) *SchemaRegistry {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = defaultSchemaRegistryTimeout
+	}
+
+	return &SchemaRegistry{
+		url:      strings.TrimSuffix(config.URL, "/"),
+		username: config.Username,
+		password: config.Password,
+		client:   &http.Client{Timeout: timeout},
+		ids:      map[string]map[string]int{},
+	}
+}
+
+//Register schema under subject and return its id. The registry returns the same id if the schema is already registered
+//and error if the schema isn't compatible with previous versions according to the subject compatibility level
+func (sr *SchemaRegistry) Register(subject, schema string) (int, error) {
+	sr.mutex.Lock()
+	defer sr.mutex.Unlock()
+
+	if id, ok := sr.ids[subject][schema]; ok {
+		return id, nil
+	}
+
+	body, err := json.Marshal(&registerSchemaRequest{Schema: schema})
+	if err != nil {
+		return 0, err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, sr.url+"/subjects/"+url.PathEscape(subject)+"/versions", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", schemaRegistryContentType)
+	request.Header.Set("Accept", schemaRegistryContentType)
+	if sr.username != "" {
+		request.SetBasicAuth(sr.username, sr.password)
+	}
+
+	response, err := sr.client.Do(request)
+	if err != nil {
+		return 0, fmt.Errorf("Error registering schema of subject [%s] in schema registry: %v", subject, err)
+	}
+	defer response.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return 0, fmt.Errorf("Error reading schema registry response of subject [%s]: %v", subject, err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		registryErr := &schemaRegistryError{}
+		if err := json.Unmarshal(responseBody, registryErr); err != nil || registryErr.Message == "" {
+			registryErr.Message = string(responseBody)
+		}
+		return 0, fmt.Errorf("Error registering schema of subject [%s] in schema registry: http code [%d]: %s", subject, response.StatusCode, registryErr.Message)
+	}
+
+	result := &registerSchemaResponse{}
+	if err := json.Unmarshal(responseBody, result); err != nil {
+		return 0, fmt.Errorf("Error parsing schema registry response of subject [%s]: %v", subject, err)
+	}
+
+	if _, ok := sr.ids[subject]; !ok {
+		sr.ids[subject] = map[string]int{}
+	}
+	sr.ids[subject][schema] = result.ID
+
+	return result.ID, nil
+}
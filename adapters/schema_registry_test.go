@@ -0,0 +1,69 @@
+package adapters
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestSchemaRegistryRegister(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		require.Equal(t, "user", username)
+		require.Equal(t, "pass", password)
+		require.Equal(t, schemaRegistryContentType, r.Header.Get("Content-Type"))
+
+		request := &registerSchemaRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(request))
+		paths = append(paths, r.URL.Path)
+
+		if request.Schema == `"int"` {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error_code":409,"message":"Schema being registered is incompatible with an earlier schema"}`))
+			return
+		}
+		w.Write([]byte(`{"id":` + strconv.Itoa(len(paths)) + `}`))
+	}))
+	defer server.Close()
+
+	config := &SchemaRegistryConfig{URL: server.URL + "/", Username: "user", Password: "pass"}
+	require.NoError(t, config.Validate())
+	registry := NewSchemaRegistry(config)
+
+	id, err := registry.Register("events-value", `"string"`)
+	require.NoError(t, err)
+	require.Equal(t, 1, id)
+
+	//cached
+	id, err = registry.Register("events-value", `"string"`)
+	require.NoError(t, err)
+	require.Equal(t, 1, id)
+
+	id, err = registry.Register("users-value", `"string"`)
+	require.NoError(t, err)
+	require.Equal(t, 2, id)
+
+	_, err = registry.Register("events-value", `"int"`)
+	require.EqualError(t, err, "Error registering schema of subject [events-value] in schema registry: http code [409]: Schema being registered is incompatible with an earlier schema")
+
+	require.Equal(t, []string{"/subjects/events-value/versions", "/subjects/users-value/versions", "/subjects/events-value/versions"}, paths)
+}
+
+func TestAvroConfigValidate(t *testing.T) {
+	config := &AvroConfig{}
+	require.NoError(t, config.Validate())
+	require.Equal(t, AvroNullCodec, config.Codec)
+
+	require.EqualError(t, (&AvroConfig{Codec: "zstd"}).Validate(), "Unknown avro codec: zstd. Available: [null, deflate, snappy]")
+	require.EqualError(t, (&AvroConfig{SchemaRegistry: &SchemaRegistryConfig{}}).Validate(), "avro schema_registry url is required parameter")
+	require.EqualError(t, (&AvroConfig{SchemaRegistry: &SchemaRegistryConfig{URL: "http://registry:8081", Timeout: -1}}).Validate(),
+		"avro schema_registry timeout can't be negative")
+
+	kafkaConfig := &KafkaConfig{Brokers: []string{"kafka:9092"}, Format: KafkaAvroFormat, Avro: &AvroConfig{}}
+	require.EqualError(t, kafkaConfig.Validate(), "Kafka avro.schema_registry is required parameter if format is avro")
+	require.EqualError(t, (&KafkaConfig{Brokers: []string{"kafka:9092"}, Format: "thrift"}).Validate(), "Unknown Kafka format: thrift. Available: [json, avro, protobuf]")
+}
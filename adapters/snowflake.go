@@ -0,0 +1,385 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	sf "github.com/snowflakedb/gosnowflake"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	tableSchemaSFQuery           = `SELECT column_name, CASE WHEN data_type = 'NUMBER' AND numeric_scale > 0 THEN 'NUMBER(' || numeric_precision || ',' || numeric_scale || ')' ELSE data_type END FROM information_schema.columns WHERE table_schema = ? AND table_name = ?`
+	createSFDbSchemaIfNotExists  = `CREATE SCHEMA IF NOT EXISTS "%s"`
+	addSFColumnTemplate          = `ALTER TABLE "%s"."%s" ADD COLUMN %s %s`
+	dropSFColumnTemplate         = `ALTER TABLE "%s"."%s" DROP COLUMN %s`
+	createSFTableTemplate        = `CREATE TABLE "%s"."%s" (%s)`
+	insertSFTemplate             = `INSERT INTO "%s"."%s" (%s) VALUES (%s)`
+	insertSelectSFTemplate       = `INSERT INTO "%s"."%s" (%s) SELECT %s`
+	putSFTemplate                = `PUT file://%s @%s AUTO_COMPRESS = TRUE OVERWRITE = TRUE`
+	removeSFTemplate             = `REMOVE @%s/%s`
+	copySFTemplate               = `COPY INTO "%s"."%s" FROM @%s/%s FILE_FORMAT = (TYPE = 'JSON') MATCH_BY_COLUMN_NAME = CASE_INSENSITIVE`
+	copySFWithPurgeTemplate      = copySFTemplate + ` PURGE = TRUE`
+	snowflakeInternalStagePrefix = "~"
+)
+
+var (
+	schemaToSnowflake = map[typing.DataType]string{
+		typing.STRING:    "text",
+		typing.INT64:     "bigint",
+		typing.FLOAT64:   "double precision",
+		typing.TIMESTAMP: "timestamp(6)",
+		typing.JSON:      "variant",
+	}
+
+	//information_schema.columns data_type values
+	snowflakeToSchema = map[string]typing.DataType{
+		"text":          typing.STRING,
+		"number":        typing.INT64,
+		"float":         typing.FLOAT64,
+		"timestamp_ntz": typing.TIMESTAMP,
+		"variant":       typing.JSON,
+	}
+)
+
+//SnowflakeConfig dto for deserialized datasource config for Snowflake
+type SnowflakeConfig struct {
+	Account    string             `mapstructure:"account"`
+	Port       int                `mapstructure:"port"`
+	Db         string             `mapstructure:"db"`
+	Schema     string             `mapstructure:"schema"`
+	Username   string             `mapstructure:"username"`
+	Password   string             `mapstructure:"password"`
+	Warehouse  string             `mapstructure:"warehouse"`
+	Stage      string             `mapstructure:"stage"`
+	Parameters map[string]*string `mapstructure:"parameters"`
+}
+
+//Validate required fields in SnowflakeConfig
+func (sfc *SnowflakeConfig) Validate() error {
+	if sfc == nil {
+		return errors.New("Snowflake config is required")
+	}
+	if sfc.Account == "" {
+		return errors.New("Snowflake account is required parameter")
+	}
+	if sfc.Db == "" {
+		return errors.New("Snowflake db is required parameter")
+	}
+	if sfc.Username == "" {
+		return errors.New("Snowflake username is required parameter")
+	}
+	if sfc.Warehouse == "" {
+		return errors.New("Snowflake warehouse is required parameter")
+	}
+
+	if sfc.Parameters == nil {
+		sfc.Parameters = map[string]*string{}
+	}
+
+	return nil
+}
+
+//Snowflake is adapter for creating,patching (schema or table), inserting and copying data from stage to Snowflake
+//Stage is Snowflake internal stage (files are put with PUT command) or external s3 stage (files are uploaded with S3 adapter)
+type Snowflake struct {
+	ctx        context.Context
+	config     *SnowflakeConfig
+	s3Config   *S3Config
+	dataSource *sql.DB
+}
+
+//NewSnowflake return configured Snowflake adapter instance
+//s3Config is nil if internal stage is used
+func NewSnowflake(ctx context.Context, config *SnowflakeConfig, s3Config *S3Config) (*Snowflake, error) {
+	cfg := &sf.Config{
+		Account:   config.Account,
+		User:      config.Username,
+		Password:  config.Password,
+		Port:      config.Port,
+		Schema:    config.Schema,
+		Database:  config.Db,
+		Warehouse: config.Warehouse,
+		Params:    config.Parameters,
+	}
+	connectionString, err := sf.DSN(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dataSource, err := sql.Open("snowflake", connectionString)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dataSource.Ping(); err != nil {
+		dataSource.Close()
+		return nil, err
+	}
+
+	return &Snowflake{ctx: ctx, config: config, s3Config: s3Config, dataSource: dataSource}, nil
+}
+
+func (Snowflake) Name() string {
+	return "Snowflake"
+}
+
+//Location return database and schema where tables are created
+func (s *Snowflake) Location() (string, string) {
+	return s.config.Db, s.config.Schema
+}
+
+//OpenTx open underline sql transaction and return wrapped instance
+func (s *Snowflake) OpenTx() (*Transaction, error) {
+	tx, err := s.dataSource.BeginTx(s.ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transaction{tx: tx, dbType: s.Name()}, nil
+}
+
+//CreateDbSchema create database schema instance if doesn't exist
+func (s *Snowflake) CreateDbSchema(dbSchemaName string) error {
+	wrappedTx, err := s.OpenTx()
+	if err != nil {
+		return err
+	}
+
+	if _, err := wrappedTx.tx.ExecContext(s.ctx, fmt.Sprintf(createSFDbSchemaIfNotExists, dbSchemaName)); err != nil {
+		wrappedTx.Rollback()
+		return fmt.Errorf("Error creating [%s] db schema: %v", dbSchemaName, err)
+	}
+
+	return wrappedTx.DirectCommit()
+}
+
+//GetTableSchema return table (name,columns with name and types) representation wrapped in schema.Table struct
+//Snowflake stores unquoted identifiers in upper case so they are lowercased
+func (s *Snowflake) GetTableSchema(tableName string) (*schema.Table, error) {
+	table := &schema.Table{Name: tableName, Columns: schema.Columns{}}
+	rows, err := s.dataSource.QueryContext(s.ctx, tableSchemaSFQuery, strings.ToUpper(s.config.Schema), strings.ToUpper(tableName))
+	if err != nil {
+		return nil, fmt.Errorf("Error querying table [%s] schema: %v", tableName, err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var columnName, columnSnowflakeType string
+		if err := rows.Scan(&columnName, &columnSnowflakeType); err != nil {
+			return nil, fmt.Errorf("Error scanning result: %v", err)
+		}
+		table.Columns[strings.ToLower(columnName)] = snowflakeColumn(columnSnowflakeType)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Last rows.Err: %v", err)
+	}
+
+	return table, nil
+}
+
+//CreateTable create database table with name,columns provided in schema.Table representation
+func (s *Snowflake) CreateTable(tableSchema *schema.Table) error {
+	return s.execDDL(tableSchema.Name, s.CreateTableDDL(tableSchema))
+}
+
+//PatchTableSchema add new columns(from provided schema.Table) to existing table
+func (s *Snowflake) PatchTableSchema(patchSchema *schema.Table) error {
+	return s.execDDL(patchSchema.Name, s.PatchTableDDL(patchSchema))
+}
+
+//CreateTableDDL return CREATE TABLE statement with columns sorted by name
+func (s *Snowflake) CreateTableDDL(tableSchema *schema.Table) []string {
+	var columnsDDL []string
+	for columnName, column := range tableSchema.Columns {
+		columnsDDL = append(columnsDDL, fmt.Sprintf(`%s %s`, snowflakeQuote(columnName), s.columnType(column)))
+	}
+
+	//sorting columns asc
+	sort.Strings(columnsDDL)
+	return []string{fmt.Sprintf(createSFTableTemplate, s.config.Schema, tableSchema.Name, strings.Join(columnsDDL, ","))}
+}
+
+//PatchTableDDL return ALTER TABLE statements of new columns sorted by name
+func (s *Snowflake) PatchTableDDL(patchSchema *schema.Table) []string {
+	var columnNames []string
+	for columnName := range patchSchema.Columns {
+		columnNames = append(columnNames, columnName)
+	}
+	sort.Strings(columnNames)
+
+	var statements []string
+	for _, columnName := range columnNames {
+		statements = append(statements, fmt.Sprintf(addSFColumnTemplate, s.config.Schema, patchSchema.Name, snowflakeQuote(columnName), s.columnType(patchSchema.Columns[columnName])))
+	}
+
+	return statements
+}
+
+//DropColumn drop the column of existing table (admin API only)
+func (s *Snowflake) DropColumn(tableName, columnName string) error {
+	return s.execDDL(tableName, s.DropColumnDDL(tableName, columnName))
+}
+
+//DropColumnDDL return ALTER TABLE DROP COLUMN statement
+func (s *Snowflake) DropColumnDDL(tableName, columnName string) []string {
+	return []string{fmt.Sprintf(dropSFColumnTemplate, s.config.Schema, tableName, snowflakeQuote(columnName))}
+}
+
+//execute DDL statements of the table in one transaction
+func (s *Snowflake) execDDL(tableName string, statements []string) error {
+	wrappedTx, err := s.OpenTx()
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range statements {
+		if _, err := wrappedTx.tx.ExecContext(s.ctx, statement); err != nil {
+			wrappedTx.Rollback()
+			return fmt.Errorf("Error changing [%s] table with statement [%s]: %v", tableName, statement, err)
+		}
+	}
+
+	return wrappedTx.DirectCommit()
+}
+
+//Insert provided object in Snowflake in stream mode
+//JSON values are parsed with PARSE_JSON which isn't allowed in VALUES clause so INSERT ... SELECT is used
+func (s *Snowflake) Insert(schema *schema.Table, valuesMap map[string]interface{}) error {
+	var header, placeholders string
+	var values []interface{}
+	template := insertSFTemplate
+	for name, value := range valuesMap {
+		header += snowflakeQuote(name) + ","
+		values = append(values, value)
+		if column, ok := schema.Columns[name]; ok && column.GetType() == typing.JSON {
+			placeholders += "PARSE_JSON(?),"
+			template = insertSelectSFTemplate
+		} else {
+			placeholders += "?,"
+		}
+	}
+
+	header = removeLastComma(header)
+	placeholders = removeLastComma(placeholders)
+
+	if _, err := s.dataSource.ExecContext(s.ctx, fmt.Sprintf(template, s.config.Schema, schema.Name, header, placeholders), values...); err != nil {
+		return fmt.Errorf("Error inserting in %s table with statement: %s values: %v: %v", schema.Name, header, values, err)
+	}
+
+	return nil
+}
+
+//PutFile upload file payload into Snowflake internal stage via local temporary file
+func (s *Snowflake) PutFile(fileName string, payload []byte) error {
+	dir, err := ioutil.TempDir("", "snowflake")
+	if err != nil {
+		return fmt.Errorf("Error creating temporary dir for snowflake stage file: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filePath := filepath.Join(dir, fileName)
+	if err := ioutil.WriteFile(filePath, payload, 0644); err != nil {
+		return fmt.Errorf("Error writing temporary snowflake stage file %s: %v", filePath, err)
+	}
+
+	if _, err := s.dataSource.ExecContext(s.ctx, fmt.Sprintf(putSFTemplate, filePath, s.stage())); err != nil {
+		return fmt.Errorf("Error putting file %s to snowflake stage %s: %v", fileName, s.stage(), err)
+	}
+
+	return nil
+}
+
+//Copy transfer data from stage file to Snowflake table by passing COPY INTO request
+//internal stage files are purged after successful copy
+func (s *Snowflake) Copy(fileKey, tableName string) error {
+	if _, err := s.dataSource.ExecContext(s.ctx, s.copyStatement(fileKey, tableName)); err != nil {
+		return fmt.Errorf("Error copying file %s from snowflake stage %s to table %s: %v", fileKey, s.stage(), tableName, err)
+	}
+
+	return nil
+}
+
+//return COPY INTO statement of the stage file. Internal stage files are purged after successful copy
+func (s *Snowflake) copyStatement(fileKey, tableName string) string {
+	template := copySFTemplate
+	if s.s3Config == nil {
+		template = copySFWithPurgeTemplate
+	}
+
+	return fmt.Sprintf(template, s.config.Schema, tableName, s.stage(), fileKey)
+}
+
+//RemoveFile delete file from Snowflake internal stage
+func (s *Snowflake) RemoveFile(fileKey string) error {
+	if _, err := s.dataSource.ExecContext(s.ctx, fmt.Sprintf(removeSFTemplate, s.stage(), fileKey)); err != nil {
+		return fmt.Errorf("Error removing file %s from snowflake stage %s: %v", fileKey, s.stage(), err)
+	}
+
+	return nil
+}
+
+//Close underlying sql.DB
+func (s *Snowflake) Close() error {
+	return s.dataSource.Close()
+}
+
+//return configured stage or user internal stage
+func (s *Snowflake) stage() string {
+	if s.config.Stage == "" {
+		return snowflakeInternalStagePrefix
+	}
+
+	return s.config.Stage
+}
+
+//return column name in double quotes if it is a reserved word. Name is uppercased as Snowflake does with unquoted identifiers,
+//so the column is the same as if it were created unquoted: order -> "ORDER"
+func snowflakeQuote(name string) string {
+	if !snowflakeReservedWords.Contains(name) {
+		return name
+	}
+
+	return `"` + strings.ToUpper(name) + `"`
+}
+
+//return schema column of information_schema.columns data_type
+//NUMBER columns with scale (e.g. NUMBER(38,9)) are decimal ones, unknown types are mapped to string
+func snowflakeColumn(columnSnowflakeType string) schema.Column {
+	if decimalType, isDecimal := schema.ParseDecimalType(columnSnowflakeType); isDecimal {
+		return schema.NewDecimalColumn(decimalType, "")
+	}
+
+	mappedType, ok := snowflakeToSchema[strings.ToLower(columnSnowflakeType)]
+	if !ok {
+		log.Println("Unknown snowflake column type:", columnSnowflakeType)
+		mappedType = typing.STRING
+	}
+
+	return schema.NewColumn(mappedType)
+}
+
+//columnType return mapped column type with comment (column description) if it is provided
+func (s *Snowflake) columnType(column schema.Column) string {
+	mappedType, ok := schemaToSnowflake[column.GetType()]
+	if decimal := column.Decimal(); decimal != nil {
+		mappedType = fmt.Sprintf("number(%d,%d)", decimal.Precision, decimal.Scale)
+	} else if !ok {
+		log.Println("Unknown snowflake schema type:", column.GetType().String())
+		mappedType = schemaToSnowflake[typing.STRING]
+	}
+
+	if column.Description() != "" {
+		return mappedType + " COMMENT " + escapedLiteral(column.Description())
+	}
+
+	return mappedType
+}
@@ -0,0 +1,99 @@
+package adapters
+
+import (
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSnowflakeConfigValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        *SnowflakeConfig
+		expectedError string
+	}{
+		{"nil", nil, "Snowflake config is required"},
+		{"without account", &SnowflakeConfig{Db: "events", Username: "user", Warehouse: "wh"}, "Snowflake account is required parameter"},
+		{"without db", &SnowflakeConfig{Account: "acc", Username: "user", Warehouse: "wh"}, "Snowflake db is required parameter"},
+		{"without username", &SnowflakeConfig{Account: "acc", Db: "events", Warehouse: "wh"}, "Snowflake username is required parameter"},
+		{"without warehouse", &SnowflakeConfig{Account: "acc", Db: "events", Username: "user"}, "Snowflake warehouse is required parameter"},
+		{"ok", &SnowflakeConfig{Account: "acc", Db: "events", Username: "user", Warehouse: "wh"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, tt.config.Parameters)
+		})
+	}
+}
+
+func TestSnowflakeStatements(t *testing.T) {
+	s := &Snowflake{config: &SnowflakeConfig{Schema: "PUBLIC"}}
+
+	table := &schema.Table{Name: "events", Columns: schema.Columns{
+		"_timestamp": schema.NewColumn(typing.TIMESTAMP),
+		"order":      schema.NewColumn(typing.STRING),
+		"eventn_ctx": schema.NewColumn(typing.JSON),
+		"revenue":    schema.NewDescribedColumn(typing.FLOAT64, "user's revenue"),
+		"total":      schema.NewDecimalColumn(&schema.DecimalType{Precision: 18, Scale: 2}, ""),
+	}}
+	require.Equal(t, []string{`CREATE TABLE "PUBLIC"."events" ("ORDER" text,_timestamp timestamp(6),eventn_ctx variant,` +
+		`revenue double precision COMMENT 'user\'s revenue',total number(18,2))`}, s.CreateTableDDL(table))
+	require.Equal(t, []string{
+		`ALTER TABLE "PUBLIC"."events" ADD COLUMN _timestamp timestamp(6)`,
+		`ALTER TABLE "PUBLIC"."events" ADD COLUMN eventn_ctx variant`,
+		`ALTER TABLE "PUBLIC"."events" ADD COLUMN "ORDER" text`,
+		`ALTER TABLE "PUBLIC"."events" ADD COLUMN revenue double precision COMMENT 'user\'s revenue'`,
+		`ALTER TABLE "PUBLIC"."events" ADD COLUMN total number(18,2)`,
+	}, s.PatchTableDDL(table))
+	require.Equal(t, []string{`ALTER TABLE "PUBLIC"."events" DROP COLUMN "ORDER"`}, s.DropColumnDDL("events", "order"))
+
+	//internal stage files are purged after copy
+	require.Equal(t, `COPY INTO "PUBLIC"."events" FROM @~/file1-events FILE_FORMAT = (TYPE = 'JSON') MATCH_BY_COLUMN_NAME = CASE_INSENSITIVE PURGE = TRUE`,
+		s.copyStatement("file1-events", "events"))
+
+	s = &Snowflake{config: &SnowflakeConfig{Schema: "PUBLIC", Stage: "my_s3_stage"}, s3Config: &S3Config{Bucket: "bucket"}}
+	require.Equal(t, `COPY INTO "PUBLIC"."events" FROM @my_s3_stage/file1-events FILE_FORMAT = (TYPE = 'JSON') MATCH_BY_COLUMN_NAME = CASE_INSENSITIVE`,
+		s.copyStatement("file1-events", "events"))
+}
+
+func TestSnowflakeColumnTypes(t *testing.T) {
+	tests := []struct {
+		dbType       string
+		expectedType typing.DataType
+	}{
+		{"TEXT", typing.STRING},
+		{"NUMBER", typing.INT64},
+		{"FLOAT", typing.FLOAT64},
+		{"TIMESTAMP_NTZ", typing.TIMESTAMP},
+		{"VARIANT", typing.JSON},
+		{"NUMBER(38,9)", typing.DECIMAL},
+		{"GEOGRAPHY", typing.STRING},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dbType, func(t *testing.T) {
+			require.Equal(t, tt.expectedType, snowflakeColumn(tt.dbType).GetType())
+		})
+	}
+
+	require.Equal(t, &schema.DecimalType{Precision: 38, Scale: 9}, snowflakeColumn("NUMBER(38,9)").Decimal())
+
+	s := &Snowflake{config: &SnowflakeConfig{Schema: "PUBLIC"}}
+	for dataType, expected := range map[typing.DataType]string{
+		typing.STRING:    "text",
+		typing.INT64:     "bigint",
+		typing.FLOAT64:   "double precision",
+		typing.TIMESTAMP: "timestamp(6)",
+		typing.JSON:      "variant",
+		typing.UNKNOWN:   "text",
+	} {
+		require.Equal(t, expected, s.columnType(schema.NewColumn(dataType)), dataType.String())
+	}
+}
@@ -1,9 +1,43 @@
 package adapters
 
-import "github.com/ksensehq/eventnative/schema"
+import (
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+)
 
 type TableManager interface {
 	GetTableSchema(tableName string) (*schema.Table, error)
 	CreateTable(schemaToCreate *schema.Table) error
 	PatchTableSchema(schemaToAdd *schema.Table) error
 }
+
+//NumericBoundsProvider is implemented by table managers which create numeric columns with limited value range
+type NumericBoundsProvider interface {
+	//NumericBounds return value range of the column which is created for the data type or nil if it isn't limited
+	NumericBounds(dataType typing.DataType) *schema.NumericBounds
+}
+
+//DDLProvider is implemented by table managers which can return DDL statements instead of executing them (read-only schema mode)
+type DDLProvider interface {
+	//CreateTableDDL return statements which CreateTable executes
+	CreateTableDDL(tableSchema *schema.Table) []string
+	//PatchTableDDL return statements which PatchTableSchema executes
+	PatchTableDDL(patchSchema *schema.Table) []string
+}
+
+//SchemaChangesClassifier is implemented by table managers which don't provide all statements they execute (see DDLProvider):
+//they change schemas with API or recreate service tables which don't keep data
+//automatic schema changes of table managers which neither provide nor classify their statements are refused (see storages.TableHelper)
+type SchemaChangesClassifier interface {
+	//DestructiveChanges return statements of creating (patch is false) or patching (patch is true) the table
+	//which can lose data or narrow existing columns
+	DestructiveChanges(tableSchema *schema.Table, patch bool) []string
+}
+
+//ColumnDropper is implemented by table managers which can drop columns of existing tables
+//columns are dropped only explicitly with admin API: automatic schema changes never drop or narrow columns
+type ColumnDropper interface {
+	//DropColumnDDL return statements which DropColumn executes
+	DropColumnDDL(tableName, columnName string) []string
+	DropColumn(tableName, columnName string) error
+}
@@ -0,0 +1,178 @@
+package adapters
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/schema"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TimescaleAuto     = "auto"
+	TimescaleEnabled  = "enabled"
+	TimescaleDisabled = "disabled"
+
+	timescaleExtensionQuery  = `SELECT count(*) FROM pg_extension WHERE extname = 'timescaledb'`
+	createHypertableTemplate = `SELECT create_hypertable('"%s"."%s"', '%s', chunk_time_interval => INTERVAL '%d seconds', if_not_exists => TRUE)`
+	bulkInsertTemplate       = `INSERT INTO "%s"."%s" (%s) VALUES %s`
+
+	defaultChunkTimeInterval = 7 * 24 * time.Hour
+	defaultInsertBatchSize   = 1000
+
+	//max count of bind parameters in one postgres statement
+	postgresMaxParameters = 65535
+)
+
+//TimescaleConfig dto for deserialized datasource.timescale config (Postgres destination only)
+//mode: auto (TimescaleDB extension is detected on start), enabled or disabled
+//chunk_time_interval: interval of hypertables chunks. Default: 7 days (as TimescaleDB one)
+//insert_batch_size: max rows count in one multi-row INSERT statement (batch mode only). Default: 1000
+type TimescaleConfig struct {
+	Mode              string        `mapstructure:"mode"`
+	ChunkTimeInterval time.Duration `mapstructure:"chunk_time_interval"`
+	InsertBatchSize   int           `mapstructure:"insert_batch_size"`
+}
+
+//Validate TimescaleConfig values and set default ones
+func (tc *TimescaleConfig) Validate() error {
+	switch tc.Mode {
+	case "":
+		tc.Mode = TimescaleAuto
+	case TimescaleAuto, TimescaleEnabled, TimescaleDisabled:
+	default:
+		return fmt.Errorf("Unknown timescale mode: %s. Available modes: [%s, %s, %s]", tc.Mode, TimescaleAuto, TimescaleEnabled, TimescaleDisabled)
+	}
+
+	if tc.ChunkTimeInterval < 0 {
+		return fmt.Errorf("timescale chunk_time_interval can't be negative: %s", tc.ChunkTimeInterval)
+	}
+	if tc.ChunkTimeInterval == 0 {
+		tc.ChunkTimeInterval = defaultChunkTimeInterval
+	}
+	if tc.ChunkTimeInterval < time.Second {
+		return fmt.Errorf("timescale chunk_time_interval must be at least 1s: %s", tc.ChunkTimeInterval)
+	}
+
+	if tc.InsertBatchSize < 0 {
+		return fmt.Errorf("timescale insert_batch_size can't be negative: %d", tc.InsertBatchSize)
+	}
+	if tc.InsertBatchSize == 0 {
+		tc.InsertBatchSize = defaultInsertBatchSize
+	}
+
+	return nil
+}
+
+//EnableTimescale make adapter create hypertables partitioned on timeColumn (_timestamp or its destination name)
+//if TimescaleDB is enabled in config or its extension is installed (auto mode)
+//return true if hypertables will be created
+func (p *Postgres) EnableTimescale(config *TimescaleConfig, timeColumn string) (bool, error) {
+	switch config.Mode {
+	case TimescaleDisabled:
+		return false, nil
+	case TimescaleAuto:
+		var count int
+		if err := p.dataSource.QueryRowContext(p.ctx, timescaleExtensionQuery).Scan(&count); err != nil {
+			return false, fmt.Errorf("Error detecting TimescaleDB extension: %v", err)
+		}
+		if count == 0 {
+			return false, nil
+		}
+	}
+
+	p.timescale = config
+	p.timescaleTimeColumn = timeColumn
+	return true, nil
+}
+
+//return statement which turns created table into hypertable partitioned on time column or empty string
+//tables without time column or with upsert keys which don't include it (TimescaleDB unique indexes must include it) are kept as is
+func (p *Postgres) hypertableDDL(tableSchema *schema.Table) string {
+	if !isHypertable(tableSchema, p.timescaleTimeColumn) {
+		if _, ok := tableSchema.Columns[p.timescaleTimeColumn]; ok {
+			log.Printf("Warn: table [%s] won't be a hypertable: upsert keys %v don't include %s column", tableSchema.Name, tableSchema.UpsertKeys, p.timescaleTimeColumn)
+		}
+		return ""
+	}
+
+	return fmt.Sprintf(createHypertableTemplate, p.config.Schema, tableSchema.Name, p.timescaleTimeColumn, int64(p.timescale.ChunkTimeInterval/time.Second))
+}
+
+//BulkInsertInTransaction insert objects with multi-row INSERT statements (as many rows in one statement as bind parameters limit allows)
+//columns which some objects don't have are inserted as NULL
+func (p *Postgres) BulkInsertInTransaction(wrappedTx *Transaction, table *schema.Table, objects []map[string]interface{}) error {
+	columnsSet := map[string]bool{}
+	for _, object := range objects {
+		for name := range object {
+			columnsSet[name] = true
+		}
+	}
+	var columns []string
+	for name := range columnsSet {
+		columns = append(columns, name)
+	}
+	sort.Strings(columns)
+	if len(columns) == 0 {
+		return nil
+	}
+
+	quotedColumns := p.reservedWords.quoteAll(columns, `"`)
+	upsertKeys := p.reservedWords.quoteAll(table.UpsertKeys, `"`)
+	maxRows := postgresMaxParameters / len(columns)
+	for start := 0; start < len(objects); start += maxRows {
+		end := start + maxRows
+		if end > len(objects) {
+			end = len(objects)
+		}
+
+		var values []interface{}
+		for _, object := range objects[start:end] {
+			for _, name := range columns {
+				values = append(values, object[name])
+			}
+		}
+
+		statement := bulkInsertStatement(p.config.Schema, table.Name, quotedColumns, end-start) + onConflictClause(upsertKeys, quotedColumns)
+		if _, err := wrappedTx.tx.ExecContext(p.ctx, statement, values...); err != nil {
+			return fmt.Errorf("Error inserting %d rows in %s table with statement: %s: %v", end-start, table.Name, strings.Join(columns, ","), err)
+		}
+	}
+
+	return nil
+}
+
+//return true if table can be a hypertable: it has time column and it is one of upsert keys if there are any
+func isHypertable(table *schema.Table, timeColumn string) bool {
+	if _, ok := table.Columns[timeColumn]; !ok {
+		return false
+	}
+	if len(table.UpsertKeys) == 0 {
+		return true
+	}
+	for _, key := range table.UpsertKeys {
+		if key == timeColumn {
+			return true
+		}
+	}
+
+	return false
+}
+
+//return multi-row INSERT statement with $1, $2, etc placeholders: INSERT INTO "schema"."table" (a,b) VALUES ($1,$2),($3,$4)
+func bulkInsertStatement(schemaName, tableName string, columns []string, rows int) string {
+	var rowsPlaceholders []string
+	i := 1
+	for row := 0; row < rows; row++ {
+		var placeholders []string
+		for range columns {
+			placeholders = append(placeholders, "$"+strconv.Itoa(i))
+			i++
+		}
+		rowsPlaceholders = append(rowsPlaceholders, "("+strings.Join(placeholders, ",")+")")
+	}
+
+	return fmt.Sprintf(bulkInsertTemplate, schemaName, tableName, strings.Join(columns, ","), strings.Join(rowsPlaceholders, ","))
+}
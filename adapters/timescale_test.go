@@ -0,0 +1,74 @@
+package adapters
+
+import (
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTimescaleConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *TimescaleConfig
+		expected    *TimescaleConfig
+		expectedErr string
+	}{
+		{"Defaults", &TimescaleConfig{}, &TimescaleConfig{Mode: TimescaleAuto, ChunkTimeInterval: 7 * 24 * time.Hour, InsertBatchSize: 1000}, ""},
+		{"Custom", &TimescaleConfig{Mode: TimescaleEnabled, ChunkTimeInterval: time.Hour, InsertBatchSize: 500}, &TimescaleConfig{Mode: TimescaleEnabled, ChunkTimeInterval: time.Hour, InsertBatchSize: 500}, ""},
+		{"Unknown mode", &TimescaleConfig{Mode: "on"}, nil, "Unknown timescale mode: on. Available modes: [auto, enabled, disabled]"},
+		{"Too small chunk interval", &TimescaleConfig{ChunkTimeInterval: time.Millisecond}, nil, "timescale chunk_time_interval must be at least 1s: 1ms"},
+		{"Negative batch size", &TimescaleConfig{InsertBatchSize: -1}, nil, "timescale insert_batch_size can't be negative: -1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, tt.config)
+		})
+	}
+}
+
+func TestIsHypertable(t *testing.T) {
+	columns := schema.Columns{"_timestamp": schema.NewColumn(typing.TIMESTAMP), "id": schema.NewColumn(typing.STRING)}
+	tests := []struct {
+		name       string
+		table      *schema.Table
+		timeColumn string
+		expected   bool
+	}{
+		{"Append-only table", &schema.Table{Name: "events", Columns: columns}, "_timestamp", true},
+		{"Without time column", &schema.Table{Name: "users", Columns: schema.Columns{"id": schema.NewColumn(typing.STRING)}}, "_timestamp", false},
+		{"Upsert keys with time column", &schema.Table{Name: "events", Columns: columns, UpsertKeys: []string{"id", "_timestamp"}}, "_timestamp", true},
+		{"Upsert keys without time column", &schema.Table{Name: "events", Columns: columns, UpsertKeys: []string{"id"}}, "_timestamp", false},
+		{"Renamed time column", &schema.Table{Name: "events", Columns: columns}, "event_time", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, isHypertable(tt.table, tt.timeColumn))
+		})
+	}
+}
+
+func TestBulkInsertStatement(t *testing.T) {
+	tests := []struct {
+		name     string
+		columns  []string
+		rows     int
+		expected string
+	}{
+		{"One row", []string{"a", "b"}, 1, `INSERT INTO "public"."events" (a,b) VALUES ($1,$2)`},
+		{"Several rows", []string{"a", "b"}, 3, `INSERT INTO "public"."events" (a,b) VALUES ($1,$2),($3,$4),($5,$6)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, bulkInsertStatement("public", "events", tt.columns, tt.rows))
+		})
+	}
+}
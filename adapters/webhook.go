@@ -0,0 +1,226 @@
+package adapters
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Webhook body formats
+const (
+	WebhookJSON      = "json"
+	WebhookJSONArray = "json_array"
+	WebhookNDJSON    = "ndjson"
+
+	defaultWebhookTimeout    = 30 * time.Second
+	defaultWebhookRetries    = 3
+	defaultWebhookRetryWait  = time.Second
+	defaultWebhookHMACHeader = "X-Signature"
+)
+
+//WebhookConfig dto for deserialized webhook (generic HTTP) destination config
+//format: json (one object per request), json_array (default) or ndjson (one object per line)
+//headers: header values may contain placeholders: {table}, {count}, {timestamp} (unix seconds) and {request_id} (random UUID)
+//retries: max request attempts on network errors, 429 and 5xx responses. retry_wait is doubled after every attempt
+//hmac_secret: if set, hex HMAC-SHA256 of the request body is sent in hmac_header as sha256=<hex>
+type WebhookConfig struct {
+	URL                string            `mapstructure:"url"`
+	Method             string            `mapstructure:"method"`
+	Headers            map[string]string `mapstructure:"headers"`
+	Format             string            `mapstructure:"format"`
+	BatchSize          int               `mapstructure:"batch_size"`
+	FlushEvery         time.Duration     `mapstructure:"flush_every"`
+	Timeout            time.Duration     `mapstructure:"timeout"`
+	Retries            int               `mapstructure:"retries"`
+	RetryWait          time.Duration     `mapstructure:"retry_wait"`
+	HMACSecret         string            `mapstructure:"hmac_secret"`
+	HMACHeader         string            `mapstructure:"hmac_header"`
+	InsecureSkipVerify bool              `mapstructure:"insecure_skip_verify"`
+}
+
+//Validate required fields in WebhookConfig and set default values
+func (wc *WebhookConfig) Validate() error {
+	if wc == nil {
+		return errors.New("Webhook config is required")
+	}
+	if wc.URL == "" {
+		return errors.New("Webhook url is required parameter")
+	}
+	if wc.BatchSize < 0 || wc.FlushEvery < 0 || wc.Timeout < 0 || wc.Retries < 0 || wc.RetryWait < 0 {
+		return errors.New("Webhook batch_size, flush_every, timeout, retries and retry_wait can't be negative")
+	}
+
+	switch wc.Format {
+	case "":
+		wc.Format = WebhookJSONArray
+	case WebhookJSONArray, WebhookNDJSON:
+	case WebhookJSON:
+		wc.BatchSize = 1
+	default:
+		return fmt.Errorf("Unknown webhook format: %s. Supported: %s, %s, %s", wc.Format, WebhookJSON, WebhookJSONArray, WebhookNDJSON)
+	}
+
+	if wc.Method == "" {
+		wc.Method = http.MethodPost
+	}
+	wc.Method = strings.ToUpper(wc.Method)
+	if wc.Timeout == 0 {
+		wc.Timeout = defaultWebhookTimeout
+	}
+	if wc.Retries == 0 {
+		wc.Retries = defaultWebhookRetries
+	}
+	if wc.RetryWait == 0 {
+		wc.RetryWait = defaultWebhookRetryWait
+	}
+	if wc.HMACHeader == "" {
+		wc.HMACHeader = defaultWebhookHMACHeader
+	}
+
+	return nil
+}
+
+//Webhook is adapter for sending objects to an arbitrary HTTP endpoint
+type Webhook struct {
+	config *WebhookConfig
+	client *http.Client
+}
+
+//NewWebhook return configured Webhook adapter instance
+func NewWebhook(config *WebhookConfig) *Webhook {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &Webhook{config: config, client: &http.Client{Timeout: config.Timeout, Transport: transport}}
+}
+
+func (Webhook) Name() string {
+	return "Webhook"
+}
+
+//Send objects of the table in one request with retries
+func (w *Webhook) Send(tableName string, objects []map[string]interface{}) error {
+	if len(objects) == 0 {
+		return nil
+	}
+
+	body, err := w.body(objects)
+	if err != nil {
+		return err
+	}
+
+	headers := w.headers(tableName, len(objects), time.Now().UTC(), uuid.New().String())
+	if w.config.HMACSecret != "" {
+		headers[w.config.HMACHeader] = hmacSignature(w.config.HMACSecret, body)
+	}
+
+	wait := w.config.RetryWait
+	for i := 1; ; i++ {
+		retry, err := w.doRequest(body, headers)
+		if err == nil {
+			return nil
+		}
+		if !retry || i >= w.config.Retries {
+			return fmt.Errorf("Error sending %d objects to webhook %s: %v", len(objects), w.config.URL, err)
+		}
+
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+//hmacSignature return sha256=<hex HMAC-SHA256 of body>
+func hmacSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+//return serialized objects according to the format
+func (w *Webhook) body(objects []map[string]interface{}) ([]byte, error) {
+	switch w.config.Format {
+	case WebhookJSON:
+		return json.Marshal(objects[0])
+	case WebhookNDJSON:
+		buf := &bytes.Buffer{}
+		for _, object := range objects {
+			b, err := json.Marshal(object)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(b)
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), nil
+	default:
+		return json.Marshal(objects)
+	}
+}
+
+//return configured headers with replaced placeholders and content type
+func (w *Webhook) headers(tableName string, count int, now time.Time, requestID string) map[string]string {
+	replacer := strings.NewReplacer(
+		"{table}", tableName,
+		"{count}", strconv.Itoa(count),
+		"{timestamp}", strconv.FormatInt(now.Unix(), 10),
+		"{request_id}", requestID,
+	)
+
+	contentType := "application/json"
+	if w.config.Format == WebhookNDJSON {
+		contentType = "application/x-ndjson"
+	}
+
+	headers := map[string]string{"Content-Type": contentType}
+	for name, value := range w.config.Headers {
+		headers[name] = replacer.Replace(value)
+	}
+
+	return headers
+}
+
+//return true if request should be retried (network errors, 429 and 5xx responses)
+func (w *Webhook) doRequest(body []byte, headers map[string]string) (bool, error) {
+	request, err := http.NewRequest(w.config.Method, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return true, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusBadRequest {
+		io.Copy(ioutil.Discard, response.Body)
+		return false, nil
+	}
+
+	responseBody, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+	err = fmt.Errorf("response code: %d body: %s", response.StatusCode, string(responseBody))
+
+	return response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= http.StatusInternalServerError, err
+}
+
+func (w *Webhook) Close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,101 @@
+package adapters
+
+import (
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWebhookSend(t *testing.T) {
+	objects := []map[string]interface{}{{"id": 1, "title": "Привет"}, {"id": 2}}
+	tests := []struct {
+		name                string
+		config              *WebhookConfig
+		objects             []map[string]interface{}
+		expectedBody        string
+		expectedContentType string
+	}{
+		{
+			"JSON array",
+			&WebhookConfig{},
+			objects,
+			`[{"id":1,"title":"Привет"},{"id":2}]`,
+			"application/json",
+		},
+		{
+			"NDJSON",
+			&WebhookConfig{Format: WebhookNDJSON},
+			objects,
+			"{\"id\":1,\"title\":\"Привет\"}\n{\"id\":2}\n",
+			"application/x-ndjson",
+		},
+		{
+			"Single JSON",
+			&WebhookConfig{Format: WebhookJSON},
+			objects[:1],
+			`{"id":1,"title":"Привет"}`,
+			"application/json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			var header http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ = ioutil.ReadAll(r.Body)
+				header = r.Header
+			}))
+			defer server.Close()
+
+			tt.config.URL = server.URL
+			tt.config.Headers = map[string]string{"x-events": "{table}:{count}"}
+			tt.config.HMACSecret = "secret"
+			require.NoError(t, tt.config.Validate())
+
+			require.NoError(t, NewWebhook(tt.config).Send("events", tt.objects))
+			require.Equal(t, tt.expectedBody, string(body))
+			require.Equal(t, tt.expectedContentType, header.Get("Content-Type"))
+			require.Equal(t, "events:"+strconv.Itoa(len(tt.objects)), header.Get("X-Events"))
+			require.Equal(t, hmacSignature("secret", body), header.Get(defaultWebhookHMACHeader))
+		})
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name             string
+		responseCode     int
+		expectedRequests int
+	}{
+		{"Retry on 5xx", http.StatusServiceUnavailable, 3},
+		{"Retry on 429", http.StatusTooManyRequests, 3},
+		{"Don't retry on 4xx", http.StatusBadRequest, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(tt.responseCode)
+				w.Write([]byte("error"))
+			}))
+			defer server.Close()
+
+			config := &WebhookConfig{URL: server.URL, RetryWait: time.Millisecond}
+			require.NoError(t, config.Validate())
+
+			err := NewWebhook(config).Send("events", []map[string]interface{}{{"id": 1}})
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "body: error")
+			require.Equal(t, tt.expectedRequests, requests)
+		})
+	}
+}
+
+func TestHMACSignature(t *testing.T) {
+	require.Equal(t, "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8", hmacSignature("key", []byte("The quick brown fox jumps over the lazy dog")))
+}
@@ -0,0 +1,196 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const (
+	C2SToken = "c2s"
+	S2SToken = "s2s"
+)
+
+//TokenConfig is a token created via admin API
+type TokenConfig struct {
+	Token string `json:"token"`
+	Type  string `json:"type"`
+}
+
+//StoreConfig is a content of admin store file
+type StoreConfig struct {
+	Tokens       []*TokenConfig                    `json:"tokens"`
+	Destinations map[string]map[string]interface{} `json:"destinations"`
+}
+
+//Store keeps destinations and tokens created via admin API in JSON file
+//They are applied on the server start together with the config ones
+type Store struct {
+	mutex  sync.RWMutex
+	path   string
+	config *StoreConfig
+}
+
+//NewStore return Store with content of the file (if exists)
+func NewStore(path string) (*Store, error) {
+	config := &StoreConfig{Destinations: map[string]map[string]interface{}{}}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("Error reading admin store file [%s]: %v", path, err)
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, config); err != nil {
+			return nil, fmt.Errorf("Error parsing admin store file [%s]: %v", path, err)
+		}
+		if config.Destinations == nil {
+			config.Destinations = map[string]map[string]interface{}{}
+		}
+	}
+
+	return &Store{path: path, config: config}, nil
+}
+
+//Tokens return all stored tokens
+func (s *Store) Tokens() []*TokenConfig {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var tokens []*TokenConfig
+	for _, tc := range s.config.Tokens {
+		copied := *tc
+		tokens = append(tokens, &copied)
+	}
+
+	return tokens
+}
+
+//AddToken save token with type (c2s or s2s)
+func (s *Store) AddToken(token, tokenType string) error {
+	if token == "" {
+		return errors.New("token can't be empty")
+	}
+	if tokenType != C2SToken && tokenType != S2SToken {
+		return fmt.Errorf("Unknown token type: %s. Available types: [%s, %s]", tokenType, C2SToken, S2SToken)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, tc := range s.config.Tokens {
+		if tc.Token == token {
+			return fmt.Errorf("Token [%s] already exists", token)
+		}
+	}
+	s.config.Tokens = append(s.config.Tokens, &TokenConfig{Token: token, Type: tokenType})
+
+	return s.save()
+}
+
+//DeleteToken remove token and return true if it was stored
+func (s *Store) DeleteToken(token string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, tc := range s.config.Tokens {
+		if tc.Token == token {
+			s.config.Tokens = append(s.config.Tokens[:i], s.config.Tokens[i+1:]...)
+			return true, s.save()
+		}
+	}
+
+	return false, nil
+}
+
+//Destinations return all stored destinations configs
+func (s *Store) Destinations() map[string]map[string]interface{} {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	destinations := map[string]map[string]interface{}{}
+	for name, config := range s.config.Destinations {
+		destinations[name] = config
+	}
+
+	return destinations
+}
+
+//DestinationNames return sorted stored destinations names
+func (s *Store) DestinationNames() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var names []string
+	for name := range s.config.Destinations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+//GetDestination return stored destination config
+func (s *Store) GetDestination(name string) (map[string]interface{}, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	config, ok := s.config.Destinations[name]
+	return config, ok
+}
+
+//PutDestination create or replace destination config
+func (s *Store) PutDestination(name string, config map[string]interface{}) error {
+	if name == "" {
+		return errors.New("destination name can't be empty")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.config.Destinations[name] = config
+
+	return s.save()
+}
+
+//DeleteDestination remove destination config and return true if it was stored
+func (s *Store) DeleteDestination(name string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.config.Destinations[name]; !ok {
+		return false, nil
+	}
+	delete(s.config.Destinations, name)
+
+	return true, s.save()
+}
+
+//write content into temporary file and rename it (must be called under lock)
+func (s *Store) save() error {
+	b, err := json.MarshalIndent(s.config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Error marshaling admin store: %v", err)
+	}
+
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("Error creating admin store dir [%s]: %v", dir, err)
+		}
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b, 0600); err != nil {
+		return fmt.Errorf("Error writing admin store file [%s]: %v", tmpPath, err)
+	}
+
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("Error renaming admin store file [%s]: %v", tmpPath, err)
+	}
+
+	return nil
+}
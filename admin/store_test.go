@@ -0,0 +1,46 @@
+package admin
+
+import (
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "admin_store")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "admin.json")
+	store, err := NewStore(path)
+	require.NoError(t, err)
+	require.Empty(t, store.Tokens())
+	require.Empty(t, store.Destinations())
+
+	require.NoError(t, store.AddToken("token1", C2SToken))
+	require.NoError(t, store.AddToken("token2", S2SToken))
+	require.EqualError(t, store.AddToken("token1", S2SToken), "Token [token1] already exists")
+	require.EqualError(t, store.AddToken("token3", "unknown"), "Unknown token type: unknown. Available types: [c2s, s2s]")
+
+	require.NoError(t, store.PutDestination("pg", map[string]interface{}{"type": "postgres"}))
+	require.NoError(t, store.PutDestination("kafka", map[string]interface{}{"type": "kafka", "mode": "stream"}))
+
+	deleted, err := store.DeleteToken("token2")
+	require.NoError(t, err)
+	require.True(t, deleted)
+	deleted, err = store.DeleteDestination("unknown")
+	require.NoError(t, err)
+	require.False(t, deleted)
+
+	//reload from file
+	reloaded, err := NewStore(path)
+	require.NoError(t, err)
+	require.Equal(t, []*TokenConfig{{Token: "token1", Type: C2SToken}}, reloaded.Tokens())
+	require.Equal(t, []string{"kafka", "pg"}, reloaded.DestinationNames())
+
+	config, ok := reloaded.GetDestination("kafka")
+	require.True(t, ok)
+	require.Equal(t, map[string]interface{}{"type": "kafka", "mode": "stream"}, config)
+}
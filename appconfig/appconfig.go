@@ -18,6 +18,8 @@ type AppConfig struct {
 	S2STokens  map[string]bool
 	//both
 	AuthorizedTokens map[string]bool
+	//admin API is disabled if empty
+	AdminToken string
 
 	GeoResolver geo.Resolver
 	UaResolver  useragent.Resolver
@@ -27,7 +29,8 @@ type AppConfig struct {
 
 var Instance *AppConfig
 
-func setDefaultParams() {
+//SetDefaultParams set default values of config parameters which aren't provided
+func SetDefaultParams() {
 	viper.SetDefault("server.port", "8001")
 	viper.SetDefault("server.static_files_dir", "./web")
 	viper.SetDefault("geo.maxmind_path", "/home/eventnative/app/res/")
@@ -37,7 +40,7 @@ func setDefaultParams() {
 }
 
 func Init() error {
-	setDefaultParams()
+	SetDefaultParams()
 
 	serverName := viper.GetString("server.name")
 	if serverName == "" {
@@ -113,6 +116,11 @@ func Init() error {
 	appConfig.C2STokens = c2sTokens
 	appConfig.S2STokens = s2sTokens
 
+	appConfig.AdminToken = strings.TrimSpace(viper.GetString("server.admin.token"))
+	if appConfig.AdminToken == "" {
+		log.Println("Admin API is disabled: 'server.admin.token' wasn't provided")
+	}
+
 	Instance = &appConfig
 	return nil
 }
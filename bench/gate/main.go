@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//benchmark line e.g. BenchmarkFlattenObject-8   	  100000	     11436 ns/op	    4867 B/op	      71 allocs/op
+var benchmarkLine = regexp.MustCompile(`^(Benchmark\S+?)(-\d+)?\s+\d+\s+(.*)$`)
+
+//result is medians of all runs of one benchmark
+type result struct {
+	nsPerOp     float64
+	allocsPerOp float64
+	bytesPerOp  float64
+}
+
+//regression is a benchmark metric which is worse than baseline more than threshold
+type regression struct {
+	name    string
+	metric  string
+	base    float64
+	current float64
+}
+
+//Compare go test -bench output with stored baseline and exit with code 1 if ns/op or allocs/op
+//of any benchmark is worse than baseline more than threshold percents
+func main() {
+	baselinePath := flag.String("baseline", "bench/baseline.txt", "baseline benchmarks output")
+	currentPath := flag.String("current", "bench/current.txt", "current benchmarks output")
+	threshold := flag.Float64("threshold", 20, "max allowed regression in percents")
+	flag.Parse()
+
+	baseline, err := parseFile(*baselinePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	current, err := parseFile(*currentPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	regressions := compare(os.Stdout, baseline, current, *threshold)
+	if len(regressions) > 0 {
+		fmt.Printf("\n%d performance regression(s) (threshold %.0f%%):\n", len(regressions), *threshold)
+		for _, r := range regressions {
+			fmt.Printf("  %s %s: %.0f -> %.0f (%+.1f%%)\n", r.name, r.metric, r.base, r.current, delta(r.base, r.current))
+		}
+		os.Exit(1)
+	}
+}
+
+func parseFile(path string) (map[string]*result, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening benchmarks file: %v", err)
+	}
+	defer file.Close()
+
+	return parse(file)
+}
+
+//parse go test -bench output. Benchmarks are named <package>.<name> without GOMAXPROCS suffix
+func parse(reader io.Reader) (map[string]*result, error) {
+	runs := map[string]map[string][]float64{}
+	var pkg string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "pkg: ") {
+			pkg = strings.TrimSpace(strings.TrimPrefix(line, "pkg: "))
+			continue
+		}
+
+		match := benchmarkLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		name := match[1]
+		if pkg != "" {
+			name = pkg + "." + name
+		}
+		metrics, ok := runs[name]
+		if !ok {
+			metrics = map[string][]float64{}
+			runs[name] = metrics
+		}
+
+		//pairs of value and unit
+		fields := strings.Fields(match[3])
+		for i := 0; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing benchmark line [%s]: %v", line, err)
+			}
+			metrics[fields[i+1]] = append(metrics[fields[i+1]], value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	results := map[string]*result{}
+	for name, metrics := range runs {
+		results[name] = &result{
+			nsPerOp:     median(metrics["ns/op"]),
+			allocsPerOp: median(metrics["allocs/op"]),
+			bytesPerOp:  median(metrics["B/op"]),
+		}
+	}
+
+	return results, nil
+}
+
+//compare print comparison table and return regressions. Benchmarks which don't exist in baseline are skipped
+func compare(out io.Writer, baseline, current map[string]*result, threshold float64) []*regression {
+	var names []string
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var regressions []*regression
+	fmt.Fprintf(out, "%-80s %14s %14s %9s %12s %12s\n", "benchmark", "base ns/op", "ns/op", "delta", "base allocs", "allocs")
+	for _, name := range names {
+		cur := current[name]
+		base, ok := baseline[name]
+		if !ok {
+			fmt.Fprintf(out, "%-80s %14s %14.0f %9s %12s %12.0f\n", name, "-", cur.nsPerOp, "new", "-", cur.allocsPerOp)
+			continue
+		}
+
+		fmt.Fprintf(out, "%-80s %14.0f %14.0f %+8.1f%% %12.0f %12.0f\n", name, base.nsPerOp, cur.nsPerOp, delta(base.nsPerOp, cur.nsPerOp),
+			base.allocsPerOp, cur.allocsPerOp)
+		if delta(base.nsPerOp, cur.nsPerOp) > threshold {
+			regressions = append(regressions, &regression{name: name, metric: "ns/op", base: base.nsPerOp, current: cur.nsPerOp})
+		}
+		if delta(base.allocsPerOp, cur.allocsPerOp) > threshold {
+			regressions = append(regressions, &regression{name: name, metric: "allocs/op", base: base.allocsPerOp, current: cur.allocsPerOp})
+		}
+	}
+
+	return regressions
+}
+
+//delta return change from base to current in percents
+func delta(base, current float64) float64 {
+	if base == 0 {
+		if current == 0 {
+			return 0
+		}
+		return 100
+	}
+
+	return (current - base) / base * 100
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+
+	return sorted[middle]
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+const baselineOutput = `goos: linux
+goarch: amd64
+pkg: github.com/ksensehq/eventnative/schema
+BenchmarkFlattenObject-8   	  100000	     10000 ns/op	    4000 B/op	      70 allocs/op
+BenchmarkFlattenObject-8   	  100000	     12000 ns/op	    4000 B/op	      70 allocs/op
+BenchmarkFlattenObject-8   	  100000	     11000 ns/op	    4000 B/op	      70 allocs/op
+BenchmarkProcessFact-8     	   50000	     30000 ns/op	    9000 B/op	     150 allocs/op
+PASS
+pkg: github.com/ksensehq/eventnative/typing
+BenchmarkConvert/float->string-8         	 5000000	       250 ns/op	      32 B/op	       2 allocs/op
+PASS
+`
+
+const currentOutput = `pkg: github.com/ksensehq/eventnative/schema
+BenchmarkFlattenObject-4   	  100000	     12500 ns/op	    4000 B/op	      70 allocs/op
+BenchmarkProcessFact-4     	   50000	     40000 ns/op	    9000 B/op	     150 allocs/op
+BenchmarkNew-4             	   50000	     40000 ns/op
+pkg: github.com/ksensehq/eventnative/typing
+BenchmarkConvert/float->string-4         	 5000000	       200 ns/op	      48 B/op	       3 allocs/op
+`
+
+func TestParse(t *testing.T) {
+	results, err := parse(strings.NewReader(baselineOutput))
+	require.NoError(t, err)
+
+	require.Equal(t, 3, len(results))
+	require.Equal(t, &result{nsPerOp: 11000, allocsPerOp: 70, bytesPerOp: 4000}, results["github.com/ksensehq/eventnative/schema.BenchmarkFlattenObject"])
+	require.Equal(t, &result{nsPerOp: 250, allocsPerOp: 2, bytesPerOp: 32}, results["github.com/ksensehq/eventnative/typing.BenchmarkConvert/float->string"])
+}
+
+func TestCompare(t *testing.T) {
+	baseline, err := parse(strings.NewReader(baselineOutput))
+	require.NoError(t, err)
+	current, err := parse(strings.NewReader(currentOutput))
+	require.NoError(t, err)
+
+	regressions := compare(ioutil.Discard, baseline, current, 20)
+	require.Equal(t, []*regression{
+		{name: "github.com/ksensehq/eventnative/schema.BenchmarkProcessFact", metric: "ns/op", base: 30000, current: 40000},
+		{name: "github.com/ksensehq/eventnative/typing.BenchmarkConvert/float->string", metric: "allocs/op", base: 2, current: 3},
+	}, regressions)
+}
@@ -0,0 +1,111 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+const (
+	Gzip = "gzip"
+	Zstd = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	//zstd encoder and decoder are safe for concurrent EncodeAll/DecodeAll calls. They are created on the first use
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+//Validate return err if codec isn't one of supported ones. Empty codec (no compression) is always valid
+func Validate(codec string, supported ...string) error {
+	if codec == "" {
+		return nil
+	}
+
+	for _, s := range supported {
+		if s == codec {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Unknown compression: %s. Supported: [%s]", codec, strings.Join(supported, ", "))
+}
+
+//Extension return file name extension of codec (e.g. .zst) or empty string if codec is empty
+func Extension(codec string) string {
+	switch codec {
+	case Gzip:
+		return ".gz"
+	case Zstd:
+		return ".zst"
+	default:
+		return ""
+	}
+}
+
+//Compress return data compressed with codec or data as is if codec is empty
+func Compress(codec string, data []byte) ([]byte, error) {
+	switch codec {
+	case "":
+		return data, nil
+	case Gzip:
+		buf := &bytes.Buffer{}
+		writer := gzip.NewWriter(buf)
+		if _, err := writer.Write(data); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case Zstd:
+		if err := initZstd(); err != nil {
+			return nil, err
+		}
+		return zstdEncoder.EncodeAll(data, make([]byte, 0, len(data)/4)), nil
+	default:
+		return nil, fmt.Errorf("Unknown compression: %s", codec)
+	}
+}
+
+//Decompress return data decompressed according to its magic bytes (gzip or zstd)
+//data without known magic bytes (e.g. written before compression was turned on) is returned as is
+func Decompress(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, zstdMagic):
+		if err := initZstd(); err != nil {
+			return nil, err
+		}
+		return zstdDecoder.DecodeAll(data, nil)
+	case bytes.HasPrefix(data, gzipMagic):
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return ioutil.ReadAll(reader)
+	default:
+		return data, nil
+	}
+}
+
+func initZstd() error {
+	zstdOnce.Do(func() {
+		if zstdEncoder, zstdErr = zstd.NewWriter(nil); zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+
+	return zstdErr
+}
@@ -0,0 +1,35 @@
+package compression
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"event_type":"pageview","eventn_ctx":{"url":"https://jitsu.com"}}`+"\n"), 100)
+	for _, codec := range []string{"", Gzip, Zstd} {
+		t.Run("codec "+codec, func(t *testing.T) {
+			compressed, err := Compress(codec, data)
+			require.NoError(t, err)
+			if codec != "" {
+				require.Less(t, len(compressed), len(data)/10)
+			}
+
+			decompressed, err := Decompress(compressed)
+			require.NoError(t, err)
+			require.Equal(t, data, decompressed)
+		})
+	}
+
+	_, err := Compress("lz4", data)
+	require.EqualError(t, err, "Unknown compression: lz4")
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(""))
+	require.NoError(t, Validate(Zstd, Gzip, Zstd))
+	require.EqualError(t, Validate("lz4", Gzip, Zstd), "Unknown compression: lz4. Supported: [gzip, zstd]")
+	require.Equal(t, ".zst", Extension(Zstd))
+	require.Equal(t, "", Extension(""))
+}
@@ -0,0 +1,208 @@
+package counters
+
+import (
+	"sync"
+	"time"
+)
+
+var instance = newCounters()
+
+//TokenCounters is a snapshot of per token events counters
+//oversized: events which were over max size (rejected, truncated or written into oversized events file)
+//invalid: events which were rejected because they didn't match the token JSON schema
+type TokenCounters struct {
+	Accepted  uint64 `json:"accepted"`
+	Oversized uint64 `json:"oversized"`
+	Invalid   uint64 `json:"invalid"`
+}
+
+//DestinationCounters is a snapshot of per destination events counters
+type DestinationCounters struct {
+	Success uint64 `json:"success"`
+	Errors  uint64 `json:"errors"`
+	Skipped uint64 `json:"skipped"`
+}
+
+//ExperimentCounters is a snapshot of per routing experiment events counters: accepted events per arm
+type ExperimentCounters struct {
+	Control   uint64 `json:"control"`
+	Treatment uint64 `json:"treatment"`
+}
+
+//ShedCounters is a snapshot of load shedding counters
+//requests: rejected because of memory limit requests, activations: how many times load shedding was turned on
+type ShedCounters struct {
+	Requests    uint64 `json:"requests"`
+	Activations uint64 `json:"activations"`
+}
+
+//Snapshot is a copy of all in-memory counters since the server start
+//latencies: per destination write latency histograms (see Config)
+type Snapshot struct {
+	StartedAt    time.Time                       `json:"started_at"`
+	Tokens       map[string]*TokenCounters       `json:"tokens"`
+	Destinations map[string]*DestinationCounters `json:"destinations"`
+	Experiments  map[string]*ExperimentCounters  `json:"experiments"`
+	Shed         ShedCounters                    `json:"shed"`
+	Latencies    map[string]*LatencyHistogram    `json:"latencies"`
+}
+
+type counters struct {
+	mutex        sync.RWMutex
+	startedAt    time.Time
+	tokens       map[string]*TokenCounters
+	destinations map[string]*DestinationCounters
+	experiments  map[string]*ExperimentCounters
+	shed         ShedCounters
+
+	latencyBuckets []time.Duration
+	exemplars      bool
+	latencies      map[string]*latencyHistogram
+}
+
+func newCounters() *counters {
+	return &counters{
+		startedAt:    time.Now().UTC(),
+		tokens:       map[string]*TokenCounters{},
+		destinations: map[string]*DestinationCounters{},
+		experiments:  map[string]*ExperimentCounters{},
+
+		latencyBuckets: defaultLatencyBuckets,
+		latencies:      map[string]*latencyHistogram{},
+	}
+}
+
+//AcceptedEvents increment accepted by http api events counter of the token
+func AcceptedEvents(token string, value int) {
+	instance.mutex.Lock()
+	instance.token(token).Accepted += uint64(value)
+	instance.mutex.Unlock()
+}
+
+//OversizedEvents increment over max size events counter of the token
+func OversizedEvents(token string, value int) {
+	instance.mutex.Lock()
+	instance.token(token).Oversized += uint64(value)
+	instance.mutex.Unlock()
+}
+
+//InvalidEvents increment not matching JSON schema events counter of the token
+func InvalidEvents(token string, value int) {
+	instance.mutex.Lock()
+	instance.token(token).Invalid += uint64(value)
+	instance.mutex.Unlock()
+}
+
+//SuccessEvents increment successfully stored events counter of the destination
+func SuccessEvents(destinationName string, value int) {
+	instance.mutex.Lock()
+	instance.destination(destinationName).Success += uint64(value)
+	instance.mutex.Unlock()
+}
+
+//ErrorEvents increment not stored because of errors events counter of the destination
+func ErrorEvents(destinationName string, value int) {
+	instance.mutex.Lock()
+	instance.destination(destinationName).Errors += uint64(value)
+	instance.mutex.Unlock()
+}
+
+//SkippedEvents increment skipped (e.g. not enqueued) events counter of the destination
+func SkippedEvents(destinationName string, value int) {
+	instance.mutex.Lock()
+	instance.destination(destinationName).Skipped += uint64(value)
+	instance.mutex.Unlock()
+}
+
+//ControlEvents increment events counter of the experiment control arm
+func ControlEvents(experiment string, value int) {
+	instance.mutex.Lock()
+	instance.experiment(experiment).Control += uint64(value)
+	instance.mutex.Unlock()
+}
+
+//TreatmentEvents increment events counter of the experiment treatment arm
+func TreatmentEvents(experiment string, value int) {
+	instance.mutex.Lock()
+	instance.experiment(experiment).Treatment += uint64(value)
+	instance.mutex.Unlock()
+}
+
+//ShedRequests increment rejected because of load shedding requests counter
+func ShedRequests(value int) {
+	instance.mutex.Lock()
+	instance.shed.Requests += uint64(value)
+	instance.mutex.Unlock()
+}
+
+//ShedActivation increment load shedding activations counter
+func ShedActivation() {
+	instance.mutex.Lock()
+	instance.shed.Activations++
+	instance.mutex.Unlock()
+}
+
+//GetSnapshot return copy of all counters
+func GetSnapshot() *Snapshot {
+	instance.mutex.RLock()
+	defer instance.mutex.RUnlock()
+
+	snapshot := &Snapshot{
+		StartedAt:    instance.startedAt,
+		Tokens:       map[string]*TokenCounters{},
+		Destinations: map[string]*DestinationCounters{},
+		Experiments:  map[string]*ExperimentCounters{},
+		Shed:         instance.shed,
+		Latencies:    map[string]*LatencyHistogram{},
+	}
+	for token, c := range instance.tokens {
+		copied := *c
+		snapshot.Tokens[token] = &copied
+	}
+	for name, c := range instance.destinations {
+		copied := *c
+		snapshot.Destinations[name] = &copied
+	}
+	for name, c := range instance.experiments {
+		copied := *c
+		snapshot.Experiments[name] = &copied
+	}
+	for name, h := range instance.latencies {
+		snapshot.Latencies[name] = h.snapshot(instance.latencyBuckets)
+	}
+
+	return snapshot
+}
+
+//must be called under lock
+func (c *counters) token(token string) *TokenCounters {
+	tc, ok := c.tokens[token]
+	if !ok {
+		tc = &TokenCounters{}
+		c.tokens[token] = tc
+	}
+
+	return tc
+}
+
+//must be called under lock
+func (c *counters) destination(name string) *DestinationCounters {
+	dc, ok := c.destinations[name]
+	if !ok {
+		dc = &DestinationCounters{}
+		c.destinations[name] = dc
+	}
+
+	return dc
+}
+
+//must be called under lock
+func (c *counters) experiment(name string) *ExperimentCounters {
+	ec, ok := c.experiments[name]
+	if !ok {
+		ec = &ExperimentCounters{}
+		c.experiments[name] = ec
+	}
+
+	return ec
+}
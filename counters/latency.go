@@ -0,0 +1,142 @@
+package counters
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"time"
+)
+
+const infBucket = "+Inf"
+
+//default write latency buckets cover both single key-value writes and warehouse bulk loads
+var defaultLatencyBuckets = []time.Duration{
+	5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second, 30 * time.Second, time.Minute,
+}
+
+//Config dto for deserialized statistics config
+//latency_buckets: upper bounds of destinations write latency histogram buckets in ascending order. Slower writes are
+//counted in +Inf bucket
+//exemplars: keep the latest write of every bucket with id of written event (stream mode) or file (batch mode)
+type Config struct {
+	LatencyBuckets []time.Duration `mapstructure:"latency_buckets"`
+	Exemplars      bool            `mapstructure:"exemplars"`
+}
+
+//LatencyHistogram is a snapshot of destination successful writes latency histogram
+//buckets are cumulative: count of writes which took less than or equal to le
+type LatencyHistogram struct {
+	Count   uint64           `json:"count"`
+	SumMs   float64          `json:"sum_ms"`
+	Buckets []*LatencyBucket `json:"buckets"`
+}
+
+//LatencyBucket is a snapshot of histogram bucket. le is a duration string (e.g. 250ms) or +Inf
+type LatencyBucket struct {
+	Le       string    `json:"le"`
+	Count    uint64    `json:"count"`
+	Exemplar *Exemplar `json:"exemplar,omitempty"`
+}
+
+//Exemplar is the latest write of a histogram bucket
+//id: eventn_ctx.event_id of written event (stream mode) or file name (batch mode)
+type Exemplar struct {
+	ID        string    `json:"id"`
+	LatencyMs float64   `json:"latency_ms"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+//latencyHistogram keeps not cumulative bucket counts: len(buckets) + 1 (+Inf)
+type latencyHistogram struct {
+	counts    []uint64
+	sum       time.Duration
+	exemplars []*Exemplar
+}
+
+//Validate Config values and set default buckets
+func (c *Config) Validate() error {
+	if len(c.LatencyBuckets) == 0 {
+		c.LatencyBuckets = defaultLatencyBuckets
+		return nil
+	}
+
+	for i, bucket := range c.LatencyBuckets {
+		if bucket <= 0 || i > 0 && bucket <= c.LatencyBuckets[i-1] {
+			return errors.New("statistics latency_buckets must be positive and in ascending order")
+		}
+	}
+
+	return nil
+}
+
+//Init validate config and apply it to write latency histograms. Default buckets without exemplars are used if config is nil
+func Init(config *Config) error {
+	if config == nil {
+		config = &Config{}
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	instance.mutex.Lock()
+	instance.latencyBuckets = config.LatencyBuckets
+	instance.exemplars = config.Exemplars
+	instance.latencies = map[string]*latencyHistogram{}
+	instance.mutex.Unlock()
+
+	log.Printf("Write latency histogram buckets: %v (exemplars: %t)", config.LatencyBuckets, config.Exemplars)
+
+	return nil
+}
+
+//WriteLatency observe successful write latency of the destination
+//id is an exemplar id: event id in stream mode or file name in batch mode (optional)
+func WriteLatency(destinationName string, latency time.Duration, id string) {
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+
+	h := instance.latency(destinationName)
+	i := sort.Search(len(instance.latencyBuckets), func(i int) bool { return latency <= instance.latencyBuckets[i] })
+	h.counts[i]++
+	h.sum += latency
+	if instance.exemplars && id != "" {
+		h.exemplars[i] = &Exemplar{ID: id, LatencyMs: milliseconds(latency), Timestamp: time.Now().UTC()}
+	}
+}
+
+//must be called under lock
+func (c *counters) latency(name string) *latencyHistogram {
+	h, ok := c.latencies[name]
+	if !ok {
+		h = &latencyHistogram{counts: make([]uint64, len(c.latencyBuckets)+1), exemplars: make([]*Exemplar, len(c.latencyBuckets)+1)}
+		c.latencies[name] = h
+	}
+
+	return h
+}
+
+//return cumulative snapshot of the histogram
+func (h *latencyHistogram) snapshot(buckets []time.Duration) *LatencyHistogram {
+	snapshot := &LatencyHistogram{SumMs: milliseconds(h.sum), Buckets: make([]*LatencyBucket, 0, len(h.counts))}
+	for i, count := range h.counts {
+		snapshot.Count += count
+		le := infBucket
+		if i < len(buckets) {
+			le = buckets[i].String()
+		}
+		bucket := &LatencyBucket{Le: le, Count: snapshot.Count}
+		if h.exemplars[i] != nil {
+			exemplar := *h.exemplars[i]
+			bucket.Exemplar = &exemplar
+		}
+		snapshot.Buckets = append(snapshot.Buckets, bucket)
+	}
+
+	return snapshot
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,61 @@
+package counters
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestConfigValidate(t *testing.T) {
+	config := &Config{}
+	require.NoError(t, config.Validate())
+	require.Equal(t, defaultLatencyBuckets, config.LatencyBuckets)
+
+	require.NoError(t, (&Config{LatencyBuckets: []time.Duration{time.Millisecond, time.Second}}).Validate())
+	require.EqualError(t, (&Config{LatencyBuckets: []time.Duration{time.Second, time.Millisecond}}).Validate(),
+		"statistics latency_buckets must be positive and in ascending order")
+	require.EqualError(t, (&Config{LatencyBuckets: []time.Duration{0}}).Validate(),
+		"statistics latency_buckets must be positive and in ascending order")
+}
+
+func TestWriteLatency(t *testing.T) {
+	require.NoError(t, Init(&Config{LatencyBuckets: []time.Duration{10 * time.Millisecond, time.Second}, Exemplars: true}))
+	defer Init(nil)
+
+	WriteLatency("redis", 5*time.Millisecond, "event1")
+	WriteLatency("redis", 10*time.Millisecond, "")
+	WriteLatency("redis", 3*time.Second, "event2")
+	WriteLatency("redshift", 500*time.Millisecond, "file1")
+
+	latencies := GetSnapshot().Latencies
+	redis := latencies["redis"]
+	require.Equal(t, uint64(3), redis.Count)
+	require.Equal(t, 3015.0, redis.SumMs)
+	require.Len(t, redis.Buckets, 3)
+
+	require.Equal(t, "10ms", redis.Buckets[0].Le)
+	require.Equal(t, uint64(2), redis.Buckets[0].Count)
+	require.Equal(t, "event1", redis.Buckets[0].Exemplar.ID)
+	require.Equal(t, 5.0, redis.Buckets[0].Exemplar.LatencyMs)
+
+	require.Equal(t, "1s", redis.Buckets[1].Le)
+	require.Equal(t, uint64(2), redis.Buckets[1].Count)
+	require.Nil(t, redis.Buckets[1].Exemplar)
+
+	require.Equal(t, infBucket, redis.Buckets[2].Le)
+	require.Equal(t, uint64(3), redis.Buckets[2].Count)
+	require.Equal(t, "event2", redis.Buckets[2].Exemplar.ID)
+
+	redshift := latencies["redshift"]
+	require.Equal(t, uint64(0), redshift.Buckets[0].Count)
+	require.Equal(t, uint64(1), redshift.Buckets[1].Count)
+	require.Equal(t, "file1", redshift.Buckets[1].Exemplar.ID)
+
+	//without exemplars
+	require.NoError(t, Init(&Config{}))
+	WriteLatency("redis", 5*time.Millisecond, "event3")
+	redis = GetSnapshot().Latencies["redis"]
+	require.Len(t, redis.Buckets, len(defaultLatencyBuckets)+1)
+	require.Equal(t, uint64(1), redis.Buckets[0].Count)
+	require.Nil(t, redis.Buckets[0].Exemplar)
+}
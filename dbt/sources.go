@@ -0,0 +1,114 @@
+package dbt
+
+import (
+	"github.com/ksensehq/eventnative/schema"
+	"gopkg.in/yaml.v2"
+	"sort"
+	"time"
+)
+
+const (
+	sourcesVersion = 2
+
+	Loader = "eventnative"
+
+	DefaultWarnAfter  = 12 * time.Hour
+	DefaultErrorAfter = 24 * time.Hour
+)
+
+//Sources is a dbt sources.yml file
+type Sources struct {
+	Version int       `yaml:"version"`
+	Sources []*Source `yaml:"sources"`
+}
+
+//Source is a dbt source: all tables of one destination
+//database and schema are where destination creates tables (e.g. BigQuery project and dataset)
+type Source struct {
+	Name      string     `yaml:"name"`
+	Database  string     `yaml:"database,omitempty"`
+	Schema    string     `yaml:"schema,omitempty"`
+	Loader    string     `yaml:"loader"`
+	Freshness *Freshness `yaml:"freshness,omitempty"`
+	Tables    []*Table   `yaml:"tables"`
+}
+
+//Freshness is a dbt source freshness: warn and error thresholds of the last loaded_at_field value age
+type Freshness struct {
+	WarnAfter  *Period `yaml:"warn_after,omitempty"`
+	ErrorAfter *Period `yaml:"error_after,omitempty"`
+}
+
+//Period is a dbt freshness threshold: count of minutes, hours or days
+type Period struct {
+	Count  int64  `yaml:"count"`
+	Period string `yaml:"period"`
+}
+
+//Table is a dbt source table
+//loaded_at_field is set only if the table has _timestamp (or renamed one) column (freshness isn't checked otherwise)
+type Table struct {
+	Name          string    `yaml:"name"`
+	LoadedAtField string    `yaml:"loaded_at_field,omitempty"`
+	Columns       []*Column `yaml:"columns"`
+}
+
+type Column struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+}
+
+//NewFreshness return Freshness with warn and error thresholds (zero threshold is omitted)
+func NewFreshness(warnAfter, errorAfter time.Duration) *Freshness {
+	return &Freshness{WarnAfter: NewPeriod(warnAfter), ErrorAfter: NewPeriod(errorAfter)}
+}
+
+//NewPeriod return the largest dbt period (day, hour or minute) which the duration is a multiple of
+//return nil if duration is less than a minute
+func NewPeriod(duration time.Duration) *Period {
+	switch {
+	case duration < time.Minute:
+		return nil
+	case duration%(24*time.Hour) == 0:
+		return &Period{Count: int64(duration / (24 * time.Hour)), Period: "day"}
+	case duration%time.Hour == 0:
+		return &Period{Count: int64(duration / time.Hour), Period: "hour"}
+	default:
+		return &Period{Count: int64(duration / time.Minute), Period: "minute"}
+	}
+}
+
+//NewSource return Source of tables with columns sorted by name
+//loadedAtField is used for freshness checks of tables which have it (_timestamp or its destination name)
+//description func return column description or empty string if the column isn't described
+func NewSource(name, database, schemaName string, tables []*schema.Table, loadedAtField string, description func(column string) string, freshness *Freshness) *Source {
+	source := &Source{Name: name, Database: database, Schema: schemaName, Loader: Loader, Freshness: freshness, Tables: []*Table{}}
+	for _, table := range tables {
+		var columnNames []string
+		for name := range table.Columns {
+			columnNames = append(columnNames, name)
+		}
+		sort.Strings(columnNames)
+
+		dbtTable := &Table{Name: table.Name, Columns: []*Column{}}
+		if _, ok := table.Columns[loadedAtField]; ok {
+			dbtTable.LoadedAtField = loadedAtField
+		}
+		for _, name := range columnNames {
+			dbtTable.Columns = append(dbtTable.Columns, &Column{Name: name, Description: description(name)})
+		}
+		source.Tables = append(source.Tables, dbtTable)
+	}
+	sort.Slice(source.Tables, func(i, j int) bool { return source.Tables[i].Name < source.Tables[j].Name })
+
+	return source
+}
+
+//Marshal return sources.yml content of sources
+func Marshal(sources []*Source) ([]byte, error) {
+	if sources == nil {
+		sources = []*Source{}
+	}
+
+	return yaml.Marshal(&Sources{Version: sourcesVersion, Sources: sources})
+}
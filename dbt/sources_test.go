@@ -0,0 +1,67 @@
+package dbt
+
+import (
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestNewPeriod(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		expected *Period
+	}{
+		{"Less than minute", 30 * time.Second, nil},
+		{"Minutes", 90 * time.Minute, &Period{Count: 90, Period: "minute"}},
+		{"Hours", 12 * time.Hour, &Period{Count: 12, Period: "hour"}},
+		{"Days", 48 * time.Hour, &Period{Count: 2, Period: "day"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, NewPeriod(tt.duration))
+		})
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	tables := []*schema.Table{
+		{Name: "users", Columns: schema.Columns{"id": schema.NewColumn(typing.STRING)}},
+		{Name: "events", Columns: schema.Columns{"_timestamp": schema.NewColumn(typing.TIMESTAMP), "user_id": schema.NewColumn(typing.STRING)}},
+	}
+	descriptions := map[string]string{"user_id": "User id"}
+	source := NewSource("pg", "db", "public", tables, "_timestamp", func(column string) string { return descriptions[column] }, NewFreshness(DefaultWarnAfter, DefaultErrorAfter))
+
+	b, err := Marshal([]*Source{source})
+	require.NoError(t, err)
+	require.Equal(t, `version: 2
+sources:
+- name: pg
+  database: db
+  schema: public
+  loader: eventnative
+  freshness:
+    warn_after:
+      count: 12
+      period: hour
+    error_after:
+      count: 1
+      period: day
+  tables:
+  - name: events
+    loaded_at_field: _timestamp
+    columns:
+    - name: _timestamp
+    - name: user_id
+      description: User id
+  - name: users
+    columns:
+    - name: id
+`, string(b))
+
+	b, err = Marshal(nil)
+	require.NoError(t, err)
+	require.Equal(t, "version: 2\nsources: []\n", string(b))
+}
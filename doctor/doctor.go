@@ -0,0 +1,256 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"github.com/ksensehq/eventnative/appconfig"
+	"github.com/ksensehq/eventnative/geo"
+	"github.com/ksensehq/eventnative/routing"
+	"github.com/ksensehq/eventnative/storages"
+	"github.com/spf13/viper"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	Command = "doctor"
+
+	OK   = "OK"
+	Warn = "WARN"
+	Fail = "FAIL"
+
+	//server time is taken from Date header of the response
+	clockURL           = "https://www.google.com"
+	maxClockSkew       = 5 * time.Second
+	clockTimeout       = 10 * time.Second
+	destinationTimeout = 30 * time.Second
+	//MaxMind GeoIP2 and GeoLite2 databases are updated weekly
+	maxGeoAge = 35 * 24 * time.Hour
+	//recommended open files limit: every stream destination queue, log file and connection is a descriptor
+	minOpenFiles = 10000
+)
+
+//Result is a result of one check
+type Result struct {
+	Check   string
+	Status  string
+	Message string
+}
+
+//Report is results of all checks
+type Report struct {
+	Results []*Result
+}
+
+//Run all checks with config which is read into viper. configErr is an error of reading config file (if any)
+//destinations are created and closed right away: their files are created in a temp dir
+func Run(configFile string, configErr error) *Report {
+	appconfig.SetDefaultParams()
+
+	report := &Report{}
+	checkConfig(report, configFile, configErr)
+	for _, dir := range directories() {
+		report.add("directory "+dir, checkDirectory(dir))
+	}
+	report.add("clock", checkClock(clockURL, time.Now))
+	report.add("geoip", checkGeo(viper.GetString("geo.maxmind_path"), time.Now()))
+	report.add("open files", checkOpenFiles(minOpenFiles))
+	checkDestinations(report)
+
+	return report
+}
+
+//Failed return true if any check is failed
+func (r *Report) Failed() bool {
+	for _, result := range r.Results {
+		if result.Status == Fail {
+			return true
+		}
+	}
+
+	return false
+}
+
+//Write readable report: one line per check and summary
+func (r *Report) Write(w io.Writer) {
+	counts := map[string]int{}
+	for _, result := range r.Results {
+		fmt.Fprintf(w, "[%-4s] %s: %s\n", result.Status, result.Check, result.Message)
+		counts[result.Status]++
+	}
+	fmt.Fprintf(w, "\n%d ok, %d warnings, %d failures\n", counts[OK], counts[Warn], counts[Fail])
+}
+
+func (r *Report) add(check string, result *Result) {
+	result.Check = check
+	r.Results = append(r.Results, result)
+}
+
+func ok(format string, args ...interface{}) *Result {
+	return &Result{Status: OK, Message: fmt.Sprintf(format, args...)}
+}
+
+func warn(format string, args ...interface{}) *Result {
+	return &Result{Status: Warn, Message: fmt.Sprintf(format, args...)}
+}
+
+func fail(format string, args ...interface{}) *Result {
+	return &Result{Status: Fail, Message: fmt.Sprintf(format, args...)}
+}
+
+func checkConfig(report *Report, configFile string, configErr error) {
+	switch {
+	case configErr != nil:
+		report.add("config", fail("Error reading config file %s: %v", configFile, configErr))
+	case viper.ConfigFileUsed() == "":
+		report.add("config", warn("config file wasn't provided: default values and env variables are used"))
+	default:
+		report.add("config", ok("%s has been read", viper.ConfigFileUsed()))
+	}
+}
+
+//return dirs which EventNative writes into: event logs (with reports, samples, queues) and server logs if configured
+func directories() []string {
+	dirs := []string{viper.GetString("log.path")}
+	if serverLogPath := viper.GetString("server.log.path"); serverLogPath != "" {
+		dirs = append(dirs, serverLogPath)
+	}
+
+	return dirs
+}
+
+//return ok if dir exists (or can be created) and a file can be written into it
+func checkDirectory(dir string) *Result {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fail("can't be created: %v", err)
+	}
+
+	file, err := ioutil.TempFile(dir, ".eventnative-doctor-")
+	if err != nil {
+		return fail("isn't writable: %v", err)
+	}
+	file.Close()
+	os.Remove(file.Name())
+
+	return ok("writable")
+}
+
+//compare local time with Date header of url response
+func checkClock(url string, now func() time.Time) *Result {
+	client := &http.Client{Timeout: clockTimeout}
+	response, err := client.Head(url)
+	if err != nil {
+		return warn("skew can't be checked: %v", err)
+	}
+	response.Body.Close()
+
+	serverTime, err := http.ParseTime(response.Header.Get("Date"))
+	if err != nil {
+		return warn("skew can't be checked: malformed Date header of %s: %v", url, err)
+	}
+
+	//Date header has seconds precision
+	skew := now().Sub(serverTime).Truncate(time.Second)
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		return fail("local clock differs from %s by %s: event timestamps and time bounds will be wrong. Sync clock with NTP", url, skew)
+	}
+
+	return ok("skew with %s: %s", url, skew)
+}
+
+//return warn if geo resolver is disabled or db is older than maxGeoAge
+func checkGeo(geoipPath string, now time.Time) *Result {
+	buildTime, err := geo.BuildTime(geoipPath)
+	if err != nil {
+		return warn("geo resolution is disabled: %v", err)
+	}
+
+	return geoAge(buildTime, now)
+}
+
+func geoAge(buildTime, now time.Time) *Result {
+	age := now.Sub(buildTime)
+	if age > maxGeoAge {
+		return warn("MaxMind db is %d days old (built at %s). Update it to resolve new IP ranges", int(age.Hours()/24), buildTime.Format(time.RFC3339))
+	}
+
+	return ok("MaxMind db is built at %s", buildTime.Format(time.RFC3339))
+}
+
+func checkOpenFiles(min uint64) *Result {
+	limit, err := openFilesLimit()
+	if err != nil {
+		return warn("limit can't be checked: %v", err)
+	}
+	if limit < min {
+		return warn("limit is %d: it might be exceeded under load. Recommended: %d or more (ulimit -n)", limit, min)
+	}
+
+	return ok("limit is %d", limit)
+}
+
+//create and close every destination from config
+func checkDestinations(report *Report) {
+	rawDestinations := viper.GetStringMap("destinations")
+	delete(rawDestinations, routing.Key)
+	if len(rawDestinations) == 0 {
+		report.add("destinations", warn("there are no destinations in config"))
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "eventnative-doctor")
+	if err != nil {
+		report.add("destinations", fail("Error creating temp dir: %v", err))
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	if appconfig.Instance == nil {
+		appconfig.Instance = &appconfig.AppConfig{ServerName: "doctor", AuthorizedTokens: map[string]bool{}}
+	}
+
+	var names []string
+	for name := range rawDestinations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		rawConfig, valid := rawDestinations[name].(map[string]interface{})
+		if !valid {
+			report.add("destination "+name, fail("config must be an object"))
+			continue
+		}
+		report.add("destination "+name, checkDestination(name, filepath.Join(dir, name), rawConfig))
+	}
+}
+
+func checkDestination(name, dir string, rawConfig map[string]interface{}) *Result {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fail("Error creating temp dir: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), destinationTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- storages.Check(ctx, name, dir, rawConfig)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fail("%s", strings.TrimSpace(err.Error()))
+		}
+		return ok("connected")
+	case <-ctx.Done():
+		return fail("connection timeout %s", destinationTimeout)
+	}
+}
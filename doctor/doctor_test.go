@@ -0,0 +1,88 @@
+package doctor
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doctor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.Equal(t, OK, checkDirectory(filepath.Join(dir, "logs", "events")).Status)
+
+	file := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(file, []byte{}, 0644))
+	require.Equal(t, Fail, checkDirectory(filepath.Join(file, "logs")).Status)
+}
+
+func TestCheckClock(t *testing.T) {
+	serverTime := time.Date(2020, 10, 10, 10, 10, 10, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", serverTime.Format(http.TimeFormat))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected string
+	}{
+		{"in sync", serverTime.Add(700 * time.Millisecond), OK},
+		{"small skew", serverTime.Add(-3 * time.Second), OK},
+		{"ahead", serverTime.Add(time.Minute), Fail},
+		{"behind", serverTime.Add(-10 * time.Second), Fail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := checkClock(server.URL, func() time.Time { return tt.now })
+			require.Equal(t, tt.expected, result.Status, result.Message)
+		})
+	}
+
+	require.Equal(t, Warn, checkClock("http://127.0.0.1:1", time.Now).Status)
+}
+
+func TestGeo(t *testing.T) {
+	now := time.Date(2020, 10, 10, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, OK, geoAge(now.AddDate(0, 0, -7), now).Status)
+
+	result := geoAge(now.AddDate(0, 0, -100), now)
+	require.Equal(t, Warn, result.Status)
+	require.Contains(t, result.Message, "100 days old")
+
+	require.Equal(t, Warn, checkGeo("", now).Status)
+}
+
+func TestCheckOpenFiles(t *testing.T) {
+	require.NotEqual(t, Fail, checkOpenFiles(0).Status)
+	require.Equal(t, Warn, checkOpenFiles(math.MaxUint64).Status)
+}
+
+func TestReport(t *testing.T) {
+	report := &Report{}
+	report.add("config", ok("eventnative.yaml has been read"))
+	report.add("geoip", warn("geo resolution is disabled"))
+	require.False(t, report.Failed())
+
+	report.add("destination redshift", fail("connection refused"))
+	require.True(t, report.Failed())
+
+	buf := &bytes.Buffer{}
+	report.Write(buf)
+	require.Equal(t, `[OK  ] config: eventnative.yaml has been read
+[WARN] geoip: geo resolution is disabled
+[FAIL] destination redshift: connection refused
+
+1 ok, 1 warnings, 1 failures
+`, buf.String())
+}
@@ -0,0 +1,15 @@
+// +build !windows
+
+package doctor
+
+import "syscall"
+
+//return soft limit of open file descriptors
+func openFilesLimit() (uint64, error) {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		return 0, err
+	}
+
+	return uint64(limit.Cur), nil
+}
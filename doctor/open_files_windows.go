@@ -0,0 +1,7 @@
+package doctor
+
+import "errors"
+
+func openFilesLimit() (uint64, error) {
+	return 0, errors.New("open files limit isn't supported on windows")
+}
@@ -0,0 +1,124 @@
+package dropcolumn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	Command = "drop-column"
+
+	//admin API of running server (see handlers.AdminHandler)
+	dropColumnPath   = "/api/v2/admin/schema/drop-column"
+	adminTokenHeader = "X-Admin-Token"
+	requestTimeout   = 5 * time.Minute
+)
+
+//Request is a column which is dropped via admin API of running server
+type Request struct {
+	ServerURL   string
+	AdminToken  string
+	Destination string
+	Table       string
+	Column      string
+}
+
+//admin API request and response (see handlers.DropColumnRequest and storages.ColumnDrop)
+type dropColumnRequest struct {
+	Destination       string `json:"destination"`
+	Table             string `json:"table"`
+	Column            string `json:"column"`
+	ConfirmationToken string `json:"confirmation_token,omitempty"`
+}
+
+type dropColumnResponse struct {
+	Statements        []string `json:"statements"`
+	ConfirmationToken string   `json:"confirmation_token"`
+	Dropped           bool     `json:"dropped"`
+	Message           string   `json:"message"`
+	Error             string   `json:"error"`
+}
+
+//Run plan dropping the column, print statements and execute them only if the column name is typed as confirmation
+//return err if the column isn't dropped
+func Run(req *Request, in io.Reader, out io.Writer) error {
+	if req.Destination == "" || req.Table == "" || req.Column == "" {
+		return errors.New("-destination, -table and -column are required")
+	}
+
+	client := &http.Client{Timeout: requestTimeout}
+	planned, err := send(client, req, "")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Column [%s] of table [%s] in destination [%s] will be dropped with statements:\n", req.Column, req.Table, req.Destination)
+	for _, statement := range planned.Statements {
+		fmt.Fprintln(out, "  "+statement)
+	}
+	fmt.Fprint(out, "Data of the column will be lost. Type the column name to confirm: ")
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if strings.TrimSpace(answer) != req.Column {
+		return errors.New("dropping is canceled")
+	}
+
+	if _, err := send(client, req, planned.ConfirmationToken); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Column [%s] has been dropped\n", req.Column)
+
+	return nil
+}
+
+func send(client *http.Client, req *Request, confirmationToken string) (*dropColumnResponse, error) {
+	body, err := json.Marshal(dropColumnRequest{Destination: req.Destination, Table: req.Table, Column: req.Column, ConfirmationToken: confirmationToken})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(req.ServerURL, "/")+dropColumnPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(adminTokenHeader, req.AdminToken)
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("Error sending request to %s: %v", req.ServerURL, err)
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading response: %v", err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, errors.New("admin token is invalid (server.admin.token)")
+	}
+
+	result := &dropColumnResponse{}
+	if err := json.Unmarshal(b, result); err != nil {
+		return nil, fmt.Errorf("Error unmarshalling response [%s]: %v", string(b), err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != "" {
+			return nil, fmt.Errorf("%s: %s", result.Message, result.Error)
+		}
+		return nil, errors.New(result.Message)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,59 @@
+package dropcolumn
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var requests []*dropColumnRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, dropColumnPath, r.URL.Path)
+		if r.Header.Get(adminTokenHeader) != "admin" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		req := &dropColumnRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+		requests = append(requests, req)
+		if req.Column == "unknown" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"Error dropping column","error":"Column unknown doesn't exist in table events in postgres"}`))
+			return
+		}
+		if req.ConfirmationToken == "" {
+			w.Write([]byte(`{"statements":["ALTER TABLE \"public\".\"events\" DROP COLUMN old_field"],"confirmation_token":"token1"}`))
+			return
+		}
+		w.Write([]byte(`{"statements":["ALTER TABLE \"public\".\"events\" DROP COLUMN old_field"],"dropped":true}`))
+	}))
+	defer server.Close()
+
+	req := &Request{ServerURL: server.URL, AdminToken: "admin", Destination: "pg", Table: "events", Column: "old_field"}
+
+	//wrong confirmation
+	out := &bytes.Buffer{}
+	require.EqualError(t, Run(req, strings.NewReader("yes\n"), out), "dropping is canceled")
+	require.Contains(t, out.String(), `ALTER TABLE "public"."events" DROP COLUMN old_field`)
+	require.Len(t, requests, 1)
+
+	requests = nil
+	out.Reset()
+	require.NoError(t, Run(req, strings.NewReader("old_field\n"), out))
+	require.Len(t, requests, 2)
+	require.Equal(t, "", requests[0].ConfirmationToken)
+	require.Equal(t, &dropColumnRequest{Destination: "pg", Table: "events", Column: "old_field", ConfirmationToken: "token1"}, requests[1])
+	require.Contains(t, out.String(), "Column [old_field] has been dropped")
+
+	require.EqualError(t, Run(&Request{ServerURL: server.URL, AdminToken: "admin", Destination: "pg", Table: "events", Column: "unknown"}, strings.NewReader(""), out),
+		"Error dropping column: Column unknown doesn't exist in table events in postgres")
+	require.EqualError(t, Run(&Request{ServerURL: server.URL, AdminToken: "wrong", Destination: "pg", Table: "events", Column: "old_field"}, strings.NewReader(""), out),
+		"admin token is invalid (server.admin.token)")
+	require.EqualError(t, Run(&Request{ServerURL: server.URL}, strings.NewReader(""), out), "-destination, -table and -column are required")
+}
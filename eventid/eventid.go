@@ -0,0 +1,205 @@
+package eventid
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"github.com/bwmarrin/snowflake"
+	"github.com/google/uuid"
+	"github.com/oklog/ulid"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	UUIDv4    = "uuidv4"
+	UUIDv7    = "uuidv7"
+	ULID      = "ulid"
+	Snowflake = "snowflake"
+
+	eventnKey  = "eventn_ctx"
+	eventIDKey = "event_id"
+
+	maxNodeID = 1023
+)
+
+var schemes = []string{UUIDv4, UUIDv7, ULID, Snowflake}
+
+//Instance is nil if server side event ids aren't configured: events keep ids which are sent by clients (or don't have them)
+var Instance *Generator
+
+//Config dto for deserialized server.event_id config
+//scheme: uuidv4, uuidv7 (time-sortable UUID), ulid (time-sortable) or snowflake (time-sortable 64-bit number as a string)
+//node_id: snowflake node id [0, 1023]. Must be unique per server in cluster deployments. Default: hash of server name
+//override: replace ids which are sent by clients. Default: only events without eventn_ctx.event_id get generated ids
+type Config struct {
+	Scheme   string `mapstructure:"scheme"`
+	NodeID   *int64 `mapstructure:"node_id"`
+	Override bool   `mapstructure:"override"`
+}
+
+//Validate Config values
+func (c *Config) Validate() error {
+	supported := false
+	for _, scheme := range schemes {
+		if c.Scheme == scheme {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("Unknown event_id scheme: [%s]. Available schemes: %v", c.Scheme, schemes)
+	}
+
+	if c.NodeID != nil {
+		if c.Scheme != Snowflake {
+			return fmt.Errorf("event_id node_id is used only with %s scheme", Snowflake)
+		}
+		if *c.NodeID < 0 || *c.NodeID > maxNodeID {
+			return fmt.Errorf("event_id node_id must be in [0, %d] range", maxNodeID)
+		}
+	}
+
+	return nil
+}
+
+//Generator puts generated ids into eventn_ctx.event_id of events
+type Generator struct {
+	scheme   string
+	override bool
+	generate func() string
+}
+
+//Init validate config and create global Generator instance. Instance is nil if config is nil or scheme isn't set
+func Init(config *Config, serverName string) error {
+	if config == nil || config.Scheme == "" {
+		return nil
+	}
+
+	generator, err := NewGenerator(config, serverName)
+	if err != nil {
+		return err
+	}
+
+	Instance = generator
+	return nil
+}
+
+//NewGenerator return Generator of configured scheme
+//serverName is used for snowflake node id if it isn't configured
+func NewGenerator(config *Config, serverName string) (*Generator, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	g := &Generator{scheme: config.Scheme, override: config.Override}
+	switch config.Scheme {
+	case UUIDv4:
+		g.generate = func() string { return uuid.New().String() }
+	case UUIDv7:
+		g.generate = newUUIDv7
+	case ULID:
+		g.generate = newULIDGenerator()
+	case Snowflake:
+		var nodeID int64
+		if config.NodeID != nil {
+			nodeID = *config.NodeID
+		} else {
+			hash := fnv.New32a()
+			hash.Write([]byte(serverName))
+			nodeID = int64(hash.Sum32() % (maxNodeID + 1))
+			log.Printf("event_id node_id wasn't provided. Node id %d is used (hash of server name [%s])", nodeID, serverName)
+		}
+		node, err := snowflake.NewNode(nodeID)
+		if err != nil {
+			return nil, fmt.Errorf("Error creating snowflake node: %v", err)
+		}
+		g.generate = func() string { return node.Generate().String() }
+	}
+
+	return g, nil
+}
+
+//Apply put id into eventn_ctx.event_id of global Generator instance (if it is configured)
+func Apply(fact map[string]interface{}) {
+	if Instance == nil {
+		return
+	}
+
+	Instance.Apply(fact)
+}
+
+//Get return eventn_ctx.event_id of the event or empty string if it doesn't have one
+func Get(fact map[string]interface{}) string {
+	eventCtx, ok := fact[eventnKey].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	id, ok := eventCtx[eventIDKey]
+	if !ok || id == nil {
+		return ""
+	}
+
+	return fmt.Sprint(id)
+}
+
+//Apply put generated id into eventn_ctx.event_id if it is empty or override is configured
+//event without eventn_ctx object gets it
+func (g *Generator) Apply(fact map[string]interface{}) {
+	eventCtx, ok := fact[eventnKey].(map[string]interface{})
+	if !ok {
+		eventCtx = map[string]interface{}{}
+		fact[eventnKey] = eventCtx
+	}
+
+	if !g.override {
+		if id, ok := eventCtx[eventIDKey]; ok && id != nil && id != "" {
+			return
+		}
+	}
+
+	eventCtx[eventIDKey] = g.Generate()
+}
+
+//Generate return new id
+func (g *Generator) Generate() string {
+	return g.generate()
+}
+
+//UUID version 7: 48-bit unix milliseconds timestamp and random bits
+//ids of the same millisecond aren't ordered
+func newUUIDv7() string {
+	var id uuid.UUID
+	if _, err := rand.Read(id[6:]); err != nil {
+		return uuid.New().String()
+	}
+
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(time.Now().UnixNano()/int64(time.Millisecond)))
+	copy(id[:6], ms[2:])
+	id[6] = (id[6] & 0x0f) | 0x70
+	id[8] = (id[8] & 0x3f) | 0x80
+
+	return id.String()
+}
+
+//return ULID generator with monotonic entropy: ids of the same millisecond are ordered too
+func newULIDGenerator() func() string {
+	var mutex sync.Mutex
+	entropy := ulid.Monotonic(rand.Reader, 0)
+	return func() string {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		id, err := ulid.New(ulid.Timestamp(time.Now()), entropy)
+		if err != nil {
+			//monotonic entropy overflow in the same millisecond
+			return ulid.MustNew(ulid.Timestamp(time.Now()), rand.Reader).String()
+		}
+
+		return id.String()
+	}
+}
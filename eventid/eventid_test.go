@@ -0,0 +1,149 @@
+package eventid
+
+import (
+	"github.com/google/uuid"
+	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *Config
+		validate func(t *testing.T, id string)
+		sortable bool
+	}{
+		{
+			"uuidv4",
+			&Config{Scheme: UUIDv4},
+			func(t *testing.T, id string) {
+				parsed, err := uuid.Parse(id)
+				require.NoError(t, err)
+				require.Equal(t, uuid.Version(4), parsed.Version())
+			},
+			false,
+		},
+		{
+			"uuidv7",
+			&Config{Scheme: UUIDv7},
+			func(t *testing.T, id string) {
+				parsed, err := uuid.Parse(id)
+				require.NoError(t, err)
+				require.Equal(t, uuid.Version(7), parsed.Version())
+				require.Equal(t, uuid.RFC4122, parsed.Variant())
+			},
+			true,
+		},
+		{
+			"ulid",
+			&Config{Scheme: ULID},
+			func(t *testing.T, id string) {
+				_, err := ulid.ParseStrict(id)
+				require.NoError(t, err)
+			},
+			true,
+		},
+		{
+			"snowflake",
+			&Config{Scheme: Snowflake},
+			func(t *testing.T, id string) {
+				_, err := strconv.ParseInt(id, 10, 64)
+				require.NoError(t, err)
+			},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator, err := NewGenerator(tt.config, "test-server")
+			require.NoError(t, err)
+
+			first := generator.Generate()
+			tt.validate(t, first)
+
+			//next millisecond
+			time.Sleep(2 * time.Millisecond)
+			second := generator.Generate()
+			tt.validate(t, second)
+			require.NotEqual(t, first, second)
+			if tt.sortable {
+				if tt.config.Scheme == Snowflake {
+					firstNumber, _ := strconv.ParseInt(first, 10, 64)
+					secondNumber, _ := strconv.ParseInt(second, 10, 64)
+					require.True(t, firstNumber < secondNumber, "%s must be less than %s", first, second)
+				} else {
+					require.True(t, first < second, "%s must be less than %s", first, second)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	nodeID := int64(5)
+	tooLargeNodeID := int64(1024)
+	tests := []struct {
+		name        string
+		config      *Config
+		expectedErr string
+	}{
+		{"unknown scheme", &Config{Scheme: "uuidv1"}, "Unknown event_id scheme: [uuidv1]. Available schemes: [uuidv4 uuidv7 ulid snowflake]"},
+		{"node_id without snowflake", &Config{Scheme: ULID, NodeID: &nodeID}, "event_id node_id is used only with snowflake scheme"},
+		{"node_id out of range", &Config{Scheme: Snowflake, NodeID: &tooLargeNodeID}, "event_id node_id must be in [0, 1023] range"},
+		{"snowflake with node_id", &Config{Scheme: Snowflake, NodeID: &nodeID}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		override bool
+		input    map[string]interface{}
+		keepID   string
+	}{
+		{"without eventn_ctx", false, map[string]interface{}{"event_type": "pageview"}, ""},
+		{"without event_id", false, map[string]interface{}{"eventn_ctx": map[string]interface{}{"user_id": "1"}}, ""},
+		{"empty event_id", false, map[string]interface{}{"eventn_ctx": map[string]interface{}{"event_id": ""}}, ""},
+		{"client event_id", false, map[string]interface{}{"eventn_ctx": map[string]interface{}{"event_id": "client-id"}}, "client-id"},
+		{"client event_id override", true, map[string]interface{}{"eventn_ctx": map[string]interface{}{"event_id": "client-id"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator, err := NewGenerator(&Config{Scheme: ULID, Override: tt.override}, "test-server")
+			require.NoError(t, err)
+
+			generator.Apply(tt.input)
+
+			eventCtx, ok := tt.input["eventn_ctx"].(map[string]interface{})
+			require.True(t, ok)
+			id, ok := eventCtx["event_id"].(string)
+			require.True(t, ok)
+			if tt.keepID != "" {
+				require.Equal(t, tt.keepID, id)
+			} else {
+				_, err := ulid.ParseStrict(id)
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	require.Equal(t, "", Get(map[string]interface{}{"event_type": "pageview"}))
+	require.Equal(t, "", Get(map[string]interface{}{"eventn_ctx": map[string]interface{}{"event_id": nil}}))
+	require.Equal(t, "e1", Get(map[string]interface{}{"eventn_ctx": map[string]interface{}{"event_id": "e1"}}))
+	require.Equal(t, "123", Get(map[string]interface{}{"eventn_ctx": map[string]interface{}{"event_id": 123}}))
+}
@@ -6,13 +6,22 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"sync"
 )
 
+//rotator is implemented by rolling file writers (e.g. lumberjack.Logger)
+type rotator interface {
+	Rotate() error
+}
+
 //AsyncLogger write json logs to file system in different goroutine
 type AsyncLogger struct {
 	writer             io.WriteCloser
 	logCh              chan Fact
 	showInGlobalLogger bool
+
+	rotateMutex sync.Mutex
+	rotatedCh   chan error
 }
 
 //Consume event fact and put it to channel
@@ -20,6 +29,29 @@ func (al *AsyncLogger) Consume(fact Fact) {
 	al.logCh <- fact
 }
 
+//Rotate wait until already consumed events are written and rotate log file if the writer supports it
+//so the file can be uploaded without waiting for the next scheduled rotation
+func (al *AsyncLogger) Rotate() error {
+	al.rotateMutex.Lock()
+	defer al.rotateMutex.Unlock()
+
+	//nil fact is a rotation marker: all facts before it are already written
+	al.logCh <- nil
+	return <-al.rotatedCh
+}
+
+func (al *AsyncLogger) rotate() error {
+	r, ok := al.writer.(rotator)
+	if !ok {
+		return nil
+	}
+
+	if err := r.Rotate(); err != nil {
+		return fmt.Errorf("Error rotating log file: %v", err)
+	}
+	return nil
+}
+
 //Close underlying log file writer
 func (al *AsyncLogger) Close() (resultErr error) {
 	if err := al.writer.Close(); err != nil {
@@ -28,13 +60,18 @@ func (al *AsyncLogger) Close() (resultErr error) {
 	return nil
 }
 
-//Create AsyncLogger and run goroutine that's read from channel and write to file
-func NewAsyncLogger(writer io.WriteCloser, showInGlobalLogger bool) Consumer {
-	logger := &AsyncLogger{writer: writer, logCh: make(chan Fact, 20000), showInGlobalLogger: showInGlobalLogger}
+//Create AsyncLogger with bufferSize channel and run goroutine that's read from channel and write to file
+func NewAsyncLogger(writer io.WriteCloser, showInGlobalLogger bool, bufferSize int) *AsyncLogger {
+	logger := &AsyncLogger{writer: writer, logCh: make(chan Fact, bufferSize), showInGlobalLogger: showInGlobalLogger, rotatedCh: make(chan error)}
 
 	go func() {
 		for {
 			fact := <-logger.logCh
+			if fact == nil {
+				logger.rotatedCh <- logger.rotate()
+				continue
+			}
+
 			bts, err := json.Marshal(fact)
 			if err != nil {
 				log.Printf("Error marshaling event to json: %v", err)
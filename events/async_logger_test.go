@@ -0,0 +1,40 @@
+package events
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type rotatingWriterMock struct {
+	current *bytes.Buffer
+	rotated []string
+}
+
+func (rwm *rotatingWriterMock) Write(p []byte) (int, error) {
+	return rwm.current.Write(p)
+}
+
+func (rwm *rotatingWriterMock) Rotate() error {
+	rwm.rotated = append(rwm.rotated, rwm.current.String())
+	rwm.current = &bytes.Buffer{}
+	return nil
+}
+
+func (rwm *rotatingWriterMock) Close() error {
+	return nil
+}
+
+func TestAsyncLoggerRotate(t *testing.T) {
+	writer := &rotatingWriterMock{current: &bytes.Buffer{}}
+	logger := NewAsyncLogger(writer, false, 10)
+
+	logger.Consume(Fact{"event_type": "pageview"})
+	logger.Consume(Fact{"event_type": "click"})
+	require.NoError(t, logger.Rotate())
+	require.Equal(t, []string{"{\"event_type\":\"pageview\"}\n{\"event_type\":\"click\"}\n"}, writer.rotated)
+
+	logger.Consume(Fact{"event_type": "conversion"})
+	require.NoError(t, logger.Rotate())
+	require.Equal(t, []string{"{\"event_type\":\"pageview\"}\n{\"event_type\":\"click\"}\n", "{\"event_type\":\"conversion\"}\n"}, writer.rotated)
+}
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+//Cache keeps last N serialized events per token in memory (for admin API)
+type Cache struct {
+	mutex    sync.RWMutex
+	capacity int
+	tokens   map[string]*ring
+}
+
+//ring buffer of serialized events
+type ring struct {
+	events [][]byte
+	next   int
+	full   bool
+}
+
+//NewCache return Cache with capacity events per token
+func NewCache(capacity int) *Cache {
+	return &Cache{capacity: capacity, tokens: map[string]*ring{}}
+}
+
+//Put serialize fact and put it into token buffer (the oldest event is replaced if buffer is full)
+func (c *Cache) Put(token string, fact Fact) {
+	if c.capacity <= 0 {
+		return
+	}
+
+	b, err := json.Marshal(fact)
+	if err != nil {
+		log.Println("Error marshaling event for cache:", err)
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	r, ok := c.tokens[token]
+	if !ok {
+		r = &ring{events: make([][]byte, c.capacity)}
+		c.tokens[token] = r
+	}
+
+	r.events[r.next] = b
+	r.next = (r.next + 1) % c.capacity
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+//GetN return up to n last events of the token (the newest first)
+func (c *Cache) GetN(token string, n int) []json.RawMessage {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	result := []json.RawMessage{}
+	r, ok := c.tokens[token]
+	if !ok {
+		return result
+	}
+
+	size := r.next
+	if r.full {
+		size = c.capacity
+	}
+	if n <= 0 || n > size {
+		n = size
+	}
+
+	for i := 1; i <= n; i++ {
+		index := (r.next - i + c.capacity) % c.capacity
+		result = append(result, r.events[index])
+	}
+
+	return result
+}
@@ -0,0 +1,32 @@
+package events
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	cache := NewCache(3)
+	require.Equal(t, 0, len(cache.GetN("token1", 10)))
+
+	cache.Put("token1", Fact{"id": 1})
+	cache.Put("token1", Fact{"id": 2})
+	cache.Put("token2", Fact{"id": 3})
+
+	actual := cache.GetN("token1", 10)
+	require.Equal(t, 2, len(actual))
+	require.JSONEq(t, `{"id":2}`, string(actual[0]))
+	require.JSONEq(t, `{"id":1}`, string(actual[1]))
+
+	cache.Put("token1", Fact{"id": 4})
+	cache.Put("token1", Fact{"id": 5})
+
+	actual = cache.GetN("token1", 2)
+	require.Equal(t, 2, len(actual))
+	require.JSONEq(t, `{"id":5}`, string(actual[0]))
+	require.JSONEq(t, `{"id":4}`, string(actual[1]))
+
+	actual = cache.GetN("token1", 0)
+	require.Equal(t, 3, len(actual))
+	require.JSONEq(t, `{"id":2}`, string(actual[2]))
+}
@@ -0,0 +1,145 @@
+package events
+
+import (
+	"errors"
+	"github.com/ksensehq/eventnative/timestamp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSentAtField    = "/sent_at"
+	defaultTimeField      = "/eventn_ctx/utc_time"
+	defaultOriginalField  = "/eventn_ctx/original_utc_time"
+	defaultCorrectedField = "/eventn_ctx/utc_time"
+)
+
+//ClockSkewConfig dto for deserialized server.clock_skew config
+//sent_at_field: client time when the event was sent (e.g. after offline queue). Default: /sent_at
+//time_field: client time of the event. Default: /eventn_ctx/utc_time
+//original_field: original value of time_field is written here. Default: /eventn_ctx/original_utc_time
+//corrected_field: corrected time is written here. Default: /eventn_ctx/utc_time (the same as time_field)
+type ClockSkewConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`
+	SentAtField    string `mapstructure:"sent_at_field"`
+	TimeField      string `mapstructure:"time_field"`
+	OriginalField  string `mapstructure:"original_field"`
+	CorrectedField string `mapstructure:"corrected_field"`
+}
+
+//Validate ClockSkewConfig fields and set default values
+func (csc *ClockSkewConfig) Validate() error {
+	if csc.SentAtField == "" {
+		csc.SentAtField = defaultSentAtField
+	}
+	if csc.TimeField == "" {
+		csc.TimeField = defaultTimeField
+	}
+	if csc.OriginalField == "" {
+		csc.OriginalField = defaultOriginalField
+	}
+	if csc.CorrectedField == "" {
+		csc.CorrectedField = defaultCorrectedField
+	}
+	if csc.OriginalField == csc.CorrectedField {
+		return errors.New("clock_skew.original_field and clock_skew.corrected_field can't be the same")
+	}
+
+	return nil
+}
+
+//ClockSkew corrects client event time with the skew between client and server clocks (like Segment does):
+//corrected time = event time + (received at - sent at), where received at is the server time of the request
+//events without sent at or event time fields (or with malformed ones) aren't changed
+type ClockSkew struct {
+	sentAtField    []string
+	timeField      []string
+	originalField  []string
+	correctedField []string
+}
+
+//NewClockSkew return ClockSkew or nil if it isn't enabled
+func NewClockSkew(config *ClockSkewConfig) (*ClockSkew, error) {
+	if config == nil || !config.Enabled {
+		return nil, nil
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &ClockSkew{
+		sentAtField:    splitPath(config.SentAtField),
+		timeField:      splitPath(config.TimeField),
+		originalField:  splitPath(config.OriginalField),
+		correctedField: splitPath(config.CorrectedField),
+	}, nil
+}
+
+//Apply write original and corrected event time into configured fields
+func (cs *ClockSkew) Apply(fact Fact, receivedAt time.Time) {
+	sentAt, ok := parseTime(getByPath(fact, cs.sentAtField))
+	if !ok {
+		return
+	}
+	rawEventTime := getByPath(fact, cs.timeField)
+	eventTime, ok := parseTime(rawEventTime)
+	if !ok {
+		return
+	}
+
+	corrected := eventTime.Add(receivedAt.Sub(sentAt))
+	setByPath(fact, cs.originalField, rawEventTime)
+	setByPath(fact, cs.correctedField, corrected.UTC().Format(timestamp.Layout))
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func parseTime(value interface{}) (time.Time, bool) {
+	str, ok := value.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, str)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+//return value by path or nil if it doesn't exist
+func getByPath(object map[string]interface{}, path []string) interface{} {
+	for _, key := range path[:len(path)-1] {
+		inner, ok := object[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		object = inner
+	}
+
+	return object[path[len(path)-1]]
+}
+
+//put value by path and create missing intermediate objects. Value isn't put if intermediate value isn't an object
+func setByPath(object map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		inner, ok := object[key]
+		if !ok {
+			created := map[string]interface{}{}
+			object[key] = created
+			object = created
+			continue
+		}
+		innerObject, ok := inner.(map[string]interface{})
+		if !ok {
+			return
+		}
+		object = innerObject
+	}
+
+	object[path[len(path)-1]] = value
+}
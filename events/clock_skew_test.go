@@ -0,0 +1,66 @@
+package events
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestClockSkewApply(t *testing.T) {
+	receivedAt := time.Date(2020, 8, 2, 18, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		config   *ClockSkewConfig
+		input    Fact
+		expected Fact
+	}{
+		{
+			"client clock is behind",
+			&ClockSkewConfig{Enabled: true},
+			Fact{"sent_at": "2020-08-02T18:20:00.000000Z", "eventn_ctx": map[string]interface{}{"utc_time": "2020-08-02T18:19:30.000000Z"}},
+			Fact{"sent_at": "2020-08-02T18:20:00.000000Z", "eventn_ctx": map[string]interface{}{"utc_time": "2020-08-02T18:29:30.000000Z", "original_utc_time": "2020-08-02T18:19:30.000000Z"}},
+		},
+		{
+			"client clock is ahead",
+			&ClockSkewConfig{Enabled: true},
+			Fact{"sent_at": "2020-08-02T19:30:00Z", "eventn_ctx": map[string]interface{}{"utc_time": "2020-08-02T19:29:00.500Z"}},
+			Fact{"sent_at": "2020-08-02T19:30:00Z", "eventn_ctx": map[string]interface{}{"utc_time": "2020-08-02T18:29:00.500000Z", "original_utc_time": "2020-08-02T19:29:00.500Z"}},
+		},
+		{
+			"custom fields",
+			&ClockSkewConfig{Enabled: true, SentAtField: "/eventn_ctx/sent_at", TimeField: "/event_time", OriginalField: "/event_time", CorrectedField: "/meta/corrected_time"},
+			Fact{"event_time": "2020-08-02T18:19:30Z", "eventn_ctx": map[string]interface{}{"sent_at": "2020-08-02T18:20:00Z"}},
+			Fact{"event_time": "2020-08-02T18:19:30Z", "eventn_ctx": map[string]interface{}{"sent_at": "2020-08-02T18:20:00Z"}, "meta": map[string]interface{}{"corrected_time": "2020-08-02T18:29:30.000000Z"}},
+		},
+		{
+			"without sent_at",
+			&ClockSkewConfig{Enabled: true},
+			Fact{"eventn_ctx": map[string]interface{}{"utc_time": "2020-08-02T18:19:30.000000Z"}},
+			Fact{"eventn_ctx": map[string]interface{}{"utc_time": "2020-08-02T18:19:30.000000Z"}},
+		},
+		{
+			"malformed event time",
+			&ClockSkewConfig{Enabled: true},
+			Fact{"sent_at": "2020-08-02T18:20:00Z", "eventn_ctx": map[string]interface{}{"utc_time": "yesterday"}},
+			Fact{"sent_at": "2020-08-02T18:20:00Z", "eventn_ctx": map[string]interface{}{"utc_time": "yesterday"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clockSkew, err := NewClockSkew(tt.config)
+			require.NoError(t, err)
+
+			clockSkew.Apply(tt.input, receivedAt)
+			require.Equal(t, tt.expected, tt.input)
+		})
+	}
+}
+
+func TestNewClockSkew(t *testing.T) {
+	clockSkew, err := NewClockSkew(&ClockSkewConfig{})
+	require.NoError(t, err)
+	require.Nil(t, clockSkew)
+
+	_, err = NewClockSkew(&ClockSkewConfig{Enabled: true, OriginalField: "/eventn_ctx/utc_time"})
+	require.EqualError(t, err, "clock_skew.original_field and clock_skew.corrected_field can't be the same")
+}
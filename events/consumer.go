@@ -6,7 +6,30 @@ import (
 
 type Fact map[string]interface{}
 
+//ForwardedByKey is put into events which have been forwarded by another node (see forwarding package) with the sender name
+//such events aren't forwarded again and the key isn't written into destinations
+const ForwardedByKey = "_forwarded_by"
+
 type Consumer interface {
 	io.Closer
 	Consume(fact Fact)
 }
+
+//DestinationsConsumer is implemented by consumers of destinations (stream destinations and event log files of batch ones):
+//Destinations return names of destinations which the fact is passed into by Consume
+type DestinationsConsumer interface {
+	Consumer
+	Destinations(fact Fact) []string
+}
+
+//TokenizedConsumers return consumers of events which are sent with the token
+type TokenizedConsumers interface {
+	Consumers(token string) []Consumer
+}
+
+//ConsumersByToken is TokenizedConsumers which doesn't change
+type ConsumersByToken map[string][]Consumer
+
+func (cbt ConsumersByToken) Consumers(token string) []Consumer {
+	return cbt[token]
+}
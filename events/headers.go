@@ -0,0 +1,71 @@
+package events
+
+import (
+	"log"
+	"net/http"
+	"strings"
+)
+
+const headersKey = "headers"
+
+//DefaultCapturedHeaders is used for tokens without explicit headers configuration
+//it doesn't contain any credentials headers (e.g. Cookie or Authorization)
+var DefaultCapturedHeaders = []string{"X-Forwarded-For", "X-Real-IP", "CF-IPCountry"}
+
+//HeadersCapture put configured request headers into eventnKey.headers object
+//e.g. X-Forwarded-For: 1.1.1.1, 2.2.2.2 -> {"eventn_ctx": {"headers": {"x_forwarded_for": "1.1.1.1, 2.2.2.2"}}}
+type HeadersCapture struct {
+	defaultHeaders []string
+	tokenHeaders   map[string][]string
+}
+
+//NewHeadersCapture return HeadersCapture with headers per token
+//tokens which aren't in tokenHeaders use defaultHeaders
+//note: tokens are compared in lower case because viper lowercases all config map keys
+func NewHeadersCapture(defaultHeaders []string, tokenHeaders map[string][]string) *HeadersCapture {
+	lowerTokenHeaders := map[string][]string{}
+	for token, headers := range tokenHeaders {
+		lowerTokenHeaders[strings.ToLower(token)] = headers
+	}
+
+	return &HeadersCapture{defaultHeaders: defaultHeaders, tokenHeaders: lowerTokenHeaders}
+}
+
+//Capture put configured headers values into fact eventnKey object. Multiple values of one header are joined with ", "
+//skip if fact doesn't have eventnKey object or there are no configured headers in request
+func (hc *HeadersCapture) Capture(token string, header http.Header, fact Fact) {
+	headerNames, ok := hc.tokenHeaders[strings.ToLower(token)]
+	if !ok {
+		headerNames = hc.defaultHeaders
+	}
+
+	captured := map[string]interface{}{}
+	for _, name := range headerNames {
+		values, ok := header[http.CanonicalHeaderKey(name)]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		captured[headerKey(name)] = strings.Join(values, ", ")
+	}
+
+	if len(captured) == 0 {
+		return
+	}
+
+	eventnObject, ok := fact[eventnKey]
+	if !ok {
+		return
+	}
+	eventCtx, ok := eventnObject.(map[string]interface{})
+	if !ok {
+		log.Printf("Unable to capture headers: %s isn't an object: %v", eventnKey, eventnObject)
+		return
+	}
+
+	eventCtx[headersKey] = captured
+}
+
+//X-Forwarded-For -> x_forwarded_for
+func headerKey(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+}
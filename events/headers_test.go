@@ -0,0 +1,57 @@
+package events
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestHeadersCapture(t *testing.T) {
+	capture := NewHeadersCapture(DefaultCapturedHeaders, map[string][]string{
+		"custom": {"X-Custom-Header"},
+		"none":   {},
+	})
+	header := http.Header{
+		"X-Forwarded-For": []string{"10.10.10.10, 10.10.10.11", "10.10.10.12"},
+		"X-Custom-Header": []string{"value"},
+		"Cookie":          []string{"secret"},
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		input    Fact
+		expected Fact
+	}{
+		{
+			"Default headers",
+			"token",
+			Fact{"eventn_ctx": map[string]interface{}{}},
+			Fact{"eventn_ctx": map[string]interface{}{"headers": map[string]interface{}{"x_forwarded_for": "10.10.10.10, 10.10.10.11, 10.10.10.12"}}},
+		},
+		{
+			"Token headers",
+			"custom",
+			Fact{"eventn_ctx": map[string]interface{}{}},
+			Fact{"eventn_ctx": map[string]interface{}{"headers": map[string]interface{}{"x_custom_header": "value"}}},
+		},
+		{
+			"Token without headers",
+			"none",
+			Fact{"eventn_ctx": map[string]interface{}{}},
+			Fact{"eventn_ctx": map[string]interface{}{}},
+		},
+		{
+			"Fact without eventn_ctx",
+			"token",
+			Fact{},
+			Fact{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capture.Capture(tt.token, header, tt.input)
+			require.Equal(t, tt.expected, tt.input, "Facts aren't equal")
+		})
+	}
+}
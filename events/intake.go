@@ -0,0 +1,108 @@
+package events
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/eventid"
+	"github.com/ksensehq/eventnative/timestamp"
+	"net/http"
+	"time"
+)
+
+const (
+	apiTokenKey = "api_key"
+
+	//event doesn't match the token JSON schema
+	InvalidRejection = "invalid"
+	//event can't be preprocessed (e.g. eventn_ctx is missing)
+	MalformedRejection = "malformed"
+	//event is over large_events.max_size and it can't be handled by configured action
+	OversizedRejection = "oversized"
+)
+
+//RejectionError is returned by Intake if incoming event is rejected: reason is one of Rejection constants
+type RejectionError struct {
+	Reason string
+	Err    error
+}
+
+func (re *RejectionError) Error() string {
+	return fmt.Sprintf("Event is %s: %v", re.Reason, re.Err)
+}
+
+//Intake is validation and enrichment of incoming events which are shared by all sources (HTTP API and UDP listeners):
+//1. validate event against the token JSON schema (before preprocessing)
+//2. preprocess event with the source preprocessor (geo, user agent)
+//3. capture request headers
+//4. put event id, api token and receive time and correct client time (clock skew)
+//5. apply large events policy to oversized events
+type Intake struct {
+	preprocessor   Preprocessor
+	headersCapture *HeadersCapture
+	sizePolicy     *SizePolicy
+	clockSkew      *ClockSkew
+	schemas        *TokenSchemas
+}
+
+//NewIntake return Intake with the source preprocessor
+//headersCapture is optional: request headers aren't captured
+//sizePolicy is optional: large events handling
+//clockSkew is optional: client event time correction
+//schemas is optional: events which don't match the token JSON schema are rejected
+func NewIntake(preprocessor Preprocessor, headersCapture *HeadersCapture, sizePolicy *SizePolicy, clockSkew *ClockSkew, schemas *TokenSchemas) *Intake {
+	return &Intake{
+		preprocessor:   preprocessor,
+		headersCapture: headersCapture,
+		sizePolicy:     sizePolicy,
+		clockSkew:      clockSkew,
+		schemas:        schemas,
+	}
+}
+
+//SizePolicy return large events policy or nil if it isn't configured
+func (i *Intake) SizePolicy() *SizePolicy {
+	return i.sizePolicy
+}
+
+//Accept validate and enrich the fact of the token. size is raw event size (large events policy is applied if it is oversized),
+//r is the request of the event (preprocessors resolve geo from its headers or remote address)
+//return processed fact and true if it should be passed to destinations (false if it has been handled by large events policy)
+//return *RejectionError if the fact is rejected and other errors if it can't be handled
+func (i *Intake) Accept(token string, fact Fact, size int, r *http.Request, receivedAt time.Time) (Fact, bool, error) {
+	if err := i.schemas.Validate(token, fact); err != nil {
+		counters.InvalidEvents(token, 1)
+		return nil, false, &RejectionError{Reason: InvalidRejection, Err: err}
+	}
+
+	processed, err := i.preprocessor.Preprocess(fact, r)
+	if err != nil {
+		return nil, false, &RejectionError{Reason: MalformedRejection, Err: err}
+	}
+
+	if i.headersCapture != nil {
+		i.headersCapture.Capture(token, r.Header, processed)
+	}
+	eventid.Apply(processed)
+
+	processed[apiTokenKey] = token
+	processed[timestamp.Key] = receivedAt.UTC().Format(timestamp.Layout)
+	if i.clockSkew != nil {
+		i.clockSkew.Apply(processed, receivedAt)
+	}
+
+	if i.sizePolicy != nil && i.sizePolicy.Oversized(size) {
+		counters.OversizedEvents(token, 1)
+		pass, err := i.sizePolicy.Apply(processed)
+		if err == ErrEventTooLarge {
+			return nil, false, &RejectionError{Reason: OversizedRejection, Err: err}
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("Error handling large event: %v", err)
+		}
+		if !pass {
+			return nil, false, nil
+		}
+	}
+
+	return processed, true, nil
+}
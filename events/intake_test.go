@@ -0,0 +1,62 @@
+package events
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+//Preprocessor mock: return copy of the fact with src field or err if eventn_ctx is missing
+type preprocessorMock struct{}
+
+func (pm *preprocessorMock) Preprocess(fact Fact, r *http.Request) (Fact, error) {
+	if _, ok := fact[eventnKey]; !ok {
+		return nil, errors.New("eventn_ctx is missing")
+	}
+
+	processed := Fact{"src": "mock"}
+	for k, v := range fact {
+		processed[k] = v
+	}
+	return processed, nil
+}
+
+func TestIntakeAccept(t *testing.T) {
+	dir, err := ioutil.TempDir("", "intake")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	schemaPath := path.Join(dir, "event.json")
+	require.NoError(t, ioutil.WriteFile(schemaPath, []byte(`{"type": "object", "required": ["event_type"]}`), 0644))
+	schemas, err := NewTokenSchemas([]*JSONSchemaConfig{{APIKeys: []string{"js"}, Schema: schemaPath}})
+	require.NoError(t, err)
+	sizePolicy, err := NewSizePolicy(&SizePolicyConfig{MaxSize: 100}, nil)
+	require.NoError(t, err)
+
+	intake := NewIntake(&preprocessorMock{}, nil, sizePolicy, nil, schemas)
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/event", nil)
+	receivedAt := time.Date(2020, 6, 16, 23, 0, 0, 0, time.UTC)
+
+	_, _, err = intake.Accept("js", Fact{eventnKey: map[string]interface{}{}}, 50, request, receivedAt)
+	require.IsType(t, &RejectionError{}, err)
+	require.Equal(t, InvalidRejection, err.(*RejectionError).Reason)
+
+	_, _, err = intake.Accept("js", Fact{"event_type": "pageview"}, 50, request, receivedAt)
+	require.EqualError(t, err, "Event is malformed: eventn_ctx is missing")
+
+	_, _, err = intake.Accept("js", Fact{"event_type": "pageview", eventnKey: map[string]interface{}{}}, 150, request, receivedAt)
+	require.IsType(t, &RejectionError{}, err)
+	require.Equal(t, OversizedRejection, err.(*RejectionError).Reason)
+
+	processed, pass, err := intake.Accept("js", Fact{"event_type": "pageview", eventnKey: map[string]interface{}{}}, 50, request, receivedAt)
+	require.NoError(t, err)
+	require.True(t, pass)
+	require.Equal(t, Fact{"event_type": "pageview", eventnKey: map[string]interface{}{}, "src": "mock", "api_key": "js",
+		"_timestamp": "2020-06-16T23:00:00.000000Z"}, processed)
+}
@@ -0,0 +1,62 @@
+package events
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/jsonschema"
+)
+
+//JSONSchemaConfig dto for deserialized one server.json_schemas item
+//schema: path to JSON Schema file which incoming events of api_keys tokens are validated against (before preprocessing)
+type JSONSchemaConfig struct {
+	APIKeys []string `mapstructure:"api_keys"`
+	Schema  string   `mapstructure:"schema"`
+}
+
+//TokenSchemas keeps JSON schemas per token. Events which don't match the token schema are rejected by http api
+type TokenSchemas struct {
+	schemas map[string]*jsonschema.Schema
+}
+
+//NewTokenSchemas load schema files and return TokenSchemas or nil if configs are empty
+//return err if a token has more than one schema or a schema file can't be loaded
+func NewTokenSchemas(configs []*JSONSchemaConfig) (*TokenSchemas, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+
+	schemas := map[string]*jsonschema.Schema{}
+	for i, config := range configs {
+		if config == nil || config.Schema == "" || len(config.APIKeys) == 0 {
+			return nil, fmt.Errorf("json_schemas item #%d: api_keys and schema are required", i+1)
+		}
+
+		schema, err := jsonschema.Load(config.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("json_schemas item #%d: %v", i+1, err)
+		}
+
+		for _, token := range config.APIKeys {
+			if _, ok := schemas[token]; ok {
+				return nil, fmt.Errorf("json_schemas item #%d: token [%s] already has a schema", i+1, token)
+			}
+			schemas[token] = schema
+		}
+	}
+
+	return &TokenSchemas{schemas: schemas}, nil
+}
+
+//Validate fact against the token schema
+//return *jsonschema.ValidationError if the fact doesn't match it. Facts of tokens without schema are always valid
+func (ts *TokenSchemas) Validate(token string, fact Fact) error {
+	if ts == nil {
+		return nil
+	}
+
+	schema, ok := ts.schemas[token]
+	if !ok {
+		return nil
+	}
+
+	return schema.Validate(map[string]interface{}(fact))
+}
@@ -0,0 +1,35 @@
+package events
+
+import (
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestTokenSchemas(t *testing.T) {
+	dir, err := ioutil.TempDir("", "json_schemas")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	schemaPath := path.Join(dir, "event.json")
+	require.NoError(t, ioutil.WriteFile(schemaPath, []byte(`{"type": "object", "required": ["event_type"]}`), 0644))
+
+	schemas, err := NewTokenSchemas(nil)
+	require.NoError(t, err)
+	require.Nil(t, schemas)
+	require.NoError(t, schemas.Validate("js", Fact{}))
+
+	_, err = NewTokenSchemas([]*JSONSchemaConfig{{APIKeys: []string{"js"}}})
+	require.EqualError(t, err, "json_schemas item #1: api_keys and schema are required")
+
+	_, err = NewTokenSchemas([]*JSONSchemaConfig{{APIKeys: []string{"js"}, Schema: schemaPath}, {APIKeys: []string{"js"}, Schema: schemaPath}})
+	require.EqualError(t, err, "json_schemas item #2: token [js] already has a schema")
+
+	schemas, err = NewTokenSchemas([]*JSONSchemaConfig{{APIKeys: []string{"js"}, Schema: schemaPath}})
+	require.NoError(t, err)
+	require.NoError(t, schemas.Validate("js", Fact{"event_type": "pageview"}))
+	require.NoError(t, schemas.Validate("s2s", Fact{}), "token without schema")
+	require.EqualError(t, schemas.Validate("js", Fact{}), "/: required property [event_type] is missing")
+}
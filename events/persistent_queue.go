@@ -5,10 +5,30 @@ import (
 	"errors"
 	"fmt"
 	"github.com/joncrlsn/dque"
+	"github.com/ksensehq/eventnative/compression"
+	"github.com/ksensehq/eventnative/webhooks"
+	"sync/atomic"
 )
 
 const eventsPerPersistedFile = 2000
 
+//ErrQueueClosed is returned by DequeueBlock after the queue has been closed: stream consumer goroutine must exit
+var ErrQueueClosed = errors.New("event queue is closed")
+
+//compression codec of enqueued events (empty if they aren't compressed). It is set once on startup
+var queueCompression string
+
+//SetQueueCompression validate and set compression codec (gzip or zstd) of events which are put into disk and external queues
+//events are dequeued regardless of the codec they were enqueued with (e.g. after the codec has been changed)
+func SetQueueCompression(codec string) error {
+	if err := compression.Validate(codec, compression.Gzip, compression.Zstd); err != nil {
+		return fmt.Errorf("queue_compression: %v", err)
+	}
+
+	queueCompression = codec
+	return nil
+}
+
 type QueuedFact struct {
 	FactBytes []byte
 }
@@ -19,8 +39,31 @@ func QueuedFactBuilder() interface{} {
 	return &QueuedFact{}
 }
 
+//AckFunc acknowledges the dequeued event
+type AckFunc func() error
+
+//QueueBackend keeps serialized events. Local disk queue is used by default, external queues (shared by all nodes) in stateless mode
+//external queues keep dequeued events until they are acknowledged: not acknowledged ones (e.g. the node has crashed
+//while inserting them) are consumed again. Local disk queue doesn't keep them (AckFunc is no-op)
+type QueueBackend interface {
+	Enqueue(factBytes []byte) error
+	DequeueBlock() ([]byte, AckFunc, error)
+	Size() int
+	Close() error
+}
+
+//NoAck is AckFunc of queues which don't keep dequeued events
+func NoAck() error {
+	return nil
+}
+
+//PersistentQueue is a disk-backed (or external in stateless mode) events queue
+//webhooks.QueueThreshold event is fired once when queue size crosses configured threshold
 type PersistentQueue struct {
-	queue *dque.DQue
+	queue            QueueBackend
+	name             string
+	thresholdCrossed int32
+	closed           int32
 }
 
 func NewPersistentQueue(queueName, fallbackDir string) (*PersistentQueue, error) {
@@ -29,7 +72,12 @@ func NewPersistentQueue(queueName, fallbackDir string) (*PersistentQueue, error)
 		return nil, fmt.Errorf("Error opening/creating event queue [%s]: %v", queueName, err)
 	}
 
-	return &PersistentQueue{queue: queue}, nil
+	return NewQueue(queueName, &diskQueue{queue: queue}), nil
+}
+
+//NewQueue return PersistentQueue which keeps events in backend
+func NewQueue(queueName string, backend QueueBackend) *PersistentQueue {
+	return &PersistentQueue{queue: backend, name: queueName}
 }
 
 func (pq *PersistentQueue) Enqueue(f Fact) error {
@@ -37,31 +85,115 @@ func (pq *PersistentQueue) Enqueue(f Fact) error {
 	if err != nil {
 		return fmt.Errorf("Error marshalling events fact: %v", err)
 	}
-	if err := pq.queue.Enqueue(QueuedFact{FactBytes: factBytes}); err != nil {
+	if factBytes, err = compression.Compress(queueCompression, factBytes); err != nil {
+		return fmt.Errorf("Error compressing events fact: %v", err)
+	}
+	if err := pq.queue.Enqueue(factBytes); err != nil {
 		return fmt.Errorf("Error putting event fact bytes to the persistent queue: %v", err)
 	}
+
+	pq.checkThreshold()
 	return nil
 }
 
+//fire webhook if queue size has crossed the threshold (and reset flag if it is below the threshold again)
+func (pq *PersistentQueue) checkThreshold() {
+	threshold := webhooks.GetQueueThreshold()
+	if threshold == 0 {
+		return
+	}
+
+	size := pq.queue.Size()
+	if size < threshold {
+		atomic.StoreInt32(&pq.thresholdCrossed, 0)
+		return
+	}
+
+	if atomic.CompareAndSwapInt32(&pq.thresholdCrossed, 0, 1) {
+		webhooks.Fire(webhooks.QueueThreshold, map[string]interface{}{"queue": pq.name, "size": size, "threshold": threshold})
+	}
+}
+
+//Size return count of events in the queue. 0 if queue is nil (destination in batch mode)
+func (pq *PersistentQueue) Size() int {
+	if pq == nil {
+		return 0
+	}
+
+	return pq.queue.Size()
+}
+
+//DequeueBlock return the event which is acknowledged right away
 func (pq *PersistentQueue) DequeueBlock() (Fact, error) {
-	iface, err := pq.queue.DequeueBlock()
+	fact, ack, err := pq.DequeueBlockAck()
 	if err != nil {
 		return nil, err
 	}
-	wrappedFact, ok := iface.(QueuedFact)
-	if !ok || len(wrappedFact.FactBytes) == 0 {
-		return nil, errors.New("Dequeued object is not a QueuedFact instance or fact bytes is empty")
+	if err := ack(); err != nil {
+		return nil, fmt.Errorf("Error acknowledging events.Fact: %v", err)
+	}
+
+	return fact, nil
+}
+
+//DequeueBlockAck return the event and AckFunc which must be called after the event has been inserted
+//events which can't be read are acknowledged right away
+func (pq *PersistentQueue) DequeueBlockAck() (Fact, AckFunc, error) {
+	factBytes, ack, err := pq.queue.DequeueBlock()
+	if err != nil {
+		if atomic.LoadInt32(&pq.closed) == 1 {
+			return nil, nil, ErrQueueClosed
+		}
+		return nil, nil, err
+	}
+
+	factBytes, err = compression.Decompress(factBytes)
+	if err != nil {
+		ack()
+		return nil, nil, fmt.Errorf("Error decompressing events.Fact bytes: %v", err)
 	}
 
 	fact := Fact{}
-	err = json.Unmarshal(wrappedFact.FactBytes, &fact)
+	err = json.Unmarshal(factBytes, &fact)
 	if err != nil {
-		return nil, fmt.Errorf("Error unmarshalling events.Fact from bytes: %v", err)
+		ack()
+		return nil, nil, fmt.Errorf("Error unmarshalling events.Fact from bytes: %v", err)
 	}
 
-	return fact, nil
+	return fact, ack, nil
 }
 
 func (pq *PersistentQueue) Close() error {
+	atomic.StoreInt32(&pq.closed, 1)
 	return pq.queue.Close()
 }
+
+//QueueBackend implementation on local disk
+type diskQueue struct {
+	queue *dque.DQue
+}
+
+func (dq *diskQueue) Enqueue(factBytes []byte) error {
+	return dq.queue.Enqueue(QueuedFact{FactBytes: factBytes})
+}
+
+func (dq *diskQueue) DequeueBlock() ([]byte, AckFunc, error) {
+	iface, err := dq.queue.DequeueBlock()
+	if err != nil {
+		return nil, nil, err
+	}
+	wrappedFact, ok := iface.(QueuedFact)
+	if !ok || len(wrappedFact.FactBytes) == 0 {
+		return nil, nil, errors.New("Dequeued object is not a QueuedFact instance or fact bytes is empty")
+	}
+
+	return wrappedFact.FactBytes, NoAck, nil
+}
+
+func (dq *diskQueue) Size() int {
+	return dq.queue.Size()
+}
+
+func (dq *diskQueue) Close() error {
+	return dq.queue.Close()
+}
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	RejectAction   = "reject"
+	TruncateAction = "truncate"
+	FileAction     = "file"
+
+	defaultTruncateTo = 1024
+)
+
+//ErrEventTooLarge is returned if event is over max size and it can't be handled by configured action
+var ErrEventTooLarge = errors.New("Event is too large")
+
+//SizePolicyConfig dto for deserialized large events config
+//max_size: max event (request body) size in bytes
+//action: reject (413 response), truncate (string values of truncate_fields are cut to truncate_to length; event is rejected if it is still too large)
+//or file (event is written into oversized events file instead of destinations)
+type SizePolicyConfig struct {
+	MaxSize        int      `mapstructure:"max_size"`
+	Action         string   `mapstructure:"action"`
+	TruncateFields []string `mapstructure:"truncate_fields"`
+	TruncateTo     int      `mapstructure:"truncate_to"`
+	FileDir        string   `mapstructure:"file_dir"`
+}
+
+//Validate required fields in SizePolicyConfig and set default values
+func (spc *SizePolicyConfig) Validate() error {
+	if spc.MaxSize <= 0 {
+		return errors.New("large_events.max_size must be positive")
+	}
+	switch spc.Action {
+	case "":
+		spc.Action = RejectAction
+	case RejectAction, FileAction:
+	case TruncateAction:
+		if len(spc.TruncateFields) == 0 {
+			return errors.New("large_events.truncate_fields is required parameter if action is truncate")
+		}
+	default:
+		return fmt.Errorf("Unknown large_events.action: %s. Supported: %s, %s, %s", spc.Action, RejectAction, TruncateAction, FileAction)
+	}
+	if spc.TruncateTo < 0 {
+		return errors.New("large_events.truncate_to can't be negative")
+	}
+	if spc.TruncateTo == 0 {
+		spc.TruncateTo = defaultTruncateTo
+	}
+
+	return nil
+}
+
+//SizePolicy handles events which are over max size according to configured action
+type SizePolicy struct {
+	maxSize        int
+	action         string
+	truncateFields [][]string
+	truncateTo     int
+
+	writerMutex sync.Mutex
+	writer      io.WriteCloser
+}
+
+//NewSizePolicy return SizePolicy. writer is required for file action
+func NewSizePolicy(config *SizePolicyConfig, writer io.WriteCloser) (*SizePolicy, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.Action == FileAction && writer == nil {
+		return nil, errors.New("oversized events writer is required if action is file")
+	}
+
+	var truncateFields [][]string
+	for _, field := range config.TruncateFields {
+		truncateFields = append(truncateFields, strings.Split(strings.Trim(field, "/"), "/"))
+	}
+
+	return &SizePolicy{
+		maxSize:        config.MaxSize,
+		action:         config.Action,
+		truncateFields: truncateFields,
+		truncateTo:     config.TruncateTo,
+		writer:         writer,
+	}, nil
+}
+
+//ReadBody return request body. If action is reject - at most max size + 1 bytes are read
+//and ErrEventTooLarge is returned for larger bodies
+func (sp *SizePolicy) ReadBody(r *http.Request) ([]byte, error) {
+	if sp.action != RejectAction {
+		return ioutil.ReadAll(r.Body)
+	}
+
+	if r.ContentLength > int64(sp.maxSize) {
+		return nil, ErrEventTooLarge
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, int64(sp.maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > sp.maxSize {
+		return nil, ErrEventTooLarge
+	}
+
+	return body, nil
+}
+
+//Oversized return true if size is over max size
+func (sp *SizePolicy) Oversized(size int) bool {
+	return size > sp.maxSize
+}
+
+//Apply configured action to oversized fact
+//return true if fact should be passed to destinations and ErrEventTooLarge if fact must be rejected
+func (sp *SizePolicy) Apply(fact Fact) (bool, error) {
+	switch sp.action {
+	case TruncateAction:
+		for _, path := range sp.truncateFields {
+			sp.truncate(fact, path)
+		}
+		truncated, err := json.Marshal(fact)
+		if err != nil {
+			return false, err
+		}
+		if len(truncated) > sp.maxSize {
+			return false, ErrEventTooLarge
+		}
+		return true, nil
+	case FileAction:
+		line, err := json.Marshal(fact)
+		if err != nil {
+			return false, err
+		}
+		sp.writerMutex.Lock()
+		defer sp.writerMutex.Unlock()
+		if _, err := sp.writer.Write(append(line, '\n')); err != nil {
+			return false, fmt.Errorf("Error writing oversized event: %v", err)
+		}
+		return false, nil
+	default:
+		return false, ErrEventTooLarge
+	}
+}
+
+//Close underlying oversized events writer
+func (sp *SizePolicy) Close() error {
+	if sp.writer != nil {
+		return sp.writer.Close()
+	}
+
+	return nil
+}
+
+//cut string value by path if it is longer than truncateTo bytes (multi-byte characters aren't split)
+func (sp *SizePolicy) truncate(object map[string]interface{}, path []string) {
+	for _, key := range path[:len(path)-1] {
+		inner, ok := object[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+		object = inner
+	}
+
+	key := path[len(path)-1]
+	if value, ok := object[key].(string); ok && len(value) > sp.truncateTo {
+		end := sp.truncateTo
+		for end > 0 && !utf8.RuneStart(value[end]) {
+			end--
+		}
+		object[key] = value[:end]
+	}
+}
@@ -0,0 +1,108 @@
+package events
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type bufferWriteCloser struct {
+	bytes.Buffer
+}
+
+func (bwc *bufferWriteCloser) Close() error {
+	return nil
+}
+
+func TestSizePolicyReadBody(t *testing.T) {
+	sp, err := NewSizePolicy(&SizePolicyConfig{MaxSize: 10}, nil)
+	require.NoError(t, err)
+
+	body, err := sp.ReadBody(httptest.NewRequest("POST", "/api/v1/event", strings.NewReader(`{"a":"b"}`)))
+	require.NoError(t, err)
+	require.Equal(t, `{"a":"b"}`, string(body))
+
+	_, err = sp.ReadBody(httptest.NewRequest("POST", "/api/v1/event", strings.NewReader(`{"a":"bcdef"}`)))
+	require.Equal(t, ErrEventTooLarge, err)
+
+	//unknown content length
+	request := httptest.NewRequest("POST", "/api/v1/event", strings.NewReader(`{"a":"bcdef"}`))
+	request.ContentLength = -1
+	_, err = sp.ReadBody(request)
+	require.Equal(t, ErrEventTooLarge, err)
+}
+
+func TestSizePolicyApply(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       *SizePolicyConfig
+		input        Fact
+		expected     Fact
+		expectedPass bool
+		expectedErr  error
+		expectedFile string
+	}{
+		{
+			"Reject",
+			&SizePolicyConfig{MaxSize: 10, Action: RejectAction},
+			Fact{"page": "long value"},
+			Fact{"page": "long value"},
+			false,
+			ErrEventTooLarge,
+			"",
+		},
+		{
+			"Truncate",
+			&SizePolicyConfig{MaxSize: 100, Action: TruncateAction, TruncateFields: []string{"/page/html", "/title", "/unknown/field"}, TruncateTo: 4},
+			Fact{"page": map[string]interface{}{"html": "<html>long value</html>", "url": "https://a.com"}, "title": "заголовок", "id": 1},
+			Fact{"page": map[string]interface{}{"html": "<htm", "url": "https://a.com"}, "title": "за", "id": 1},
+			true,
+			nil,
+			"",
+		},
+		{
+			"Truncate isn't enough",
+			&SizePolicyConfig{MaxSize: 20, Action: TruncateAction, TruncateFields: []string{"/title"}, TruncateTo: 4},
+			Fact{"title": "long title", "description": "long description"},
+			Fact{"title": "long", "description": "long description"},
+			false,
+			ErrEventTooLarge,
+			"",
+		},
+		{
+			"File",
+			&SizePolicyConfig{MaxSize: 10, Action: FileAction},
+			Fact{"page": "long value"},
+			Fact{"page": "long value"},
+			false,
+			nil,
+			`{"page":"long value"}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &bufferWriteCloser{}
+			sp, err := NewSizePolicy(tt.config, writer)
+			require.NoError(t, err)
+
+			pass, err := sp.Apply(tt.input)
+			require.Equal(t, tt.expectedErr, err)
+			require.Equal(t, tt.expectedPass, pass)
+			require.Equal(t, tt.expected, tt.input)
+			require.Equal(t, tt.expectedFile, writer.String())
+		})
+	}
+}
+
+func TestSizePolicyConfigValidate(t *testing.T) {
+	require.EqualError(t, (&SizePolicyConfig{}).Validate(), "large_events.max_size must be positive")
+	require.EqualError(t, (&SizePolicyConfig{MaxSize: 10, Action: TruncateAction}).Validate(), "large_events.truncate_fields is required parameter if action is truncate")
+	require.EqualError(t, (&SizePolicyConfig{MaxSize: 10, Action: "drop"}).Validate(), "Unknown large_events.action: drop. Supported: reject, truncate, file")
+
+	config := &SizePolicyConfig{MaxSize: 10}
+	require.NoError(t, config.Validate())
+	require.Equal(t, RejectAction, config.Action)
+	require.Equal(t, defaultTruncateTo, config.TruncateTo)
+}
@@ -1,10 +1,39 @@
 package events
 
-import "io"
+import (
+	"github.com/ksensehq/eventnative/reports"
+	"io"
+)
 
+//Storage stores event log files
+//rows which are skipped (if break_on_error is false) are counted in report
 type Storage interface {
 	io.Closer
-	Store(fileName string, payload []byte) error
+	Store(fileName string, payload []byte, report *reports.LoadReport) error
 	Name() string
 	Type() string
 }
+
+//TransactionalStorage is implemented by storages which can store all tables of a file payload in one transaction
+//(Transactional returns true): nothing is stored if Store fails, so the payload can be retried separately without duplicating rows
+type TransactionalStorage interface {
+	Transactional() bool
+}
+
+//IsTransactional return true if the storage stores file payloads in one transaction (see TransactionalStorage)
+func IsTransactional(storage Storage) bool {
+	transactional, ok := storage.(TransactionalStorage)
+	return ok && transactional.Transactional()
+}
+
+//TokenizedStorages return batch storages of event log files which are written with the token
+type TokenizedStorages interface {
+	Storages(token string) []Storage
+}
+
+//StoragesByToken is TokenizedStorages which doesn't change
+type StoragesByToken map[string][]Storage
+
+func (sbt StoragesByToken) Storages(token string) []Storage {
+	return sbt[token]
+}
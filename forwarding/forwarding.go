@@ -0,0 +1,220 @@
+package forwarding
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/ksensehq/eventnative/events"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	//Path of internal endpoint which accepts events forwarded by other nodes
+	Path = "/api/v1/internal/forward"
+	//SecretHeader authenticates forwarding requests with the shared secret
+	SecretHeader = "X-EventNative-Forward-Secret"
+	//ServerHeader is set on forwarding requests with the server name
+	ServerHeader = "X-EventNative-Forwarded-By"
+
+	defaultTimeout = 10 * time.Second
+)
+
+//Instance is nil if forwarding isn't configured
+var Instance *Forwarder
+
+//Config dto for deserialized server.forwarding config
+//peers: base URLs of other nodes of the cluster. Node without peers only accepts forwarded events
+//secret: shared by all nodes of the cluster. Internal endpoint rejects requests without it
+type Config struct {
+	Peers   []string      `mapstructure:"peers"`
+	Secret  string        `mapstructure:"secret"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+//Validate required fields in Config and set default values
+func (c *Config) Validate() error {
+	if c.Secret == "" {
+		return errors.New("forwarding secret is required parameter")
+	}
+	for _, peer := range c.Peers {
+		if _, err := url.ParseRequestURI(peer); err != nil {
+			return fmt.Errorf("forwarding peer url [%s] is invalid: %v", peer, err)
+		}
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+
+	return nil
+}
+
+//Request is a body of forwarding request
+type Request struct {
+	Destination string        `json:"destination"`
+	Events      []events.Fact `json:"events"`
+}
+
+//EnqueueFunc puts forwarded events into the queue of the local stream destination
+//it returns error if the destination doesn't exist or can't reach its storage too
+type EnqueueFunc func(destination string, facts []events.Fact) error
+
+//Forwarder sends events of stream destinations which can't be reached from this node (e.g. network partition)
+//to peer nodes. The first peer which accepts them puts them into its own queue of the destination with the same name
+type Forwarder struct {
+	peers      []string
+	secret     string
+	serverName string
+	client     *http.Client
+	next       uint32
+}
+
+//Init validate config and create global Forwarder instance
+func Init(config *Config, serverName string) error {
+	if config == nil || (config.Secret == "" && len(config.Peers) == 0) {
+		return nil
+	}
+
+	forwarder, err := NewForwarder(config, serverName)
+	if err != nil {
+		return err
+	}
+
+	Instance = forwarder
+	return nil
+}
+
+//NewForwarder return Forwarder
+func NewForwarder(config *Config, serverName string) (*Forwarder, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	var peers []string
+	for _, peer := range config.Peers {
+		peers = append(peers, strings.TrimRight(peer, "/"))
+	}
+
+	return &Forwarder{
+		peers:      peers,
+		secret:     config.Secret,
+		serverName: serverName,
+		client:     &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+//IsForwarded return true if the event has been forwarded by another node
+func IsForwarded(fact events.Fact) bool {
+	_, ok := fact[events.ForwardedByKey]
+	return ok
+}
+
+//Forward send events to peers (starting from the next one in round robin order) until one of them accepts them
+//return name of the peer which has accepted events
+//return err if events have been forwarded by another node already: they aren't forwarded again to prevent forwarding loops
+func (f *Forwarder) Forward(destination string, facts []events.Fact) (string, error) {
+	if len(f.peers) == 0 {
+		return "", errors.New("forwarding peers aren't configured")
+	}
+	for _, fact := range facts {
+		if IsForwarded(fact) {
+			return "", fmt.Errorf("event has been forwarded by %v already", fact[events.ForwardedByKey])
+		}
+	}
+
+	body, err := json.Marshal(&Request{Destination: destination, Events: facts})
+	if err != nil {
+		return "", fmt.Errorf("Error marshaling forwarding request: %v", err)
+	}
+
+	start := int(atomic.AddUint32(&f.next, 1))
+	var errs []string
+	for i := range f.peers {
+		peer := f.peers[(start+i)%len(f.peers)]
+		if err := f.send(peer, body); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", peer, err))
+			continue
+		}
+		return peer, nil
+	}
+
+	return "", fmt.Errorf("events weren't accepted by peers: %s", strings.Join(errs, "; "))
+}
+
+func (f *Forwarder) send(peer string, body []byte) error {
+	request, err := http.NewRequest(http.MethodPost, peer+Path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(SecretHeader, f.secret)
+	request.Header.Set(ServerHeader, f.serverName)
+
+	response, err := f.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		responseBody, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+		return fmt.Errorf("http %d: %s", response.StatusCode, string(responseBody))
+	}
+	//read body for connection reusing
+	io.Copy(ioutil.Discard, response.Body)
+
+	return nil
+}
+
+//Handler return internal endpoint handler which authenticates forwarding requests and passes events into enqueue
+//events are marked with events.ForwardedByKey. Requests from this node or with already forwarded events are rejected
+//with 508 Loop Detected (the sender keeps them e.g. in dead-letter)
+func (f *Forwarder) Handler(enqueue EnqueueFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader(SecretHeader)), []byte(f.secret)) != 1 {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		request := &Request{}
+		if err := c.BindJSON(request); err != nil {
+			return
+		}
+		if request.Destination == "" {
+			c.String(http.StatusBadRequest, "destination is required")
+			return
+		}
+
+		sender := c.GetHeader(ServerHeader)
+		if sender == "" {
+			c.String(http.StatusBadRequest, ServerHeader+" header is required")
+			return
+		}
+		if sender == f.serverName {
+			c.String(http.StatusLoopDetected, "events have been forwarded by this node")
+			return
+		}
+		for _, fact := range request.Events {
+			if IsForwarded(fact) {
+				c.String(http.StatusLoopDetected, fmt.Sprintf("event has been forwarded by %v already", fact[events.ForwardedByKey]))
+				return
+			}
+			fact[events.ForwardedByKey] = sender
+		}
+
+		if err := enqueue(request.Destination, request.Events); err != nil {
+			c.String(http.StatusServiceUnavailable, err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
@@ -0,0 +1,139 @@
+package forwarding
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestForward(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+
+	//unhealthy peer rejects events
+	unhealthyPeer, err := NewForwarder(&Config{Secret: "secret"}, "node-2")
+	require.NoError(t, err)
+	unhealthyRouter := gin.New()
+	unhealthyRouter.POST(Path, unhealthyPeer.Handler(func(destination string, facts []events.Fact) error {
+		return errors.New("destination is unavailable")
+	}))
+	unhealthyServer := httptest.NewServer(unhealthyRouter)
+	defer unhealthyServer.Close()
+
+	var forwardedBy, forwardedTo string
+	var forwarded []events.Fact
+	healthyPeer, err := NewForwarder(&Config{Secret: "secret"}, "node-3")
+	require.NoError(t, err)
+	healthyRouter := gin.New()
+	healthyRouter.POST(Path, func(c *gin.Context) {
+		forwardedBy = c.GetHeader(ServerHeader)
+		c.Next()
+	}, healthyPeer.Handler(func(destination string, facts []events.Fact) error {
+		forwardedTo = destination
+		forwarded = append(forwarded, facts...)
+		return nil
+	}))
+	healthyServer := httptest.NewServer(healthyRouter)
+	defer healthyServer.Close()
+
+	forwarder, err := NewForwarder(&Config{Peers: []string{unhealthyServer.URL, healthyServer.URL + "/"}, Secret: "secret"}, "node-1")
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		peer, err := forwarder.Forward("pg", []events.Fact{{"event_type": "pageview"}})
+		require.NoError(t, err)
+		require.Equal(t, healthyServer.URL, peer)
+	}
+	require.Equal(t, "node-1", forwardedBy)
+	require.Equal(t, "pg", forwardedTo)
+	require.Equal(t, []events.Fact{{"event_type": "pageview", events.ForwardedByKey: "node-1"}, {"event_type": "pageview", events.ForwardedByKey: "node-1"}}, forwarded)
+
+	//forwarded events aren't forwarded again
+	_, err = forwarder.Forward("pg", forwarded[:1])
+	require.EqualError(t, err, "event has been forwarded by node-1 already")
+	require.Len(t, forwarded, 2)
+
+	//wrong secret
+	wrongSecret, err := NewForwarder(&Config{Peers: []string{healthyServer.URL}, Secret: "wrong"}, "node-1")
+	require.NoError(t, err)
+	_, err = wrongSecret.Forward("pg", []events.Fact{{"event_type": "pageview"}})
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "http 401"), err.Error())
+	require.Len(t, forwarded, 2)
+
+	//all peers reject events
+	onlyUnhealthy, err := NewForwarder(&Config{Peers: []string{unhealthyServer.URL}, Secret: "secret"}, "node-1")
+	require.NoError(t, err)
+	_, err = onlyUnhealthy.Forward("pg", []events.Fact{{"event_type": "pageview"}})
+	require.EqualError(t, err, "events weren't accepted by peers: "+unhealthyServer.URL+": http 503: destination is unavailable")
+}
+
+func TestHandlerRejectsLoops(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+
+	enqueued := 0
+	node, err := NewForwarder(&Config{Secret: "secret"}, "node-2")
+	require.NoError(t, err)
+	router := gin.New()
+	router.POST(Path, node.Handler(func(destination string, facts []events.Fact) error {
+		enqueued += len(facts)
+		return nil
+	}))
+
+	tests := []struct {
+		name           string
+		sender         string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			"without sender",
+			"",
+			`{"destination":"pg","events":[{"event_type":"pageview"}]}`,
+			http.StatusBadRequest,
+			"X-EventNative-Forwarded-By header is required",
+		},
+		{
+			"from itself",
+			"node-2",
+			`{"destination":"pg","events":[{"event_type":"pageview"}]}`,
+			http.StatusLoopDetected,
+			"events have been forwarded by this node",
+		},
+		{
+			"already forwarded event",
+			"node-1",
+			`{"destination":"pg","events":[{"event_type":"pageview"},{"event_type":"pageview","_forwarded_by":"node-3"}]}`,
+			http.StatusLoopDetected,
+			"event has been forwarded by node-3 already",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, Path, strings.NewReader(tt.body))
+			request.Header.Set(SecretHeader, "secret")
+			if tt.sender != "" {
+				request.Header.Set(ServerHeader, tt.sender)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			require.Equal(t, tt.expectedStatus, recorder.Code)
+			require.Equal(t, tt.expectedBody, recorder.Body.String())
+			require.Equal(t, 0, enqueued)
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.EqualError(t, (&Config{Peers: []string{"http://node-2:8001"}}).Validate(), "forwarding secret is required parameter")
+
+	config := &Config{Secret: "secret"}
+	require.NoError(t, config.Validate())
+	require.Equal(t, defaultTimeout, config.Timeout)
+}
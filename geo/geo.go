@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"path"
 	"strings"
+	"time"
 
 	"log"
 )
@@ -56,6 +57,21 @@ func CreateResolver(geoipPath string) (Resolver, error) {
 	return resolver, nil
 }
 
+//BuildTime return build time of maxmind db from http source or from local file
+func BuildTime(geoipPath string) (time.Time, error) {
+	if geoipPath == "" {
+		return time.Time{}, errors.New("Maxmind db source wasn't provided")
+	}
+
+	geoIpParser, err := createGeoIpParser(geoipPath)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Error open maxmind db: %v", err)
+	}
+	defer geoIpParser.Close()
+
+	return time.Unix(int64(geoIpParser.Metadata().BuildEpoch), 0).UTC(), nil
+}
+
 //Create maxmind geo resolver from http source or from local file
 func createGeoIpParser(geoipPath string) (*geoip2.Reader, error) {
 	if strings.Contains(geoipPath, "http://") || strings.Contains(geoipPath, "https://") {
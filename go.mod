@@ -5,20 +5,36 @@ go 1.14
 require (
 	bou.ke/monkey v1.0.2
 	cloud.google.com/go/bigquery v1.10.0
+	cloud.google.com/go/pubsub v1.6.1
 	cloud.google.com/go/storage v1.10.0
+	github.com/ClickHouse/clickhouse-go v1.4.3
+	github.com/Knetic/govaluate v3.0.0+incompatible
+	github.com/Shopify/sarama v1.27.2
 	github.com/aws/aws-sdk-go v1.34.0
+	github.com/bwmarrin/snowflake v0.3.0
+	github.com/denisenkom/go-mssqldb v0.0.0-20191124224453-732737034ffd
 	github.com/gin-gonic/gin v1.6.3
+	github.com/go-redis/redis/v7 v7.4.0
 	github.com/google/uuid v1.1.1
 	github.com/hashicorp/go-multierror v1.1.0
 	github.com/joncrlsn/dque v0.0.0-20200702023911-3e80e3146ce5
+	github.com/klauspost/compress v1.11.4
 	github.com/lib/pq v1.8.0
+	github.com/linkedin/goavro/v2 v2.9.8
 	github.com/mailru/easyjson v0.7.2
 	github.com/mailru/go-clickhouse v1.3.0
+	github.com/nats-io/nats.go v1.11.0
+	github.com/oklog/ulid v1.3.1
 	github.com/oschwald/geoip2-golang v1.4.0
+	github.com/snowflakedb/gosnowflake v1.3.10
 	github.com/spf13/viper v1.7.1
 	github.com/stretchr/testify v1.6.1
 	github.com/ua-parser/uap-go v0.0.0-20200325213135-e1c09f13e2fe
+	github.com/xdg/scram v0.0.0-20180814205039-7eeb5667e42c
+	github.com/xitongsys/parquet-go v1.5.4
+	github.com/xitongsys/parquet-go-source v0.0.0-20200817004010-026bad9b25d0
 	google.golang.org/api v0.30.0
+	google.golang.org/protobuf v1.25.0
 	gopkg.in/natefinch/lumberjack.v2 v2.0.0
-	gopkg.in/yaml.v2 v2.3.0 // indirect
+	gopkg.in/yaml.v2 v2.3.0
 )
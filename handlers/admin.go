@@ -0,0 +1,937 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/ksensehq/eventnative/admin"
+	"github.com/ksensehq/eventnative/appconfig"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/dbt"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/logfiles"
+	"github.com/ksensehq/eventnative/middleware"
+	"github.com/ksensehq/eventnative/openapi"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/samples"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/storages"
+	"github.com/ksensehq/eventnative/watermarks"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	AdminAPIPrefix = "/api/v2/admin"
+	//runtime destinations endpoint (admin token is required too)
+	DestinationsAPIPath = "/api/v1/destinations"
+	//tables schemas which destinations know about (admin token is required too)
+	SchemasAPIPath = "/api/v1/schemas"
+
+	configSource = "config"
+	adminSource  = "admin"
+
+	pendingRestartStatus = "pending_restart"
+	deletedStatus        = "deleted"
+
+	defaultTestEventTimeout = 30 * time.Second
+)
+
+//ErrorResponse is a body of admin API error responses
+type ErrorResponse struct {
+	Message string `json:"message"`
+	Error   string `json:"error,omitempty"`
+}
+
+type AdminAuthResponse struct {
+	Server     string `json:"server"`
+	Authorized bool   `json:"authorized"`
+}
+
+//DestinationResponse is a destination state
+//Destinations created, changed or deleted via admin API are applied after the server restart (pending_restart is true)
+//except ones which are created or deleted via runtime destinations endpoint
+type DestinationResponse struct {
+	Name           string                        `json:"name"`
+	Source         string                        `json:"source"`
+	Type           string                        `json:"type,omitempty"`
+	Mode           string                        `json:"mode,omitempty"`
+	Tokens         []string                      `json:"tokens,omitempty"`
+	Status         string                        `json:"status"`
+	Error          string                        `json:"error,omitempty"`
+	PendingRestart bool                          `json:"pending_restart"`
+	Statistics     *counters.DestinationCounters `json:"statistics,omitempty"`
+	Config         map[string]interface{}        `json:"config,omitempty"`
+}
+
+type DestinationsResponse struct {
+	Destinations []*DestinationResponse `json:"destinations"`
+}
+
+//RuntimeDestinationRequest is a body of runtime destination creation request
+//config is the same as destination config in destinations section of config file
+type RuntimeDestinationRequest struct {
+	Name   string                 `json:"name"`
+	Config map[string]interface{} `json:"config"`
+}
+
+//TokenRequest is a body of token creation request. Token is generated if empty
+type TokenRequest struct {
+	Token string `json:"token,omitempty"`
+	Type  string `json:"type"`
+}
+
+type TokenResponse struct {
+	Token          string   `json:"token"`
+	Types          []string `json:"types"`
+	Source         string   `json:"source"`
+	PendingRestart bool     `json:"pending_restart"`
+}
+
+type TokensResponse struct {
+	Tokens []*TokenResponse `json:"tokens"`
+}
+
+type LastEventsResponse struct {
+	Token  string            `json:"token"`
+	Events []json.RawMessage `json:"events"`
+}
+
+type TableSchema struct {
+	Name    string            `json:"name"`
+	Version int64             `json:"version"`
+	Columns map[string]string `json:"columns"`
+}
+
+type DestinationSchema struct {
+	Destination string         `json:"destination"`
+	Tables      []*TableSchema `json:"tables"`
+}
+
+type SchemaResponse struct {
+	Destinations []*DestinationSchema `json:"destinations"`
+}
+
+type TableSamplesResponse struct {
+	Samples []*samples.Sample `json:"samples"`
+}
+
+//RenamesResponse is renamed fields which have been detected in destination tables (see data_layout.renames)
+type RenamesResponse struct {
+	Destination string           `json:"destination"`
+	Renames     []*schema.Rename `json:"renames"`
+}
+
+type LoadReportsResponse struct {
+	Reports []*reports.LoadReport `json:"reports"`
+}
+
+//WatermarksResponse is a list of destinations tables watermarks
+//landed is set if through query parameter is provided: true if all listed watermarks are not before it
+type WatermarksResponse struct {
+	Watermarks []*watermarks.Watermark `json:"watermarks"`
+	Landed     *bool                   `json:"landed,omitempty"`
+}
+
+//FlushResponse is a result of on demand flush of accumulated batches
+//files: event log files which have been stored in all batch destinations (failed ones are retried by the uploader)
+//destinations: stream mode destinations which accumulated files have been uploaded
+type FlushResponse struct {
+	Files        []string `json:"files"`
+	Destinations []string `json:"destinations"`
+}
+
+//ReplayResponse is a count of events from destination dead-letter file which have been put into the stream queue
+type ReplayResponse struct {
+	Destination string `json:"destination"`
+	Replayed    int    `json:"replayed"`
+}
+
+//DropColumnRequest is a request of dropping destination table column
+//without confirmation_token the drop is only planned: statements and a new token are returned and nothing is changed
+type DropColumnRequest struct {
+	Destination       string `json:"destination"`
+	Table             string `json:"table"`
+	Column            string `json:"column"`
+	ConfirmationToken string `json:"confirmation_token,omitempty"`
+}
+
+//ModifyTTLRequest is a request of applying configured TTL to existing destination table
+//without confirmation_token the modification is only planned: statements and a new token are returned and nothing is changed
+type ModifyTTLRequest struct {
+	Destination       string `json:"destination"`
+	Table             string `json:"table"`
+	ConfirmationToken string `json:"confirmation_token,omitempty"`
+}
+
+//AdminHandler serves admin API: destinations (including runtime add/remove and test events), tokens, statistics, last events, schema catalog, table samples, renamed fields, dropping columns, modifying TTL, load reports, watermarks, on demand flush and dead-letter replay
+//Destinations and tokens from config file are read-only. Ones created via API are kept in admin.Store
+type AdminHandler struct {
+	store              *admin.Store
+	eventsCache        *events.Cache
+	configDestinations map[string]bool
+	destinations       *storages.Destinations
+	//storages.GetDestinationStatuses and storages.GetTables (schemas API)
+	destinationStatuses func() []*storages.DestinationStatus
+	destinationTables   func(name string) ([]*schema.Table, bool)
+
+	mutex   sync.RWMutex
+	changed map[string]bool
+}
+
+//NewAdminHandler return AdminHandler
+//configDestinations are names of destinations from config file
+//destinations are running destinations which can be added and removed at runtime
+func NewAdminHandler(store *admin.Store, eventsCache *events.Cache, configDestinations map[string]bool, destinations *storages.Destinations) *AdminHandler {
+	return &AdminHandler{
+		store:               store,
+		eventsCache:         eventsCache,
+		configDestinations:  configDestinations,
+		destinations:        destinations,
+		destinationStatuses: storages.GetDestinationStatuses,
+		destinationTables:   storages.GetTables,
+		changed:             map[string]bool{},
+	}
+}
+
+//Routes return all admin API routes under AdminAPIPrefix. All routes require admin token
+func (ah *AdminHandler) Routes() []Route {
+	security := []string{AdminTokenSecurity}
+	schemaQueryParams := []openapi.Parameter{{Name: "destination", Description: "destination name filter"}, {Name: "table", Description: "table name filter"}}
+	routes := []Route{
+		{
+			Operation: openapi.Operation{Method: http.MethodGet, Path: "/auth", Summary: "Check admin token", Tags: []string{"auth"}, Security: security, Response: AdminAuthResponse{}},
+			Handler:   ah.AuthHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodGet, Path: "/destinations", Summary: "List destinations with health and statistics", Tags: []string{"destinations"}, Security: security, Response: DestinationsResponse{}},
+			Handler:   ah.DestinationsHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodGet, Path: "/destinations/:name", Summary: "Get destination", Tags: []string{"destinations"}, Security: security, Response: DestinationResponse{}},
+			Handler:   ah.DestinationHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodPut, Path: "/destinations/:name", Summary: "Create or replace destination (applied after restart)", Tags: []string{"destinations"}, Security: security, Request: map[string]interface{}{}, Response: DestinationResponse{}},
+			Handler:   ah.PutDestinationHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodDelete, Path: "/destinations/:name", Summary: "Delete destination (applied after restart)", Tags: []string{"destinations"}, Security: security, Response: DestinationResponse{}},
+			Handler:   ah.DeleteDestinationHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodPost, Path: "/destinations/:name/test-event", Summary: "Send test event through destination pipeline and select the landed row", Tags: []string{"destinations"}, Security: security, QueryParams: []openapi.Parameter{{Name: "table", Description: "schema catalog table which columns are filled with test values (default: canned pageview event)"}, {Name: "timeout", Description: "max duration of waiting for the landed row (default: 30s)"}}, Response: storages.TestEventResult{}},
+			Handler:   ah.TestEventHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodGet, Path: "/tokens", Summary: "List tokens", Tags: []string{"tokens"}, Security: security, Response: TokensResponse{}},
+			Handler:   ah.TokensHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodPost, Path: "/tokens", Summary: "Create token (applied after restart)", Tags: []string{"tokens"}, Security: security, Request: TokenRequest{}, Response: TokenResponse{}},
+			Handler:   ah.PostTokenHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodDelete, Path: "/tokens/:token", Summary: "Delete token (applied after restart)", Tags: []string{"tokens"}, Security: security, Response: TokenResponse{}},
+			Handler:   ah.DeleteTokenHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodGet, Path: "/statistics", Summary: "Events counters since the server start", Tags: []string{"statistics"}, Security: security, Response: counters.Snapshot{}},
+			Handler:   ah.StatisticsHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodGet, Path: "/events/last", Summary: "Last accepted events of the token (the newest first)", Tags: []string{"events"}, Security: security, QueryParams: []openapi.Parameter{{Name: "token", Description: "API token", Required: true}, {Name: "limit", Description: "max events count"}}, Response: LastEventsResponse{}},
+			Handler:   ah.LastEventsHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodGet, Path: "/schema", Summary: "Tables schemas known by destinations", Tags: []string{"schema"}, Security: security, QueryParams: schemaQueryParams, Response: SchemaResponse{}},
+			Handler:   ah.SchemaHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodGet, Path: "/dbt/sources", Summary: "dbt sources.yml (YAML) of SQL destinations tables", Tags: []string{"schema"}, Security: security, QueryParams: []openapi.Parameter{{Name: "destination", Description: "destination name filter"}, {Name: "warn_after", Description: "freshness warn threshold duration (default: 12h)"}, {Name: "error_after", Description: "freshness error threshold duration (default: 24h)"}}},
+			Handler:   ah.DbtSourcesHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodGet, Path: "/samples", Summary: "Last raw event payloads which were written into destination table (the newest first)", Tags: []string{"schema"}, Security: security, QueryParams: []openapi.Parameter{{Name: "destination", Description: "destination name", Required: true}, {Name: "table", Description: "table name", Required: true}}, Response: TableSamplesResponse{}},
+			Handler:   ah.TableSamplesHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodGet, Path: "/renames", Summary: "Renamed fields detected in destination tables with suggested mappings", Tags: []string{"schema"}, Security: security, QueryParams: []openapi.Parameter{{Name: "destination", Description: "destination name", Required: true}}, Response: RenamesResponse{}},
+			Handler:   ah.RenamesHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodPost, Path: "/schema/drop-column", Summary: "Drop destination table column: the first request returns statements and confirmation token, the second one with the token executes them", Tags: []string{"schema"}, Security: security, Request: DropColumnRequest{}, Response: storages.ColumnDrop{}},
+			Handler:   ah.DropColumnHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodPost, Path: "/schema/modify-ttl", Summary: "Apply configured TTL to existing destination table (expired rows are deleted): the first request returns statements and confirmation token, the second one with the token executes them", Tags: []string{"schema"}, Security: security, Request: ModifyTTLRequest{}, Response: storages.TTLModification{}},
+			Handler:   ah.ModifyTTLHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodGet, Path: "/reports", Summary: "Load reports of event log files in batch destinations (the newest first)", Tags: []string{"reports"}, Security: security, QueryParams: []openapi.Parameter{{Name: "destination", Description: "destination name filter"}, {Name: "file", Description: "event log file name filter"}, {Name: "skipped", Description: "if true, only reports with skipped rows are returned"}}, Response: LoadReportsResponse{}},
+			Handler:   ah.LoadReportsHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodGet, Path: "/watermarks", Summary: "Max event time of committed events per destination table since the server start", Tags: []string{"statistics"}, Security: security, QueryParams: []openapi.Parameter{{Name: "destination", Description: "destination name filter"}, {Name: "table", Description: "table name filter"}, {Name: "through", Description: "RFC3339 time: landed is true if data through it has landed in all listed tables"}}, Response: WatermarksResponse{}},
+			Handler:   ah.WatermarksHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodPost, Path: "/flush", Summary: "Upload partially accumulated event log files and stream mode files to destinations right away", Tags: []string{"destinations"}, Security: security, Response: FlushResponse{}},
+			Handler:   ah.FlushHandler,
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodPost, Path: "/dead-letter/replay", Summary: "Put events which weren't inserted after all retries back into the stream destination queue", Tags: []string{"destinations"}, Security: security, QueryParams: []openapi.Parameter{{Name: "destination", Description: "destination name", Required: true}}, Response: ReplayResponse{}},
+			Handler:   ah.ReplayDeadLetterHandler,
+		},
+	}
+
+	for i := range routes {
+		routes[i].Path = AdminAPIPrefix + routes[i].Path
+	}
+
+	//destinations are created and torn down right away (without restart)
+	routes = append(routes,
+		Route{
+			Operation: openapi.Operation{Method: http.MethodPost, Path: DestinationsAPIPath, Summary: "Create or replace destination at runtime", Tags: []string{"destinations"}, Security: security, Request: RuntimeDestinationRequest{}, Response: DestinationResponse{}},
+			Handler:   ah.AddDestinationHandler,
+		},
+		Route{
+			Operation: openapi.Operation{Method: http.MethodDelete, Path: DestinationsAPIPath, Summary: "Tear down destination at runtime", Tags: []string{"destinations"}, Security: security, QueryParams: []openapi.Parameter{{Name: "name", Description: "destination name", Required: true}}, Response: DestinationResponse{}},
+			Handler:   ah.RemoveDestinationHandler,
+		},
+		Route{
+			Operation: openapi.Operation{Method: http.MethodGet, Path: SchemasAPIPath, Summary: "Tables and column types known by destinations (cached schemas)", Tags: []string{"schema"}, Security: security, QueryParams: schemaQueryParams, Response: SchemaResponse{}},
+			Handler:   ah.SchemaHandler,
+		},
+	)
+
+	for i := range routes {
+		routes[i].Handler = middleware.AdminAuth(routes[i].Handler)
+	}
+
+	return routes
+}
+
+func (ah *AdminHandler) AuthHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, AdminAuthResponse{Server: appconfig.Instance.ServerName, Authorized: true})
+}
+
+func (ah *AdminHandler) DestinationsHandler(c *gin.Context) {
+	names := map[string]bool{}
+	for _, status := range storages.GetDestinationStatuses() {
+		names[status.Name] = true
+	}
+	for _, name := range ah.store.DestinationNames() {
+		names[name] = true
+	}
+
+	statistics := counters.GetSnapshot()
+	response := DestinationsResponse{Destinations: []*DestinationResponse{}}
+	for name := range names {
+		response.Destinations = append(response.Destinations, ah.destination(name, statistics))
+	}
+	sort.Slice(response.Destinations, func(i, j int) bool { return response.Destinations[i].Name < response.Destinations[j].Name })
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (ah *AdminHandler) DestinationHandler(c *gin.Context) {
+	name := c.Param("name")
+	_, running := storages.GetDestinationStatus(name)
+	_, stored := ah.store.GetDestination(name)
+	if !running && !stored {
+		c.JSON(http.StatusNotFound, ErrorResponse{Message: fmt.Sprintf("Destination [%s] wasn't found", name)})
+		return
+	}
+
+	c.JSON(http.StatusOK, ah.destination(name, counters.GetSnapshot()))
+}
+
+func (ah *AdminHandler) PutDestinationHandler(c *gin.Context) {
+	name := c.Param("name")
+	if ah.configDestinations[name] {
+		c.JSON(http.StatusConflict, ErrorResponse{Message: fmt.Sprintf("Destination [%s] is defined in config file and can't be changed via API", name)})
+		return
+	}
+
+	config := map[string]interface{}{}
+	if err := c.BindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Failed to parse body", Error: err.Error()})
+		return
+	}
+
+	if err := storages.ValidateDestination(name, config); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid destination config", Error: err.Error()})
+		return
+	}
+
+	if err := ah.store.PutDestination(name, config); err != nil {
+		log.Println("Error saving destination via admin API:", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to save destination", Error: err.Error()})
+		return
+	}
+	ah.markChanged("destination:" + name)
+
+	c.JSON(http.StatusOK, ah.destination(name, counters.GetSnapshot()))
+}
+
+func (ah *AdminHandler) DeleteDestinationHandler(c *gin.Context) {
+	name := c.Param("name")
+	if ah.configDestinations[name] {
+		c.JSON(http.StatusConflict, ErrorResponse{Message: fmt.Sprintf("Destination [%s] is defined in config file and can't be deleted via API", name)})
+		return
+	}
+
+	deleted, err := ah.store.DeleteDestination(name)
+	if err != nil {
+		log.Println("Error deleting destination via admin API:", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to delete destination", Error: err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, ErrorResponse{Message: fmt.Sprintf("Destination [%s] wasn't found", name)})
+		return
+	}
+	ah.markChanged("destination:" + name)
+
+	c.JSON(http.StatusOK, ah.destination(name, counters.GetSnapshot()))
+}
+
+//AddDestinationHandler create destination (storage, stream queue and adapters) and start passing events into it right away
+//the destination is kept in admin store. Admin destination with the same name is torn down before
+func (ah *AdminHandler) AddDestinationHandler(c *gin.Context) {
+	req := RuntimeDestinationRequest{}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Failed to parse body", Error: err.Error()})
+		return
+	}
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "name is required"})
+		return
+	}
+	if ah.configDestinations[req.Name] {
+		c.JSON(http.StatusConflict, ErrorResponse{Message: fmt.Sprintf("Destination [%s] is defined in config file and can't be changed via API", req.Name)})
+		return
+	}
+	if req.Config == nil {
+		req.Config = map[string]interface{}{}
+	}
+
+	if err := storages.ValidateDestination(req.Name, req.Config); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Invalid destination config", Error: err.Error()})
+		return
+	}
+
+	if err := ah.destinations.Add(req.Name, req.Config); err != nil {
+		c.JSON(http.StatusBadGateway, ErrorResponse{Message: "Failed to initialize destination", Error: err.Error()})
+		return
+	}
+
+	if err := ah.store.PutDestination(req.Name, req.Config); err != nil {
+		log.Println("Error saving destination via admin API:", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Destination has been initialized but wasn't saved", Error: err.Error()})
+		return
+	}
+	ah.markApplied("destination:" + req.Name)
+
+	c.JSON(http.StatusOK, ah.destination(req.Name, counters.GetSnapshot()))
+}
+
+//RemoveDestinationHandler stop passing events into destination, close it and remove it from admin store
+func (ah *AdminHandler) RemoveDestinationHandler(c *gin.Context) {
+	name := c.Query("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "name query parameter is required"})
+		return
+	}
+	if ah.configDestinations[name] {
+		c.JSON(http.StatusConflict, ErrorResponse{Message: fmt.Sprintf("Destination [%s] is defined in config file and can't be deleted via API", name)})
+		return
+	}
+
+	removed := ah.destinations.Remove(name)
+	deleted, err := ah.store.DeleteDestination(name)
+	if err != nil {
+		log.Println("Error deleting destination via admin API:", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Destination has been torn down but wasn't deleted from store", Error: err.Error()})
+		return
+	}
+	if !removed && !deleted {
+		c.JSON(http.StatusNotFound, ErrorResponse{Message: fmt.Sprintf("Destination [%s] wasn't found", name)})
+		return
+	}
+	ah.markApplied("destination:" + name)
+
+	c.JSON(http.StatusOK, ah.destination(name, counters.GetSnapshot()))
+}
+
+func (ah *AdminHandler) TokensHandler(c *gin.Context) {
+	tokens := map[string]*TokenResponse{}
+	for token := range appconfig.Instance.C2STokens {
+		tr := ah.tokenResponse(tokens, token)
+		tr.Types = append(tr.Types, admin.C2SToken)
+	}
+	for token := range appconfig.Instance.S2STokens {
+		tr := ah.tokenResponse(tokens, token)
+		tr.Types = append(tr.Types, admin.S2SToken)
+	}
+
+	//tokens from admin store are marked as admin ones (not applied ones are pending restart)
+	for _, tc := range ah.store.Tokens() {
+		tr, ok := tokens[tc.Token]
+		if !ok {
+			tr = ah.tokenResponse(tokens, tc.Token)
+			tr.Types = []string{tc.Type}
+			tr.PendingRestart = true
+		}
+		tr.Source = adminSource
+	}
+
+	response := TokensResponse{Tokens: []*TokenResponse{}}
+	for _, tr := range tokens {
+		response.Tokens = append(response.Tokens, tr)
+	}
+	sort.Slice(response.Tokens, func(i, j int) bool { return response.Tokens[i].Token < response.Tokens[j].Token })
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (ah *AdminHandler) PostTokenHandler(c *gin.Context) {
+	req := TokenRequest{}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Failed to parse body", Error: err.Error()})
+		return
+	}
+
+	if req.Token == "" {
+		req.Token = uuid.New().String()
+	}
+	if req.Type == "" {
+		req.Type = admin.C2SToken
+	}
+	if appconfig.Instance.AuthorizedTokens[req.Token] {
+		c.JSON(http.StatusConflict, ErrorResponse{Message: fmt.Sprintf("Token [%s] already exists", req.Token)})
+		return
+	}
+
+	if err := ah.store.AddToken(req.Token, req.Type); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Failed to create token", Error: err.Error()})
+		return
+	}
+	ah.markChanged("token:" + req.Token)
+
+	c.JSON(http.StatusOK, TokenResponse{Token: req.Token, Types: []string{req.Type}, Source: adminSource, PendingRestart: true})
+}
+
+func (ah *AdminHandler) DeleteTokenHandler(c *gin.Context) {
+	token := c.Param("token")
+	deleted, err := ah.store.DeleteToken(token)
+	if err != nil {
+		log.Println("Error deleting token via admin API:", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to delete token", Error: err.Error()})
+		return
+	}
+	if !deleted {
+		if appconfig.Instance.AuthorizedTokens[token] {
+			c.JSON(http.StatusConflict, ErrorResponse{Message: fmt.Sprintf("Token [%s] is defined in config file and can't be deleted via API", token)})
+		} else {
+			c.JSON(http.StatusNotFound, ErrorResponse{Message: fmt.Sprintf("Token [%s] wasn't found", token)})
+		}
+		return
+	}
+	ah.markChanged("token:" + token)
+
+	c.JSON(http.StatusOK, TokenResponse{Token: token, Types: []string{}, Source: adminSource, PendingRestart: true})
+}
+
+func (ah *AdminHandler) StatisticsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, counters.GetSnapshot())
+}
+
+func (ah *AdminHandler) LastEventsHandler(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "token query parameter is required"})
+		return
+	}
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: "limit query parameter must be a positive integer"})
+			return
+		}
+	}
+
+	response := LastEventsResponse{Token: token, Events: []json.RawMessage{}}
+	if ah.eventsCache != nil {
+		response.Events = ah.eventsCache.GetN(token, limit)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+//SchemaHandler return tables and column types from destinations schema caches (without querying the destinations)
+func (ah *AdminHandler) SchemaHandler(c *gin.Context) {
+	filter := c.Query("destination")
+	tableFilter := c.Query("table")
+
+	response := SchemaResponse{Destinations: []*DestinationSchema{}}
+	for _, status := range ah.destinationStatuses() {
+		if filter != "" && filter != status.Name {
+			continue
+		}
+
+		tables, ok := ah.destinationTables(status.Name)
+		if !ok {
+			continue
+		}
+
+		destinationSchema := &DestinationSchema{Destination: status.Name, Tables: []*TableSchema{}}
+		for _, table := range tables {
+			if tableFilter != "" && tableFilter != table.Name {
+				continue
+			}
+			tableSchema := &TableSchema{Name: table.Name, Version: table.Version, Columns: map[string]string{}}
+			for name, column := range table.Columns {
+				tableSchema.Columns[name] = column.GetType().String()
+			}
+			destinationSchema.Tables = append(destinationSchema.Tables, tableSchema)
+		}
+		response.Destinations = append(response.Destinations, destinationSchema)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+//DbtSourcesHandler return dbt sources.yml with tables of all SQL destinations (one source per destination)
+func (ah *AdminHandler) DbtSourcesHandler(c *gin.Context) {
+	warnAfter, err := durationQuery(c, "warn_after", dbt.DefaultWarnAfter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "warn_after query parameter must be a duration (e.g. 12h)"})
+		return
+	}
+	errorAfter, err := durationQuery(c, "error_after", dbt.DefaultErrorAfter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "error_after query parameter must be a duration (e.g. 24h)"})
+		return
+	}
+
+	filter := c.Query("destination")
+	freshness := dbt.NewFreshness(warnAfter, errorAfter)
+	var sources []*dbt.Source
+	for _, status := range storages.GetDestinationStatuses() {
+		if filter != "" && filter != status.Name {
+			continue
+		}
+
+		source, ok := storages.GetDbtSource(status.Name, freshness)
+		if !ok {
+			continue
+		}
+		sources = append(sources, source)
+	}
+
+	body, err := dbt.Marshal(sources)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Failed to marshal dbt sources", Error: err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/x-yaml", body)
+}
+
+func (ah *AdminHandler) TableSamplesHandler(c *gin.Context) {
+	destination := c.Query("destination")
+	table := c.Query("table")
+	if destination == "" || table == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "destination and table query parameters are required"})
+		return
+	}
+
+	c.JSON(http.StatusOK, TableSamplesResponse{Samples: samples.List(destination, table)})
+}
+
+//RenamesHandler return renamed fields which have been detected in destination tables
+func (ah *AdminHandler) RenamesHandler(c *gin.Context) {
+	destination := c.Query("destination")
+	if destination == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "destination query parameter is required"})
+		return
+	}
+
+	renames, ok := storages.GetRenames(destination)
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Message: fmt.Sprintf("Destination [%s] isn't running or doesn't have data_layout.renames", destination)})
+		return
+	}
+
+	c.JSON(http.StatusOK, RenamesResponse{Destination: destination, Renames: renames})
+}
+
+//DropColumnHandler plan dropping the column (without confirmation token) or drop it (with the token of the planned drop)
+//columns are never dropped by automatic schema changes
+func (ah *AdminHandler) DropColumnHandler(c *gin.Context) {
+	req := DropColumnRequest{}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Failed to parse body", Error: err.Error()})
+		return
+	}
+	if req.Destination == "" || req.Table == "" || req.Column == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "destination, table and column are required"})
+		return
+	}
+
+	var drop *storages.ColumnDrop
+	var ok bool
+	var err error
+	if req.ConfirmationToken == "" {
+		drop, ok, err = storages.PlanDropColumn(req.Destination, req.Table, req.Column)
+	} else {
+		drop, ok, err = storages.DropColumn(req.Destination, req.Table, req.Column, req.ConfirmationToken)
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Message: fmt.Sprintf("Destination [%s] isn't running or its columns can't be dropped", req.Destination)})
+		return
+	}
+	if err == storages.ErrInvalidConfirmationToken {
+		c.JSON(http.StatusConflict, ErrorResponse{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Error dropping column", Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, drop)
+}
+
+//ModifyTTLHandler plan applying TTL to the table (without confirmation token) or apply it (with the token of the planned modification)
+//TTL of existing tables is never modified automatically
+func (ah *AdminHandler) ModifyTTLHandler(c *gin.Context) {
+	req := ModifyTTLRequest{}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Failed to parse body", Error: err.Error()})
+		return
+	}
+	if req.Destination == "" || req.Table == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "destination and table are required"})
+		return
+	}
+
+	var modification *storages.TTLModification
+	var ok bool
+	var err error
+	if req.ConfirmationToken == "" {
+		modification, ok, err = storages.PlanModifyTTL(req.Destination, req.Table)
+	} else {
+		modification, ok, err = storages.ModifyTTL(req.Destination, req.Table, req.ConfirmationToken)
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Message: fmt.Sprintf("Destination [%s] isn't running or doesn't support TTL", req.Destination)})
+		return
+	}
+	if err == storages.ErrInvalidConfirmationToken {
+		c.JSON(http.StatusConflict, ErrorResponse{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Error modifying TTL", Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, modification)
+}
+
+func (ah *AdminHandler) LoadReportsHandler(c *gin.Context) {
+	skippedOnly := false
+	if skippedStr := c.Query("skipped"); skippedStr != "" {
+		var err error
+		skippedOnly, err = strconv.ParseBool(skippedStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: "skipped query parameter must be a boolean"})
+			return
+		}
+	}
+
+	response := LoadReportsResponse{Reports: []*reports.LoadReport{}}
+	for _, report := range reports.List(c.Query("destination"), c.Query("file")) {
+		if skippedOnly && report.Skipped == 0 {
+			continue
+		}
+		response.Reports = append(response.Reports, report)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (ah *AdminHandler) WatermarksHandler(c *gin.Context) {
+	response := WatermarksResponse{Watermarks: watermarks.List(c.Query("destination"), c.Query("table"))}
+	if throughStr := c.Query("through"); throughStr != "" {
+		through, err := time.Parse(time.RFC3339, throughStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: "through query parameter must be RFC3339 time e.g. 2020-12-01T14:00:00Z", Error: err.Error()})
+			return
+		}
+		landed := watermarks.Landed(response.Watermarks, through)
+		response.Landed = &landed
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+//FlushHandler rotate event log files and upload them with files accumulated by stream mode destinations
+func (ah *AdminHandler) FlushHandler(c *gin.Context) {
+	response := FlushResponse{Files: logfiles.Flush(), Destinations: storages.FlushFiles()}
+	if response.Files == nil {
+		response.Files = []string{}
+	}
+	if response.Destinations == nil {
+		response.Destinations = []string{}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+//ReplayDeadLetterHandler replay destination dead-letter events (destination must have retry.dead_letter config)
+func (ah *AdminHandler) ReplayDeadLetterHandler(c *gin.Context) {
+	destination := c.Query("destination")
+	if destination == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "destination query parameter is required"})
+		return
+	}
+
+	replayed, ok, err := storages.ReplayDeadLetter(destination)
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Message: fmt.Sprintf("Destination [%s] doesn't exist or doesn't have retry.dead_letter config", destination)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Message: "Error replaying dead-letter events", Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ReplayResponse{Destination: destination, Replayed: replayed})
+}
+
+//TestEventHandler send test event into running destination and return the result (see storages.TestEventResult)
+func (ah *AdminHandler) TestEventHandler(c *gin.Context) {
+	name := c.Param("name")
+	timeout, err := durationQuery(c, "timeout", defaultTestEventTimeout)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "timeout query parameter must be a duration (e.g. 30s)"})
+		return
+	}
+
+	status, ok := storages.GetDestinationStatus(name)
+	if !ok || status.Status != storages.DestinationStatusOK {
+		c.JSON(http.StatusNotFound, ErrorResponse{Message: fmt.Sprintf("Destination [%s] isn't running", name)})
+		return
+	}
+
+	fact, err := storages.NewTestEvent(name, c.Query("table"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Error constructing test event", Error: err.Error()})
+		return
+	}
+
+	result, ok := ah.destinations.SendTestEvent(name, fact, timeout)
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Message: fmt.Sprintf("Destination [%s] isn't running", name)})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+//return query parameter parsed as time.Duration or defaultValue if the parameter is empty
+func durationQuery(c *gin.Context, name string, defaultValue time.Duration) (time.Duration, error) {
+	value := c.Query(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err == nil && duration < 0 {
+		err = fmt.Errorf("negative duration: %s", value)
+	}
+	return duration, err
+}
+
+//build destination response from running destination status and admin store config
+func (ah *AdminHandler) destination(name string, statistics *counters.Snapshot) *DestinationResponse {
+	response := &DestinationResponse{Name: name, Source: configSource, PendingRestart: ah.isChanged("destination:" + name)}
+	if !ah.configDestinations[name] {
+		response.Source = adminSource
+	}
+
+	config, stored := ah.store.GetDestination(name)
+	if stored {
+		response.Config = config
+		if t, ok := config["type"].(string); ok {
+			response.Type = t
+		}
+		if mode, ok := config["mode"].(string); ok {
+			response.Mode = mode
+		}
+	}
+
+	status, running := storages.GetDestinationStatus(name)
+	switch {
+	case running && !response.PendingRestart:
+		response.Type = status.Type
+		response.Mode = status.Mode
+		response.Tokens = status.Tokens
+		response.Status = status.Status
+		response.Error = status.Error
+	case stored:
+		response.Status = pendingRestartStatus
+		response.PendingRestart = true
+	default:
+		response.Status = deletedStatus
+	}
+
+	if running {
+		response.Statistics = statistics.Destinations[name]
+	}
+
+	return response
+}
+
+//return token response from map (creates if doesn't exist)
+func (ah *AdminHandler) tokenResponse(tokens map[string]*TokenResponse, token string) *TokenResponse {
+	tr, ok := tokens[token]
+	if !ok {
+		tr = &TokenResponse{Token: token, Source: configSource, PendingRestart: ah.isChanged("token:" + token)}
+		tokens[token] = tr
+	}
+
+	return tr
+}
+
+func (ah *AdminHandler) markChanged(key string) {
+	ah.mutex.Lock()
+	ah.changed[key] = true
+	ah.mutex.Unlock()
+}
+
+//changes which are applied at runtime aren't pending restart
+func (ah *AdminHandler) markApplied(key string) {
+	ah.mutex.Lock()
+	delete(ah.changed, key)
+	ah.mutex.Unlock()
+}
+
+func (ah *AdminHandler) isChanged(key string) bool {
+	ah.mutex.RLock()
+	defer ah.mutex.RUnlock()
+
+	return ah.changed[key]
+}
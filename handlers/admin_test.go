@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ksensehq/eventnative/appconfig"
+	"github.com/ksensehq/eventnative/middleware"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/storages"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testAdminToken = "admin_token"
+
+//return router with the routes. Admin token is testAdminToken
+func newTestRouter(routes []Route) *gin.Engine {
+	if appconfig.Instance == nil {
+		appconfig.Instance = &appconfig.AppConfig{}
+	}
+	appconfig.Instance.AdminToken = testAdminToken
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	for _, route := range routes {
+		router.Handle(route.Method, route.Path, route.Handler)
+	}
+
+	return router
+}
+
+//serve GET request with admin token (if it isn't empty) and return response code and body
+func get(router *gin.Engine, target, adminToken string) (int, string) {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if adminToken != "" {
+		req.Header.Set(middleware.AdminTokenHeader, adminToken)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w.Code, w.Body.String()
+}
+
+func TestSchemaHandler(t *testing.T) {
+	ah := NewAdminHandler(nil, nil, nil, nil)
+	ah.destinationStatuses = func() []*storages.DestinationStatus {
+		return []*storages.DestinationStatus{{Name: "kafka"}, {Name: "pg"}, {Name: "redshift"}}
+	}
+	ah.destinationTables = func(name string) ([]*schema.Table, bool) {
+		switch name {
+		case "pg":
+			return []*schema.Table{
+				{Name: "events", Version: 2, Columns: schema.Columns{"_timestamp": schema.NewColumn(typing.TIMESTAMP), "id": schema.NewColumn(typing.INT64)}},
+				{Name: "users", Version: 1, Columns: schema.Columns{"name": schema.NewColumn(typing.STRING)}},
+			}, true
+		case "redshift":
+			return []*schema.Table{}, true
+		default:
+			//destination doesn't keep tables schemas
+			return nil, false
+		}
+	}
+
+	router := newTestRouter(ah.Routes())
+
+	tests := []struct {
+		name         string
+		query        string
+		adminToken   string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			"without admin token",
+			"",
+			"",
+			http.StatusUnauthorized,
+			"",
+		},
+		{
+			"wrong admin token",
+			"",
+			"wrong",
+			http.StatusUnauthorized,
+			"",
+		},
+		{
+			"all destinations",
+			"",
+			testAdminToken,
+			http.StatusOK,
+			`{"destinations":[{"destination":"pg","tables":[{"name":"events","version":2,"columns":{"_timestamp":"TIMESTAMP","id":"INT64"}},{"name":"users","version":1,"columns":{"name":"STRING"}}]},{"destination":"redshift","tables":[]}]}`,
+		},
+		{
+			"destination and table filters",
+			"?destination=pg&table=users",
+			testAdminToken,
+			http.StatusOK,
+			`{"destinations":[{"destination":"pg","tables":[{"name":"users","version":1,"columns":{"name":"STRING"}}]}]}`,
+		},
+		{
+			"unknown table",
+			"?destination=pg&table=orders",
+			testAdminToken,
+			http.StatusOK,
+			`{"destinations":[{"destination":"pg","tables":[]}]}`,
+		},
+		{
+			"unknown destination",
+			"?destination=mysql",
+			testAdminToken,
+			http.StatusOK,
+			`{"destinations":[]}`,
+		},
+		{
+			"destination without tables schemas",
+			"?destination=kafka",
+			testAdminToken,
+			http.StatusOK,
+			`{"destinations":[]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := get(router, SchemasAPIPath+tt.query, tt.adminToken)
+			require.Equal(t, tt.expectedCode, code)
+			if tt.expectedBody != "" {
+				require.JSONEq(t, tt.expectedBody, body)
+			}
+		})
+	}
+}
@@ -1,62 +1,178 @@
 package handlers
 
 import (
+	"bytes"
 	"github.com/gin-gonic/gin"
+	"github.com/ksensehq/eventnative/counters"
 	"github.com/ksensehq/eventnative/events"
 	"github.com/ksensehq/eventnative/middleware"
-	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/ksensehq/eventnative/routing"
+	"github.com/ksensehq/eventnative/storages"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"sort"
 	"time"
 )
 
-const apiTokenKey = "api_key"
+const (
+	destinationsResponseKey = "destinations"
+
+	okStatus           = "ok"
+	unknownTokenStatus = "unknown_token"
+)
+
+//EventResponse is a body of s2s event response which is returned if request has destinations=true query parameter
+//status: ok or unknown_token if the token doesn't have any destinations (the event isn't stored anywhere)
+//destinations: destinations which the event has been routed into (empty if the event has been dropped by routing)
+type EventResponse struct {
+	Status       string               `json:"status"`
+	Dropped      bool                 `json:"dropped,omitempty"`
+	Destinations []*RoutedDestination `json:"destinations"`
+}
+
+//RoutedDestination is a destination of the event
+//queue_position is approximate count of events in stream mode queue after the event has been enqueued
+//(batch mode destinations receive the event with the next event log file upload)
+type RoutedDestination struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Mode          string `json:"mode"`
+	QueuePosition *int   `json:"queue_position,omitempty"`
+}
 
 //Accept all events
 type EventHandler struct {
-	eventConsumersByToken map[string][]events.Consumer
-	preprocessor          events.Preprocessor
+	eventConsumersByToken events.TokenizedConsumers
+	intake                *events.Intake
+	eventsCache           *events.Cache
+	destinationsResponse  bool
 }
 
 //Accept all events according to token
-func NewEventHandler(eventConsumersByToken map[string][]events.Consumer, preprocessor events.Preprocessor) (eventHandler *EventHandler) {
+//intake: validation and enrichment of events (it is shared with UDP listeners)
+//eventsCache is optional: last events are kept for admin API
+//destinationsResponse: return EventResponse if request has destinations=true query parameter
+func NewEventHandler(eventConsumersByToken events.TokenizedConsumers, intake *events.Intake, eventsCache *events.Cache, destinationsResponse bool) (eventHandler *EventHandler) {
 	return &EventHandler{
 		eventConsumersByToken: eventConsumersByToken,
-		preprocessor:          preprocessor,
+		intake:                intake,
+		eventsCache:           eventsCache,
+		destinationsResponse:  destinationsResponse,
 	}
 }
 
 func (eh *EventHandler) Handler(c *gin.Context) {
+	iface, ok := c.Get(middleware.TokenName)
+	if !ok {
+		log.Println("System error: token wasn't found in context")
+		return
+	}
+	token := iface.(string)
+
+	var size int
+	if sizePolicy := eh.intake.SizePolicy(); sizePolicy != nil {
+		body, err := sizePolicy.ReadBody(c.Request)
+		if err == events.ErrEventTooLarge {
+			counters.OversizedEvents(token, 1)
+			c.Writer.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			log.Println("Error reading event:", err)
+			c.Writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		size = len(body)
+		//restore body for binding
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
 	payload := events.Fact{}
 	if err := c.BindJSON(&payload); err != nil {
 		c.Writer.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	iface, ok := c.Get(middleware.TokenName)
-	if !ok {
-		log.Println("System error: token wasn't found in context")
+	processed, pass, err := eh.intake.Accept(token, payload, size, c.Request, time.Now().UTC())
+	if rejection, ok := err.(*events.RejectionError); ok {
+		switch rejection.Reason {
+		case events.InvalidRejection:
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: "Event doesn't match JSON schema", Error: rejection.Err.Error()})
+		case events.OversizedRejection:
+			c.Writer.WriteHeader(http.StatusRequestEntityTooLarge)
+		default:
+			log.Println("Error processing event:", rejection.Err)
+			c.Writer.WriteHeader(http.StatusBadRequest)
+		}
 		return
 	}
-	token := iface.(string)
-
-	processed, err := eh.preprocessor.Preprocess(payload, c.Request)
 	if err != nil {
-		log.Println("Error processing event:", err)
-		c.Writer.WriteHeader(http.StatusBadRequest)
+		log.Println(err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if !pass {
 		return
 	}
 
-	processed[apiTokenKey] = token
-	processed[timestamp.Key] = time.Now().UTC().Format(timestamp.Layout)
+	counters.AcceptedEvents(token, 1)
+	if eh.eventsCache != nil {
+		eh.eventsCache.Put(token, processed)
+	}
+
+	respond := eh.destinationsResponse && c.Query(destinationsResponseKey) == "true"
 
-	consumers, ok := eh.eventConsumersByToken[token]
-	if ok {
-		for _, consumer := range consumers {
-			consumer.Consume(processed)
+	//events of the drop route aren't written into event log files and aren't consumed by stream destinations
+	if routing.Drop(processed) {
+		if respond {
+			c.JSON(http.StatusOK, EventResponse{Status: okStatus, Dropped: true, Destinations: []*RoutedDestination{}})
 		}
-	} else {
+		return
+	}
+
+	routing.CountArms(processed)
+
+	consumers := eh.eventConsumersByToken.Consumers(token)
+	if len(consumers) == 0 {
 		log.Printf("Unknown token[%s] request was received", token)
+		if respond {
+			c.JSON(http.StatusOK, EventResponse{Status: unknownTokenStatus, Destinations: []*RoutedDestination{}})
+		}
+		return
+	}
+
+	for _, consumer := range consumers {
+		consumer.Consume(processed)
+	}
+
+	if respond {
+		c.JSON(http.StatusOK, EventResponse{Status: okStatus, Destinations: routedDestinations(consumers, processed)})
+	}
+}
+
+//return destinations of the token consumers which the event is routed into (sorted by name)
+func routedDestinations(consumers []events.Consumer, fact events.Fact) []*RoutedDestination {
+	destinations := []*RoutedDestination{}
+	for _, consumer := range consumers {
+		destinationsConsumer, ok := consumer.(events.DestinationsConsumer)
+		if !ok {
+			continue
+		}
+
+		for _, name := range destinationsConsumer.Destinations(fact) {
+			destination := &RoutedDestination{Name: name}
+			if status, ok := storages.GetDestinationStatus(name); ok {
+				destination.Type = status.Type
+				destination.Mode = status.Mode
+			}
+			if size, ok := storages.GetQueueSize(name); ok {
+				destination.QueuePosition = &size
+			}
+			destinations = append(destinations, destination)
+		}
 	}
+	sort.Slice(destinations, func(i, j int) bool { return destinations[i].Name < destinations[j].Name })
 
+	return destinations
 }
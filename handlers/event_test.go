@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/middleware"
+	"github.com/ksensehq/eventnative/routing"
+	"github.com/ksensehq/eventnative/storages"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type preprocessorMock struct{}
+
+func (pm *preprocessorMock) Preprocess(fact events.Fact, r *http.Request) (events.Fact, error) {
+	return fact, nil
+}
+
+type loggerMock struct {
+	consumed []events.Fact
+}
+
+func (lm *loggerMock) Consume(fact events.Fact) {
+	lm.consumed = append(lm.consumed, fact)
+}
+
+func (lm *loggerMock) Close() error {
+	return nil
+}
+
+func TestEventHandlerDestinations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logger := &loggerMock{}
+	destinations := storages.NewDestinations(context.Background(), "", map[string]events.Consumer{"token1": logger})
+	defer destinations.Close()
+	//dry run destinations don't connect to anything
+	require.NoError(t, destinations.Add("handler_batch", map[string]interface{}{"type": "s3", "dry_run": true, "only_tokens": []string{"token1"}}))
+	require.NoError(t, destinations.Add("handler_stream", map[string]interface{}{"type": "s3", "mode": "stream", "dry_run": true, "only_tokens": []string{"token1"}}))
+
+	require.NoError(t, routing.Init(&routing.Config{Rules: []*routing.RuleConfig{
+		{EventTypes: []string{"heartbeat"}, Drop: true},
+		{EventTypes: []string{"click"}, Destinations: []string{"handler_stream"}},
+	}}, nil))
+	defer func() { routing.Instance = nil }()
+
+	handler := NewEventHandler(destinations, events.NewIntake(&preprocessorMock{}, nil, nil, nil, nil), nil, true)
+	router := gin.New()
+	router.POST("/event", func(c *gin.Context) {
+		c.Set(middleware.TokenName, c.Query("token"))
+		handler.Handler(c)
+	})
+
+	tests := []struct {
+		name           string
+		token          string
+		body           string
+		expected       EventResponse
+		expectedLogged int
+	}{
+		{
+			"routed event",
+			"token1",
+			`{"event_type":"pageview"}`,
+			EventResponse{Status: "ok", Destinations: []*RoutedDestination{
+				{Name: "handler_batch", Type: "s3", Mode: "batch"},
+				{Name: "handler_stream", Type: "s3", Mode: "stream"},
+			}},
+			1,
+		},
+		{
+			"event routed into one destination",
+			"token1",
+			`{"event_type":"click"}`,
+			EventResponse{Status: "ok", Destinations: []*RoutedDestination{{Name: "handler_stream", Type: "s3", Mode: "stream"}}},
+			1,
+		},
+		{
+			"dropped event",
+			"token1",
+			`{"event_type":"heartbeat"}`,
+			EventResponse{Status: "ok", Dropped: true, Destinations: []*RoutedDestination{}},
+			0,
+		},
+		{
+			"unknown token",
+			"token2",
+			`{"event_type":"pageview"}`,
+			EventResponse{Status: "unknown_token", Destinations: []*RoutedDestination{}},
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger.consumed = nil
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, "/event?destinations=true&token="+tt.token, bytes.NewBufferString(tt.body))
+			router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			actual := EventResponse{}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &actual))
+			//queue position of stream destinations depends on the consumer goroutine
+			for _, destination := range actual.Destinations {
+				destination.QueuePosition = nil
+			}
+			require.Equal(t, tt.expected, actual)
+			require.Len(t, logger.consumed, tt.expectedLogged)
+		})
+	}
+}
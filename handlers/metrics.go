@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/middleware"
+	"github.com/ksensehq/eventnative/openapi"
+	"github.com/ksensehq/eventnative/storages"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	MetricsPath = "/api/v1/metrics/:metric"
+
+	defaultMetricsEventTypeColumn = "event_type"
+	defaultMetricsPeriod          = 24 * time.Hour
+	defaultMetricsMaxPeriod       = 30 * 24 * time.Hour
+)
+
+//MetricsConfig dto for deserialized server.metrics_api config
+//destination: name of SQL destination (postgres, redshift or clickhouse) which is queried
+//table: table with events (after table_name_template is applied)
+//time_column, event_type_column and user_id_column: flattened column names which metrics are grouped and counted by
+//max_period: max value of period query parameter
+type MetricsConfig struct {
+	Destination     string        `mapstructure:"destination"`
+	Table           string        `mapstructure:"table"`
+	TimeColumn      string        `mapstructure:"time_column"`
+	EventTypeColumn string        `mapstructure:"event_type_column"`
+	UserIDColumn    string        `mapstructure:"user_id_column"`
+	MaxPeriod       time.Duration `mapstructure:"max_period"`
+}
+
+//Validate required fields in MetricsConfig and set default values
+func (mc *MetricsConfig) Validate() error {
+	if mc.Destination == "" {
+		return errors.New("metrics_api destination is required parameter")
+	}
+	if mc.MaxPeriod < 0 {
+		return errors.New("metrics_api max_period can't be negative")
+	}
+
+	if mc.Table == "" {
+		mc.Table = defaultUserEventsTable
+	}
+	if mc.TimeColumn == "" {
+		mc.TimeColumn = defaultUserEventsTimeColumn
+	}
+	if mc.EventTypeColumn == "" {
+		mc.EventTypeColumn = defaultMetricsEventTypeColumn
+	}
+	if mc.UserIDColumn == "" {
+		mc.UserIDColumn = defaultUserEventsUserIDColumn
+	}
+	if mc.MaxPeriod == 0 {
+		mc.MaxPeriod = defaultMetricsMaxPeriod
+	}
+
+	for _, column := range []string{mc.TimeColumn, mc.EventTypeColumn, mc.UserIDColumn} {
+		if !columnIdentifier.MatchString(column) {
+			return fmt.Errorf("metrics_api column [%s] must contain only letters, digits and underscores", column)
+		}
+	}
+
+	return nil
+}
+
+type MetricResponse struct {
+	Metric      string                   `json:"metric"`
+	Destination string                   `json:"destination"`
+	Since       time.Time                `json:"since"`
+	Rows        []map[string]interface{} `json:"rows"`
+}
+
+//MetricsHandler runs predefined aggregate queries (see adapters.Metrics) against the configured SQL destination table
+//Only metric name and period are provided by the caller. Admin token is required
+type MetricsHandler struct {
+	config *MetricsConfig
+	//storages.SelectMetricRows
+	selectMetricRows func(name string, query *adapters.MetricQuery) ([]map[string]interface{}, bool, error)
+}
+
+//NewMetricsHandler return MetricsHandler. Config must be validated
+func NewMetricsHandler(config *MetricsConfig) *MetricsHandler {
+	return &MetricsHandler{config: config, selectMetricRows: storages.SelectMetricRows}
+}
+
+//Routes return metrics API route
+func (mh *MetricsHandler) Routes() []Route {
+	return []Route{
+		{
+			Operation: openapi.Operation{Method: http.MethodGet, Path: MetricsPath, Summary: "Predefined aggregate query against SQL destination: " + strings.Join(adapters.Metrics, ", "), Tags: []string{"statistics"}, Security: []string{AdminTokenSecurity}, QueryParams: []openapi.Parameter{{Name: "period", Description: fmt.Sprintf("duration before now which is aggregated (default: %s, max: %s)", defaultMetricsPeriod, mh.config.MaxPeriod)}}, Response: MetricResponse{}},
+			Handler:   middleware.AdminAuth(mh.Handler),
+		},
+	}
+}
+
+func (mh *MetricsHandler) Handler(c *gin.Context) {
+	metric := c.Param("metric")
+	known := false
+	for _, m := range adapters.Metrics {
+		if m == metric {
+			known = true
+			break
+		}
+	}
+	if !known {
+		c.JSON(http.StatusNotFound, ErrorResponse{Message: fmt.Sprintf("Unknown metric [%s]. Available metrics: %s", metric, strings.Join(adapters.Metrics, ", "))})
+		return
+	}
+
+	period, err := durationQuery(c, "period", defaultMetricsPeriod)
+	if err != nil || period == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Message: "period query parameter must be a positive duration (e.g. 24h)"})
+		return
+	}
+	if period > mh.config.MaxPeriod {
+		period = mh.config.MaxPeriod
+	}
+
+	query := &adapters.MetricQuery{
+		Metric:          metric,
+		Table:           mh.config.Table,
+		TimeColumn:      mh.config.TimeColumn,
+		EventTypeColumn: mh.config.EventTypeColumn,
+		UserIDColumn:    mh.config.UserIDColumn,
+		Since:           time.Now().UTC().Add(-period),
+	}
+	rows, ok, err := mh.selectMetricRows(mh.config.Destination, query)
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Message: fmt.Sprintf("Destination [%s] isn't initialized or can't be queried", mh.config.Destination)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, ErrorResponse{Message: "Failed to query destination", Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, MetricResponse{Metric: metric, Destination: mh.config.Destination, Since: query.Since, Rows: rows})
+}
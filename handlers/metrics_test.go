@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsConfigValidate(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         *MetricsConfig
+		expectedConfig *MetricsConfig
+		expectedErr    string
+	}{
+		{
+			"without destination",
+			&MetricsConfig{},
+			nil,
+			"metrics_api destination is required parameter",
+		},
+		{
+			"negative max period",
+			&MetricsConfig{Destination: "ch", MaxPeriod: -time.Hour},
+			nil,
+			"metrics_api max_period can't be negative",
+		},
+		{
+			"malformed event type column",
+			&MetricsConfig{Destination: "ch", EventTypeColumn: "event-type"},
+			nil,
+			"metrics_api column [event-type] must contain only letters, digits and underscores",
+		},
+		{
+			"malformed user id column",
+			&MetricsConfig{Destination: "ch", UserIDColumn: "user_id) FROM users --"},
+			nil,
+			"metrics_api column [user_id) FROM users --] must contain only letters, digits and underscores",
+		},
+		{
+			"default values",
+			&MetricsConfig{Destination: "ch"},
+			&MetricsConfig{Destination: "ch", Table: "events", TimeColumn: "_timestamp", EventTypeColumn: "event_type",
+				UserIDColumn: "eventn_ctx_user_anonymous_id", MaxPeriod: 30 * 24 * time.Hour},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedConfig, tt.config)
+		})
+	}
+}
+
+//storages.SelectMetricRows mock
+type metricsSelectorMock struct {
+	rows []map[string]interface{}
+	ok   bool
+	err  error
+
+	query *adapters.MetricQuery
+}
+
+func (msm *metricsSelectorMock) selectMetricRows(name string, query *adapters.MetricQuery) ([]map[string]interface{}, bool, error) {
+	msm.query = query
+	return msm.rows, msm.ok, msm.err
+}
+
+func TestMetricsHandler(t *testing.T) {
+	rows := []map[string]interface{}{{"hour": "2020-08-02T18:00:00Z", "event_type": "pageview", "count": 10}}
+	tests := []struct {
+		name           string
+		path           string
+		adminToken     string
+		selector       *metricsSelectorMock
+		expectedCode   int
+		expectedBody   string
+		expectedPeriod time.Duration
+	}{
+		{
+			"without admin token",
+			"/api/v1/metrics/events_per_hour",
+			"",
+			&metricsSelectorMock{rows: rows, ok: true},
+			http.StatusUnauthorized,
+			"",
+			0,
+		},
+		{
+			"unknown metric",
+			"/api/v1/metrics/revenue",
+			testAdminToken,
+			&metricsSelectorMock{rows: rows, ok: true},
+			http.StatusNotFound,
+			`{"message":"Unknown metric [revenue]. Available metrics: events_per_hour, uniques_per_day"}`,
+			0,
+		},
+		{
+			"default period",
+			"/api/v1/metrics/events_per_hour",
+			testAdminToken,
+			&metricsSelectorMock{rows: rows, ok: true},
+			http.StatusOK,
+			"",
+			24 * time.Hour,
+		},
+		{
+			"period",
+			"/api/v1/metrics/uniques_per_day?period=12h",
+			testAdminToken,
+			&metricsSelectorMock{rows: rows, ok: true},
+			http.StatusOK,
+			"",
+			12 * time.Hour,
+		},
+		{
+			"period over max period",
+			"/api/v1/metrics/uniques_per_day?period=8760h",
+			testAdminToken,
+			&metricsSelectorMock{rows: rows, ok: true},
+			http.StatusOK,
+			"",
+			48 * time.Hour,
+		},
+		{
+			"negative period",
+			"/api/v1/metrics/events_per_hour?period=-1h",
+			testAdminToken,
+			&metricsSelectorMock{rows: rows, ok: true},
+			http.StatusBadRequest,
+			`{"message":"period query parameter must be a positive duration (e.g. 24h)"}`,
+			0,
+		},
+		{
+			"zero period",
+			"/api/v1/metrics/events_per_hour?period=0s",
+			testAdminToken,
+			&metricsSelectorMock{rows: rows, ok: true},
+			http.StatusBadRequest,
+			`{"message":"period query parameter must be a positive duration (e.g. 24h)"}`,
+			0,
+		},
+		{
+			"malformed period",
+			"/api/v1/metrics/events_per_hour?period=week",
+			testAdminToken,
+			&metricsSelectorMock{rows: rows, ok: true},
+			http.StatusBadRequest,
+			`{"message":"period query parameter must be a positive duration (e.g. 24h)"}`,
+			0,
+		},
+		{
+			"destination isn't initialized",
+			"/api/v1/metrics/events_per_hour",
+			testAdminToken,
+			&metricsSelectorMock{},
+			http.StatusServiceUnavailable,
+			`{"message":"Destination [ch] isn't initialized or can't be queried"}`,
+			24 * time.Hour,
+		},
+		{
+			"query error",
+			"/api/v1/metrics/events_per_hour",
+			testAdminToken,
+			&metricsSelectorMock{ok: true, err: errors.New("Table default.events doesn't exist")},
+			http.StatusBadGateway,
+			`{"message":"Failed to query destination","error":"Table default.events doesn't exist"}`,
+			24 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &MetricsConfig{Destination: "ch", MaxPeriod: 48 * time.Hour}
+			require.NoError(t, config.Validate())
+			handler := NewMetricsHandler(config)
+			handler.selectMetricRows = tt.selector.selectMetricRows
+
+			started := time.Now().UTC()
+			code, body := get(newTestRouter(handler.Routes()), tt.path, tt.adminToken)
+			require.Equal(t, tt.expectedCode, code)
+			if tt.expectedBody != "" {
+				require.JSONEq(t, tt.expectedBody, body)
+			}
+
+			if tt.expectedPeriod == 0 {
+				require.Nil(t, tt.selector.query)
+				return
+			}
+
+			//only metric and period are provided by the caller: columns are from config
+			metric := strings.TrimPrefix(strings.Split(tt.path, "?")[0], "/api/v1/metrics/")
+			query := tt.selector.query
+			require.Equal(t, &adapters.MetricQuery{Metric: metric, Table: "events", TimeColumn: "_timestamp", EventTypeColumn: "event_type",
+				UserIDColumn: "eventn_ctx_user_anonymous_id", Since: query.Since}, query)
+			require.WithinDuration(t, started.Add(-tt.expectedPeriod), query.Since, time.Second)
+
+			if code == http.StatusOK {
+				response := &MetricResponse{}
+				require.NoError(t, json.Unmarshal([]byte(body), response))
+				require.Equal(t, metric, response.Metric)
+				require.Equal(t, "ch", response.Destination)
+				require.True(t, query.Since.Equal(response.Since))
+				require.Equal(t, []map[string]interface{}{{"hour": "2020-08-02T18:00:00Z", "event_type": "pageview", "count": float64(10)}}, response.Rows)
+			}
+		})
+	}
+}
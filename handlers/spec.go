@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ksensehq/eventnative/middleware"
+	"github.com/ksensehq/eventnative/openapi"
+	"net/http"
+)
+
+const (
+	SpecPath = "/api/spec"
+
+	APITokenSecurity   = "apiToken"
+	AdminTokenSecurity = "adminToken"
+
+	specTitle   = "EventNative API"
+	specVersion = "1.0"
+)
+
+//Route is http endpoint description with handler. It is used for both registering routes and generating OpenAPI spec
+type Route struct {
+	openapi.Operation
+	Handler gin.HandlerFunc
+}
+
+//SpecHandler serves OpenAPI document of all registered routes
+type SpecHandler struct {
+	document *openapi.Document
+}
+
+//NewSpecHandler return SpecHandler with OpenAPI document generated from routes
+func NewSpecHandler(routes []Route) *SpecHandler {
+	var operations []openapi.Operation
+	for _, route := range routes {
+		operations = append(operations, route.Operation)
+	}
+
+	securitySchemes := map[string]openapi.SecurityScheme{
+		APITokenSecurity:   {Type: "apiKey", In: "query", Name: middleware.TokenName},
+		AdminTokenSecurity: {Type: "apiKey", In: "header", Name: middleware.AdminTokenHeader},
+	}
+
+	return &SpecHandler{document: openapi.Generate(openapi.Info{Title: specTitle, Version: specVersion}, securitySchemes, ErrorResponse{}, operations)}
+}
+
+func (sh *SpecHandler) Handler(c *gin.Context) {
+	c.JSON(http.StatusOK, sh.document)
+}
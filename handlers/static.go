@@ -5,6 +5,7 @@ import (
 	"compress/gzip"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/ksensehq/eventnative/compression"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -23,6 +24,7 @@ const eventsChainJsTemplate = "eventN.track('%s'); "
 type StaticHandler struct {
 	servingFiles    map[string][]byte
 	gzippedFiles    map[string][]byte
+	zstdFiles       map[string][]byte
 	serverPublicUrl string
 	inlineJsParts   [][]byte
 }
@@ -46,6 +48,7 @@ func NewStaticHandler(sourceDir, serverPublicUrl string) *StaticHandler {
 	}
 	servingFiles := map[string][]byte{}
 	gzippedFiles := map[string][]byte{}
+	zstdFiles := map[string][]byte{}
 	for _, f := range files {
 		if f.IsDir() {
 			log.Println("Serving directories isn't supported", f.Name())
@@ -71,6 +74,12 @@ func NewStaticHandler(sourceDir, serverPublicUrl string) *StaticHandler {
 		} else {
 			gzippedFiles[f.Name()] = gzipped
 		}
+		compressed, err := compression.Compress(compression.Zstd, servingFiles[f.Name()])
+		if err != nil {
+			log.Println("Failed to compress with zstd", sourceDir+f.Name(), err)
+		} else {
+			zstdFiles[f.Name()] = compressed
+		}
 		log.Println("Serve static file:", "/"+f.Name())
 	}
 	var inlineJsParts = make([][]byte, 2)
@@ -82,6 +91,7 @@ func NewStaticHandler(sourceDir, serverPublicUrl string) *StaticHandler {
 		serverPublicUrl: serverPublicUrl,
 		inlineJsParts:   inlineJsParts,
 		gzippedFiles:    gzippedFiles,
+		zstdFiles:       zstdFiles,
 	}
 }
 
@@ -135,9 +145,13 @@ func (sh *StaticHandler) Handler(c *gin.Context) {
 		}
 
 	default:
-		gzipped, ok := sh.gzippedFiles[fileName]
-		if ok && strings.Contains(c.Request.Header.Get("Accept-Encoding"), "gzip") {
-			c.Header("Content-Encoding", "gzip")
+		//zstd is preferred: it is smaller and faster to decode
+		acceptEncoding := c.Request.Header.Get("Accept-Encoding")
+		if compressed, ok := sh.zstdFiles[fileName]; ok && strings.Contains(acceptEncoding, compression.Zstd) {
+			c.Header("Content-Encoding", compression.Zstd)
+			c.Writer.Write(compressed)
+		} else if gzipped, ok := sh.gzippedFiles[fileName]; ok && strings.Contains(acceptEncoding, compression.Gzip) {
+			c.Header("Content-Encoding", compression.Gzip)
 			c.Writer.Write(gzipped)
 		} else {
 			c.Writer.Write(file)
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/ksensehq/eventnative/middleware"
+	"github.com/ksensehq/eventnative/openapi"
+	"github.com/ksensehq/eventnative/storages"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+const (
+	UserEventsPath = "/api/v1/users/:id/events"
+
+	defaultUserEventsTable        = "events"
+	defaultUserEventsUserIDColumn = "eventn_ctx_user_anonymous_id"
+	defaultUserEventsTimeColumn   = "_timestamp"
+	defaultUserEventsLimit        = 20
+	defaultUserEventsMaxLimit     = 100
+)
+
+//configured columns are put into queries as is (values are passed as parameters)
+var columnIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+//UserEventsConfig dto for deserialized server.users_api config
+//destination: name of SQL destination (postgres, redshift or clickhouse) which is queried
+//table: table with users events (after table_name_template is applied)
+//user_id_column and time_column: flattened column names of user id filter and order
+//max_limit: max value of limit query parameter
+type UserEventsConfig struct {
+	Destination  string `mapstructure:"destination"`
+	Table        string `mapstructure:"table"`
+	UserIDColumn string `mapstructure:"user_id_column"`
+	TimeColumn   string `mapstructure:"time_column"`
+	MaxLimit     int    `mapstructure:"max_limit"`
+}
+
+//Validate required fields in UserEventsConfig and set default values
+func (uec *UserEventsConfig) Validate() error {
+	if uec.Destination == "" {
+		return errors.New("users_api destination is required parameter")
+	}
+	if uec.MaxLimit < 0 {
+		return errors.New("users_api max_limit can't be negative")
+	}
+
+	if uec.Table == "" {
+		uec.Table = defaultUserEventsTable
+	}
+	if uec.UserIDColumn == "" {
+		uec.UserIDColumn = defaultUserEventsUserIDColumn
+	}
+	if uec.TimeColumn == "" {
+		uec.TimeColumn = defaultUserEventsTimeColumn
+	}
+	if uec.MaxLimit == 0 {
+		uec.MaxLimit = defaultUserEventsMaxLimit
+	}
+
+	for _, column := range []string{uec.UserIDColumn, uec.TimeColumn} {
+		if !columnIdentifier.MatchString(column) {
+			return fmt.Errorf("users_api column [%s] must contain only letters, digits and underscores", column)
+		}
+	}
+
+	return nil
+}
+
+type UserEventsResponse struct {
+	UserID      string                   `json:"user_id"`
+	Destination string                   `json:"destination"`
+	Events      []map[string]interface{} `json:"events"`
+}
+
+//UserEventsHandler serves last events of a user from the configured SQL destination table
+//The query is fixed: only user id and limit are provided by the caller. Admin token is required
+type UserEventsHandler struct {
+	config *UserEventsConfig
+	//storages.SelectLastRows
+	selectLastRows func(name, tableName, keyColumn, orderColumn string, value interface{}, limit int) ([]map[string]interface{}, bool, error)
+}
+
+//NewUserEventsHandler return UserEventsHandler. Config must be validated
+func NewUserEventsHandler(config *UserEventsConfig) *UserEventsHandler {
+	return &UserEventsHandler{config: config, selectLastRows: storages.SelectLastRows}
+}
+
+//Routes return users events API route
+func (ueh *UserEventsHandler) Routes() []Route {
+	return []Route{
+		{
+			Operation: openapi.Operation{Method: http.MethodGet, Path: UserEventsPath, Summary: "Last events of the user from SQL destination (the newest first)", Tags: []string{"events"}, Security: []string{AdminTokenSecurity}, QueryParams: []openapi.Parameter{{Name: "limit", Description: fmt.Sprintf("max events count (default: %d, max: %d)", defaultUserEventsLimit, ueh.config.MaxLimit)}}, Response: UserEventsResponse{}},
+			Handler:   middleware.AdminAuth(ueh.Handler),
+		},
+	}
+}
+
+func (ueh *UserEventsHandler) Handler(c *gin.Context) {
+	userID := c.Param("id")
+
+	limit := defaultUserEventsLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Message: "limit query parameter must be a positive integer"})
+			return
+		}
+	}
+	if limit > ueh.config.MaxLimit {
+		limit = ueh.config.MaxLimit
+	}
+
+	rows, ok, err := ueh.selectLastRows(ueh.config.Destination, ueh.config.Table, ueh.config.UserIDColumn, ueh.config.TimeColumn, userID, limit)
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Message: fmt.Sprintf("Destination [%s] isn't initialized or can't be queried", ueh.config.Destination)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, ErrorResponse{Message: "Failed to query destination", Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, UserEventsResponse{UserID: userID, Destination: ueh.config.Destination, Events: rows})
+}
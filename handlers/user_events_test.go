@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestUserEventsConfigValidate(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         *UserEventsConfig
+		expectedConfig *UserEventsConfig
+		expectedErr    string
+	}{
+		{
+			"without destination",
+			&UserEventsConfig{},
+			nil,
+			"users_api destination is required parameter",
+		},
+		{
+			"negative max limit",
+			&UserEventsConfig{Destination: "pg", MaxLimit: -1},
+			nil,
+			"users_api max_limit can't be negative",
+		},
+		{
+			"malformed user id column",
+			&UserEventsConfig{Destination: "pg", UserIDColumn: "user_id; drop table events"},
+			nil,
+			"users_api column [user_id; drop table events] must contain only letters, digits and underscores",
+		},
+		{
+			"malformed time column",
+			&UserEventsConfig{Destination: "pg", TimeColumn: "1time"},
+			nil,
+			"users_api column [1time] must contain only letters, digits and underscores",
+		},
+		{
+			"default values",
+			&UserEventsConfig{Destination: "pg"},
+			&UserEventsConfig{Destination: "pg", Table: "events", UserIDColumn: "eventn_ctx_user_anonymous_id", TimeColumn: "_timestamp", MaxLimit: 100},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedConfig, tt.config)
+		})
+	}
+}
+
+//storages.SelectLastRows mock
+type lastRowsSelectorMock struct {
+	rows []map[string]interface{}
+	ok   bool
+	err  error
+
+	userID interface{}
+	limit  int
+}
+
+func (lrsm *lastRowsSelectorMock) selectLastRows(name, tableName, keyColumn, orderColumn string, value interface{}, limit int) ([]map[string]interface{}, bool, error) {
+	lrsm.userID = value
+	lrsm.limit = limit
+	return lrsm.rows, lrsm.ok, lrsm.err
+}
+
+func TestUserEventsHandler(t *testing.T) {
+	rows := []map[string]interface{}{{"event_type": "purchase"}, {"event_type": "pageview"}}
+	tests := []struct {
+		name          string
+		path          string
+		adminToken    string
+		selector      *lastRowsSelectorMock
+		expectedCode  int
+		expectedBody  string
+		expectedLimit int
+	}{
+		{
+			"without admin token",
+			"/api/v1/users/u1/events",
+			"",
+			&lastRowsSelectorMock{rows: rows, ok: true},
+			http.StatusUnauthorized,
+			"",
+			0,
+		},
+		{
+			"default limit",
+			"/api/v1/users/u1/events",
+			testAdminToken,
+			&lastRowsSelectorMock{rows: rows, ok: true},
+			http.StatusOK,
+			`{"user_id":"u1","destination":"pg","events":[{"event_type":"purchase"},{"event_type":"pageview"}]}`,
+			20,
+		},
+		{
+			"limit",
+			"/api/v1/users/u1/events?limit=5",
+			testAdminToken,
+			&lastRowsSelectorMock{rows: rows, ok: true},
+			http.StatusOK,
+			`{"user_id":"u1","destination":"pg","events":[{"event_type":"purchase"},{"event_type":"pageview"}]}`,
+			5,
+		},
+		{
+			"limit over max limit",
+			"/api/v1/users/u1/events?limit=1000",
+			testAdminToken,
+			&lastRowsSelectorMock{rows: rows, ok: true},
+			http.StatusOK,
+			`{"user_id":"u1","destination":"pg","events":[{"event_type":"purchase"},{"event_type":"pageview"}]}`,
+			50,
+		},
+		{
+			"negative limit",
+			"/api/v1/users/u1/events?limit=-5",
+			testAdminToken,
+			&lastRowsSelectorMock{rows: rows, ok: true},
+			http.StatusBadRequest,
+			`{"message":"limit query parameter must be a positive integer"}`,
+			0,
+		},
+		{
+			"zero limit",
+			"/api/v1/users/u1/events?limit=0",
+			testAdminToken,
+			&lastRowsSelectorMock{rows: rows, ok: true},
+			http.StatusBadRequest,
+			`{"message":"limit query parameter must be a positive integer"}`,
+			0,
+		},
+		{
+			"not numeric limit",
+			"/api/v1/users/u1/events?limit=ten",
+			testAdminToken,
+			&lastRowsSelectorMock{rows: rows, ok: true},
+			http.StatusBadRequest,
+			`{"message":"limit query parameter must be a positive integer"}`,
+			0,
+		},
+		{
+			"destination isn't initialized",
+			"/api/v1/users/u1/events",
+			testAdminToken,
+			&lastRowsSelectorMock{},
+			http.StatusServiceUnavailable,
+			`{"message":"Destination [pg] isn't initialized or can't be queried"}`,
+			20,
+		},
+		{
+			"query error",
+			"/api/v1/users/u1/events",
+			testAdminToken,
+			&lastRowsSelectorMock{ok: true, err: errors.New("relation \"events\" does not exist")},
+			http.StatusBadGateway,
+			`{"message":"Failed to query destination","error":"relation \"events\" does not exist"}`,
+			20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &UserEventsConfig{Destination: "pg", MaxLimit: 50}
+			require.NoError(t, config.Validate())
+			handler := NewUserEventsHandler(config)
+			handler.selectLastRows = tt.selector.selectLastRows
+
+			code, body := get(newTestRouter(handler.Routes()), tt.path, tt.adminToken)
+			require.Equal(t, tt.expectedCode, code)
+			if tt.expectedBody != "" {
+				require.JSONEq(t, tt.expectedBody, body)
+			}
+			require.Equal(t, tt.expectedLimit, tt.selector.limit)
+			if tt.expectedLimit != 0 {
+				require.Equal(t, "u1", tt.selector.userID)
+			}
+		})
+	}
+}
+
+func TestUserEventsHandlerEscapedUserID(t *testing.T) {
+	selector := &lastRowsSelectorMock{rows: []map[string]interface{}{}, ok: true}
+	config := &UserEventsConfig{Destination: "pg"}
+	require.NoError(t, config.Validate())
+	handler := NewUserEventsHandler(config)
+	handler.selectLastRows = selector.selectLastRows
+
+	//user id is passed to the query as a parameter as is
+	code, body := get(newTestRouter(handler.Routes()), "/api/v1/users/"+url.PathEscape("u1' or '1'='1")+"/events", testAdminToken)
+	require.Equal(t, http.StatusOK, code)
+	require.JSONEq(t, `{"user_id":"u1' or '1'='1","destination":"pg","events":[]}`, body)
+	require.Equal(t, "u1' or '1'='1", selector.userID)
+}
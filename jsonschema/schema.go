@@ -0,0 +1,433 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+//keywords which change validation result but aren't supported. Schemas with them are rejected on load
+//instead of being silently validated partially
+var unsupportedKeywords = []string{"$ref", "allOf", "anyOf", "oneOf", "not", "if", "then", "else", "patternProperties",
+	"propertyNames", "dependencies", "contains", "additionalItems", "uniqueItems", "minProperties", "maxProperties", "multipleOf"}
+
+var typeNames = map[string]bool{"null": true, "boolean": true, "integer": true, "number": true, "string": true, "array": true, "object": true}
+
+//Schema is a parsed JSON Schema (draft-07 subset): type, properties, required, additionalProperties, items, enum, const,
+//minimum, maximum, exclusiveMinimum, exclusiveMaximum, minLength, maxLength, pattern, minItems, maxItems and boolean schemas
+//other keywords (title, description, format, etc.) are annotations and they are ignored
+type Schema struct {
+	//true/false schema: accepts/rejects all values
+	boolean *bool
+
+	types                []string
+	properties           map[string]*Schema
+	required             []string
+	additionalProperties *Schema
+	items                *Schema
+	enum                 []interface{}
+	hasConst             bool
+	constant             interface{}
+	minimum              *float64
+	maximum              *float64
+	exclusiveMinimum     *float64
+	exclusiveMaximum     *float64
+	minLength            *int
+	maxLength            *int
+	pattern              *regexp.Regexp
+	minItems             *int
+	maxItems             *int
+}
+
+//ValidationError contains all violations of the validated value. Every violation is prefixed with JSON pointer
+//of the invalid node (e.g. /user/id: expected type string, got number)
+type ValidationError struct {
+	Violations []string
+}
+
+func (ve *ValidationError) Error() string {
+	return strings.Join(ve.Violations, "; ")
+}
+
+//Load read and parse JSON Schema file
+func Load(filePath string) (*Schema, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading JSON schema file [%s]: %v", filePath, err)
+	}
+
+	schema, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing JSON schema file [%s]: %v", filePath, err)
+	}
+
+	return schema, nil
+}
+
+//Parse return Schema from JSON bytes
+//return err if schema is malformed or contains unsupported keywords
+func Parse(b []byte) (*Schema, error) {
+	var raw interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+
+	return parse(raw, "")
+}
+
+func parse(raw interface{}, path string) (*Schema, error) {
+	if boolean, ok := raw.(bool); ok {
+		return &Schema{boolean: &boolean}, nil
+	}
+
+	object, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object or a boolean", pointer(path))
+	}
+
+	for _, keyword := range unsupportedKeywords {
+		if _, ok := object[keyword]; ok {
+			return nil, fmt.Errorf("%s: keyword [%s] isn't supported", pointer(path), keyword)
+		}
+	}
+
+	schema := &Schema{}
+	var err error
+
+	switch t := object["type"].(type) {
+	case nil:
+	case string:
+		schema.types = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: type must be a string or an array of strings", pointer(path))
+			}
+			schema.types = append(schema.types, name)
+		}
+	default:
+		return nil, fmt.Errorf("%s: type must be a string or an array of strings", pointer(path))
+	}
+	for _, name := range schema.types {
+		if !typeNames[name] {
+			return nil, fmt.Errorf("%s: unknown type [%s]", pointer(path), name)
+		}
+	}
+
+	if rawProperties, ok := object["properties"]; ok {
+		properties, ok := rawProperties.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: properties must be an object", pointer(path))
+		}
+		schema.properties = map[string]*Schema{}
+		for name, rawProperty := range properties {
+			if schema.properties[name], err = parse(rawProperty, path+"/properties/"+name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if rawRequired, ok := object["required"]; ok {
+		required, ok := rawRequired.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: required must be an array of strings", pointer(path))
+		}
+		for _, item := range required {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: required must be an array of strings", pointer(path))
+			}
+			schema.required = append(schema.required, name)
+		}
+	}
+
+	if rawAdditional, ok := object["additionalProperties"]; ok {
+		if schema.additionalProperties, err = parse(rawAdditional, path+"/additionalProperties"); err != nil {
+			return nil, err
+		}
+	}
+
+	if rawItems, ok := object["items"]; ok {
+		if _, tuple := rawItems.([]interface{}); tuple {
+			return nil, fmt.Errorf("%s: tuple items aren't supported", pointer(path))
+		}
+		if schema.items, err = parse(rawItems, path+"/items"); err != nil {
+			return nil, err
+		}
+	}
+
+	if rawEnum, ok := object["enum"]; ok {
+		enum, ok := rawEnum.([]interface{})
+		if !ok || len(enum) == 0 {
+			return nil, fmt.Errorf("%s: enum must be a non-empty array", pointer(path))
+		}
+		schema.enum = enum
+	}
+
+	if constant, ok := object["const"]; ok {
+		schema.hasConst = true
+		schema.constant = constant
+	}
+
+	for keyword, target := range map[string]**float64{"minimum": &schema.minimum, "maximum": &schema.maximum,
+		"exclusiveMinimum": &schema.exclusiveMinimum, "exclusiveMaximum": &schema.exclusiveMaximum} {
+		if rawValue, ok := object[keyword]; ok {
+			value, ok := rawValue.(float64)
+			if !ok {
+				//draft-04 boolean exclusiveMinimum/exclusiveMaximum are also rejected here
+				return nil, fmt.Errorf("%s: %s must be a number", pointer(path), keyword)
+			}
+			*target = &value
+		}
+	}
+
+	for keyword, target := range map[string]**int{"minLength": &schema.minLength, "maxLength": &schema.maxLength,
+		"minItems": &schema.minItems, "maxItems": &schema.maxItems} {
+		if rawValue, ok := object[keyword]; ok {
+			value, ok := rawValue.(float64)
+			if !ok || value < 0 || value != math.Trunc(value) {
+				return nil, fmt.Errorf("%s: %s must be a non-negative integer", pointer(path), keyword)
+			}
+			intValue := int(value)
+			*target = &intValue
+		}
+	}
+
+	if rawPattern, ok := object["pattern"]; ok {
+		pattern, ok := rawPattern.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: pattern must be a string", pointer(path))
+		}
+		if schema.pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("%s: malformed pattern: %v", pointer(path), err)
+		}
+	}
+
+	return schema, nil
+}
+
+//Validate value (e.g. unmarshaled JSON event) against the schema
+//return *ValidationError with all violations or nil if value is valid
+func (s *Schema) Validate(value interface{}) error {
+	var violations []string
+	s.validate(value, "", &violations)
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+func (s *Schema) validate(value interface{}, path string, violations *[]string) {
+	if s.boolean != nil {
+		if !*s.boolean {
+			addViolation(violations, path, "value isn't allowed")
+		}
+		return
+	}
+
+	value = normalize(value)
+
+	if len(s.types) > 0 && !matchesAnyType(value, s.types) {
+		addViolation(violations, path, fmt.Sprintf("expected type %s, got %s", strings.Join(s.types, " or "), typeOf(value)))
+		//other keywords describe values of the expected type
+		return
+	}
+
+	if s.enum != nil {
+		found := false
+		for _, item := range s.enum {
+			if reflect.DeepEqual(value, item) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			addViolation(violations, path, fmt.Sprintf("value %s isn't one of %s", marshal(value), marshal(s.enum)))
+		}
+	}
+
+	if s.hasConst && !reflect.DeepEqual(value, s.constant) {
+		addViolation(violations, path, fmt.Sprintf("value %s isn't equal to %s", marshal(value), marshal(s.constant)))
+	}
+
+	switch v := value.(type) {
+	case float64:
+		s.validateNumber(v, path, violations)
+	case string:
+		s.validateString(v, path, violations)
+	case []interface{}:
+		s.validateArray(v, path, violations)
+	case map[string]interface{}:
+		s.validateObject(v, path, violations)
+	}
+}
+
+func (s *Schema) validateNumber(value float64, path string, violations *[]string) {
+	if s.minimum != nil && value < *s.minimum {
+		addViolation(violations, path, fmt.Sprintf("value %v is less than minimum %v", value, *s.minimum))
+	}
+	if s.maximum != nil && value > *s.maximum {
+		addViolation(violations, path, fmt.Sprintf("value %v is greater than maximum %v", value, *s.maximum))
+	}
+	if s.exclusiveMinimum != nil && value <= *s.exclusiveMinimum {
+		addViolation(violations, path, fmt.Sprintf("value %v must be greater than %v", value, *s.exclusiveMinimum))
+	}
+	if s.exclusiveMaximum != nil && value >= *s.exclusiveMaximum {
+		addViolation(violations, path, fmt.Sprintf("value %v must be less than %v", value, *s.exclusiveMaximum))
+	}
+}
+
+func (s *Schema) validateString(value string, path string, violations *[]string) {
+	length := utf8.RuneCountInString(value)
+	if s.minLength != nil && length < *s.minLength {
+		addViolation(violations, path, fmt.Sprintf("length %d is less than minLength %d", length, *s.minLength))
+	}
+	if s.maxLength != nil && length > *s.maxLength {
+		addViolation(violations, path, fmt.Sprintf("length %d is greater than maxLength %d", length, *s.maxLength))
+	}
+	if s.pattern != nil && !s.pattern.MatchString(value) {
+		addViolation(violations, path, fmt.Sprintf("value %q doesn't match pattern %s", value, s.pattern.String()))
+	}
+}
+
+func (s *Schema) validateArray(value []interface{}, path string, violations *[]string) {
+	if s.minItems != nil && len(value) < *s.minItems {
+		addViolation(violations, path, fmt.Sprintf("items count %d is less than minItems %d", len(value), *s.minItems))
+	}
+	if s.maxItems != nil && len(value) > *s.maxItems {
+		addViolation(violations, path, fmt.Sprintf("items count %d is greater than maxItems %d", len(value), *s.maxItems))
+	}
+	if s.items != nil {
+		for i, item := range value {
+			s.items.validate(item, fmt.Sprintf("%s/%d", path, i), violations)
+		}
+	}
+}
+
+func (s *Schema) validateObject(value map[string]interface{}, path string, violations *[]string) {
+	for _, name := range s.required {
+		if _, ok := value[name]; !ok {
+			addViolation(violations, path, fmt.Sprintf("required property [%s] is missing", name))
+		}
+	}
+
+	//sorted names make violations order stable
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propertyPath := path + "/" + escape(name)
+		if property, ok := s.properties[name]; ok {
+			property.validate(value[name], propertyPath, violations)
+		} else if s.additionalProperties != nil {
+			if s.additionalProperties.boolean != nil && !*s.additionalProperties.boolean {
+				addViolation(violations, propertyPath, "additional property isn't allowed")
+			} else {
+				s.additionalProperties.validate(value[name], propertyPath, violations)
+			}
+		}
+	}
+}
+
+//normalize numbers into float64 and events.Fact-like maps into map[string]interface{}
+//so values are compared with enum and const ones parsed from JSON
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case map[string]interface{}:
+		return v
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = normalize(item)
+		}
+		return result
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String && rv.Type().Elem().Kind() == reflect.Interface {
+		result := map[string]interface{}{}
+		for _, key := range rv.MapKeys() {
+			result[key.String()] = rv.MapIndex(key).Interface()
+		}
+		return result
+	}
+
+	return value
+}
+
+func matchesAnyType(value interface{}, types []string) bool {
+	actual := typeOf(value)
+	for _, t := range types {
+		if t == actual || (t == "integer" && actual == "number" && value.(float64) == math.Trunc(value.(float64))) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func addViolation(violations *[]string, path, message string) {
+	*violations = append(*violations, pointer(path)+": "+message)
+}
+
+func pointer(path string) string {
+	if path == "" {
+		return "/"
+	}
+
+	return path
+}
+
+//escape property name as JSON pointer token
+func escape(name string) string {
+	return strings.Replace(strings.Replace(name, "~", "~0", -1), "/", "~1", -1)
+}
+
+func marshal(value interface{}) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(b)
+}
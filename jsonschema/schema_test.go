@@ -0,0 +1,110 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const eventSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "event",
+  "type": "object",
+  "required": ["event_type", "user"],
+  "properties": {
+    "event_type": {"type": "string", "enum": ["pageview", "click"]},
+    "version": {"const": 2},
+    "user": {
+      "type": "object",
+      "required": ["id"],
+      "properties": {
+        "id": {"type": "string", "minLength": 3, "maxLength": 8, "pattern": "^u"},
+        "age": {"type": ["integer", "null"], "minimum": 0, "exclusiveMaximum": 150}
+      },
+      "additionalProperties": false
+    },
+    "tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}},
+    "revenue": {"type": "number", "exclusiveMinimum": 0}
+  }
+}`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		schema      string
+		expectedErr string
+	}{
+		{"object", eventSchema, ""},
+		{"boolean", `true`, ""},
+		{"malformed json", `{`, "unexpected end of JSON input"},
+		{"not schema", `"string"`, "/: schema must be an object or a boolean"},
+		{"unknown type", `{"properties": {"a": {"type": "int"}}}`, "/properties/a: unknown type [int]"},
+		{"unsupported keyword", `{"items": {"anyOf": [{"type": "string"}]}}`, "/items: keyword [anyOf] isn't supported"},
+		{"tuple items", `{"items": [{"type": "string"}]}`, "/: tuple items aren't supported"},
+		{"malformed minLength", `{"minLength": -1}`, "/: minLength must be a non-negative integer"},
+		{"malformed pattern", `{"pattern": "("}`, "/: malformed pattern: error parsing regexp: missing closing ): `(`"},
+		{"empty enum", `{"enum": []}`, "/: enum must be a non-empty array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.schema))
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schema, err := Parse([]byte(eventSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		event       map[string]interface{}
+		expectedErr string
+	}{
+		{
+			"valid",
+			map[string]interface{}{"event_type": "click", "version": 2.0, "user": map[string]interface{}{"id": "u123", "age": 30.0}, "tags": []interface{}{"a"}, "other": true},
+			"",
+		},
+		{
+			"valid with json.Number and null",
+			map[string]interface{}{"event_type": "pageview", "version": json.Number("2"), "user": map[string]interface{}{"id": "u123", "age": nil}},
+			"",
+		},
+		{
+			"missing required",
+			map[string]interface{}{"user": map[string]interface{}{}},
+			"/: required property [event_type] is missing; /user: required property [id] is missing",
+		},
+		{
+			"wrong types",
+			map[string]interface{}{"event_type": 1.0, "user": map[string]interface{}{"id": 123.0, "age": 30.5}, "tags": []interface{}{"a", 1.0}},
+			"/event_type: expected type string, got number; /tags/1: expected type string, got number; /user/age: expected type integer or null, got number; /user/id: expected type string, got number",
+		},
+		{
+			"values out of bounds",
+			map[string]interface{}{"event_type": "signup", "version": 1.0, "revenue": 0.0, "tags": []interface{}{"a", "b", "c"},
+				"user": map[string]interface{}{"id": "a1", "age": 150.0, "name": "John"}},
+			`/event_type: value "signup" isn't one of ["pageview","click"]; /revenue: value 0 must be greater than 0; /tags: items count 3 is greater than maxItems 2; /user/age: value 150 must be less than 150; /user/id: length 2 is less than minLength 3; /user/id: value "a1" doesn't match pattern ^u; /user/name: additional property isn't allowed; /version: value 1 isn't equal to 2`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate(tt.event)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	falseSchema, err := Parse([]byte(`false`))
+	require.NoError(t, err)
+	require.EqualError(t, falseSchema.Validate(map[string]interface{}{}), "/: value isn't allowed")
+}
@@ -0,0 +1,117 @@
+package logfiles
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	failedDir          = "failed"
+	failedMetaFileMask = "*.meta"
+)
+
+//FailedFile is a metadata of event log file payload which wasn't stored in the destination
+//the payload is kept next to it in $log.path/failed/$file-$destination.log
+type FailedFile struct {
+	File        string     `json:"file"`
+	Destination string     `json:"destination"`
+	Token       string     `json:"token"`
+	Error       string     `json:"error"`
+	Attempts    int        `json:"attempts"`
+	FailedAt    time.Time  `json:"failed_at"`
+	RetriedAt   *time.Time `json:"retried_at,omitempty"`
+}
+
+//keeps failed payloads with metadata files
+type failedStore struct {
+	dir string
+}
+
+func newFailedStore(logEventPath string) (*failedStore, error) {
+	dir := path.Join(logEventPath, failedDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Error creating failed files dir [%s]: %v", dir, err)
+	}
+
+	return &failedStore{dir: dir}, nil
+}
+
+//return payload and metadata file paths (without extensions)
+func (fs *failedStore) basePath(fileName, destination string) string {
+	return path.Join(fs.dir, strings.TrimSuffix(fileName, ".log")+"-"+destination)
+}
+
+//write payload and metadata. Metadata is written after payload: files without metadata are ignored
+func (fs *failedStore) spill(fileName, destination, token string, payload []byte, storeErr error) error {
+	basePath := fs.basePath(fileName, destination)
+	if err := ioutil.WriteFile(basePath+".log", payload, 0644); err != nil {
+		return err
+	}
+
+	return fs.update(&FailedFile{
+		File:        fileName,
+		Destination: destination,
+		Token:       token,
+		Error:       storeErr.Error(),
+		Attempts:    1,
+		FailedAt:    time.Now().UTC(),
+	})
+}
+
+func (fs *failedStore) update(failedFile *FailedFile) error {
+	b, err := json.Marshal(failedFile)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(fs.basePath(failedFile.File, failedFile.Destination)+".meta", b, 0644)
+}
+
+//return metadata of all failed payloads sorted by file name. Malformed metadata files are skipped
+func (fs *failedStore) list() ([]*FailedFile, error) {
+	metaFiles, err := filepath.Glob(path.Join(fs.dir, failedMetaFileMask))
+	if err != nil {
+		return nil, err
+	}
+
+	var failedFiles []*FailedFile
+	for _, metaFile := range metaFiles {
+		b, err := ioutil.ReadFile(metaFile)
+		if err != nil {
+			log.Println("Error reading failed file metadata", metaFile, err)
+			continue
+		}
+
+		failedFile := &FailedFile{}
+		if err := json.Unmarshal(b, failedFile); err != nil {
+			log.Println("Error parsing failed file metadata", metaFile, err)
+			continue
+		}
+		failedFiles = append(failedFiles, failedFile)
+	}
+	sort.Slice(failedFiles, func(i, j int) bool { return failedFiles[i].File < failedFiles[j].File })
+
+	return failedFiles, nil
+}
+
+func (fs *failedStore) payload(failedFile *FailedFile) ([]byte, error) {
+	return ioutil.ReadFile(fs.basePath(failedFile.File, failedFile.Destination) + ".log")
+}
+
+//remove metadata first: payload without metadata is ignored
+func (fs *failedStore) remove(failedFile *FailedFile) error {
+	basePath := fs.basePath(failedFile.File, failedFile.Destination)
+	if err := os.Remove(basePath + ".meta"); err != nil {
+		return err
+	}
+
+	return os.Remove(basePath + ".log")
+}
@@ -0,0 +1,114 @@
+package logfiles
+
+import (
+	"errors"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+type storageMock struct {
+	name          string
+	err           error
+	stored        []string
+	transactional bool
+}
+
+func (sm *storageMock) Transactional() bool {
+	return sm.transactional
+}
+
+func (sm *storageMock) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if sm.err != nil {
+		return sm.err
+	}
+	sm.stored = append(sm.stored, fileName+":"+string(payload))
+	return nil
+}
+
+func (sm *storageMock) Name() string {
+	return sm.name
+}
+
+func (sm *storageMock) Type() string {
+	return "mock"
+}
+
+func (sm *storageMock) Close() error {
+	return nil
+}
+
+func TestFailedPayloadRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "failed")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	storage := &storageMock{name: "pg", err: errors.New("connection refused"), transactional: true}
+	uploader, err := NewUploader(dir, "test-event-*-20*.log", 10, 60, time.Minute, events.StoragesByToken{"token1": {storage}}, nil)
+	require.NoError(t, err)
+	periodicUploader := uploader.(*PeriodicUploader)
+
+	fileName := "test-event-token1-2020-08-02T18-23-59.757.log"
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, fileName), []byte("{\"a\":1}\n"), 0644))
+
+	//failed payload is spilled into failed dir and the file is removed
+	require.Equal(t, []string{fileName}, periodicUploader.upload(10))
+	failedFiles, err := periodicUploader.failedStore.list()
+	require.NoError(t, err)
+	require.Len(t, failedFiles, 1)
+	require.Equal(t, "pg", failedFiles[0].Destination)
+	require.Equal(t, "token1", failedFiles[0].Token)
+	require.Equal(t, "connection refused", failedFiles[0].Error)
+	require.Equal(t, 1, failedFiles[0].Attempts)
+
+	//retry is failed: attempts are counted
+	periodicUploader.retryFailed()
+	failedFiles, err = periodicUploader.failedStore.list()
+	require.NoError(t, err)
+	require.Len(t, failedFiles, 1)
+	require.Equal(t, 2, failedFiles[0].Attempts)
+	require.NotNil(t, failedFiles[0].RetriedAt)
+
+	//retry is succeeded: failed payload is removed
+	storage.err = nil
+	periodicUploader.retryFailed()
+	require.Equal(t, []string{fileName + ":{\"a\":1}\n"}, storage.stored)
+	failedFiles, err = periodicUploader.failedStore.list()
+	require.NoError(t, err)
+	require.Len(t, failedFiles, 0)
+	dirFiles, err := ioutil.ReadDir(path.Join(dir, failedDir))
+	require.NoError(t, err)
+	require.Len(t, dirFiles, 0)
+}
+
+func TestFailedPayloadOfNonTransactionalDestination(t *testing.T) {
+	dir, err := ioutil.TempDir("", "failed")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	//e.g. ClickHouse: a part of file tables could have been stored
+	storage := &storageMock{name: "ch", err: errors.New("connection refused")}
+	uploader, err := NewUploader(dir, "test-event-*-20*.log", 10, 60, time.Minute, events.StoragesByToken{"token1": {storage}}, nil)
+	require.NoError(t, err)
+	periodicUploader := uploader.(*PeriodicUploader)
+
+	fileName := "test-event-token1-2020-08-02T18-23-59.757.log"
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, fileName), []byte("{\"a\":1}\n"), 0644))
+
+	//payload isn't spilled: the file is kept and retried by the uploader
+	require.Empty(t, periodicUploader.upload(10))
+	failedFiles, err := periodicUploader.failedStore.list()
+	require.NoError(t, err)
+	require.Empty(t, failedFiles)
+	_, err = os.Stat(path.Join(dir, fileName))
+	require.NoError(t, err)
+
+	storage.err = nil
+	require.Equal(t, []string{fileName}, periodicUploader.upload(10))
+	require.Equal(t, []string{fileName + ":{\"a\":1}\n"}, storage.stored)
+}
@@ -0,0 +1,54 @@
+package logfiles
+
+import (
+	"log"
+	"sync"
+)
+
+var Instance *Flusher
+
+//Rotator is implemented by event loggers which can close current log file before the scheduled rotation
+type Rotator interface {
+	Rotate() error
+}
+
+//Flusher rotates partially written event log files and uploads them to batch destinations right away
+//instead of waiting for the next rotation and upload (e.g. on shutdown or via admin API)
+type Flusher struct {
+	mutex    sync.Mutex
+	rotators []Rotator
+	uploader Uploader
+}
+
+//Init initialize Flusher Instance
+func Init(rotators []Rotator, uploader Uploader) {
+	Instance = NewFlusher(rotators, uploader)
+}
+
+func NewFlusher(rotators []Rotator, uploader Uploader) *Flusher {
+	return &Flusher{rotators: rotators, uploader: uploader}
+}
+
+//Flush rotate all event log files and upload them. Files which failed to rotate are uploaded with the next rotation
+//return names of files which have been stored in all destinations
+func (f *Flusher) Flush() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for _, rotator := range f.rotators {
+		if err := rotator.Rotate(); err != nil {
+			log.Println("Error flushing event log file:", err)
+		}
+	}
+
+	return f.uploader.Flush()
+}
+
+//Flush rotate and upload event log files with Instance if it was initialized
+func Flush() []string {
+	if Instance == nil {
+		return nil
+	}
+
+	return Instance.Flush()
+}
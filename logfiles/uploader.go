@@ -1,8 +1,15 @@
 package logfiles
 
 import (
+	"bytes"
 	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
 	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/memlimit"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/routing"
+	"github.com/ksensehq/eventnative/watermarks"
+	"github.com/ksensehq/eventnative/webhooks"
 	"io/ioutil"
 	"log"
 	"os"
@@ -10,6 +17,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"sync"
 	"time"
 )
 
@@ -18,10 +26,11 @@ var tokenExtractRegexp = regexp.MustCompile("-event-(.*)-\\d\\d\\d\\d-\\d\\d-\\d
 
 type Uploader interface {
 	Start()
+	Flush() []string
 }
 
 //PeriodicUploader read already rotated and closed log files
-//Pass them to storages according to tokens
+//Pass them to storages according to tokens: every storage stores its payloads in its own goroutines (see bulkhead)
 //Keep uploading log file with result statuses
 type PeriodicUploader struct {
 	logEventPath   string
@@ -30,7 +39,82 @@ type PeriodicUploader struct {
 	uploadEvery    time.Duration
 
 	statusManager          *statusManager
-	tokenizedEventStorages map[string][]events.Storage
+	tokenizedEventStorages events.TokenizedStorages
+
+	//nil if failed payloads aren't spilled (whole file is kept and retried). Only payloads of transactional destinations
+	//are spilled (see events.TransactionalStorage): other ones could have stored a part of file tables
+	failedStore      *failedStore
+	failedRetryEvery time.Duration
+
+	//max count of files which are stored into the destination concurrently
+	uploadWorkers func(destinationName string) int
+	//destination name: bulkhead
+	bulkheads      map[string]*bulkhead
+	bulkheadsMutex sync.Mutex
+
+	//periodic and on demand files reading and failed payloads retries don't run concurrently
+	mutex sync.Mutex
+	//statusManager and files removing are accessed by destinations workers concurrently
+	filesMutex sync.Mutex
+}
+
+//rotated event log file which is being stored into destinations
+//pending (names of destinations which haven't stored the file yet) and failed are guarded by filesMutex
+type logFile struct {
+	path    string
+	name    string
+	token   string
+	pending map[string]bool
+	failed  bool
+}
+
+//file payload of the destination
+type uploadTask struct {
+	file    *logFile
+	storage events.Storage
+	payload []byte
+}
+
+//uploadRun is files uploading into destinations which is done in background by destinations workers
+type uploadRun struct {
+	wg       sync.WaitGroup
+	mutex    sync.Mutex
+	uploaded []string
+}
+
+func (ur *uploadRun) add(fileName string) {
+	ur.mutex.Lock()
+	ur.uploaded = append(ur.uploaded, fileName)
+	ur.mutex.Unlock()
+}
+
+//bulkhead isolates uploading into a destination: only one run of the destination is in progress at a time
+//and files are stored by the destination own workers, so a slow destination doesn't stall uploading into other ones
+type bulkhead struct {
+	busy chan struct{}
+}
+
+func newBulkhead() *bulkhead {
+	return &bulkhead{busy: make(chan struct{}, 1)}
+}
+
+//acquire return true if the bulkhead is acquired. If block is true, wait for the run in progress
+func (b *bulkhead) acquire(block bool) bool {
+	if block {
+		b.busy <- struct{}{}
+		return true
+	}
+
+	select {
+	case b.busy <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (b *bulkhead) release() {
+	<-b.busy
 }
 
 type DummyUploader struct{}
@@ -38,8 +122,16 @@ type DummyUploader struct{}
 func (*DummyUploader) Start() {
 }
 
-func NewUploader(logEventPath, fileMask string, filesBatchSize, uploadEveryS int, tokenizedEventStorages map[string][]events.Storage) (Uploader, error) {
-	if len(tokenizedEventStorages) == 0 {
+func (*DummyUploader) Flush() []string {
+	return nil
+}
+
+//NewUploader return PeriodicUploader or DummyUploader if batch storages are nil (they can't be added e.g. in stateless mode)
+//if failedRetryEvery > 0, payloads which failed to be stored are written into $logEventPath/failed and retried every failedRetryEvery
+//every destination stores files in its own goroutines: up to uploadWorkers(destination) files concurrently (one if uploadWorkers is nil)
+func NewUploader(logEventPath, fileMask string, filesBatchSize, uploadEveryS int, failedRetryEvery time.Duration, tokenizedEventStorages events.TokenizedStorages,
+	uploadWorkers func(destinationName string) int) (Uploader, error) {
+	if tokenizedEventStorages == nil {
 		return &DummyUploader{}, nil
 	}
 
@@ -47,6 +139,19 @@ func NewUploader(logEventPath, fileMask string, filesBatchSize, uploadEveryS int
 	if err != nil {
 		return nil, err
 	}
+
+	var failed *failedStore
+	if failedRetryEvery > 0 {
+		failed, err = newFailedStore(logEventPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if uploadWorkers == nil {
+		uploadWorkers = func(string) int { return 1 }
+	}
+
 	return &PeriodicUploader{
 		logEventPath:           logEventPath,
 		fileMask:               path.Join(logEventPath, fileMask),
@@ -54,6 +159,10 @@ func NewUploader(logEventPath, fileMask string, filesBatchSize, uploadEveryS int
 		uploadEvery:            time.Duration(uploadEveryS) * time.Second,
 		statusManager:          statusManager,
 		tokenizedEventStorages: tokenizedEventStorages,
+		failedStore:            failed,
+		failedRetryEvery:       failedRetryEvery,
+		uploadWorkers:          uploadWorkers,
+		bulkheads:              map[string]*bulkhead{},
 	}, nil
 }
 
@@ -61,72 +170,334 @@ func NewUploader(logEventPath, fileMask string, filesBatchSize, uploadEveryS int
 //pass them to storages according to tokens
 //keep uploading log statuses file for every event log file
 func (u *PeriodicUploader) Start() {
+	if u.failedStore != nil {
+		u.startFailedRetry()
+	}
+
 	go func() {
 		for {
 			if appstatus.Instance.Idle {
 				break
 			}
-			files, err := filepath.Glob(u.fileMask)
-			if err != nil {
-				log.Println("Error finding files by mask", u.fileMask, err)
-				return
+			//don't read files into memory while load shedding is turned on
+			if memlimit.Shedding() {
+				log.Println("Log files uploading is paused because of memory limit")
+				time.Sleep(u.uploadEvery)
+				continue
 			}
+			u.dispatch(u.filesBatchSize, false)
+
+			time.Sleep(u.uploadEvery)
+		}
+	}()
+}
 
-			sort.Strings(files)
-			batchSize := len(files)
-			if batchSize > u.filesBatchSize {
-				batchSize = u.filesBatchSize
+//run goroutine which retries failed payloads every failedRetryEvery until they are stored
+func (u *PeriodicUploader) startFailedRetry() {
+	go func() {
+		for {
+			time.Sleep(u.failedRetryEvery)
+			if appstatus.Instance.Idle {
+				break
 			}
-			for _, filePath := range files[:batchSize] {
-				fileName := filepath.Base(filePath)
-
-				b, err := ioutil.ReadFile(filePath)
-				if err != nil {
-					log.Println("Error reading file", filePath, err)
-					continue
-				}
-				if len(b) == 0 {
-					os.Remove(filePath)
-					continue
-				}
-				//get token from filename
-				regexResult := tokenExtractRegexp.FindStringSubmatch(fileName)
-				if len(regexResult) != 2 {
-					log.Printf("Error processing file %s. Malformed name", filePath)
-					continue
-				}
-
-				token := regexResult[1]
-				eventStorages, ok := u.tokenizedEventStorages[token]
-				if !ok {
-					log.Printf("Destination storages weren't found for token %s", token)
-					continue
-				}
-
-				//flag for deleting file if all storages don't have errors while storing this file
-				deleteFile := true
-				for _, storage := range eventStorages {
-					if !u.statusManager.isUploaded(fileName, storage.Name()) {
-						err := storage.Store(fileName, b)
-						if err != nil {
-							deleteFile = false
-							log.Println("Error store file", filePath, "in", storage.Name(), "destination:", err)
-						}
-						u.statusManager.updateStatus(fileName, storage.Name(), err)
-					}
-				}
-
-				if deleteFile {
-					err := os.Remove(filePath)
-					if err != nil {
-						log.Println("Error deleting file", filePath, err)
-					} else {
-						u.statusManager.cleanUp(fileName)
-					}
-				}
+			if memlimit.Shedding() {
+				continue
 			}
 
-			time.Sleep(u.uploadEvery)
+			u.retryFailed()
+		}
+	}()
+}
+
+//store failed payloads into their destinations. Stored ones are removed, others are kept with the last error
+func (u *PeriodicUploader) retryFailed() {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	failedFiles, err := u.failedStore.list()
+	if err != nil {
+		log.Println("Error listing failed files:", err)
+		return
+	}
+
+	for _, failedFile := range failedFiles {
+		storage := u.storage(failedFile.Token, failedFile.Destination)
+		if storage == nil {
+			log.Printf("Failed payload of file %s isn't retried: destination %s wasn't found for token %s", failedFile.File, failedFile.Destination, failedFile.Token)
+			continue
+		}
+
+		payload, err := u.failedStore.payload(failedFile)
+		if err != nil {
+			log.Printf("Error reading failed payload of file %s for %s destination: %v", failedFile.File, failedFile.Destination, err)
+			continue
+		}
+
+		//the destination is storing files right now: the payload is retried next time
+		bh := u.bulkhead(failedFile.Destination)
+		if !bh.acquire(false) {
+			continue
+		}
+
+		retriedAt := time.Now().UTC()
+		failedFile.Attempts++
+		failedFile.RetriedAt = &retriedAt
+		err = u.store(failedFile.File, failedFile.Token, storage, payload)
+		bh.release()
+		if err != nil {
+			log.Printf("Error retrying failed payload of file %s in %s destination (attempt %d): %v", failedFile.File, failedFile.Destination, failedFile.Attempts, err)
+			failedFile.Error = err.Error()
+			if err := u.failedStore.update(failedFile); err != nil {
+				log.Printf("Error updating failed payload metadata of file %s for %s destination: %v", failedFile.File, failedFile.Destination, err)
+			}
+			continue
+		}
+
+		log.Printf("Failed payload of file %s has been stored in %s destination (attempt %d)", failedFile.File, failedFile.Destination, failedFile.Attempts)
+		if err := u.failedStore.remove(failedFile); err != nil {
+			log.Printf("Error removing failed payload of file %s for %s destination: %v", failedFile.File, failedFile.Destination, err)
+		}
+	}
+}
+
+//return token storage by name or nil
+func (u *PeriodicUploader) storage(token, name string) events.Storage {
+	for _, storage := range u.tokenizedEventStorages.Storages(token) {
+		if storage.Name() == name {
+			return storage
+		}
+	}
+
+	return nil
+}
+
+//Flush upload all already rotated log files right away (e.g. on shutdown or via admin API)
+//return names of files which have been stored in all destinations
+func (u *PeriodicUploader) Flush() []string {
+	return u.upload(0)
+}
+
+//upload files and wait until all destinations have stored them. Files count is limited if limit > 0
+//return names of files which have been stored in all destinations (and deleted)
+func (u *PeriodicUploader) upload(limit int) []string {
+	run := u.dispatch(limit, true)
+	run.wg.Wait()
+	return run.uploaded
+}
+
+//read files by mask and pass payloads to destinations bulkheads. Files count is limited if limit > 0
+//if block is false, destinations which are still storing files of the previous run are skipped (their files are kept)
+//otherwise their previous runs are waited for
+func (u *PeriodicUploader) dispatch(limit int, block bool) *uploadRun {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	run := &uploadRun{}
+	files, err := filepath.Glob(u.fileMask)
+	if err != nil {
+		log.Println("Error finding files by mask", u.fileMask, err)
+		return run
+	}
+
+	sort.Strings(files)
+	batchSize := len(files)
+	if limit > 0 && batchSize > limit {
+		batchSize = limit
+	}
+
+	//destination name: its payloads in files order
+	tasks := map[string][]*uploadTask{}
+	var destinations []string
+	for _, filePath := range files[:batchSize] {
+		fileName := filepath.Base(filePath)
+
+		b, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			log.Println("Error reading file", filePath, err)
+			continue
+		}
+		if len(b) == 0 {
+			os.Remove(filePath)
+			continue
+		}
+		//get token from filename
+		regexResult := tokenExtractRegexp.FindStringSubmatch(fileName)
+		if len(regexResult) != 2 {
+			log.Printf("Error processing file %s. Malformed name", filePath)
+			continue
+		}
+
+		token := regexResult[1]
+		eventStorages := u.tokenizedEventStorages.Storages(token)
+		if len(eventStorages) == 0 {
+			log.Printf("Destination storages weren't found for token %s", token)
+			continue
+		}
+
+		//lines which are routed into every storage (see routing.Router)
+		var storageNames []string
+		for _, storage := range eventStorages {
+			storageNames = append(storageNames, storage.Name())
 		}
+		payloads := routing.Payloads(b, storageNames)
+
+		file := &logFile{path: filePath, name: fileName, token: token, pending: map[string]bool{}}
+		u.filesMutex.Lock()
+		for _, storage := range eventStorages {
+			payload := payloads[storage.Name()]
+			//there is nothing to store if all lines are routed into other storages
+			if u.statusManager.isUploaded(fileName, storage.Name()) || len(bytes.TrimSpace(payload)) == 0 {
+				continue
+			}
+
+			file.pending[storage.Name()] = true
+			if _, ok := tasks[storage.Name()]; !ok {
+				destinations = append(destinations, storage.Name())
+			}
+			tasks[storage.Name()] = append(tasks[storage.Name()], &uploadTask{file: file, storage: storage, payload: payload})
+		}
+		if len(file.pending) == 0 && u.remove(file) {
+			run.add(fileName)
+		}
+		u.filesMutex.Unlock()
+	}
+
+	for _, destination := range destinations {
+		bh := u.bulkhead(destination)
+		if !bh.acquire(block) {
+			log.Printf("Files uploading into %s destination is postponed: the previous upload is still in progress", destination)
+			continue
+		}
+		u.runDestination(run, bh, tasks[destination])
+	}
+
+	return run
+}
+
+//return destination bulkhead (create if it doesn't exist)
+func (u *PeriodicUploader) bulkhead(destinationName string) *bulkhead {
+	u.bulkheadsMutex.Lock()
+	defer u.bulkheadsMutex.Unlock()
+
+	bh, ok := u.bulkheads[destinationName]
+	if !ok {
+		bh = newBulkhead()
+		u.bulkheads[destinationName] = bh
+	}
+
+	return bh
+}
+
+//store destination tasks by its own workers in background and release the bulkhead after all of them are done
+func (u *PeriodicUploader) runDestination(run *uploadRun, bh *bulkhead, tasks []*uploadTask) {
+	workersCount := u.uploadWorkers(tasks[0].storage.Name())
+	if workersCount > len(tasks) {
+		workersCount = len(tasks)
+	}
+
+	queue := make(chan *uploadTask, len(tasks))
+	for _, task := range tasks {
+		queue <- task
+	}
+	close(queue)
+
+	var workers sync.WaitGroup
+	workers.Add(workersCount)
+	run.wg.Add(1)
+	for i := 0; i < workersCount; i++ {
+		go func() {
+			defer workers.Done()
+			for task := range queue {
+				u.storeTask(run, task)
+			}
+		}()
+	}
+
+	go func() {
+		workers.Wait()
+		bh.release()
+		run.wg.Done()
 	}()
 }
+
+//store file payload into the destination (or spill it into failed dir if the destination is transactional)
+//and remove the file if all destinations have stored it
+func (u *PeriodicUploader) storeTask(run *uploadRun, task *uploadTask) {
+	storageName := task.storage.Name()
+
+	//the payload could be stored by the previous run of the destination while the file was being read
+	u.filesMutex.Lock()
+	_, statErr := os.Stat(task.file.path)
+	stored := statErr != nil || u.statusManager.isUploaded(task.file.name, storageName)
+	u.filesMutex.Unlock()
+	if stored {
+		return
+	}
+
+	err := u.store(task.file.name, task.file.token, task.storage, task.payload)
+	if err != nil {
+		log.Println("Error store file", task.file.path, "in", storageName, "destination:", err)
+		//the payload is retried from failed dir so it doesn't keep the whole file
+		if u.failedStore != nil && events.IsTransactional(task.storage) {
+			if spillErr := u.failedStore.spill(task.file.name, storageName, task.file.token, task.payload, err); spillErr != nil {
+				log.Printf("Error writing payload of file %s for %s destination into failed dir: %v", task.file.name, storageName, spillErr)
+			} else {
+				log.Printf("Payload of file %s for %s destination has been written into failed dir and will be retried", task.file.name, storageName)
+				err = nil
+			}
+		}
+	}
+
+	u.filesMutex.Lock()
+	defer u.filesMutex.Unlock()
+
+	u.statusManager.updateStatus(task.file.name, storageName, err)
+	delete(task.file.pending, storageName)
+	//file is kept until all destinations have stored it without errors
+	if err != nil {
+		task.file.failed = true
+	}
+	if len(task.file.pending) == 0 && !task.file.failed && u.remove(task.file) {
+		run.add(task.file.name)
+	}
+}
+
+//remove file and its statuses. Must be called under filesMutex
+func (u *PeriodicUploader) remove(file *logFile) bool {
+	if err := os.Remove(file.path); err != nil {
+		log.Println("Error deleting file", file.path, err)
+		return false
+	}
+
+	u.statusManager.cleanUp(file.name)
+	return true
+}
+
+//store payload into storage and account result with counters, watermarks, webhooks and load report
+func (u *PeriodicUploader) store(fileName, token string, storage events.Storage, payload []byte) error {
+	//every line of log file is an event
+	eventsCount := bytes.Count(bytes.TrimSpace(payload), []byte("\n")) + 1
+	report := reports.NewLoadReport(fileName, storage.Name(), token, eventsCount)
+	started := time.Now()
+	err := storage.Store(fileName, payload, report)
+	report.Finish(err)
+	if err != nil {
+		counters.ErrorEvents(storage.Name(), eventsCount)
+	} else {
+		//skipped rows (if break_on_error is false) are counted as errors
+		counters.SuccessEvents(storage.Name(), report.Loaded)
+		counters.WriteLatency(storage.Name(), time.Since(started), fileName)
+		for table, eventTime := range report.EventTimes {
+			watermarks.Commit(storage.Name(), table, eventTime)
+		}
+		if report.Skipped > 0 {
+			log.Printf("File %s has been stored in %s destination with %d skipped rows of %d: %v", fileName, storage.Name(), report.Skipped, report.Rows, report.Reasons)
+			counters.ErrorEvents(storage.Name(), report.Skipped)
+		}
+		webhooks.Fire(webhooks.FileLoaded, map[string]interface{}{"file": fileName, "destination": storage.Name(), "token": token,
+			"loaded": report.Loaded, "skipped": report.Skipped})
+	}
+	reports.Save(report)
+
+	return err
+}
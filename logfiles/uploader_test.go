@@ -0,0 +1,74 @@
+package logfiles
+
+import (
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+)
+
+//storage which doesn't return from Store until it is unblocked
+type blockingStorageMock struct {
+	storageMock
+	mutex   sync.Mutex
+	started chan string
+	unblock chan struct{}
+}
+
+func (bsm *blockingStorageMock) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	bsm.started <- fileName
+	<-bsm.unblock
+
+	bsm.mutex.Lock()
+	defer bsm.mutex.Unlock()
+	return bsm.storageMock.Store(fileName, payload, report)
+}
+
+func TestUploadBulkheads(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bulkheads")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	slow := &blockingStorageMock{storageMock: storageMock{name: "slow"}, started: make(chan string, 10), unblock: make(chan struct{})}
+	fast := &storageMock{name: "fast"}
+	uploader, err := NewUploader(dir, "test-event-*-20*.log", 10, 60, 0, events.StoragesByToken{"token1": {slow, fast}}, nil)
+	require.NoError(t, err)
+	periodicUploader := uploader.(*PeriodicUploader)
+
+	file1 := "test-event-token1-2020-08-02T18-23-59.757.log"
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, file1), []byte("{\"a\":1}\n"), 0644))
+
+	//fast destination isn't stalled by slow one
+	run1 := periodicUploader.dispatch(10, false)
+	require.Equal(t, file1, <-slow.started)
+	require.Eventually(t, func() bool {
+		periodicUploader.filesMutex.Lock()
+		defer periodicUploader.filesMutex.Unlock()
+		return periodicUploader.statusManager.isUploaded(file1, "fast")
+	}, time.Second, 10*time.Millisecond)
+
+	//slow destination is skipped while its previous upload is in progress
+	file2 := "test-event-token1-2020-08-02T18-24-59.757.log"
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, file2), []byte("{\"a\":2}\n"), 0644))
+	run2 := periodicUploader.dispatch(10, false)
+	run2.wg.Wait()
+	require.Equal(t, []string{file1 + ":{\"a\":1}\n", file2 + ":{\"a\":2}\n"}, fast.stored)
+	require.Empty(t, run2.uploaded, "slow destination hasn't stored files yet")
+
+	//file is removed when all destinations have stored it
+	close(slow.unblock)
+	run1.wg.Wait()
+	require.Equal(t, []string{file1}, run1.uploaded)
+	_, err = os.Stat(path.Join(dir, file1))
+	require.True(t, os.IsNotExist(err))
+
+	require.Equal(t, []string{file2}, periodicUploader.upload(10))
+	require.Equal(t, file2, <-slow.started)
+	require.Equal(t, []string{file1 + ":{\"a\":1}\n", file2 + ":{\"a\":2}\n"}, slow.stored)
+	require.Equal(t, []string{file1 + ":{\"a\":1}\n", file2 + ":{\"a\":2}\n"}, fast.stored, "fast destination doesn't store files twice")
+}
@@ -5,19 +5,37 @@ import (
 	"context"
 	"flag"
 	"github.com/gin-gonic/gin"
+	"github.com/ksensehq/eventnative/admin"
 	"github.com/ksensehq/eventnative/appconfig"
 	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/doctor"
+	"github.com/ksensehq/eventnative/dropcolumn"
+	"github.com/ksensehq/eventnative/eventid"
 	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/forwarding"
 	"github.com/ksensehq/eventnative/handlers"
 	"github.com/ksensehq/eventnative/logfiles"
 	"github.com/ksensehq/eventnative/logging"
+	"github.com/ksensehq/eventnative/memlimit"
 	"github.com/ksensehq/eventnative/middleware"
+	"github.com/ksensehq/eventnative/mirror"
+	"github.com/ksensehq/eventnative/openapi"
+	"github.com/ksensehq/eventnative/performance"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/samples"
+	"github.com/ksensehq/eventnative/stateless"
 	"github.com/ksensehq/eventnative/storages"
+	"github.com/ksensehq/eventnative/udp"
+	"github.com/ksensehq/eventnative/webhooks"
+	"io"
+	"io/ioutil"
 	"log"
 	"math/rand"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
@@ -28,18 +46,40 @@ import (
 //some inner parameters
 const (
 	//$serverName-event-$token-$timestamp.log
-	uploaderFileMask   = "-event-*-20*.log"
-	uploaderBatchSize  = 50
-	uploaderLoadEveryS = 60
+	uploaderFileMask = "-event-*-20*.log"
+	//$serverName-oversized-events.log doesn't match uploaderFileMask
+	oversizedEventsLoggerName = "oversized-events"
+
+	adminStoreFileName     = "admin.json"
+	defaultLastEventsCount = 100
+
+	//per file load reports are kept in $log.path/reports
+	loadReportsDir          = "reports"
+	defaultLoadReportsCount = 1000
+
+	//raw payloads samples per destination table are kept in $log.path/samples
+	tableSamplesDir          = "samples"
+	defaultTableSamplesCount = 10
+
+	c2sEventPath = "/api/v1/event"
+	s2sEventPath = "/api/v1/s2s/event"
 )
 
 var (
 	configFilePath   = flag.String("cfg", "", "config file path")
 	containerizedRun = flag.Bool("cr", false, "containerised run marker")
+
+	//preflight responses of event endpoints are cacheable (see middleware.Cors)
+	eventPaths = []string{c2sEventPath, s2sEventPath}
+
+	//drop-column command flags
+	dropServerURL   = flag.String("server", "", "drop-column: running server URL (default: http://localhost:$server.port)")
+	dropDestination = flag.String("destination", "", "drop-column: destination name")
+	dropTable       = flag.String("table", "", "drop-column: table name")
+	dropColumn      = flag.String("column", "", "drop-column: column name")
 )
 
 func readInViperConfig() error {
-	flag.Parse()
 	viper.AutomaticEnv()
 	//support OS env variables as lower case and dot divided variables e.g. SERVER_PORT as server.port
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
@@ -56,6 +96,47 @@ func readInViperConfig() error {
 	return nil
 }
 
+//run self-diagnostics, print the report and return exit code: 1 if any check is failed
+func runDoctor() int {
+	//the report is the only output
+	log.SetOutput(ioutil.Discard)
+
+	report := doctor.Run(*configFilePath, readInViperConfig())
+	report.Write(os.Stdout)
+	if report.Failed() {
+		return 1
+	}
+
+	return 0
+}
+
+//drop the column via admin API of running server after confirmation and return exit code: 1 if it isn't dropped
+func runDropColumn() int {
+	if err := readInViperConfig(); err != nil {
+		log.Println("Error while reading application config:", err)
+		return 1
+	}
+	appconfig.SetDefaultParams()
+
+	serverURL := *dropServerURL
+	if serverURL == "" {
+		port := viper.GetString("port")
+		if port == "" {
+			port = viper.GetString("server.port")
+		}
+		serverURL = "http://localhost:" + port
+	}
+
+	req := &dropcolumn.Request{ServerURL: serverURL, AdminToken: strings.TrimSpace(viper.GetString("server.admin.token")),
+		Destination: *dropDestination, Table: *dropTable, Column: *dropColumn}
+	if err := dropcolumn.Run(req, os.Stdin, os.Stdout); err != nil {
+		log.Println("Column isn't dropped:", err)
+		return 1
+	}
+
+	return 0
+}
+
 //go:generate easyjson -all useragent/resolver.go
 func main() {
 	// Setup seed for globalRand
@@ -64,20 +145,134 @@ func main() {
 	//Setup default timezone for time.Now() calls
 	time.Local = time.UTC
 
+	flag.Parse()
+	//eventnative doctor -cfg eventnative.yaml or eventnative -cfg eventnative.yaml doctor
+	if flag.Arg(0) == doctor.Command {
+		flag.CommandLine.Parse(flag.Args()[1:])
+		os.Exit(runDoctor())
+	}
+	//eventnative drop-column -cfg eventnative.yaml -destination pg -table events -column old_field
+	if flag.Arg(0) == dropcolumn.Command {
+		flag.CommandLine.Parse(flag.Args()[1:])
+		os.Exit(runDropColumn())
+	}
+
 	if err := readInViperConfig(); err != nil {
 		log.Fatal("Error while reading application config: ", err)
 	}
 
+	//destinations and tokens created via admin API
+	adminStorePath := viper.GetString("server.admin.store_path")
+	if adminStorePath == "" {
+		adminStorePath = filepath.Join(filepath.Dir(*configFilePath), adminStoreFileName)
+	}
+	adminStore, err := admin.NewStore(adminStorePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	applyAdminTokens(adminStore)
+
 	if err := appconfig.Init(); err != nil {
 		log.Fatal(err)
 	}
 
+	//workers, buffers, batches and flush intervals
+	performanceConfig := &performance.Config{}
+	if err := viper.UnmarshalKey("performance", performanceConfig); err != nil {
+		log.Fatal("Error parsing performance config: ", err)
+	}
+	if err := performance.Init(performanceConfig); err != nil {
+		log.Fatal("Error initializing performance config: ", err)
+	}
+
+	//destinations write latency histograms
+	statisticsConfig := &counters.Config{}
+	if err := viper.UnmarshalKey("statistics", statisticsConfig); err != nil {
+		log.Fatal("Error parsing statistics config: ", err)
+	}
+	if err := counters.Init(statisticsConfig); err != nil {
+		log.Fatal("Error initializing statistics config: ", err)
+	}
+
+	//lifecycle events webhooks
+	webhooksConfig := &webhooks.Config{}
+	if err := viper.UnmarshalKey("webhooks", webhooksConfig); err != nil {
+		log.Fatal("Error parsing webhooks config: ", err)
+	}
+	if err := webhooks.Init(webhooksConfig, appconfig.Instance.ServerName); err != nil {
+		log.Fatal("Error initializing webhooks: ", err)
+	}
+	if webhooks.Instance != nil {
+		appconfig.Instance.ScheduleClosing(webhooks.Instance)
+	}
+
+	//requests mirroring (e.g. to canary instance)
+	mirrorConfig := &mirror.Config{}
+	if err := viper.UnmarshalKey("server.mirror", mirrorConfig); err != nil {
+		log.Fatal("Error parsing mirror config: ", err)
+	}
+	if err := mirror.Init(mirrorConfig, appconfig.Instance.ServerName); err != nil {
+		log.Fatal("Error initializing requests mirroring: ", err)
+	}
+	if mirror.Instance != nil {
+		appconfig.Instance.ScheduleClosing(mirror.Instance)
+	}
+
+	//forwarding of stream destinations events to peer nodes if destinations can't be reached from this node
+	forwardingConfig := &forwarding.Config{}
+	if err := viper.UnmarshalKey("server.forwarding", forwardingConfig); err != nil {
+		log.Fatal("Error parsing forwarding config: ", err)
+	}
+	if err := forwarding.Init(forwardingConfig, appconfig.Instance.ServerName); err != nil {
+		log.Fatal("Error initializing events forwarding: ", err)
+	}
+
+	//stream mode queues payloads compression
+	if err := events.SetQueueCompression(viper.GetString("server.queue_compression")); err != nil {
+		log.Fatal("Error initializing queues: ", err)
+	}
+
+	//stateless mode: stream mode events are buffered in external queue shared by all nodes instead of local disk
+	statelessConfig := &stateless.Config{}
+	if err := viper.UnmarshalKey("server.stateless", statelessConfig); err != nil {
+		log.Fatal("Error parsing stateless config: ", err)
+	}
+	if err := stateless.Init(statelessConfig, appconfig.Instance.ServerName); err != nil {
+		log.Fatal("Error initializing stateless mode: ", err)
+	}
+
+	//server side event ids
+	eventIDConfig := &eventid.Config{}
+	if err := viper.UnmarshalKey("server.event_id", eventIDConfig); err != nil {
+		log.Fatal("Error parsing event_id config: ", err)
+	}
+	if err := eventid.Init(eventIDConfig, appconfig.Instance.ServerName); err != nil {
+		log.Fatal("Error initializing event_id generator: ", err)
+	}
+
+	//load shedding on memory pressure
+	memoryLimitConfig := &memlimit.Config{}
+	if err := viper.UnmarshalKey("server.memory_limit", memoryLimitConfig); err != nil {
+		log.Fatal("Error parsing memory limit config: ", err)
+	}
+	if err := memlimit.Init(memoryLimitConfig); err != nil {
+		log.Fatal("Error initializing memory limit: ", err)
+	}
+	if memlimit.Instance != nil {
+		appconfig.Instance.ScheduleClosing(memlimit.Instance)
+	}
+
 	//listen to shutdown signal to free up all resources
 	ctx, cancel := context.WithCancel(context.Background())
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGTERM, syscall.SIGINT, syscall.SIGKILL, syscall.SIGHUP)
 	go func() {
 		<-c
+		//upload partially accumulated event log files while destinations aren't closed
+		if viper.GetBool("log.flush_on_shutdown") {
+			log.Println("Flushing event log files before shutdown")
+			logfiles.Flush()
+		}
 		appstatus.Instance.Idle = true
 		cancel()
 		appconfig.Instance.Close()
@@ -99,12 +294,25 @@ func main() {
 		}
 	}
 
+	configDestinations := map[string]bool{}
+	if destinationsViper != nil {
+		for name := range destinationsViper.AllSettings() {
+			configDestinations[name] = true
+		}
+	}
+	destinationsViper = applyAdminDestinations(adminStore, destinationsViper, configDestinations)
+
 	//Get event logger path
 	logEventPath := viper.GetString("log.path")
 
-	//logger consumers per token
+	//logger consumers per token (there aren't batch destinations in stateless mode)
 	loggingConsumers := map[string]events.Consumer{}
+	//event loggers are rotated on flush
+	var loggers []logfiles.Rotator
 	for token := range appconfig.Instance.AuthorizedTokens {
+		if stateless.Enabled() {
+			break
+		}
 		eventLogWriter, err := logging.NewWriter(logging.Config{
 			LoggerName:  "event-" + token,
 			ServerName:  appconfig.Instance.ServerName,
@@ -113,57 +321,106 @@ func main() {
 		if err != nil {
 			log.Fatal(err)
 		}
-		logger := events.NewAsyncLogger(eventLogWriter, viper.GetBool("log.show_in_server"))
+		logger := events.NewAsyncLogger(eventLogWriter, viper.GetBool("log.show_in_server"), performance.Instance.LogBufferSize)
 		loggingConsumers[token] = logger
+		loggers = append(loggers, logger)
 		appconfig.Instance.ScheduleClosing(logger)
 	}
 
+	//raw payloads samples of destinations tables
+	viper.SetDefault("log.table_samples", defaultTableSamplesCount)
+	if stateless.Enabled() {
+		if viper.IsSet("log.table_samples") && viper.GetInt("log.table_samples") > 0 {
+			log.Fatal(stateless.Unsupported("log.table_samples"))
+		}
+		viper.Set("log.table_samples", 0)
+	}
+	if err := samples.Init(filepath.Join(logEventPath, tableSamplesDir), viper.GetInt("log.table_samples")); err != nil {
+		log.Fatal("Error initializing table samples: ", err)
+	}
+	if samples.Instance != nil {
+		appconfig.Instance.ScheduleClosing(samples.Instance)
+	}
+
 	//Create event destinations:
 	//- batch mode (events.Storage)
 	//- stream mode (events.Consumer)
-	//per token
-	batchStoragesByToken, streamingConsumersByToken := storages.Create(ctx, destinationsViper, logEventPath)
+	//per token. Logger consumers are merged with storage consumers only if the token has batch storages
+	//(because we don't need to write log files for streaming storages)
+	destinations := storages.Create(ctx, destinationsViper, logEventPath, loggingConsumers)
+	appconfig.Instance.ScheduleClosing(destinations)
+	//external queue connections are closed after destinations queues
+	if stateless.Instance != nil {
+		appconfig.Instance.ScheduleClosing(stateless.Instance)
+	}
 
-	//Schedule storages resource releasing
-	for _, eStorages := range batchStoragesByToken {
-		for _, es := range eStorages {
-			appconfig.Instance.ScheduleClosing(es)
-		}
+	//per file load reports of batch destinations
+	viper.SetDefault("log.load_reports", defaultLoadReportsCount)
+	//reports are written only by batch destinations
+	if stateless.Enabled() {
+		viper.Set("log.load_reports", 0)
 	}
-	//Schedule consumers resource releasing
-	for _, eConsumers := range streamingConsumersByToken {
-		for _, ec := range eConsumers {
-			appconfig.Instance.ScheduleClosing(ec)
-		}
+	if err := reports.Init(filepath.Join(logEventPath, loadReportsDir), viper.GetInt("log.load_reports")); err != nil {
+		log.Fatal("Error initializing load reports: ", err)
 	}
 
-	//merge logger consumers with storage consumers: Skip loggers which don't have batches storages (because we don't need to write log files for streaming storages)
-	for token, loggingConsumer := range loggingConsumers {
-		if _, ok := batchStoragesByToken[token]; !ok {
-			continue
-		}
-
-		consumers, ok := streamingConsumersByToken[token]
-		if !ok {
-			consumers = []events.Consumer{}
-		}
-		consumers = append(consumers, loggingConsumer)
-		streamingConsumersByToken[token] = consumers
+	//Uploader must read event logger directory (batch destinations can be added at runtime except stateless mode)
+	var batchStorages events.TokenizedStorages = destinations
+	if stateless.Enabled() {
+		batchStorages = nil
 	}
-
-	//Uploader must read event logger directory
-	uploader, err := logfiles.NewUploader(logEventPath, appconfig.Instance.ServerName+uploaderFileMask, uploaderBatchSize, uploaderLoadEveryS, batchStoragesByToken)
+	uploader, err := logfiles.NewUploader(logEventPath, appconfig.Instance.ServerName+uploaderFileMask, performance.Instance.UploaderBatchSize, int(performance.Instance.UploaderEvery.Seconds()),
+		viper.GetDuration("log.failed_retry_every"), batchStorages, storages.UploadWorkers)
 	if err != nil {
 		log.Fatal("Error while creating file uploader", err)
 	}
 	uploader.Start()
+	logfiles.Init(loggers, uploader)
+
+	//admin API
+	var eventsCache *events.Cache
+	var adminHandler *handlers.AdminHandler
+	if appconfig.Instance.AdminToken != "" {
+		viper.SetDefault("server.admin.last_events", defaultLastEventsCount)
+		eventsCache = events.NewCache(viper.GetInt("server.admin.last_events"))
+		adminHandler = handlers.NewAdminHandler(adminStore, eventsCache, configDestinations, destinations)
+	}
+
+	//UDP intake of edge devices: events are checked as HTTP API ones and are buffered in the same queues as stream mode ones
+	checks := createEventChecks()
+	udpConfig := &udp.Config{}
+	if err := viper.UnmarshalKey("server.udp", udpConfig); err != nil {
+		log.Fatal("Error parsing udp config: ", err)
+	}
+	udpIntake := events.NewIntake(udp.NewPreprocessor(), nil, checks.sizePolicy, checks.clockSkew, checks.schemas)
+	if err := udp.Init(udpConfig, appconfig.Instance.AuthorizedTokens, destinations, udpIntake, eventsCache, func(name string) (*events.PersistentQueue, error) {
+		if stateless.Enabled() {
+			return stateless.Instance.NewQueue(name)
+		}
+		return events.NewPersistentQueue(appconfig.Instance.ServerName+"-"+name, logEventPath)
+	}); err != nil {
+		log.Fatal("Error initializing udp listeners: ", err)
+	}
+	if udp.Instance != nil {
+		appconfig.Instance.ScheduleClosing(udp.Instance)
+	}
 
-	router := SetupRouter(streamingConsumersByToken)
+	router := SetupRouter(destinations, eventsCache, adminHandler, checks)
+
+	//preflight responses caching
+	corsConfig := &middleware.CorsConfig{}
+	if err := viper.UnmarshalKey("server.cors", corsConfig); err != nil {
+		log.Fatal("Error parsing cors config: ", err)
+	}
+	handler, err := middleware.Cors(router, corsConfig, eventPaths)
+	if err != nil {
+		log.Fatal("Error initializing cors: ", err)
+	}
 
 	log.Println("Started server: " + appconfig.Instance.Authority)
 	server := &http.Server{
 		Addr:              appconfig.Instance.Authority,
-		Handler:           middleware.Cors(router),
+		Handler:           handler,
 		ReadTimeout:       time.Second * 60,
 		ReadHeaderTimeout: time.Second * 60,
 		IdleTimeout:       time.Second * 65,
@@ -171,7 +428,151 @@ func main() {
 	log.Fatal(server.ListenAndServe())
 }
 
-func SetupRouter(tokenizedEventConsumers map[string][]events.Consumer) *gin.Engine {
+//add tokens from admin store to config ones
+func applyAdminTokens(store *admin.Store) {
+	for _, tc := range store.Tokens() {
+		key := "server.auth"
+		if tc.Type == admin.S2SToken {
+			key = "server.s2s_auth"
+		}
+		viper.Set(key, append(viper.GetStringSlice(key), tc.Token))
+	}
+}
+
+//add destinations from admin store to config ones (destinations from config file have priority)
+func applyAdminDestinations(store *admin.Store, destinationsViper *viper.Viper, configDestinations map[string]bool) *viper.Viper {
+	for name, config := range store.Destinations() {
+		if configDestinations[name] {
+			log.Printf("Destination [%s] from admin store is skipped: destination with the same name is defined in config", name)
+			continue
+		}
+
+		if destinationsViper == nil {
+			destinationsViper = viper.New()
+		}
+		destinationsViper.Set(name, config)
+	}
+
+	return destinationsViper
+}
+
+//return events.SizePolicy from server.large_events config or nil if it isn't configured
+//oversized events file is written next to events logs by default
+func createSizePolicy() (*events.SizePolicy, error) {
+	config := &events.SizePolicyConfig{}
+	if err := viper.UnmarshalKey("server.large_events", config); err != nil {
+		return nil, err
+	}
+	if config.MaxSize == 0 {
+		return nil, nil
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	var writer io.WriteCloser
+	if config.Action == events.FileAction {
+		if err := stateless.Unsupported("large_events.action: file"); err != nil {
+			return nil, err
+		}
+		fileDir := config.FileDir
+		if fileDir == "" {
+			fileDir = viper.GetString("log.path")
+		}
+		var err error
+		writer, err = logging.NewWriter(logging.Config{
+			LoggerName:  oversizedEventsLoggerName,
+			ServerName:  appconfig.Instance.ServerName,
+			FileDir:     fileDir,
+			RotationMin: viper.GetInt64("log.rotation_min")})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sizePolicy, err := events.NewSizePolicy(config, writer)
+	if err != nil {
+		return nil, err
+	}
+	appconfig.Instance.ScheduleClosing(sizePolicy)
+
+	return sizePolicy, nil
+}
+
+//return handlers.UserEventsHandler from server.users_api config or nil if it isn't configured
+func createUserEventsHandler() (*handlers.UserEventsHandler, error) {
+	if !viper.IsSet("server.users_api") {
+		return nil, nil
+	}
+
+	config := &handlers.UserEventsConfig{}
+	if err := viper.UnmarshalKey("server.users_api", config); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return handlers.NewUserEventsHandler(config), nil
+}
+
+//return handlers.MetricsHandler from server.metrics_api config or nil if it isn't configured
+func createMetricsHandler() (*handlers.MetricsHandler, error) {
+	if !viper.IsSet("server.metrics_api") {
+		return nil, nil
+	}
+
+	config := &handlers.MetricsConfig{}
+	if err := viper.UnmarshalKey("server.metrics_api", config); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return handlers.NewMetricsHandler(config), nil
+}
+
+//eventChecks are incoming events checks which are shared by HTTP API and UDP listeners (see events.Intake)
+type eventChecks struct {
+	sizePolicy *events.SizePolicy
+	clockSkew  *events.ClockSkew
+	schemas    *events.TokenSchemas
+}
+
+//return eventChecks from server.large_events, server.clock_skew and server.json_schemas configs
+func createEventChecks() *eventChecks {
+	//large events handling
+	sizePolicy, err := createSizePolicy()
+	if err != nil {
+		log.Fatal("Error creating large events policy: ", err)
+	}
+
+	//client clock skew correction
+	clockSkewConfig := &events.ClockSkewConfig{}
+	if err := viper.UnmarshalKey("server.clock_skew", clockSkewConfig); err != nil {
+		log.Fatal("Error parsing clock_skew config: ", err)
+	}
+	clockSkew, err := events.NewClockSkew(clockSkewConfig)
+	if err != nil {
+		log.Fatal("Error creating clock skew correction: ", err)
+	}
+
+	//incoming events validation
+	var schemaConfigs []*events.JSONSchemaConfig
+	if err := viper.UnmarshalKey("server.json_schemas", &schemaConfigs); err != nil {
+		log.Fatal("Error parsing json_schemas config: ", err)
+	}
+	tokenSchemas, err := events.NewTokenSchemas(schemaConfigs)
+	if err != nil {
+		log.Fatal("Error loading JSON schemas: ", err)
+	}
+
+	return &eventChecks{sizePolicy: sizePolicy, clockSkew: clockSkew, schemas: tokenSchemas}
+}
+
+//eventsCache and adminHandler are optional (nil if admin API is disabled)
+func SetupRouter(tokenizedEventConsumers events.TokenizedConsumers, eventsCache *events.Cache, adminHandler *handlers.AdminHandler, checks *eventChecks) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 
 	router := gin.New() //gin.Default()
@@ -190,12 +591,58 @@ func SetupRouter(tokenizedEventConsumers map[string][]events.Consumer) *gin.Engi
 	router.GET("/s/:filename", staticHandler.Handler)
 	router.GET("/t/:filename", staticHandler.Handler)
 
-	c2sEventHandler := handlers.NewEventHandler(tokenizedEventConsumers, events.NewC2SPreprocessor()).Handler
-	s2sEventHandler := handlers.NewEventHandler(tokenizedEventConsumers, events.NewS2SPreprocessor()).Handler
-	apiV1 := router.Group("/api/v1")
-	{
-		apiV1.POST("/event", middleware.TokenAuth(middleware.AccessControl(c2sEventHandler, appconfig.Instance.C2STokens, "")))
-		apiV1.POST("/s2s/event", middleware.TokenAuth(middleware.AccessControl(s2sEventHandler, appconfig.Instance.S2STokens, "The token isn't a server token. Please use s2s integration token\n")))
+	//request headers which will be captured into events
+	capturedHeaders := events.DefaultCapturedHeaders
+	if viper.IsSet("headers.default") {
+		capturedHeaders = viper.GetStringSlice("headers.default")
+	}
+	headersCapture := events.NewHeadersCapture(capturedHeaders, viper.GetStringMapStringSlice("headers.tokens"))
+
+	c2sIntake := events.NewIntake(events.NewC2SPreprocessor(), headersCapture, checks.sizePolicy, checks.clockSkew, checks.schemas)
+	s2sIntake := events.NewIntake(events.NewS2SPreprocessor(), headersCapture, checks.sizePolicy, checks.clockSkew, checks.schemas)
+	c2sEventHandler := handlers.NewEventHandler(tokenizedEventConsumers, c2sIntake, eventsCache, false).Handler
+	s2sEventHandler := handlers.NewEventHandler(tokenizedEventConsumers, s2sIntake, eventsCache, true).Handler
+	eventsSecurity := []string{handlers.APITokenSecurity}
+	routes := []handlers.Route{
+		{
+			Operation: openapi.Operation{Method: http.MethodPost, Path: c2sEventPath, Summary: "Send client side (browser) event", Tags: []string{"events"}, Security: eventsSecurity, Request: events.Fact{}},
+			Handler:   memlimit.Wrap(mirror.Wrap(middleware.TokenAuth(middleware.AccessControl(c2sEventHandler, appconfig.Instance.C2STokens, "")))),
+		},
+		{
+			Operation: openapi.Operation{Method: http.MethodPost, Path: s2sEventPath, Summary: "Send server to server event", Tags: []string{"events"}, Security: eventsSecurity, QueryParams: []openapi.Parameter{{Name: "destinations", Description: "true: response with destinations which the event has been routed into"}}, Request: events.Fact{}, Response: handlers.EventResponse{}},
+			Handler:   memlimit.Wrap(mirror.Wrap(middleware.TokenAuth(middleware.AccessControl(s2sEventHandler, appconfig.Instance.S2STokens, "The token isn't a server token. Please use s2s integration token\n")))),
+		},
+	}
+	if adminHandler != nil {
+		routes = append(routes, adminHandler.Routes()...)
+	}
+
+	//users last events read API
+	userEventsHandler, err := createUserEventsHandler()
+	if err != nil {
+		log.Fatal("Error creating users API: ", err)
+	}
+	if userEventsHandler != nil {
+		routes = append(routes, userEventsHandler.Routes()...)
+	}
+
+	//predefined aggregate queries API
+	metricsHandler, err := createMetricsHandler()
+	if err != nil {
+		log.Fatal("Error creating metrics API: ", err)
+	}
+	if metricsHandler != nil {
+		routes = append(routes, metricsHandler.Routes()...)
+	}
+
+	for _, route := range routes {
+		router.Handle(route.Method, route.Path, route.Handler)
+	}
+	router.GET(handlers.SpecPath, handlers.NewSpecHandler(routes).Handler)
+
+	//internal endpoint which accepts events forwarded by peer nodes (it isn't a part of API spec)
+	if forwarding.Instance != nil {
+		router.POST(forwarding.Path, forwarding.Instance.Handler(storages.EnqueueForwarded))
 	}
 
 	return router
@@ -2,9 +2,11 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"github.com/ksensehq/eventnative/events"
 	"github.com/ksensehq/eventnative/logging"
 	"github.com/ksensehq/eventnative/middleware"
+	"github.com/ksensehq/eventnative/performance"
 	"github.com/ksensehq/eventnative/test"
 	"time"
 
@@ -17,6 +19,7 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"testing"
 )
@@ -97,18 +100,21 @@ func TestApiEvent(t *testing.T) {
 			defer appconfig.Instance.Close()
 
 			inmemWriter := logging.InitInMemoryWriter()
-			router := SetupRouter(map[string][]events.Consumer{
-				"c2stoken": {events.NewAsyncLogger(inmemWriter, false)},
-				"s2stoken": {events.NewAsyncLogger(inmemWriter, false)},
-			})
+			router := SetupRouter(events.ConsumersByToken{
+				"c2stoken": {events.NewAsyncLogger(inmemWriter, false, performance.Instance.LogBufferSize)},
+				"s2stoken": {events.NewAsyncLogger(inmemWriter, false, performance.Instance.LogBufferSize)},
+			}, nil, nil, createEventChecks())
 
 			freezeTime := time.Date(2020, 06, 16, 23, 0, 0, 0, time.UTC)
 			patch := monkey.Patch(time.Now, func() time.Time { return freezeTime })
 			defer patch.Unpatch()
 
+			handler, err := middleware.Cors(router, nil, eventPaths)
+			require.NoError(t, err)
+
 			server := &http.Server{
 				Addr:              httpAuthority,
-				Handler:           middleware.Cors(router),
+				Handler:           handler,
 				ReadTimeout:       time.Second * 60,
 				ReadHeaderTimeout: time.Second * 60,
 				IdleTimeout:       time.Second * 65,
@@ -132,6 +138,8 @@ func TestApiEvent(t *testing.T) {
 			optResp, err := http.DefaultClient.Do(optReq)
 			require.NoError(t, err)
 			require.Equal(t, 200, optResp.StatusCode)
+			require.Equal(t, "86400", optResp.Header.Get("Access-Control-Max-Age"))
+			require.Equal(t, "public, max-age=86400", optResp.Header.Get("Cache-Control"), "event endpoints preflights are cacheable")
 
 			//check http POST
 			apiReq, err := http.NewRequest("POST", "http://"+httpAuthority+tt.reqUrn, bytes.NewBuffer(b))
@@ -165,6 +173,28 @@ func TestApiEvent(t *testing.T) {
 	}
 }
 
+func TestApiSpec(t *testing.T) {
+	SetTestDefaultParams()
+	err := appconfig.Init()
+	require.NoError(t, err)
+	defer appconfig.Instance.Close()
+
+	router := SetupRouter(events.ConsumersByToken{}, nil, nil, createEventChecks())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/spec", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	spec := &struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), spec))
+	require.Equal(t, "3.0.3", spec.OpenAPI)
+	require.Contains(t, spec.Paths["/api/v1/event"], "post")
+	require.Contains(t, spec.Paths["/api/v1/s2s/event"], "post")
+}
+
 func getLocalAuthority() (string, error) {
 	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
 	if err != nil {
@@ -0,0 +1,211 @@
+package memlimit
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/ksensehq/eventnative/counters"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultShedThreshold   = 0.9
+	defaultResumeThreshold = 0.8
+	defaultCheckEvery      = time.Second
+	retryAfterSeconds      = "5"
+
+	statmPath = "/proc/self/statm"
+)
+
+//Instance is nil if memory limit isn't configured
+var Instance *Limiter
+
+//Config dto for deserialized memory limit config
+//limit_mb: process memory limit (e.g. container memory limit)
+//shed_threshold: part of limit (0, 1] when load shedding is turned on (default: 0.9)
+//resume_threshold: part of limit when load shedding is turned off (default: 0.8). Must be <= shed_threshold
+//check_every: memory usage check interval (default: 1s)
+type Config struct {
+	LimitMB         uint64        `mapstructure:"limit_mb"`
+	ShedThreshold   float64       `mapstructure:"shed_threshold"`
+	ResumeThreshold float64       `mapstructure:"resume_threshold"`
+	CheckEvery      time.Duration `mapstructure:"check_every"`
+}
+
+//Validate required fields in Config and set default values
+func (c *Config) Validate() error {
+	if c.LimitMB == 0 {
+		return errors.New("memory limit_mb is required parameter")
+	}
+	if c.ShedThreshold < 0 || c.ShedThreshold > 1 || c.ResumeThreshold < 0 || c.ResumeThreshold > 1 {
+		return errors.New("memory limit thresholds must be in (0, 1] range")
+	}
+	if c.ShedThreshold == 0 {
+		c.ShedThreshold = defaultShedThreshold
+	}
+	if c.ResumeThreshold == 0 {
+		c.ResumeThreshold = defaultResumeThreshold
+	}
+	if c.ResumeThreshold > c.ShedThreshold {
+		return errors.New("memory limit resume_threshold must be less than or equal to shed_threshold")
+	}
+	if c.CheckEvery <= 0 {
+		c.CheckEvery = defaultCheckEvery
+	}
+
+	return nil
+}
+
+//Limiter periodically compares process memory usage with the limit and turns load shedding on and off:
+//ingestion requests are rejected with 503 and batch processing is paused until memory usage goes down
+type Limiter struct {
+	shedAt     uint64
+	resumeAt   uint64
+	checkEvery time.Duration
+	usage      func() uint64
+
+	shedding  int32
+	closed    chan bool
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+//Init validate config and create global Limiter instance with started checking goroutine
+func Init(config *Config) error {
+	if config == nil || config.LimitMB == 0 {
+		return nil
+	}
+
+	limiter, err := NewLimiter(config)
+	if err != nil {
+		return err
+	}
+
+	Instance = limiter
+	return nil
+}
+
+//NewLimiter return Limiter which checks process memory usage and start checking goroutine
+func NewLimiter(config *Config) (*Limiter, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	l := newLimiter(config, processMemory)
+	l.start()
+
+	return l, nil
+}
+
+func newLimiter(config *Config, usage func() uint64) *Limiter {
+	limit := config.LimitMB * 1024 * 1024
+	return &Limiter{
+		shedAt:     uint64(float64(limit) * config.ShedThreshold),
+		resumeAt:   uint64(float64(limit) * config.ResumeThreshold),
+		checkEvery: config.CheckEvery,
+		usage:      usage,
+		closed:     make(chan bool),
+	}
+}
+
+//Shedding return true if global Limiter is configured and load shedding is turned on
+func Shedding() bool {
+	if Instance == nil {
+		return false
+	}
+
+	return Instance.Shedding()
+}
+
+//Wrap return handler which rejects requests via global Limiter (if it is configured) or calls main
+func Wrap(main gin.HandlerFunc) gin.HandlerFunc {
+	if Instance == nil {
+		return main
+	}
+
+	return Instance.Wrap(main)
+}
+
+//Shedding return true if memory usage is over shed threshold (and hasn't gone down resume threshold yet)
+func (l *Limiter) Shedding() bool {
+	return atomic.LoadInt32(&l.shedding) == 1
+}
+
+//Wrap return handler which responds 503 with Retry-After header while load shedding is turned on or calls main
+func (l *Limiter) Wrap(main gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l.Shedding() {
+			counters.ShedRequests(1)
+			c.Header("Retry-After", retryAfterSeconds)
+			c.AbortWithStatus(http.StatusServiceUnavailable)
+			return
+		}
+
+		main(c)
+	}
+}
+
+//Close stop checking goroutine
+func (l *Limiter) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+	})
+	l.wg.Wait()
+
+	return nil
+}
+
+func (l *Limiter) start() {
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		ticker := time.NewTicker(l.checkEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				l.check()
+			case <-l.closed:
+				return
+			}
+		}
+	}()
+}
+
+//check memory usage and turn load shedding on or off
+func (l *Limiter) check() {
+	usage := l.usage()
+	if !l.Shedding() && usage >= l.shedAt {
+		atomic.StoreInt32(&l.shedding, 1)
+		counters.ShedActivation()
+		log.Printf("Memory usage %d MB is over shedding threshold %d MB. Incoming events are rejected and batch processing is paused", usage/1024/1024, l.shedAt/1024/1024)
+	} else if l.Shedding() && usage < l.resumeAt {
+		atomic.StoreInt32(&l.shedding, 0)
+		log.Printf("Memory usage %d MB is under resume threshold %d MB. Load shedding is turned off", usage/1024/1024, l.resumeAt/1024/1024)
+	}
+}
+
+//processMemory return process RSS (from /proc) or memory obtained from OS by Go runtime if RSS isn't available
+func processMemory() uint64 {
+	if statm, err := ioutil.ReadFile(statmPath); err == nil {
+		//size resident shared ... (in pages)
+		fields := strings.Fields(string(statm))
+		if len(fields) > 1 {
+			if residentPages, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+				return residentPages * uint64(os.Getpagesize())
+			}
+		}
+	}
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	return memStats.Sys
+}
@@ -0,0 +1,70 @@
+package memlimit
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	var usage uint64
+	l := newLimiter(&Config{LimitMB: 100, ShedThreshold: 0.9, ResumeThreshold: 0.8}, func() uint64 { return usage })
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.POST("/api/v1/event", l.Wrap(func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	}))
+	send := func() *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/event", nil))
+		return recorder
+	}
+	initial := counters.GetSnapshot().Shed
+
+	usage = 85 * 1024 * 1024
+	l.check()
+	require.False(t, l.Shedding())
+	require.Equal(t, http.StatusOK, send().Code)
+
+	usage = 95 * 1024 * 1024
+	l.check()
+	require.True(t, l.Shedding())
+	response := send()
+	require.Equal(t, http.StatusServiceUnavailable, response.Code)
+	require.Equal(t, retryAfterSeconds, response.Header().Get("Retry-After"))
+
+	//between thresholds: still shedding
+	usage = 85 * 1024 * 1024
+	l.check()
+	require.True(t, l.Shedding())
+
+	usage = 70 * 1024 * 1024
+	l.check()
+	require.False(t, l.Shedding())
+	require.Equal(t, http.StatusOK, send().Code)
+
+	shed := counters.GetSnapshot().Shed
+	require.Equal(t, uint64(1), shed.Activations-initial.Activations)
+	require.Equal(t, uint64(1), shed.Requests-initial.Requests)
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.EqualError(t, (&Config{}).Validate(), "memory limit_mb is required parameter")
+	require.EqualError(t, (&Config{LimitMB: 100, ShedThreshold: 1.5}).Validate(), "memory limit thresholds must be in (0, 1] range")
+	require.EqualError(t, (&Config{LimitMB: 100, ShedThreshold: 0.7}).Validate(), "memory limit resume_threshold must be less than or equal to shed_threshold")
+
+	config := &Config{LimitMB: 100}
+	require.NoError(t, config.Validate())
+	require.Equal(t, defaultShedThreshold, config.ShedThreshold)
+	require.Equal(t, defaultResumeThreshold, config.ResumeThreshold)
+	require.Equal(t, time.Second, config.CheckEvery)
+}
+
+func TestProcessMemory(t *testing.T) {
+	require.True(t, processMemory() > 0)
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"github.com/ksensehq/eventnative/appconfig"
+	"net/http"
+	"strings"
+)
+
+const AdminTokenHeader = "X-Admin-Token"
+
+//AdminAuth check that provided admin token (X-Admin-Token header or Authorization: Bearer header) equals configured one
+//all requests are rejected if admin token isn't configured
+func AdminAuth(main gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader(AdminTokenHeader)
+		if token == "" {
+			token = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+
+		adminToken := appconfig.Instance.AdminToken
+		if adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		main(c)
+	}
+}
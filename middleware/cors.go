@@ -1,21 +1,83 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 )
 
-func Cors(h http.Handler) http.Handler {
+const defaultCorsMaxAge = 24 * time.Hour
+
+//CorsConfig dto for deserialized server.cors config
+//max_age: browsers cache preflight (OPTIONS) responses for it and don't send a preflight before every request
+//(browsers limit it: e.g. Chromium - 2h, Firefox - 24h). Default: 24h
+type CorsConfig struct {
+	MaxAge time.Duration `mapstructure:"max_age"`
+}
+
+//Validate CorsConfig values and set default ones
+func (cc *CorsConfig) Validate() error {
+	if cc.MaxAge < 0 {
+		return errors.New("server.cors max_age can't be negative")
+	}
+	if cc.MaxAge == 0 {
+		cc.MaxAge = defaultCorsMaxAge
+	}
+
+	return nil
+}
+
+//Cors add CORS headers to all responses and respond to preflight requests right away
+//preflight responses are written from prebuilt headers. Ones of event endpoints (eventPaths) are cacheable by intermediate
+//proxies and CDNs as well, so tracking calls from browsers don't cost a preflight each
+//return err if config is invalid (caller's config isn't changed: defaults are set to its copy)
+func Cors(h http.Handler, config *CorsConfig, eventPaths []string) (http.Handler, error) {
+	corsConfig := CorsConfig{}
+	if config != nil {
+		corsConfig = *config
+	}
+	if err := corsConfig.Validate(); err != nil {
+		return nil, err
+	}
+	maxAge := strconv.Itoa(int(corsConfig.MaxAge.Seconds()))
+
+	headers := http.Header{
+		"Access-Control-Allow-Origin":      {"*"},
+		"Access-Control-Allow-Methods":     {"POST, GET, OPTIONS, PUT, DELETE, UPDATE"},
+		"Access-Control-Allow-Headers":     {"Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Host"},
+		"Access-Control-Allow-Credentials": {"true"},
+	}
+	preflightHeaders := http.Header{"Access-Control-Max-Age": {maxAge}}
+	eventPreflightHeaders := http.Header{
+		"Access-Control-Max-Age": {maxAge},
+		"Cache-Control":          {"public, max-age=" + maxAge},
+		"Vary":                   {"Origin, Access-Control-Request-Method, Access-Control-Request-Headers"},
+	}
+	events := map[string]bool{}
+	for _, path := range eventPaths {
+		events[path] = true
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Add("Access-Control-Allow-Origin", "*")
-		w.Header().Add("Access-Control-Max-Age", "86400")
-		w.Header().Add("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, UPDATE")
-		w.Header().Add("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Host")
-		w.Header().Add("Access-Control-Allow-Credentials", "true")
+		copyHeaders(w.Header(), headers)
 
-		if r.Method == "OPTIONS" {
+		if r.Method == http.MethodOptions {
+			if events[r.URL.Path] {
+				copyHeaders(w.Header(), eventPreflightHeaders)
+			} else {
+				copyHeaders(w.Header(), preflightHeaders)
+			}
 			w.WriteHeader(http.StatusOK)
 			return
 		}
 		h.ServeHTTP(w, r)
-	})
+	}), nil
+}
+
+//prebuilt header values are shared between responses: they aren't changed after writing
+func copyHeaders(dst, src http.Header) {
+	for name, values := range src {
+		dst[name] = values
+	}
 }
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCors(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	config := &CorsConfig{MaxAge: 2 * time.Hour}
+	handler, err := Cors(next, config, []string{"/api/v1/event"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name                 string
+		method               string
+		url                  string
+		expectedCode         int
+		expectedMaxAge       string
+		expectedCacheControl string
+	}{
+		{"event preflight", http.MethodOptions, "/api/v1/event?token=c2stoken", http.StatusOK, "7200", "public, max-age=7200"},
+		{"other preflight", http.MethodOptions, "/api/v1/destinations", http.StatusOK, "7200", ""},
+		{"event request", http.MethodPost, "/api/v1/event?token=c2stoken", http.StatusAccepted, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+
+			require.Equal(t, tt.expectedCode, w.Code)
+			require.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+			require.Equal(t, tt.expectedMaxAge, w.Header().Get("Access-Control-Max-Age"))
+			require.Equal(t, tt.expectedCacheControl, w.Header().Get("Cache-Control"))
+		})
+	}
+
+	require.EqualError(t, (&CorsConfig{MaxAge: -time.Second}).Validate(), "server.cors max_age can't be negative")
+	config = &CorsConfig{}
+	require.NoError(t, config.Validate())
+	require.Equal(t, 24*time.Hour, config.MaxAge)
+
+	//caller's config isn't changed
+	config = &CorsConfig{}
+	_, err = Cors(next, config, nil)
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), config.MaxAge)
+
+	_, err = Cors(next, &CorsConfig{MaxAge: -time.Second}, nil)
+	require.EqualError(t, err, "server.cors max_age can't be negative")
+}
@@ -0,0 +1,235 @@
+package mirror
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+//MirrorHeader is set on mirrored requests with the server name. Requests with this header aren't mirrored again
+const MirrorHeader = "X-EventNative-Mirrored-By"
+
+const (
+	defaultPercent   = 100
+	defaultTimeout   = 10 * time.Second
+	defaultQueueSize = 1000
+	defaultWorkers   = 4
+)
+
+//Instance is nil if mirroring isn't configured
+var Instance *Mirror
+
+//Config dto for deserialized requests mirroring config
+//percent: percentage of requests which are mirrored (0 < percent <= 100)
+//queue_size: requests which are waiting for sending. New requests aren't mirrored if queue is full
+type Config struct {
+	URL       string        `mapstructure:"url"`
+	Percent   float64       `mapstructure:"percent"`
+	Timeout   time.Duration `mapstructure:"timeout"`
+	QueueSize int           `mapstructure:"queue_size"`
+	Workers   int           `mapstructure:"workers"`
+}
+
+//Validate required fields in Config and set default values
+func (c *Config) Validate() error {
+	if c.URL == "" {
+		return errors.New("mirror url is required parameter")
+	}
+	if _, err := url.ParseRequestURI(c.URL); err != nil {
+		return fmt.Errorf("mirror url is invalid: %v", err)
+	}
+	if c.Percent < 0 || c.Percent > 100 {
+		return errors.New("mirror percent must be in (0, 100] range")
+	}
+	if c.Percent == 0 {
+		c.Percent = defaultPercent
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = defaultQueueSize
+	}
+	if c.Workers <= 0 {
+		c.Workers = defaultWorkers
+	}
+
+	return nil
+}
+
+//Mirror sends copies of a percentage of incoming requests to another EventNative instance asynchronously.
+//Mirrored requests don't affect original ones: responses are ignored and requests are dropped if queue is full
+type Mirror struct {
+	url        string
+	percent    float64
+	serverName string
+	client     *http.Client
+	requests   chan *request
+
+	closed    chan bool
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+type request struct {
+	method string
+	uri    string
+	header http.Header
+	body   []byte
+}
+
+//Init validate config and create global Mirror instance with started sending goroutines
+func Init(config *Config, serverName string) error {
+	if config == nil || config.URL == "" {
+		return nil
+	}
+
+	mirror, err := NewMirror(config, serverName)
+	if err != nil {
+		return err
+	}
+
+	Instance = mirror
+	return nil
+}
+
+//NewMirror return Mirror and start sending goroutines
+func NewMirror(config *Config, serverName string) (*Mirror, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	m := &Mirror{
+		url:        strings.TrimRight(config.URL, "/"),
+		percent:    config.Percent,
+		serverName: serverName,
+		client:     &http.Client{Timeout: config.Timeout},
+		requests:   make(chan *request, config.QueueSize),
+		closed:     make(chan bool),
+	}
+	for i := 0; i < config.Workers; i++ {
+		m.start()
+	}
+
+	return m, nil
+}
+
+//Wrap return handler which mirrors requests via global Mirror (if it is configured) and then calls main
+func Wrap(main gin.HandlerFunc) gin.HandlerFunc {
+	if Instance == nil {
+		return main
+	}
+
+	return Instance.Wrap(main)
+}
+
+//Wrap return handler which puts a copy of the sampled request into sending queue and then calls main
+func (m *Mirror) Wrap(main gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Header.Get(MirrorHeader) == "" && rand.Float64()*100 < m.percent {
+			if err := m.enqueue(c.Request); err != nil {
+				log.Printf("Error mirroring request %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+			}
+		}
+
+		main(c)
+	}
+}
+
+//Close stop sending goroutines after sending of already queued requests
+func (m *Mirror) Close() error {
+	m.closeOnce.Do(func() {
+		close(m.closed)
+	})
+	m.wg.Wait()
+
+	return nil
+}
+
+//copy request (body is read and restored for main handler) and put it into sending queue
+func (m *Mirror) enqueue(r *http.Request) error {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+	}
+
+	header := r.Header.Clone()
+	header.Del("Connection")
+	header.Del("Content-Length")
+	header.Set(MirrorHeader, m.serverName)
+	//keep client ip for preprocessing on the target instance
+	if header.Get("X-Real-IP") == "" && header.Get("X-Forwarded-For") == "" {
+		if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			header.Set("X-Forwarded-For", ip)
+		}
+	}
+
+	select {
+	case m.requests <- &request{method: r.Method, uri: r.URL.RequestURI(), header: header, body: body}:
+		return nil
+	default:
+		return errors.New("mirror queue is full")
+	}
+}
+
+func (m *Mirror) start() {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		for {
+			select {
+			case r := <-m.requests:
+				m.send(r)
+			case <-m.closed:
+				//send queued requests
+				for {
+					select {
+					case r := <-m.requests:
+						m.send(r)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+func (m *Mirror) send(r *request) {
+	mirrored, err := http.NewRequest(r.method, m.url+r.uri, bytes.NewReader(r.body))
+	if err != nil {
+		log.Printf("Error creating mirrored request %s %s: %v", r.method, r.uri, err)
+		return
+	}
+	mirrored.Header = r.header
+
+	response, err := m.client.Do(mirrored)
+	if err != nil {
+		log.Printf("Error sending mirrored request %s %s: %v", r.method, r.uri, err)
+		return
+	}
+	defer response.Body.Close()
+	//read body for connection reusing
+	io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode >= http.StatusInternalServerError {
+		log.Printf("Mirrored request %s %s failed with response code: %d", r.method, r.uri, response.StatusCode)
+	}
+}
@@ -0,0 +1,71 @@
+package mirror
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMirror(t *testing.T) {
+	var mutex sync.Mutex
+	var mirrored []*http.Request
+	var mirroredBodies []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		mutex.Lock()
+		mirrored = append(mirrored, r)
+		mirroredBodies = append(mirroredBodies, string(body))
+		mutex.Unlock()
+	}))
+	defer target.Close()
+
+	m, err := NewMirror(&Config{URL: target.URL + "/"}, "test-server")
+	require.NoError(t, err)
+
+	var originalBodies []string
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.POST("/api/v1/event", m.Wrap(func(c *gin.Context) {
+		body, err := ioutil.ReadAll(c.Request.Body)
+		require.NoError(t, err)
+		originalBodies = append(originalBodies, string(body))
+		c.Status(http.StatusOK)
+	}))
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/event?token=t1", strings.NewReader(`{"event_type": "a"}`))
+	request.RemoteAddr = "10.0.0.1:12345"
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	//already mirrored request isn't mirrored again
+	request = httptest.NewRequest(http.MethodPost, "/api/v1/event?token=t1", strings.NewReader(`{"event_type": "b"}`))
+	request.Header.Set(MirrorHeader, "another-server")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	require.NoError(t, m.Close())
+
+	require.Equal(t, []string{`{"event_type": "a"}`, `{"event_type": "b"}`}, originalBodies)
+	require.Equal(t, 1, len(mirrored))
+	require.Equal(t, []string{`{"event_type": "a"}`}, mirroredBodies)
+	require.Equal(t, "/api/v1/event?token=t1", mirrored[0].URL.RequestURI())
+	require.Equal(t, "test-server", mirrored[0].Header.Get(MirrorHeader))
+	require.Equal(t, "10.0.0.1", mirrored[0].Header.Get("X-Forwarded-For"))
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.EqualError(t, (&Config{}).Validate(), "mirror url is required parameter")
+	require.EqualError(t, (&Config{URL: "http://canary:8001", Percent: 101}).Validate(), "mirror percent must be in (0, 100] range")
+
+	config := &Config{URL: "http://canary:8001"}
+	require.NoError(t, config.Validate())
+	require.Equal(t, float64(defaultPercent), config.Percent)
+	require.Equal(t, defaultTimeout, config.Timeout)
+	require.Equal(t, defaultQueueSize, config.QueueSize)
+	require.Equal(t, defaultWorkers, config.Workers)
+}
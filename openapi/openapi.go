@@ -0,0 +1,268 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const Version = "3.0.3"
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+//Operation describes one http endpoint. It is used for both registering routes and generating the spec
+//Request and Response are sample values of body types (nil if there is no body)
+type Operation struct {
+	Method      string
+	Path        string
+	Summary     string
+	Tags        []string
+	Security    []string
+	QueryParams []Parameter
+	Request     interface{}
+	Response    interface{}
+}
+
+//Parameter describes string query parameter
+type Parameter struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+//SecurityScheme is OpenAPI security scheme object
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+//Document is OpenAPI 3 document
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Components struct {
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	OperationID string                `json:"operationId"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*parameter          `json:"parameters,omitempty"`
+	RequestBody *body                 `json:"requestBody,omitempty"`
+	Responses   map[string]*body      `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type parameter struct {
+	Name        string                 `json:"name"`
+	In          string                 `json:"in"`
+	Description string                 `json:"description,omitempty"`
+	Required    bool                   `json:"required"`
+	Schema      map[string]interface{} `json:"schema"`
+}
+
+type body struct {
+	Description string                            `json:"description,omitempty"`
+	Required    bool                              `json:"required,omitempty"`
+	Content     map[string]map[string]interface{} `json:"content,omitempty"`
+}
+
+//Generate return OpenAPI document with all operations
+//gin path parameters (:name) are converted into OpenAPI ones ({name})
+//errorResponse is a sample value of common error response body of all operations
+func Generate(info Info, securitySchemes map[string]SecurityScheme, errorResponse interface{}, operations []Operation) *Document {
+	doc := &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      map[string]map[string]*operation{},
+		Components: Components{SecuritySchemes: securitySchemes},
+	}
+
+	for _, op := range operations {
+		path, pathParams := convertPath(op.Path)
+		method := strings.ToLower(op.Method)
+
+		o := &operation{
+			Summary:     op.Summary,
+			OperationID: operationID(method, path),
+			Tags:        op.Tags,
+			Responses:   map[string]*body{},
+		}
+		for _, name := range pathParams {
+			o.Parameters = append(o.Parameters, &parameter{Name: name, In: "path", Required: true, Schema: map[string]interface{}{"type": "string"}})
+		}
+		for _, qp := range op.QueryParams {
+			o.Parameters = append(o.Parameters, &parameter{Name: qp.Name, In: "query", Description: qp.Description, Required: qp.Required, Schema: map[string]interface{}{"type": "string"}})
+		}
+		for _, scheme := range op.Security {
+			o.Security = append(o.Security, map[string][]string{scheme: {}})
+		}
+		if op.Request != nil {
+			o.RequestBody = &body{Required: true, Content: jsonContent(op.Request)}
+		}
+
+		response := &body{Description: "OK"}
+		if op.Response != nil {
+			response.Content = jsonContent(op.Response)
+		}
+		o.Responses["200"] = response
+		if errorResponse != nil {
+			o.Responses["default"] = &body{Description: "Error", Content: jsonContent(errorResponse)}
+		}
+
+		methods, ok := doc.Paths[path]
+		if !ok {
+			methods = map[string]*operation{}
+			doc.Paths[path] = methods
+		}
+		methods[method] = o
+	}
+
+	return doc
+}
+
+//Schema return JSON schema of value type according to encoding/json rules
+func Schema(value interface{}) map[string]interface{} {
+	return schemaOf(reflect.TypeOf(value), map[reflect.Type]bool{})
+}
+
+func jsonContent(value interface{}) map[string]map[string]interface{} {
+	return map[string]map[string]interface{}{"application/json": {"schema": Schema(value)}}
+}
+
+func schemaOf(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	if t == nil {
+		return map[string]interface{}{}
+	}
+
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem(), visiting)
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem(), visiting)}
+	case reflect.Struct:
+		//recursive types are described as plain objects
+		if visiting[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := map[string]interface{}{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			name, omitEmpty, skip := jsonName(field)
+			if skip {
+				continue
+			}
+			properties[name] = schemaOf(field.Type, visiting)
+			if !omitEmpty {
+				required = append(required, name)
+			}
+		}
+
+		result := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			sort.Strings(required)
+			result["required"] = required
+		}
+		return result
+	default:
+		//interface{} and others
+		return map[string]interface{}{}
+	}
+}
+
+//return json field name, omitempty flag and skip flag
+func jsonName(field reflect.StructField) (string, bool, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+
+	omitEmpty := false
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	return name, omitEmpty, false
+}
+
+//convert gin path /a/:name/b into /a/{name}/b and return path parameters names
+func convertPath(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			name := segment[1:]
+			params = append(params, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+//e.g. get /api/v2/admin/destinations/{name} -> getApiV2AdminDestinationsName
+func operationID(method, path string) string {
+	id := method
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.Trim(segment, "{}")
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+			id += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+
+	return id
+}
@@ -0,0 +1,69 @@
+package openapi
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type testNested struct {
+	Value float64 `json:"value"`
+}
+
+type testBody struct {
+	Name     string                 `json:"name"`
+	Count    int                    `json:"count,omitempty"`
+	Tags     []string               `json:"tags"`
+	Created  time.Time              `json:"created"`
+	Nested   *testNested            `json:"nested,omitempty"`
+	Config   map[string]interface{} `json:"config"`
+	Raw      json.RawMessage        `json:"raw"`
+	Ignored  string                 `json:"-"`
+	internal string
+}
+
+func TestSchema(t *testing.T) {
+	b, err := json.Marshal(Schema(testBody{}))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+  "type": "object",
+  "properties": {
+    "name": {"type": "string"},
+    "count": {"type": "integer"},
+    "tags": {"type": "array", "items": {"type": "string"}},
+    "created": {"type": "string", "format": "date-time"},
+    "nested": {"type": "object", "properties": {"value": {"type": "number"}}, "required": ["value"]},
+    "config": {"type": "object", "additionalProperties": {}},
+    "raw": {}
+  },
+  "required": ["config", "created", "name", "raw", "tags"]
+}`, string(b))
+}
+
+func TestGenerate(t *testing.T) {
+	doc := Generate(Info{Title: "test", Version: "1.0"}, map[string]SecurityScheme{"token": {Type: "apiKey", In: "header", Name: "X-Token"}}, nil, []Operation{
+		{Method: "GET", Path: "/api/items", Summary: "List", Security: []string{"token"}, QueryParams: []Parameter{{Name: "limit"}}, Response: []testNested{}},
+		{Method: "PUT", Path: "/api/items/:name", Request: testNested{}},
+	})
+
+	require.Equal(t, Version, doc.OpenAPI)
+	require.Equal(t, 2, len(doc.Paths))
+
+	list := doc.Paths["/api/items"]["get"]
+	require.NotNil(t, list)
+	require.Equal(t, "getApiItems", list.OperationID)
+	require.Equal(t, []map[string][]string{{"token": {}}}, list.Security)
+	require.Equal(t, 1, len(list.Parameters))
+	require.Equal(t, "query", list.Parameters[0].In)
+	require.Nil(t, list.RequestBody)
+
+	put := doc.Paths["/api/items/{name}"]["put"]
+	require.NotNil(t, put)
+	require.Equal(t, "putApiItemsName", put.OperationID)
+	require.Equal(t, "path", put.Parameters[0].In)
+	require.Equal(t, "name", put.Parameters[0].Name)
+	require.NotNil(t, put.RequestBody)
+	require.Nil(t, put.Responses["200"].Content)
+	require.Nil(t, put.Responses["default"])
+}
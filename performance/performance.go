@@ -0,0 +1,137 @@
+package performance
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	SmallProfile          = "small"
+	MediumProfile         = "medium"
+	HighThroughputProfile = "high_throughput"
+)
+
+var (
+	//presets of performance profiles. Medium one is the default
+	presets = map[string]Config{
+		SmallProfile: {
+			StreamWorkers:     1,
+			LogBufferSize:     5000,
+			UploaderBatchSize: 10,
+			UploaderEvery:     time.Minute,
+			FilesUploadEvery:  time.Minute,
+			FilesMaxObjects:   1000,
+			BulkSize:          200,
+			FlushEvery:        time.Second,
+		},
+		MediumProfile: {
+			StreamWorkers:     1,
+			LogBufferSize:     20000,
+			UploaderBatchSize: 50,
+			UploaderEvery:     time.Minute,
+			FilesUploadEvery:  time.Minute,
+			FilesMaxObjects:   10000,
+			BulkSize:          1000,
+			FlushEvery:        time.Second,
+		},
+		HighThroughputProfile: {
+			StreamWorkers:     4,
+			LogBufferSize:     100000,
+			UploaderBatchSize: 200,
+			UploaderEvery:     30 * time.Second,
+			FilesUploadEvery:  time.Minute,
+			FilesMaxObjects:   50000,
+			BulkSize:          5000,
+			FlushEvery:        5 * time.Second,
+		},
+	}
+
+	//Instance is resolved performance config. It has medium profile values until Init is called
+	Instance = preset(MediumProfile)
+)
+
+//Config dto for deserialized performance config. Values which aren't provided are taken from profile preset
+//profile: small, medium or high_throughput (default: medium)
+//stream_workers: goroutines which consume events from the queue of every SQL destination in stream mode
+//log_buffer_size: events channel buffer of every token events log writer
+//uploader_batch_size, uploader_every: max count of log files which are uploaded to batch destinations every uploader_every
+//files_upload_every, files_max_objects: default rotation of files destinations in stream mode (s3, gcs, parquet)
+//bulk_size, flush_every: default bulk size (elasticsearch, webhook, amplitude, mixpanel) and flush interval (elasticsearch, kinesis, webhook, amplitude, mixpanel)
+type Config struct {
+	Profile           string        `mapstructure:"profile"`
+	StreamWorkers     int           `mapstructure:"stream_workers"`
+	LogBufferSize     int           `mapstructure:"log_buffer_size"`
+	UploaderBatchSize int           `mapstructure:"uploader_batch_size"`
+	UploaderEvery     time.Duration `mapstructure:"uploader_every"`
+	FilesUploadEvery  time.Duration `mapstructure:"files_upload_every"`
+	FilesMaxObjects   int           `mapstructure:"files_max_objects"`
+	BulkSize          int           `mapstructure:"bulk_size"`
+	FlushEvery        time.Duration `mapstructure:"flush_every"`
+}
+
+//Validate Config values and fill not provided ones from profile preset
+func (c *Config) Validate() error {
+	if c.Profile == "" {
+		c.Profile = MediumProfile
+	}
+	p, ok := presets[c.Profile]
+	if !ok {
+		return fmt.Errorf("Unknown performance profile: %s. Supported: %s, %s, %s", c.Profile, SmallProfile, MediumProfile, HighThroughputProfile)
+	}
+	if c.StreamWorkers < 0 || c.LogBufferSize < 0 || c.UploaderBatchSize < 0 || c.UploaderEvery < 0 ||
+		c.FilesUploadEvery < 0 || c.FilesMaxObjects < 0 || c.BulkSize < 0 || c.FlushEvery < 0 {
+		return errors.New("performance values can't be negative")
+	}
+
+	if c.StreamWorkers == 0 {
+		c.StreamWorkers = p.StreamWorkers
+	}
+	if c.LogBufferSize == 0 {
+		c.LogBufferSize = p.LogBufferSize
+	}
+	if c.UploaderBatchSize == 0 {
+		c.UploaderBatchSize = p.UploaderBatchSize
+	}
+	if c.UploaderEvery == 0 {
+		c.UploaderEvery = p.UploaderEvery
+	}
+	if c.FilesUploadEvery == 0 {
+		c.FilesUploadEvery = p.FilesUploadEvery
+	}
+	if c.FilesMaxObjects == 0 {
+		c.FilesMaxObjects = p.FilesMaxObjects
+	}
+	if c.BulkSize == 0 {
+		c.BulkSize = p.BulkSize
+	}
+	if c.FlushEvery == 0 {
+		c.FlushEvery = p.FlushEvery
+	}
+
+	return nil
+}
+
+//Init validate config and replace global Instance. Medium profile is used if config is nil
+func Init(config *Config) error {
+	if config == nil {
+		config = &Config{}
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	Instance = config
+	log.Printf("Performance profile: %s (stream workers: %d, log buffer size: %d, uploader: %d files every %s, files: %d objects every %s, bulk size: %d, flush every: %s)",
+		config.Profile, config.StreamWorkers, config.LogBufferSize, config.UploaderBatchSize, config.UploaderEvery,
+		config.FilesMaxObjects, config.FilesUploadEvery, config.BulkSize, config.FlushEvery)
+
+	return nil
+}
+
+func preset(profile string) *Config {
+	p := presets[profile]
+	p.Profile = profile
+	return &p
+}
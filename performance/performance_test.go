@@ -0,0 +1,69 @@
+package performance
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       *Config
+		expected    *Config
+		expectedErr string
+	}{
+		{
+			"Empty config is medium profile",
+			&Config{},
+			preset(MediumProfile),
+			"",
+		},
+		{
+			"High throughput profile",
+			&Config{Profile: HighThroughputProfile},
+			preset(HighThroughputProfile),
+			"",
+		},
+		{
+			"Profile values are overridden",
+			&Config{Profile: SmallProfile, StreamWorkers: 2, FlushEvery: 3 * time.Second},
+			&Config{
+				Profile:           SmallProfile,
+				StreamWorkers:     2,
+				LogBufferSize:     5000,
+				UploaderBatchSize: 10,
+				UploaderEvery:     time.Minute,
+				FilesUploadEvery:  time.Minute,
+				FilesMaxObjects:   1000,
+				BulkSize:          200,
+				FlushEvery:        3 * time.Second,
+			},
+			"",
+		},
+		{
+			"Unknown profile",
+			&Config{Profile: "huge"},
+			nil,
+			"Unknown performance profile: huge. Supported: small, medium, high_throughput",
+		},
+		{
+			"Negative value",
+			&Config{LogBufferSize: -1},
+			nil,
+			"performance values can't be negative",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.Validate()
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, tt.input)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package reports
+
+import (
+	"fmt"
+	"time"
+)
+
+//Reasons of skipped rows
+const (
+	MalformedReason    = "malformed"        //row can't be parsed or processed (mapping, typecasts, table name)
+	EmptyReason        = "empty"            //row doesn't have any fields after processing
+	TimeBoundsReason   = "time_bounds"      //row timestamp is out of time bounds
+	OverflowReason     = "numeric_overflow" //value is out of DB column range
+	TypeConflictReason = "type_conflict"    //value can't be converted to DB column type
+	ConversionReason   = "conversion"       //row can't be converted into destination format
+	InsertReason       = "insert"           //row is rejected by destination
+	DeleteReason       = "delete"           //deletion of rows with the row keys is rejected by destination
+	SchemaReason       = "json_schema"      //row doesn't match destination JSON schema
+
+	maxSamples = 10
+)
+
+//LoadReport is a result of loading one event log file into one destination when break_on_error is false:
+//rows: lines in the file, loaded: rows which have been stored, skipped: rows which have been skipped with counts per reason
+//and first maxSamples errors, filtered: rows which don't match destination filter (they aren't errors), event_times: max event time of stored rows per table (see watermarks)
+//LoadReport isn't thread-safe: it is filled by one destination Store() call
+type LoadReport struct {
+	File        string               `json:"file"`
+	Destination string               `json:"destination"`
+	Token       string               `json:"token,omitempty"`
+	Time        time.Time            `json:"time"`
+	Rows        int                  `json:"rows"`
+	Loaded      int                  `json:"loaded"`
+	Skipped     int                  `json:"skipped"`
+	Filtered    int                  `json:"filtered,omitempty"`
+	Reasons     map[string]int       `json:"reasons,omitempty"`
+	Samples     []string             `json:"samples,omitempty"`
+	EventTimes  map[string]time.Time `json:"event_times,omitempty"`
+	Error       string               `json:"error,omitempty"`
+}
+
+//NewLoadReport return LoadReport of file with rows count
+func NewLoadReport(file, destination, token string, rows int) *LoadReport {
+	return &LoadReport{File: file, Destination: destination, Token: token, Rows: rows, Reasons: map[string]int{}}
+}
+
+//Skip increment skipped rows with reason and keep error as sample (if there are less than maxSamples ones)
+//Skip is no-op on nil LoadReport (e.g. in stream mode)
+func (lr *LoadReport) Skip(reason string, err error) {
+	if lr == nil {
+		return
+	}
+
+	if lr.Reasons == nil {
+		lr.Reasons = map[string]int{}
+	}
+	lr.Skipped++
+	lr.Reasons[reason]++
+	if err != nil && len(lr.Samples) < maxSamples {
+		lr.Samples = append(lr.Samples, fmt.Sprintf("%s: %v", reason, err))
+	}
+}
+
+//Filter increment rows which don't match destination filter
+//Filter is no-op on nil LoadReport (e.g. in stream mode)
+func (lr *LoadReport) Filter() {
+	if lr == nil {
+		return
+	}
+
+	lr.Filtered++
+}
+
+//EventTime keep eventTime as table max event time if it is after the current one
+//EventTime is no-op on nil LoadReport (e.g. in stream mode) and zero eventTime (row doesn't have timestamp)
+func (lr *LoadReport) EventTime(table string, eventTime time.Time) {
+	if lr == nil || eventTime.IsZero() {
+		return
+	}
+
+	if lr.EventTimes == nil {
+		lr.EventTimes = map[string]time.Time{}
+	}
+	if current, ok := lr.EventTimes[table]; !ok || eventTime.After(current) {
+		lr.EventTimes[table] = eventTime.UTC()
+	}
+}
+
+//Finish set loading time and result: all rows are failed if storeErr isn't nil
+func (lr *LoadReport) Finish(storeErr error) {
+	lr.Time = time.Now().UTC()
+	if storeErr != nil {
+		lr.Loaded = 0
+		lr.Error = storeErr.Error()
+		return
+	}
+
+	lr.Error = ""
+	lr.Loaded = lr.Rows - lr.Skipped - lr.Filtered
+	if lr.Loaded < 0 {
+		lr.Loaded = 0
+	}
+}
@@ -0,0 +1,161 @@
+package reports
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const reportFileExtension = ".report"
+
+//Instance is nil if load reports are disabled
+var Instance *Store
+
+//Store keeps last retention load reports in memory and as JSON files in dir (one file per event log file and destination)
+//Report of the same file and destination is replaced on every upload retry
+type Store struct {
+	mutex     sync.RWMutex
+	dir       string
+	retention int
+	//oldest first
+	reports []*LoadReport
+}
+
+//Init initialize Instance with reports from dir. Reports are disabled if retention is 0
+func Init(dir string, retention int) error {
+	if retention < 0 {
+		return fmt.Errorf("load reports retention can't be negative: %d", retention)
+	}
+	if retention == 0 {
+		return nil
+	}
+
+	store, err := NewStore(dir, retention)
+	if err != nil {
+		return err
+	}
+
+	Instance = store
+	return nil
+}
+
+//NewStore return Store with reports from dir (creates dir if doesn't exist)
+func NewStore(dir string, retention int) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Error creating load reports dir [%s]: %v", dir, err)
+	}
+
+	files, err := filepath.Glob(path.Join(dir, "*"+reportFileExtension))
+	if err != nil {
+		return nil, err
+	}
+
+	store := &Store{dir: dir, retention: retention}
+	for _, filePath := range files {
+		b, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			log.Println("Error reading load report file", filePath, err)
+			continue
+		}
+		report := &LoadReport{}
+		if err := json.Unmarshal(b, report); err != nil {
+			log.Println("Error unmarshalling load report file", filePath, err)
+			continue
+		}
+		store.reports = append(store.reports, report)
+	}
+	sort.SliceStable(store.reports, func(i, j int) bool { return store.reports[i].Time.Before(store.reports[j].Time) })
+	store.removeExpired()
+
+	return store, nil
+}
+
+//Save put report into Instance. No-op if reports are disabled
+func Save(report *LoadReport) {
+	if Instance == nil {
+		return
+	}
+
+	if err := Instance.Save(report); err != nil {
+		log.Println(err)
+	}
+}
+
+//List return Instance reports (the newest first) filtered by destination and file (if not empty)
+func List(destination, file string) []*LoadReport {
+	if Instance == nil {
+		return []*LoadReport{}
+	}
+
+	return Instance.List(destination, file)
+}
+
+//Save put report in memory and file. Previous report of the same file and destination is replaced
+//the oldest reports are removed if there are more than retention ones
+func (s *Store) Save(report *LoadReport) error {
+	b, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("Error marshaling load report of file %s in %s destination: %v", report.File, report.Destination, err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, r := range s.reports {
+		if r.File == report.File && r.Destination == report.Destination {
+			s.reports = append(s.reports[:i], s.reports[i+1:]...)
+			break
+		}
+	}
+	s.reports = append(s.reports, report)
+	s.removeExpired()
+
+	filePath := s.filePath(report)
+	if err := ioutil.WriteFile(filePath, b, 0644); err != nil {
+		return fmt.Errorf("Error writing load report file %s: %v", filePath, err)
+	}
+
+	return nil
+}
+
+//List return reports (the newest first) filtered by destination and file (if not empty)
+func (s *Store) List(destination, file string) []*LoadReport {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := []*LoadReport{}
+	for i := len(s.reports) - 1; i >= 0; i-- {
+		report := s.reports[i]
+		if (destination != "" && report.Destination != destination) || (file != "" && report.File != file) {
+			continue
+		}
+		result = append(result, report)
+	}
+
+	return result
+}
+
+//remove the oldest reports and their files if there are more than retention ones (must be called under lock)
+func (s *Store) removeExpired() {
+	if len(s.reports) <= s.retention {
+		return
+	}
+
+	expired := len(s.reports) - s.retention
+	for _, report := range s.reports[:expired] {
+		if err := os.Remove(s.filePath(report)); err != nil && !os.IsNotExist(err) {
+			log.Println("Error removing load report file", s.filePath(report), err)
+		}
+	}
+	s.reports = append([]*LoadReport{}, s.reports[expired:]...)
+}
+
+func (s *Store) filePath(report *LoadReport) string {
+	return path.Join(s.dir, report.File+"_"+report.Destination+reportFileExtension)
+}
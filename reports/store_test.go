@@ -0,0 +1,86 @@
+package reports
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadReport(t *testing.T) {
+	tests := []struct {
+		name            string
+		skipped         int
+		storeErr        error
+		expectedLoaded  int
+		expectedSamples int
+		expectedErr     string
+	}{
+		{"All rows are loaded", 0, nil, 20, 0, ""},
+		{"Some rows are skipped", 3, nil, 17, 3, ""},
+		{"Samples are limited", 15, nil, 5, maxSamples, ""},
+		{"Store error", 3, errors.New("connection refused"), 0, 3, "connection refused"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewLoadReport("file1", "pg", "token1", 20)
+			for i := 0; i < tt.skipped; i++ {
+				report.Skip(MalformedReason, errors.New("unexpected end of JSON input"))
+			}
+			report.Finish(tt.storeErr)
+
+			require.Equal(t, tt.skipped, report.Skipped)
+			require.Equal(t, tt.expectedLoaded, report.Loaded)
+			require.Len(t, report.Samples, tt.expectedSamples)
+			require.Equal(t, tt.expectedErr, report.Error)
+		})
+	}
+
+	//stream mode
+	var report *LoadReport
+	report.Skip(InsertReason, errors.New("error"))
+}
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "load_reports")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewStore(dir, 2)
+	require.NoError(t, err)
+	require.Empty(t, store.List("", ""))
+
+	now := time.Now().UTC()
+	report1 := &LoadReport{File: "file1", Destination: "pg", Time: now.Add(-3 * time.Minute), Rows: 2, Loaded: 1, Skipped: 1}
+	report2 := &LoadReport{File: "file1", Destination: "ch", Time: now.Add(-2 * time.Minute), Rows: 2, Loaded: 2}
+	report3 := &LoadReport{File: "file2", Destination: "pg", Time: now.Add(-time.Minute), Rows: 5, Loaded: 5}
+	require.NoError(t, store.Save(report1))
+	require.NoError(t, store.Save(report2))
+	require.NoError(t, store.Save(report3))
+
+	//the oldest report is removed with its file
+	require.Equal(t, []*LoadReport{report3, report2}, store.List("", ""))
+	require.Equal(t, []*LoadReport{report3}, store.List("pg", ""))
+	require.Equal(t, []*LoadReport{report2}, store.List("", "file1"))
+	files, err := filepath.Glob(filepath.Join(dir, "*"+reportFileExtension))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	//report of the same file and destination is replaced
+	retried := &LoadReport{File: "file1", Destination: "ch", Time: now, Rows: 2, Loaded: 1, Skipped: 1}
+	require.NoError(t, store.Save(retried))
+	require.Equal(t, []*LoadReport{retried, report3}, store.List("", ""))
+
+	//reload from files
+	reloaded, err := NewStore(dir, 2)
+	require.NoError(t, err)
+	actual := reloaded.List("", "")
+	require.Len(t, actual, 2)
+	require.Equal(t, "file1", actual[0].File)
+	require.Equal(t, "ch", actual[0].Destination)
+	require.Equal(t, 1, actual[0].Skipped)
+	require.Equal(t, "file2", actual[1].File)
+}
@@ -0,0 +1,378 @@
+package routing
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/counters"
+	"hash/fnv"
+	"log"
+	"strings"
+)
+
+//Key is a reserved name in destinations config section for routing config
+const Key = "routing"
+
+const (
+	eventTypeKey = "event_type"
+	apiKeyKey    = "api_key"
+
+	defaultUserIDField = "/eventn_ctx/user/anonymous_id"
+	//users are split into buckets with 0.01% granularity
+	experimentBuckets = 10000
+)
+
+//Instance is nil if routing isn't configured: all events of the token are sent to all token destinations
+var Instance *Router
+
+//Config dto for deserialized destinations.routing config
+//rules: the first matching rule routes an event. Events which don't match any rule are sent to default destinations
+//default: destinations of events which don't match any rule. All token destinations if empty
+//experiments: A/B splits of tokens traffic between two destination sets. They are applied after rules
+type Config struct {
+	Rules       []*RuleConfig       `mapstructure:"rules"`
+	Default     []string            `mapstructure:"default"`
+	Experiments []*ExperimentConfig `mapstructure:"experiments"`
+}
+
+//RuleConfig dto for deserialized one routing rule
+//event matches the rule if its event_type is in event_types and its api_key is in api_keys (empty list matches all)
+//matching events are sent to destinations (only ones which are configured for the token) or dropped if drop is true
+type RuleConfig struct {
+	EventTypes   []string `mapstructure:"event_types"`
+	APIKeys      []string `mapstructure:"api_keys"`
+	Destinations []string `mapstructure:"destinations"`
+	Drop         bool     `mapstructure:"drop"`
+}
+
+//ExperimentConfig dto for deserialized one routing experiment
+//name: experiment name in statistics (see counters.ExperimentCounters)
+//api_keys: tokens which traffic is split
+//percent: share of users (0, 100) whose events are sent into treatment destinations. Events of other users are sent into control ones
+//user_id_field: path of the event field users are split by. Events without it are sent into control destinations.
+//Default: /eventn_ctx/user/anonymous_id
+//the event isn't sent into destinations of the other arm. Destinations which aren't in the experiment aren't affected
+type ExperimentConfig struct {
+	Name        string   `mapstructure:"name"`
+	APIKeys     []string `mapstructure:"api_keys"`
+	Percent     float64  `mapstructure:"percent"`
+	Control     []string `mapstructure:"control"`
+	Treatment   []string `mapstructure:"treatment"`
+	UserIDField string   `mapstructure:"user_id_field"`
+}
+
+//Validate ExperimentConfig fields and set default values
+func (ec *ExperimentConfig) Validate() error {
+	if ec.Name == "" {
+		return errors.New("name is required parameter")
+	}
+	if len(ec.APIKeys) == 0 {
+		return errors.New("api_keys is required parameter")
+	}
+	if ec.Percent <= 0 || ec.Percent >= 100 {
+		return errors.New("percent must be in (0, 100) range")
+	}
+	if len(ec.Control) == 0 || len(ec.Treatment) == 0 {
+		return errors.New("control and treatment are required parameters")
+	}
+	control := toSet(ec.Control)
+	for _, destination := range ec.Treatment {
+		if control[destination] {
+			return fmt.Errorf("destination [%s] can't be in both control and treatment", destination)
+		}
+	}
+	if ec.UserIDField == "" {
+		ec.UserIDField = defaultUserIDField
+	}
+
+	return nil
+}
+
+//Router routes events to destinations by event_type and api_key
+//and splits traffic of experiments tokens by user
+type Router struct {
+	rules []*rule
+	//nil if all token destinations are default ones
+	defaultDestinations map[string]bool
+	experiments         []*experiment
+}
+
+type rule struct {
+	eventTypes   map[string]bool
+	apiKeys      map[string]bool
+	destinations map[string]bool
+	drop         bool
+}
+
+type experiment struct {
+	name        string
+	apiKeys     map[string]bool
+	buckets     uint32
+	control     map[string]bool
+	treatment   map[string]bool
+	userIDField []string
+}
+
+//return true if the user of the event is in the treatment arm
+//users are assigned by hash of the experiment name and user id so experiments split users independently
+func (e *experiment) treated(fact map[string]interface{}) bool {
+	userID := valueByPath(fact, e.userIDField)
+	if userID == nil {
+		return false
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(e.name))
+	hash.Write([]byte(fmt.Sprint(userID)))
+	return hash.Sum32()%experimentBuckets < e.buckets
+}
+
+//Init initialize Instance with config. Instance is nil if config is nil or doesn't have rules and default destinations
+//destinations are names of configured destinations: unknown names in rules are logged
+func Init(config *Config, destinations map[string]bool) error {
+	router, err := NewRouter(config, destinations)
+	if err != nil {
+		return err
+	}
+
+	Instance = router
+	return nil
+}
+
+//NewRouter return Router or nil if config is nil or empty
+//return err if rule doesn't have conditions or has both destinations and drop (or none of them) or if experiment is invalid
+func NewRouter(config *Config, destinations map[string]bool) (*Router, error) {
+	if config == nil || (len(config.Rules) == 0 && len(config.Default) == 0 && len(config.Experiments) == 0) {
+		return nil, nil
+	}
+
+	router := &Router{}
+	for i, rc := range config.Rules {
+		if rc == nil || (len(rc.EventTypes) == 0 && len(rc.APIKeys) == 0) {
+			return nil, fmt.Errorf("destinations.routing rule #%d: event_types or api_keys is required", i+1)
+		}
+		if rc.Drop == (len(rc.Destinations) > 0) {
+			return nil, fmt.Errorf("destinations.routing rule #%d: either destinations or drop: true is required", i+1)
+		}
+
+		router.rules = append(router.rules, &rule{
+			eventTypes:   toSet(rc.EventTypes),
+			apiKeys:      toSet(rc.APIKeys),
+			destinations: toSet(rc.Destinations),
+			drop:         rc.Drop,
+		})
+		warnUnknown(rc.Destinations, destinations)
+	}
+
+	if len(config.Default) > 0 {
+		router.defaultDestinations = toSet(config.Default)
+		warnUnknown(config.Default, destinations)
+	}
+
+	names := map[string]bool{}
+	for i, ec := range config.Experiments {
+		if ec == nil {
+			return nil, fmt.Errorf("destinations.routing experiment #%d can't be empty", i+1)
+		}
+		if err := ec.Validate(); err != nil {
+			return nil, fmt.Errorf("destinations.routing experiment #%d: %v", i+1, err)
+		}
+		if names[ec.Name] {
+			return nil, fmt.Errorf("destinations.routing experiment [%s] is configured more than once", ec.Name)
+		}
+		names[ec.Name] = true
+
+		router.experiments = append(router.experiments, &experiment{
+			name:        ec.Name,
+			apiKeys:     toSet(ec.APIKeys),
+			buckets:     uint32(ec.Percent * experimentBuckets / 100),
+			control:     toSet(ec.Control),
+			treatment:   toSet(ec.Treatment),
+			userIDField: strings.Split(strings.Trim(ec.UserIDField, "/"), "/"),
+		})
+		warnUnknown(ec.Control, destinations)
+		warnUnknown(ec.Treatment, destinations)
+	}
+
+	return router, nil
+}
+
+//Drop return true if Instance routes the event into the drop route
+func Drop(fact map[string]interface{}) bool {
+	if Instance == nil {
+		return false
+	}
+
+	_, drop := Instance.Route(fact)
+	return drop
+}
+
+//Routed return true if Instance is nil or routes the event into the destination
+func Routed(destination string, fact map[string]interface{}) bool {
+	if Instance == nil {
+		return true
+	}
+
+	return Instance.Routed(destination, fact)
+}
+
+//CountArms increment events counters of experiments arms which the event is assigned to
+//it is called once per accepted event
+func CountArms(fact map[string]interface{}) {
+	if Instance == nil {
+		return
+	}
+
+	apiKey := stringValue(fact, apiKeyKey)
+	for _, experiment := range Instance.experiments {
+		if !experiment.apiKeys[apiKey] {
+			continue
+		}
+		if experiment.treated(fact) {
+			counters.TreatmentEvents(experiment.name, 1)
+		} else {
+			counters.ControlEvents(experiment.name, 1)
+		}
+	}
+}
+
+//Route return destinations of the event (nil - all token destinations) and true if the event must be dropped
+//experiments aren't applied (see Excluded)
+func (r *Router) Route(fact map[string]interface{}) (map[string]bool, bool) {
+	eventType := stringValue(fact, eventTypeKey)
+	apiKey := stringValue(fact, apiKeyKey)
+	for _, rule := range r.rules {
+		if (len(rule.eventTypes) > 0 && !rule.eventTypes[eventType]) || (len(rule.apiKeys) > 0 && !rule.apiKeys[apiKey]) {
+			continue
+		}
+		if rule.drop {
+			return nil, true
+		}
+		return rule.destinations, false
+	}
+
+	return r.defaultDestinations, false
+}
+
+//Excluded return destinations of experiments arms which the user of the event isn't assigned to
+func (r *Router) Excluded(fact map[string]interface{}) map[string]bool {
+	apiKey := stringValue(fact, apiKeyKey)
+	excluded := map[string]bool{}
+	for _, experiment := range r.experiments {
+		if !experiment.apiKeys[apiKey] {
+			continue
+		}
+
+		other := experiment.treatment
+		if experiment.treated(fact) {
+			other = experiment.control
+		}
+		for destination := range other {
+			excluded[destination] = true
+		}
+	}
+
+	return excluded
+}
+
+//Routed return true if the event is routed into the destination and the destination isn't excluded by experiments
+func (r *Router) Routed(destination string, fact map[string]interface{}) bool {
+	destinations, drop := r.Route(fact)
+	if drop {
+		return false
+	}
+
+	return (destinations == nil || destinations[destination]) && !r.Excluded(fact)[destination]
+}
+
+//Payloads return event log file payload lines which are routed into every destination
+//all lines are routed into all destinations if Instance is nil. Malformed lines are routed into all destinations
+//(they are counted as malformed ones by destinations)
+func Payloads(payload []byte, destinations []string) map[string][]byte {
+	result := map[string][]byte{}
+	if Instance == nil {
+		for _, destination := range destinations {
+			result[destination] = payload
+		}
+		return result
+	}
+
+	buffers := map[string]*bytes.Buffer{}
+	for _, destination := range destinations {
+		buffers[destination] = &bytes.Buffer{}
+	}
+
+	reader := bufio.NewReaderSize(bytes.NewReader(payload), 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var routed, excluded map[string]bool
+			drop := false
+			fact := map[string]interface{}{}
+			if jsonErr := json.Unmarshal(line, &fact); jsonErr == nil {
+				routed, drop = Instance.Route(fact)
+				excluded = Instance.Excluded(fact)
+			}
+			for destination, buffer := range buffers {
+				if !drop && (routed == nil || routed[destination]) && !excluded[destination] {
+					buffer.Write(line)
+				}
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	for destination, buffer := range buffers {
+		result[destination] = buffer.Bytes()
+	}
+
+	return result
+}
+
+func stringValue(fact map[string]interface{}, key string) string {
+	value, ok := fact[key].(string)
+	if !ok {
+		return ""
+	}
+
+	return value
+}
+
+//return value by path or nil if it doesn't exist
+func valueByPath(object map[string]interface{}, path []string) interface{} {
+	for _, key := range path[:len(path)-1] {
+		inner, ok := object[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		object = inner
+	}
+
+	return object[path[len(path)-1]]
+}
+
+func toSet(values []string) map[string]bool {
+	set := map[string]bool{}
+	for _, value := range values {
+		set[value] = true
+	}
+
+	return set
+}
+
+//log destinations which aren't configured (if configured destinations are provided)
+func warnUnknown(names []string, destinations map[string]bool) {
+	if destinations == nil {
+		return
+	}
+
+	for _, name := range names {
+		if !destinations[name] {
+			log.Printf("Warn: destinations.routing refers to unknown destination [%s]. Events won't be sent there", name)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package routing
+
+import (
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+)
+
+func TestNewRouter(t *testing.T) {
+	router, err := NewRouter(&Config{}, nil)
+	require.NoError(t, err)
+	require.Nil(t, router)
+
+	_, err = NewRouter(&Config{Rules: []*RuleConfig{{Destinations: []string{"pg"}}}}, nil)
+	require.Error(t, err)
+
+	_, err = NewRouter(&Config{Rules: []*RuleConfig{{EventTypes: []string{"pageview"}}}}, nil)
+	require.Error(t, err)
+
+	_, err = NewRouter(&Config{Rules: []*RuleConfig{{EventTypes: []string{"pageview"}, Destinations: []string{"pg"}, Drop: true}}}, nil)
+	require.Error(t, err)
+}
+
+func TestRoute(t *testing.T) {
+	router, err := NewRouter(&Config{
+		Rules: []*RuleConfig{
+			{EventTypes: []string{"heartbeat"}, Drop: true},
+			{EventTypes: []string{"pageview", "click"}, APIKeys: []string{"js"}, Destinations: []string{"ch"}},
+			{APIKeys: []string{"s2s"}, Destinations: []string{"pg", "bq"}},
+		},
+		Default: []string{"bq"},
+	}, map[string]bool{"ch": true, "pg": true, "bq": true})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		fact     map[string]interface{}
+		expected []string
+		drop     bool
+	}{
+		{"drop", map[string]interface{}{"event_type": "heartbeat", "api_key": "s2s"}, nil, true},
+		{"event type and api key", map[string]interface{}{"event_type": "click", "api_key": "js"}, []string{"ch"}, false},
+		{"api key", map[string]interface{}{"event_type": "click", "api_key": "s2s"}, []string{"bq", "pg"}, false},
+		{"default", map[string]interface{}{"event_type": "identify", "api_key": "js"}, []string{"bq"}, false},
+		{"without event type", map[string]interface{}{"api_key": "js"}, []string{"bq"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, drop := router.Route(tt.fact)
+			require.Equal(t, tt.drop, drop)
+
+			var routed []string
+			for _, destination := range []string{"bq", "ch", "pg"} {
+				if router.Routed(destination, tt.fact) {
+					routed = append(routed, destination)
+				}
+			}
+			require.Equal(t, tt.expected, routed)
+		})
+	}
+
+	router, err = NewRouter(&Config{Rules: []*RuleConfig{{EventTypes: []string{"heartbeat"}, Drop: true}}}, nil)
+	require.NoError(t, err)
+	require.True(t, router.Routed("pg", map[string]interface{}{"event_type": "pageview"}))
+}
+
+func TestPayloads(t *testing.T) {
+	payload := []byte(`{"event_type":"heartbeat"}` + "\n" +
+		`{"event_type":"pageview","id":1}` + "\n" +
+		`{"event_type":"identify","id":2}` + "\n" +
+		`{malformed` + "\n")
+
+	Instance = nil
+	require.Equal(t, map[string][]byte{"pg": payload, "ch": payload}, Payloads(payload, []string{"pg", "ch"}))
+
+	require.NoError(t, Init(&Config{Rules: []*RuleConfig{
+		{EventTypes: []string{"heartbeat"}, Drop: true},
+		{EventTypes: []string{"pageview"}, Destinations: []string{"ch"}},
+	}}, nil))
+	defer func() { Instance = nil }()
+
+	payloads := Payloads(payload, []string{"pg", "ch"})
+	require.Equal(t, `{"event_type":"identify","id":2}`+"\n"+`{malformed`+"\n", string(payloads["pg"]))
+	require.Equal(t, `{"event_type":"pageview","id":1}`+"\n"+`{"event_type":"identify","id":2}`+"\n"+`{malformed`+"\n", string(payloads["ch"]))
+
+	require.True(t, Drop(map[string]interface{}{"event_type": "heartbeat"}))
+	require.False(t, Drop(map[string]interface{}{"event_type": "pageview"}))
+}
+
+func TestExperiments(t *testing.T) {
+	_, err := NewRouter(&Config{Experiments: []*ExperimentConfig{{Name: "sf", APIKeys: []string{"js"}, Percent: 100, Control: []string{"ch"}, Treatment: []string{"sf"}}}}, nil)
+	require.EqualError(t, err, "destinations.routing experiment #1: percent must be in (0, 100) range")
+
+	_, err = NewRouter(&Config{Experiments: []*ExperimentConfig{{Name: "sf", APIKeys: []string{"js"}, Percent: 5, Control: []string{"ch"}, Treatment: []string{"ch"}}}}, nil)
+	require.EqualError(t, err, "destinations.routing experiment #1: destination [ch] can't be in both control and treatment")
+
+	require.NoError(t, Init(&Config{
+		Rules:       []*RuleConfig{{EventTypes: []string{"heartbeat"}, Drop: true}},
+		Experiments: []*ExperimentConfig{{Name: "sf", APIKeys: []string{"js"}, Percent: 20, Control: []string{"ch"}, Treatment: []string{"sf"}}},
+	}, nil))
+	defer func() { Instance = nil }()
+
+	treated := 0
+	for i := 0; i < 1000; i++ {
+		fact := map[string]interface{}{"api_key": "js", "eventn_ctx": map[string]interface{}{"user": map[string]interface{}{"anonymous_id": "user" + strconv.Itoa(i)}}}
+		inTreatment := Routed("sf", fact)
+		//exactly one arm gets the event, other destinations aren't affected
+		require.NotEqual(t, inTreatment, Routed("ch", fact))
+		require.True(t, Routed("pg", fact))
+		//assignment is deterministic by user id
+		require.Equal(t, inTreatment, Routed("sf", fact))
+		if inTreatment {
+			treated++
+		}
+	}
+	require.InDelta(t, 200, treated, 50)
+
+	//events of other tokens and events without user id
+	require.True(t, Routed("sf", map[string]interface{}{"api_key": "s2s", "eventn_ctx": map[string]interface{}{"user": map[string]interface{}{"anonymous_id": "user1"}}}))
+	require.True(t, Routed("ch", map[string]interface{}{"api_key": "s2s"}))
+	require.True(t, Routed("ch", map[string]interface{}{"api_key": "js"}))
+	require.False(t, Routed("sf", map[string]interface{}{"api_key": "js"}))
+
+	payloads := Payloads([]byte(`{"api_key":"js"}`+"\n"), []string{"ch", "sf"})
+	require.Equal(t, `{"api_key":"js"}`+"\n", string(payloads["ch"]))
+	require.Empty(t, payloads["sf"])
+
+	before := *experimentCounters("sf")
+	CountArms(map[string]interface{}{"api_key": "js"})
+	CountArms(map[string]interface{}{"api_key": "s2s"})
+	after := *experimentCounters("sf")
+	require.Equal(t, before.Control+1, after.Control)
+	require.Equal(t, before.Treatment, after.Treatment)
+}
+
+func experimentCounters(name string) *counters.ExperimentCounters {
+	if ec, ok := counters.GetSnapshot().Experiments[name]; ok {
+		return ec
+	}
+	return &counters.ExperimentCounters{}
+}
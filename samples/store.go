@@ -0,0 +1,233 @@
+package samples
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	samplesFileExtension = ".samples"
+
+	//at most one payload per table is sampled every samplingInterval
+	samplingInterval = time.Second
+	flushEvery       = 10 * time.Second
+)
+
+//Instance is nil if table samples are disabled
+var Instance *Store
+
+//Sample is a raw event payload which was written into destination table
+type Sample struct {
+	Destination string          `json:"destination"`
+	Table       string          `json:"table"`
+	Time        time.Time       `json:"time"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+//samples of one destination table (the oldest first)
+type tableSamples struct {
+	samples []*Sample
+	changed bool
+}
+
+//Store keeps last capacity raw payloads per destination table in memory and as JSON files in dir (one file per destination table)
+//Files are rewritten with changed samples every flushEvery and on Close
+type Store struct {
+	mutex    sync.RWMutex
+	dir      string
+	capacity int
+	tables   map[string]*tableSamples
+
+	closed chan struct{}
+}
+
+//Init initialize Instance with samples from dir. Samples are disabled if capacity is 0
+func Init(dir string, capacity int) error {
+	if capacity < 0 {
+		return fmt.Errorf("table samples count can't be negative: %d", capacity)
+	}
+	if capacity == 0 {
+		return nil
+	}
+
+	store, err := NewStore(dir, capacity)
+	if err != nil {
+		return err
+	}
+	store.startFlushing()
+
+	Instance = store
+	return nil
+}
+
+//NewStore return Store with samples from dir (creates dir if doesn't exist)
+func NewStore(dir string, capacity int) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Error creating table samples dir [%s]: %v", dir, err)
+	}
+
+	files, err := filepath.Glob(path.Join(dir, "*"+samplesFileExtension))
+	if err != nil {
+		return nil, err
+	}
+
+	store := &Store{dir: dir, capacity: capacity, tables: map[string]*tableSamples{}, closed: make(chan struct{})}
+	for _, filePath := range files {
+		b, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			log.Println("Error reading table samples file", filePath, err)
+			continue
+		}
+		var samples []*Sample
+		if err := json.Unmarshal(b, &samples); err != nil {
+			log.Println("Error unmarshalling table samples file", filePath, err)
+			continue
+		}
+		if len(samples) == 0 {
+			continue
+		}
+		if len(samples) > capacity {
+			samples = samples[len(samples)-capacity:]
+		}
+		store.tables[key(samples[0].Destination, samples[0].Table)] = &tableSamples{samples: samples}
+	}
+
+	return store, nil
+}
+
+//Put sample payload of destination table into Instance. No-op if samples are disabled
+//payload func is called only if the payload is sampled
+func Put(destination, table string, payload func() []byte) {
+	if Instance == nil {
+		return
+	}
+
+	Instance.Put(destination, table, payload)
+}
+
+//List return Instance samples of destination table (the newest first)
+func List(destination, table string) []*Sample {
+	if Instance == nil {
+		return []*Sample{}
+	}
+
+	return Instance.List(destination, table)
+}
+
+//Put sample payload of destination table if the last table sample is older than samplingInterval
+//the oldest sample is removed if there are more than capacity ones
+func (s *Store) Put(destination, table string, payload func() []byte) {
+	k := key(destination, table)
+	now := time.Now().UTC()
+
+	s.mutex.RLock()
+	ts, ok := s.tables[k]
+	sampled := ok && now.Sub(ts.samples[len(ts.samples)-1].Time) < samplingInterval
+	s.mutex.RUnlock()
+	if sampled {
+		return
+	}
+
+	b := payload()
+	if !json.Valid(b) {
+		return
+	}
+	sample := &Sample{Destination: destination, Table: table, Time: now, Payload: b}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ts, ok = s.tables[k]
+	if !ok {
+		ts = &tableSamples{}
+		s.tables[k] = ts
+	}
+	ts.samples = append(ts.samples, sample)
+	if len(ts.samples) > s.capacity {
+		ts.samples = append([]*Sample{}, ts.samples[len(ts.samples)-s.capacity:]...)
+	}
+	ts.changed = true
+}
+
+//List return samples of destination table (the newest first)
+func (s *Store) List(destination, table string) []*Sample {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := []*Sample{}
+	ts, ok := s.tables[key(destination, table)]
+	if !ok {
+		return result
+	}
+	for i := len(ts.samples) - 1; i >= 0; i-- {
+		result = append(result, ts.samples[i])
+	}
+
+	return result
+}
+
+//Flush write changed tables samples into files
+func (s *Store) Flush() {
+	s.mutex.Lock()
+	changed := map[string][]*Sample{}
+	for k, ts := range s.tables {
+		if ts.changed {
+			changed[k] = append([]*Sample{}, ts.samples...)
+			ts.changed = false
+		}
+	}
+	s.mutex.Unlock()
+
+	for _, samples := range changed {
+		destination, table := samples[0].Destination, samples[0].Table
+		b, err := json.Marshal(samples)
+		if err != nil {
+			log.Printf("Error marshaling samples of table %s in %s destination: %v", table, destination, err)
+			continue
+		}
+
+		filePath := path.Join(s.dir, fileName(destination, table))
+		if err := ioutil.WriteFile(filePath, b, 0644); err != nil {
+			log.Printf("Error writing table samples file %s: %v", filePath, err)
+		}
+	}
+}
+
+//Close stop flushing goroutine and flush changed samples
+func (s *Store) Close() error {
+	close(s.closed)
+	s.Flush()
+	return nil
+}
+
+func (s *Store) startFlushing() {
+	go func() {
+		ticker := time.NewTicker(flushEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.closed:
+				return
+			case <-ticker.C:
+				s.Flush()
+			}
+		}
+	}()
+}
+
+func key(destination, table string) string {
+	return destination + "/" + table
+}
+
+//return destination table samples file name with replaced path separators
+func fileName(destination, table string) string {
+	return strings.NewReplacer("/", "_", `\`, "_").Replace(destination+"_"+table) + samplesFileExtension
+}
@@ -0,0 +1,63 @@
+package samples
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "table_samples")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewStore(dir, 2)
+	require.NoError(t, err)
+	require.Empty(t, store.List("pg", "events"))
+
+	payloads := []string{`{"id":1}`, `{"id":2}`, `{"id":3}`}
+	for _, payload := range payloads {
+		p := payload
+		store.Put("pg", "events", func() []byte { return []byte(p) })
+		//the next payload is sampled only after samplingInterval
+		store.Put("pg", "events", func() []byte { return []byte(`{"id":0}`) })
+		ageLastSample(store, "pg", "events")
+	}
+	store.Put("pg", "users", func() []byte { return []byte(`{"id":"u1"}`) })
+	store.Put("pg", "users", func() []byte { return []byte(`malformed`) })
+
+	//the oldest sample is removed
+	require.Equal(t, []json.RawMessage{json.RawMessage(`{"id":3}`), json.RawMessage(`{"id":2}`)}, payloadsOf(store.List("pg", "events")))
+	require.Equal(t, []json.RawMessage{json.RawMessage(`{"id":"u1"}`)}, payloadsOf(store.List("pg", "users")))
+	require.Empty(t, store.List("ch", "events"))
+
+	//reload from files
+	store.Flush()
+	reloaded, err := NewStore(dir, 1)
+	require.NoError(t, err)
+	actual := reloaded.List("pg", "events")
+	require.Equal(t, []json.RawMessage{json.RawMessage(`{"id":3}`)}, payloadsOf(actual))
+	require.Equal(t, "pg", actual[0].Destination)
+	require.Equal(t, "events", actual[0].Table)
+	require.Equal(t, []json.RawMessage{json.RawMessage(`{"id":"u1"}`)}, payloadsOf(reloaded.List("pg", "users")))
+}
+
+func ageLastSample(store *Store, destination, table string) {
+	ts := store.tables[key(destination, table)]
+	ts.samples[len(ts.samples)-1].Time = ts.samples[len(ts.samples)-1].Time.Add(-samplingInterval)
+}
+
+func payloadsOf(samples []*Sample) []json.RawMessage {
+	var payloads []json.RawMessage
+	for _, sample := range samples {
+		payloads = append(payloads, sample.Payload)
+	}
+	return payloads
+}
+
+func TestFileName(t *testing.T) {
+	require.Equal(t, "pg_events.samples", fileName("pg", "events"))
+	require.Equal(t, "pg_events_2020_09.samples", fileName("pg", "events/2020/09"))
+}
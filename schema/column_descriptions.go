@@ -0,0 +1,46 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+)
+
+//ColumnDescriptionConfig dto for deserialized data_layout.column_descriptions config item
+//column: flattened field name (after mapping is applied)
+//description: text which is written as column comment in destinations which support it
+type ColumnDescriptionConfig struct {
+	Column      string `mapstructure:"column"`
+	Description string `mapstructure:"description"`
+}
+
+//Validate required fields in ColumnDescriptionConfig
+func (cdc *ColumnDescriptionConfig) Validate() error {
+	if cdc == nil {
+		return errors.New("column_descriptions item can't be empty")
+	}
+	if cdc.Column == "" {
+		return errors.New("column_descriptions column is required parameter")
+	}
+	if cdc.Description == "" {
+		return fmt.Errorf("column_descriptions description is required parameter for column [%s]", cdc.Column)
+	}
+
+	return nil
+}
+
+//NewColumnDescriptions return column name - description map from configs
+//return err if config is invalid or column is described twice
+func NewColumnDescriptions(configs []*ColumnDescriptionConfig) (map[string]string, error) {
+	descriptions := map[string]string{}
+	for _, config := range configs {
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := descriptions[config.Column]; ok {
+			return nil, fmt.Errorf("column_descriptions column [%s] is described more than once", config.Column)
+		}
+		descriptions[config.Column] = config.Description
+	}
+
+	return descriptions, nil
+}
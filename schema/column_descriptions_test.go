@@ -0,0 +1,59 @@
+package schema
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestProcessFactColumnDescriptions(t *testing.T) {
+	p, err := NewProcessor(&ProcessorConfig{
+		TableNameTemplate: "events",
+		Mapping:           []string{"/user/id -> /user_id"},
+		ColumnDescriptions: []*ColumnDescriptionConfig{
+			{Column: "user_id", Description: "Identified user id"},
+			{Column: "eventn_ctx_event_id", Description: "Unique event id"},
+		},
+	})
+	require.NoError(t, err)
+
+	table, _, err := p.ProcessFact(map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "user": map[string]interface{}{"id": "u1"}, "event_type": "pageview"})
+	require.NoError(t, err)
+	require.Equal(t, "Identified user id", table.Columns["user_id"].Description())
+	require.Equal(t, "", table.Columns["event_type"].Description())
+
+	diff, err := (&Table{Name: "events", Columns: Columns{"event_type": NewColumn(table.Columns["event_type"].GetType())}}).Diff(table)
+	require.NoError(t, err)
+	require.Equal(t, "Identified user id", diff.Columns["user_id"].Description())
+}
+
+func TestNewColumnDescriptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		configs     []*ColumnDescriptionConfig
+		expected    map[string]string
+		expectedErr string
+	}{
+		{"Empty config", nil, map[string]string{}, ""},
+		{"Valid config", []*ColumnDescriptionConfig{{Column: "user_id", Description: "User id"}}, map[string]string{"user_id": "User id"}, ""},
+		{"Without column", []*ColumnDescriptionConfig{{Description: "User id"}}, nil, "column_descriptions column is required parameter"},
+		{"Without description", []*ColumnDescriptionConfig{{Column: "user_id"}}, nil, "column_descriptions description is required parameter for column [user_id]"},
+		{
+			"Duplicated column",
+			[]*ColumnDescriptionConfig{{Column: "user_id", Description: "User id"}, {Column: "user_id", Description: "Id"}},
+			nil,
+			"column_descriptions column [user_id] is described more than once",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := NewColumnDescriptions(tt.configs)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	LowerCase = "lower"
+	KeepCase  = "keep"
+
+	defaultNameReplacement = "_"
+	defaultCollisionSuffix = "_%d"
+)
+
+//ColumnNamesConfig dto for deserialized data_layout.column_names config. Values which aren't provided are taken
+//from destination dialect defaults (see storages)
+//case: lower (default) or keep (field names case is preserved)
+//allowed_characters: regexp character class of allowed characters (e.g. a-zA-Z0-9_). Other ones are replaced with replacement
+//replacement: string which replaces not allowed characters (default: _). Empty string removes them
+//max_length: max column name length (0 - unlimited). Longer names are truncated
+//collision_suffix: format of suffix of names which collide with other object names after sanitization. %d is
+//a collision number starting from 2 (default: _%d). Names which haven't been changed by sanitization keep it
+//reserved_words: case insensitive keywords of the destination SQL dialect (e.g. order, select)
+//reserved_suffix: reserved words get the suffix e.g. order -> order_ (default: they are quoted by the destination as is)
+type ColumnNamesConfig struct {
+	Case              string   `mapstructure:"case"`
+	AllowedCharacters string   `mapstructure:"allowed_characters"`
+	Replacement       *string  `mapstructure:"replacement"`
+	MaxLength         int      `mapstructure:"max_length"`
+	CollisionSuffix   string   `mapstructure:"collision_suffix"`
+	ReservedWords     []string `mapstructure:"reserved_words"`
+	ReservedSuffix    string   `mapstructure:"reserved_suffix"`
+}
+
+//ColumnNames sanitizes flattened column names according to identifier rules of the destination
+//protected names (EventNative system columns) are never changed
+type ColumnNames struct {
+	keepCase        bool
+	notAllowed      *regexp.Regexp
+	replacement     string
+	maxLength       int
+	collisionSuffix string
+	reserved        map[string]bool
+	reservedSuffix  string
+	protected       map[string]bool
+}
+
+//NewColumnNames return ColumnNames or nil if config is nil
+//return err if case is unknown, allowed characters aren't a valid character class or collision suffix is malformed
+func NewColumnNames(config *ColumnNamesConfig, protected []string) (*ColumnNames, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	switch config.Case {
+	case "", LowerCase, KeepCase:
+	default:
+		return nil, fmt.Errorf("Unknown column_names.case value: %s. Supported: %s, %s", config.Case, LowerCase, KeepCase)
+	}
+
+	if config.MaxLength < 0 {
+		return nil, errors.New("column_names.max_length can't be negative")
+	}
+
+	var notAllowed *regexp.Regexp
+	if config.AllowedCharacters != "" {
+		var err error
+		notAllowed, err = regexp.Compile("[^" + config.AllowedCharacters + "]")
+		if err != nil {
+			return nil, fmt.Errorf("Malformed column_names.allowed_characters [%s]: %v", config.AllowedCharacters, err)
+		}
+	}
+
+	replacement := defaultNameReplacement
+	if config.Replacement != nil {
+		replacement = *config.Replacement
+	}
+	if notAllowed != nil && notAllowed.MatchString(replacement) {
+		return nil, fmt.Errorf("column_names.replacement [%s] contains not allowed characters", replacement)
+	}
+
+	collisionSuffix := config.CollisionSuffix
+	if collisionSuffix == "" {
+		collisionSuffix = defaultCollisionSuffix
+	}
+	if strings.Count(collisionSuffix, "%d") != 1 || strings.Count(collisionSuffix, "%") != 1 {
+		return nil, fmt.Errorf("column_names.collision_suffix [%s] must contain %%d placeholder of collision number once", collisionSuffix)
+	}
+	if config.MaxLength > 0 && len(fmt.Sprintf(collisionSuffix, 9)) >= config.MaxLength {
+		return nil, fmt.Errorf("column_names.collision_suffix [%s] must be shorter than column_names.max_length", collisionSuffix)
+	}
+
+	if notAllowed != nil && notAllowed.MatchString(config.ReservedSuffix) {
+		return nil, fmt.Errorf("column_names.reserved_suffix [%s] contains not allowed characters", config.ReservedSuffix)
+	}
+	if config.MaxLength > 0 && len(config.ReservedSuffix) >= config.MaxLength {
+		return nil, fmt.Errorf("column_names.reserved_suffix [%s] must be shorter than column_names.max_length", config.ReservedSuffix)
+	}
+	reserved := map[string]bool{}
+	if config.ReservedSuffix != "" {
+		for _, word := range config.ReservedWords {
+			reserved[strings.ToLower(word)] = true
+		}
+	}
+
+	protectedNames := map[string]bool{}
+	for _, name := range protected {
+		protectedNames[name] = true
+	}
+
+	return &ColumnNames{
+		keepCase:        config.Case == KeepCase,
+		notAllowed:      notAllowed,
+		replacement:     replacement,
+		maxLength:       config.MaxLength,
+		collisionSuffix: collisionSuffix,
+		reserved:        reserved,
+		reservedSuffix:  config.ReservedSuffix,
+		protected:       protectedNames,
+	}, nil
+}
+
+//KeepCase return true if field names case is preserved
+func (cn *ColumnNames) KeepCase() bool {
+	return cn != nil && cn.keepCase
+}
+
+//Sanitize return object with sanitized names. Names which collide after sanitization get collision suffix
+//in order of their original names: names which haven't been changed go first
+func (cn *ColumnNames) Sanitize(object map[string]interface{}) map[string]interface{} {
+	if cn == nil {
+		return object
+	}
+
+	//sanitized name: original names
+	candidates := map[string][]string{}
+	changed := false
+	for name := range object {
+		sanitized := cn.sanitize(name)
+		candidates[sanitized] = append(candidates[sanitized], name)
+		if sanitized != name {
+			changed = true
+		}
+	}
+	if !changed {
+		return object
+	}
+
+	result := make(map[string]interface{}, len(object))
+	var collisions []string
+	for sanitized, names := range candidates {
+		if len(names) == 1 {
+			result[sanitized] = object[names[0]]
+			continue
+		}
+		collisions = append(collisions, sanitized)
+	}
+
+	//names with suffixes can collide with other names, so collisions are resolved after all other names are put
+	sort.Strings(collisions)
+	for _, sanitized := range collisions {
+		names := candidates[sanitized]
+		sort.Slice(names, func(i, j int) bool {
+			iUnchanged, jUnchanged := names[i] == sanitized, names[j] == sanitized
+			if iUnchanged != jUnchanged {
+				return iUnchanged
+			}
+			return names[i] < names[j]
+		})
+
+		if _, ok := result[sanitized]; !ok {
+			result[sanitized] = object[names[0]]
+			names = names[1:]
+		}
+		number := 2
+		for _, name := range names {
+			var suffixed string
+			for {
+				suffixed = cn.suffixed(sanitized, number)
+				number++
+				if _, ok := result[suffixed]; !ok {
+					break
+				}
+			}
+			result[suffixed] = object[name]
+		}
+	}
+
+	return result
+}
+
+//return name with replaced not allowed characters and truncated to max length. Reserved words get reserved suffix
+//Protected names are returned as is
+func (cn *ColumnNames) sanitize(name string) string {
+	if cn.protected[name] {
+		return name
+	}
+
+	if cn.notAllowed != nil {
+		name = cn.notAllowed.ReplaceAllLiteralString(name, cn.replacement)
+	}
+	if name == "" {
+		name = defaultNameReplacement
+	}
+	if cn.maxLength > 0 && len(name) > cn.maxLength {
+		name = truncateName(name, cn.maxLength)
+	}
+	if cn.reserved[strings.ToLower(name)] {
+		if cn.maxLength > 0 && len(name)+len(cn.reservedSuffix) > cn.maxLength {
+			name = truncateName(name, cn.maxLength-len(cn.reservedSuffix))
+		}
+		name += cn.reservedSuffix
+	}
+
+	return name
+}
+
+//return name with collision suffix (name is truncated to fit max length)
+func (cn *ColumnNames) suffixed(name string, number int) string {
+	suffix := fmt.Sprintf(cn.collisionSuffix, number)
+	if cn.maxLength > 0 && len(name)+len(suffix) > cn.maxLength {
+		name = truncateName(name, cn.maxLength-len(suffix))
+	}
+
+	return name + suffix
+}
+
+//return the longest prefix of name which fits max bytes and doesn't split multibyte characters
+func truncateName(name string, max int) string {
+	for i := range name {
+		if _, size := utf8.DecodeRuneInString(name[i:]); i+size > max {
+			return name[:i]
+		}
+	}
+
+	return name
+}
@@ -0,0 +1,194 @@
+package schema
+
+import (
+	"github.com/ksensehq/eventnative/test"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewColumnNames(t *testing.T) {
+	empty := ""
+	dash := "-"
+	tests := []struct {
+		name        string
+		config      *ColumnNamesConfig
+		expectedErr string
+	}{
+		{
+			"not configured",
+			nil,
+			"",
+		},
+		{
+			"unknown case",
+			&ColumnNamesConfig{Case: "upper"},
+			"Unknown column_names.case value: upper. Supported: lower, keep",
+		},
+		{
+			"negative max length",
+			&ColumnNamesConfig{MaxLength: -1},
+			"column_names.max_length can't be negative",
+		},
+		{
+			"malformed allowed characters",
+			&ColumnNamesConfig{AllowedCharacters: "z-a"},
+			"Malformed column_names.allowed_characters [z-a]: error parsing regexp: invalid character class range: `z-a`",
+		},
+		{
+			"not allowed replacement",
+			&ColumnNamesConfig{AllowedCharacters: "a-z_", Replacement: &dash},
+			"column_names.replacement [-] contains not allowed characters",
+		},
+		{
+			"suffix without number",
+			&ColumnNamesConfig{CollisionSuffix: "_dup"},
+			"column_names.collision_suffix [_dup] must contain %d placeholder of collision number once",
+		},
+		{
+			"suffix with other verbs",
+			&ColumnNamesConfig{CollisionSuffix: "_%s%d"},
+			"column_names.collision_suffix [_%s%d] must contain %d placeholder of collision number once",
+		},
+		{
+			"suffix longer than max length",
+			&ColumnNamesConfig{MaxLength: 3, CollisionSuffix: "__%d"},
+			"column_names.collision_suffix [__%d] must be shorter than column_names.max_length",
+		},
+		{
+			"not allowed reserved suffix",
+			&ColumnNamesConfig{AllowedCharacters: "a-z_", ReservedSuffix: "$"},
+			"column_names.reserved_suffix [$] contains not allowed characters",
+		},
+		{
+			"reserved suffix longer than max length",
+			&ColumnNamesConfig{MaxLength: 3, ReservedSuffix: "_col"},
+			"column_names.reserved_suffix [_col] must be shorter than column_names.max_length",
+		},
+		{
+			"ok",
+			&ColumnNamesConfig{Case: KeepCase, AllowedCharacters: "a-zA-Z0-9_", Replacement: &empty, MaxLength: 63, CollisionSuffix: "_dup%d"},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewColumnNames(tt.config, nil)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestColumnNamesSanitize(t *testing.T) {
+	empty := ""
+	tests := []struct {
+		name     string
+		config   *ColumnNamesConfig
+		input    map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			"nothing to sanitize",
+			&ColumnNamesConfig{AllowedCharacters: "a-z0-9_", MaxLength: 10},
+			map[string]interface{}{"field_1": 1, "field_2": 2},
+			map[string]interface{}{"field_1": 1, "field_2": 2},
+		},
+		{
+			"replaced characters",
+			&ColumnNamesConfig{AllowedCharacters: "a-z0-9_"},
+			map[string]interface{}{"page-url": 1, "utm.source": 2, "заголовок": 3},
+			map[string]interface{}{"page_url": 1, "utm_source": 2, "_________": 3},
+		},
+		{
+			"removed characters",
+			&ColumnNamesConfig{AllowedCharacters: "a-z0-9_", Replacement: &empty},
+			map[string]interface{}{"page-url": 1, "$$": 2},
+			map[string]interface{}{"pageurl": 1, "_": 2},
+		},
+		{
+			"truncated names",
+			&ColumnNamesConfig{MaxLength: 5},
+			map[string]interface{}{"abcdefgh": 1, "abc": 2, "ёёё": 3},
+			map[string]interface{}{"abcde": 1, "abc": 2, "ёё": 3},
+		},
+		{
+			"collisions",
+			&ColumnNamesConfig{AllowedCharacters: "a-z0-9_"},
+			map[string]interface{}{"user-id": 1, "user_id": 2, "user.id": 3, "user_id_2": 4},
+			map[string]interface{}{"user_id": 2, "user_id_2": 4, "user_id_3": 1, "user_id_4": 3},
+		},
+		{
+			"collisions without unchanged name",
+			&ColumnNamesConfig{AllowedCharacters: "a-z0-9_", CollisionSuffix: "__%d"},
+			map[string]interface{}{"user-id": 1, "user.id": 3},
+			map[string]interface{}{"user_id": 1, "user_id__2": 3},
+		},
+		{
+			"collisions of truncated names",
+			&ColumnNamesConfig{MaxLength: 8},
+			map[string]interface{}{"property_1": 1, "property_2": 2},
+			map[string]interface{}{"property": 1, "proper_2": 2},
+		},
+		{
+			"reserved words",
+			&ColumnNamesConfig{ReservedWords: []string{"ORDER", "select", "user"}, ReservedSuffix: "_"},
+			map[string]interface{}{"order": 1, "Select": 2, "users": 3, "user": 4, "user_": 5},
+			map[string]interface{}{"order_": 1, "Select_": 2, "users": 3, "user_": 5, "user__2": 4},
+		},
+		{
+			"reserved words are quoted without suffix",
+			&ColumnNamesConfig{ReservedWords: []string{"order"}},
+			map[string]interface{}{"order": 1},
+			map[string]interface{}{"order": 1},
+		},
+		{
+			"truncated reserved words",
+			&ColumnNamesConfig{MaxLength: 6, ReservedWords: []string{"select"}, ReservedSuffix: "_c"},
+			map[string]interface{}{"select": 1, "selected": 2},
+			map[string]interface{}{"sele_c": 1, "sele_2": 2},
+		},
+		{
+			"protected names",
+			&ColumnNamesConfig{AllowedCharacters: "a-z_", MaxLength: 5},
+			map[string]interface{}{"_timestamp": 1, "eventn_ctx_event_id": 2, "field1": 3},
+			map[string]interface{}{"_timestamp": 1, "eventn_ctx_event_id": 2, "field": 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columnNames, err := NewColumnNames(tt.config, systemColumnNames)
+			require.NoError(t, err)
+			test.ObjectsEqual(t, tt.expected, columnNames.Sanitize(tt.input), "Wrong sanitized object")
+		})
+	}
+}
+
+func TestFlattenObjectKeepCase(t *testing.T) {
+	columnNames, err := NewColumnNames(&ColumnNamesConfig{Case: KeepCase, AllowedCharacters: "a-zA-Z0-9_"}, nil)
+	require.NoError(t, err)
+	flattener := NewFlattener(nil, false, map[string]bool{"props": true}, columnNames, nil)
+
+	actual, err := flattener.FlattenObject(map[string]interface{}{
+		"userId": "u1",
+		"Page":   map[string]interface{}{"URL": "/", "Referrer-Host": "google.com"},
+		"Props":  map[string]interface{}{"A": 1},
+	})
+	require.NoError(t, err)
+	test.ObjectsEqual(t, map[string]interface{}{"userId": "u1", "Page_URL": "/", "Page_Referrer_Host": "google.com", "Props": `{"A":1}`}, actual, "Wrong flattened object")
+}
+
+func TestProcessFactColumnNames(t *testing.T) {
+	p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: "events", ColumnNames: &ColumnNamesConfig{AllowedCharacters: "a-z0-9_", MaxLength: 12}})
+	require.NoError(t, err)
+
+	table, object, err := p.ProcessFact(map[string]interface{}{"_timestamp": "2020-10-10T10:10:10.000000Z", "eventn_ctx": map[string]interface{}{"event_id": "e1"},
+		"page-url": "/", "utm_campaign_name": "spring"})
+	require.NoError(t, err)
+	require.Equal(t, "e1", object[EventIDColumn], "system columns aren't sanitized")
+	require.Equal(t, "/", object["page_url"])
+	require.Equal(t, "spring", object["utm_campaign"])
+	require.Contains(t, table.Columns, "utm_campaign")
+}
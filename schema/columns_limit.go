@@ -0,0 +1,124 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/typing"
+	"sort"
+)
+
+//UnmappedColumn is a catch-all JSON column of fields which don't fit into max_columns limit
+const UnmappedColumn = "_unmapped"
+
+//ColumnsLimit protects tables from endless ALTERs caused by events with unbounded custom keys (data_layout.max_columns):
+//when adding fields would make table wider than max columns, fields which don't fit are written into _unmapped JSON column
+//as {"field": value} object. Existing columns are written as is. New fields fit in alphabetical order, protected columns
+//(system columns, upsert and deletion keys) are never moved
+type ColumnsLimit struct {
+	max       int
+	protected map[string]bool
+}
+
+//NewColumnsLimit return ColumnsLimit or nil if max is 0 (tables aren't limited)
+func NewColumnsLimit(max int, protected []string) (*ColumnsLimit, error) {
+	if max < 0 {
+		return nil, errors.New("data_layout.max_columns can't be negative")
+	}
+	if max == 0 {
+		return nil, nil
+	}
+
+	protectedColumns := map[string]bool{}
+	for _, column := range protected {
+		protectedColumns[column] = true
+	}
+
+	return &ColumnsLimit{max: max, protected: protectedColumns}, nil
+}
+
+//Fit move fields which don't fit into the limit of dbSchema table from dataSchema and objects into _unmapped column
+//return names of moved fields sorted
+func (cl *ColumnsLimit) Fit(dbSchema, dataSchema *Table, objects []map[string]interface{}) ([]string, error) {
+	protected := map[string]bool{}
+	for _, key := range dataSchema.UpsertKeys {
+		protected[key] = true
+	}
+	for _, key := range dataSchema.DeletionKeys {
+		protected[key] = true
+	}
+
+	var newColumns []string
+	required := 0
+	for name := range dataSchema.Columns {
+		if _, ok := dbSchema.Columns[name]; ok || name == UnmappedColumn {
+			continue
+		}
+		if cl.protected[name] || protected[name] {
+			required++
+			continue
+		}
+		newColumns = append(newColumns, name)
+	}
+
+	free := cl.max - len(dbSchema.Columns) - required
+	if len(newColumns) <= free {
+		return nil, nil
+	}
+
+	//_unmapped column takes one of free columns
+	if _, ok := dbSchema.Columns[UnmappedColumn]; !ok {
+		free--
+	}
+	if free < 0 {
+		free = 0
+	}
+	sort.Strings(newColumns)
+	moved := newColumns[free:]
+
+	for _, object := range objects {
+		if err := moveUnmapped(object, moved); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range moved {
+		delete(dataSchema.Columns, name)
+	}
+	dataSchema.Columns[UnmappedColumn] = NewColumn(typing.JSON)
+
+	return moved, nil
+}
+
+//merge fields values into object _unmapped JSON object
+func moveUnmapped(object map[string]interface{}, fields []string) error {
+	unmapped := map[string]interface{}{}
+	for _, field := range fields {
+		if value, ok := object[field]; ok {
+			unmapped[field] = value
+			delete(object, field)
+		}
+	}
+	if len(unmapped) == 0 {
+		return nil
+	}
+
+	//object can be fitted several times (e.g. after string fallback columns are added)
+	if current, ok := object[UnmappedColumn].(string); ok {
+		existing := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(current), &existing); err == nil {
+			for field, value := range existing {
+				if _, ok := unmapped[field]; !ok {
+					unmapped[field] = value
+				}
+			}
+		}
+	}
+
+	b, err := json.Marshal(unmapped)
+	if err != nil {
+		return fmt.Errorf("Error marshalling %s column: %v", UnmappedColumn, err)
+	}
+	object[UnmappedColumn] = string(b)
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewColumnsLimit(t *testing.T) {
+	columnsLimit, err := NewColumnsLimit(0, nil)
+	require.NoError(t, err)
+	require.Nil(t, columnsLimit, "tables aren't limited")
+
+	_, err = NewColumnsLimit(-1, nil)
+	require.EqualError(t, err, "data_layout.max_columns can't be negative")
+}
+
+func TestColumnsLimitFit(t *testing.T) {
+	columnsLimit, err := NewColumnsLimit(4, []string{"_timestamp"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		dbColumns       Columns
+		upsertKeys      []string
+		object          map[string]interface{}
+		expectedMoved   []string
+		expectedColumns []string
+		expectedObject  map[string]interface{}
+	}{
+		{
+			"fields fit",
+			Columns{},
+			nil,
+			map[string]interface{}{"_timestamp": 1, "a": 1, "b": 2},
+			nil,
+			[]string{"_timestamp", "a", "b"},
+			map[string]interface{}{"_timestamp": 1, "a": 1, "b": 2},
+		},
+		{
+			"new table",
+			Columns{},
+			nil,
+			map[string]interface{}{"_timestamp": 1, "d": 4, "c": 3, "b": 2, "a": 1},
+			[]string{"c", "d"},
+			[]string{"_timestamp", "_unmapped", "a", "b"},
+			map[string]interface{}{"_timestamp": 1, "a": 1, "b": 2, "_unmapped": `{"c":3,"d":4}`},
+		},
+		{
+			"existing columns are written as is",
+			Columns{"_timestamp": NewColumn(typing.TIMESTAMP), "_unmapped": NewColumn(typing.JSON), "x": NewColumn(typing.INT64)},
+			nil,
+			map[string]interface{}{"_timestamp": 1, "x": 1, "b": 2, "a": 1},
+			[]string{"b"},
+			[]string{"_timestamp", "_unmapped", "a", "x"},
+			map[string]interface{}{"_timestamp": 1, "x": 1, "a": 1, "_unmapped": `{"b":2}`},
+		},
+		{
+			"protected and upsert keys aren't moved",
+			Columns{"x": NewColumn(typing.INT64), "y": NewColumn(typing.INT64), "_unmapped": NewColumn(typing.JSON)},
+			[]string{"id"},
+			map[string]interface{}{"_timestamp": 1, "id": 1, "a": 1},
+			[]string{"a"},
+			[]string{"_timestamp", "_unmapped", "id"},
+			map[string]interface{}{"_timestamp": 1, "id": 1, "_unmapped": `{"a":1}`},
+		},
+		{
+			"merge into existing unmapped value",
+			Columns{"_timestamp": NewColumn(typing.TIMESTAMP), "_unmapped": NewColumn(typing.JSON), "x": NewColumn(typing.INT64), "y": NewColumn(typing.INT64)},
+			nil,
+			map[string]interface{}{"_timestamp": 1, "a": 1, "_unmapped": `{"b":2}`},
+			[]string{"a"},
+			[]string{"_timestamp", "_unmapped"},
+			map[string]interface{}{"_timestamp": 1, "_unmapped": `{"a":1,"b":2}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataSchema := &Table{Name: "events", Columns: Columns{}, UpsertKeys: tt.upsertKeys}
+			for name := range tt.object {
+				dataSchema.Columns[name] = NewColumn(typing.INT64)
+			}
+			if _, ok := tt.object[UnmappedColumn]; ok {
+				dataSchema.Columns[UnmappedColumn] = NewColumn(typing.JSON)
+			}
+
+			moved, err := columnsLimit.Fit(&Table{Name: "events", Columns: tt.dbColumns}, dataSchema, []map[string]interface{}{tt.object})
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedMoved, moved)
+			require.Equal(t, tt.expectedObject, tt.object)
+
+			var columns []string
+			for name := range dataSchema.Columns {
+				columns = append(columns, name)
+			}
+			require.ElementsMatch(t, tt.expectedColumns, columns)
+			if len(tt.expectedMoved) > 0 {
+				require.Equal(t, typing.JSON, dataSchema.Columns[UnmappedColumn].GetType())
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package schema
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/typing"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultDecimalPrecision = 38
+	defaultDecimalScale     = 9
+	maxDecimalPrecision     = 76
+)
+
+var (
+	decimalTypeExpression = regexp.MustCompile(`^\s*(?i:decimal|numeric|number)\s*\(\s*(\d+)\s*,\s*(\d+)\s*\)\s*$`)
+
+	//field name parts of monetary values e.g. order_total, price_usd, shipping_fee
+	monetaryNameParts = map[string]bool{"price": true, "amount": true, "revenue": true, "cost": true, "total": true, "subtotal": true,
+		"tax": true, "discount": true, "fee": true, "fees": true, "shipping": true, "refund": true, "balance": true, "payment": true,
+		"spend": true, "spent": true, "income": true, "profit": true, "salary": true, "budget": true}
+	//field name parts of not monetary values e.g. total_items, discount_percent, payment_id
+	notMonetaryNameParts = map[string]bool{"id": true, "count": true, "qty": true, "quantity": true, "items": true, "currency": true,
+		"percent": true, "percentage": true, "pct": true, "rate": true, "ratio": true, "type": true, "code": true, "name": true,
+		"status": true, "method": true, "time": true, "duration": true, "ms": true}
+)
+
+//DecimalType is precision (total number of digits) and scale (number of digits after the decimal point) of DECIMAL column
+type DecimalType struct {
+	Precision int
+	Scale     int
+}
+
+//ParseDecimalType return DecimalType of decimal(p,s), numeric(p,s) or number(p,s) type string (case insensitive)
+//return false if the type string isn't a decimal one with precision and scale
+func ParseDecimalType(columnType string) (*DecimalType, bool) {
+	match := decimalTypeExpression.FindStringSubmatch(columnType)
+	if match == nil {
+		return nil, false
+	}
+	precision, _ := strconv.Atoi(match[1])
+	scale, _ := strconv.Atoi(match[2])
+
+	return &DecimalType{Precision: precision, Scale: scale}, true
+}
+
+//Validate precision and scale values
+func (dt *DecimalType) Validate() error {
+	if dt.Precision < 1 || dt.Precision > maxDecimalPrecision {
+		return fmt.Errorf("decimal precision must be in range [1, %d]", maxDecimalPrecision)
+	}
+	if dt.Scale < 0 || dt.Scale > dt.Precision {
+		return fmt.Errorf("decimal scale must be in range [0, precision]")
+	}
+
+	return nil
+}
+
+func (dt *DecimalType) String() string {
+	return fmt.Sprintf("decimal(%d,%d)", dt.Precision, dt.Scale)
+}
+
+//DecimalsConfig dto for deserialized data_layout.decimals config
+//detect: monetary-looking numeric fields (e.g. price, order_total, shipping_fee) are written into DECIMAL columns
+//precision and scale: DECIMAL columns of detected fields and of fields typed as decimal without parameters (default: 38, 9)
+type DecimalsConfig struct {
+	Detect    bool `mapstructure:"detect"`
+	Precision int  `mapstructure:"precision"`
+	Scale     int  `mapstructure:"scale"`
+}
+
+//Decimals decides which fields are written into DECIMAL columns and their precision and scale:
+//fields typed as decimal(p,s) in data_layout.types (or mapping casts) and detected monetary fields (if detect is enabled)
+type Decimals struct {
+	detect      bool
+	defaultType *DecimalType
+	//flattened field name: explicit type
+	fields map[string]*DecimalType
+}
+
+//NewDecimals return Decimals with explicit decimal types from data_layout.types config: JSON path -> type
+//return err if precision or scale is invalid
+func NewDecimals(config *DecimalsConfig, typesConfig map[string]string) (*Decimals, error) {
+	decimals := &Decimals{defaultType: &DecimalType{Precision: defaultDecimalPrecision, Scale: defaultDecimalScale}, fields: map[string]*DecimalType{}}
+	if config != nil {
+		decimals.detect = config.Detect
+		if config.Precision > 0 {
+			decimals.defaultType.Precision = config.Precision
+		}
+		if config.Scale > 0 {
+			decimals.defaultType.Scale = config.Scale
+		}
+		if err := decimals.defaultType.Validate(); err != nil {
+			return nil, fmt.Errorf("Malformed data_layout.decimals: %v", err)
+		}
+	}
+
+	for path, typeName := range typesConfig {
+		decimalType, ok := ParseDecimalType(typeName)
+		if !ok {
+			continue
+		}
+		if err := decimalType.Validate(); err != nil {
+			return nil, fmt.Errorf("Malformed data_layout.types [%s] type: %v", path, err)
+		}
+		//flattened keys are lower case
+		decimals.fields[strings.ToLower(strings.Join(splitPath(path), "_"))] = decimalType
+	}
+
+	return decimals, nil
+}
+
+//DefaultType return precision and scale of DECIMAL columns without explicit ones
+func (d *Decimals) DefaultType() *DecimalType {
+	return d.defaultType
+}
+
+//Type return DecimalType of the field column or nil if the field isn't a decimal one
+//typed is true if the field has an explicit type (detection doesn't override explicit types)
+func (d *Decimals) Type(field string, dataType typing.DataType, typed bool) *DecimalType {
+	if typed {
+		if dataType != typing.DECIMAL {
+			return nil
+		}
+		if decimalType, ok := d.fields[field]; ok {
+			return decimalType
+		}
+		return d.defaultType
+	}
+
+	if d.detect && (dataType == typing.INT64 || dataType == typing.FLOAT64) && monetaryField(field) {
+		return d.defaultType
+	}
+
+	return nil
+}
+
+//return true if flattened field name has a monetary part and doesn't have not monetary ones
+func monetaryField(field string) bool {
+	monetary := false
+	for _, part := range strings.Split(strings.ToLower(field), "_") {
+		if notMonetaryNameParts[part] {
+			return false
+		}
+		if monetaryNameParts[part] {
+			monetary = true
+		}
+	}
+
+	return monetary
+}
@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseDecimalType(t *testing.T) {
+	tests := []struct {
+		columnType string
+		expected   *DecimalType
+	}{
+		{"decimal(18,2)", &DecimalType{Precision: 18, Scale: 2}},
+		{"Decimal(38, 9)", &DecimalType{Precision: 38, Scale: 9}},
+		{"numeric(10,0)", &DecimalType{Precision: 10, Scale: 0}},
+		{"NUMBER(38,4)", &DecimalType{Precision: 38, Scale: 4}},
+		{"decimal", nil},
+		{"numeric(10)", nil},
+		{"double precision", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.columnType, func(t *testing.T) {
+			actual, ok := ParseDecimalType(tt.columnType)
+			require.Equal(t, tt.expected != nil, ok)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestNewDecimals(t *testing.T) {
+	_, err := NewDecimals(&DecimalsConfig{Precision: 10, Scale: 12}, nil)
+	require.EqualError(t, err, "Malformed data_layout.decimals: decimal scale must be in range [0, precision]")
+
+	_, err = NewDecimals(nil, map[string]string{"/total": "decimal(100,2)"})
+	require.EqualError(t, err, "Malformed data_layout.types [/total] type: decimal precision must be in range [1, 76]")
+
+	decimals, err := NewDecimals(nil, map[string]string{"/Order/Total": "decimal(18,2)", "/price": "decimal", "/id": "string"})
+	require.NoError(t, err)
+	require.Equal(t, &DecimalType{Precision: 38, Scale: 9}, decimals.DefaultType())
+	require.Equal(t, &DecimalType{Precision: 18, Scale: 2}, decimals.Type("order_total", typing.DECIMAL, true))
+	require.Equal(t, &DecimalType{Precision: 38, Scale: 9}, decimals.Type("price", typing.DECIMAL, true))
+	require.Nil(t, decimals.Type("amount", typing.FLOAT64, false), "detection is disabled")
+}
+
+func TestDecimalsDetection(t *testing.T) {
+	decimals, err := NewDecimals(&DecimalsConfig{Detect: true, Precision: 18, Scale: 4}, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		field    string
+		dataType typing.DataType
+		typed    bool
+		decimal  bool
+	}{
+		{"price", typing.FLOAT64, false, true},
+		{"order_total", typing.INT64, false, true},
+		{"ecommerce_shipping_fee", typing.FLOAT64, false, true},
+		{"Revenue_USD", typing.FLOAT64, false, true},
+		{"price", typing.STRING, false, false},
+		{"price", typing.FLOAT64, true, false},
+		{"total_items", typing.INT64, false, false},
+		{"discount_percent", typing.FLOAT64, false, false},
+		{"payment_id", typing.INT64, false, false},
+		{"page_views", typing.INT64, false, false},
+		{"totals", typing.FLOAT64, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			actual := decimals.Type(tt.field, tt.dataType, tt.typed)
+			if tt.decimal {
+				require.Equal(t, &DecimalType{Precision: 18, Scale: 4}, actual)
+			} else {
+				require.Nil(t, actual)
+			}
+		})
+	}
+}
+
+func TestProcessFactDecimals(t *testing.T) {
+	p, err := NewProcessor(&ProcessorConfig{
+		TableNameTemplate: "events",
+		Types:             map[string]string{"/order/total": "decimal(18,2)", "/tax": "double"},
+		Decimals:          &DecimalsConfig{Detect: true},
+	})
+	require.NoError(t, err)
+
+	table, object, err := p.ProcessFact(map[string]interface{}{"_timestamp": "2020-10-10T10:10:10.000000Z", "order": map[string]interface{}{"total": "19.99"},
+		"price": 10.0, "tax": 1.5, "items_count": 2.0})
+	require.NoError(t, err)
+
+	require.Equal(t, typing.DECIMAL, table.Columns["order_total"].GetType())
+	require.Equal(t, &DecimalType{Precision: 18, Scale: 2}, table.Columns["order_total"].Decimal())
+	require.Equal(t, DecimalBounds(18, 2), table.Columns["order_total"].Bounds())
+	require.Equal(t, 19.99, object["order_total"])
+	require.Equal(t, &DecimalType{Precision: 38, Scale: 9}, table.Columns["price"].Decimal())
+	require.Equal(t, typing.FLOAT64, table.Columns["tax"].GetType(), "explicit types aren't overridden")
+	require.Equal(t, typing.INT64, table.Columns["items_count"].GetType())
+
+	_, _, err = p.ProcessFact(map[string]interface{}{"_timestamp": "2020-10-10T10:10:10.000000Z", "order": map[string]interface{}{"total": "N/A"}})
+	require.EqualError(t, err, "Error converting field [order_total] to [decimal]: Error stringToDecimal() for value: N/A: not a decimal number")
+}
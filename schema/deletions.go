@@ -0,0 +1,152 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const (
+	DeleteMode = "delete"
+	TableMode  = "table"
+
+	defaultDeletionsField = "event_type"
+	defaultDeletionsTable = "deletions"
+
+	//processed files of deletion events are put into ProcessFilePayload result with prefixed key
+	deletionsFileKeyPrefix = "__deletions__"
+)
+
+//DeletionsConfig dto for deserialized data_layout.deletions config item
+//Events with field == event_type are deletion events which reference rows of table by keys:
+//delete mode: rows with the same keys values are deleted from table (or tombstones are published)
+//table mode: deletion events are written into deletions_table as is (for downstream processing)
+type DeletionsConfig struct {
+	Field          string   `mapstructure:"field"`
+	EventType      string   `mapstructure:"event_type"`
+	Table          string   `mapstructure:"table"`
+	Keys           []string `mapstructure:"keys"`
+	Mode           string   `mapstructure:"mode"`
+	DeletionsTable string   `mapstructure:"deletions_table"`
+}
+
+//Validate required fields in DeletionsConfig and set default values
+func (dc *DeletionsConfig) Validate() error {
+	if dc == nil {
+		return errors.New("deletions config item can't be empty")
+	}
+	if dc.EventType == "" {
+		return errors.New("deletions event_type is required parameter")
+	}
+	if dc.Table == "" {
+		return fmt.Errorf("deletions table is required parameter for event_type [%s]", dc.EventType)
+	}
+	if len(dc.Keys) == 0 {
+		return fmt.Errorf("deletions keys are required parameter for event_type [%s]", dc.EventType)
+	}
+	for _, key := range dc.Keys {
+		if key == "" {
+			return fmt.Errorf("deletions key can't be empty in event_type [%s] keys", dc.EventType)
+		}
+	}
+
+	if dc.Field == "" {
+		dc.Field = defaultDeletionsField
+	}
+	switch dc.Mode {
+	case "":
+		dc.Mode = DeleteMode
+	case DeleteMode:
+	case TableMode:
+		if dc.DeletionsTable == "" {
+			dc.DeletionsTable = defaultDeletionsTable
+		}
+	default:
+		return fmt.Errorf("Unknown deletions mode: %s. Available modes: [%s, %s]", dc.Mode, DeleteMode, TableMode)
+	}
+
+	return nil
+}
+
+//Deletions recognizes deletion events by configured field values
+type Deletions struct {
+	configs []*DeletionsConfig
+}
+
+//NewDeletions return Deletions or nil if configs are empty
+//return err if config is invalid or event_type is configured twice
+func NewDeletions(configs []*DeletionsConfig) (*Deletions, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+
+	eventTypes := map[string]bool{}
+	for _, config := range configs {
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+		key := config.Field + "=" + config.EventType
+		if eventTypes[key] {
+			return nil, fmt.Errorf("deletions event_type [%s] is configured more than once", config.EventType)
+		}
+		eventTypes[key] = true
+	}
+
+	return &Deletions{configs: configs}, nil
+}
+
+//Match return deletion config of the flatten object or nil if it isn't a deletion event
+func (d *Deletions) Match(object map[string]interface{}) *DeletionsConfig {
+	for _, config := range d.configs {
+		if value, ok := object[config.Field]; ok && value == config.EventType {
+			return config
+		}
+	}
+
+	return nil
+}
+
+//Apply return table and object of deletion event:
+//delete mode: table with DeletionKeys and object with only keys values
+//table mode: deletions_table and object as is
+//return err if object doesn't have any key value
+func (d *Deletions) Apply(config *DeletionsConfig, object map[string]interface{}) (*Table, map[string]interface{}, error) {
+	keysObject := map[string]interface{}{}
+	for _, key := range config.Keys {
+		value, ok := object[key]
+		if !ok || value == nil {
+			return nil, nil, fmt.Errorf("Deletion key field [%s] of table [%s] doesn't exist or is null", key, config.Table)
+		}
+		keysObject[key] = value
+	}
+
+	if config.Mode == TableMode {
+		return &Table{Name: config.DeletionsTable, Columns: Columns{}}, object, nil
+	}
+
+	return &Table{Name: config.Table, Columns: Columns{}, DeletionKeys: config.Keys}, keysObject, nil
+}
+
+//ExtractDeletions remove processed files of deletion events (delete mode) from files and return them sorted by table name
+//they should be applied after other files of the same payload
+func ExtractDeletions(files map[string]*ProcessedFile) []*ProcessedFile {
+	var deletions []*ProcessedFile
+	for key, file := range files {
+		if len(file.DataSchema.DeletionKeys) > 0 {
+			deletions = append(deletions, file)
+			delete(files, key)
+		}
+	}
+	sort.Slice(deletions, func(i, j int) bool { return deletions[i].DataSchema.Name < deletions[j].DataSchema.Name })
+
+	return deletions
+}
+
+//return ProcessFilePayload result key of table file
+func fileKey(table *Table) string {
+	if len(table.DeletionKeys) > 0 {
+		return deletionsFileKeyPrefix + table.Name
+	}
+
+	return table.Name
+}
@@ -0,0 +1,131 @@
+package schema
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestProcessFactDeletions(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputObject    map[string]interface{}
+		expectedTable  string
+		expectedKeys   []string
+		expectedObject map[string]interface{}
+		expectedErr    string
+	}{
+		{
+			"Deletion event",
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "user_deleted", "user": map[string]interface{}{"id": "u1"}},
+			"identify",
+			[]string{"user_id"},
+			map[string]interface{}{"user_id": "u1"},
+			"",
+		},
+		{
+			"Deletion event in table mode",
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "action": "erase", "user": map[string]interface{}{"id": "u1"}},
+			"erasures",
+			nil,
+			nil,
+			"",
+		},
+		{
+			"Not deletion event",
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "pageview"},
+			"pageview",
+			nil,
+			nil,
+			"",
+		},
+		{
+			"Deletion event without key",
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "user_deleted"},
+			"",
+			nil,
+			nil,
+			"Deletion key field [user_id] of table [identify] doesn't exist or is null",
+		},
+	}
+	p, err := NewProcessor(&ProcessorConfig{
+		TableNameTemplate: "{{.event_type}}",
+		Deletions: []*DeletionsConfig{
+			{EventType: "user_deleted", Table: "identify", Keys: []string{"user_id"}},
+			{Field: "action", EventType: "erase", Table: "identify", Keys: []string{"user_id"}, Mode: TableMode, DeletionsTable: "erasures"},
+		},
+	})
+	require.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, object, err := p.ProcessFact(tt.inputObject)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedTable, table.Name)
+			require.Equal(t, tt.expectedKeys, table.DeletionKeys)
+			if tt.expectedObject != nil {
+				require.Equal(t, tt.expectedObject, object)
+			}
+		})
+	}
+}
+
+func TestProcessFilePayloadDeletions(t *testing.T) {
+	payload := []byte(`{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "identify", "user_id": "u1"}
+{"_timestamp": "2020-08-02T18:24:59.757719Z", "event_type": "user_deleted", "user_id": "u1"}
+{"_timestamp": "2020-08-02T18:25:59.757719Z", "event_type": "user_deleted", "user_id": "u2"}
+`)
+	p, err := NewProcessor(&ProcessorConfig{
+		TableNameTemplate: "{{.event_type}}",
+		Deletions:         []*DeletionsConfig{{EventType: "user_deleted", Table: "identify", Keys: []string{"user_id"}}},
+	})
+	require.NoError(t, err)
+
+	files, err := p.ProcessFilePayload("testfile", payload, true, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(files))
+
+	deletions := ExtractDeletions(files)
+	require.Equal(t, 1, len(files))
+	require.Equal(t, 1, len(files["identify"].GetPayload()))
+	require.Equal(t, 1, len(deletions))
+	require.Equal(t, "identify", deletions[0].DataSchema.Name)
+	require.Equal(t, []string{"user_id"}, deletions[0].DataSchema.DeletionKeys)
+	require.Equal(t, []map[string]interface{}{{"user_id": "u1"}, {"user_id": "u2"}}, deletions[0].GetPayload())
+}
+
+func TestNewDeletions(t *testing.T) {
+	tests := []struct {
+		name        string
+		configs     []*DeletionsConfig
+		expectedErr string
+	}{
+		{"Valid config", []*DeletionsConfig{{EventType: "user_deleted", Table: "identify", Keys: []string{"user_id"}}}, ""},
+		{"Without event type", []*DeletionsConfig{{Table: "identify", Keys: []string{"user_id"}}}, "deletions event_type is required parameter"},
+		{"Without keys", []*DeletionsConfig{{EventType: "user_deleted", Table: "identify"}}, "deletions keys are required parameter for event_type [user_deleted]"},
+		{
+			"Unknown mode",
+			[]*DeletionsConfig{{EventType: "user_deleted", Table: "identify", Keys: []string{"user_id"}, Mode: "truncate"}},
+			"Unknown deletions mode: truncate. Available modes: [delete, table]",
+		},
+		{
+			"Duplicated event type",
+			[]*DeletionsConfig{{EventType: "user_deleted", Table: "identify", Keys: []string{"user_id"}}, {EventType: "user_deleted", Table: "users", Keys: []string{"id"}}},
+			"deletions event_type [user_deleted] is configured more than once",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewDeletions(tt.configs)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package schema
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/typing"
+	"math"
+	"time"
+)
+
+//EngineColumns are service columns of table engine (e.g. ClickHouse MergeTree family) which Processor adds into every table object:
+//version: column with row version. Rows with max version win on merges. Current time in unix nanoseconds if the object doesn't have a value
+//sign: column with row sign. Rows with opposite signs are collapsed on merges. 1 if the object doesn't have a value, -1 cancels the row
+type EngineColumns struct {
+	Version string
+	Sign    string
+}
+
+//Apply put version and sign values into object and their columns into table
+//return err if object has a value which can't be used as version or sign
+func (ec *EngineColumns) Apply(table *Table, object map[string]interface{}) error {
+	if ec.Version != "" {
+		version, err := engineColumnValue(object, ec.Version, time.Now().UTC().UnixNano())
+		if err != nil {
+			return fmt.Errorf("Version field [%s] of table [%s] must be a number: %v", ec.Version, table.Name, err)
+		}
+		if version < 0 {
+			return fmt.Errorf("Version field [%s] of table [%s] can't be negative", ec.Version, table.Name)
+		}
+		object[ec.Version] = version
+		table.Columns[ec.Version] = NewColumn(typing.INT64)
+	}
+
+	if ec.Sign != "" {
+		sign, err := engineColumnValue(object, ec.Sign, 1)
+		if err != nil || sign != 1 && sign != -1 {
+			return fmt.Errorf("Sign field [%s] of table [%s] must be 1 or -1", ec.Sign, table.Name)
+		}
+		object[ec.Sign] = sign
+		table.Columns[ec.Sign] = NewColumn(typing.INT64)
+	}
+
+	return nil
+}
+
+//return object field value converted into int64 or defaultValue if the object doesn't have the field or it is null
+func engineColumnValue(object map[string]interface{}, field string, defaultValue int64) (int64, error) {
+	value, ok := object[field]
+	if !ok || value == nil {
+		return defaultValue, nil
+	}
+
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float64:
+		if math.Trunc(v) != v {
+			return 0, fmt.Errorf("%v isn't an integer", v)
+		}
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("%v isn't a number", v)
+	}
+}
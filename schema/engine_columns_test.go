@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestProcessFactEngineColumns(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputObject    map[string]interface{}
+		expectedFields map[string]interface{}
+		expectedErr    string
+	}{
+		{
+			"Default sign",
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "balances"},
+			map[string]interface{}{"_sign": int64(1)},
+			"",
+		},
+		{
+			"Cancel sign",
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "balances", "_sign": float64(-1)},
+			map[string]interface{}{"_sign": int64(-1)},
+			"",
+		},
+		{
+			"Wrong sign",
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "balances", "_sign": "-1"},
+			nil,
+			"Sign field [_sign] of table [balances] must be 1 or -1",
+		},
+		{
+			"Provided version",
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "users", "_version": float64(15)},
+			map[string]interface{}{"_version": int64(15)},
+			"",
+		},
+		{
+			"Negative version",
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "users", "_version": float64(-15)},
+			nil,
+			"Version field [_version] of table [users] can't be negative",
+		},
+		{
+			"Table without engine columns",
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "pageview"},
+			map[string]interface{}{"_version": nil, "_sign": nil},
+			"",
+		},
+	}
+	p, err := NewProcessor(&ProcessorConfig{
+		TableNameTemplate: "{{.event_type}}",
+		EngineColumns: map[string]*EngineColumns{
+			"users":    {Version: "_version"},
+			"balances": {Sign: "_sign"},
+		},
+	})
+	require.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, object, err := p.ProcessFact(tt.inputObject)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			for field, value := range tt.expectedFields {
+				require.Equal(t, value, object[field], field)
+				if value != nil {
+					require.Contains(t, table.Columns, field)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessFactDefaultVersion(t *testing.T) {
+	p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: "users", EngineColumns: map[string]*EngineColumns{"users": {Version: "_version"}}})
+	require.NoError(t, err)
+
+	_, first, err := p.ProcessFact(map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z"})
+	require.NoError(t, err)
+	_, second, err := p.ProcessFact(map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z"})
+	require.NoError(t, err)
+	require.True(t, second["_version"].(int64) >= first["_version"].(int64))
+}
@@ -0,0 +1,157 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//ExistingTablesConfig dto for deserialized data_layout.existing_tables config
+//enabled: write into tables which are created outside EventNative. Tables schemas are read from the destination,
+//tables aren't created or altered and fields without matching columns are skipped
+//columns: flattened field -> existing column for fields which don't match columns names e.g. eventn_ctx_user_id: customer_id
+type ExistingTablesConfig struct {
+	Enabled bool              `mapstructure:"enabled"`
+	Columns map[string]string `mapstructure:"columns"`
+}
+
+//ExistingTables maps flattened objects fields onto columns of existing tables:
+//1. explicitly configured column (field is skipped if the table doesn't have it)
+//2. column with the same name
+//3. column with the same name in another case (e.g. USER_ID for user_id field)
+//fields without matching columns are skipped
+type ExistingTables struct {
+	columns map[string]string
+}
+
+//NewExistingTables return ExistingTables or nil if compatibility mode isn't enabled
+//return err if config has empty field or column or the same column twice
+func NewExistingTables(config *ExistingTablesConfig) (*ExistingTables, error) {
+	if config == nil {
+		return nil, nil
+	}
+	if !config.Enabled {
+		if len(config.Columns) > 0 {
+			return nil, errors.New("data_layout.existing_tables: columns require enabled: true")
+		}
+		return nil, nil
+	}
+
+	var fields []string
+	for field := range config.Columns {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	columns := map[string]string{}
+	mapped := map[string]string{}
+	for _, field := range fields {
+		column := config.Columns[field]
+		if field == "" || column == "" {
+			return nil, fmt.Errorf("data_layout.existing_tables: field [%s] and column [%s] can't be empty", field, column)
+		}
+		if another, ok := mapped[column]; ok {
+			return nil, fmt.Errorf("data_layout.existing_tables: [%s] and [%s] can't be written into the same column [%s]", another, field, column)
+		}
+		mapped[column] = field
+		columns[field] = column
+	}
+
+	return &ExistingTables{columns: columns}, nil
+}
+
+//Column return existing table column of the field and false if the table doesn't have matching column
+func (et *ExistingTables) Column(dbSchema *Table, field string) (string, bool) {
+	if column, ok := et.columns[field]; ok {
+		_, exists := dbSchema.Columns[column]
+		return column, exists
+	}
+
+	if _, ok := dbSchema.Columns[field]; ok {
+		return field, true
+	}
+
+	for column := range dbSchema.Columns {
+		if strings.EqualFold(column, field) {
+			return column, true
+		}
+	}
+
+	return "", false
+}
+
+//FitTable replace dataSchema columns with matching existing table ones
+//return skipped fields names sorted
+func (et *ExistingTables) FitTable(dbSchema, dataSchema *Table) []string {
+	var skipped, explicit []string
+	columns := Columns{}
+	for field, column := range dataSchema.Columns {
+		name, ok := et.Column(dbSchema, field)
+		if !ok {
+			skipped = append(skipped, field)
+			continue
+		}
+		if _, ok := et.columns[field]; ok {
+			explicit = append(explicit, field)
+			continue
+		}
+		columns[name] = column
+	}
+	for _, field := range explicit {
+		name, _ := et.Column(dbSchema, field)
+		columns[name] = dataSchema.Columns[field]
+	}
+	dataSchema.Columns = columns
+	sort.Strings(skipped)
+
+	return skipped
+}
+
+//FitObject rename object fields into matching existing table columns and remove fields without them
+//explicitly configured fields win if another field has the same name as the configured column
+//change input object
+func (et *ExistingTables) FitObject(dbSchema *Table, object map[string]interface{}) {
+	fitted := map[string]interface{}{}
+	var explicit []string
+	for field, value := range object {
+		if _, ok := et.columns[field]; ok {
+			explicit = append(explicit, field)
+			continue
+		}
+		if column, ok := et.Column(dbSchema, field); ok {
+			fitted[column] = value
+		}
+	}
+	for _, field := range explicit {
+		if column, ok := et.Column(dbSchema, field); ok {
+			fitted[column] = object[field]
+		}
+	}
+
+	for field := range object {
+		delete(object, field)
+	}
+	for column, value := range fitted {
+		object[column] = value
+	}
+}
+
+//Missing return table with dataSchema columns which don't have matching existing table columns
+//explicitly mapped fields are named as configured columns
+func (et *ExistingTables) Missing(dbSchema, dataSchema *Table) *Table {
+	missing := &Table{Name: dataSchema.Name, Columns: Columns{}, UpsertKeys: dataSchema.UpsertKeys}
+	for field, column := range dataSchema.Columns {
+		if _, ok := et.Column(dbSchema, field); ok {
+			continue
+		}
+
+		name := field
+		if configured, ok := et.columns[field]; ok {
+			name = configured
+		}
+		missing.Columns[name] = column
+	}
+
+	return missing
+}
@@ -0,0 +1,104 @@
+package schema
+
+import (
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewExistingTables(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *ExistingTablesConfig
+		expected    *ExistingTables
+		expectedErr string
+	}{
+		{"Empty config", nil, nil, ""},
+		{"Disabled", &ExistingTablesConfig{}, nil, ""},
+		{"Enabled", &ExistingTablesConfig{Enabled: true}, &ExistingTables{columns: map[string]string{}}, ""},
+		{"Mapped columns", &ExistingTablesConfig{Enabled: true, Columns: map[string]string{"eventn_ctx_user_id": "customer_id"}},
+			&ExistingTables{columns: map[string]string{"eventn_ctx_user_id": "customer_id"}}, ""},
+		{"Columns without enabled", &ExistingTablesConfig{Columns: map[string]string{"eventn_ctx_user_id": "customer_id"}}, nil,
+			"data_layout.existing_tables: columns require enabled: true"},
+		{"Empty column", &ExistingTablesConfig{Enabled: true, Columns: map[string]string{"user_id": ""}}, nil,
+			"data_layout.existing_tables: field [user_id] and column [] can't be empty"},
+		{"Duplicated column", &ExistingTablesConfig{Enabled: true, Columns: map[string]string{"user_id": "customer_id", "uid": "customer_id"}}, nil,
+			"data_layout.existing_tables: [uid] and [user_id] can't be written into the same column [customer_id]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := NewExistingTables(tt.config)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestExistingTablesFit(t *testing.T) {
+	et, err := NewExistingTables(&ExistingTablesConfig{Enabled: true, Columns: map[string]string{
+		"eventn_ctx_user_id": "customer_id",
+		"page_title":         "title",
+	}})
+	require.NoError(t, err)
+
+	dbSchema := &Table{Name: "events", Columns: Columns{
+		"_timestamp":  NewColumn(typing.TIMESTAMP),
+		"customer_id": NewColumn(typing.STRING),
+		"URL":         NewColumn(typing.STRING),
+	}}
+
+	dataSchema := &Table{Name: "events", Columns: Columns{
+		"_timestamp":         NewColumn(typing.TIMESTAMP),
+		"eventn_ctx_user_id": NewColumn(typing.STRING),
+		"customer_id":        NewColumn(typing.INT64),
+		"url":                NewColumn(typing.STRING),
+		"page_title":         NewColumn(typing.STRING),
+		"referer":            NewColumn(typing.STRING),
+	}}
+	require.Equal(t, []string{"page_title", "referer"}, et.FitTable(dbSchema, dataSchema))
+	require.Equal(t, Columns{
+		"_timestamp":  NewColumn(typing.TIMESTAMP),
+		"customer_id": NewColumn(typing.STRING),
+		"URL":         NewColumn(typing.STRING),
+	}, dataSchema.Columns)
+
+	object := map[string]interface{}{
+		"_timestamp":         "2020-12-01T10:00:00.000000Z",
+		"eventn_ctx_user_id": "u1",
+		"customer_id":        1,
+		"url":                "https://site.com",
+		"page_title":         "Home",
+		"referer":            "https://google.com",
+	}
+	et.FitObject(dbSchema, object)
+	require.Equal(t, map[string]interface{}{
+		"_timestamp":  "2020-12-01T10:00:00.000000Z",
+		"customer_id": "u1",
+		"URL":         "https://site.com",
+	}, object)
+}
+
+func TestExistingTablesMissing(t *testing.T) {
+	et, err := NewExistingTables(&ExistingTablesConfig{Enabled: true, Columns: map[string]string{"page_title": "title"}})
+	require.NoError(t, err)
+
+	dbSchema := &Table{Name: "events", Columns: Columns{"_timestamp": NewColumn(typing.TIMESTAMP), "URL": NewColumn(typing.STRING)}}
+	dataSchema := &Table{Name: "events", UpsertKeys: []string{"id"}, Columns: Columns{
+		"_timestamp": NewColumn(typing.TIMESTAMP),
+		"url":        NewColumn(typing.STRING),
+		"page_title": NewColumn(typing.STRING),
+		"id":         NewColumn(typing.INT64),
+	}}
+	require.Equal(t, &Table{Name: "events", UpsertKeys: []string{"id"}, Columns: Columns{
+		"title": NewColumn(typing.STRING),
+		"id":    NewColumn(typing.INT64),
+	}}, et.Missing(dbSchema, dataSchema))
+
+	require.Equal(t, 4, len(et.Missing(&Table{Name: "events", Columns: Columns{}}, dataSchema).Columns))
+	require.False(t, et.Missing(dbSchema, &Table{Name: "events", Columns: Columns{"url": NewColumn(typing.STRING)}}).Exists())
+}
@@ -23,6 +23,9 @@ type MappingRule struct {
 	source []string
 	//[key4, key5]
 	destination []string
+	//constant rule puts value into destination (source is empty)
+	value    interface{}
+	constant bool
 }
 
 //NewFieldMapper return FieldMapper, fields to typecast and err
@@ -75,7 +78,7 @@ func NewFieldMapper(mappings []string) (Mapper, map[string]typing.DataType, erro
 		castType := strings.ReplaceAll(destParts[0], "(", "")
 		dataType, err := typing.TypeFromString(castType)
 		if err != nil {
-			return nil, nil, fmt.Errorf("Malformed cast type in data mapping [%s]: %v. Available types: integer, double, string, timestamp", mapping, err)
+			return nil, nil, fmt.Errorf("Malformed cast type in data mapping [%s]: %v. Available types: integer, double, decimal, string, timestamp", mapping, err)
 		}
 
 		fieldsToCast[formattedDestination] = dataType
@@ -101,6 +104,11 @@ func (fm FieldMapper) Map(object map[string]interface{}) (map[string]interface{}
 	}
 
 	for _, rule := range fm.rules {
+		if rule.constant {
+			putNode(mappedObject, rule.destination, rule.value)
+			continue
+		}
+
 		//dive into source inner and map last key from mapping '/key1/../lastkey'
 		sourceInner := mappedObject
 		destInner := mappedObject
@@ -158,6 +166,28 @@ func (fm FieldMapper) Map(object map[string]interface{}) (map[string]interface{}
 	return mappedObject, nil
 }
 
+//put value into the path (intermediate objects are created). Value isn't put if path goes through a non-object node
+func putNode(object map[string]interface{}, path []string, value interface{}) {
+	inner := object
+	for _, key := range path[:len(path)-1] {
+		sub, ok := inner[key]
+		if !ok {
+			subMap := map[string]interface{}{}
+			inner[key] = subMap
+			inner = subMap
+			continue
+		}
+
+		subMap, ok := sub.(map[string]interface{})
+		if !ok {
+			return
+		}
+		inner = subMap
+	}
+
+	inner[path[len(path)-1]] = value
+}
+
 //Return object as is
 func (DummyMapper) Map(object map[string]interface{}) (map[string]interface{}, error) {
 	return object, nil
@@ -0,0 +1,113 @@
+package schema
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	KeepNonASCII          = "keep"
+	TransliterateNonASCII = "transliterate"
+	HashNonASCII          = "hash"
+	RejectNonASCII        = "reject"
+
+	hashedFieldPrefix = "f_"
+	hashedFieldLength = 12
+)
+
+//Latin letters with diacritics, Cyrillic and Greek letters. Other non-ASCII characters are transliterated as u<hex code>
+var transliterations = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a", 'æ': "ae",
+	'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ğ': "g", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'į': "i", 'ı': "i",
+	'ķ': "k", 'ĺ': "l", 'ļ': "l", 'ľ': "l", 'ł': "l", 'ñ': "n", 'ń': "n", 'ņ': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o", 'œ': "oe",
+	'ŕ': "r", 'ř': "r", 'ś': "s", 'ş': "s", 'š': "s", 'ß': "ss", 'ţ': "t", 'ť': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'ý': "y", 'ÿ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'ґ': "g", 'д': "d", 'е': "e", 'ё': "e", 'є': "ye", 'ж': "zh",
+	'з': "z", 'и': "i", 'і': "i", 'ї': "yi", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh",
+	'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i", 'κ': "k",
+	'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t",
+	'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o", 'ά': "a", 'έ': "e", 'ή': "i", 'ί': "i", 'ό': "o",
+	'ύ': "y", 'ώ': "o",
+}
+
+//FieldNameNormalizer handles object keys with non-ASCII characters (most of destinations don't support them in column names)
+//keep: keys are used as is
+//transliterate: characters are transliterated into latin (e.g. заголовок -> zagolovok, 🎉 -> u1f389)
+//hash: keys are replaced with f_ + the first 12 hex characters of key SHA-1
+//reject: objects with such keys are rejected
+type FieldNameNormalizer struct {
+	mode string
+}
+
+//NewFieldNameNormalizer return FieldNameNormalizer or error if mode is unknown. Default mode is keep
+func NewFieldNameNormalizer(mode string) (*FieldNameNormalizer, error) {
+	switch mode {
+	case "":
+		mode = KeepNonASCII
+	case KeepNonASCII, TransliterateNonASCII, HashNonASCII, RejectNonASCII:
+	default:
+		return nil, fmt.Errorf("Unknown non_ascii_fields value: %s. Supported: %s, %s, %s, %s", mode, KeepNonASCII, TransliterateNonASCII, HashNonASCII, RejectNonASCII)
+	}
+
+	return &FieldNameNormalizer{mode: mode}, nil
+}
+
+//Normalize return name as is if it consists of ASCII characters or normalized according to the mode
+func (fnn *FieldNameNormalizer) Normalize(name string) (string, error) {
+	if fnn == nil || fnn.mode == KeepNonASCII || isASCII(name) {
+		return name, nil
+	}
+
+	switch fnn.mode {
+	case TransliterateNonASCII:
+		return transliterate(name), nil
+	case HashNonASCII:
+		hash := sha1.Sum([]byte(name))
+		return hashedFieldPrefix + hex.EncodeToString(hash[:])[:hashedFieldLength], nil
+	default:
+		return "", fmt.Errorf("Field name [%s] contains non-ASCII characters", name)
+	}
+}
+
+func transliterate(name string) string {
+	var result strings.Builder
+	for _, r := range name {
+		switch {
+		case r < utf8.RuneSelf:
+			result.WriteRune(r)
+		case unicode.Is(unicode.Mn, r):
+			//combining marks (e.g. accents) are dropped
+		default:
+			if latin, ok := transliterations[unicode.ToLower(r)]; ok {
+				result.WriteString(latin)
+			} else {
+				result.WriteString("u" + strconv.FormatInt(int64(r), 16))
+			}
+		}
+	}
+
+	return result.String()
+}
+
+func isASCII(name string) bool {
+	for i := 0; i < len(name); i++ {
+		if name[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,44 @@
+package schema
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFieldNameNormalizer(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		input       string
+		expected    string
+		expectedErr string
+	}{
+		{"ASCII is kept in any mode", RejectNonASCII, "page_title", "page_title", ""},
+		{"Keep", KeepNonASCII, "заголовок", "заголовок", ""},
+		{"Default is keep", "", "🎉", "🎉", ""},
+		{"Transliterate cyrillic", TransliterateNonASCII, "Заголовок_1", "zagolovok_1", ""},
+		{"Transliterate latin with diacritics", TransliterateNonASCII, "straße_título", "strasse_titulo", ""},
+		{"Transliterate combining marks", TransliterateNonASCII, "café", "cafe", ""},
+		{"Transliterate greek", TransliterateNonASCII, "όνομα", "onoma", ""},
+		{"Transliterate emoji and CJK", TransliterateNonASCII, "🎉_名", "u1f389_u540d", ""},
+		{"Hash", HashNonASCII, "заголовок", "f_44c4befed987", ""},
+		{"Reject", RejectNonASCII, "🎉", "", "Field name [🎉] contains non-ASCII characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalizer, err := NewFieldNameNormalizer(tt.mode)
+			require.NoError(t, err)
+
+			actual, err := normalizer.Normalize(tt.input)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+
+	_, err := NewFieldNameNormalizer("drop")
+	require.EqualError(t, err, "Unknown non_ascii_fields value: drop. Supported: keep, transliterate, hash, reject")
+}
@@ -0,0 +1,56 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Knetic/govaluate"
+)
+
+//errFiltered is returned by Processor.processObject if object doesn't match destination filter
+var errFiltered = errors.New("Object doesn't match destination filter")
+
+//Filter is a destination filter expression e.g. src == 'eventn' && event_type != 'heartbeat'
+//Syntax: https://github.com/Knetic/govaluate/blob/master/MANUAL.md
+//expression is evaluated against flattened object after mapping (the same fields as in table_name_template e.g. eventn_ctx_user_id)
+//missing fields are nil: event_type != 'heartbeat' is true for objects without event_type
+type Filter struct {
+	raw        string
+	expression *govaluate.EvaluableExpression
+}
+
+//filterParameters return nil values of missing fields instead of errors
+type filterParameters map[string]interface{}
+
+func (fp filterParameters) Get(name string) (interface{}, error) {
+	return fp[name], nil
+}
+
+//NewFilter return Filter or nil if expression is empty
+func NewFilter(expression string) (*Filter, error) {
+	if expression == "" {
+		return nil, nil
+	}
+
+	parsed, err := govaluate.NewEvaluableExpression(expression)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing filter expression [%s]: %v", expression, err)
+	}
+
+	return &Filter{raw: expression, expression: parsed}, nil
+}
+
+//Match return true if object matches the filter expression
+//return err if expression can't be evaluated (e.g. number comparison of string field) or its result isn't boolean
+func (f *Filter) Match(object map[string]interface{}) (bool, error) {
+	result, err := f.expression.Eval(filterParameters(object))
+	if err != nil {
+		return false, fmt.Errorf("Error evaluating filter expression [%s]: %v", f.raw, err)
+	}
+
+	matched, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("Filter expression [%s] result isn't boolean: %v", f.raw, result)
+	}
+
+	return matched, nil
+}
@@ -0,0 +1,78 @@
+package schema
+
+import (
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	filter, err := NewFilter("")
+	require.NoError(t, err)
+	require.Nil(t, filter)
+
+	_, err = NewFilter("src == 'eventn' &&")
+	require.Error(t, err)
+
+	filter, err = NewFilter("src == 'eventn' && event_type != 'heartbeat' && eventn_ctx_revenue >= 10")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		object        map[string]interface{}
+		expected      bool
+		expectedError bool
+	}{
+		{"match", map[string]interface{}{"src": "eventn", "event_type": "pageview", "eventn_ctx_revenue": 10.5}, true, false},
+		{"integer", map[string]interface{}{"src": "eventn", "event_type": "pageview", "eventn_ctx_revenue": 12}, true, false},
+		{"heartbeat", map[string]interface{}{"src": "eventn", "event_type": "heartbeat", "eventn_ctx_revenue": 10}, false, false},
+		{"missing event_type", map[string]interface{}{"src": "eventn", "eventn_ctx_revenue": 10}, true, false},
+		{"another src", map[string]interface{}{"src": "api", "event_type": "pageview", "eventn_ctx_revenue": 10}, false, false},
+		{"string revenue", map[string]interface{}{"src": "eventn", "event_type": "pageview", "eventn_ctx_revenue": "ten"}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, err := filter.Match(tt.object)
+			if tt.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, matched)
+		})
+	}
+
+	filter, err = NewFilter("event_type")
+	require.NoError(t, err)
+	_, err = filter.Match(map[string]interface{}{"event_type": "pageview"})
+	require.Error(t, err)
+}
+
+func TestProcessFilePayloadFilter(t *testing.T) {
+	p, err := NewProcessor(&ProcessorConfig{
+		TableNameTemplate: "{{.event_type}}",
+		Mapping:           []string{"/eventn_ctx/source -> /src"},
+		Filter:            "src == 'eventn' && event_type != 'heartbeat'",
+	})
+	require.NoError(t, err)
+
+	payload := `{"_timestamp":"2020-12-01T10:00:00.000000Z","eventn_ctx":{"source":"eventn"},"event_type":"pageview","id":1}` + "\n" +
+		`{"_timestamp":"2020-12-01T10:00:00.000000Z","eventn_ctx":{"source":"eventn"},"event_type":"heartbeat","id":2}` + "\n" +
+		`{"_timestamp":"2020-12-01T10:00:00.000000Z","eventn_ctx":{"source":"api"},"event_type":"pageview","id":3}` + "\n"
+
+	report := reports.NewLoadReport("test", "destination", "token", 3)
+	files, err := p.ProcessFilePayload("test", []byte(payload), true, report)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, 1, files["pageview"].Size())
+	require.Equal(t, 2, report.Filtered)
+	require.Equal(t, 0, report.Skipped)
+
+	report.Finish(nil)
+	require.Equal(t, 1, report.Loaded)
+
+	table, object, err := p.ProcessFact(map[string]interface{}{"eventn_ctx": map[string]interface{}{"source": "eventn"}, "event_type": "heartbeat"})
+	require.NoError(t, err)
+	require.Nil(t, table)
+	require.Nil(t, object)
+}
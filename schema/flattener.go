@@ -2,45 +2,149 @@ package schema
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/ksensehq/eventnative/typing"
 	"reflect"
 	"strconv"
 	"strings"
 )
 
+const (
+	JSONDepthOverflow     = "json"
+	TruncateDepthOverflow = "truncate"
+
+	defaultFlattenDelimiter = "_"
+	//eventn_ctx_event_id system column is nested
+	minFlattenDepth = 2
+)
+
+//FlatteningConfig dto for deserialized data_layout.flattening config
+//max_depth: nesting levels of objects which are flattened (0 - unlimited). Top level fields are level 1
+//overflow: json (default) - objects on max_depth level are written as JSON strings, truncate - they are skipped
+//delimiter: string which joins keys of nested objects (default: _). Paths in other data_layout settings (types,
+//json_columns) are matched regardless of the delimiter and EventNative system columns (eventn_ctx_event_id) keep their names
+type FlatteningConfig struct {
+	MaxDepth  int    `mapstructure:"max_depth"`
+	Overflow  string `mapstructure:"overflow"`
+	Delimiter string `mapstructure:"delimiter"`
+}
+
+//Validate FlatteningConfig values and set default ones
+func (fc *FlatteningConfig) Validate() error {
+	if fc == nil {
+		return nil
+	}
+
+	if fc.MaxDepth < 0 {
+		return errors.New("flattening max_depth can't be negative")
+	}
+	if fc.MaxDepth > 0 && fc.MaxDepth < minFlattenDepth {
+		return fmt.Errorf("flattening max_depth must be at least %d: eventn_ctx object keeps system fields", minFlattenDepth)
+	}
+	switch fc.Overflow {
+	case "":
+		fc.Overflow = JSONDepthOverflow
+	case JSONDepthOverflow, TruncateDepthOverflow:
+	default:
+		return fmt.Errorf("Unknown flattening overflow value: %s. Supported: %s, %s", fc.Overflow, JSONDepthOverflow, TruncateDepthOverflow)
+	}
+	if fc.Delimiter == "" {
+		fc.Delimiter = defaultFlattenDelimiter
+	}
+
+	return nil
+}
+
 type Flattener struct {
 	omitNilValues   bool
 	toLowerCaseKeys bool
+	fieldNames      *FieldNameNormalizer
+	//keep arrays of scalars as arrays (e.g. for ClickHouse Array(T) columns) instead of JSON strings
+	arrays bool
+	//flattened keys of values which are kept as JSON strings instead of flattening
+	jsonColumns map[string]bool
+	columnNames *ColumnNames
+	//0 - unlimited
+	maxDepth         int
+	truncateOverflow bool
+	delimiter        string
 }
 
-func NewFlattener() *Flattener {
-	return &Flattener{
+//NewFlattener return Flattener. fieldNames is optional normalizer of keys with non-ASCII characters
+//if arrays is true, arrays of scalars are kept as []interface{} values (empty arrays are omitted)
+//jsonColumns is optional set of flattened keys which values (objects, arrays or scalars) are serialized into JSON as is
+//columnNames is optional sanitizer of flattened keys (keys case is kept if it is configured)
+//flattening is optional validated depth limit and delimiter config (unlimited depth and _ delimiter by default)
+func NewFlattener(fieldNames *FieldNameNormalizer, arrays bool, jsonColumns map[string]bool, columnNames *ColumnNames, flattening *FlatteningConfig) *Flattener {
+	f := &Flattener{
 		omitNilValues:   true,
-		toLowerCaseKeys: true,
+		toLowerCaseKeys: !columnNames.KeepCase(),
+		fieldNames:      fieldNames,
+		arrays:          arrays,
+		jsonColumns:     jsonColumns,
+		columnNames:     columnNames,
+		delimiter:       defaultFlattenDelimiter,
 	}
+	if flattening != nil {
+		f.maxDepth = flattening.MaxDepth
+		f.truncateOverflow = flattening.Overflow == TruncateDepthOverflow
+		if flattening.Delimiter != "" {
+			f.delimiter = flattening.Delimiter
+		}
+	}
+
+	return f
+}
+
+//CanonicalKey return flattened key joined with default delimiter (_) e.g. key1.key2 -> key1_key2
+//data_layout paths (e.g. types) are converted into canonical keys
+func (f *Flattener) CanonicalKey(key string) string {
+	if f.delimiter == defaultFlattenDelimiter {
+		return key
+	}
+
+	return strings.Replace(key, f.delimiter, defaultFlattenDelimiter, -1)
 }
 
 //FlattenObject flatten object e.g. from {"key1":{"key2":123}} to {"key1_key2":123}
 func (f *Flattener) FlattenObject(json map[string]interface{}) (map[string]interface{}, error) {
 	flattenMap := make(map[string]interface{})
 
-	err := f.flatten("", json, flattenMap)
+	err := f.flatten("", json, flattenMap, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	return flattenMap, nil
-
+	return f.columnNames.Sanitize(flattenMap), nil
 }
 
 //recursive function for flatten key (if value is inner object -> recursion call)
-func (f *Flattener) flatten(key string, value interface{}, destination map[string]interface{}) error {
+//depth is nesting level of the key (0 - root object)
+func (f *Flattener) flatten(key string, value interface{}, destination map[string]interface{}, depth int) error {
 	if f.toLowerCaseKeys {
 		key = strings.ToLower(key)
 	}
+	//json columns are configured in lower case
+	if f.jsonColumns[f.CanonicalKey(strings.ToLower(key))] && value != nil {
+		b, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("Error marshaling json column %s: %v", key, err)
+		}
+		destination[key] = string(b)
+		return nil
+	}
 	t := reflect.ValueOf(value)
 	switch t.Kind() {
 	case reflect.Slice:
+		if f.arrays {
+			if array, ok := scalarArray(t); ok {
+				if len(array) > 0 {
+					destination[key] = array
+				}
+				return nil
+			}
+		}
 		b, err := json.Marshal(value)
 		if err != nil {
 			return fmt.Errorf("Error marshaling array with key %s: %v", key, err)
@@ -48,13 +152,32 @@ func (f *Flattener) flatten(key string, value interface{}, destination map[strin
 		destination[key] = string(b)
 	case reflect.Map:
 		unboxed := value.(map[string]interface{})
+		//objects on max depth level aren't flattened
+		if f.maxDepth > 0 && depth >= f.maxDepth {
+			if f.truncateOverflow {
+				return nil
+			}
+			b, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("Error marshaling object with key %s: %v", key, err)
+			}
+			destination[key] = string(b)
+			return nil
+		}
 		for k, v := range unboxed {
-			newKey := k
+			newKey, err := f.fieldNames.Normalize(k)
+			if err != nil {
+				return err
+			}
 			if key != "" {
-				newKey = key + "_" + newKey
+				newKey = key + f.delimiter + newKey
+				//system and default typed columns keep their names
+				if canonical := f.CanonicalKey(newKey); canonical != newKey && isCanonicalColumn(canonical) {
+					newKey = canonical
+				}
 			}
-			if err := f.flatten(newKey, v, destination); err != nil {
-				return fmt.Errorf("Error flatten object with key %s_%s: %v", key, k, err)
+			if err := f.flatten(newKey, v, destination, depth+1); err != nil {
+				return fmt.Errorf("Error flatten object with key %s%s%s: %v", key, f.delimiter, k, err)
 			}
 		}
 	case reflect.Bool:
@@ -67,3 +190,30 @@ func (f *Flattener) flatten(key string, value interface{}, destination map[strin
 
 	return nil
 }
+
+func isCanonicalColumn(key string) bool {
+	if key == EventIDColumn {
+		return true
+	}
+	_, ok := typing.DefaultTypes[key]
+	return ok
+}
+
+//scalarArray return array elements as []interface{} (booleans are formatted as strings like scalar ones)
+//return false if array contains nulls, objects or nested arrays
+func scalarArray(value reflect.Value) ([]interface{}, bool) {
+	array := make([]interface{}, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		element := value.Index(i).Interface()
+		switch v := element.(type) {
+		case bool:
+			array[i] = strconv.FormatBool(v)
+		case string, float64, float32, int, int8, int16, int32, int64:
+			array[i] = v
+		default:
+			return nil, false
+		}
+	}
+
+	return array, true
+}
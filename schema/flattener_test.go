@@ -2,6 +2,7 @@ package schema
 
 import (
 	"github.com/ksensehq/eventnative/test"
+	"github.com/ksensehq/eventnative/typing"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -44,7 +45,7 @@ func TestFlattenObject(t *testing.T) {
 				"key8_sub_key2": 123123.3123, "key8_sub_key3_sub_sub_key1": "[\"1,\",\"2.\"]", "key10": "true"},
 		},
 	}
-	flattener := NewFlattener()
+	flattener := NewFlattener(nil, false, nil, nil, nil)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			actualFlattenJson, err := flattener.FlattenObject(tt.inputJson)
@@ -53,3 +54,153 @@ func TestFlattenObject(t *testing.T) {
 		})
 	}
 }
+
+func TestFlattenObjectUnicode(t *testing.T) {
+	input := map[string]interface{}{
+		"title":     "Привет 👋🏽",
+		"tags":      []interface{}{"🎉", "𝄞"},
+		"заголовок": map[string]interface{}{"🎉": "party"},
+	}
+
+	actual, err := NewFlattener(nil, false, nil, nil, nil).FlattenObject(input)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"title": "Привет 👋🏽", "tags": `["🎉","𝄞"]`, "заголовок_🎉": "party"}, actual)
+
+	transliterate, err := NewFieldNameNormalizer(TransliterateNonASCII)
+	require.NoError(t, err)
+	actual, err = NewFlattener(transliterate, false, nil, nil, nil).FlattenObject(input)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"title": "Привет 👋🏽", "tags": `["🎉","𝄞"]`, "zagolovok_u1f389": "party"}, actual)
+
+	reject, err := NewFieldNameNormalizer(RejectNonASCII)
+	require.NoError(t, err)
+	_, err = NewFlattener(reject, false, nil, nil, nil).FlattenObject(input)
+	require.Error(t, err)
+}
+
+func TestFlattenObjectArrays(t *testing.T) {
+	input := map[string]interface{}{
+		"tags":    []interface{}{"a", "b"},
+		"ids":     []interface{}{1.0, 2.0},
+		"flags":   []interface{}{true, false},
+		"empty":   []interface{}{},
+		"nulls":   []interface{}{"a", nil},
+		"objects": []interface{}{map[string]interface{}{"id": 1.0}},
+		"nested":  map[string]interface{}{"ints": []int{1, 2}},
+	}
+
+	actual, err := NewFlattener(nil, true, nil, nil, nil).FlattenObject(input)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"tags":        []interface{}{"a", "b"},
+		"ids":         []interface{}{1.0, 2.0},
+		"flags":       []interface{}{"true", "false"},
+		"nulls":       `["a",null]`,
+		"objects":     `[{"id":1}]`,
+		"nested_ints": []interface{}{1, 2},
+	}, actual)
+}
+
+func TestFlattenObjectJSONColumns(t *testing.T) {
+	input := map[string]interface{}{
+		"properties": map[string]interface{}{"plan": "pro", "seats": 5.0, "tags": []interface{}{"a"}},
+		"user":       map[string]interface{}{"id": "u1", "traits": map[string]interface{}{"age": 30.0}},
+		"custom":     "plain",
+		"empty":      nil,
+	}
+
+	actual, err := NewFlattener(nil, false, map[string]bool{"properties": true, "user_traits": true, "custom": true, "empty": true}, nil, nil).FlattenObject(input)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"properties":  `{"plan":"pro","seats":5,"tags":["a"]}`,
+		"user_id":     "u1",
+		"user_traits": `{"age":30}`,
+		"custom":      `"plain"`,
+	}, actual)
+}
+
+func TestFlattenObjectDepthAndDelimiter(t *testing.T) {
+	input := map[string]interface{}{
+		"event_type": "reading",
+		"eventn_ctx": map[string]interface{}{"event_id": "1", "utc_time": "2020-08-02T18:23:59.757719Z", "user": map[string]interface{}{"id": "u1"}},
+		"device":     map[string]interface{}{"id": "d1", "sensors": map[string]interface{}{"t1": map[string]interface{}{"value": 21.5}}},
+	}
+
+	tests := []struct {
+		name     string
+		config   *FlatteningConfig
+		expected map[string]interface{}
+	}{
+		{
+			"json overflow",
+			&FlatteningConfig{MaxDepth: 2},
+			map[string]interface{}{"event_type": "reading", "eventn_ctx_event_id": "1", "eventn_ctx_utc_time": "2020-08-02T18:23:59.757719Z",
+				"eventn_ctx_user": `{"id":"u1"}`, "device_id": "d1", "device_sensors": `{"t1":{"value":21.5}}`},
+		},
+		{
+			"truncate overflow",
+			&FlatteningConfig{MaxDepth: 3, Overflow: TruncateDepthOverflow},
+			map[string]interface{}{"event_type": "reading", "eventn_ctx_event_id": "1", "eventn_ctx_utc_time": "2020-08-02T18:23:59.757719Z",
+				"eventn_ctx_user_id": "u1", "device_id": "d1"},
+		},
+		{
+			"dot delimiter keeps system columns",
+			&FlatteningConfig{Delimiter: "."},
+			map[string]interface{}{"event_type": "reading", "eventn_ctx_event_id": "1", "eventn_ctx_utc_time": "2020-08-02T18:23:59.757719Z",
+				"eventn_ctx.user.id": "u1", "device.id": "d1", "device.sensors.t1.value": 21.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.config.Validate())
+			actual, err := NewFlattener(nil, false, nil, nil, tt.config).FlattenObject(input)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestFlatteningConfigValidate(t *testing.T) {
+	require.EqualError(t, (&FlatteningConfig{MaxDepth: -1}).Validate(), "flattening max_depth can't be negative")
+	require.EqualError(t, (&FlatteningConfig{MaxDepth: 1}).Validate(), "flattening max_depth must be at least 2: eventn_ctx object keeps system fields")
+	require.EqualError(t, (&FlatteningConfig{Overflow: "drop"}).Validate(), "Unknown flattening overflow value: drop. Supported: json, truncate")
+
+	config := &FlatteningConfig{}
+	require.NoError(t, config.Validate())
+	require.Equal(t, &FlatteningConfig{Overflow: JSONDepthOverflow, Delimiter: "_"}, config)
+}
+
+func TestProcessFactFlatteningDelimiter(t *testing.T) {
+	p, err := NewProcessor(&ProcessorConfig{
+		TableNameTemplate: "events",
+		Types:             map[string]string{"/device/battery": "string"},
+		JSONColumns:       []string{"/device/props"},
+		Flattening:        &FlatteningConfig{Delimiter: "."},
+	})
+	require.NoError(t, err)
+
+	table, object, err := p.ProcessFact(map[string]interface{}{
+		"_timestamp": "2020-08-02T18:23:59.757719Z",
+		"eventn_ctx": map[string]interface{}{"event_id": "1"},
+		"device":     map[string]interface{}{"battery": 87.0, "props": map[string]interface{}{"fw": "1.2"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "87", object["device.battery"])
+	require.Equal(t, `{"fw":"1.2"}`, object["device.props"])
+	require.Equal(t, "1", object[EventIDColumn])
+	require.Equal(t, typing.STRING, table.Columns["device.battery"].GetType())
+	require.Equal(t, typing.JSON, table.Columns["device.props"].GetType())
+}
+
+func BenchmarkFlattenObject(b *testing.B) {
+	objects := test.ReadObjects(b, test.BenchEventsPath)
+	flattener := NewFlattener(nil, false, nil, nil, nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := flattener.FlattenObject(objects[i%len(objects)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package schema
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/typing"
+	"log"
+	"strings"
+)
+
+const (
+	MoveAction     = "move"
+	RenameAction   = "rename"
+	RemoveAction   = "remove"
+	ConstantAction = "constant"
+)
+
+//MappingConfig dto for deserialized declarative mapping rule (data_layout.mappings)
+//move: src node is moved into dst path (e.g. /page/url -> /url)
+//rename: src node is renamed into dst name in the same parent object (e.g. /page/url -> link: /page/link)
+//remove: src node is removed
+//constant: value is put into dst path (existing node is overwritten)
+//type: optional cast type of dst field (integer, double, string, timestamp)
+type MappingConfig struct {
+	Action string      `mapstructure:"action"`
+	Src    string      `mapstructure:"src"`
+	Dst    string      `mapstructure:"dst"`
+	Value  interface{} `mapstructure:"value"`
+	Type   string      `mapstructure:"type"`
+}
+
+//NewMappingsMapper return FieldMapper which applies rules in the configured order, fields to typecast and err
+func NewMappingsMapper(configs []*MappingConfig) (Mapper, map[string]typing.DataType, error) {
+	if len(configs) == 0 {
+		return &DummyMapper{}, nil, nil
+	}
+
+	var rules []*MappingRule
+	fieldsToCast := map[string]typing.DataType{}
+	for i, config := range configs {
+		rule, err := newMappingRule(config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Malformed mappings rule #%d: %v", i+1, err)
+		}
+
+		if config.Type != "" {
+			if config.Action == RemoveAction {
+				return nil, nil, fmt.Errorf("Malformed mappings rule #%d: type can't be used with %s action", i+1, RemoveAction)
+			}
+			dataType, err := typing.TypeFromString(config.Type)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Malformed mappings rule #%d cast type: %v. Available types: integer, double, decimal, string, timestamp", i+1, err)
+			}
+			fieldsToCast[strings.Join(rule.destination, "_")] = dataType
+		}
+
+		rules = append(rules, rule)
+	}
+
+	log.Println("Configured mappings rules:")
+	for _, config := range configs {
+		log.Printf("%s src: %s dst: %s value: %v", config.Action, config.Src, config.Dst, config.Value)
+	}
+
+	return &FieldMapper{rules: rules}, fieldsToCast, nil
+}
+
+func newMappingRule(config *MappingConfig) (*MappingRule, error) {
+	src := splitPath(config.Src)
+	dst := splitPath(config.Dst)
+
+	switch config.Action {
+	case MoveAction:
+		if len(src) == 0 || len(dst) == 0 {
+			return nil, fmt.Errorf("src and dst are required for %s action", MoveAction)
+		}
+		return &MappingRule{source: src, destination: dst}, nil
+	case RenameAction:
+		if len(src) == 0 || len(dst) != 1 {
+			return nil, fmt.Errorf("src and dst field name (without '/') are required for %s action", RenameAction)
+		}
+		renamed := append(append([]string{}, src[:len(src)-1]...), dst[0])
+		return &MappingRule{source: src, destination: renamed}, nil
+	case RemoveAction:
+		if len(src) == 0 {
+			return nil, fmt.Errorf("src is required for %s action", RemoveAction)
+		}
+		if len(dst) != 0 {
+			return nil, fmt.Errorf("dst can't be used with %s action", RemoveAction)
+		}
+		return &MappingRule{source: src, destination: []string{}}, nil
+	case ConstantAction:
+		if len(dst) == 0 || config.Value == nil {
+			return nil, fmt.Errorf("dst and value are required for %s action", ConstantAction)
+		}
+		if len(src) != 0 {
+			return nil, fmt.Errorf("src can't be used with %s action", ConstantAction)
+		}
+		return &MappingRule{destination: dst, value: config.Value, constant: true}, nil
+	case "":
+		return nil, fmt.Errorf("action is required. Available actions: %s, %s, %s, %s", MoveAction, RenameAction, RemoveAction, ConstantAction)
+	default:
+		return nil, fmt.Errorf("unknown action [%s]. Available actions: %s, %s, %s, %s", config.Action, MoveAction, RenameAction, RemoveAction, ConstantAction)
+	}
+}
+
+// /key1/key2 -> [key1, key2] (empty path -> empty slice)
+func splitPath(path string) []string {
+	path = formatPrefixSuffix(strings.TrimSpace(path))
+	if path == "" {
+		return []string{}
+	}
+
+	return strings.Split(path, "/")
+}
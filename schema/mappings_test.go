@@ -0,0 +1,108 @@
+package schema
+
+import (
+	"github.com/ksensehq/eventnative/test"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMappingsMapper(t *testing.T) {
+	tests := []struct {
+		name           string
+		configs        []*MappingConfig
+		inputObject    map[string]interface{}
+		expectedObject map[string]interface{}
+	}{
+		{
+			"Empty rules don't change input object",
+			nil,
+			map[string]interface{}{"page": map[string]interface{}{"url": "https://jitsu.com"}},
+			map[string]interface{}{"page": map[string]interface{}{"url": "https://jitsu.com"}},
+		},
+		{
+			"Move, rename and remove",
+			[]*MappingConfig{
+				{Action: MoveAction, Src: "/page/url", Dst: "/url"},
+				{Action: RenameAction, Src: "/page/title", Dst: "name"},
+				{Action: RemoveAction, Src: "/user/email"},
+				{Action: RemoveAction, Src: "/user/phone"},
+			},
+			map[string]interface{}{
+				"page": map[string]interface{}{"url": "https://jitsu.com", "title": "Jitsu"},
+				"user": map[string]interface{}{"id": "1", "email": "a@b.com"},
+			},
+			map[string]interface{}{
+				"url":  "https://jitsu.com",
+				"page": map[string]interface{}{"name": "Jitsu"},
+				"user": map[string]interface{}{"id": "1"},
+			},
+		},
+		{
+			"Constants are put into new and existing nodes",
+			[]*MappingConfig{
+				{Action: ConstantAction, Dst: "/source", Value: "web"},
+				{Action: ConstantAction, Dst: "/app/version", Value: 2},
+				{Action: ConstantAction, Dst: "/user/id", Value: "anonymous"},
+				{Action: ConstantAction, Dst: "/event_type/name", Value: "skipped"},
+			},
+			map[string]interface{}{"user": map[string]interface{}{"id": "1"}, "event_type": "pageview"},
+			map[string]interface{}{
+				"source":     "web",
+				"app":        map[string]interface{}{"version": 2},
+				"user":       map[string]interface{}{"id": "anonymous"},
+				"event_type": "pageview",
+			},
+		},
+		{
+			"Rules are applied in order",
+			[]*MappingConfig{
+				{Action: MoveAction, Src: "/utm/source", Dst: "/source"},
+				{Action: ConstantAction, Dst: "/utm/source", Value: "moved"},
+			},
+			map[string]interface{}{"utm": map[string]interface{}{"source": "google"}},
+			map[string]interface{}{"source": "google", "utm": map[string]interface{}{"source": "moved"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper, _, err := NewMappingsMapper(tt.configs)
+			require.NoError(t, err)
+
+			actualObject, _ := mapper.Map(tt.inputObject)
+			test.ObjectsEqual(t, tt.expectedObject, actualObject, "Mapped objects aren't equal")
+		})
+	}
+}
+
+func TestMappingsMapperTypeCasts(t *testing.T) {
+	_, typeCasts, err := NewMappingsMapper([]*MappingConfig{
+		{Action: MoveAction, Src: "/page/id", Dst: "/page_id", Type: "integer"},
+		{Action: RenameAction, Src: "/user/age", Dst: "years", Type: "double"},
+		{Action: ConstantAction, Dst: "/version", Value: "1", Type: "string"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]typing.DataType{"page_id": typing.INT64, "user_years": typing.FLOAT64, "version": typing.STRING}, typeCasts)
+}
+
+func TestMappingsMapperErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        *MappingConfig
+		expectedError string
+	}{
+		{"without action", &MappingConfig{Src: "/a"}, "Malformed mappings rule #1: action is required. Available actions: move, rename, remove, constant"},
+		{"unknown action", &MappingConfig{Action: "copy", Src: "/a", Dst: "/b"}, "Malformed mappings rule #1: unknown action [copy]. Available actions: move, rename, remove, constant"},
+		{"move without dst", &MappingConfig{Action: MoveAction, Src: "/a"}, "Malformed mappings rule #1: src and dst are required for move action"},
+		{"rename into path", &MappingConfig{Action: RenameAction, Src: "/a/b", Dst: "/c/d"}, "Malformed mappings rule #1: src and dst field name (without '/') are required for rename action"},
+		{"remove with dst", &MappingConfig{Action: RemoveAction, Src: "/a", Dst: "/b"}, "Malformed mappings rule #1: dst can't be used with remove action"},
+		{"constant without value", &MappingConfig{Action: ConstantAction, Dst: "/a"}, "Malformed mappings rule #1: dst and value are required for constant action"},
+		{"remove with type", &MappingConfig{Action: RemoveAction, Src: "/a", Type: "integer"}, "Malformed mappings rule #1: type can't be used with remove action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := NewMappingsMapper([]*MappingConfig{tt.config})
+			require.EqualError(t, err, tt.expectedError)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package schema
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+//MetadataCache keeps tables schemas which are read from the destination and shares them between all writers of the destination
+//(e.g. TableHelpers of ClickHouse nodes and stream mode workers) so system tables aren't queried on every cold path:
+//schemas (including missing tables) are kept for refresh interval and concurrent reads of the same table are done with one query
+//schema of the table is invalidated when the table is created, patched or its column is dropped
+type MetadataCache struct {
+	refreshInterval time.Duration
+
+	mutex   sync.Mutex
+	entries map[string]*metadataEntry
+}
+
+//read of table schema: done is closed when it is finished
+type metadataEntry struct {
+	done      chan struct{}
+	table     *Table
+	err       error
+	fetchedAt time.Time
+}
+
+//NewMetadataCache return MetadataCache with data_layout.schema_cache refresh_interval
+//or nil if refresh_interval isn't configured (tables schemas are read from db right away and known ones aren't re-read)
+//return err if refresh_interval is negative
+func NewMetadataCache(config *TablesCacheConfig) (*MetadataCache, error) {
+	if config == nil || config.RefreshInterval == 0 {
+		return nil, nil
+	}
+	if config.RefreshInterval < 0 {
+		return nil, errors.New("data_layout.schema_cache refresh_interval can't be negative")
+	}
+
+	return &MetadataCache{refreshInterval: config.RefreshInterval, entries: map[string]*metadataEntry{}}, nil
+}
+
+//RefreshInterval return duration of keeping tables schemas
+func (mc *MetadataCache) RefreshInterval() time.Duration {
+	return mc.refreshInterval
+}
+
+//Get return copy of the table schema which has been read with read func less than refresh interval ago or read it
+//if another read of the table is in progress - wait for its result. Errors aren't cached
+//if cache is nil - the table schema is read right away
+func (mc *MetadataCache) Get(tableName string, read func(tableName string) (*Table, error)) (*Table, error) {
+	if mc == nil {
+		return read(tableName)
+	}
+
+	mc.mutex.Lock()
+	entry, ok := mc.entries[tableName]
+	if ok {
+		select {
+		case <-entry.done:
+			ok = entry.err == nil && time.Since(entry.fetchedAt) < mc.refreshInterval
+		default:
+			//in progress
+		}
+	}
+
+	if ok {
+		mc.mutex.Unlock()
+		<-entry.done
+	} else {
+		entry = &metadataEntry{done: make(chan struct{})}
+		mc.entries[tableName] = entry
+		mc.mutex.Unlock()
+
+		entry.table, entry.err = read(tableName)
+		entry.fetchedAt = time.Now()
+		close(entry.done)
+	}
+
+	if entry.err != nil {
+		return nil, entry.err
+	}
+
+	//callers change returned schemas
+	columns := Columns{}
+	for name, column := range entry.table.Columns {
+		columns[name] = column
+	}
+	table := *entry.table
+	table.Columns = columns
+
+	return &table, nil
+}
+
+//Invalidate forget the table schema: it is read again on the next Get
+func (mc *MetadataCache) Invalidate(tableName string) {
+	if mc == nil {
+		return
+	}
+
+	mc.mutex.Lock()
+	delete(mc.entries, tableName)
+	mc.mutex.Unlock()
+}
@@ -0,0 +1,118 @@
+package schema
+
+import (
+	"errors"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewMetadataCache(t *testing.T) {
+	cache, err := NewMetadataCache(nil)
+	require.NoError(t, err)
+	require.Nil(t, cache)
+
+	cache, err = NewMetadataCache(&TablesCacheConfig{Enabled: true})
+	require.NoError(t, err)
+	require.Nil(t, cache)
+
+	cache, err = NewMetadataCache(&TablesCacheConfig{RefreshInterval: 10 * time.Second})
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Second, cache.RefreshInterval())
+
+	_, err = NewMetadataCache(&TablesCacheConfig{RefreshInterval: -time.Second})
+	require.EqualError(t, err, "data_layout.schema_cache refresh_interval can't be negative")
+}
+
+func TestMetadataCacheGet(t *testing.T) {
+	var reads int32
+	read := func(tableName string) (*Table, error) {
+		atomic.AddInt32(&reads, 1)
+		time.Sleep(10 * time.Millisecond)
+		if tableName == "missing" {
+			return &Table{Name: tableName, Columns: Columns{}}, nil
+		}
+		return &Table{Name: tableName, Columns: Columns{"id": NewColumn(typing.INT64)}}, nil
+	}
+
+	cache, err := NewMetadataCache(&TablesCacheConfig{RefreshInterval: time.Hour})
+	require.NoError(t, err)
+
+	//concurrent reads of the same table are done with one query
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			table, err := cache.Get("events", read)
+			require.NoError(t, err)
+			require.Len(t, table.Columns, 1)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), atomic.LoadInt32(&reads))
+
+	//returned schemas are copies
+	table, err := cache.Get("events", read)
+	require.NoError(t, err)
+	table.Columns["url"] = NewColumn(typing.STRING)
+	table, err = cache.Get("events", read)
+	require.NoError(t, err)
+	require.Len(t, table.Columns, 1)
+	require.Equal(t, int32(1), atomic.LoadInt32(&reads))
+
+	//missing tables are cached as well
+	for i := 0; i < 2; i++ {
+		table, err = cache.Get("missing", read)
+		require.NoError(t, err)
+		require.False(t, table.Exists())
+	}
+	require.Equal(t, int32(2), atomic.LoadInt32(&reads))
+
+	cache.Invalidate("missing")
+	_, err = cache.Get("missing", read)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&reads))
+
+	//errors aren't cached
+	failed := func(tableName string) (*Table, error) {
+		atomic.AddInt32(&reads, 1)
+		return nil, errors.New("connection refused")
+	}
+	for i := 0; i < 2; i++ {
+		_, err = cache.Get("users", failed)
+		require.EqualError(t, err, "connection refused")
+	}
+	require.Equal(t, int32(5), atomic.LoadInt32(&reads))
+
+	//nil cache reads right away
+	var disabled *MetadataCache
+	_, err = disabled.Get("events", read)
+	require.NoError(t, err)
+	disabled.Invalidate("events")
+	require.Equal(t, int32(6), atomic.LoadInt32(&reads))
+}
+
+func TestMetadataCacheRefresh(t *testing.T) {
+	reads := 0
+	read := func(tableName string) (*Table, error) {
+		reads++
+		return &Table{Name: tableName, Columns: Columns{"id": NewColumn(typing.INT64)}}, nil
+	}
+
+	cache, err := NewMetadataCache(&TablesCacheConfig{RefreshInterval: 20 * time.Millisecond})
+	require.NoError(t, err)
+	_, err = cache.Get("events", read)
+	require.NoError(t, err)
+	_, err = cache.Get("events", read)
+	require.NoError(t, err)
+	require.Equal(t, 1, reads)
+
+	time.Sleep(30 * time.Millisecond)
+	_, err = cache.Get("events", read)
+	require.NoError(t, err)
+	require.Equal(t, 2, reads)
+}
@@ -0,0 +1,137 @@
+package schema
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/typing"
+	"math"
+	"strconv"
+)
+
+const (
+	ClampOverflow  = "clamp"
+	NullOverflow   = "null"
+	StringOverflow = "string"
+	RejectOverflow = "reject"
+
+	//string fallback column name is <column>_overflow
+	overflowColumnSuffix = "_overflow"
+)
+
+//Int64Bounds is a value range of INT64 columns without explicit bounds
+var Int64Bounds = &NumericBounds{Min: math.MinInt64, Max: math.MaxInt64}
+
+//OverflowError is returned when value is out of DB column range and numeric overflow policy is reject
+type OverflowError struct {
+	Field string
+	Value interface{}
+	Min   float64
+	Max   float64
+}
+
+func (oe *OverflowError) Error() string {
+	return fmt.Sprintf("Value %v of [%s] field is out of DB column range [%v, %v]", oe.Value, oe.Field, oe.Min, oe.Max)
+}
+
+//NumericBounds is a value range which can be stored in a DB numeric column
+type NumericBounds struct {
+	Min float64
+	Max float64
+}
+
+//DecimalBounds return range of decimal(precision, scale): values with less than precision - scale integer digits
+func DecimalBounds(precision, scale int) *NumericBounds {
+	max := math.Nextafter(math.Pow10(precision-scale), 0)
+	return &NumericBounds{Min: -max, Max: max}
+}
+
+//NumericOverflow applies a policy to values which exceed DB column bounds:
+//clamp: value is replaced with the nearest bound
+//null: value is replaced with null
+//string: value is moved as string into <column>_overflow column
+//reject: object is skipped (default)
+type NumericOverflow struct {
+	policy string
+}
+
+//NewNumericOverflow return NumericOverflow or error if policy is unknown. Default policy is reject
+func NewNumericOverflow(policy string) (*NumericOverflow, error) {
+	switch policy {
+	case "":
+		policy = RejectOverflow
+	case ClampOverflow, NullOverflow, StringOverflow, RejectOverflow:
+	default:
+		return nil, fmt.Errorf("Unknown numeric_overflow value: %s. Supported: %s, %s, %s, %s", policy, ClampOverflow, NullOverflow, StringOverflow, RejectOverflow)
+	}
+
+	return &NumericOverflow{policy: policy}, nil
+}
+
+//Apply check object field value against column bounds and apply the policy if it overflows
+//string fallback column is added into dataSchema
+//return err if the value overflows and the policy is reject
+func (no *NumericOverflow) Apply(dataSchema *Table, column Column, name string, object map[string]interface{}) error {
+	bounds := column.Bounds()
+	if bounds == nil {
+		return nil
+	}
+
+	value, ok := toFloat(object[name])
+	if !ok || (value >= bounds.Min && value <= bounds.Max) {
+		return nil
+	}
+
+	switch no.policy {
+	case ClampOverflow:
+		object[name] = bounds.clamp(value, column.GetType())
+	case NullOverflow:
+		object[name] = nil
+	case StringOverflow:
+		delete(object, name)
+		object[name+overflowColumnSuffix] = strconv.FormatFloat(value, 'f', -1, 64)
+		if _, ok := dataSchema.Columns[name+overflowColumnSuffix]; !ok {
+			dataSchema.Columns[name+overflowColumnSuffix] = NewColumn(typing.STRING)
+		}
+	default:
+		return &OverflowError{Field: name, Value: object[name], Min: bounds.Min, Max: bounds.Max}
+	}
+
+	return nil
+}
+
+//return the nearest bound (integer for INT64 columns)
+func (nb *NumericBounds) clamp(value float64, dataType typing.DataType) interface{} {
+	bound := nb.Min
+	if value > nb.Max {
+		bound = nb.Max
+	}
+
+	if dataType != typing.INT64 {
+		return bound
+	}
+
+	switch {
+	case bound >= math.MaxInt64:
+		return int64(math.MaxInt64)
+	case bound <= math.MinInt64:
+		return int64(math.MinInt64)
+	default:
+		return int64(bound)
+	}
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,73 @@
+package schema
+
+import (
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestNumericOverflowApply(t *testing.T) {
+	decimal := NewBoundedColumn(typing.FLOAT64, DecimalBounds(38, 18))
+	bigint := NewColumn(typing.INT64)
+	tests := []struct {
+		name           string
+		policy         string
+		column         Column
+		value          interface{}
+		expected       map[string]interface{}
+		expectedColumn bool
+		expectedErr    string
+	}{
+		{"Value in range", RejectOverflow, decimal, 1e19, map[string]interface{}{"amount": 1e19}, false, ""},
+		{"Not limited column", RejectOverflow, NewColumn(typing.FLOAT64), 1e30, map[string]interface{}{"amount": 1e30}, false, ""},
+		{"String value", RejectOverflow, NewColumn(typing.STRING), "1e30", map[string]interface{}{"amount": "1e30"}, false, ""},
+		{"Clamp decimal", ClampOverflow, decimal, -1e25, map[string]interface{}{"amount": -math.Nextafter(1e20, 0)}, false, ""},
+		{"Clamp bigint", ClampOverflow, bigint, 1e19, map[string]interface{}{"amount": int64(math.MaxInt64)}, false, ""},
+		{"Null", NullOverflow, decimal, 1e25, map[string]interface{}{"amount": nil}, false, ""},
+		{"String fallback", StringOverflow, bigint, 1e19, map[string]interface{}{"amount_overflow": "10000000000000000000"}, true, ""},
+		{"Reject", "", decimal, 1e25, nil, false, "Value 1e+25 of [amount] field is out of DB column range [-9.999999999999998e+19, 9.999999999999998e+19]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			numericOverflow, err := NewNumericOverflow(tt.policy)
+			require.NoError(t, err)
+
+			dataSchema := &Table{Name: "events", Columns: Columns{"amount": tt.column}}
+			object := map[string]interface{}{"amount": tt.value}
+			err = numericOverflow.Apply(dataSchema, tt.column, "amount", object)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, object)
+
+			_, ok := dataSchema.Columns["amount_overflow"]
+			require.Equal(t, tt.expectedColumn, ok)
+		})
+	}
+
+	_, err := NewNumericOverflow("wrap")
+	require.EqualError(t, err, "Unknown numeric_overflow value: wrap. Supported: clamp, null, string, reject")
+}
+
+func TestApplyDBTypingNumericOverflow(t *testing.T) {
+	p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: "events", NumericOverflow: RejectOverflow})
+	require.NoError(t, err)
+
+	dbSchema := &Table{Name: "events", Columns: Columns{
+		"id":     NewColumn(typing.INT64),
+		"amount": NewBoundedColumn(typing.FLOAT64, DecimalBounds(38, 18)),
+	}}
+	pf := NewProcessedFile("file1", &Table{Name: "events", Columns: Columns{}})
+	pf.Report = reports.NewLoadReport("file1", "postgres", "", 3)
+	pf.Add(&Table{Name: "events", Columns: Columns{}}, map[string]interface{}{"id": float64(1), "amount": 1.5})
+	pf.Add(&Table{Name: "events", Columns: Columns{}}, map[string]interface{}{"id": float64(2), "amount": 1e21})
+	pf.Add(&Table{Name: "events", Columns: Columns{}}, map[string]interface{}{"id": float64(3), "amount": float64(1)})
+
+	require.NoError(t, p.ApplyDBTyping(dbSchema, pf))
+	require.Equal(t, []map[string]interface{}{{"id": float64(1), "amount": 1.5}, {"id": float64(3), "amount": float64(1)}}, pf.GetPayload())
+	require.Equal(t, map[string]int{reports.OverflowReason: 1}, pf.Report.Reasons)
+}
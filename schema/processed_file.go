@@ -3,17 +3,42 @@ package schema
 import (
 	"bytes"
 	"encoding/json"
+	"github.com/ksensehq/eventnative/reports"
 	"log"
 )
 
 //ProcessedFile collect data in payload and return it in two formats
+//Report is a load report of the source event log file (nil in stream mode)
 type ProcessedFile struct {
 	FileName   string
 	DataSchema *Table
+	Report     *reports.LoadReport
 
 	payload []map[string]interface{}
 }
 
+//NewProcessedFile return ProcessedFile with empty payload
+func NewProcessedFile(fileName string, dataSchema *Table) *ProcessedFile {
+	return &ProcessedFile{FileName: fileName, DataSchema: dataSchema, payload: []map[string]interface{}{}}
+}
+
+//Add put object into payload and merge object table columns into DataSchema
+func (pf *ProcessedFile) Add(table *Table, object map[string]interface{}) {
+	pf.DataSchema.Columns.Merge(table.Columns)
+	pf.payload = append(pf.payload, object)
+}
+
+//Merge put all objects from other into payload and merge DataSchema columns
+func (pf *ProcessedFile) Merge(other *ProcessedFile) {
+	pf.DataSchema.Columns.Merge(other.DataSchema.Columns)
+	pf.payload = append(pf.payload, other.payload...)
+}
+
+//Size return payload objects count
+func (pf ProcessedFile) Size() int {
+	return len(pf.payload)
+}
+
 //GetPayload return payload as is
 func (pf ProcessedFile) GetPayload() []map[string]interface{} {
 	return pf.payload
@@ -4,8 +4,11 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/samples"
 	"github.com/ksensehq/eventnative/timestamp"
 	"github.com/ksensehq/eventnative/typing"
 	"io"
@@ -19,10 +22,170 @@ type Processor struct {
 	fieldMapper          Mapper
 	typeCasts            map[string]typing.DataType
 	tableNameExtractFunc TableNameExtractFunction
+	timeBounds           *TimeBounds
+	timestamps           *Timestamps
+	numericOverflow      *NumericOverflow
+	decimals             *Decimals
+	schemaEvolution      *SchemaEvolution
+	columnsLimit         *ColumnsLimit
+	renames              *Renames
+	tablesCache          *TablesCache
+	metadataCache        *MetadataCache
+	upsertKeys           map[string][]string
+	deletions            *Deletions
+	engineColumns        map[string]*EngineColumns
+	descriptions         map[string]string
+	//destination name for raw payloads samples (see samples.Store). Payloads aren't sampled if it is empty
+	samplesDestination string
+	systemColumns      *SystemColumns
+	existingTables     *ExistingTables
+	filter             *Filter
 }
 
-func NewProcessor(tableNameFuncExpression string, mappings []string) (*Processor, error) {
-	mapper, typeCasts, err := NewFieldMapper(mappings)
+//ProcessorConfig is a set of destination data_layout settings (see storages.DataLayout) which Processor is created with
+//zero values are defaults: e.g. &ProcessorConfig{TableNameTemplate: "events"} writes all events into events table
+type ProcessorConfig struct {
+	TableNameTemplate  string
+	Mapping            []string
+	Mappings           []*MappingConfig
+	Types              map[string]string
+	TimeBounds         *TimeBoundsConfig
+	NonASCIIFields     string
+	NumericOverflow    string
+	Upsert             []*UpsertConfig
+	Deletions          []*DeletionsConfig
+	EngineColumns      map[string]*EngineColumns
+	ColumnDescriptions []*ColumnDescriptionConfig
+	//destination name for raw payloads samples (see samples.Store). Payloads aren't sampled if it is empty
+	SamplesDestination string
+	SystemColumns      map[string]string
+	ExistingTables     *ExistingTablesConfig
+	Filter             string
+	Arrays             bool
+	JSONColumns        []string
+	SchemaEvolution    string
+	Timestamps         *TimestampsConfig
+	MaxColumns         int
+	Renames            string
+	ColumnNames        *ColumnNamesConfig
+	Decimals           *DecimalsConfig
+	TablesCache        *TablesCacheConfig
+	TableNameField     *TableNameFieldConfig
+	Flattening         *FlatteningConfig
+}
+
+//NewProcessor return Processor or err if config is invalid
+func NewProcessor(config *ProcessorConfig) (*Processor, error) {
+	//declarative mappings rules or mapping strings
+	if len(config.Mapping) > 0 && len(config.Mappings) > 0 {
+		return nil, errors.New("data_layout.mapping and data_layout.mappings can't be used together")
+	}
+	mapper, typeCasts, err := NewFieldMapper(config.Mapping)
+	if len(config.Mappings) > 0 {
+		mapper, typeCasts, err = NewMappingsMapper(config.Mappings)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	systemColumns, err := NewSystemColumns(config.SystemColumns)
+	if err != nil {
+		return nil, err
+	}
+	timestampColumn := systemColumns.Name(timestamp.Key)
+
+	fieldNames, err := NewFieldNameNormalizer(config.NonASCIIFields)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.Flattening.Validate(); err != nil {
+		return nil, err
+	}
+
+	//time bounds are checked on renamed timestamp column by default
+	timeBoundsConfig := config.TimeBounds
+	if timeBoundsConfig != nil && (timeBoundsConfig.Field == "" || timeBoundsConfig.Field == timestamp.Key) {
+		renamedConfig := *timeBoundsConfig
+		renamedConfig.Field = timestampColumn
+		timeBoundsConfig = &renamedConfig
+	}
+	timeBounds, err := NewTimeBounds(timeBoundsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamps, err := NewTimestamps(config.Timestamps)
+	if err != nil {
+		return nil, err
+	}
+
+	numericOverflow, err := NewNumericOverflow(config.NumericOverflow)
+	if err != nil {
+		return nil, err
+	}
+
+	decimals, err := NewDecimals(config.Decimals, config.Types)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaEvolution, err := NewSchemaEvolution(config.SchemaEvolution)
+	if err != nil {
+		return nil, err
+	}
+
+	//system columns are never moved into unmapped column
+	var protectedColumns []string
+	for _, column := range systemColumnNames {
+		protectedColumns = append(protectedColumns, systemColumns.Name(column))
+	}
+	columnsLimit, err := NewColumnsLimit(config.MaxColumns, protectedColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	renames, err := NewRenames(config.Renames, protectedColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	tablesCache, err := NewTablesCache(config.TablesCache)
+	if err != nil {
+		return nil, err
+	}
+	metadataCache, err := NewMetadataCache(config.TablesCache)
+	if err != nil {
+		return nil, err
+	}
+
+	//column names are sanitized before system columns renaming
+	columnNames, err := NewColumnNames(config.ColumnNames, append(protectedColumns, systemColumnNames...))
+	if err != nil {
+		return nil, err
+	}
+
+	upsertKeys, err := NewUpsertKeys(config.Upsert)
+	if err != nil {
+		return nil, err
+	}
+
+	deletions, err := NewDeletions(config.Deletions)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptions, err := NewColumnDescriptions(config.ColumnDescriptions)
+	if err != nil {
+		return nil, err
+	}
+
+	existingTables, err := NewExistingTables(config.ExistingTables)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := NewFilter(config.Filter)
 	if err != nil {
 		return nil, err
 	}
@@ -30,52 +193,195 @@ func NewProcessor(tableNameFuncExpression string, mappings []string) (*Processor
 	if typeCasts == nil {
 		typeCasts = map[string]typing.DataType{}
 	}
+	//explicit types override mapping casts
+	typeOverrides, err := NewTypeOverrides(config.Types)
+	if err != nil {
+		return nil, err
+	}
+	for field, dataType := range typeOverrides {
+		typeCasts[field] = dataType
+	}
+	//JSON columns are always JSON typed
+	jsonColumns, err := NewJSONColumns(config.JSONColumns)
+	if err != nil {
+		return nil, err
+	}
+	for field := range jsonColumns {
+		typeCasts[field] = typing.JSON
+	}
+	//renamed timestamp column has the same default type as the system one
+	if _, ok := typeCasts[timestampColumn]; !ok && timestampColumn != timestamp.Key {
+		typeCasts[timestampColumn] = typing.DefaultTypes[timestamp.Key]
+	}
 
 	tmpl, err := template.New("table name extract").
 		Option("missingkey=error").
-		Parse(tableNameFuncExpression)
+		Funcs(tableNameFuncs).
+		Parse(config.TableNameTemplate)
 	if err != nil {
 		return nil, fmt.Errorf("Error parsing table name template %v", err)
 	}
 
 	tableNameExtractFunc := func(object map[string]interface{}) (string, error) {
 		//we need time type of _timestamp field for extracting table name with date template
-		ts, ok := object[timestamp.Key]
+		ts, ok := object[timestampColumn]
 		if !ok {
-			return "", fmt.Errorf("Error extracting table name: %s field doesn't exist", timestamp.Key)
+			return "", fmt.Errorf("Error extracting table name: %s field doesn't exist", timestampColumn)
 		}
 		t, err := time.Parse(timestamp.Layout, ts.(string))
 		if err != nil {
-			return "", fmt.Errorf("Error extracting table name: malformed %s field: %v", timestamp.Key, err)
+			return "", fmt.Errorf("Error extracting table name: malformed %s field: %v", timestampColumn, err)
 		}
 
-		object[timestamp.Key] = t
+		object[timestampColumn] = t
 		var buf bytes.Buffer
-		if err := tmpl.Execute(&buf, object); err != nil {
-			return "", fmt.Errorf("Error executing %s template: %v", tableNameFuncExpression, err)
-		}
-
+		err = tmpl.Execute(&buf, object)
 		//revert type of _timestamp field
-		object[timestamp.Key] = ts
+		object[timestampColumn] = ts
+		if err != nil {
+			return "", fmt.Errorf("Error executing %s template: %v", config.TableNameTemplate, err)
+		}
 
 		return buf.String(), nil
 	}
 
+	//table per field value instead of template
+	tableNameField, err := NewTableNameField(config.TableNameField)
+	if err != nil {
+		return nil, err
+	}
+	if tableNameField != nil {
+		tableNameExtractFunc = tableNameField.Extract
+	}
+
 	return &Processor{
-		flattener:            NewFlattener(),
+		flattener:            NewFlattener(fieldNames, config.Arrays, jsonColumns, columnNames, config.Flattening),
 		fieldMapper:          mapper,
 		typeCasts:            typeCasts,
-		tableNameExtractFunc: tableNameExtractFunc}, nil
+		tableNameExtractFunc: tableNameExtractFunc,
+		timeBounds:           timeBounds,
+		timestamps:           timestamps,
+		numericOverflow:      numericOverflow,
+		decimals:             decimals,
+		schemaEvolution:      schemaEvolution,
+		columnsLimit:         columnsLimit,
+		renames:              renames,
+		tablesCache:          tablesCache,
+		metadataCache:        metadataCache,
+		upsertKeys:           upsertKeys,
+		deletions:            deletions,
+		engineColumns:        config.EngineColumns,
+		descriptions:         descriptions,
+		samplesDestination:   config.SamplesDestination,
+		systemColumns:        systemColumns,
+		existingTables:       existingTables,
+		filter:               filter}, nil
+}
+
+//UpsertKeys return upsert keys of the table (or keys of all tables upsert config) or nil if the table is append-only
+func (p *Processor) UpsertKeys(tableName string) []string {
+	if keys, ok := p.upsertKeys[tableName]; ok {
+		return keys
+	}
+
+	return p.upsertKeys[AllTables]
+}
+
+//SystemColumn return destination column name of EventNative system column (e.g. _timestamp)
+func (p *Processor) SystemColumn(column string) string {
+	return p.systemColumns.Name(column)
+}
+
+//SchemaEvolution return policy of values which can't be converted to DB columns types
+func (p *Processor) SchemaEvolution() *SchemaEvolution {
+	return p.schemaEvolution
+}
+
+//ColumnsLimit return max columns limit of tables or nil if tables aren't limited
+func (p *Processor) ColumnsLimit() *ColumnsLimit {
+	return p.columnsLimit
+}
+
+//Renames return detector of renamed fields or nil if it is disabled
+func (p *Processor) Renames() *Renames {
+	return p.renames
+}
+
+//TablesCache return cache of DB tables schemas or nil if it is disabled
+func (p *Processor) TablesCache() *TablesCache {
+	return p.tablesCache
+}
+
+//MetadataCache return cache of tables schemas which are read from the destination (it is shared by all destination writers)
+func (p *Processor) MetadataCache() *MetadataCache {
+	return p.metadataCache
+}
+
+//ExistingTables return mapping onto columns of existing tables or nil if tables are created and patched by EventNative
+func (p *Processor) ExistingTables() *ExistingTables {
+	return p.existingTables
+}
+
+//ColumnDescription return configured column description or empty string if the column isn't described
+func (p *Processor) ColumnDescription(column string) string {
+	return p.descriptions[column]
+}
+
+//EventTime return event time (timestamp system column value) of the processed object
+//or zero time if the object doesn't have it or it is a deletion event
+func (p *Processor) EventTime(table *Table, object map[string]interface{}) time.Time {
+	if table == nil || len(table.DeletionKeys) > 0 {
+		return time.Time{}
+	}
+
+	value, ok := object[p.SystemColumn(timestamp.Key)]
+	if !ok || value == nil {
+		return time.Time{}
+	}
+
+	converted, err := typing.Convert(typing.TIMESTAMP, value)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return converted.(time.Time)
 }
 
 //ProcessFact return table representation, processed flatten object
+//return nil table if object doesn't match destination filter or is out of time bounds
 func (p *Processor) ProcessFact(fact events.Fact) (*Table, map[string]interface{}, error) {
-	return p.processObject(fact)
+	table, flattenObject, err := p.processObject(fact)
+	if err == errFiltered {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.sample(table, func() []byte {
+		b, _ := json.Marshal(fact)
+		return b
+	})
+
+	return table, flattenObject, nil
+}
+
+//ResolveTable return table representation of the fact without sampling its payload (see ProcessFact)
+//return nil table if object doesn't match destination filter or is out of time bounds
+func (p *Processor) ResolveTable(fact events.Fact) (*Table, error) {
+	table, _, err := p.processObject(fact)
+	if err == errFiltered {
+		return nil, nil
+	}
+
+	return table, err
 }
 
 //ProcessFilePayload process file payload lines divided with \n. Line by line where 1 line = 1 json
 //Return array of processed objects per table like {"table1": []objects, "table2": []objects}
-func (p *Processor) ProcessFilePayload(fileName string, payload []byte, breakOnError bool) (map[string]*ProcessedFile, error) {
+//skipped lines are counted in report (it is put into every ProcessedFile for counting further skipped objects)
+//lines which don't match destination filter are counted in report as filtered ones
+func (p *Processor) ProcessFilePayload(fileName string, payload []byte, breakOnError bool, report *reports.LoadReport) (map[string]*ProcessedFile, error) {
 	filePerTable := map[string]*ProcessedFile{}
 	input := bytes.NewBuffer(payload)
 	reader := bufio.NewReaderSize(input, 64*1024)
@@ -83,22 +389,32 @@ func (p *Processor) ProcessFilePayload(fileName string, payload []byte, breakOnE
 
 	for readErr == nil {
 		table, processedObject, err := p.processLine(line)
-		if err != nil {
+		if err == errFiltered {
+			report.Filter()
+		} else if err != nil {
 			if breakOnError {
 				return nil, err
 			} else {
 				log.Printf("Warn: unable to process object %s reason: %v. This line will be skipped", string(line), err)
+				report.Skip(reports.MalformedReason, err)
 			}
 		}
 
-		//don't process empty object
-		if table.Exists() {
-			f, ok := filePerTable[table.Name]
+		switch {
+		case err != nil:
+		case table == nil:
+			//object is out of time bounds
+			report.Skip(reports.TimeBoundsReason, nil)
+		case !table.Exists():
+			//don't process empty object
+			report.Skip(reports.EmptyReason, nil)
+		default:
+			report.EventTime(table.Name, p.EventTime(table, processedObject))
+			f, ok := filePerTable[fileKey(table)]
 			if !ok {
-				filePerTable[table.Name] = &ProcessedFile{FileName: fileName, DataSchema: table, payload: []map[string]interface{}{processedObject}}
+				filePerTable[fileKey(table)] = &ProcessedFile{FileName: fileName, DataSchema: table, Report: report, payload: []map[string]interface{}{processedObject}}
 			} else {
-				f.DataSchema.Columns.Merge(table.Columns)
-				f.payload = append(f.payload, processedObject)
+				f.Add(table, processedObject)
 			}
 		}
 
@@ -112,33 +428,100 @@ func (p *Processor) ProcessFilePayload(fileName string, payload []byte, breakOnE
 }
 
 //ApplyDBTyping call ApplyDBTypingToObject to every object in input *ProcessedFile payload
+//objects with values out of DB columns range are skipped (and counted in pf.Report) if numeric overflow policy is reject
 //return err if can't convert any field to DB schema type
 func (p *Processor) ApplyDBTyping(dbSchema *Table, pf *ProcessedFile) error {
-	for _, object := range pf.payload {
-		if err := p.ApplyDBTypingToObject(dbSchema, object); err != nil {
-			return err
+	//payload without skipped objects is allocated on the first skipped object
+	var payload []map[string]interface{}
+	for i, object := range pf.payload {
+		if err := p.ApplyDBTypingToObject(dbSchema, pf.DataSchema, object); err != nil {
+			reason := reports.OverflowReason
+			switch err.(type) {
+			case *OverflowError:
+			case *TypeConflictError:
+				reason = reports.TypeConflictReason
+			default:
+				return err
+			}
+
+			log.Printf("Warn: %v. Object %v will be skipped", err, object)
+			pf.Report.Skip(reason, err)
+			if payload == nil {
+				payload = append(make([]map[string]interface{}, 0, len(pf.payload)), pf.payload[:i]...)
+			}
+			continue
+		}
+
+		if payload != nil {
+			payload = append(payload, object)
 		}
 	}
 
+	if payload != nil {
+		pf.payload = payload
+	}
+
 	return nil
 }
 
-//ApplyDBTypingToObject convert all object fields to DB schema types
+//ApplyDBTypingToObject convert all object fields to DB schema types, apply schema evolution policy to values which
+//can't be converted and numeric overflow policy to values out of DB columns range (string fallback columns are added into dataSchema)
 //change input object
-//return err if can't convert any field to DB schema type
-func (p *Processor) ApplyDBTypingToObject(dbSchema *Table, object map[string]interface{}) error {
+//return err if any field conflicts with DB schema type and the policy is strict or value overflows and the policy is reject
+func (p *Processor) ApplyDBTypingToObject(dbSchema, dataSchema *Table, object map[string]interface{}) error {
+	var bounded []string
+	conflicts := map[string]error{}
 	for k, v := range object {
 		column := dbSchema.Columns[k]
 		converted, err := typing.Convert(column.GetType(), v)
 		if err != nil {
-			return fmt.Errorf("Error applying DB type [%s] to input [%s] field with [%v] value: %v", column.GetType(), k, v, err)
+			conflicts[k] = err
+			continue
 		}
 		object[k] = converted
+
+		if column.Bounds() != nil {
+			bounded = append(bounded, k)
+		}
+	}
+
+	//string fallback columns are added into object, so the policies are applied after the iteration
+	for name, err := range conflicts {
+		if err := p.schemaEvolution.Apply(dataSchema, dbSchema.Columns[name], name, object, err); err != nil {
+			return err
+		}
+	}
+	for _, name := range bounded {
+		if err := p.numericOverflow.Apply(dataSchema, dbSchema.Columns[name], name, object); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+//return explicit type of the flattened field. Types of data_layout paths are keyed by canonical keys (see Flattener.CanonicalKey)
+func (p *Processor) typeCast(field string) (typing.DataType, bool) {
+	if dataType, ok := p.typeCasts[field]; ok {
+		return dataType, true
+	}
+
+	dataType, ok := p.typeCasts[p.flattener.CanonicalKey(field)]
+	return dataType, ok
+}
+
+//return true if field isn't typed or is typed as timestamp (explicit types override default ones)
+func (p *Processor) timestampCandidate(field string) bool {
+	if dataType, ok := p.typeCast(field); ok {
+		return dataType == typing.TIMESTAMP
+	}
+	if dataType, ok := typing.DefaultTypes[field]; ok {
+		return dataType == typing.TIMESTAMP
+	}
+
+	return true
+}
+
 //Return table representation of object and flatten object from file line
 func (p *Processor) processLine(line []byte) (*Table, map[string]interface{}, error) {
 	object := map[string]interface{}{}
@@ -153,14 +536,32 @@ func (p *Processor) processLine(line []byte) (*Table, map[string]interface{}, er
 		return nil, nil, err
 	}
 
+	p.sample(table, func() []byte { return bytes.TrimSpace(line) })
+
 	return table, flattenObject, nil
 }
 
+//put raw payload into table samples if the table isn't empty and isn't a deletion one
+func (p *Processor) sample(table *Table, payload func() []byte) {
+	if p.samplesDestination == "" || !table.Exists() || len(table.DeletionKeys) > 0 {
+		return
+	}
+
+	samples.Put(p.samplesDestination, table.Name, payload)
+}
+
 //Return table representation of object and flatten, mapped object
-//1. remove toDelete fields from object
+//1. map object (remove toDelete fields, apply mapping rules)
 //2. flatten object
-//3. map object
-//4. apply typecast
+//3. rename system columns (see SystemColumns)
+//4. check destination filter (errFiltered is returned if object doesn't match it)
+//5. extract table name or deletion table (see DeletionsConfig)
+//6. detect timestamps in custom layouts and apply typecast
+//7. check timestamp bounds (object can be redirected to another table or skipped)
+//8. detect renamed fields and merge them into old columns (see Renames)
+//9. check upsert keys values if the table is upsert one
+//10. put engine columns values (see EngineColumns) if the table has them
+//deletion events (see DeletionsConfig) don't use table name template and aren't checked by timestamp bounds in delete mode
 func (p *Processor) processObject(object map[string]interface{}) (*Table, map[string]interface{}, error) {
 	mappedObject, err := p.fieldMapper.Map(object)
 	if err != nil {
@@ -171,19 +572,43 @@ func (p *Processor) processObject(object map[string]interface{}) (*Table, map[st
 	if err != nil {
 		return nil, nil, err
 	}
+	delete(flatObject, events.ForwardedByKey)
+	p.systemColumns.Apply(flatObject)
 
-	tableName, err := p.tableNameExtractFunc(flatObject)
-	if err != nil {
-		return nil, nil, fmt.Errorf("Error extracting table name from object {%v}: %v", flatObject, err)
+	if p.filter != nil {
+		matched, err := p.filter.Match(flatObject)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !matched {
+			return nil, nil, errFiltered
+		}
 	}
-	if tableName == "" {
-		return nil, nil, fmt.Errorf("Unknown table name. Object {%v}", flatObject)
+
+	var table *Table
+	var deletionsConfig *DeletionsConfig
+	if p.deletions != nil {
+		deletionsConfig = p.deletions.Match(flatObject)
 	}
+	if deletionsConfig != nil {
+		table, flatObject, err = p.deletions.Apply(deletionsConfig, flatObject)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		tableName, err := p.tableNameExtractFunc(flatObject)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error extracting table name from object {%v}: %v", flatObject, err)
+		}
+		if tableName == "" {
+			return nil, nil, fmt.Errorf("Unknown table name. Object {%v}", flatObject)
+		}
 
-	table := &Table{Name: tableName, Columns: Columns{}}
+		table = &Table{Name: tableName, Columns: Columns{}}
+	}
 
 	//apply typecast and define column types
-	//mapping typecast overrides default typecast
+	//mapping typecast and data_layout.types override default typecast
 	for k, v := range flatObject {
 		//value type
 		resultColumnType, err := typing.TypeFromValue(v)
@@ -191,6 +616,15 @@ func (p *Processor) processObject(object map[string]interface{}) (*Table, map[st
 			return nil, nil, fmt.Errorf("Error getting type of field [%s]: %v", k, err)
 		}
 
+		//string values in custom layouts (see TimestampsConfig)
+		if str, ok := v.(string); ok && p.timestamps != nil && p.timestampCandidate(k) {
+			if t, ok := p.timestamps.Parse(str); ok {
+				v = t
+				resultColumnType = typing.TIMESTAMP
+				flatObject[k] = t
+			}
+		}
+
 		//default typecast
 		if defaultType, ok := typing.DefaultTypes[k]; ok {
 			converted, err := typing.Convert(defaultType, v)
@@ -203,7 +637,8 @@ func (p *Processor) processObject(object map[string]interface{}) (*Table, map[st
 		}
 
 		//mapping typecast
-		if toType, ok := p.typeCasts[k]; ok {
+		toType, typed := p.typeCast(k)
+		if typed {
 			converted, err := typing.Convert(toType, v)
 			if err != nil {
 				strType, getStrErr := typing.StringFromType(toType)
@@ -217,7 +652,47 @@ func (p *Processor) processObject(object map[string]interface{}) (*Table, map[st
 			flatObject[k] = converted
 		}
 
-		table.Columns[k] = NewColumn(resultColumnType)
+		//explicit decimal types and detected monetary fields
+		if decimalType := p.decimals.Type(p.flattener.CanonicalKey(k), resultColumnType, typed); decimalType != nil {
+			table.Columns[k] = NewDecimalColumn(decimalType, p.descriptions[k])
+			continue
+		}
+
+		table.Columns[k] = NewDescribedColumn(resultColumnType, p.descriptions[k])
+	}
+
+	if len(table.DeletionKeys) > 0 {
+		return table, flatObject, nil
+	}
+
+	if p.timeBounds != nil {
+		table.Name, err = p.timeBounds.Apply(table.Name, flatObject)
+		if err != nil {
+			return nil, nil, err
+		}
+		//object is out of bounds and must be skipped
+		if table.Name == "" {
+			return nil, nil, nil
+		}
+	}
+
+	if p.renames != nil {
+		p.renames.Apply(table, flatObject)
+	}
+
+	if keys := p.UpsertKeys(table.Name); len(keys) > 0 {
+		for _, key := range keys {
+			if value, ok := flatObject[key]; !ok || value == nil {
+				return nil, nil, fmt.Errorf("Upsert key field [%s] of table [%s] doesn't exist or is null", key, table.Name)
+			}
+		}
+		table.UpsertKeys = keys
+	}
+
+	if engineColumns, ok := p.engineColumns[table.Name]; ok {
+		if err := engineColumns.Apply(table, flatObject); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	return table, flatObject, nil
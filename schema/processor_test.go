@@ -1,6 +1,8 @@
 package schema
 
 import (
+	"encoding/json"
+	"github.com/ksensehq/eventnative/reports"
 	"github.com/ksensehq/eventnative/test"
 	"github.com/ksensehq/eventnative/timestamp"
 	"github.com/ksensehq/eventnative/typing"
@@ -78,14 +80,14 @@ func TestProcess(t *testing.T) {
 			},
 		},
 	}
-	p, err := NewProcessor(`{{.event_type}}_{{._timestamp.Format "2006_01"}}`, []string{})
+	p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: `{{.event_type}}_{{._timestamp.Format "2006_01"}}`})
 	require.NoError(t, err)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			fBytes, err := ioutil.ReadFile(tt.inputFilePath)
 			require.NoError(t, err)
 
-			actual, err := p.ProcessFilePayload("testfile", fBytes, false)
+			actual, err := p.ProcessFilePayload("testfile", fBytes, false, nil)
 			require.NoError(t, err)
 
 			require.Equal(t, len(tt.expected), len(actual), "Result sizes aren't equal")
@@ -101,3 +103,305 @@ func TestProcess(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessTimestampBounds(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name          string
+		config        *TimeBoundsConfig
+		inputObject   map[string]interface{}
+		expectedTable string
+	}{
+		{
+			"Object in bounds",
+			&TimeBoundsConfig{MaxAge: 24 * time.Hour, MaxFuture: time.Hour},
+			map[string]interface{}{"_timestamp": now.Format(timestamp.Layout)},
+			"events",
+		},
+		{
+			"Old object is rejected",
+			&TimeBoundsConfig{MaxAge: 24 * time.Hour},
+			map[string]interface{}{"_timestamp": now.Add(-48 * time.Hour).Format(timestamp.Layout)},
+			"",
+		},
+		{
+			"Future object is redirected",
+			&TimeBoundsConfig{MaxFuture: time.Hour, Action: RedirectAction, RedirectTable: "events_out_of_bounds"},
+			map[string]interface{}{"_timestamp": now.Add(2 * time.Hour).Format(timestamp.Layout)},
+			"events_out_of_bounds",
+		},
+		{
+			"Custom field is checked",
+			&TimeBoundsConfig{Field: "eventn_ctx_utc_time", MaxAge: time.Hour},
+			map[string]interface{}{"_timestamp": now.Format(timestamp.Layout), "eventn_ctx": map[string]interface{}{"utc_time": now.Add(-2 * time.Hour).Format(timestamp.Layout)}},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: "events", TimeBounds: tt.config})
+			require.NoError(t, err)
+
+			table, _, err := p.ProcessFact(tt.inputObject)
+			require.NoError(t, err)
+
+			if tt.expectedTable == "" {
+				require.False(t, table.Exists(), "Object must be skipped")
+			} else {
+				require.Equal(t, tt.expectedTable, table.Name, "Table names aren't equal")
+			}
+		})
+	}
+}
+
+//file destinations (s3, gcs, redshift, bigquery, snowflake) get payload bytes
+func TestProcessFilePayloadUnicode(t *testing.T) {
+	p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: "events", NonASCIIFields: HashNonASCII})
+	require.NoError(t, err)
+
+	payload := `{"_timestamp":"2020-08-02T18:23:59.757719Z","title":"Привет 👋🏽","escaped":"\ud83c\udf89","заголовок":"𝄞"}` + "\n"
+	files, err := p.ProcessFilePayload("test", []byte(payload), true, nil)
+	require.NoError(t, err)
+	require.Contains(t, files, "events")
+
+	var actual map[string]interface{}
+	require.NoError(t, json.Unmarshal(files["events"].GetPayloadBytes(), &actual))
+	require.Equal(t, "Привет 👋🏽", actual["title"])
+	require.Equal(t, "🎉", actual["escaped"])
+	require.Equal(t, "𝄞", actual["f_44c4befed987"])
+	require.Contains(t, string(files["events"].GetPayloadBytes()), `"title":"Привет 👋🏽"`)
+}
+
+func TestProcessFilePayloadReport(t *testing.T) {
+	p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: "events", TimeBounds: &TimeBoundsConfig{Field: timestamp.Key, MaxAge: time.Hour, Action: RejectAction}})
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Format(timestamp.Layout)
+	payload := `{"_timestamp":"` + now + `","id":1}` + "\n" +
+		`{"_timestamp":` + "\n" +
+		`{"id":3}` + "\n" +
+		`{"_timestamp":"2020-08-02T18:23:59.757719Z","id":4}` + "\n" +
+		`{"_timestamp":"` + now + `","id":5}` + "\n"
+
+	report := reports.NewLoadReport("test", "destination", "token", 5)
+	files, err := p.ProcessFilePayload("test", []byte(payload), false, report)
+	require.NoError(t, err)
+	require.Equal(t, 2, files["events"].Size())
+	require.Equal(t, report, files["events"].Report)
+
+	require.Equal(t, 3, report.Skipped)
+	require.Equal(t, map[string]int{reports.MalformedReason: 2, reports.TimeBoundsReason: 1}, report.Reasons)
+	require.Len(t, report.Samples, 2)
+
+	eventTime, err := time.Parse(timestamp.Layout, now)
+	require.NoError(t, err)
+	require.Equal(t, map[string]time.Time{"events": eventTime}, report.EventTimes)
+
+	report.Finish(nil)
+	require.Equal(t, 2, report.Loaded)
+}
+
+func TestProcessFactUpsertKeys(t *testing.T) {
+	tests := []struct {
+		name          string
+		inputObject   map[string]interface{}
+		expectedTable string
+		expectedKeys  []string
+		expectedErr   string
+	}{
+		{
+			"Upsert table object",
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "identify", "user": map[string]interface{}{"id": "u1"}},
+			"identify",
+			[]string{"user_id"},
+			"",
+		},
+		{
+			"Append-only table object",
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "pageview"},
+			"pageview",
+			nil,
+			"",
+		},
+		{
+			"Upsert table object without key",
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "identify"},
+			"",
+			nil,
+			"Upsert key field [user_id] of table [identify] doesn't exist or is null",
+		},
+		{
+			"Upsert table object with null key",
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "identify", "user": map[string]interface{}{"id": nil}},
+			"",
+			nil,
+			"Upsert key field [user_id] of table [identify] doesn't exist or is null",
+		},
+	}
+	p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: "{{.event_type}}", Upsert: []*UpsertConfig{{Table: "identify", Keys: []string{"user_id"}}}})
+	require.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, _, err := p.ProcessFact(tt.inputObject)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedTable, table.Name)
+			require.Equal(t, tt.expectedKeys, table.UpsertKeys)
+			require.Equal(t, tt.expectedKeys, p.UpsertKeys(tt.expectedTable))
+		})
+	}
+}
+
+func TestProcessFactAllTablesUpsertKeys(t *testing.T) {
+	p, err := NewProcessor(&ProcessorConfig{
+		TableNameTemplate: "{{.event_type}}",
+		Upsert: []*UpsertConfig{
+			{Table: "identify", Keys: []string{"user_id"}},
+			{Table: AllTables, Keys: []string{"eventn_ctx_event_id"}},
+		},
+	})
+	require.NoError(t, err)
+
+	table, _, err := p.ProcessFact(map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "pageview", "eventn_ctx": map[string]interface{}{"event_id": "e1"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"eventn_ctx_event_id"}, table.UpsertKeys)
+	require.Equal(t, []string{"eventn_ctx_event_id"}, p.UpsertKeys("pageview"))
+
+	//table config overrides all tables one
+	table, _, err = p.ProcessFact(map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "identify", "user": map[string]interface{}{"id": "u1"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"user_id"}, table.UpsertKeys)
+
+	_, _, err = p.ProcessFact(map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "pageview"})
+	require.EqualError(t, err, "Upsert key field [eventn_ctx_event_id] of table [pageview] doesn't exist or is null")
+}
+
+func TestNewUpsertKeys(t *testing.T) {
+	tests := []struct {
+		name        string
+		configs     []*UpsertConfig
+		expected    map[string][]string
+		expectedErr string
+	}{
+		{"Empty config", nil, map[string][]string{}, ""},
+		{
+			"Several tables",
+			[]*UpsertConfig{{Table: "identify", Keys: []string{"user_id"}}, {Table: "user_profile", Keys: []string{"project_id", "user_id"}}},
+			map[string][]string{"identify": {"user_id"}, "user_profile": {"project_id", "user_id"}},
+			"",
+		},
+		{"Without keys", []*UpsertConfig{{Table: "identify"}}, nil, "upsert keys are required parameter for table [identify]"},
+		{"Without table", []*UpsertConfig{{Keys: []string{"user_id"}}}, nil, "upsert table is required parameter"},
+		{
+			"Duplicated table",
+			[]*UpsertConfig{{Table: "identify", Keys: []string{"user_id"}}, {Table: "identify", Keys: []string{"id"}}},
+			nil,
+			"upsert table [identify] is configured more than once",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := NewUpsertKeys(tt.configs)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestProcessFactArrays(t *testing.T) {
+	p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: "events", Arrays: true})
+	require.NoError(t, err)
+
+	table, object, err := p.ProcessFact(map[string]interface{}{
+		"_timestamp": "2020-08-02T18:23:59.757719Z",
+		"tags":       []interface{}{"a", "b"},
+		"scores":     []interface{}{1.0, 2.5},
+		"items":      []interface{}{map[string]interface{}{"id": 1.0}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, typing.ARRAY_STRING, table.Columns["tags"].GetType())
+	require.Equal(t, typing.ARRAY_FLOAT64, table.Columns["scores"].GetType())
+	require.Equal(t, typing.STRING, table.Columns["items"].GetType())
+
+	//existing string column gets arrays as JSON, array column gets converted elements
+	dbSchema := &Table{Name: "events", Columns: Columns{
+		"_timestamp": NewColumn(typing.TIMESTAMP),
+		"tags":       NewColumn(typing.STRING),
+		"scores":     NewColumn(typing.ARRAY_FLOAT64),
+		"items":      NewColumn(typing.STRING),
+	}}
+	_, object, err = p.ProcessFact(map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "tags": []interface{}{"a"}, "scores": []interface{}{1.0}})
+	require.NoError(t, err)
+	require.NoError(t, p.ApplyDBTypingToObject(dbSchema, table, object))
+	require.Equal(t, `["a"]`, object["tags"])
+	require.Equal(t, []interface{}{1.0}, object["scores"])
+}
+
+func BenchmarkProcessFact(b *testing.B) {
+	objects := test.ReadObjects(b, test.BenchEventsPath)
+	p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: `{{.event_type}}_{{._timestamp.Format "2006_01"}}`})
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := p.ProcessFact(objects[i%len(objects)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessFilePayload(b *testing.B) {
+	payload, err := ioutil.ReadFile(test.BenchEventsPath)
+	require.NoError(b, err)
+	p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: `{{.event_type}}_{{._timestamp.Format "2006_01"}}`})
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(payload)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.ProcessFilePayload("bench", payload, true, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+//every object field is converted from processed type to DB type (e.g. numbers and timestamps into strings)
+func BenchmarkApplyDBTypingToObject(b *testing.B) {
+	p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: "events"})
+	require.NoError(b, err)
+
+	var flatObjects []map[string]interface{}
+	dbSchema := &Table{Name: "events", Columns: Columns{}}
+	for _, object := range test.ReadObjects(b, test.BenchEventsPath) {
+		table, flatObject, err := p.ProcessFact(object)
+		require.NoError(b, err)
+		for name := range table.Columns {
+			dbSchema.Columns[name] = NewColumn(typing.STRING)
+		}
+		flatObjects = append(flatObjects, flatObject)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		flatObject := flatObjects[i%len(flatObjects)]
+		object := make(map[string]interface{}, len(flatObject))
+		for k, v := range flatObject {
+			object[k] = v
+		}
+		if err := p.ApplyDBTypingToObject(dbSchema, dbSchema, object); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ksensehq/eventnative/typing"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	SuggestRenames = "suggest"
+	ApplyRenames   = "apply"
+
+	//min profile correlation of the new column with the old one
+	renamesCorrelation = 0.99
+	//every renamesSampleEvery event of the table is put into columns profiles
+	renamesSampleEvery = 10
+	//both columns must be in at least renamesMinSamples sampled events
+	renamesMinSamples = 10
+	//renames are detected every renamesCheckEvery sampled events of the table
+	renamesCheckEvery = 100
+	//the old column is empty if it hasn't been in the last renamesEmptyAfter events of the table
+	//the new column must appear before or within renamesEmptyAfter events after the old one has become empty
+	renamesEmptyAfter = 1000
+	//columns of the table over the limit aren't tracked
+	renamesMaxColumns = 500
+)
+
+//Rename is a detected instrumentation rename of the table field (e.g. userId -> user_id)
+//mapping is a data_layout.mapping rule which merges the new field into the old column (for top-level fields)
+//applied is true if values of the new column are written into the old one
+type Rename struct {
+	Table       string    `json:"table"`
+	OldColumn   string    `json:"old_column"`
+	NewColumn   string    `json:"new_column"`
+	Correlation float64   `json:"correlation"`
+	Mapping     string    `json:"mapping"`
+	Applied     bool      `json:"applied"`
+	DetectedAt  time.Time `json:"detected_at"`
+}
+
+//Renames is a mapping assistant which detects renamed fields per table (data_layout.renames):
+//the new column is a rename of the old one if the old column has become empty (while the new one has appeared),
+//they haven't been in the same event, their types are equal and profiles are correlated (>= 0.99).
+//Column profile is a share of events with every other column among events with the column
+//suggest: renames are logged and listed via admin API
+//apply: values of the new column are written into the old one, so the table isn't split into two columns
+//Detected renames are kept in file (if it is provided), so they are applied after restart
+type Renames struct {
+	apply     bool
+	protected map[string]bool
+
+	mutex  sync.Mutex
+	file   string
+	tables map[string]*renamesTable
+}
+
+//observed columns and detected renames of one table
+type renamesTable struct {
+	events  int64
+	sampled int64
+	columns map[string]*renamesColumn
+	//new column: rename
+	renames map[string]*Rename
+}
+
+//renamesColumn is column stats: firstSeen and lastSeen are numbers of table events,
+//count and siblings (other columns of the same events) are counted in sampled events
+type renamesColumn struct {
+	dataType  typing.DataType
+	firstSeen int64
+	lastSeen  int64
+	count     int64
+	siblings  map[string]int64
+}
+
+//NewRenames return Renames or nil if mode is empty. Protected columns (system ones) aren't renamed
+func NewRenames(mode string, protected []string) (*Renames, error) {
+	switch mode {
+	case "":
+		return nil, nil
+	case SuggestRenames, ApplyRenames:
+	default:
+		return nil, fmt.Errorf("Unknown renames value: %s. Supported: %s, %s", mode, SuggestRenames, ApplyRenames)
+	}
+
+	protectedColumns := map[string]bool{}
+	for _, column := range protected {
+		protectedColumns[column] = true
+	}
+
+	return &Renames{apply: mode == ApplyRenames, protected: protectedColumns, tables: map[string]*renamesTable{}}, nil
+}
+
+//Persist load detected renames from the file (if it exists) and write them into it on every detection
+func (r *Renames) Persist(file string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.file = file
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("Error creating renames dir [%s]: %v", filepath.Dir(file), err)
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Error reading renames file [%s]: %v", file, err)
+	}
+
+	var renames []*Rename
+	if err := json.Unmarshal(b, &renames); err != nil {
+		return fmt.Errorf("Error unmarshalling renames file [%s]: %v", file, err)
+	}
+	for _, rename := range renames {
+		rename.Applied = r.apply
+		r.table(rename.Table).renames[rename.NewColumn] = rename
+	}
+
+	return nil
+}
+
+//List return detected renames sorted by table and new column
+func (r *Renames) List() []*Rename {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.list()
+}
+
+//Apply write new columns values into old columns (in apply mode) and observe object columns
+//change input table and object
+func (r *Renames) Apply(table *Table, object map[string]interface{}) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	rt := r.table(table.Name)
+	if r.apply {
+		for newColumn, rename := range rt.renames {
+			value, ok := object[newColumn]
+			if !ok {
+				continue
+			}
+			if _, ok := object[rename.OldColumn]; ok {
+				continue
+			}
+			object[rename.OldColumn] = value
+			delete(object, newColumn)
+			table.Columns[rename.OldColumn] = table.Columns[newColumn]
+			delete(table.Columns, newColumn)
+		}
+	}
+
+	rt.events++
+	sampled := rt.events%renamesSampleEvery == 0
+	var columns []string
+	for name, column := range table.Columns {
+		if r.protected[name] {
+			continue
+		}
+		stats, ok := rt.columns[name]
+		if !ok {
+			if len(rt.columns) >= renamesMaxColumns {
+				continue
+			}
+			stats = &renamesColumn{firstSeen: rt.events, siblings: map[string]int64{}}
+			rt.columns[name] = stats
+		}
+		stats.dataType = column.GetType()
+		stats.lastSeen = rt.events
+		if sampled {
+			stats.count++
+			columns = append(columns, name)
+		}
+	}
+
+	if !sampled {
+		return
+	}
+	for _, name := range columns {
+		stats := rt.columns[name]
+		for _, sibling := range columns {
+			if sibling != name {
+				stats.siblings[sibling]++
+			}
+		}
+	}
+
+	rt.sampled++
+	if rt.sampled%renamesCheckEvery == 0 {
+		r.detect(table.Name, rt)
+	}
+}
+
+//find old columns of new ones which don't have renames yet. Must be called under mutex
+func (r *Renames) detect(tableName string, rt *renamesTable) {
+	detected := false
+	for newName, newColumn := range rt.columns {
+		if _, ok := rt.renames[newName]; ok || newColumn.count < renamesMinSamples {
+			continue
+		}
+
+		var best *Rename
+		for oldName, oldColumn := range rt.columns {
+			//old app versions can send the old field while the new one is being rolled out
+			empty := rt.events-oldColumn.lastSeen >= renamesEmptyAfter && newColumn.firstSeen-oldColumn.lastSeen <= renamesEmptyAfter
+			if oldName == newName || !empty || oldColumn.firstSeen >= newColumn.firstSeen || oldColumn.count < renamesMinSamples ||
+				oldColumn.dataType != newColumn.dataType || oldColumn.siblings[newName] > 0 {
+				continue
+			}
+
+			correlation := profilesCorrelation(oldName, oldColumn, newName, newColumn)
+			if correlation < renamesCorrelation || (best != nil && correlation <= best.Correlation) {
+				continue
+			}
+			best = &Rename{Table: tableName, OldColumn: oldName, NewColumn: newName, Correlation: correlation,
+				Mapping: fmt.Sprintf("/%s -> /%s", newName, oldName), Applied: r.apply, DetectedAt: time.Now().UTC()}
+		}
+
+		if best != nil {
+			rt.renames[newName] = best
+			detected = true
+			if r.apply {
+				log.Printf("Column %s of table %s is a rename of %s column (correlation: %.3f). Its values will be written into %s column",
+					newName, tableName, best.OldColumn, best.Correlation, best.OldColumn)
+			} else {
+				log.Printf("Column %s of table %s looks like a rename of %s column (correlation: %.3f). Merge them with data_layout.mapping rule: %s",
+					newName, tableName, best.OldColumn, best.Correlation, best.Mapping)
+			}
+		}
+	}
+
+	if detected && r.file != "" {
+		b, err := json.MarshalIndent(r.list(), "", "  ")
+		if err != nil {
+			log.Println("Error marshalling renames:", err)
+			return
+		}
+		if err := ioutil.WriteFile(r.file, b, 0644); err != nil {
+			log.Printf("Error writing renames file [%s]: %v", r.file, err)
+		}
+	}
+}
+
+//return table stats (create if they don't exist). Must be called under mutex
+func (r *Renames) table(name string) *renamesTable {
+	rt, ok := r.tables[name]
+	if !ok {
+		rt = &renamesTable{columns: map[string]*renamesColumn{}, renames: map[string]*Rename{}}
+		r.tables[name] = rt
+	}
+
+	return rt
+}
+
+//must be called under mutex
+func (r *Renames) list() []*Rename {
+	result := []*Rename{}
+	for _, rt := range r.tables {
+		for _, rename := range rt.renames {
+			copied := *rename
+			result = append(result, &copied)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Table != result[j].Table {
+			return result[i].Table < result[j].Table
+		}
+		return result[i].NewColumn < result[j].NewColumn
+	})
+
+	return result
+}
+
+//return cosine similarity of columns profiles (without each other)
+func profilesCorrelation(oldName string, oldColumn *renamesColumn, newName string, newColumn *renamesColumn) float64 {
+	siblings := map[string]bool{}
+	for sibling := range oldColumn.siblings {
+		siblings[sibling] = true
+	}
+	for sibling := range newColumn.siblings {
+		siblings[sibling] = true
+	}
+	delete(siblings, oldName)
+	delete(siblings, newName)
+
+	var product, oldNorm, newNorm float64
+	for sibling := range siblings {
+		oldShare := float64(oldColumn.siblings[sibling]) / float64(oldColumn.count)
+		newShare := float64(newColumn.siblings[sibling]) / float64(newColumn.count)
+		product += oldShare * newShare
+		oldNorm += oldShare * oldShare
+		newNorm += newShare * newShare
+	}
+	if oldNorm == 0 || newNorm == 0 {
+		return 0
+	}
+
+	return product / math.Sqrt(oldNorm*newNorm)
+}
@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+//apply count events with the user id field and the same other columns
+func observeRenames(renames *Renames, userIDField string, count int, extra map[string]interface{}) {
+	for i := 0; i < count; i++ {
+		object := map[string]interface{}{"_timestamp": "2020-10-10T10:10:10.000000Z", "event_type": "identify", "url": "https://ksense.io", userIDField: "u1"}
+		for k, v := range extra {
+			object[k] = v
+		}
+		table := &Table{Name: "events", Columns: Columns{}}
+		for k := range object {
+			table.Columns[k] = NewColumn(typing.STRING)
+		}
+		renames.Apply(table, object)
+	}
+}
+
+func TestNewRenames(t *testing.T) {
+	renames, err := NewRenames("", nil)
+	require.NoError(t, err)
+	require.Nil(t, renames)
+
+	_, err = NewRenames("merge", nil)
+	require.EqualError(t, err, "Unknown renames value: merge. Supported: suggest, apply")
+}
+
+func TestRenamesDetection(t *testing.T) {
+	renames, err := NewRenames(SuggestRenames, []string{"_timestamp"})
+	require.NoError(t, err)
+
+	//coupon field has been removed before userId, but it has a different profile
+	observeRenames(renames, "userId", 100, map[string]interface{}{"coupon": "c1"})
+	observeRenames(renames, "userId", 400, nil)
+	observeRenames(renames, "user_id", 500, nil)
+	require.Empty(t, renames.List(), "userId column isn't empty yet")
+
+	observeRenames(renames, "user_id", 1000, nil)
+	detected := renames.List()
+	require.Len(t, detected, 1)
+	require.Equal(t, "events", detected[0].Table)
+	require.Equal(t, "userId", detected[0].OldColumn)
+	require.Equal(t, "user_id", detected[0].NewColumn)
+	require.Equal(t, "/user_id -> /userId", detected[0].Mapping)
+	require.False(t, detected[0].Applied)
+	require.GreaterOrEqual(t, detected[0].Correlation, 0.99, "userId profile has coupon column in a fifth of events")
+
+	//suggest mode doesn't change objects
+	object := map[string]interface{}{"user_id": "u2"}
+	table := &Table{Name: "events", Columns: Columns{"user_id": NewColumn(typing.STRING)}}
+	renames.Apply(table, object)
+	require.Equal(t, map[string]interface{}{"user_id": "u2"}, object)
+}
+
+func TestRenamesApply(t *testing.T) {
+	dir, err := ioutil.TempDir("", "renames")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := path.Join(dir, "renames", "pg.json")
+
+	renames, err := NewRenames(ApplyRenames, []string{"_timestamp"})
+	require.NoError(t, err)
+	require.NoError(t, renames.Persist(file))
+	observeRenames(renames, "userId", 500, nil)
+	observeRenames(renames, "user_id", 1500, nil)
+	require.Len(t, renames.List(), 1)
+
+	//detected renames are applied after restart
+	restarted, err := NewRenames(ApplyRenames, []string{"_timestamp"})
+	require.NoError(t, err)
+	require.NoError(t, restarted.Persist(file))
+	require.Equal(t, renames.List(), restarted.List())
+
+	object := map[string]interface{}{"user_id": "u2", "url": "https://ksense.io"}
+	table := &Table{Name: "events", Columns: Columns{"user_id": NewColumn(typing.STRING), "url": NewColumn(typing.STRING)}}
+	restarted.Apply(table, object)
+	require.Equal(t, map[string]interface{}{"userId": "u2", "url": "https://ksense.io"}, object)
+	require.Equal(t, Columns{"userId": NewColumn(typing.STRING), "url": NewColumn(typing.STRING)}, table.Columns)
+
+	//the old field isn't overwritten
+	object = map[string]interface{}{"user_id": "u2", "userId": "u1"}
+	table = &Table{Name: "events", Columns: Columns{"user_id": NewColumn(typing.STRING), "userId": NewColumn(typing.STRING)}}
+	restarted.Apply(table, object)
+	require.Equal(t, map[string]interface{}{"user_id": "u2", "userId": "u1"}, object)
+}
+
+func TestProcessFactRenames(t *testing.T) {
+	p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: "events", Renames: ApplyRenames})
+	require.NoError(t, err)
+
+	process := func(field string, count int) (*Table, map[string]interface{}) {
+		var table *Table
+		var object map[string]interface{}
+		for i := 0; i < count; i++ {
+			table, object, err = p.ProcessFact(map[string]interface{}{"_timestamp": "2020-10-10T10:10:10.000000Z", "event_type": "identify", "user": map[string]interface{}{field: "u1"}})
+			require.NoError(t, err)
+		}
+		return table, object
+	}
+	process("userId", 500)
+	table, object := process("user_id", 1500)
+	require.Contains(t, table.Columns, "user_user_id", "rename is detected after the last event")
+	require.Equal(t, "u1", object["user_user_id"])
+
+	table, object = process("user_id", 1)
+	require.Contains(t, table.Columns, "user_userid")
+	require.NotContains(t, table.Columns, "user_user_id")
+	require.Equal(t, "u1", object["user_userid"])
+}
@@ -0,0 +1,81 @@
+package schema
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/typing"
+)
+
+const (
+	StrictEvolution  = "strict"
+	LenientEvolution = "lenient"
+
+	//string fallback column name is <column>_string
+	conflictColumnSuffix = "_string"
+)
+
+//TypeConflictError is returned when value can't be converted to DB column type and schema evolution policy is strict
+type TypeConflictError struct {
+	Field      string
+	Value      interface{}
+	ColumnType typing.DataType
+	Err        error
+}
+
+func (tce *TypeConflictError) Error() string {
+	return fmt.Sprintf("Value %v of [%s] field conflicts with DB column type %s: %v", tce.Value, tce.Field, tce.ColumnType, tce.Err)
+}
+
+//SchemaEvolution applies a policy to values which can't be converted to existing DB columns types
+//(e.g. string value of integer column):
+//strict: object is rejected (default). It is skipped in batch mode and isn't inserted in stream mode
+//(it is written into dead-letter file if retry is configured)
+//lenient: value is moved as string into <column>_string column
+type SchemaEvolution struct {
+	policy string
+}
+
+//NewSchemaEvolution return SchemaEvolution or error if policy is unknown. Default policy is strict
+func NewSchemaEvolution(policy string) (*SchemaEvolution, error) {
+	switch policy {
+	case "":
+		policy = StrictEvolution
+	case StrictEvolution, LenientEvolution:
+	default:
+		return nil, fmt.Errorf("Unknown schema_evolution value: %s. Supported: %s, %s", policy, StrictEvolution, LenientEvolution)
+	}
+
+	return &SchemaEvolution{policy: policy}, nil
+}
+
+//Apply the policy to object field value which can't be converted to DB column type
+//string fallback column is added into dataSchema
+//return err if the policy is strict
+func (se *SchemaEvolution) Apply(dataSchema *Table, column Column, name string, object map[string]interface{}, err error) error {
+	if se.policy == StrictEvolution {
+		return &TypeConflictError{Field: name, Value: object[name], ColumnType: column.GetType(), Err: err}
+	}
+
+	value := object[name]
+	delete(object, name)
+	converted, convertErr := typing.Convert(typing.STRING, value)
+	if convertErr != nil {
+		converted = fmt.Sprint(value)
+	}
+	object[name+conflictColumnSuffix] = converted
+	if _, ok := dataSchema.Columns[name+conflictColumnSuffix]; !ok {
+		dataSchema.Columns[name+conflictColumnSuffix] = NewColumn(typing.STRING)
+	}
+
+	return nil
+}
+
+//FitConflicts replace types of dataSchema columns which can't be converted to existing DB columns types with DB ones,
+//so tables aren't patched with conflicting types. Conflicting values are handled by the policy while DB typing
+func FitConflicts(dbSchema, dataSchema *Table) {
+	for name, column := range dataSchema.Columns {
+		dbColumn, ok := dbSchema.Columns[name]
+		if ok && !typing.IsConvertible(column.GetType(), dbColumn.GetType()) {
+			dataSchema.Columns[name] = dbColumn
+		}
+	}
+}
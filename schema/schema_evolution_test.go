@@ -0,0 +1,103 @@
+package schema
+
+import (
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestApplyDBTypingSchemaEvolution(t *testing.T) {
+	dbSchema := &Table{Name: "events", Columns: Columns{
+		"id":    NewColumn(typing.INT64),
+		"price": NewColumn(typing.FLOAT64),
+		"title": NewColumn(typing.STRING),
+	}}
+	tests := []struct {
+		name            string
+		policy          string
+		expected        []map[string]interface{}
+		expectedColumns []string
+		expectedReasons map[string]int
+	}{
+		{
+			"Strict",
+			"",
+			[]map[string]interface{}{{"id": float64(1), "price": 1.5, "title": "a"}, {"id": float64(3), "price": float64(2), "title": "12"}},
+			[]string{"id", "price", "title"},
+			map[string]int{reports.TypeConflictReason: 1},
+		},
+		{
+			"Lenient",
+			LenientEvolution,
+			[]map[string]interface{}{
+				{"id": float64(1), "price": 1.5, "title": "a"},
+				{"id_string": "abc", "price_string": "[1,2]", "title": "b"},
+				{"id": float64(3), "price": float64(2), "title": "12"},
+			},
+			[]string{"id", "id_string", "price", "price_string", "title"},
+			map[string]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: "events", SchemaEvolution: tt.policy})
+			require.NoError(t, err)
+
+			pf := NewProcessedFile("file1", &Table{Name: "events", Columns: Columns{
+				"id":    NewColumn(typing.INT64),
+				"price": NewColumn(typing.FLOAT64),
+				"title": NewColumn(typing.STRING),
+			}})
+			pf.Report = reports.NewLoadReport("file1", "postgres", "", 3)
+			pf.Add(&Table{Name: "events", Columns: Columns{}}, map[string]interface{}{"id": float64(1), "price": 1.5, "title": "a"})
+			pf.Add(&Table{Name: "events", Columns: Columns{}}, map[string]interface{}{"id": "abc", "price": []interface{}{1.0, 2.0}, "title": "b"})
+			pf.Add(&Table{Name: "events", Columns: Columns{}}, map[string]interface{}{"id": float64(3), "price": float64(2), "title": 12.0})
+
+			require.NoError(t, p.ApplyDBTyping(dbSchema, pf))
+			require.Equal(t, tt.expected, pf.GetPayload())
+			require.Equal(t, tt.expectedReasons, pf.Report.Reasons)
+
+			var columns []string
+			for name := range pf.DataSchema.Columns {
+				columns = append(columns, name)
+			}
+			require.ElementsMatch(t, tt.expectedColumns, columns)
+		})
+	}
+
+	_, err := NewSchemaEvolution("loose")
+	require.EqualError(t, err, "Unknown schema_evolution value: loose. Supported: strict, lenient")
+}
+
+func TestApplyDBTypingToObjectTypeConflict(t *testing.T) {
+	p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: "events", SchemaEvolution: StrictEvolution})
+	require.NoError(t, err)
+
+	dbSchema := &Table{Name: "events", Columns: Columns{"id": NewColumn(typing.INT64)}}
+	err = p.ApplyDBTypingToObject(dbSchema, &Table{Name: "events", Columns: Columns{}}, map[string]interface{}{"id": "abc"})
+	require.IsType(t, &TypeConflictError{}, err)
+	require.EqualError(t, err, "Value abc of [id] field conflicts with DB column type INT64: No rule for converting STRING to INT64")
+}
+
+func TestFitConflicts(t *testing.T) {
+	dbSchema := &Table{Name: "events", Columns: Columns{
+		"id":    NewColumn(typing.INT64),
+		"price": NewColumn(typing.FLOAT64),
+	}}
+	dataSchema := &Table{Name: "events", Columns: Columns{
+		"id":    NewColumn(typing.STRING),
+		"price": NewColumn(typing.INT64),
+		"title": NewColumn(typing.STRING),
+	}}
+
+	FitConflicts(dbSchema, dataSchema)
+	require.Equal(t, typing.INT64, dataSchema.Columns["id"].GetType())
+	require.Equal(t, typing.INT64, dataSchema.Columns["price"].GetType())
+	require.Equal(t, typing.STRING, dataSchema.Columns["title"].GetType())
+
+	diff, err := dbSchema.Diff(dataSchema)
+	require.NoError(t, err)
+	require.Len(t, diff.Columns, 1)
+	require.Contains(t, diff.Columns, "title")
+}
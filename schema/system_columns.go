@@ -0,0 +1,98 @@
+package schema
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/timestamp"
+	"sort"
+)
+
+const (
+	EventIDColumn = "eventn_ctx_event_id"
+	TokenColumn   = "api_key"
+	SourceColumn  = "src"
+)
+
+//columns which EventNative puts into every event
+var systemColumnNames = []string{timestamp.Key, EventIDColumn, TokenColumn, SourceColumn}
+
+//SystemColumns renames EventNative system columns of flattened objects into destination specific names
+//configured with data_layout.system_columns: system column -> destination column e.g. _timestamp: event_time
+//other data_layout settings (table name template, upsert keys, etc) refer to destination columns names
+type SystemColumns struct {
+	names map[string]string
+}
+
+//NewSystemColumns return SystemColumns or nil if nothing is renamed
+//return err if config has unknown system column, empty destination column or the same destination column twice
+func NewSystemColumns(config map[string]string) (*SystemColumns, error) {
+	if len(config) == 0 {
+		return nil, nil
+	}
+
+	known := map[string]bool{}
+	for _, name := range systemColumnNames {
+		known[name] = true
+	}
+
+	var columns []string
+	for column := range config {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	names := map[string]string{}
+	renamed := map[string]string{}
+	for _, column := range columns {
+		name := config[column]
+		if !known[column] {
+			return nil, fmt.Errorf("data_layout.system_columns: unknown system column [%s]. Available columns: %v", column, systemColumnNames)
+		}
+		if name == "" {
+			return nil, fmt.Errorf("data_layout.system_columns: destination column of [%s] can't be empty", column)
+		}
+		if another, ok := renamed[name]; ok {
+			return nil, fmt.Errorf("data_layout.system_columns: [%s] and [%s] can't be written into the same column [%s]", another, column, name)
+		}
+		renamed[name] = column
+		if name != column {
+			names[column] = name
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	return &SystemColumns{names: names}, nil
+}
+
+//Name return destination column name of system column (or column itself if it isn't renamed)
+func (sc *SystemColumns) Name(column string) string {
+	if sc == nil {
+		return column
+	}
+
+	if name, ok := sc.names[column]; ok {
+		return name
+	}
+
+	return column
+}
+
+//Apply rename system columns of flattened object
+func (sc *SystemColumns) Apply(object map[string]interface{}) {
+	if sc == nil {
+		return
+	}
+
+	values := map[string]interface{}{}
+	for column := range sc.names {
+		if value, ok := object[column]; ok {
+			values[column] = value
+			delete(object, column)
+		}
+	}
+	for column, value := range values {
+		object[sc.names[column]] = value
+	}
+}
@@ -0,0 +1,84 @@
+package schema
+
+import (
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestNewSystemColumns(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      map[string]string
+		expected    *SystemColumns
+		expectedErr string
+	}{
+		{"Empty config", nil, nil, ""},
+		{"Same names", map[string]string{"_timestamp": "_timestamp"}, nil, ""},
+		{"Renamed columns", map[string]string{"_timestamp": "event_time", "api_key": "token", "src": "src"},
+			&SystemColumns{names: map[string]string{"_timestamp": "event_time", "api_key": "token"}}, ""},
+		{"Swapped columns", map[string]string{"api_key": "src", "src": "api_key"},
+			&SystemColumns{names: map[string]string{"api_key": "src", "src": "api_key"}}, ""},
+		{"Unknown column", map[string]string{"user_id": "uid"}, nil,
+			"data_layout.system_columns: unknown system column [user_id]. Available columns: [_timestamp eventn_ctx_event_id api_key src]"},
+		{"Empty name", map[string]string{"src": ""}, nil, "data_layout.system_columns: destination column of [src] can't be empty"},
+		{"Duplicated name", map[string]string{"api_key": "source", "src": "source"}, nil,
+			"data_layout.system_columns: [api_key] and [src] can't be written into the same column [source]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := NewSystemColumns(tt.config)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestSystemColumnsApply(t *testing.T) {
+	sc, err := NewSystemColumns(map[string]string{"api_key": "src", "src": "api_key", "eventn_ctx_event_id": "id"})
+	require.NoError(t, err)
+
+	object := map[string]interface{}{"api_key": "token1", "src": "eventn", "user_id": "u1"}
+	sc.Apply(object)
+	require.Equal(t, map[string]interface{}{"api_key": "eventn", "src": "token1", "user_id": "u1"}, object)
+
+	var nilColumns *SystemColumns
+	nilColumns.Apply(object)
+	require.Equal(t, "_timestamp", nilColumns.Name(timestamp.Key))
+}
+
+func TestProcessFactSystemColumns(t *testing.T) {
+	now := time.Now().UTC()
+	p, err := NewProcessor(&ProcessorConfig{
+		TableNameTemplate: `{{.event_type}}_{{.event_time.Format "2006"}}`,
+		TimeBounds:        &TimeBoundsConfig{MaxAge: time.Hour},
+		Upsert:            []*UpsertConfig{{Table: "identify_" + now.Format("2006"), Keys: []string{"id"}}},
+		SystemColumns:     map[string]string{"_timestamp": "event_time", "eventn_ctx_event_id": "id"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "event_time", p.SystemColumn(timestamp.Key))
+	require.Equal(t, "src", p.SystemColumn(SourceColumn))
+
+	table, object, err := p.ProcessFact(events.Fact{"_timestamp": now.Format(timestamp.Layout), "event_type": "identify",
+		"eventn_ctx": map[string]interface{}{"event_id": "e1"}})
+	require.NoError(t, err)
+	require.Equal(t, "identify_"+now.Format("2006"), table.Name)
+	require.Equal(t, []string{"id"}, table.UpsertKeys)
+	require.Equal(t, Columns{"event_time": NewColumn(typing.TIMESTAMP), "event_type": NewColumn(typing.STRING), "id": NewColumn(typing.STRING)}, table.Columns)
+	require.Equal(t, "e1", object["id"])
+	require.IsType(t, time.Time{}, object["event_time"])
+
+	//time bounds are checked on renamed column
+	table, _, err = p.ProcessFact(events.Fact{"_timestamp": now.Add(-2 * time.Hour).Format(timestamp.Layout), "event_type": "identify",
+		"eventn_ctx": map[string]interface{}{"event_id": "e2"}})
+	require.NoError(t, err)
+	require.Nil(t, table)
+}
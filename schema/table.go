@@ -44,10 +44,15 @@ func (c Columns) Header() string {
 	return header
 }
 
+//Table is a table representation with columns
+//UpsertKeys aren't empty if rows with the same keys values must be replaced (see UpsertConfig)
+//DeletionKeys aren't empty if objects of the table are keys values of rows which must be deleted (see DeletionsConfig)
 type Table struct {
-	Name    string
-	Columns Columns
-	Version int64
+	Name         string
+	Columns      Columns
+	Version      int64
+	UpsertKeys   []string
+	DeletionKeys []string
 }
 
 //Return true if there is at least one column
@@ -83,6 +88,9 @@ func (t Table) Diff(another *Table) (*Table, error) {
 type Column struct {
 	dataType       *typing.DataType
 	typeOccurrence map[typing.DataType]bool
+	bounds         *NumericBounds
+	description    string
+	decimal        *DecimalType
 }
 
 func NewColumn(t typing.DataType) Column {
@@ -92,6 +100,57 @@ func NewColumn(t typing.DataType) Column {
 	}
 }
 
+//NewBoundedColumn return column of numeric DB type with limited value range (e.g. integer or numeric(38,18))
+func NewBoundedColumn(t typing.DataType, bounds *NumericBounds) Column {
+	column := NewColumn(t)
+	column.bounds = bounds
+	return column
+}
+
+//NewDescribedColumn return column with description which is written as column comment (see ColumnDescriptionConfig)
+func NewDescribedColumn(t typing.DataType, description string) Column {
+	column := NewColumn(t)
+	column.description = description
+	return column
+}
+
+//NewDecimalColumn return DECIMAL column with precision and scale (see Decimals)
+func NewDecimalColumn(decimalType *DecimalType, description string) Column {
+	column := NewDescribedColumn(typing.DECIMAL, description)
+	column.decimal = decimalType
+	return column
+}
+
+//Decimal return precision and scale of DECIMAL column or nil
+func (c Column) Decimal() *DecimalType {
+	if c.GetType() != typing.DECIMAL {
+		return nil
+	}
+
+	return c.decimal
+}
+
+//Description return column description or empty string
+func (c Column) Description() string {
+	return c.description
+}
+
+//Bounds return DB column value range: explicit one, int64 range for INT64 columns, decimal range for DECIMAL columns
+//or nil if it isn't limited
+func (c Column) Bounds() *NumericBounds {
+	if c.bounds != nil {
+		return c.bounds
+	}
+	if c.GetType() == typing.INT64 {
+		return Int64Bounds
+	}
+	if decimal := c.Decimal(); decimal != nil {
+		return DecimalBounds(decimal.Precision, decimal.Scale)
+	}
+
+	return nil
+}
+
 //GetType get column type based on occurrence in one file
 //lazily get common ancestor type (typing.GetCommonAncestorType)
 func (c Column) GetType() typing.DataType {
@@ -0,0 +1,30 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+//tableNameFuncs are functions which can be used in table_name_template:
+//default: return the first argument if the value is nil or empty string e.g. {{default "web" (index . "app")}}
+//(index returns nil for missing fields unlike {{.app}} which fails on events without app field)
+//lower, upper: change case of the value e.g. {{lower .event_type}}
+//replace: replace all occurrences of old with new in the value e.g. {{replace "-" "_" .event_type}}
+var tableNameFuncs = template.FuncMap{
+	"default": func(defaultValue string, value interface{}) string {
+		if value == nil || value == "" {
+			return defaultValue
+		}
+		return fmt.Sprint(value)
+	},
+	"lower": func(value interface{}) string {
+		return strings.ToLower(fmt.Sprint(value))
+	},
+	"upper": func(value interface{}) string {
+		return strings.ToUpper(fmt.Sprint(value))
+	},
+	"replace": func(old, new string, value interface{}) string {
+		return strings.Replace(fmt.Sprint(value), old, new, -1)
+	},
+}
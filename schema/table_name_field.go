@@ -0,0 +1,113 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//identifiers longer than it are truncated by Postgres and Redshift
+const maxTableNameFieldLength = 63
+
+var notTableNameCharacters = regexp.MustCompile(`[^a-z0-9_]+`)
+
+//TableNameFieldConfig dto for deserialized data_layout.table_name_field config. It is used instead of table_name_template:
+//events are written into the tables named after the field value (e.g. page_view and purchase events into page_view and purchase tables)
+//field: flattened field name after mapping (e.g. event_type or eventn_ctx_event_type)
+//allowlist: values which get own tables (after sanitization). Events with other values are written into default_table
+//(all values get own tables if it is empty)
+//prefix: table names prefix e.g. events_ -> events_page_view
+//default_table: table of events without the field or with not allowed value (such events are rejected if it is empty)
+type TableNameFieldConfig struct {
+	Field        string   `mapstructure:"field"`
+	Allowlist    []string `mapstructure:"allowlist"`
+	Prefix       string   `mapstructure:"prefix"`
+	DefaultTable string   `mapstructure:"default_table"`
+}
+
+//TableNameField resolves table name from the event field value: it is lower cased, characters other than a-z, 0-9 and _
+//are replaced with _ and prefixed table name is truncated to 63 characters
+type TableNameField struct {
+	field        string
+	allowlist    map[string]bool
+	prefix       string
+	defaultTable string
+}
+
+//NewTableNameField return TableNameField or nil if config is nil
+//return err if field isn't set or prefix or allowlist value is empty after sanitization
+func NewTableNameField(config *TableNameFieldConfig) (*TableNameField, error) {
+	if config == nil {
+		return nil, nil
+	}
+	if config.Field == "" {
+		return nil, errors.New("table_name_field field is required parameter")
+	}
+
+	//trailing separator is kept: Events- -> events_
+	prefix := strings.TrimLeft(notTableNameCharacters.ReplaceAllString(strings.ToLower(config.Prefix), "_"), "_")
+	if config.Prefix != "" && strings.Trim(prefix, "_") == "" {
+		return nil, fmt.Errorf("table_name_field prefix [%s] is empty after sanitization", config.Prefix)
+	}
+
+	var allowlist map[string]bool
+	for _, value := range config.Allowlist {
+		sanitized := sanitizeTableNameValue(value)
+		if sanitized == "" {
+			return nil, fmt.Errorf("table_name_field allowlist value [%s] is empty after sanitization", value)
+		}
+		if allowlist == nil {
+			allowlist = map[string]bool{}
+		}
+		allowlist[sanitized] = true
+	}
+
+	return &TableNameField{field: config.Field, allowlist: allowlist, prefix: prefix, defaultTable: config.DefaultTable}, nil
+}
+
+//Extract return prefixed sanitized value of the field or default table if the value is missing or isn't allowed
+//return err if there isn't default table in these cases or the value isn't a scalar
+func (tnf *TableNameField) Extract(object map[string]interface{}) (string, error) {
+	value, ok := object[tnf.field]
+	if !ok || value == nil {
+		return tnf.fallback(fmt.Errorf("%s field doesn't exist", tnf.field))
+	}
+
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return "", fmt.Errorf("%s field value must be a scalar", tnf.field)
+	}
+
+	sanitized := sanitizeTableNameValue(fmt.Sprint(value))
+	if sanitized == "" {
+		return tnf.fallback(fmt.Errorf("%s field value [%v] is empty after sanitization", tnf.field, value))
+	}
+	if tnf.allowlist != nil && !tnf.allowlist[sanitized] {
+		return tnf.fallback(fmt.Errorf("%s field value [%v] isn't in table_name_field allowlist", tnf.field, value))
+	}
+
+	return truncateTableName(tnf.prefix + sanitized), nil
+}
+
+func (tnf *TableNameField) fallback(err error) (string, error) {
+	if tnf.defaultTable == "" {
+		return "", err
+	}
+
+	return tnf.defaultTable, nil
+}
+
+//page-view, Page View -> page_view
+func sanitizeTableNameValue(value string) string {
+	return truncateTableName(strings.Trim(notTableNameCharacters.ReplaceAllString(strings.ToLower(value), "_"), "_"))
+}
+
+//sanitized names consist of ASCII characters only so truncation by bytes is safe
+func truncateTableName(name string) string {
+	if len(name) > maxTableNameFieldLength {
+		name = strings.TrimRight(name[:maxTableNameFieldLength], "_")
+	}
+
+	return name
+}
@@ -0,0 +1,121 @@
+package schema
+
+import (
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestTableNameField(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        *TableNameFieldConfig
+		inputObject   map[string]interface{}
+		expectedTable string
+		expectedErr   string
+	}{
+		{
+			"Sanitized value",
+			&TableNameFieldConfig{Field: "event_type"},
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": " Page-View!"},
+			"page_view",
+			"",
+		},
+		{
+			"Prefix and allowed value",
+			&TableNameFieldConfig{Field: "event_type", Allowlist: []string{"page_view", "Purchase"}, Prefix: "events_"},
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "purchase"},
+			"events_purchase",
+			"",
+		},
+		{
+			"Not allowed value into default table",
+			&TableNameFieldConfig{Field: "event_type", Allowlist: []string{"page_view", "purchase"}, DefaultTable: "other_events"},
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "drop table"},
+			"other_events",
+			"",
+		},
+		{
+			"Missing field into default table",
+			&TableNameFieldConfig{Field: "eventn_ctx_event_type", DefaultTable: "other_events"},
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "page_view"},
+			"other_events",
+			"",
+		},
+		{
+			"Numeric value",
+			&TableNameFieldConfig{Field: "code", Prefix: "code_"},
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "code": 404},
+			"code_404",
+			"",
+		},
+		{
+			"Truncated value",
+			&TableNameFieldConfig{Field: "event_type"},
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": strings.Repeat("a", 70)},
+			strings.Repeat("a", 63),
+			"",
+		},
+		{
+			"Sanitized prefix",
+			&TableNameFieldConfig{Field: "event_type", Prefix: "My-Events."},
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "page_view"},
+			"my_events_page_view",
+			"",
+		},
+		{
+			"Truncated prefixed value",
+			&TableNameFieldConfig{Field: "event_type", Prefix: "events_"},
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": strings.Repeat("a", 60)},
+			"events_" + strings.Repeat("a", 56),
+			"",
+		},
+		{
+			"Not allowed value without default table",
+			&TableNameFieldConfig{Field: "event_type", Allowlist: []string{"page_view"}},
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "purchase"},
+			"",
+			"Error extracting table name from object {map[_timestamp:2020-08-02T18:23:59.757719Z event_type:purchase]}: event_type field value [purchase] isn't in table_name_field allowlist",
+		},
+		{
+			"Empty value after sanitization without default table",
+			&TableNameFieldConfig{Field: "event_type"},
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "!!!"},
+			"",
+			"Error extracting table name from object {map[_timestamp:2020-08-02T18:23:59.757719Z event_type:!!!]}: event_type field value [!!!] is empty after sanitization",
+		},
+		{
+			"Missing field without default table",
+			&TableNameFieldConfig{Field: "event_type"},
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z"},
+			"",
+			"Error extracting table name from object {map[_timestamp:2020-08-02T18:23:59.757719Z]}: event_type field doesn't exist",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: "events", TableNameField: tt.config})
+			require.NoError(t, err)
+
+			table, _, err := p.ProcessFact(tt.inputObject)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedTable, table.Name)
+		})
+	}
+}
+
+func TestNewTableNameFieldErrors(t *testing.T) {
+	_, err := NewTableNameField(&TableNameFieldConfig{})
+	require.EqualError(t, err, "table_name_field field is required parameter")
+
+	_, err = NewTableNameField(&TableNameFieldConfig{Field: "event_type", Allowlist: []string{"page_view", "---"}})
+	require.EqualError(t, err, "table_name_field allowlist value [---] is empty after sanitization")
+
+	_, err = NewTableNameField(&TableNameFieldConfig{Field: "event_type", Prefix: "--"})
+	require.EqualError(t, err, "table_name_field prefix [--] is empty after sanitization")
+}
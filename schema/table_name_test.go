@@ -0,0 +1,67 @@
+package schema
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTableNameTemplate(t *testing.T) {
+	tests := []struct {
+		name          string
+		template      string
+		inputObject   map[string]interface{}
+		expectedTable string
+		expectedErr   string
+	}{
+		{
+			"Fields and timestamp",
+			`{{.app}}_{{.event_type}}_{{._timestamp.Format "2006_01"}}`,
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "app": "shop", "event_type": "pageview"},
+			"shop_pageview_2020_08",
+			"",
+		},
+		{
+			"Default of missing field",
+			`{{default "web" (index . "app")}}_{{.event_type}}`,
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "pageview"},
+			"web_pageview",
+			"",
+		},
+		{
+			"Default of existing field",
+			`{{default "web" (index . "app")}}_{{.event_type}}`,
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "app": "shop", "event_type": "pageview"},
+			"shop_pageview",
+			"",
+		},
+		{
+			"Lower and replace",
+			`{{replace "-" "_" (lower .event_type)}}`,
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "Page-View"},
+			"page_view",
+			"",
+		},
+		{
+			"Missing field",
+			`{{.app}}_{{.event_type}}`,
+			map[string]interface{}{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "pageview"},
+			"",
+			`Error extracting table name from object {map[_timestamp:2020-08-02T18:23:59.757719Z event_type:pageview]}: Error executing {{.app}}_{{.event_type}} template: template: table name extract:1:2: executing "table name extract" at <.app>: map has no entry for key "app"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProcessor(&ProcessorConfig{TableNameTemplate: tt.template})
+			require.NoError(t, err)
+
+			table, _, err := p.ProcessFact(tt.inputObject)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedTable, table.Name)
+		})
+	}
+}
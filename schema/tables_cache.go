@@ -0,0 +1,222 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/typing"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultTablesCacheMaxAge = 24 * time.Hour
+
+//TablesCacheConfig dto for deserialized data_layout.schema_cache config
+//enabled: DB tables schemas which are read from the destination or created by EventNative are kept in file (see storages)
+//and loaded at startup, so they aren't re-read from the destination on the first events after restart
+//max_age: cached schemas which are older are re-read from the destination (e.g. to pick up outer changes). Default: 24h
+//refresh_interval: tables schemas which are read from the destination are kept in memory and re-read after it (it is used
+//even if the cache isn't enabled, see MetadataCache). Default: 1m
+type TablesCacheConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	MaxAge          time.Duration `mapstructure:"max_age"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+}
+
+//TablesCache keeps DB tables schemas in file (if it is provided). Schemas are written on every change
+//note: outer changes of cached tables in DB aren't noticed until cached schemas reach max age
+type TablesCache struct {
+	maxAge time.Duration
+
+	mutex  sync.Mutex
+	file   string
+	tables map[string]*cachedTable
+}
+
+//serialized table schema
+type cachedTable struct {
+	Name     string                   `json:"name"`
+	Version  int64                    `json:"version"`
+	Columns  map[string]*cachedColumn `json:"columns"`
+	CachedAt time.Time                `json:"cached_at"`
+}
+
+//serialized column: type is an input type name (see typing.StringFromType)
+//DECIMAL columns have precision and scale, other ones have bounds if their DB value range is limited
+type cachedColumn struct {
+	Type      string   `json:"type"`
+	Precision int      `json:"precision,omitempty"`
+	Scale     int      `json:"scale,omitempty"`
+	Min       *float64 `json:"min,omitempty"`
+	Max       *float64 `json:"max,omitempty"`
+}
+
+//NewTablesCache return TablesCache or nil if config is nil or cache isn't enabled
+//return err if max_age is negative
+func NewTablesCache(config *TablesCacheConfig) (*TablesCache, error) {
+	if config == nil || !config.Enabled {
+		return nil, nil
+	}
+
+	if config.MaxAge < 0 {
+		return nil, errors.New("data_layout.schema_cache max_age can't be negative")
+	}
+
+	maxAge := config.MaxAge
+	if maxAge == 0 {
+		maxAge = defaultTablesCacheMaxAge
+	}
+
+	return &TablesCache{maxAge: maxAge, tables: map[string]*cachedTable{}}, nil
+}
+
+//Persist load not expired tables schemas from the file (if it exists) and keep all changes in it
+func (tc *TablesCache) Persist(file string) error {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+
+	tc.file = file
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("Error creating schema cache dir [%s]: %v", filepath.Dir(file), err)
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Error reading schema cache file [%s]: %v", file, err)
+	}
+
+	var tables []*cachedTable
+	if err := json.Unmarshal(b, &tables); err != nil {
+		return fmt.Errorf("Error unmarshalling schema cache file [%s]: %v", file, err)
+	}
+	for _, table := range tables {
+		if time.Since(table.CachedAt) < tc.maxAge {
+			tc.tables[table.Name] = table
+		}
+	}
+
+	return nil
+}
+
+//Tables return not expired cached tables schemas sorted by name. Tables which can't be deserialized are skipped
+func (tc *TablesCache) Tables() []*Table {
+	if tc == nil {
+		return nil
+	}
+
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+
+	result := []*Table{}
+	for _, ct := range tc.tables {
+		if time.Since(ct.CachedAt) >= tc.maxAge {
+			continue
+		}
+
+		table, err := ct.table()
+		if err != nil {
+			log.Printf("Warn: cached schema of table [%s] is skipped: %v", ct.Name, err)
+			continue
+		}
+		result = append(result, table)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result
+}
+
+//Put cache the table schema and write all cached schemas into the file (if it is provided)
+func (tc *TablesCache) Put(table *Table) {
+	if tc == nil {
+		return
+	}
+
+	ct, err := newCachedTable(table)
+	if err != nil {
+		log.Printf("Error caching schema of table [%s]: %v", table.Name, err)
+		return
+	}
+
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+
+	tc.tables[table.Name] = ct
+	if tc.file == "" {
+		return
+	}
+
+	tables := make([]*cachedTable, 0, len(tc.tables))
+	for _, ct := range tc.tables {
+		tables = append(tables, ct)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+
+	b, err := json.MarshalIndent(tables, "", "  ")
+	if err != nil {
+		log.Println("Error marshalling schema cache:", err)
+		return
+	}
+	//written into temporary file and renamed, so the file is never left half-written
+	tmpFile := tc.file + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, b, 0644); err != nil {
+		log.Printf("Error writing schema cache file [%s]: %v", tmpFile, err)
+		return
+	}
+	if err := os.Rename(tmpFile, tc.file); err != nil {
+		log.Printf("Error renaming schema cache file [%s]: %v", tmpFile, err)
+	}
+}
+
+func newCachedTable(table *Table) (*cachedTable, error) {
+	columns := map[string]*cachedColumn{}
+	for name, column := range table.Columns {
+		dataType, err := typing.StringFromType(column.GetType())
+		if err != nil {
+			return nil, err
+		}
+
+		cc := &cachedColumn{Type: dataType}
+		if decimal := column.Decimal(); decimal != nil {
+			cc.Precision, cc.Scale = decimal.Precision, decimal.Scale
+		} else if bounds := column.Bounds(); bounds != nil {
+			min, max := bounds.Min, bounds.Max
+			cc.Min, cc.Max = &min, &max
+		}
+		columns[name] = cc
+	}
+
+	return &cachedTable{Name: table.Name, Version: table.Version, Columns: columns, CachedAt: time.Now().UTC()}, nil
+}
+
+func (ct *cachedTable) table() (*Table, error) {
+	table := &Table{Name: ct.Name, Version: ct.Version, Columns: Columns{}}
+	for name, cc := range ct.Columns {
+		dataType, err := typing.TypeFromString(cc.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case dataType == typing.DECIMAL:
+			decimalType := &DecimalType{Precision: cc.Precision, Scale: cc.Scale}
+			if err := decimalType.Validate(); err != nil {
+				return nil, err
+			}
+			table.Columns[name] = NewDecimalColumn(decimalType, "")
+		case cc.Min != nil && cc.Max != nil:
+			table.Columns[name] = NewBoundedColumn(dataType, &NumericBounds{Min: *cc.Min, Max: *cc.Max})
+		default:
+			table.Columns[name] = NewColumn(dataType)
+		}
+	}
+
+	return table, nil
+}
@@ -0,0 +1,69 @@
+package schema
+
+import (
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestNewTablesCache(t *testing.T) {
+	cache, err := NewTablesCache(&TablesCacheConfig{Enabled: false})
+	require.NoError(t, err)
+	require.Nil(t, cache)
+	require.Nil(t, cache.Tables())
+	cache.Put(&Table{Name: "events"})
+
+	_, err = NewTablesCache(&TablesCacheConfig{Enabled: true, MaxAge: -time.Hour})
+	require.EqualError(t, err, "data_layout.schema_cache max_age can't be negative")
+}
+
+func TestTablesCachePersist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schemas")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := path.Join(dir, "schemas", "pg.json")
+
+	cache, err := NewTablesCache(&TablesCacheConfig{Enabled: true})
+	require.NoError(t, err)
+	require.NoError(t, cache.Persist(file))
+	require.Empty(t, cache.Tables())
+
+	cache.Put(&Table{Name: "events", Version: 2, Columns: Columns{
+		"id":      NewColumn(typing.INT64),
+		"price":   NewBoundedColumn(typing.FLOAT64, DecimalBounds(38, 18)),
+		"total":   NewDecimalColumn(&DecimalType{Precision: 18, Scale: 2}, "order total"),
+		"url":     NewColumn(typing.STRING),
+		"payload": NewColumn(typing.JSON),
+	}})
+	cache.Put(&Table{Name: "users", Version: 1, Columns: Columns{"name": NewColumn(typing.STRING)}})
+
+	//cached schemas are loaded after restart
+	restarted, err := NewTablesCache(&TablesCacheConfig{Enabled: true})
+	require.NoError(t, err)
+	require.NoError(t, restarted.Persist(file))
+	tables := restarted.Tables()
+	require.Len(t, tables, 2)
+
+	events := tables[0]
+	require.Equal(t, "events", events.Name)
+	require.Equal(t, int64(2), events.Version)
+	require.Len(t, events.Columns, 5)
+	require.Equal(t, typing.INT64, events.Columns["id"].GetType())
+	require.Equal(t, Int64Bounds, events.Columns["id"].Bounds())
+	require.Equal(t, DecimalBounds(38, 18), events.Columns["price"].Bounds())
+	require.Equal(t, &DecimalType{Precision: 18, Scale: 2}, events.Columns["total"].Decimal())
+	require.Equal(t, typing.STRING, events.Columns["url"].GetType())
+	require.Nil(t, events.Columns["url"].Bounds())
+	require.Equal(t, typing.JSON, events.Columns["payload"].GetType())
+	require.Equal(t, "users", tables[1].Name)
+
+	//expired schemas are re-read from DB
+	expired, err := NewTablesCache(&TablesCacheConfig{Enabled: true, MaxAge: time.Nanosecond})
+	require.NoError(t, err)
+	require.NoError(t, expired.Persist(file))
+	require.Empty(t, expired.Tables())
+}
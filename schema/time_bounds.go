@@ -0,0 +1,121 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/ksensehq/eventnative/typing"
+	"log"
+	"time"
+)
+
+const (
+	RejectAction   = "reject"
+	RedirectAction = "redirect"
+)
+
+//TimeBoundsConfig dto for deserialized data_layout.timestamp_bounds config
+//It protects partitioned destinations (ClickHouse, BigQuery) from events which create partitions
+//too far in the past or in the future
+type TimeBoundsConfig struct {
+	Field         string        `mapstructure:"field"`
+	MaxAge        time.Duration `mapstructure:"max_age"`
+	MaxFuture     time.Duration `mapstructure:"max_future"`
+	Action        string        `mapstructure:"action"`
+	RedirectTable string        `mapstructure:"redirect_table"`
+}
+
+//Validate required fields in TimeBoundsConfig and set default values
+func (tbc *TimeBoundsConfig) Validate() error {
+	if tbc == nil {
+		return nil
+	}
+
+	if tbc.MaxAge < 0 || tbc.MaxFuture < 0 {
+		return errors.New("timestamp_bounds max_age and max_future can't be negative")
+	}
+
+	if tbc.Field == "" {
+		tbc.Field = timestamp.Key
+	}
+
+	switch tbc.Action {
+	case "":
+		tbc.Action = RejectAction
+	case RejectAction:
+	case RedirectAction:
+		if tbc.RedirectTable == "" {
+			return errors.New("timestamp_bounds redirect_table is required parameter if action is redirect")
+		}
+	default:
+		return fmt.Errorf("Unknown timestamp_bounds action: %s. Available actions: [%s, %s]", tbc.Action, RejectAction, RedirectAction)
+	}
+
+	return nil
+}
+
+//TimeBounds checks that object timestamp field is in [now - maxAge, now + maxFuture] range
+type TimeBounds struct {
+	field         string
+	maxAge        time.Duration
+	maxFuture     time.Duration
+	redirectTable string
+}
+
+//NewTimeBounds return TimeBounds or nil if config is nil or both bounds are empty
+func NewTimeBounds(config *TimeBoundsConfig) (*TimeBounds, error) {
+	if config == nil || (config.MaxAge == 0 && config.MaxFuture == 0) {
+		return nil, nil
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	tb := &TimeBounds{
+		field:     config.Field,
+		maxAge:    config.MaxAge,
+		maxFuture: config.MaxFuture,
+	}
+	if config.Action == RedirectAction {
+		tb.redirectTable = config.RedirectTable
+	}
+
+	return tb, nil
+}
+
+//Apply return table name for object:
+//input tableName if object timestamp is in bounds (or object doesn't have timestamp field)
+//redirect table name if object is out of bounds and action is redirect
+//empty string if object is out of bounds and action is reject
+func (tb *TimeBounds) Apply(tableName string, object map[string]interface{}) (string, error) {
+	value, ok := object[tb.field]
+	if !ok || value == nil {
+		return tableName, nil
+	}
+
+	converted, err := typing.Convert(typing.TIMESTAMP, value)
+	if err != nil {
+		return "", fmt.Errorf("Error converting timestamp bounds field [%s]: %v", tb.field, err)
+	}
+	t := converted.(time.Time)
+
+	now := time.Now().UTC()
+	if tb.maxAge > 0 && t.Before(now.Add(-tb.maxAge)) {
+		return tb.outOfBounds(tableName, t, "older than max_age")
+	}
+	if tb.maxFuture > 0 && t.After(now.Add(tb.maxFuture)) {
+		return tb.outOfBounds(tableName, t, "newer than max_future")
+	}
+
+	return tableName, nil
+}
+
+func (tb *TimeBounds) outOfBounds(tableName string, t time.Time, reason string) (string, error) {
+	if tb.redirectTable == "" {
+		log.Printf("Warn: object for table [%s] with %s=%s is %s. This object will be skipped", tableName, tb.field, t.Format(timestamp.Layout), reason)
+		return "", nil
+	}
+
+	return tb.redirectTable, nil
+}
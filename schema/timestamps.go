@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+//TimestampsConfig dto for deserialized data_layout.timestamps config
+//layouts: additional Go time layouts (e.g. 2006/01/02 15:04) of string fields which are detected as timestamps
+//timezone: IANA timezone (e.g. Europe/Berlin) of values which don't have zone offset in their layout (default UTC).
+//Detected timestamps are converted to UTC
+type TimestampsConfig struct {
+	Layouts  []string `mapstructure:"layouts"`
+	Timezone string   `mapstructure:"timezone"`
+}
+
+//Validate layouts and timezone in TimestampsConfig
+func (tc *TimestampsConfig) Validate() error {
+	if tc == nil {
+		return nil
+	}
+
+	for _, layout := range tc.Layouts {
+		if strings.TrimSpace(layout) == "" {
+			return errors.New("timestamps layouts can't contain empty values")
+		}
+	}
+
+	if _, err := time.LoadLocation(tc.Timezone); err != nil {
+		return fmt.Errorf("Unknown timestamps timezone: %s: %v", tc.Timezone, err)
+	}
+
+	return nil
+}
+
+//Timestamps detects string fields in custom layouts as timestamps
+type Timestamps struct {
+	layouts  []string
+	location *time.Location
+}
+
+//NewTimestamps return Timestamps or nil if config is nil or doesn't have layouts
+func NewTimestamps(config *TimestampsConfig) (*Timestamps, error) {
+	if config == nil || len(config.Layouts) == 0 {
+		return nil, nil
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	//empty timezone is UTC
+	location, _ := time.LoadLocation(config.Timezone)
+
+	return &Timestamps{layouts: config.Layouts, location: location}, nil
+}
+
+//Parse return UTC time of value in the first matched layout and true or false if value doesn't match any layout
+func (t *Timestamps) Parse(value string) (time.Time, bool) {
+	for _, layout := range t.layouts {
+		parsed, err := time.ParseInLocation(layout, value, t.location)
+		if err == nil {
+			return parsed.UTC(), true
+		}
+	}
+
+	return time.Time{}, false
+}
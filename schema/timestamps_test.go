@@ -0,0 +1,69 @@
+package schema
+
+import (
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestNewTimestamps(t *testing.T) {
+	timestamps, err := NewTimestamps(&TimestampsConfig{Timezone: "Europe/Berlin"})
+	require.NoError(t, err)
+	require.Nil(t, timestamps, "timestamps without layouts aren't detected")
+
+	_, err = NewTimestamps(&TimestampsConfig{Layouts: []string{"2006/01/02", " "}})
+	require.EqualError(t, err, "timestamps layouts can't contain empty values")
+
+	_, err = NewTimestamps(&TimestampsConfig{Layouts: []string{"2006/01/02"}, Timezone: "Mars/Olympus"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Unknown timestamps timezone: Mars/Olympus")
+}
+
+func TestTimestampsParse(t *testing.T) {
+	timestamps, err := NewTimestamps(&TimestampsConfig{Layouts: []string{"2006/01/02 15:04", "02.01.2006 15:04:05 -0700"}, Timezone: "Europe/Berlin"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Time
+		ok       bool
+	}{
+		{"layout without offset is in timezone", "2020/10/01 15:04", time.Date(2020, 10, 1, 13, 4, 0, 0, time.UTC), true},
+		{"winter time", "2020/12/01 15:04", time.Date(2020, 12, 1, 14, 4, 0, 0, time.UTC), true},
+		{"offset overrides timezone", "01.10.2020 15:04:05 +0300", time.Date(2020, 10, 1, 12, 4, 5, 0, time.UTC), true},
+		{"not matched", "2020-10-01 15:04", time.Time{}, false},
+		{"plain string", "home page", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, ok := timestamps.Parse(tt.value)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestProcessFactTimestamps(t *testing.T) {
+	p, err := NewProcessor(&ProcessorConfig{
+		TableNameTemplate: "events",
+		Types:             map[string]string{"/code": "string"},
+		Timestamps:        &TimestampsConfig{Layouts: []string{"2006/01/02 15:04"}},
+	})
+	require.NoError(t, err)
+
+	table, flatObject, err := p.ProcessFact(map[string]interface{}{
+		"_timestamp": "2020-10-10T10:10:10.000000Z",
+		"paid_at":    "2020/10/01 15:04",
+		"code":       "2020/10/01 15:04",
+		"title":      "home",
+	})
+	require.NoError(t, err)
+	require.Equal(t, typing.TIMESTAMP, table.Columns["paid_at"].GetType())
+	require.Equal(t, time.Date(2020, 10, 1, 15, 4, 0, 0, time.UTC), flatObject["paid_at"])
+	require.Equal(t, typing.STRING, table.Columns["code"].GetType(), "explicitly typed fields are kept as is")
+	require.Equal(t, "2020/10/01 15:04", flatObject["code"])
+	require.Equal(t, typing.STRING, table.Columns["title"].GetType())
+	require.Equal(t, typing.TIMESTAMP, table.Columns["_timestamp"].GetType())
+}
@@ -0,0 +1,48 @@
+package schema
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/typing"
+	"strings"
+)
+
+//NewTypeOverrides return flattened field name -> explicit type from data_layout.types config: JSON path -> type
+//e.g. /user/id: string -> user_id: STRING. Paths are paths of the event after mapping
+//Explicit types override automatic type detection and mapping casts so column types don't flap between events
+func NewTypeOverrides(config map[string]string) (map[string]typing.DataType, error) {
+	overrides := map[string]typing.DataType{}
+	for path, typeName := range config {
+		parts := splitPath(path)
+		if len(parts) == 0 {
+			return nil, fmt.Errorf("Malformed data_layout.types path [%s]: path can't be empty", path)
+		}
+
+		dataType, err := typing.TypeFromString(typeName)
+		if err != nil {
+			return nil, fmt.Errorf("Malformed data_layout.types [%s] type: %v. Available types: integer (int64), double (float64), decimal(precision,scale), string, timestamp", path, err)
+		}
+
+		//flattened keys are lower case
+		overrides[strings.ToLower(strings.Join(parts, "_"))] = dataType
+	}
+
+	return overrides, nil
+}
+
+//NewJSONColumns return flattened names of data_layout.json_columns config: JSON paths of nested objects
+//which are kept as single JSON columns instead of flattening e.g. /properties -> properties: JSON
+//Paths are paths of the event after mapping
+func NewJSONColumns(paths []string) (map[string]bool, error) {
+	columns := map[string]bool{}
+	for _, path := range paths {
+		parts := splitPath(path)
+		if len(parts) == 0 {
+			return nil, fmt.Errorf("Malformed data_layout.json_columns path [%s]: path can't be empty", path)
+		}
+
+		//flattened keys are lower case
+		columns[strings.ToLower(strings.Join(parts, "_"))] = true
+	}
+
+	return columns, nil
+}
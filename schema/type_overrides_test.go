@@ -0,0 +1,63 @@
+package schema
+
+import (
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewTypeOverrides(t *testing.T) {
+	overrides, err := NewTypeOverrides(map[string]string{"/revenue": "float64", "/user/ID": "string", "/page/views/": "integer"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]typing.DataType{"revenue": typing.FLOAT64, "user_id": typing.STRING, "page_views": typing.INT64}, overrides)
+
+	_, err = NewTypeOverrides(map[string]string{"/": "string"})
+	require.EqualError(t, err, "Malformed data_layout.types path [/]: path can't be empty")
+
+	_, err = NewTypeOverrides(map[string]string{"/revenue": "money"})
+	require.EqualError(t, err, "Malformed data_layout.types [/revenue] type: Unknown casting type: money. Available types: integer (int64), double (float64), decimal(precision,scale), string, timestamp")
+}
+
+func TestProcessFactTypeOverrides(t *testing.T) {
+	p, err := NewProcessor(&ProcessorConfig{
+		TableNameTemplate: "events",
+		Mapping:           []string{"/user/id -> (integer) /user_id"},
+		Types:             map[string]string{"/revenue": "float64", "/user_id": "string"},
+	})
+	require.NoError(t, err)
+
+	//integer and float values of the same field don't change column type
+	for _, revenue := range []interface{}{10.0, 10.5} {
+		table, flatObject, err := p.ProcessFact(map[string]interface{}{"_timestamp": "2020-10-10T10:10:10.000000Z", "revenue": revenue, "user": map[string]interface{}{"id": 123.0}})
+		require.NoError(t, err)
+		require.Equal(t, typing.FLOAT64, table.Columns["revenue"].GetType())
+		require.Equal(t, typing.STRING, table.Columns["user_id"].GetType(), "explicit type overrides mapping cast")
+		require.Equal(t, "123", flatObject["user_id"])
+	}
+}
+
+func TestProcessFactJSONColumns(t *testing.T) {
+	_, err := NewJSONColumns([]string{"/properties", " "})
+	require.EqualError(t, err, "Malformed data_layout.json_columns path [ ]: path can't be empty")
+
+	p, err := NewProcessor(&ProcessorConfig{
+		TableNameTemplate: "events",
+		Types:             map[string]string{"/properties": "string"},
+		JSONColumns:       []string{"/Properties", "/eventn_ctx/custom/"},
+	})
+	require.NoError(t, err)
+
+	table, flatObject, err := p.ProcessFact(map[string]interface{}{
+		"_timestamp": "2020-10-10T10:10:10.000000Z",
+		"properties": map[string]interface{}{"plan": "pro", "seats": 5.0},
+		"eventn_ctx": map[string]interface{}{"custom": "vip", "url": "/home"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, typing.JSON, table.Columns["properties"].GetType(), "json columns override explicit types")
+	require.Equal(t, typing.JSON, table.Columns["eventn_ctx_custom"].GetType())
+	require.Equal(t, typing.STRING, table.Columns["eventn_ctx_url"].GetType())
+	require.Equal(t, `{"plan":"pro","seats":5}`, flatObject["properties"])
+	require.Equal(t, `"vip"`, flatObject["eventn_ctx_custom"])
+	_, ok := flatObject["properties_plan"]
+	require.False(t, ok)
+}
@@ -0,0 +1,55 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+)
+
+//AllTables is upsert config table name which matches all tables without their own upsert config
+const AllTables = "*"
+
+//UpsertConfig dto for deserialized data_layout.upsert config item
+//It turns table into mutable entities table with one row per keys values (e.g. one row per user for identify events)
+//table: result table name (after table_name_template and timestamp_bounds redirect are applied) or * for all other tables
+//keys: flattened field names which identify the row
+type UpsertConfig struct {
+	Table string   `mapstructure:"table"`
+	Keys  []string `mapstructure:"keys"`
+}
+
+//Validate required fields in UpsertConfig
+func (uc *UpsertConfig) Validate() error {
+	if uc == nil {
+		return errors.New("upsert config item can't be empty")
+	}
+	if uc.Table == "" {
+		return errors.New("upsert table is required parameter")
+	}
+	if len(uc.Keys) == 0 {
+		return fmt.Errorf("upsert keys are required parameter for table [%s]", uc.Table)
+	}
+	for _, key := range uc.Keys {
+		if key == "" {
+			return fmt.Errorf("upsert key can't be empty in table [%s] keys", uc.Table)
+		}
+	}
+
+	return nil
+}
+
+//NewUpsertKeys return table name - upsert keys map from configs
+//return err if config is invalid or table is configured twice
+func NewUpsertKeys(configs []*UpsertConfig) (map[string][]string, error) {
+	upsertKeys := map[string][]string{}
+	for _, config := range configs {
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := upsertKeys[config.Table]; ok {
+			return nil, fmt.Errorf("upsert table [%s] is configured more than once", config.Table)
+		}
+		upsertKeys[config.Table] = config.Keys
+	}
+
+	return upsertKeys, nil
+}
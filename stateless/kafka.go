@@ -0,0 +1,258 @@
+package stateless
+
+import (
+	"context"
+	"fmt"
+	"github.com/Shopify/sarama"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/events"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	//delay before rejoining consumer group after consuming error
+	kafkaRejoinDelay = 5 * time.Second
+	//consumer group lag (queue size) isn't requested on every enqueue
+	kafkaLagRefreshInterval = 10 * time.Second
+)
+
+//all queues share one sync producer and one client for consumer groups lag requests. Every queue has own consumer group
+type kafkaFactory struct {
+	brokers      []string
+	saramaConfig *sarama.Config
+	client       sarama.Client
+	admin        sarama.ClusterAdmin
+	producer     sarama.SyncProducer
+}
+
+func newKafkaFactory(config *KafkaQueueConfig) (*kafkaFactory, error) {
+	saramaConfig, err := adapters.NewSaramaConfig(config.adapterConfig())
+	if err != nil {
+		return nil, err
+	}
+	//consumer groups are supported since 0.10.2
+	saramaConfig.Version = sarama.V1_0_0_0
+	//events which have been enqueued before the first start of the consumer group mustn't be lost
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	client, err := sarama.NewClient(config.Brokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating stateless Kafka client: %v", err)
+	}
+
+	//admin closes the client
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("Error creating stateless Kafka cluster admin: %v", err)
+	}
+
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		admin.Close()
+		return nil, fmt.Errorf("Error creating stateless Kafka producer: %v", err)
+	}
+
+	return &kafkaFactory{brokers: config.Brokers, saramaConfig: saramaConfig, client: client, admin: admin, producer: producer}, nil
+}
+
+func (kf *kafkaFactory) queueName(prefix, destinationName string) string {
+	return prefix + "-" + destinationName
+}
+
+func (kf *kafkaFactory) create(name string) (events.QueueBackend, error) {
+	group, err := sarama.NewConsumerGroup(kf.brokers, name, kf.saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating Kafka consumer group: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	kq := &kafkaQueue{
+		topic:    name,
+		client:   kf.client,
+		admin:    kf.admin,
+		producer: kf.producer,
+		group:    group,
+		messages: make(chan *kafkaMessage),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	kq.start()
+	kq.startLagRefreshing()
+
+	return kq, nil
+}
+
+func (kf *kafkaFactory) Close() error {
+	if err := kf.producer.Close(); err != nil {
+		return err
+	}
+
+	return kf.admin.Close()
+}
+
+//consumed message and func which marks its offset
+type kafkaMessage struct {
+	value []byte
+	ack   events.AckFunc
+}
+
+//QueueBackend implementation on Kafka topic (with the same name consumer group)
+//message offset is committed after the message has been acknowledged (inserted). Kafka commits offsets per partition:
+//not acknowledged message is consumed again only if later messages of the partition aren't acknowledged too
+//size is consumer group lag which is refreshed every kafkaLagRefreshInterval
+type kafkaQueue struct {
+	topic    string
+	client   sarama.Client
+	admin    sarama.ClusterAdmin
+	producer sarama.SyncProducer
+	group    sarama.ConsumerGroup
+	messages chan *kafkaMessage
+	lag      int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+//consume topic in the consumer group session until the queue is closed (session is restarted on rebalancing)
+func (kq *kafkaQueue) start() {
+	kq.wg.Add(1)
+	go func() {
+		defer kq.wg.Done()
+		for {
+			if err := kq.group.Consume(kq.ctx, []string{kq.topic}, kq); err != nil {
+				log.Printf("Error consuming stateless Kafka topic [%s]: %v", kq.topic, err)
+				select {
+				case <-kq.ctx.Done():
+				case <-time.After(kafkaRejoinDelay):
+				}
+			}
+			if kq.ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+}
+
+func (kq *kafkaQueue) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (kq *kafkaQueue) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+//ConsumeClaim pass messages into DequeueBlock one by one. Offsets are marked on acknowledgement
+func (kq *kafkaQueue) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for message := range claim.Messages() {
+		message := message
+		ack := func() error {
+			session.MarkMessage(message, "")
+			return nil
+		}
+
+		select {
+		case kq.messages <- &kafkaMessage{value: message.Value, ack: ack}:
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (kq *kafkaQueue) Enqueue(factBytes []byte) error {
+	_, _, err := kq.producer.SendMessage(&sarama.ProducerMessage{Topic: kq.topic, Value: sarama.ByteEncoder(factBytes)})
+	return err
+}
+
+func (kq *kafkaQueue) DequeueBlock() ([]byte, events.AckFunc, error) {
+	select {
+	case message := <-kq.messages:
+		return message.value, message.ack, nil
+	case <-kq.ctx.Done():
+		return nil, nil, events.ErrQueueClosed
+	}
+}
+
+//Size return consumer group lag (it is refreshed every kafkaLagRefreshInterval)
+func (kq *kafkaQueue) Size() int {
+	return int(atomic.LoadInt64(&kq.lag))
+}
+
+//refresh consumer group lag until the queue is closed
+func (kq *kafkaQueue) startLagRefreshing() {
+	kq.wg.Add(1)
+	go func() {
+		defer kq.wg.Done()
+		ticker := time.NewTicker(kafkaLagRefreshInterval)
+		defer ticker.Stop()
+		for {
+			lag, err := kq.consumerGroupLag()
+			if err != nil {
+				log.Printf("Error requesting stateless Kafka topic [%s] consumer group lag: %v", kq.topic, err)
+			} else {
+				atomic.StoreInt64(&kq.lag, lag)
+			}
+
+			select {
+			case <-kq.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+//return sum of differences between the newest and committed offsets of all topic partitions
+//partitions without committed offset are consumed from the oldest one
+func (kq *kafkaQueue) consumerGroupLag() (int64, error) {
+	partitions, err := kq.client.Partitions(kq.topic)
+	if err == sarama.ErrUnknownTopicOrPartition {
+		//topic is created on the first enqueue
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	offsets, err := kq.admin.ListConsumerGroupOffsets(kq.topic, map[string][]int32{kq.topic: partitions})
+	if err != nil {
+		return 0, err
+	}
+
+	var lag int64
+	for _, partition := range partitions {
+		newest, err := kq.client.GetOffset(kq.topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return 0, err
+		}
+
+		committed := int64(-1)
+		if block := offsets.GetBlock(kq.topic, partition); block != nil {
+			committed = block.Offset
+		}
+		if committed < 0 {
+			if committed, err = kq.client.GetOffset(kq.topic, partition, sarama.OffsetOldest); err != nil {
+				return 0, err
+			}
+		}
+
+		if newest > committed {
+			lag += newest - committed
+		}
+	}
+
+	return lag, nil
+}
+
+//Close stop consuming and leave consumer group. Not acknowledged messages aren't committed and are consumed by other nodes
+func (kq *kafkaQueue) Close() error {
+	kq.cancel()
+	kq.wg.Wait()
+	return kq.group.Close()
+}
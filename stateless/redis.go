@@ -0,0 +1,138 @@
+package stateless
+
+import (
+	"fmt"
+	"github.com/go-redis/redis/v7"
+	"github.com/ksensehq/eventnative/events"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+//BLMOVE timeout: closed queue is checked between moves
+const redisPopTimeout = time.Second
+
+//all queues share one client
+type redisFactory struct {
+	client     *redis.Client
+	serverName string
+}
+
+func newRedisFactory(config *RedisQueueConfig, serverName string) (*redisFactory, error) {
+	options, err := redis.ParseURL(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing stateless Redis url: %v", err)
+	}
+
+	client := redis.NewClient(options)
+	if err := client.Ping().Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("Error connecting to stateless Redis: %v", err)
+	}
+
+	return &redisFactory{client: client, serverName: serverName}, nil
+}
+
+func (rf *redisFactory) queueName(prefix, destinationName string) string {
+	return prefix + ":" + destinationName
+}
+
+//create queue and return events which haven't been acknowledged by this node before restart back into the queue
+func (rf *redisFactory) create(name string) (events.QueueBackend, error) {
+	rq := &redisQueue{client: rf.client, key: name, processingKey: name + ":processing:" + rf.serverName}
+	returned, err := rq.returnProcessing()
+	if err != nil {
+		return nil, fmt.Errorf("Error returning not acknowledged events into the queue: %v", err)
+	}
+	if returned > 0 {
+		log.Printf("%d not acknowledged events have been returned into stateless Redis queue [%s]", returned, name)
+	}
+
+	return rq, nil
+}
+
+func (rf *redisFactory) Close() error {
+	return rf.client.Close()
+}
+
+//QueueBackend implementation on Redis list: RPUSH on enqueue and BLMOVE into the node processing list on dequeue
+//(requires Redis 6.2+). Acknowledged events are removed from the processing list with LREM. Not acknowledged ones
+//(e.g. the node has crashed while inserting them) are returned into the queue on the node restart
+type redisQueue struct {
+	client        *redis.Client
+	key           string
+	processingKey string
+	closed        int32
+}
+
+func (rq *redisQueue) Enqueue(factBytes []byte) error {
+	return rq.client.RPush(rq.key, factBytes).Err()
+}
+
+//DequeueBlock wait for the event. The event which is moved after Close is put back to the head of the list
+//so it is consumed by other nodes
+func (rq *redisQueue) DequeueBlock() ([]byte, events.AckFunc, error) {
+	for {
+		if atomic.LoadInt32(&rq.closed) == 1 {
+			return nil, nil, events.ErrQueueClosed
+		}
+
+		value, err := rq.client.Do("BLMOVE", rq.key, rq.processingKey, "LEFT", "RIGHT", int(redisPopTimeout.Seconds())).Text()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			if atomic.LoadInt32(&rq.closed) == 1 {
+				return nil, nil, events.ErrQueueClosed
+			}
+			return nil, nil, err
+		}
+
+		if atomic.LoadInt32(&rq.closed) == 1 {
+			_, err := rq.client.TxPipelined(func(pipe redis.Pipeliner) error {
+				pipe.LRem(rq.processingKey, 1, value)
+				pipe.LPush(rq.key, value)
+				return nil
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("Error returning event into closed queue: %v", err)
+			}
+			return nil, nil, events.ErrQueueClosed
+		}
+
+		ack := func() error {
+			return rq.client.LRem(rq.processingKey, 1, value).Err()
+		}
+		return []byte(value), ack, nil
+	}
+}
+
+//move all events from the processing list to the head of the queue (keeping their order)
+func (rq *redisQueue) returnProcessing() (int, error) {
+	returned := 0
+	for {
+		err := rq.client.RPopLPush(rq.processingKey, rq.key).Err()
+		if err == redis.Nil {
+			return returned, nil
+		}
+		if err != nil {
+			return returned, err
+		}
+		returned++
+	}
+}
+
+func (rq *redisQueue) Size() int {
+	size, err := rq.client.LLen(rq.key).Result()
+	if err != nil {
+		return 0
+	}
+
+	return int(size)
+}
+
+//Close mark queue closed. Shared client is closed with ExternalQueues
+func (rq *redisQueue) Close() error {
+	atomic.StoreInt32(&rq.closed, 1)
+	return nil
+}
@@ -0,0 +1,152 @@
+package stateless
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/events"
+)
+
+const (
+	RedisQueue = "redis"
+	KafkaQueue = "kafka"
+
+	defaultPrefix = "eventnative"
+)
+
+//Instance is nil if stateless mode is disabled
+var Instance *ExternalQueues
+
+//Config dto for deserialized server.stateless config
+//queue: redis (lists) or kafka (topics). Stream mode events are buffered there instead of local disk queues
+//prefix: queue names prefix: $prefix:$destination Redis lists or $prefix-$destination Kafka topics and consumer groups
+//queues are shared by all nodes (without server name) so events of the removed node are consumed by other ones
+type Config struct {
+	Enabled bool              `mapstructure:"enabled"`
+	Queue   string            `mapstructure:"queue"`
+	Prefix  string            `mapstructure:"prefix"`
+	Redis   *RedisQueueConfig `mapstructure:"redis"`
+	Kafka   *KafkaQueueConfig `mapstructure:"kafka"`
+}
+
+//RedisQueueConfig dto for deserialized server.stateless.redis config
+//url: redis://[:password@]host:port[/db] or rediss:// for TLS connection
+type RedisQueueConfig struct {
+	URL string `mapstructure:"url"`
+}
+
+//KafkaQueueConfig dto for deserialized server.stateless.kafka config
+type KafkaQueueConfig struct {
+	Brokers []string                  `mapstructure:"brokers"`
+	SASL    *adapters.KafkaSASLConfig `mapstructure:"sasl"`
+	TLS     *adapters.KafkaTLSConfig  `mapstructure:"tls"`
+}
+
+func (kqc *KafkaQueueConfig) adapterConfig() *adapters.KafkaConfig {
+	return &adapters.KafkaConfig{Brokers: kqc.Brokers, Acks: "all", SASL: kqc.SASL, TLS: kqc.TLS}
+}
+
+//Validate required fields in Config and set default values
+func (c *Config) Validate() error {
+	if c.Prefix == "" {
+		c.Prefix = defaultPrefix
+	}
+
+	switch c.Queue {
+	case RedisQueue:
+		if c.Redis == nil || c.Redis.URL == "" {
+			return errors.New("stateless redis.url is required parameter")
+		}
+	case KafkaQueue:
+		if c.Kafka == nil {
+			return errors.New("stateless kafka config is required")
+		}
+		return c.Kafka.adapterConfig().Validate()
+	case "":
+		return errors.New("stateless queue is required parameter")
+	default:
+		return fmt.Errorf("Unknown stateless queue: %s. Available queues: [%s, %s]", c.Queue, RedisQueue, KafkaQueue)
+	}
+
+	return nil
+}
+
+//ExternalQueues creates stream mode queues of destinations in the external queue
+type ExternalQueues struct {
+	prefix  string
+	factory backendFactory
+}
+
+//creates QueueBackend by queue name
+type backendFactory interface {
+	queueName(prefix, destinationName string) string
+	create(name string) (events.QueueBackend, error)
+	Close() error
+}
+
+//Init validate config and create global ExternalQueues instance (connected to the external queue)
+func Init(config *Config, serverName string) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+
+	queues, err := NewExternalQueues(config, serverName)
+	if err != nil {
+		return err
+	}
+
+	Instance = queues
+	return nil
+}
+
+//NewExternalQueues return ExternalQueues connected to the external queue
+//serverName is used in names of the node own Redis lists of not acknowledged events
+func NewExternalQueues(config *Config, serverName string) (*ExternalQueues, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	var factory backendFactory
+	var err error
+	switch config.Queue {
+	case RedisQueue:
+		factory, err = newRedisFactory(config.Redis, serverName)
+	case KafkaQueue:
+		factory, err = newKafkaFactory(config.Kafka)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExternalQueues{prefix: config.Prefix, factory: factory}, nil
+}
+
+//Enabled return true if stateless mode is configured: there mustn't be any local disk state
+func Enabled() bool {
+	return Instance != nil
+}
+
+//NewQueue return destination stream mode queue in the external queue
+func (eq *ExternalQueues) NewQueue(destinationName string) (*events.PersistentQueue, error) {
+	name := eq.factory.queueName(eq.prefix, destinationName)
+	backend, err := eq.factory.create(name)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating external event queue [%s]: %v", name, err)
+	}
+
+	return events.NewQueue(name, backend), nil
+}
+
+//Close connections to the external queue
+func (eq *ExternalQueues) Close() error {
+	return eq.factory.Close()
+}
+
+//Unsupported return err if stateless mode is enabled: the feature keeps state on local disk
+func Unsupported(feature string) error {
+	if Enabled() {
+		return fmt.Errorf("%s keeps state on local disk and isn't supported in stateless mode", feature)
+	}
+
+	return nil
+}
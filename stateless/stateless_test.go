@@ -0,0 +1,157 @@
+package stateless
+
+import (
+	"errors"
+	"github.com/ksensehq/eventnative/compression"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+//in-memory queues by name
+type factoryMock struct {
+	mutex  sync.Mutex
+	queues map[string]*queueMock
+}
+
+func (fm *factoryMock) queueName(prefix, destinationName string) string {
+	return prefix + ":" + destinationName
+}
+
+func (fm *factoryMock) create(name string) (events.QueueBackend, error) {
+	fm.mutex.Lock()
+	defer fm.mutex.Unlock()
+
+	queue, ok := fm.queues[name]
+	if !ok {
+		queue = &queueMock{items: make(chan []byte, 10)}
+		fm.queues[name] = queue
+	}
+	return queue, nil
+}
+
+func (fm *factoryMock) Close() error {
+	return nil
+}
+
+type queueMock struct {
+	items chan []byte
+	acked int
+}
+
+func (qm *queueMock) Enqueue(factBytes []byte) error {
+	qm.items <- factBytes
+	return nil
+}
+
+func (qm *queueMock) DequeueBlock() ([]byte, events.AckFunc, error) {
+	factBytes, ok := <-qm.items
+	if !ok {
+		return nil, nil, errors.New("closed")
+	}
+	return factBytes, func() error {
+		qm.acked++
+		return nil
+	}, nil
+}
+
+func (qm *queueMock) Size() int {
+	return len(qm.items)
+}
+
+func (qm *queueMock) Close() error {
+	return nil
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.EqualError(t, (&Config{Enabled: true}).Validate(), "stateless queue is required parameter")
+	require.EqualError(t, (&Config{Enabled: true, Queue: "rabbitmq"}).Validate(), "Unknown stateless queue: rabbitmq. Available queues: [redis, kafka]")
+	require.EqualError(t, (&Config{Enabled: true, Queue: RedisQueue, Redis: &RedisQueueConfig{}}).Validate(), "stateless redis.url is required parameter")
+	require.EqualError(t, (&Config{Enabled: true, Queue: KafkaQueue}).Validate(), "stateless kafka config is required")
+	require.EqualError(t, (&Config{Enabled: true, Queue: KafkaQueue, Kafka: &KafkaQueueConfig{}}).Validate(), "Kafka brokers is required parameter")
+
+	config := &Config{Enabled: true, Queue: RedisQueue, Redis: &RedisQueueConfig{URL: "redis://localhost:6379"}}
+	require.NoError(t, config.Validate())
+	require.Equal(t, defaultPrefix, config.Prefix)
+}
+
+func TestSharedQueues(t *testing.T) {
+	factory := &factoryMock{queues: map[string]*queueMock{}}
+	Instance = &ExternalQueues{prefix: "en", factory: factory}
+	defer func() { Instance = nil }()
+
+	require.True(t, Enabled())
+	require.EqualError(t, Unsupported("batch mode"), "batch mode keeps state on local disk and isn't supported in stateless mode")
+
+	//queues of the same destination on different nodes are the same external queue
+	node1, err := Instance.NewQueue("pg")
+	require.NoError(t, err)
+	node2, err := Instance.NewQueue("pg")
+	require.NoError(t, err)
+	require.Len(t, factory.queues, 1)
+	require.Contains(t, factory.queues, "en:pg")
+
+	require.NoError(t, node1.Enqueue(events.Fact{"event_type": "pageview"}))
+	require.Equal(t, 1, node2.Size())
+
+	fact, err := node2.DequeueBlock()
+	require.NoError(t, err)
+	require.Equal(t, events.Fact{"event_type": "pageview"}, fact)
+}
+
+func TestCompressedQueue(t *testing.T) {
+	require.EqualError(t, events.SetQueueCompression("lz4"), "queue_compression: Unknown compression: lz4. Supported: [gzip, zstd]")
+	require.NoError(t, events.SetQueueCompression(compression.Zstd))
+	defer events.SetQueueCompression("")
+
+	backend := &queueMock{items: make(chan []byte, 10)}
+	queue := events.NewQueue("en:pg", backend)
+	require.NoError(t, queue.Enqueue(events.Fact{"event_type": "pageview"}))
+	payload := <-backend.items
+	require.NotEqual(t, byte('{'), payload[0], "payload is compressed")
+	require.NoError(t, backend.Enqueue(payload))
+
+	//event which has been enqueued before compression was turned on
+	require.NoError(t, backend.Enqueue([]byte(`{"event_type":"click"}`)))
+
+	fact, err := queue.DequeueBlock()
+	require.NoError(t, err)
+	require.Equal(t, events.Fact{"event_type": "pageview"}, fact)
+	fact, err = queue.DequeueBlock()
+	require.NoError(t, err)
+	require.Equal(t, events.Fact{"event_type": "click"}, fact)
+}
+
+func TestAcknowledgement(t *testing.T) {
+	backend := &queueMock{items: make(chan []byte, 10)}
+	queue := events.NewQueue("en:pg", backend)
+	require.NoError(t, queue.Enqueue(events.Fact{"event_type": "pageview"}))
+	require.NoError(t, queue.Enqueue(events.Fact{"event_type": "click"}))
+	require.NoError(t, backend.Enqueue([]byte("not json")))
+
+	//acknowledged by the consumer after insert
+	fact, ack, err := queue.DequeueBlockAck()
+	require.NoError(t, err)
+	require.Equal(t, events.Fact{"event_type": "pageview"}, fact)
+	require.Equal(t, 0, backend.acked)
+	require.NoError(t, ack())
+	require.Equal(t, 1, backend.acked)
+
+	//acknowledged right away
+	fact, err = queue.DequeueBlock()
+	require.NoError(t, err)
+	require.Equal(t, events.Fact{"event_type": "click"}, fact)
+	require.Equal(t, 2, backend.acked)
+
+	//malformed event isn't kept in the queue
+	_, _, err = queue.DequeueBlockAck()
+	require.Error(t, err)
+	require.Equal(t, 3, backend.acked)
+}
+
+func TestDisabled(t *testing.T) {
+	require.NoError(t, Init(&Config{Queue: RedisQueue}, "node-1"))
+	require.False(t, Enabled())
+	require.NoError(t, Unsupported("batch mode"))
+}
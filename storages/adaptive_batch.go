@@ -0,0 +1,140 @@
+package storages
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAdaptiveBatchMinSize       = 10
+	defaultAdaptiveBatchTargetLatency = time.Second
+)
+
+//destination types which accumulate stream mode events into micro-batches (see FileBatcher)
+var adaptiveBatchDestinationTypes = []string{"s3", "gcs", "parquet", "kinesis", "druid", "amplitude", "mixpanel", "webhook", "elasticsearch"}
+
+//adaptive batch configs per destination name (only destinations with adaptive_batch config)
+var (
+	adaptiveBatchesMutex sync.RWMutex
+	adaptiveBatches      = map[string]*AdaptiveBatchConfig{}
+)
+
+//AdaptiveBatchConfig dto for deserialized destination adaptive_batch config
+//stream mode micro-batch size starts from min_size and is tuned after every upload within [min_size, configured batch size]:
+//it grows by a quarter after full batch upload which took less than a half of target_latency
+//and is halved after upload which took longer than target_latency or failed
+type AdaptiveBatchConfig struct {
+	MinSize       int           `mapstructure:"min_size"`
+	TargetLatency time.Duration `mapstructure:"target_latency"`
+}
+
+//Validate AdaptiveBatchConfig values and set default ones
+func (abc *AdaptiveBatchConfig) Validate() error {
+	if abc.MinSize < 0 || abc.TargetLatency < 0 {
+		return errors.New("adaptive_batch min_size and target_latency can't be negative")
+	}
+	if abc.MinSize == 0 {
+		abc.MinSize = defaultAdaptiveBatchMinSize
+	}
+	if abc.TargetLatency == 0 {
+		abc.TargetLatency = defaultAdaptiveBatchTargetLatency
+	}
+
+	return nil
+}
+
+//return err if adaptive batch is configured for destination type or mode which doesn't support it or config is invalid
+func validateAdaptiveBatch(destination *DestinationConfig) error {
+	if destination.AdaptiveBatch == nil {
+		return nil
+	}
+
+	if destination.Mode != streamMode {
+		return errors.New("adaptive_batch is supported only in stream mode")
+	}
+
+	for _, t := range adaptiveBatchDestinationTypes {
+		if t == destination.Type {
+			return destination.AdaptiveBatch.Validate()
+		}
+	}
+
+	return fmt.Errorf("adaptive_batch isn't supported by %s destination. Supported types: %v", destination.Type, adaptiveBatchDestinationTypes)
+}
+
+//set (or remove if config is nil) destination adaptive batch config. It is used by FileBatcher which is created afterwards
+func setAdaptiveBatch(destinationName string, config *AdaptiveBatchConfig) {
+	adaptiveBatchesMutex.Lock()
+	defer adaptiveBatchesMutex.Unlock()
+
+	if config == nil {
+		delete(adaptiveBatches, destinationName)
+		return
+	}
+
+	adaptiveBatches[destinationName] = config
+}
+
+//return adaptive batch size of the destination or nil if it isn't configured
+func newDestinationBatchSize(destinationName string, maxSize int) *adaptiveBatchSize {
+	adaptiveBatchesMutex.RLock()
+	config, ok := adaptiveBatches[destinationName]
+	adaptiveBatchesMutex.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	return newAdaptiveBatchSize(config, maxSize)
+}
+
+//adaptiveBatchSize is a micro-batch max objects count which is tuned by upload latency (AIMD)
+type adaptiveBatchSize struct {
+	mutex         sync.Mutex
+	minSize       int
+	maxSize       int
+	targetLatency time.Duration
+	size          int
+}
+
+func newAdaptiveBatchSize(config *AdaptiveBatchConfig, maxSize int) *adaptiveBatchSize {
+	minSize := config.MinSize
+	if minSize > maxSize {
+		minSize = maxSize
+	}
+
+	return &adaptiveBatchSize{minSize: minSize, maxSize: maxSize, targetLatency: config.TargetLatency, size: minSize}
+}
+
+//Size return current batch size
+func (abs *adaptiveBatchSize) Size() int {
+	abs.mutex.Lock()
+	defer abs.mutex.Unlock()
+
+	return abs.size
+}
+
+//Observe tune batch size by the upload of objects count
+//batches which weren't full (e.g. rotated by time) don't show if a bigger batch would be fast enough
+func (abs *adaptiveBatchSize) Observe(objects int, latency time.Duration, failed bool) {
+	abs.mutex.Lock()
+	defer abs.mutex.Unlock()
+
+	switch {
+	case failed || latency > abs.targetLatency:
+		abs.size /= 2
+		if abs.size < abs.minSize {
+			abs.size = abs.minSize
+		}
+	case objects >= abs.size && latency < abs.targetLatency/2:
+		growth := abs.size / 4
+		if growth == 0 {
+			growth = 1
+		}
+		abs.size += growth
+		if abs.size > abs.maxSize {
+			abs.size = abs.maxSize
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package storages
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveBatchSize(t *testing.T) {
+	abs := newAdaptiveBatchSize(&AdaptiveBatchConfig{MinSize: 10, TargetLatency: time.Second}, 20)
+	require.Equal(t, 10, abs.Size())
+
+	//not full batch doesn't change size
+	abs.Observe(5, 10*time.Millisecond, false)
+	require.Equal(t, 10, abs.Size())
+
+	//fast full batches grow size up to max
+	abs.Observe(10, 10*time.Millisecond, false)
+	require.Equal(t, 12, abs.Size())
+	abs.Observe(12, 10*time.Millisecond, false)
+	require.Equal(t, 15, abs.Size())
+	abs.Observe(15, 10*time.Millisecond, false)
+	require.Equal(t, 18, abs.Size())
+	abs.Observe(18, 10*time.Millisecond, false)
+	require.Equal(t, 20, abs.Size())
+
+	//latency between a half of target and target doesn't change size
+	abs.Observe(20, 700*time.Millisecond, false)
+	require.Equal(t, 20, abs.Size())
+
+	//slow or failed uploads shrink size down to min
+	abs.Observe(20, 2*time.Second, false)
+	require.Equal(t, 10, abs.Size())
+	abs.Observe(10, 10*time.Millisecond, true)
+	require.Equal(t, 10, abs.Size())
+
+	//min size isn't greater than max one
+	require.Equal(t, 5, newAdaptiveBatchSize(&AdaptiveBatchConfig{MinSize: 10, TargetLatency: time.Second}, 5).Size())
+}
+
+func TestValidateAdaptiveBatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination *DestinationConfig
+		expectedErr string
+	}{
+		{
+			"not configured",
+			&DestinationConfig{Type: "postgres"},
+			"",
+		},
+		{
+			"batch mode",
+			&DestinationConfig{Type: "s3", Mode: batchMode, AdaptiveBatch: &AdaptiveBatchConfig{}},
+			"adaptive_batch is supported only in stream mode",
+		},
+		{
+			"unsupported type",
+			&DestinationConfig{Type: "postgres", Mode: streamMode, AdaptiveBatch: &AdaptiveBatchConfig{}},
+			"adaptive_batch isn't supported by postgres destination. Supported types: [s3 gcs parquet kinesis druid amplitude mixpanel webhook elasticsearch]",
+		},
+		{
+			"negative values",
+			&DestinationConfig{Type: "webhook", Mode: streamMode, AdaptiveBatch: &AdaptiveBatchConfig{MinSize: -1}},
+			"adaptive_batch min_size and target_latency can't be negative",
+		},
+		{
+			"ok",
+			&DestinationConfig{Type: "webhook", Mode: streamMode, AdaptiveBatch: &AdaptiveBatchConfig{}},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAdaptiveBatch(tt.destination)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	config := &AdaptiveBatchConfig{}
+	require.NoError(t, config.Validate())
+	require.Equal(t, &AdaptiveBatchConfig{MinSize: defaultAdaptiveBatchMinSize, TargetLatency: defaultAdaptiveBatchTargetLatency}, config)
+}
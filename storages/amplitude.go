@@ -0,0 +1,254 @@
+package storages
+
+import (
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/ksensehq/eventnative/typing"
+	"log"
+	"time"
+)
+
+const (
+	defaultAmplitudeUserIDField    = "eventn_ctx_user_id"
+	defaultAmplitudeDeviceIDField  = "eventn_ctx_user_anonymous_id"
+	defaultAmplitudeEventTypeField = "event_type"
+
+	//Amplitude deduplicates events by insert_id
+	amplitudeInsertIDField = "eventn_ctx_event_id"
+)
+
+//Send processed events to Amplitude Batch API in two modes:
+//batch: (1 file = requests with all file events by batch_size events)
+//stream: via events queue and FileBatcher (events are accumulated and sent every flush_every or by batch_size events)
+//events without event type or user_id/device_id are skipped
+type Amplitude struct {
+	name             string
+	amplitudeAdapter *adapters.Amplitude
+	userIDField      string
+	deviceIDField    string
+	eventTypeField   string
+	batchSize        int
+	schemaProcessor  *schema.Processor
+	eventQueue       *events.PersistentQueue
+	batcher          *FileBatcher
+	breakOnError     bool
+}
+
+//NewAmplitude return Amplitude and start goroutine for stream consumer if destination is in stream mode
+func NewAmplitude(name, fallbackDir string, config *adapters.AmplitudeConfig, processor *schema.Processor, breakOnError, streamMode bool) (*Amplitude, error) {
+	amplitudeAdapter := adapters.NewAmplitude(config)
+
+	a := &Amplitude{
+		name:             name,
+		amplitudeAdapter: amplitudeAdapter,
+		userIDField:      config.UserIDField,
+		deviceIDField:    config.DeviceIDField,
+		eventTypeField:   config.EventTypeField,
+		batchSize:        config.BatchSize,
+		schemaProcessor:  processor,
+		breakOnError:     breakOnError,
+	}
+
+	if streamMode {
+		var err error
+		a.eventQueue, err = newEventQueue(name, fallbackDir)
+		if err != nil {
+			amplitudeAdapter.Close()
+			return nil, err
+		}
+
+		a.batcher = NewFileBatcher(name, &FilesConfig{UploadEvery: config.FlushEvery, MaxObjects: config.BatchSize}, a.send)
+		a.startStreamingConsumer()
+	}
+
+	return a, nil
+}
+
+//Consume events.Fact and enqueue it
+func (a *Amplitude) Consume(fact events.Fact) {
+	if err := a.eventQueue.Enqueue(fact); err != nil {
+		logSkippedEvent(a.name, fact, err)
+	}
+}
+
+//QueueSize return count of events in the stream mode queue
+func (a *Amplitude) QueueSize() int {
+	return a.eventQueue.Size()
+}
+
+//Run goroutine to:
+//1. read from queue
+//2. put processed object into batcher if it can be sent to Amplitude
+func (a *Amplitude) startStreamingConsumer() {
+	go func() {
+		for {
+			if appstatus.Instance.Idle {
+				break
+			}
+			fact, err := a.eventQueue.DequeueBlock()
+			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
+				log.Println("Error reading event fact from amplitude queue", err)
+				continue
+			}
+
+			dataSchema, flattenObject, err := a.schemaProcessor.ProcessFact(fact)
+			if err != nil {
+				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(a.name, 1)
+				continue
+			}
+
+			//don't process empty object
+			if !dataSchema.Exists() {
+				continue
+			}
+
+			if _, err := a.toEvent(flattenObject); err != nil {
+				log.Printf("Unable to convert object %v to amplitude event: %v", flattenObject, err)
+				counters.ErrorEvents(a.name, 1)
+				continue
+			}
+
+			a.batcher.Add(dataSchema, flattenObject)
+		}
+	}()
+}
+
+//Store file payload to Amplitude with processing
+func (a *Amplitude) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(a.name); err != nil {
+		return err
+	}
+
+	flatData, err := a.schemaProcessor.ProcessFilePayload(fileName, payload, a.breakOnError, report)
+	if err != nil {
+		return err
+	}
+
+	for _, fdata := range flatData {
+		if err := a.send(fdata); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//send all file objects as Amplitude events with requests by batchSize events
+func (a *Amplitude) send(fdata *schema.ProcessedFile) error {
+	var batch []*adapters.AmplitudeEvent
+	for _, object := range fdata.GetPayload() {
+		event, err := a.toEvent(object)
+		if err != nil {
+			if a.breakOnError {
+				return err
+			}
+			log.Printf("Warn: unable to convert object %v from file %s to amplitude event: %v", object, fdata.FileName, err)
+			fdata.Report.Skip(reports.ConversionReason, err)
+			continue
+		}
+
+		batch = append(batch, event)
+		if len(batch) == a.batchSize {
+			if err := a.amplitudeAdapter.Send(batch); err != nil {
+				return err
+			}
+			batch = nil
+		}
+	}
+
+	return a.amplitudeAdapter.Send(batch)
+}
+
+//return Amplitude event with mapped user_id, device_id, event_type, insert_id, time (from object timestamp or current time)
+//and other fields as event properties
+//ids shorter than Amplitude min length are omitted
+func (a *Amplitude) toEvent(object map[string]interface{}) (*adapters.AmplitudeEvent, error) {
+	event := &adapters.AmplitudeEvent{
+		UserID:          amplitudeID(object, a.userIDField),
+		DeviceID:        amplitudeID(object, a.deviceIDField),
+		EventType:       stringField(object, a.eventTypeField),
+		InsertID:        stringField(object, amplitudeInsertIDField),
+		Time:            time.Now().UTC().UnixNano() / int64(time.Millisecond),
+		EventProperties: map[string]interface{}{},
+	}
+	if event.EventType == "" {
+		return nil, fmt.Errorf("%s field is required", a.eventTypeField)
+	}
+	if event.UserID == "" && event.DeviceID == "" {
+		return nil, fmt.Errorf("%s or %s field with at least %d characters is required", a.userIDField, a.deviceIDField, adapters.AmplitudeMinIDLength)
+	}
+
+	if value, ok := object[timestamp.Key]; ok && value != nil {
+		if converted, err := typing.Convert(typing.TIMESTAMP, value); err == nil {
+			event.Time = converted.(time.Time).UnixNano() / int64(time.Millisecond)
+		}
+	}
+
+	for name, value := range object {
+		switch name {
+		case a.userIDField, a.deviceIDField, a.eventTypeField, amplitudeInsertIDField, timestamp.Key:
+		default:
+			event.EventProperties[name] = value
+		}
+	}
+
+	return event, nil
+}
+
+func (a *Amplitude) Name() string {
+	return a.name
+}
+
+func (a *Amplitude) Type() string {
+	return a.amplitudeAdapter.Name()
+}
+
+func (a *Amplitude) Close() (multiErr error) {
+	if a.batcher != nil {
+		if err := a.batcher.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing amplitude batcher: %v", err))
+		}
+	}
+
+	if a.eventQueue != nil {
+		if err := a.eventQueue.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing amplitude event queue: %v", err))
+		}
+	}
+
+	if err := a.amplitudeAdapter.Close(); err != nil {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing amplitude client: %v", err))
+	}
+
+	return
+}
+
+//return object field value as string or empty string if it doesn't exist
+func stringField(object map[string]interface{}, field string) string {
+	if value, ok := object[field]; ok && value != nil {
+		return fmt.Sprint(value)
+	}
+
+	return ""
+}
+
+//return object field value as string or empty string if it doesn't exist or is shorter than Amplitude min id length
+func amplitudeID(object map[string]interface{}, field string) string {
+	id := stringField(object, field)
+	if len(id) < adapters.AmplitudeMinIDLength {
+		return ""
+	}
+
+	return id
+}
@@ -0,0 +1,73 @@
+package storages
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAmplitudeToEvent(t *testing.T) {
+	a := &Amplitude{userIDField: "eventn_ctx_user_id", deviceIDField: "eventn_ctx_user_anonymous_id", eventTypeField: "event_type"}
+	tests := []struct {
+		name             string
+		object           map[string]interface{}
+		expectedUserID   string
+		expectedDeviceID string
+		expectedTime     int64
+		expectedProps    map[string]interface{}
+		expectedErr      string
+	}{
+		{
+			"All fields",
+			map[string]interface{}{"eventn_ctx_user_id": "user1", "eventn_ctx_user_anonymous_id": "anonym1", "event_type": "pageview",
+				"eventn_ctx_event_id": "id1", "_timestamp": "2020-06-16T23:00:00.000000Z", "page_title": "Привет"},
+			"user1",
+			"anonym1",
+			1592348400000,
+			map[string]interface{}{"page_title": "Привет"},
+			"",
+		},
+		{
+			"Short user id is omitted",
+			map[string]interface{}{"eventn_ctx_user_id": 42, "eventn_ctx_user_anonymous_id": "anonym1", "event_type": "pageview",
+				"_timestamp": "2020-06-16T23:00:00.000000Z"},
+			"",
+			"anonym1",
+			1592348400000,
+			map[string]interface{}{},
+			"",
+		},
+		{
+			"Without ids",
+			map[string]interface{}{"eventn_ctx_user_anonymous_id": "a1", "event_type": "pageview"},
+			"",
+			"",
+			0,
+			nil,
+			"eventn_ctx_user_id or eventn_ctx_user_anonymous_id field with at least 5 characters is required",
+		},
+		{
+			"Without event type",
+			map[string]interface{}{"eventn_ctx_user_id": "user1"},
+			"",
+			"",
+			0,
+			nil,
+			"event_type field is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := a.toEvent(tt.object)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedUserID, event.UserID)
+			require.Equal(t, tt.expectedDeviceID, event.DeviceID)
+			require.Equal(t, "pageview", event.EventType)
+			require.Equal(t, tt.expectedTime, event.Time)
+			require.Equal(t, tt.expectedProps, event.EventProperties)
+		})
+	}
+}
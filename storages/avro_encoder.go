@@ -0,0 +1,276 @@
+package storages
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/linkedin/goavro/v2"
+	"sync"
+)
+
+const (
+	avroNamespace     = "eventnative"
+	avroSubjectSuffix = "-value"
+	//Confluent wire format: magic byte, 4 bytes schema id, avro binary
+	avroMagicByte = 0
+)
+
+var avroTypes = map[typing.DataType]interface{}{
+	typing.INT64:     "long",
+	typing.FLOAT64:   "double",
+	typing.STRING:    "string",
+	typing.TIMESTAMP: map[string]string{"type": "long", "logicalType": "timestamp-micros"},
+}
+
+//names of avro union members
+var avroUnionNames = map[typing.DataType]string{
+	typing.INT64:     "long",
+	typing.FLOAT64:   "double",
+	typing.STRING:    "string",
+	typing.TIMESTAMP: "long.timestamp-micros",
+}
+
+type avroRecordSchema struct {
+	Type      string            `json:"type"`
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Fields    []avroFieldSchema `json:"fields"`
+}
+
+type avroFieldSchema struct {
+	Name    string        `json:"name"`
+	Doc     string        `json:"doc,omitempty"`
+	Type    []interface{} `json:"type"`
+	Default interface{}   `json:"default"`
+}
+
+//avroField is a record field of a flattened object column
+type avroField struct {
+	column   string
+	name     string
+	dataType typing.DataType
+}
+
+//avroSchema is a registered version of a subject record schema
+//it is immutable: a new version is created when a subject gets new fields or field types are widened
+type avroSchema struct {
+	fields []avroField
+	codec  *goavro.Codec
+	//schema registry id or 0 if registry isn't configured
+	id int
+}
+
+//avroSubject accumulates record fields of one subject (kafka topic or files table)
+type avroSubject struct {
+	recordName string
+	fields     []avroField
+	//column -> fields index
+	columns map[string]int
+	names   map[string]bool
+	current *avroSchema
+	//current version doesn't have all fields (e.g. a new version hasn't been registered because of an error)
+	dirty bool
+}
+
+//avroEncoder serializes processed objects into avro. Record schemas are derived from objects: every subject
+//accumulates all fields it has seen (new fields are appended, types are widened to common ancestor types
+//e.g. long -> double). All fields are nullable with null default, so new schema versions are backward compatible unless
+//a field type is changed to an incompatible one (e.g. long -> string). New versions are registered in schema registry
+//if it is configured (the registry rejects incompatible versions according to the subject compatibility level)
+type avroEncoder struct {
+	codec    string
+	registry *adapters.SchemaRegistry
+
+	mutex    sync.Mutex
+	subjects map[string]*avroSubject
+}
+
+func newAvroEncoder(config *adapters.AvroConfig) *avroEncoder {
+	var registry *adapters.SchemaRegistry
+	if config.SchemaRegistry != nil {
+		registry = adapters.NewSchemaRegistry(config.SchemaRegistry)
+	}
+
+	return &avroEncoder{codec: config.Codec, registry: registry, subjects: map[string]*avroSubject{}}
+}
+
+//EncodeMessage return object in Confluent wire format with schema of subject (e.g. topic-value)
+func (ae *avroEncoder) EncodeMessage(subject, tableName string, object map[string]interface{}) ([]byte, error) {
+	version, err := ae.schema(subject, tableName, []map[string]interface{}{object})
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := version.record(object)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 5)
+	header[0] = avroMagicByte
+	binary.BigEndian.PutUint32(header[1:], uint32(version.id))
+
+	return version.codec.BinaryFromNative(header, record)
+}
+
+//EncodeFile return avro object container file with all processed file objects. Subject is table-value
+func (ae *avroEncoder) EncodeFile(fdata *schema.ProcessedFile) ([]byte, error) {
+	tableName := fdata.DataSchema.Name
+	version, err := ae.schema(tableName+avroSubjectSuffix, tableName, fdata.GetPayload())
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]interface{}, 0, len(fdata.GetPayload()))
+	for _, object := range fdata.GetPayload() {
+		record, err := version.record(object)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	buf := &bytes.Buffer{}
+	writer, err := goavro.NewOCFWriter(goavro.OCFConfig{W: buf, Codec: version.codec, CompressionName: ae.codec})
+	if err != nil {
+		return nil, err
+	}
+	if err := writer.Append(records); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+//return subject schema version which contains all objects fields. Create and register a new version if it is needed
+func (ae *avroEncoder) schema(subject, tableName string, objects []map[string]interface{}) (*avroSchema, error) {
+	ae.mutex.Lock()
+	defer ae.mutex.Unlock()
+
+	s, ok := ae.subjects[subject]
+	if !ok {
+		s = &avroSubject{recordName: avroName(tableName, nil), columns: map[string]int{}, names: map[string]bool{}, dirty: true}
+		ae.subjects[subject] = s
+	}
+
+	for _, object := range objects {
+		for column, value := range object {
+			if value == nil {
+				continue
+			}
+			dataType, err := typing.TypeFromValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("Error getting avro type of field [%s]: %v", column, err)
+			}
+			if _, ok := avroTypes[dataType]; !ok {
+				dataType = typing.STRING
+			}
+
+			index, ok := s.columns[column]
+			if !ok {
+				s.columns[column] = len(s.fields)
+				s.fields = append(s.fields, avroField{column: column, name: avroName(column, s.names), dataType: dataType})
+				s.dirty = true
+				continue
+			}
+
+			if widened := typing.GetCommonAncestorType(s.fields[index].dataType, dataType); widened != s.fields[index].dataType {
+				s.fields[index].dataType = widened
+				s.dirty = true
+			}
+		}
+	}
+
+	if !s.dirty {
+		return s.current, nil
+	}
+
+	version, err := ae.newSchema(subject, s)
+	if err != nil {
+		return nil, err
+	}
+	s.current = version
+	s.dirty = false
+
+	return version, nil
+}
+
+//build subject record schema and register it in schema registry if it is configured
+func (ae *avroEncoder) newSchema(subject string, s *avroSubject) (*avroSchema, error) {
+	record := avroRecordSchema{Type: "record", Name: s.recordName, Namespace: avroNamespace, Fields: []avroFieldSchema{}}
+	for _, field := range s.fields {
+		fieldSchema := avroFieldSchema{Name: field.name, Type: []interface{}{"null", avroTypes[field.dataType]}}
+		if field.name != field.column {
+			fieldSchema.Doc = field.column
+		}
+		record.Fields = append(record.Fields, fieldSchema)
+	}
+
+	schemaBytes, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	if err != nil {
+		return nil, fmt.Errorf("Error creating avro schema of subject [%s]: %v", subject, err)
+	}
+
+	version := &avroSchema{fields: append([]avroField{}, s.fields...), codec: codec}
+	if ae.registry != nil {
+		if version.id, err = ae.registry.Register(subject, codec.Schema()); err != nil {
+			return nil, err
+		}
+	}
+
+	return version, nil
+}
+
+//return avro record of object with values converted to fields types
+func (as *avroSchema) record(object map[string]interface{}) (map[string]interface{}, error) {
+	record := make(map[string]interface{}, len(as.fields))
+	for _, field := range as.fields {
+		value, ok := object[field.column]
+		if !ok || value == nil {
+			record[field.name] = nil
+			continue
+		}
+
+		converted, err := typing.Convert(field.dataType, value)
+		if err != nil {
+			return nil, fmt.Errorf("Error converting field [%s] to avro %s: %v", field.column, avroUnionNames[field.dataType], err)
+		}
+		record[field.name] = goavro.Union(avroUnionNames[field.dataType], converted)
+	}
+
+	return record, nil
+}
+
+//return avro name ([A-Za-z_][A-Za-z0-9_]*) of flattened field or table name
+//name is suffixed with a number if it is already used (names is optional)
+func avroName(name string, names map[string]bool) string {
+	result := []byte(name)
+	for i, c := range result {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || i > 0 && c >= '0' && c <= '9') {
+			result[i] = '_'
+		}
+	}
+	if len(result) == 0 {
+		result = []byte{'_'}
+	}
+
+	avroName := string(result)
+	if names == nil {
+		return avroName
+	}
+	for i := 1; names[avroName]; i++ {
+		avroName = fmt.Sprintf("%s_%d", result, i)
+	}
+	names[avroName] = true
+
+	return avroName
+}
@@ -0,0 +1,137 @@
+package storages
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+//schema registry stub which returns new id for every new schema
+func newTestSchemaRegistry(t *testing.T) (*httptest.Server, map[int]string) {
+	schemas := map[int]string{}
+	ids := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := map[string]string{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		key := r.URL.Path + request["schema"]
+		id, ok := ids[key]
+		if !ok {
+			id = len(ids) + 1
+			ids[key] = id
+			schemas[id] = request["schema"]
+		}
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	}))
+	return server, schemas
+}
+
+func decodeTestMessage(t *testing.T, schemas map[int]string, message []byte) (int, map[string]interface{}) {
+	require.Equal(t, byte(avroMagicByte), message[0])
+	id := int(binary.BigEndian.Uint32(message[1:5]))
+	codec, err := goavro.NewCodec(schemas[id])
+	require.NoError(t, err)
+
+	native, rest, err := codec.NativeFromBinary(message[5:])
+	require.NoError(t, err)
+	require.Empty(t, rest)
+	return id, native.(map[string]interface{})
+}
+
+func TestAvroEncoderMessage(t *testing.T) {
+	server, schemas := newTestSchemaRegistry(t)
+	defer server.Close()
+
+	config := &adapters.AvroConfig{SchemaRegistry: &adapters.SchemaRegistryConfig{URL: server.URL}}
+	require.NoError(t, config.Validate())
+	encoder := newAvroEncoder(config)
+
+	ts := time.Date(2020, 10, 10, 10, 10, 10, 123456000, time.UTC)
+	message, err := encoder.EncodeMessage("events_pageview-value", "pageview", map[string]interface{}{"id": 1.0, "_timestamp": ts, "url": "/home"})
+	require.NoError(t, err)
+	id, record := decodeTestMessage(t, schemas, message)
+	require.Equal(t, 1, id)
+	require.Equal(t, map[string]interface{}{
+		"id":         map[string]interface{}{"long": int64(1)},
+		"_timestamp": map[string]interface{}{"long.timestamp-micros": ts},
+		"url":        map[string]interface{}{"string": "/home"},
+	}, record)
+
+	//the same fields: the same version
+	message, err = encoder.EncodeMessage("events_pageview-value", "pageview", map[string]interface{}{"id": 2.0, "url": nil})
+	require.NoError(t, err)
+	id, record = decodeTestMessage(t, schemas, message)
+	require.Equal(t, 1, id)
+	require.Nil(t, record["url"])
+
+	//new field and widened type: new version
+	message, err = encoder.EncodeMessage("events_pageview-value", "pageview", map[string]interface{}{"id": 2.5, "1st-click": "button", "tags": []interface{}{"a", "b"}})
+	require.NoError(t, err)
+	id, record = decodeTestMessage(t, schemas, message)
+	require.Equal(t, 2, id)
+	require.Equal(t, map[string]interface{}{"double": 2.5}, record["id"])
+	require.Equal(t, map[string]interface{}{"string": "button"}, record["_st_click"])
+	require.Equal(t, map[string]interface{}{"string": `["a","b"]`}, record["tags"])
+	require.Nil(t, record["_timestamp"])
+	require.Contains(t, schemas[2], `"doc":"1st-click"`)
+	require.Contains(t, schemas[2], `"name":"pageview","namespace":"eventnative"`)
+
+	//old objects are encoded with the latest version
+	message, err = encoder.EncodeMessage("events_pageview-value", "pageview", map[string]interface{}{"id": 3.0})
+	require.NoError(t, err)
+	id, record = decodeTestMessage(t, schemas, message)
+	require.Equal(t, 2, id)
+	require.Equal(t, map[string]interface{}{"double": 3.0}, record["id"])
+
+	//subjects are independent
+	message, err = encoder.EncodeMessage("events_click-value", "click", map[string]interface{}{"id": "abc"})
+	require.NoError(t, err)
+	id, record = decodeTestMessage(t, schemas, message)
+	require.Equal(t, 3, id)
+	require.Equal(t, map[string]interface{}{"string": "abc"}, record["id"])
+}
+
+func TestAvroEncoderFile(t *testing.T) {
+	config := &adapters.AvroConfig{Codec: adapters.AvroDeflateCodec}
+	require.NoError(t, config.Validate())
+	encoder := newAvroEncoder(config)
+
+	payload, err := encoder.EncodeFile(newTestProcessedFile("events",
+		map[string]interface{}{"id": 1.0, "title": "a"},
+		map[string]interface{}{"id": 2.0, "price": 9.99},
+	))
+	require.NoError(t, err)
+
+	reader, err := goavro.NewOCFReader(bytes.NewReader(payload))
+	require.NoError(t, err)
+	require.Equal(t, adapters.AvroDeflateCodec, reader.CompressionName())
+	require.True(t, strings.Contains(reader.Codec().Schema(), `"name":"events"`))
+
+	var records []interface{}
+	for reader.Scan() {
+		record, err := reader.Read()
+		require.NoError(t, err)
+		records = append(records, record)
+	}
+	require.NoError(t, reader.Err())
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"id": map[string]interface{}{"long": int64(1)}, "title": map[string]interface{}{"string": "a"}, "price": nil},
+		map[string]interface{}{"id": map[string]interface{}{"long": int64(2)}, "title": nil, "price": map[string]interface{}{"double": 9.99}},
+	}, records)
+}
+
+func TestAvroName(t *testing.T) {
+	names := map[string]bool{}
+	require.Equal(t, "event_type", avroName("event_type", names))
+	require.Equal(t, "_st_click", avroName("1st-click", names))
+	require.Equal(t, "_st_click_1", avroName("1st_click", names))
+	require.Equal(t, "_", avroName("", names))
+	require.Equal(t, "events_2020", avroName("events.2020", nil))
+}
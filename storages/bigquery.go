@@ -7,8 +7,13 @@ import (
 	"github.com/ksensehq/eventnative/adapters"
 	"github.com/ksensehq/eventnative/appconfig"
 	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/dbt"
 	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
 	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/ksensehq/eventnative/watermarks"
 	"log"
 	"strings"
 	"time"
@@ -35,8 +40,7 @@ func NewBigQuery(ctx context.Context, name, fallbackDir string, config *adapters
 	var eventQueue *events.PersistentQueue
 	if streamMode {
 		var err error
-		queueName := fmt.Sprintf("%s-%s", appconfig.Instance.ServerName, name)
-		eventQueue, err = events.NewPersistentQueue(queueName, fallbackDir)
+		eventQueue, err = newEventQueue(name, fallbackDir)
 		if err != nil {
 			return nil, err
 		}
@@ -53,15 +57,16 @@ func NewBigQuery(ctx context.Context, name, fallbackDir string, config *adapters
 		return nil, err
 	}
 
-	//create dataset if doesn't exist
-	err = bigQueryAdapter.CreateDataset(config.Dataset)
-	if err != nil {
-		return nil, err
+	//create dataset if doesn't exist (nothing is created with existing tables)
+	if processor.ExistingTables() == nil {
+		if err := bigQueryAdapter.CreateDataset(config.Dataset); err != nil {
+			return nil, err
+		}
 	}
 
 	monitorKeeper := NewMonitorKeeper()
 
-	tableHelper := NewTableHelper(bigQueryAdapter, monitorKeeper, bqStorageType)
+	tableHelper := NewTableHelper(bigQueryAdapter, monitorKeeper, bqStorageType, processor.ExistingTables(), nil, processor.SchemaEvolution(), processor.ColumnsLimit(), processor.TablesCache(), processor.MetadataCache())
 
 	bq := &BigQuery{
 		name:            name,
@@ -73,7 +78,9 @@ func NewBigQuery(ctx context.Context, name, fallbackDir string, config *adapters
 		breakOnError:    breakOnError,
 	}
 	if streamMode {
-		bq.startStreamingConsumer()
+		for i := 0; i < streamWorkers(name); i++ {
+			bq.startStreamingConsumer()
+		}
 	} else {
 		bq.startBatchStorage()
 	}
@@ -90,8 +97,11 @@ func (bq *BigQuery) startStreamingConsumer() {
 			if appstatus.Instance.Idle {
 				break
 			}
-			fact, err := bq.eventQueue.DequeueBlock()
+			fact, ack, err := bq.eventQueue.DequeueBlockAck()
 			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
 				log.Println("Error reading event fact from bigquery queue", err)
 				continue
 			}
@@ -99,25 +109,35 @@ func (bq *BigQuery) startStreamingConsumer() {
 			dataSchema, flattenObject, err := bq.schemaProcessor.ProcessFact(fact)
 			if err != nil {
 				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(bq.name, 1)
+				acknowledge(bq.name, ack)
 				continue
 			}
 
 			//don't process empty object
 			if !dataSchema.Exists() {
+				acknowledge(bq.name, ack)
 				continue
 			}
 
-			if err := bq.insert(dataSchema, flattenObject); err != nil {
-				log.Printf("Error inserting to bigquery table [%s]: %v", dataSchema.Name, err)
+			eventTime := bq.schemaProcessor.EventTime(dataSchema, flattenObject)
+			if err := insertWithRetry(bq.name, fact, ack, func() error { return bq.insert(dataSchema, flattenObject) }); err != nil {
+				if err != errForwarded {
+					log.Printf("Error inserting to bigquery table [%s]: %v", dataSchema.Name, err)
+					counters.ErrorEvents(bq.name, 1)
+				}
 				continue
 			}
+
+			counters.SuccessEvents(bq.name, 1)
+			watermarks.Commit(bq.name, dataSchema.Name, eventTime)
 		}
 	}()
 }
 
 //Periodically (every 30 seconds):
 //1. get all files from google cloud storage
-//2. load them to BigQuery via google api
+//2. load them to BigQuery via google api (merge them into upsert tables)
 //3. delete file from google cloud storage
 func (bq *BigQuery) startBatchStorage() {
 	go func() {
@@ -145,7 +165,12 @@ func (bq *BigQuery) startBatchStorage() {
 					continue
 				}
 
-				if err := bq.bqAdapter.Copy(fileKey, names[1]); err != nil {
+				if upsertKeys := bq.schemaProcessor.UpsertKeys(names[1]); len(upsertKeys) > 0 {
+					if err := bq.bqAdapter.Merge(fileKey, names[1], upsertKeys, bq.schemaProcessor.SystemColumn(timestamp.Key)); err != nil {
+						log.Printf("Error merging file [%s] from google cloud storage to BigQuery: %v", fileKey, err)
+						continue
+					}
+				} else if err := bq.bqAdapter.Copy(fileKey, names[1]); err != nil {
 					log.Printf("Error copying file [%s] from google cloud storage to BigQuery: %v", fileKey, err)
 					continue
 				}
@@ -162,18 +187,27 @@ func (bq *BigQuery) startBatchStorage() {
 //Consume events.Fact and enqueue it
 func (bq *BigQuery) Consume(fact events.Fact) {
 	if err := bq.eventQueue.Enqueue(fact); err != nil {
-		logSkippedEvent(fact, err)
+		logSkippedEvent(bq.name, fact, err)
 	}
 }
 
+//QueueSize return count of events in the stream mode queue
+func (bq *BigQuery) QueueSize() int {
+	return bq.eventQueue.Size()
+}
+
 //insert fact in BigQuery
 func (bq *BigQuery) insert(dataSchema *schema.Table, fact events.Fact) (err error) {
-	dbSchema, err := bq.tableHelper.EnsureTable(dataSchema)
+	if err := injectFault(bq.name); err != nil {
+		return err
+	}
+
+	dbSchema, err := bq.tableHelper.EnsureTable(dataSchema, fact)
 	if err != nil {
 		return err
 	}
 
-	if err := bq.schemaProcessor.ApplyDBTypingToObject(dbSchema, fact); err != nil {
+	if err := bq.tableHelper.ApplyDBTypingToObject(bq.schemaProcessor, dbSchema, dataSchema, fact); err != nil {
 		return err
 	}
 
@@ -181,19 +215,23 @@ func (bq *BigQuery) insert(dataSchema *schema.Table, fact events.Fact) (err erro
 }
 
 //Store file from byte payload to google cloud storage with processing
-func (bq *BigQuery) Store(fileName string, payload []byte) error {
-	flatData, err := bq.schemaProcessor.ProcessFilePayload(fileName, payload, bq.breakOnError)
+func (bq *BigQuery) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(bq.name); err != nil {
+		return err
+	}
+
+	flatData, err := bq.schemaProcessor.ProcessFilePayload(fileName, payload, bq.breakOnError, report)
 	if err != nil {
 		return err
 	}
 
 	for _, fdata := range flatData {
-		dbSchema, err := bq.tableHelper.EnsureTable(fdata.DataSchema)
+		dbSchema, err := bq.tableHelper.EnsureTable(fdata.DataSchema, fdata.GetPayload()...)
 		if err != nil {
 			return err
 		}
 
-		if err := bq.schemaProcessor.ApplyDBTyping(dbSchema, fdata); err != nil {
+		if err := bq.tableHelper.ApplyDBTyping(bq.schemaProcessor, dbSchema, fdata); err != nil {
 			return err
 		}
 	}
@@ -208,6 +246,17 @@ func (bq *BigQuery) Store(fileName string, payload []byte) error {
 	return nil
 }
 
+//Tables return tables schemas known by the destination
+func (bq *BigQuery) Tables() []*schema.Table {
+	return bq.tableHelper.Tables()
+}
+
+//DbtSource return dbt source of tables known by the destination
+func (bq *BigQuery) DbtSource(freshness *dbt.Freshness) *dbt.Source {
+	database, schemaName := bq.bqAdapter.Location()
+	return dbt.NewSource(bq.name, database, schemaName, bq.Tables(), bq.schemaProcessor.SystemColumn(timestamp.Key), bq.schemaProcessor.ColumnDescription, freshness)
+}
+
 func (bq *BigQuery) Name() string {
 	return bq.name
 }
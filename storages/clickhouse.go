@@ -5,12 +5,17 @@ import (
 	"fmt"
 	"github.com/hashicorp/go-multierror"
 	"github.com/ksensehq/eventnative/adapters"
-	"github.com/ksensehq/eventnative/appconfig"
 	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/dbt"
 	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
 	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/ksensehq/eventnative/watermarks"
 	"log"
-	"math/rand"
+	"sort"
+	"sync"
 )
 
 const clickHouseStorageType = "ClickHouse"
@@ -18,13 +23,33 @@ const clickHouseStorageType = "ClickHouse"
 //Store files to ClickHouse in two modes:
 //batch: (1 file = 1 transaction)
 //stream: (1 object = 1 transaction)
+//if engine.ttl is configured - it is applied to existing tables only explicitly with admin API (see ModifyTTL)
+//if buffer is configured - in stream mode events are inserted into buffer tables which are created once for every table
+//if native is configured - in batch mode every table data is inserted with one native protocol INSERT (columnar blocks)
+//if staging is configured - in batch mode every table data is inserted into staging table and is moved into the main one
+//only after all file tables have been loaded successfully
+//if distributed is configured - rows are inserted into distributed tables which are created once for every table
+//so they are spread across cluster shards by the sharding key
+//nodes (dsns) are chosen by NodeBalancer: failed nodes are excluded from rotation
 type ClickHouse struct {
 	name            string
 	adapters        []*adapters.ClickHouse
 	tableHelpers    []*TableHelper
+	balancer        *NodeBalancer
 	schemaProcessor *schema.Processor
 	eventQueue      *events.PersistentQueue
 	breakOnError    bool
+	staging         bool
+
+	ttl string
+
+	buffered       bool
+	bufferMutex    sync.Mutex
+	bufferedTables map[string]bool
+
+	distributed       bool
+	distributedMutex  sync.Mutex
+	distributedTables map[string]bool
 }
 
 func NewClickHouse(ctx context.Context, name, fallbackDir string, config *adapters.ClickHouseConfig, processor *schema.Processor,
@@ -37,27 +62,40 @@ func NewClickHouse(ctx context.Context, name, fallbackDir string, config *adapte
 	var eventQueue *events.PersistentQueue
 	if streamMode {
 		var err error
-		queueName := fmt.Sprintf("%s-%s", appconfig.Instance.ServerName, name)
-		eventQueue, err = events.NewPersistentQueue(queueName, fallbackDir)
+		eventQueue, err = newEventQueue(name, fallbackDir)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	//put default values and values from config
-	nonNullFields := map[string]bool{"eventn_ctx_event_id": true, "_timestamp": true}
+	nonNullFields := map[string]bool{processor.SystemColumn(schema.EventIDColumn): true, processor.SystemColumn(timestamp.Key): true}
 	if config.Engine != nil {
 		for _, fieldName := range config.Engine.NonNullFields {
 			nonNullFields[fieldName] = true
 		}
+		//partition, order and primary key fields are added automatically
+		for _, fieldName := range config.Engine.KeyFields() {
+			nonNullFields[fieldName] = true
+		}
+	}
+	for _, fieldName := range config.Distributed.KeyFields() {
+		nonNullFields[fieldName] = true
 	}
 
 	monitorKeeper := NewMonitorKeeper()
+	//buffer tables are used only for stream inserts
+	buffered := streamMode && config.Buffer != nil
+	//batch inserts are already large enough
+	var asyncInsert *adapters.AsyncInsertConfig
+	if streamMode {
+		asyncInsert = config.AsyncInsert
+	}
 
 	var chAdapters []*adapters.ClickHouse
 	var tableHelpers []*TableHelper
 	for _, dsn := range config.Dsns {
-		adapter, err := adapters.NewClickHouse(ctx, dsn, config.Database, config.Cluster, config.Tls, tableStatementFactory, nonNullFields)
+		adapter, err := adapters.NewClickHouse(ctx, dsn, config.Database, config.Cluster, config.Tls, tableStatementFactory, nonNullFields, config.Columns, buffered, config.Native, asyncInsert)
 		if err != nil {
 			//close all previous created adapters
 			for _, toClose := range chAdapters {
@@ -67,35 +105,88 @@ func NewClickHouse(ctx context.Context, name, fallbackDir string, config *adapte
 		}
 
 		chAdapters = append(chAdapters, adapter)
-		tableHelpers = append(tableHelpers, NewTableHelper(adapter, monitorKeeper, clickHouseStorageType))
+		tableHelpers = append(tableHelpers, NewTableHelper(adapter, monitorKeeper, clickHouseStorageType, processor.ExistingTables(), nil, processor.SchemaEvolution(), processor.ColumnsLimit(), processor.TablesCache(), processor.MetadataCache()))
 	}
 
 	ch := &ClickHouse{
-		name:            name,
-		adapters:        chAdapters,
-		tableHelpers:    tableHelpers,
-		schemaProcessor: processor,
-		eventQueue:      eventQueue,
-		breakOnError:    breakOnError,
+		name:              name,
+		adapters:          chAdapters,
+		tableHelpers:      tableHelpers,
+		balancer:          NewNodeBalancer(name, len(chAdapters), config.Balancer, func(node int) error { return chAdapters[node].Ping() }),
+		schemaProcessor:   processor,
+		eventQueue:        eventQueue,
+		breakOnError:      breakOnError,
+		staging:           config.Staging,
+		buffered:          buffered,
+		bufferedTables:    map[string]bool{},
+		distributed:       config.Distributed != nil,
+		distributedTables: map[string]bool{},
+	}
+	if config.Engine != nil {
+		ch.ttl = config.Engine.TTL
 	}
 
-	adapter, _ := ch.getAdapters()
-	err = adapter.CreateDB(config.Database)
-	if err != nil {
-		//close all previous created adapters
-		for _, toClose := range chAdapters {
-			toClose.Close()
+	//create database if doesn't exist (nothing is created with existing tables)
+	if processor.ExistingTables() == nil {
+		_, adapter, _ := ch.getAdapters()
+		if err := adapter.CreateDB(config.Database); err != nil {
+			//close all previous created adapters
+			for _, toClose := range chAdapters {
+				toClose.Close()
+			}
+			ch.balancer.Close()
+			return nil, err
 		}
-		return nil, err
 	}
 
 	if streamMode {
-		ch.startStreamingConsumer()
+		for i := 0; i < streamWorkers(name); i++ {
+			ch.startStreamingConsumer()
+		}
 	}
 
 	return ch, nil
 }
 
+//Tables return tables schemas known by the destination
+//every ClickHouse node has own table helper so the latest versions of tables are taken
+func (ch *ClickHouse) Tables() []*schema.Table {
+	tablesByName := map[string]*schema.Table{}
+	for _, tableHelper := range ch.tableHelpers {
+		for _, table := range tableHelper.Tables() {
+			if current, ok := tablesByName[table.Name]; !ok || current.Version < table.Version {
+				tablesByName[table.Name] = table
+			}
+		}
+	}
+
+	tables := []*schema.Table{}
+	for _, table := range tablesByName {
+		tables = append(tables, table)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+
+	return tables
+}
+
+//DbtSource return dbt source of tables known by the destination
+func (ch *ClickHouse) DbtSource(freshness *dbt.Freshness) *dbt.Source {
+	database, schemaName := ch.adapters[0].Location()
+	return dbt.NewSource(ch.name, database, schemaName, ch.Tables(), ch.schemaProcessor.SystemColumn(timestamp.Key), ch.schemaProcessor.ColumnDescription, freshness)
+}
+
+//SelectLast return last rows of the table where keyColumn equals value (on the node which is chosen by NodeBalancer)
+func (ch *ClickHouse) SelectLast(tableName, keyColumn, orderColumn string, value interface{}, limit int) ([]map[string]interface{}, error) {
+	_, adapter, _ := ch.getAdapters()
+	return adapter.SelectLast(tableName, keyColumn, orderColumn, value, limit)
+}
+
+//SelectMetric return rows of predefined aggregate query (on the node which is chosen by NodeBalancer)
+func (ch *ClickHouse) SelectMetric(query *adapters.MetricQuery) ([]map[string]interface{}, error) {
+	_, adapter, _ := ch.getAdapters()
+	return adapter.SelectMetric(query)
+}
+
 func (ch *ClickHouse) Name() string {
 	return ch.name
 }
@@ -107,10 +198,15 @@ func (ch *ClickHouse) Type() string {
 //Consume events.Fact and enqueue it
 func (ch *ClickHouse) Consume(fact events.Fact) {
 	if err := ch.eventQueue.Enqueue(fact); err != nil {
-		logSkippedEvent(fact, err)
+		logSkippedEvent(ch.name, fact, err)
 	}
 }
 
+//QueueSize return count of events in the stream mode queue
+func (ch *ClickHouse) QueueSize() int {
+	return ch.eventQueue.Size()
+}
+
 //Run goroutine to:
 //1. read from queue
 //2. insert in ClickHouse
@@ -120,8 +216,11 @@ func (ch *ClickHouse) startStreamingConsumer() {
 			if appstatus.Instance.Idle {
 				break
 			}
-			fact, err := ch.eventQueue.DequeueBlock()
+			fact, ack, err := ch.eventQueue.DequeueBlockAck()
 			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
 				log.Println("Error reading event fact from clickhouse queue", err)
 				continue
 			}
@@ -129,32 +228,68 @@ func (ch *ClickHouse) startStreamingConsumer() {
 			dataSchema, flattenObject, err := ch.schemaProcessor.ProcessFact(fact)
 			if err != nil {
 				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(ch.name, 1)
+				acknowledge(ch.name, ack)
 				continue
 			}
 
 			//don't process empty object
 			if !dataSchema.Exists() {
+				acknowledge(ch.name, ack)
 				continue
 			}
 
-			if err := ch.insert(dataSchema, flattenObject); err != nil {
-				log.Printf("Error inserting to clickhouse table [%s]: %v", dataSchema.Name, err)
+			eventTime := ch.schemaProcessor.EventTime(dataSchema, flattenObject)
+			if err := insertWithRetry(ch.name, fact, ack, func() error { return ch.insert(dataSchema, flattenObject) }); err != nil {
+				if err != errForwarded {
+					log.Printf("Error inserting to clickhouse table [%s]: %v", dataSchema.Name, err)
+					counters.ErrorEvents(ch.name, 1)
+				}
 				continue
 			}
+
+			counters.SuccessEvents(ch.name, 1)
+			watermarks.Commit(ch.name, dataSchema.Name, eventTime)
 		}
 	}()
 }
 
 //insert fact in ClickHouse
 func (ch *ClickHouse) insert(dataSchema *schema.Table, fact events.Fact) (err error) {
-	adapter, tableHelper := ch.getAdapters()
+	if err := injectFault(ch.name); err != nil {
+		return err
+	}
+
+	node, adapter, tableHelper := ch.getAdapters()
+	defer func() {
+		ch.balancer.Report(node, err)
+	}()
+
+	if len(dataSchema.DeletionKeys) > 0 {
+		dbSchema, err := tableHelper.DeletionsTable(dataSchema)
+		if err != nil || dbSchema == nil {
+			return err
+		}
+		if err := ch.schemaProcessor.ApplyDBTypingToObject(dbSchema, dataSchema, fact); err != nil {
+			return err
+		}
+
+		return adapter.Delete(dataSchema, []map[string]interface{}{fact})
+	}
 
-	dbSchema, err := tableHelper.EnsureTable(dataSchema)
+	dbSchema, err := tableHelper.EnsureTable(dataSchema, fact)
 	if err != nil {
 		return err
 	}
+	//buffer table is flushed into distributed one so it must be created before
+	if err := ch.ensureDistributed(adapter, dataSchema.Name); err != nil {
+		return err
+	}
+	if err := ch.ensureBuffer(adapter, dataSchema.Name); err != nil {
+		return err
+	}
 
-	if err := ch.schemaProcessor.ApplyDBTypingToObject(dbSchema, fact); err != nil {
+	if err := tableHelper.ApplyDBTypingToObject(ch.schemaProcessor, dbSchema, dataSchema, fact); err != nil {
 		return err
 	}
 
@@ -162,25 +297,110 @@ func (ch *ClickHouse) insert(dataSchema *schema.Table, fact events.Fact) (err er
 }
 
 //Store file payload to ClickHouse with processing
-func (ch *ClickHouse) Store(fileName string, payload []byte) error {
-	flatData, err := ch.schemaProcessor.ProcessFilePayload(fileName, payload, ch.breakOnError)
+func (ch *ClickHouse) Store(fileName string, payload []byte, report *reports.LoadReport) (err error) {
+	if err := injectFault(ch.name); err != nil {
+		return err
+	}
+
+	flatData, err := ch.schemaProcessor.ProcessFilePayload(fileName, payload, ch.breakOnError, report)
 	if err != nil {
 		return err
 	}
 
-	adapter, tableHelper := ch.getAdapters()
+	deletions := schema.ExtractDeletions(flatData)
+
+	node, adapter, tableHelper := ch.getAdapters()
+	defer func() {
+		ch.balancer.Report(node, err)
+	}()
 	//process db tables & schema
 	for _, fdata := range flatData {
-		dbSchema, err := tableHelper.EnsureTable(fdata.DataSchema)
+		dbSchema, err := tableHelper.EnsureTable(fdata.DataSchema, fdata.GetPayload()...)
+		if err != nil {
+			return err
+		}
+		if err := ch.ensureDistributed(adapter, fdata.DataSchema.Name); err != nil {
+			return err
+		}
+
+		if err := tableHelper.ApplyDBTyping(ch.schemaProcessor, dbSchema, fdata); err != nil {
+			return err
+		}
+	}
+
+	deletions, err = tableHelper.PrepareDeletions(ch.schemaProcessor, deletions)
+	if err != nil {
+		return err
+	}
+
+	if !ch.staging {
+		if err := ch.insertBatch(adapter, flatData, nil, report); err != nil {
+			return err
+		}
+		return ch.delete(adapter, deletions, report)
+	}
+
+	//two-phase loading: data is moved into main tables only after all tables data has been inserted into staging ones
+	stagingTables := map[string]string{}
+	defer func() {
+		for _, stagingTable := range stagingTables {
+			if err := adapter.DropStagingTable(stagingTable); err != nil {
+				log.Printf("Error in %s destination: %v", ch.name, err)
+			}
+		}
+	}()
+	for _, fdata := range flatData {
+		stagingTable, err := adapter.CreateStagingTable(fdata.DataSchema.Name)
 		if err != nil {
 			return err
 		}
+		stagingTables[fdata.DataSchema.Name] = stagingTable
+	}
+
+	if err := ch.insertBatch(adapter, flatData, stagingTables, report); err != nil {
+		return err
+	}
 
-		if err := ch.schemaProcessor.ApplyDBTyping(dbSchema, fdata); err != nil {
+	for tableName, stagingTable := range stagingTables {
+		if err := adapter.MoveStagingTable(stagingTable, tableName); err != nil {
 			return err
 		}
 	}
 
+	return ch.delete(adapter, deletions, report)
+}
+
+//delete rows referenced by deletion events files (one mutation per table)
+func (ch *ClickHouse) delete(adapter *adapters.ClickHouse, deletions []*schema.ProcessedFile, report *reports.LoadReport) error {
+	for _, fdata := range deletions {
+		if err := adapter.Delete(fdata.DataSchema, fdata.GetPayload()); err != nil {
+			if ch.breakOnError {
+				return err
+			}
+			log.Printf("Warn: unable to delete objects of table %s reason: %v. These lines will be skipped", fdata.DataSchema.Name, err)
+			for range fdata.GetPayload() {
+				report.Skip(reports.DeleteReason, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+//insert all tables data over native protocol or in one transaction
+//data is inserted into staging tables instead of main ones if stagingTables (main table name: staging table name) is provided
+//or into distributed tables if distributed is configured
+func (ch *ClickHouse) insertBatch(adapter *adapters.ClickHouse, flatData map[string]*schema.ProcessedFile, stagingTables map[string]string,
+	report *reports.LoadReport) error {
+	if adapter.Native() {
+		for _, fdata := range flatData {
+			if err := adapter.InsertBlock(insertTable(fdata.DataSchema, stagingTables, ch.distributed).Name, fdata.GetPayload(), !ch.breakOnError, report); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	//insert all data in one transaction
 	tx, err := adapter.OpenTx()
 	if err != nil {
@@ -188,13 +408,15 @@ func (ch *ClickHouse) Store(fileName string, payload []byte) error {
 	}
 
 	for _, fdata := range flatData {
+		table := insertTable(fdata.DataSchema, stagingTables, ch.distributed)
 		for _, object := range fdata.GetPayload() {
-			if err := adapter.InsertInTransaction(tx, fdata.DataSchema, object); err != nil {
+			if err := adapter.InsertInTransaction(tx, table, object); err != nil {
 				if ch.breakOnError {
 					tx.Rollback()
 					return err
 				} else {
 					log.Printf("Warn: unable to insert object %v reason: %v. This line will be skipped", object, err)
+					report.Skip(reports.InsertReason, err)
 				}
 			}
 		}
@@ -203,8 +425,110 @@ func (ch *ClickHouse) Store(fileName string, payload []byte) error {
 	return tx.DirectCommit()
 }
 
-//Close adapters.ClickHouse
+//return staging table of dataSchema (with the same columns) if it exists in stagingTables,
+//distributed table of dataSchema if distributed otherwise dataSchema
+//staging tables are local so rows are distributed when they are moved into the main table
+func insertTable(dataSchema *schema.Table, stagingTables map[string]string, distributed bool) *schema.Table {
+	if stagingTable, ok := stagingTables[dataSchema.Name]; ok {
+		return &schema.Table{Name: stagingTable, Columns: dataSchema.Columns}
+	}
+	if distributed {
+		return &schema.Table{Name: adapters.DistributedTableName(dataSchema.Name), Columns: dataSchema.Columns}
+	}
+
+	return dataSchema
+}
+
+//ModifyTTLDDL return statements which apply engine.ttl to existing table (see ModifyTTL)
+func (ch *ClickHouse) ModifyTTLDDL(tableName string) ([]string, error) {
+	adapter, err := ch.ttlAdapter(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{adapter.ModifyTTLStatement(tableName, ch.ttl)}, nil
+}
+
+//ModifyTTL apply engine.ttl to existing table explicitly (admin API): rows which are already expired are deleted
+func (ch *ClickHouse) ModifyTTL(tableName string) error {
+	adapter, err := ch.ttlAdapter(tableName)
+	if err != nil {
+		return err
+	}
+
+	if err := adapter.ModifyTTL(tableName, ch.ttl); err != nil {
+		return err
+	}
+	log.Printf("TTL [%s] has been applied to table [%s] in %s destination", ch.ttl, tableName, ch.name)
+
+	return nil
+}
+
+//return adapter of the next node if engine.ttl is configured and the table exists
+func (ch *ClickHouse) ttlAdapter(tableName string) (*adapters.ClickHouse, error) {
+	if ch.ttl == "" {
+		return nil, fmt.Errorf("engine.ttl isn't configured in %s destination", ch.name)
+	}
+
+	_, adapter, _ := ch.getAdapters()
+	tableSchema, err := adapter.GetTableSchema(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting table %s schema from %s: %v", tableName, clickHouseStorageType, err)
+	}
+	if !tableSchema.Exists() {
+		return nil, fmt.Errorf("Table %s doesn't exist in %s", tableName, clickHouseStorageType)
+	}
+
+	return adapter, nil
+}
+
+//ensureBuffer create buffer table of existing table if buffer is configured and it hasn't been created after start yet
+//new tables are created together with buffer ones
+func (ch *ClickHouse) ensureBuffer(adapter *adapters.ClickHouse, tableName string) error {
+	if !ch.buffered {
+		return nil
+	}
+
+	ch.bufferMutex.Lock()
+	defer ch.bufferMutex.Unlock()
+
+	if ch.bufferedTables[tableName] {
+		return nil
+	}
+
+	if err := adapter.CreateBufferTable(tableName); err != nil {
+		return err
+	}
+	ch.bufferedTables[tableName] = true
+
+	return nil
+}
+
+//ensureDistributed create distributed table of existing table if distributed is configured and it hasn't been created after start yet
+//new tables are created together with distributed ones
+func (ch *ClickHouse) ensureDistributed(adapter *adapters.ClickHouse, tableName string) error {
+	if !ch.distributed {
+		return nil
+	}
+
+	ch.distributedMutex.Lock()
+	defer ch.distributedMutex.Unlock()
+
+	if ch.distributedTables[tableName] {
+		return nil
+	}
+
+	if err := adapter.CreateDistributedTable(tableName); err != nil {
+		return err
+	}
+	ch.distributedTables[tableName] = true
+
+	return nil
+}
+
+//Close adapters.ClickHouse and NodeBalancer
 func (ch *ClickHouse) Close() (multiErr error) {
+	ch.balancer.Close()
 	for i, adapter := range ch.adapters {
 		if err := adapter.Close(); err != nil {
 			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing clickhouse datasource[%d]: %v", i, err))
@@ -220,8 +544,9 @@ func (ch *ClickHouse) Close() (multiErr error) {
 	return multiErr
 }
 
+//return node index, adapter and table helper of the node which is chosen by NodeBalancer
 //assume that adapters quantity == tableHelpers quantity
-func (ch *ClickHouse) getAdapters() (*adapters.ClickHouse, *TableHelper) {
-	num := rand.Intn(len(ch.adapters))
-	return ch.adapters[num], ch.tableHelpers[num]
+func (ch *ClickHouse) getAdapters() (int, *adapters.ClickHouse, *TableHelper) {
+	num := ch.balancer.Next()
+	return num, ch.adapters[num], ch.tableHelpers[num]
 }
@@ -0,0 +1,23 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestInsertTable(t *testing.T) {
+	events := &schema.Table{Name: "events", Columns: schema.Columns{"id": schema.NewColumn(typing.INT64)}}
+	stagingTables := map[string]string{"events": "events_staging_1"}
+
+	require.Equal(t, events, insertTable(events, nil, false))
+	require.Equal(t, &schema.Table{Name: "events_staging_1", Columns: events.Columns}, insertTable(events, stagingTables, false))
+
+	other := &schema.Table{Name: "other", Columns: schema.Columns{}}
+	require.Equal(t, other, insertTable(other, stagingTables, false))
+
+	//rows are distributed on moving from local staging tables
+	require.Equal(t, &schema.Table{Name: "events_staging_1", Columns: events.Columns}, insertTable(events, stagingTables, true))
+	require.Equal(t, &schema.Table{Name: "dist_other", Columns: other.Columns}, insertTable(other, stagingTables, true))
+}
@@ -0,0 +1,58 @@
+package storages
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/schema"
+)
+
+const sqlIdentifierCharacters = "a-zA-Z0-9_"
+
+//identifier rules of destination dialects which are used as data_layout.column_names defaults
+var columnNamesDialects = map[string]schema.ColumnNamesConfig{
+	"postgres":   {AllowedCharacters: sqlIdentifierCharacters, MaxLength: 63},
+	"redshift":   {AllowedCharacters: sqlIdentifierCharacters, MaxLength: 127},
+	"snowflake":  {AllowedCharacters: sqlIdentifierCharacters, MaxLength: 255},
+	"mssql":      {AllowedCharacters: sqlIdentifierCharacters, MaxLength: 128},
+	"bigquery":   {AllowedCharacters: sqlIdentifierCharacters, MaxLength: 300},
+	"clickhouse": {AllowedCharacters: sqlIdentifierCharacters},
+}
+
+//destination types which write column names unquoted (so the database folds their case) and compare them in lower case
+var lowerCaseColumnsDestinationTypes = []string{"postgres", "redshift", "snowflake", "mssql"}
+
+//return data_layout.column_names config with destination dialect defaults (for values which aren't provided)
+//or nil if it isn't configured
+//return err if case can't be kept in destination type or config is invalid
+func destinationColumnNames(destination *DestinationConfig, columnNames *schema.ColumnNamesConfig) (*schema.ColumnNamesConfig, error) {
+	if columnNames == nil {
+		return nil, nil
+	}
+
+	if columnNames.Case == schema.KeepCase {
+		for _, t := range lowerCaseColumnsDestinationTypes {
+			if t == destination.Type {
+				return nil, fmt.Errorf("data_layout.column_names.case: %s isn't supported by %s destination: column names are case insensitive", schema.KeepCase, destination.Type)
+			}
+		}
+	}
+
+	result := *columnNames
+	if dialect, ok := columnNamesDialects[destination.Type]; ok {
+		if result.AllowedCharacters == "" {
+			result.AllowedCharacters = dialect.AllowedCharacters
+		}
+		if result.MaxLength == 0 {
+			result.MaxLength = dialect.MaxLength
+		}
+	}
+	if result.ReservedWords == nil {
+		result.ReservedWords = adapters.DestinationReservedWords(destination.Type)
+	}
+
+	if _, err := schema.NewColumnNames(&result, nil); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
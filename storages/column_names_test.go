@@ -0,0 +1,79 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDestinationColumnNames(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination *DestinationConfig
+		config      *schema.ColumnNamesConfig
+		expected    *schema.ColumnNamesConfig
+		expectedErr string
+	}{
+		{
+			"not configured",
+			&DestinationConfig{Type: "postgres"},
+			nil,
+			nil,
+			"",
+		},
+		{
+			"dialect defaults",
+			&DestinationConfig{Type: "postgres"},
+			&schema.ColumnNamesConfig{},
+			&schema.ColumnNamesConfig{AllowedCharacters: "a-zA-Z0-9_", MaxLength: 63, ReservedWords: adapters.DestinationReservedWords("postgres")},
+			"",
+		},
+		{
+			"overridden defaults",
+			&DestinationConfig{Type: "bigquery"},
+			&schema.ColumnNamesConfig{AllowedCharacters: "a-z0-9_", MaxLength: 64, ReservedWords: []string{"order"}, ReservedSuffix: "_"},
+			&schema.ColumnNamesConfig{AllowedCharacters: "a-z0-9_", MaxLength: 64, ReservedWords: []string{"order"}, ReservedSuffix: "_"},
+			"",
+		},
+		{
+			"destination without dialect",
+			&DestinationConfig{Type: "s3"},
+			&schema.ColumnNamesConfig{Case: schema.KeepCase},
+			&schema.ColumnNamesConfig{Case: schema.KeepCase},
+			"",
+		},
+		{
+			"keep case in clickhouse",
+			&DestinationConfig{Type: "clickhouse"},
+			&schema.ColumnNamesConfig{Case: schema.KeepCase},
+			&schema.ColumnNamesConfig{Case: schema.KeepCase, AllowedCharacters: "a-zA-Z0-9_", ReservedWords: adapters.DestinationReservedWords("clickhouse")},
+			"",
+		},
+		{
+			"keep case in snowflake",
+			&DestinationConfig{Type: "snowflake"},
+			&schema.ColumnNamesConfig{Case: schema.KeepCase},
+			nil,
+			"data_layout.column_names.case: keep isn't supported by snowflake destination: column names are case insensitive",
+		},
+		{
+			"invalid config",
+			&DestinationConfig{Type: "redshift"},
+			&schema.ColumnNamesConfig{CollisionSuffix: "_dup"},
+			nil,
+			"column_names.collision_suffix [_dup] must contain %d placeholder of collision number once",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := destinationColumnNames(tt.destination, tt.config)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
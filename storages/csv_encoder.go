@@ -0,0 +1,199 @@
+package storages
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/timestamp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	JSONFormat = "json"
+	CSVFormat  = "csv"
+	AvroFormat = "avro"
+
+	MinimalQuoting = "minimal"
+	AllQuoting     = "all"
+	NoneQuoting    = "none"
+)
+
+//CSVConfig dto for deserialized files.csv config
+//delimiter and quote_char: one character. Default values: , and "
+//quoting: minimal (default) - only values with delimiter, quote char, line breaks or leading/trailing spaces are quoted,
+//all - all not null values are quoted, none - values are written as is (values with delimiter or line breaks are rejected)
+//null: representation of missing and null values (never quoted). Default value: empty string
+//header: write header row with column names at the beginning of every object
+//columns: pinned columns per table: only these columns are written in this order. Columns of other tables are
+//pinned in the order they are first seen (new columns are appended to the end)
+type CSVConfig struct {
+	Delimiter string              `mapstructure:"delimiter"`
+	QuoteChar string              `mapstructure:"quote_char"`
+	Quoting   string              `mapstructure:"quoting"`
+	Null      string              `mapstructure:"null"`
+	Header    bool                `mapstructure:"header"`
+	Columns   map[string][]string `mapstructure:"columns"`
+}
+
+//Validate CSVConfig values and set default ones
+func (cc *CSVConfig) Validate() error {
+	if cc.Delimiter == "" {
+		cc.Delimiter = ","
+	}
+	if cc.QuoteChar == "" {
+		cc.QuoteChar = `"`
+	}
+	if cc.Quoting == "" {
+		cc.Quoting = MinimalQuoting
+	}
+
+	if utf8.RuneCountInString(cc.Delimiter) != 1 || utf8.RuneCountInString(cc.QuoteChar) != 1 {
+		return errors.New("files csv delimiter and quote_char must be one character")
+	}
+	if cc.Delimiter == cc.QuoteChar || strings.ContainsAny(cc.Delimiter, "\r\n") || strings.ContainsAny(cc.QuoteChar, "\r\n") {
+		return errors.New("files csv delimiter and quote_char must be different and can't be line breaks")
+	}
+	if cc.Quoting != MinimalQuoting && cc.Quoting != AllQuoting && cc.Quoting != NoneQuoting {
+		return fmt.Errorf("Unknown files csv quoting: %s. Available: [%s, %s, %s]", cc.Quoting, MinimalQuoting, AllQuoting, NoneQuoting)
+	}
+	for table, columns := range cc.Columns {
+		if len(columns) == 0 {
+			return fmt.Errorf("files csv columns of table [%s] can't be empty", table)
+		}
+	}
+
+	return nil
+}
+
+//csvEncoder serializes processed files into CSV objects with stable column order per table
+type csvEncoder struct {
+	config *CSVConfig
+
+	mutex sync.Mutex
+	//column order per table (pinned columns or accumulated ones)
+	catalog map[string][]string
+}
+
+func newCSVEncoder(config *CSVConfig) *csvEncoder {
+	catalog := map[string][]string{}
+	for table, columns := range config.Columns {
+		catalog[table] = columns
+	}
+
+	return &csvEncoder{config: config, catalog: catalog}
+}
+
+//Encode return CSV rows of all processed file objects (with header row if configured)
+//return err if a value can't be written with configured quoting
+func (ce *csvEncoder) Encode(fdata *schema.ProcessedFile) ([]byte, error) {
+	columns := ce.columns(fdata)
+
+	buf := &bytes.Buffer{}
+	if ce.config.Header {
+		header := make([]string, len(columns))
+		for i, column := range columns {
+			header[i] = ce.quote(column, false)
+		}
+		buf.WriteString(strings.Join(header, ce.config.Delimiter))
+		buf.WriteByte('\n')
+	}
+
+	row := make([]string, len(columns))
+	for _, object := range fdata.GetPayload() {
+		for i, column := range columns {
+			value, ok := object[column]
+			if !ok || value == nil {
+				row[i] = ce.config.Null
+				continue
+			}
+
+			str := csvValue(value)
+			if ce.config.Quoting == NoneQuoting && (strings.Contains(str, ce.config.Delimiter) || strings.ContainsAny(str, "\r\n")) {
+				return nil, fmt.Errorf("Value of column [%s] contains delimiter or line break and can't be written without quoting", column)
+			}
+			row[i] = ce.quote(str, ce.config.Quoting == AllQuoting)
+		}
+		buf.WriteString(strings.Join(row, ce.config.Delimiter))
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+//return table columns order: pinned one or accumulated one with new columns (sorted) appended
+func (ce *csvEncoder) columns(fdata *schema.ProcessedFile) []string {
+	ce.mutex.Lock()
+	defer ce.mutex.Unlock()
+
+	tableName := fdata.DataSchema.Name
+	if _, pinned := ce.config.Columns[tableName]; pinned {
+		return ce.catalog[tableName]
+	}
+
+	current := ce.catalog[tableName]
+	known := make(map[string]bool, len(current))
+	for _, column := range current {
+		known[column] = true
+	}
+
+	var newColumns []string
+	for _, object := range fdata.GetPayload() {
+		for column := range object {
+			if !known[column] {
+				known[column] = true
+				newColumns = append(newColumns, column)
+			}
+		}
+	}
+	if len(newColumns) > 0 {
+		sort.Strings(newColumns)
+		//copy for not changing slices which have been returned before
+		current = append(append([]string{}, current...), newColumns...)
+		ce.catalog[tableName] = current
+	}
+
+	return current
+}
+
+func (ce *csvEncoder) quote(value string, force bool) string {
+	switch ce.config.Quoting {
+	case NoneQuoting:
+		return value
+	case MinimalQuoting:
+		if !force && !strings.Contains(value, ce.config.Delimiter) && !strings.Contains(value, ce.config.QuoteChar) &&
+			!strings.ContainsAny(value, "\r\n") && strings.TrimSpace(value) == value {
+			return value
+		}
+	}
+
+	return ce.config.QuoteChar + strings.Replace(value, ce.config.QuoteChar, ce.config.QuoteChar+ce.config.QuoteChar, -1) + ce.config.QuoteChar
+}
+
+//return string representation of flattened object value
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, bool:
+		return fmt.Sprint(v)
+	case time.Time:
+		return v.UTC().Format(timestamp.Layout)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
@@ -0,0 +1,91 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestProcessedFile(table string, objects ...map[string]interface{}) *schema.ProcessedFile {
+	fdata := schema.NewProcessedFile("file.log", &schema.Table{Name: table, Columns: schema.Columns{}})
+	for _, object := range objects {
+		fdata.Add(&schema.Table{Name: table, Columns: schema.Columns{}}, object)
+	}
+	return fdata
+}
+
+func TestCSVEncoder(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *CSVConfig
+		objects  []map[string]interface{}
+		expected string
+	}{
+		{
+			"default dialect",
+			&CSVConfig{},
+			[]map[string]interface{}{
+				{"id": 1.0, "title": "a, b", "quote": `say "hi"`, "flag": true},
+				{"id": 2.5, "title": " padded", "time": time.Date(2020, 10, 10, 10, 10, 10, 0, time.UTC)},
+			},
+			"true,1,\"say \"\"hi\"\"\",,\"a, b\"\n,2.5,,2020-10-10T10:10:10.000000Z,\" padded\"\n",
+		},
+		{
+			"custom dialect with header",
+			&CSVConfig{Delimiter: ";", QuoteChar: "'", Quoting: AllQuoting, Null: `\N`, Header: true},
+			[]map[string]interface{}{
+				{"id": 1.0, "title": "it's", "empty": ""},
+				{"id": 2.0, "title": nil},
+			},
+			"'empty';'id';'title'\n'';'1';'it''s'\n\\N;'2';\\N\n",
+		},
+		{
+			"pinned columns",
+			&CSVConfig{Columns: map[string][]string{"events": {"title", "id", "missing"}}},
+			[]map[string]interface{}{
+				{"id": 1.0, "title": "a", "other": "dropped"},
+			},
+			"a,1,\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.config.Validate())
+			payload, err := newCSVEncoder(tt.config).Encode(newTestProcessedFile("events", tt.objects...))
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(payload))
+		})
+	}
+}
+
+func TestCSVEncoderColumnsOrder(t *testing.T) {
+	config := &CSVConfig{Header: true}
+	require.NoError(t, config.Validate())
+	encoder := newCSVEncoder(config)
+
+	payload, err := encoder.Encode(newTestProcessedFile("events", map[string]interface{}{"b": "1", "d": "2"}))
+	require.NoError(t, err)
+	require.Equal(t, "b,d\n1,2\n", string(payload))
+
+	//known columns keep their positions, new ones are appended
+	payload, err = encoder.Encode(newTestProcessedFile("events", map[string]interface{}{"a": "3", "d": "4", "c": "5"}))
+	require.NoError(t, err)
+	require.Equal(t, "b,d,a,c\n,4,3,5\n", string(payload))
+
+	payload, err = encoder.Encode(newTestProcessedFile("users", map[string]interface{}{"z": "6"}))
+	require.NoError(t, err)
+	require.Equal(t, "z\n6\n", string(payload))
+}
+
+func TestCSVConfigValidate(t *testing.T) {
+	require.EqualError(t, (&CSVConfig{Delimiter: "||"}).Validate(), "files csv delimiter and quote_char must be one character")
+	require.EqualError(t, (&CSVConfig{Delimiter: `"`}).Validate(), "files csv delimiter and quote_char must be different and can't be line breaks")
+	require.EqualError(t, (&CSVConfig{Quoting: "some"}).Validate(), "Unknown files csv quoting: some. Available: [minimal, all, none]")
+	require.EqualError(t, (&CSVConfig{Columns: map[string][]string{"events": {}}}).Validate(), "files csv columns of table [events] can't be empty")
+
+	config := &CSVConfig{Quoting: NoneQuoting}
+	require.NoError(t, config.Validate())
+	_, err := newCSVEncoder(config).Encode(newTestProcessedFile("events", map[string]interface{}{"title": "a,b"}))
+	require.EqualError(t, err, "Value of column [title] contains delimiter or line break and can't be written without quoting")
+}
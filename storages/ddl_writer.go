@@ -0,0 +1,121 @@
+package storages
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/webhooks"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDDLRefreshEvery = time.Minute
+	ddlFileExtension       = ".sql"
+)
+
+//ReadOnlySchemaConfig dto for deserialized data_layout.read_only_schema config
+//enabled: DDL statements are never executed. CREATE TABLE and ALTER TABLE statements which are required by events
+//are written into ddl_dir/$destination.sql and sent with ddl_required webhook for applying by a human (e.g. DBA)
+//ddl_dir: directory of DDL files. Default: $log.path/ddl
+//refresh_every: tables schemas are re-read from the destination so applied statements are picked up. Default: 1m
+type ReadOnlySchemaConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	DDLDir       string        `mapstructure:"ddl_dir"`
+	RefreshEvery time.Duration `mapstructure:"refresh_every"`
+}
+
+//Validate ReadOnlySchemaConfig values and set default ones
+func (rc *ReadOnlySchemaConfig) Validate(logEventPath string) error {
+	if rc.RefreshEvery < 0 {
+		return errors.New("data_layout.read_only_schema refresh_every can't be negative")
+	}
+
+	if rc.DDLDir == "" {
+		rc.DDLDir = path.Join(logEventPath, "ddl")
+	}
+	if rc.RefreshEvery == 0 {
+		rc.RefreshEvery = defaultDDLRefreshEvery
+	}
+
+	return nil
+}
+
+//DDLWriter writes DDL statements which are required by events instead of executing them (read-only schema mode)
+//every statement is written once per process lifetime: it is written again after restart if it hasn't been applied
+type DDLWriter struct {
+	destination  string
+	filePath     string
+	refreshEvery time.Duration
+
+	mutex   sync.Mutex
+	written map[string]bool
+}
+
+//NewDDLWriter return DDLWriter of the destination or nil if read-only schema mode isn't enabled
+func NewDDLWriter(destination string, config *ReadOnlySchemaConfig) (*DDLWriter, error) {
+	if config == nil || !config.Enabled {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(config.DDLDir, 0744); err != nil {
+		return nil, fmt.Errorf("Error creating DDL dir [%s]: %v", config.DDLDir, err)
+	}
+
+	return &DDLWriter{
+		destination:  destination,
+		filePath:     path.Join(config.DDLDir, destination+ddlFileExtension),
+		refreshEvery: config.RefreshEvery,
+		written:      map[string]bool{},
+	}, nil
+}
+
+//RefreshEvery return interval of re-reading tables schemas
+func (dw *DDLWriter) RefreshEvery() time.Duration {
+	return dw.refreshEvery
+}
+
+//Write append not written yet statements of the table into the DDL file with the comment header
+//and fire ddl_required webhook with them
+func (dw *DDLWriter) Write(storageType, tableName string, statements []string) error {
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+
+	var notWritten []string
+	for _, statement := range statements {
+		if !dw.written[statement] {
+			notWritten = append(notWritten, statement)
+		}
+	}
+	if len(notWritten) == 0 {
+		return nil
+	}
+
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("-- %s %s table %s\n", time.Now().UTC().Format(time.RFC3339), storageType, tableName))
+	for _, statement := range notWritten {
+		content.WriteString(statement + ";\n")
+	}
+
+	file, err := os.OpenFile(dw.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("Error opening DDL file [%s]: %v", dw.filePath, err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(content.String()); err != nil {
+		return fmt.Errorf("Error writing DDL file [%s]: %v", dw.filePath, err)
+	}
+
+	for _, statement := range notWritten {
+		dw.written[statement] = true
+	}
+
+	log.Printf("[%s] DDL statements of table %s are required and written into %s: %s", dw.destination, tableName, dw.filePath, strings.Join(notWritten, "; "))
+	webhooks.Fire(webhooks.DDLRequired, map[string]interface{}{"destination": dw.destination, "storage_type": storageType, "table": tableName, "statements": notWritten})
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package storages
+
+import (
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDDLWriter(t *testing.T) {
+	writer, err := NewDDLWriter("pg", &ReadOnlySchemaConfig{Enabled: false})
+	require.NoError(t, err)
+	require.Nil(t, writer)
+
+	dir, err := ioutil.TempDir("", "ddl_writer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &ReadOnlySchemaConfig{Enabled: true}
+	require.NoError(t, config.Validate(dir))
+	require.Equal(t, path.Join(dir, "ddl"), config.DDLDir)
+	require.Equal(t, time.Minute, config.RefreshEvery)
+
+	writer, err = NewDDLWriter("pg", config)
+	require.NoError(t, err)
+
+	require.NoError(t, writer.Write("Postgres", "events", []string{`CREATE TABLE "public"."events" ("id" bigint)`}))
+	require.NoError(t, writer.Write("Postgres", "events", []string{`CREATE TABLE "public"."events" ("id" bigint)`}))
+	require.NoError(t, writer.Write("Postgres", "users", []string{`ALTER TABLE "public"."users" ADD COLUMN "name" text`,
+		`ALTER TABLE "public"."users" ADD COLUMN "email" text`}))
+
+	content, err := ioutil.ReadFile(path.Join(dir, "ddl", "pg.sql"))
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Equal(t, 5, len(lines))
+	require.True(t, strings.HasPrefix(lines[0], "-- ") && strings.HasSuffix(lines[0], " Postgres table events"), lines[0])
+	require.Equal(t, `CREATE TABLE "public"."events" ("id" bigint);`, lines[1])
+	require.True(t, strings.HasSuffix(lines[2], " Postgres table users"), lines[2])
+	require.Equal(t, `ALTER TABLE "public"."users" ADD COLUMN "name" text;`, lines[3])
+	require.Equal(t, `ALTER TABLE "public"."users" ADD COLUMN "email" text;`, lines[4])
+
+	require.Error(t, (&ReadOnlySchemaConfig{Enabled: true, RefreshEvery: -time.Second}).Validate(dir))
+}
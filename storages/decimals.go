@@ -0,0 +1,66 @@
+package storages
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+)
+
+//destination types which create DECIMAL columns
+var decimalsDestinationTypes = []string{"postgres", "redshift", "snowflake", "mssql", "bigquery", "clickhouse"}
+
+//max precision of DECIMAL columns per destination type
+var decimalsMaxPrecision = map[string]int{
+	"postgres":   1000,
+	"redshift":   38,
+	"snowflake":  38,
+	"mssql":      38,
+	"bigquery":   38,
+	"clickhouse": 76,
+}
+
+//BigQuery NUMERIC type has fixed scale 9 (29 digits before the decimal point)
+const bigQueryNumericScale = 9
+
+//return err if data_layout.decimals or decimal types are configured for destination type which doesn't support them
+//or their precision and scale don't fit destination DECIMAL type
+func validateDecimals(destination *DestinationConfig, decimalsConfig *schema.DecimalsConfig, types map[string]string) error {
+	decimals, err := schema.NewDecimals(decimalsConfig, types)
+	if err != nil {
+		return err
+	}
+
+	decimalTypes := map[string]*schema.DecimalType{}
+	if decimalsConfig != nil {
+		decimalTypes["data_layout.decimals"] = decimals.DefaultType()
+	}
+	for path, typeName := range types {
+		if dataType, err := typing.TypeFromString(typeName); err != nil || dataType != typing.DECIMAL {
+			continue
+		}
+		decimalType, ok := schema.ParseDecimalType(typeName)
+		if !ok {
+			decimalType = decimals.DefaultType()
+		}
+		decimalTypes[fmt.Sprintf("data_layout.types [%s]", path)] = decimalType
+	}
+	if len(decimalTypes) == 0 {
+		return nil
+	}
+
+	maxPrecision, ok := decimalsMaxPrecision[destination.Type]
+	if !ok {
+		return fmt.Errorf("Decimal types aren't supported by %s destination. Supported types: %v", destination.Type, decimalsDestinationTypes)
+	}
+	for name, decimalType := range decimalTypes {
+		if decimalType.Precision > maxPrecision {
+			return fmt.Errorf("%s: %s precision is greater than max %s precision: %d", name, decimalType, destination.Type, maxPrecision)
+		}
+		if destination.Type == "bigquery" && (decimalType.Scale > bigQueryNumericScale || decimalType.Precision-decimalType.Scale > 38-bigQueryNumericScale) {
+			return fmt.Errorf("%s: %s doesn't fit bigquery NUMERIC type: scale must be <= %d and precision - scale <= %d", name, decimalType,
+				bigQueryNumericScale, 38-bigQueryNumericScale)
+		}
+	}
+
+	return nil
+}
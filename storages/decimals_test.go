@@ -0,0 +1,77 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestValidateDecimals(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination *DestinationConfig
+		decimals    *schema.DecimalsConfig
+		types       map[string]string
+		expectedErr string
+	}{
+		{
+			"not configured",
+			&DestinationConfig{Type: "s3"},
+			nil,
+			map[string]string{"/price": "double"},
+			"",
+		},
+		{
+			"unsupported destination",
+			&DestinationConfig{Type: "s3"},
+			&schema.DecimalsConfig{Detect: true},
+			nil,
+			"Decimal types aren't supported by s3 destination. Supported types: [postgres redshift snowflake mssql bigquery clickhouse]",
+		},
+		{
+			"unsupported destination type",
+			&DestinationConfig{Type: "elasticsearch"},
+			nil,
+			map[string]string{"/price": "decimal"},
+			"Decimal types aren't supported by elasticsearch destination. Supported types: [postgres redshift snowflake mssql bigquery clickhouse]",
+		},
+		{
+			"precision over destination max",
+			&DestinationConfig{Type: "redshift"},
+			nil,
+			map[string]string{"/price": "decimal(60,2)"},
+			"data_layout.types [/price]: decimal(60,2) precision is greater than max redshift precision: 38",
+		},
+		{
+			"bigquery scale",
+			&DestinationConfig{Type: "bigquery"},
+			&schema.DecimalsConfig{Precision: 38, Scale: 12},
+			nil,
+			"data_layout.decimals: decimal(38,12) doesn't fit bigquery NUMERIC type: scale must be <= 9 and precision - scale <= 29",
+		},
+		{
+			"bigquery default",
+			&DestinationConfig{Type: "bigquery"},
+			&schema.DecimalsConfig{Detect: true},
+			map[string]string{"/total": "decimal(18,2)"},
+			"",
+		},
+		{
+			"clickhouse wide decimal",
+			&DestinationConfig{Type: "clickhouse"},
+			nil,
+			map[string]string{"/total": "decimal(76,10)"},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDecimals(tt.destination, tt.decimals, tt.types)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
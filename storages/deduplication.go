@@ -0,0 +1,105 @@
+package storages
+
+import (
+	"errors"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/eventid"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/schema"
+	"sync"
+)
+
+//DeduplicationConfig dto for deserialized destination deduplication config
+//upsert: all tables without own data_layout.upsert item are created as upsert ones keyed on event id column
+//(ReplacingMergeTree in ClickHouse, ON CONFLICT in Postgres, MERGE in BigQuery and MSSQL) so duplicates replace each other
+//cache_size: recent event ids which are kept in memory. Duplicates of them are skipped before enqueueing (stream mode only)
+type DeduplicationConfig struct {
+	Upsert    bool `mapstructure:"upsert"`
+	CacheSize int  `mapstructure:"cache_size"`
+}
+
+//Validate DeduplicationConfig values
+func (dc *DeduplicationConfig) Validate() error {
+	if dc.CacheSize < 0 {
+		return errors.New("deduplication.cache_size can't be negative")
+	}
+	if !dc.Upsert && dc.CacheSize == 0 {
+		return errors.New("deduplication requires upsert: true or cache_size")
+	}
+
+	return nil
+}
+
+//return data_layout.upsert config with all tables item keyed on event id column if deduplication.upsert is configured
+func deduplicationUpsert(destination *DestinationConfig, upsert []*schema.UpsertConfig, systemColumns map[string]string) ([]*schema.UpsertConfig, error) {
+	if destination.Deduplication == nil || !destination.Deduplication.Upsert {
+		return upsert, nil
+	}
+
+	columns, err := schema.NewSystemColumns(systemColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	result := append([]*schema.UpsertConfig{}, upsert...)
+	return append(result, &schema.UpsertConfig{Table: schema.AllTables, Keys: []string{columns.Name(schema.EventIDColumn)}}), nil
+}
+
+//RecentIDs is a fixed size set of the latest event ids: the oldest id is evicted when a new one is added
+type RecentIDs struct {
+	mutex sync.Mutex
+	ids   map[string]bool
+	ring  []string
+	next  int
+}
+
+//NewRecentIDs return RecentIDs with size capacity
+func NewRecentIDs(size int) *RecentIDs {
+	return &RecentIDs{ids: make(map[string]bool, size), ring: make([]string, size)}
+}
+
+//Add id and return false if it has been added recently
+func (ri *RecentIDs) Add(id string) bool {
+	ri.mutex.Lock()
+	defer ri.mutex.Unlock()
+
+	if ri.ids[id] {
+		return false
+	}
+
+	if evicted := ri.ring[ri.next]; evicted != "" {
+		delete(ri.ids, evicted)
+	}
+	ri.ring[ri.next] = id
+	ri.next = (ri.next + 1) % len(ri.ring)
+	ri.ids[id] = true
+
+	return true
+}
+
+//DedupConsumer skips events with recently consumed ids (e.g. client re-sends) and passes others into the stream destination
+//events without id are passed as is. Retries and dead-letter replays don't pass through it
+type DedupConsumer struct {
+	events.Consumer
+	name      string
+	recentIDs *RecentIDs
+}
+
+//return consumer as is if deduplication cache isn't configured
+func newDedupConsumer(name string, consumer events.Consumer, config *DeduplicationConfig) events.Consumer {
+	if config == nil || config.CacheSize == 0 {
+		return consumer
+	}
+
+	return &DedupConsumer{Consumer: consumer, name: name, recentIDs: NewRecentIDs(config.CacheSize)}
+}
+
+//Consume events.Fact if its id hasn't been consumed recently
+func (dc *DedupConsumer) Consume(fact events.Fact) {
+	if id := eventid.Get(fact); id != "" && !dc.recentIDs.Add(id) {
+		counters.SkippedEvents(dc.name, 1)
+		return
+	}
+
+	dc.Consumer.Consume(fact)
+}
@@ -0,0 +1,77 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRecentIDs(t *testing.T) {
+	recentIDs := NewRecentIDs(2)
+	require.True(t, recentIDs.Add("e1"))
+	require.True(t, recentIDs.Add("e2"))
+	require.False(t, recentIDs.Add("e1"))
+
+	//e1 is evicted by e3
+	require.True(t, recentIDs.Add("e3"))
+	require.True(t, recentIDs.Add("e1"))
+	require.False(t, recentIDs.Add("e3"))
+}
+
+func TestDedupConsumer(t *testing.T) {
+	mock := &consumerMock{}
+	require.Equal(t, mock, newDedupConsumer("dedup_test", mock, &DeduplicationConfig{Upsert: true}))
+
+	consumer := newDedupConsumer("dedup_test", mock, &DeduplicationConfig{CacheSize: 10})
+	first := events.Fact{"eventn_ctx": map[string]interface{}{"event_id": "e1"}}
+	consumer.Consume(first)
+	consumer.Consume(events.Fact{"eventn_ctx": map[string]interface{}{"event_id": "e1"}})
+	consumer.Consume(events.Fact{"event_type": "without_id"})
+	consumer.Consume(events.Fact{"event_type": "without_id"})
+
+	require.Equal(t, []events.Fact{first, {"event_type": "without_id"}, {"event_type": "without_id"}}, mock.consumed)
+	require.Equal(t, uint64(1), counters.GetSnapshot().Destinations["dedup_test"].Skipped)
+}
+
+func TestValidateDeduplication(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination *DestinationConfig
+		expectedErr string
+	}{
+		{"not configured", &DestinationConfig{Type: "s3", Mode: batchMode}, ""},
+		{"empty", &DestinationConfig{Type: "clickhouse", Mode: streamMode, Deduplication: &DeduplicationConfig{}}, "deduplication requires upsert: true or cache_size"},
+		{"cache in batch mode", &DestinationConfig{Type: "s3", Mode: batchMode, Deduplication: &DeduplicationConfig{CacheSize: 100}}, "deduplication.cache_size is supported only in stream mode"},
+		{"cache", &DestinationConfig{Type: "s3", Mode: streamMode, Deduplication: &DeduplicationConfig{CacheSize: 100}}, ""},
+		{"unsupported upsert", &DestinationConfig{Type: "s3", Mode: streamMode, Deduplication: &DeduplicationConfig{Upsert: true}},
+			"deduplication.upsert isn't supported by s3 destination. Supported types: [postgres clickhouse bigquery mssql]"},
+		{"bigquery stream upsert", &DestinationConfig{Type: "bigquery", Mode: streamMode, Deduplication: &DeduplicationConfig{Upsert: true}},
+			"deduplication.upsert isn't supported by bigquery destination in stream mode"},
+		{"upsert", &DestinationConfig{Type: "clickhouse", Mode: batchMode, Deduplication: &DeduplicationConfig{Upsert: true}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDeduplication(tt.destination)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestDeduplicationUpsert(t *testing.T) {
+	identify := &schema.UpsertConfig{Table: "identify", Keys: []string{"user_id"}}
+
+	upsert, err := deduplicationUpsert(&DestinationConfig{}, []*schema.UpsertConfig{identify}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []*schema.UpsertConfig{identify}, upsert)
+
+	upsert, err = deduplicationUpsert(&DestinationConfig{Deduplication: &DeduplicationConfig{Upsert: true}}, []*schema.UpsertConfig{identify},
+		map[string]string{schema.EventIDColumn: "event_id"})
+	require.NoError(t, err)
+	require.Equal(t, []*schema.UpsertConfig{identify, {Table: schema.AllTables, Keys: []string{"event_id"}}}, upsert)
+}
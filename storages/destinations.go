@@ -0,0 +1,240 @@
+package storages
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/ksensehq/eventnative/appconfig"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/webhooks"
+	"io"
+	"log"
+	"sort"
+	"sync"
+)
+
+//Destinations keeps batch storages and stream consumers of initialized destinations per token
+//destinations can be added and removed at runtime (via admin API) without restarting the server
+type Destinations struct {
+	ctx          context.Context
+	logEventPath string
+	//event log file writers per token: they consume events only if the token has batch storages
+	loggers map[string]events.Consumer
+
+	mutex   sync.RWMutex
+	entries map[string]*destinationEntry
+	//built from entries on every change (sorted by destination name)
+	storages  map[string][]events.Storage
+	consumers map[string][]events.Consumer
+}
+
+type destinationEntry struct {
+	tokens   []string
+	storage  events.Storage
+	consumer events.Consumer
+}
+
+func (de *destinationEntry) closer() io.Closer {
+	if de.storage != nil {
+		return de.storage
+	}
+	return de.consumer
+}
+
+//NewDestinations return Destinations without destinations
+func NewDestinations(ctx context.Context, logEventPath string, loggers map[string]events.Consumer) *Destinations {
+	return &Destinations{
+		ctx:          ctx,
+		logEventPath: logEventPath,
+		loggers:      loggers,
+		entries:      map[string]*destinationEntry{},
+		storages:     map[string][]events.Storage{},
+		consumers:    map[string][]events.Consumer{},
+	}
+}
+
+//Consumers return stream consumers of the token with the token event logger if the token has batch storages
+func (d *Destinations) Consumers(token string) []events.Consumer {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return d.consumers[token]
+}
+
+//Storages return batch storages of the token
+func (d *Destinations) Storages(token string) []events.Storage {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return d.storages[token]
+}
+
+//Add validate raw destination config, create the destination and start passing events of its tokens into it
+//the destination with the same name is torn down before
+func (d *Destinations) Add(name string, rawConfig map[string]interface{}) error {
+	if err := ValidateDestination(name, rawConfig); err != nil {
+		return err
+	}
+
+	destination, err := parseDestination(rawConfig)
+	if err != nil {
+		return err
+	}
+
+	//stream mode queue and per destination state can't be shared with the old destination
+	d.Remove(name)
+
+	return d.add(name, &destination)
+}
+
+//Check validate raw destination config, create the destination and close it right away (see doctor command)
+//destinations connect and check access to databases, buckets or topics while creating. The checked destination isn't
+//registered and doesn't receive events. Its files (e.g. stream mode queue) are created in logEventPath
+func Check(ctx context.Context, name, logEventPath string, rawConfig map[string]interface{}) error {
+	if err := ValidateDestination(name, rawConfig); err != nil {
+		return err
+	}
+
+	destination, err := parseDestination(rawConfig)
+	if err != nil {
+		return err
+	}
+
+	storage, consumer, err := newDestination(ctx, name, logEventPath, &destination)
+	if err != nil {
+		return err
+	}
+
+	if storage != nil {
+		return storage.Close()
+	}
+	return consumer.Close()
+}
+
+//create destination and register it (failed destination is registered with error)
+func (d *Destinations) add(name string, destination *DestinationConfig) error {
+	//schema is loaded before the destination is created so the failed one isn't left open
+	schemaGate, err := newJSONSchemaGate(name, d.logEventPath, destination)
+	if err != nil {
+		logError(name, destination, err)
+		return err
+	}
+
+	storage, consumer, err := newDestination(d.ctx, name, d.logEventPath, destination)
+	if err != nil {
+		logError(name, destination, err)
+		return err
+	}
+
+	webhooks.Fire(webhooks.DestinationCreated, map[string]interface{}{"destination": name, "type": destination.Type, "mode": destination.Mode})
+
+	tokens := destination.OnlyTokens
+	if len(tokens) == 0 {
+		log.Printf("Warn: only_tokens wasn't provided. All tokens will be stored in %s %s destination", name, destination.Type)
+		for token := range appconfig.Instance.AuthorizedTokens {
+			tokens = append(tokens, token)
+		}
+	}
+
+	entry := &destinationEntry{tokens: tokens}
+	if storage != nil {
+		entry.storage = newValidatingStorage(storage, schemaGate)
+	}
+	if consumer != nil {
+		entry.consumer = newRoutedConsumer(name, newValidatingConsumer(newDedupConsumer(name, consumer, destination.Deduplication), schemaGate))
+	}
+
+	d.mutex.Lock()
+	d.entries[name] = entry
+	d.rebuild()
+	d.mutex.Unlock()
+
+	status := &DestinationStatus{Name: name, Type: destination.Type, Mode: destination.Mode, Tokens: tokens, Status: DestinationStatusOK}
+	if storage != nil {
+		registerDestination(status, storage)
+	} else {
+		registerDestination(status, consumer)
+	}
+
+	return nil
+}
+
+//Remove stop passing events into the destination and close it
+//return false if the destination wasn't initialized (failed destination status is removed anyway)
+func (d *Destinations) Remove(name string) bool {
+	d.mutex.Lock()
+	entry, ok := d.entries[name]
+	if ok {
+		delete(d.entries, name)
+		d.rebuild()
+	}
+	d.mutex.Unlock()
+
+	unregisterDestination(name)
+	if !ok {
+		return false
+	}
+
+	if err := entry.closer().Close(); err != nil {
+		log.Printf("Error closing removed destination [%s]: %v", name, err)
+	}
+	setFaultInjector(name, nil)
+	setRetryPolicy(name, nil, d.logEventPath)
+	setAdaptiveBatch(name, nil)
+	setWorkers(name, nil)
+	setHealthy(name, true)
+	setProcessor(name, nil)
+
+	log.Printf("Destination [%s] has been removed", name)
+	return true
+}
+
+//Close all destinations
+func (d *Destinations) Close() (multiErr error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for name, entry := range d.entries {
+		if err := entry.closer().Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("[%s] %v", name, err))
+		}
+	}
+
+	return
+}
+
+//build storages and consumers per token from entries. Must be called under the write lock
+func (d *Destinations) rebuild() {
+	var names []string
+	for name := range d.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	storages := map[string][]events.Storage{}
+	//batch destinations names per token
+	storageNames := map[string][]string{}
+	consumers := map[string][]events.Consumer{}
+	for _, name := range names {
+		entry := d.entries[name]
+		for _, token := range entry.tokens {
+			if entry.storage != nil {
+				storages[token] = append(storages[token], entry.storage)
+				storageNames[token] = append(storageNames[token], name)
+			}
+			if entry.consumer != nil {
+				consumers[token] = append(consumers[token], &destinationsConsumer{Consumer: entry.consumer, names: []string{name}})
+			}
+		}
+	}
+
+	//events of tokens with batch storages are written into event log files
+	for token := range storages {
+		if logger, ok := d.loggers[token]; ok {
+			consumers[token] = append(consumers[token], &destinationsConsumer{Consumer: logger, names: storageNames[token]})
+		}
+	}
+
+	d.storages = storages
+	d.consumers = consumers
+}
@@ -0,0 +1,44 @@
+package storages
+
+import (
+	"context"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRuntimeDestinations(t *testing.T) {
+	logger := &consumerMock{}
+	d := NewDestinations(context.Background(), "", map[string]events.Consumer{"token1": logger})
+	defer d.Close()
+
+	//dry run destinations don't connect to anything
+	require.NoError(t, d.Add("runtime_stream", map[string]interface{}{"type": "s3", "mode": "stream", "dry_run": true, "only_tokens": []string{"token1"}}))
+	require.Len(t, d.Consumers("token1"), 1)
+	require.Empty(t, d.Storages("token1"))
+
+	status, ok := GetDestinationStatus("runtime_stream")
+	require.True(t, ok)
+	require.Equal(t, DestinationStatusOK, status.Status)
+
+	//event logger of the token consumes events after the first batch storage has been added
+	require.NoError(t, d.Add("runtime_batch", map[string]interface{}{"type": "s3", "dry_run": true, "only_tokens": []string{"token1"}}))
+	require.Len(t, d.Storages("token1"), 1)
+	consumers := d.Consumers("token1")
+	require.Len(t, consumers, 2)
+	require.Equal(t, logger, consumers[1].(*destinationsConsumer).Consumer)
+	require.Equal(t, []string{"runtime_batch"}, consumers[1].(events.DestinationsConsumer).Destinations(events.Fact{}))
+
+	require.Error(t, d.Add("runtime_unknown", map[string]interface{}{"type": "unknown"}))
+	require.Len(t, d.Consumers("token1"), 2)
+
+	require.True(t, d.Remove("runtime_batch"))
+	require.Empty(t, d.Storages("token1"))
+	require.Len(t, d.Consumers("token1"), 1)
+	_, ok = GetDestinationStatus("runtime_batch")
+	require.False(t, ok)
+
+	require.True(t, d.Remove("runtime_stream"))
+	require.False(t, d.Remove("runtime_stream"))
+	require.Empty(t, d.Consumers("token1"))
+}
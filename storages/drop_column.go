@@ -0,0 +1,105 @@
+package storages
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"sync"
+	"time"
+)
+
+//confirmation tokens of dropping columns and modifying TTL expire after
+const confirmationTokenTTL = 5 * time.Minute
+
+//ColumnDropper is implemented by SQL destinations which tables columns can be dropped explicitly with admin API
+type ColumnDropper interface {
+	DropColumnDDL(tableName, columnName string) ([]string, error)
+	DropColumn(tableName, columnName string) error
+}
+
+//ColumnDrop is a planned (with confirmation token) or executed (dropped is true) drop of the destination table column
+//confirmation token is returned by PlanDropColumn and is required by DropColumn
+type ColumnDrop struct {
+	Destination       string     `json:"destination"`
+	Table             string     `json:"table"`
+	Column            string     `json:"column"`
+	Statements        []string   `json:"statements"`
+	ConfirmationToken string     `json:"confirmation_token,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	Dropped           bool       `json:"dropped"`
+}
+
+var (
+	ErrInvalidConfirmationToken = errors.New("Confirmation token is invalid or expired. Plan the change again")
+
+	//confirmation token: planned drop
+	plannedDrops      = map[string]*ColumnDrop{}
+	plannedDropsMutex sync.Mutex
+)
+
+//PlanDropColumn return statements which drop the column of the destination table and one-time confirmation token
+//which expires after confirmationTokenTTL. Nothing is changed in the destination
+//return false if the destination isn't running or its columns can't be dropped
+func PlanDropColumn(destinationName, tableName, columnName string) (*ColumnDrop, bool, error) {
+	dropper, ok := getColumnDropper(destinationName)
+	if !ok {
+		return nil, false, nil
+	}
+
+	statements, err := dropper.DropColumnDDL(tableName, columnName)
+	if err != nil {
+		return nil, true, err
+	}
+
+	expiresAt := time.Now().UTC().Add(confirmationTokenTTL)
+	drop := &ColumnDrop{Destination: destinationName, Table: tableName, Column: columnName, Statements: statements,
+		ConfirmationToken: uuid.New().String(), ExpiresAt: &expiresAt}
+
+	plannedDropsMutex.Lock()
+	for token, planned := range plannedDrops {
+		if time.Now().After(*planned.ExpiresAt) {
+			delete(plannedDrops, token)
+		}
+	}
+	plannedDrops[drop.ConfirmationToken] = drop
+	plannedDropsMutex.Unlock()
+
+	return drop, true, nil
+}
+
+//DropColumn drop the column of the destination table if confirmation token of the same planned drop is provided
+//the token is used once: it is invalidated even if dropping fails
+//return false if the destination isn't running or its columns can't be dropped
+func DropColumn(destinationName, tableName, columnName, confirmationToken string) (*ColumnDrop, bool, error) {
+	dropper, ok := getColumnDropper(destinationName)
+	if !ok {
+		return nil, false, nil
+	}
+
+	plannedDropsMutex.Lock()
+	planned, ok := plannedDrops[confirmationToken]
+	delete(plannedDrops, confirmationToken)
+	plannedDropsMutex.Unlock()
+
+	if !ok || time.Now().After(*planned.ExpiresAt) || planned.Destination != destinationName || planned.Table != tableName ||
+		planned.Column != columnName {
+		return nil, true, ErrInvalidConfirmationToken
+	}
+
+	if err := dropper.DropColumn(tableName, columnName); err != nil {
+		return nil, true, err
+	}
+
+	return &ColumnDrop{Destination: destinationName, Table: tableName, Column: columnName, Statements: planned.Statements, Dropped: true}, true, nil
+}
+
+func getColumnDropper(destinationName string) (ColumnDropper, bool) {
+	registry.mutex.RLock()
+	destination, ok := registry.destinations[destinationName]
+	registry.mutex.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	dropper, ok := destination.(ColumnDropper)
+	return dropper, ok
+}
@@ -0,0 +1,45 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDropColumn(t *testing.T) {
+	manager := &tableManagerMock{tables: map[string]*schema.Table{
+		"events": {Name: "events", Columns: schema.Columns{"id": schema.NewColumn(typing.INT64), "old_field": schema.NewColumn(typing.STRING)}},
+	}}
+	//destinations drop columns with TableHelper
+	registerDestination(&DestinationStatus{Name: "pg"}, NewTableHelper(manager, NewMonitorKeeper(), "postgres", nil, nil, nil, nil, nil, nil))
+	defer unregisterDestination("pg")
+
+	_, ok, _ := PlanDropColumn("unknown", "events", "old_field")
+	require.False(t, ok)
+
+	planned, ok, err := PlanDropColumn("pg", "events", "old_field")
+	require.True(t, ok)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ALTER TABLE events DROP COLUMN old_field"}, planned.Statements)
+	require.NotEmpty(t, planned.ConfirmationToken)
+	require.False(t, planned.Dropped)
+	require.Empty(t, manager.dropped)
+
+	//the token is bound to the planned column
+	_, _, err = DropColumn("pg", "events", "id", planned.ConfirmationToken)
+	require.Equal(t, ErrInvalidConfirmationToken, err)
+	//and is used once
+	_, _, err = DropColumn("pg", "events", "old_field", planned.ConfirmationToken)
+	require.Equal(t, ErrInvalidConfirmationToken, err)
+	require.Empty(t, manager.dropped)
+
+	planned, _, err = PlanDropColumn("pg", "events", "old_field")
+	require.NoError(t, err)
+	dropped, ok, err := DropColumn("pg", "events", "old_field", planned.ConfirmationToken)
+	require.True(t, ok)
+	require.NoError(t, err)
+	require.True(t, dropped.Dropped)
+	require.Empty(t, dropped.ConfirmationToken)
+	require.Equal(t, []string{"events.old_field"}, manager.dropped)
+}
@@ -0,0 +1,174 @@
+package storages
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/timestamp"
+	"log"
+)
+
+var errDruidNoTimestamp = errors.New("Object doesn't have timestamp value")
+
+//Ingest events into Apache Druid datasources (table = datasource) in two modes:
+//batch: (1 file = 1 ingestion task per table)
+//stream: via events queue and FileBatcher (objects are accumulated and ingested with one task per table every flush_every)
+//batch is considered stored only when ingestion task succeeds
+type Druid struct {
+	name            string
+	druidAdapter    *adapters.Druid
+	schemaProcessor *schema.Processor
+	eventQueue      *events.PersistentQueue
+	batcher         *FileBatcher
+	breakOnError    bool
+}
+
+//NewDruid return Druid and start goroutine for stream consumer if destination is in stream mode
+func NewDruid(name, fallbackDir string, config *adapters.DruidConfig, processor *schema.Processor, breakOnError, streamMode bool) (*Druid, error) {
+	druidAdapter, err := adapters.NewDruid(config)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Druid{
+		name:            name,
+		druidAdapter:    druidAdapter,
+		schemaProcessor: processor,
+		breakOnError:    breakOnError,
+	}
+
+	if streamMode {
+		d.eventQueue, err = newEventQueue(name, fallbackDir)
+		if err != nil {
+			druidAdapter.Close()
+			return nil, err
+		}
+
+		d.batcher = NewFileBatcher(name, &FilesConfig{UploadEvery: config.FlushEvery, MaxObjects: config.MaxRows}, d.ingest)
+		d.startStreamingConsumer()
+	}
+
+	return d, nil
+}
+
+//Consume events.Fact and enqueue it
+func (d *Druid) Consume(fact events.Fact) {
+	if err := d.eventQueue.Enqueue(fact); err != nil {
+		logSkippedEvent(d.name, fact, err)
+	}
+}
+
+//QueueSize return count of events in the stream mode queue
+func (d *Druid) QueueSize() int {
+	return d.eventQueue.Size()
+}
+
+//Run goroutine to:
+//1. read from queue
+//2. put processed object into batcher
+func (d *Druid) startStreamingConsumer() {
+	go func() {
+		for {
+			if appstatus.Instance.Idle {
+				break
+			}
+			fact, err := d.eventQueue.DequeueBlock()
+			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
+				log.Println("Error reading event fact from druid queue", err)
+				continue
+			}
+
+			dataSchema, flattenObject, err := d.schemaProcessor.ProcessFact(fact)
+			if err != nil {
+				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(d.name, 1)
+				continue
+			}
+
+			//don't process empty object
+			if !dataSchema.Exists() {
+				continue
+			}
+
+			d.batcher.Add(dataSchema, flattenObject)
+		}
+	}()
+}
+
+//Store file payload to Druid with processing
+func (d *Druid) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(d.name); err != nil {
+		return err
+	}
+
+	flatData, err := d.schemaProcessor.ProcessFilePayload(fileName, payload, d.breakOnError, report)
+	if err != nil {
+		return err
+	}
+
+	for _, fdata := range flatData {
+		if err := d.ingest(fdata); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//ingest all objects which have timestamp value with one ingestion task
+//objects without timestamp (Druid __time column source) are skipped
+func (d *Druid) ingest(fdata *schema.ProcessedFile) error {
+	timestampColumn := d.schemaProcessor.SystemColumn(timestamp.Key)
+
+	var objects []map[string]interface{}
+	for _, object := range fdata.GetPayload() {
+		if value, ok := object[timestampColumn]; !ok || value == nil {
+			if d.breakOnError {
+				return errDruidNoTimestamp
+			}
+			log.Printf("Warn: object %v doesn't have %s value. It will be skipped", object, timestampColumn)
+			fdata.Report.Skip(reports.ConversionReason, errDruidNoTimestamp)
+			continue
+		}
+		objects = append(objects, object)
+	}
+
+	return d.druidAdapter.Ingest(fdata.DataSchema, timestampColumn, objects)
+}
+
+func (d *Druid) Name() string {
+	return d.name
+}
+
+func (d *Druid) Type() string {
+	return d.druidAdapter.Name()
+}
+
+func (d *Druid) Close() (multiErr error) {
+	if d.batcher != nil {
+		if err := d.batcher.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing druid batcher: %v", err))
+		}
+	}
+
+	if d.eventQueue != nil {
+		if err := d.eventQueue.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing druid event queue: %v", err))
+		}
+	}
+
+	if err := d.druidAdapter.Close(); err != nil {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing druid client: %v", err))
+	}
+
+	return
+}
@@ -0,0 +1,210 @@
+package storages
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//DDLInspector reads tables schemas from the destination and return DDL statements without executing them
+type DDLInspector interface {
+	io.Closer
+	adapters.DDLProvider
+	GetTableSchema(tableName string) (*schema.Table, error)
+}
+
+//DryRun is used instead of the destination if dry_run is true: events are processed with the destination data layout
+//and diffed with tables schemas but intended DDL statements and inserts are logged instead of executing
+//inspector is nil if destination type doesn't provide DDL statements: tables are described with columns types
+//and diffed with schemas which are built from previous events since the start
+type DryRun struct {
+	name            string
+	destinationType string
+	processor       *schema.Processor
+	inspector       DDLInspector
+
+	mutex  sync.Mutex
+	tables map[string]*schema.Table
+}
+
+//NewDryRun return DryRun. inspector is optional
+func NewDryRun(name, destinationType string, processor *schema.Processor, inspector DDLInspector) *DryRun {
+	return &DryRun{
+		name:            name,
+		destinationType: destinationType,
+		processor:       processor,
+		inspector:       inspector,
+		tables:          map[string]*schema.Table{},
+	}
+}
+
+//Consume process the event and log intended DDL and insert
+func (dr *DryRun) Consume(fact events.Fact) {
+	dataSchema, flattenObject, err := dr.processor.ProcessFact(fact)
+	if err != nil {
+		log.Printf("[%s] dry run: unable to process object %v: %v", dr.name, fact, err)
+		counters.ErrorEvents(dr.name, 1)
+		return
+	}
+	//filtered
+	if dataSchema == nil {
+		return
+	}
+
+	if err := dr.ensureTable(dataSchema); err != nil {
+		log.Printf("[%s] dry run: %v", dr.name, err)
+		counters.ErrorEvents(dr.name, 1)
+		return
+	}
+
+	log.Printf("[%s] dry run: INSERT into %s: %v", dr.name, dataSchema.Name, flattenObject)
+}
+
+//Store process file payload and log intended DDL and inserts
+func (dr *DryRun) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	flatData, err := dr.processor.ProcessFilePayload(fileName, payload, false, report)
+	if err != nil {
+		return err
+	}
+
+	for _, fdata := range flatData {
+		if err := dr.ensureTable(fdata.DataSchema); err != nil {
+			return err
+		}
+
+		action := "INSERT"
+		if len(fdata.DataSchema.DeletionKeys) > 0 {
+			action = "DELETE by keys"
+		}
+		log.Printf("[%s] dry run: %s %d rows into %s from %s", dr.name, action, fdata.Size(), fdata.DataSchema.Name, fileName)
+	}
+
+	return nil
+}
+
+//log statements which create the table or add missing columns and keep the table schema as if they were executed
+func (dr *DryRun) ensureTable(dataSchema *schema.Table) error {
+	//deletions don't change tables
+	if len(dataSchema.DeletionKeys) > 0 {
+		return nil
+	}
+
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+
+	dbSchema, ok := dr.tables[dataSchema.Name]
+	if !ok {
+		dbSchema = &schema.Table{Name: dataSchema.Name, Columns: schema.Columns{}}
+		if dr.inspector != nil {
+			var err error
+			dbSchema, err = dr.inspector.GetTableSchema(dataSchema.Name)
+			if err != nil {
+				return fmt.Errorf("Error getting table %s schema: %v", dataSchema.Name, err)
+			}
+		}
+		dr.tables[dataSchema.Name] = dbSchema
+	}
+
+	if !dbSchema.Exists() {
+		dr.logDDL(dataSchema, true)
+		addColumns(dbSchema, dataSchema.Columns)
+		return nil
+	}
+
+	diff, err := dbSchema.Diff(dataSchema)
+	if err != nil {
+		return fmt.Errorf("Error diffing table %s schema: %v", dataSchema.Name, err)
+	}
+	if diff.Exists() {
+		dr.logDDL(diff, false)
+		addColumns(dbSchema, diff.Columns)
+	}
+
+	return nil
+}
+
+func (dr *DryRun) logDDL(table *schema.Table, create bool) {
+	var statements []string
+	switch {
+	case dr.inspector != nil && create:
+		statements = dr.inspector.CreateTableDDL(table)
+	case dr.inspector != nil:
+		statements = dr.inspector.PatchTableDDL(table)
+	default:
+		action := "add columns to"
+		if create {
+			action = "create"
+		}
+		statements = []string{fmt.Sprintf("%s table %s: %s", action, table.Name, describeColumns(table.Columns))}
+	}
+
+	log.Printf("[%s] dry run: DDL %s", dr.name, strings.Join(statements, "; "))
+}
+
+//Tables return tables schemas as if intended DDL statements were executed
+func (dr *DryRun) Tables() []*schema.Table {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+
+	tables := []*schema.Table{}
+	for _, table := range dr.tables {
+		columns := schema.Columns{}
+		for name, column := range table.Columns {
+			columns[name] = column
+		}
+		tables = append(tables, &schema.Table{Name: table.Name, Columns: columns})
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+
+	return tables
+}
+
+func (dr *DryRun) Name() string {
+	return dr.name
+}
+
+func (dr *DryRun) Type() string {
+	return dr.destinationType
+}
+
+//Close DDLInspector if it is provided
+func (dr *DryRun) Close() error {
+	if dr.inspector != nil {
+		if err := dr.inspector.Close(); err != nil {
+			return fmt.Errorf("[%s] Error closing dry run datasource: %v", dr.name, err)
+		}
+	}
+
+	return nil
+}
+
+//put columns with resulting types into the table (type occurrences aren't shared with processed data)
+func addColumns(table *schema.Table, columns schema.Columns) {
+	for name, column := range columns {
+		table.Columns[name] = schema.NewColumn(column.GetType())
+	}
+}
+
+//return sorted column_name type pairs
+func describeColumns(columns schema.Columns) string {
+	var names []string
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var described []string
+	for _, name := range names {
+		described = append(described, name+" "+columns[name].GetType().String())
+	}
+
+	return strings.Join(described, ", ")
+}
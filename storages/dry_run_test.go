@@ -0,0 +1,99 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"sort"
+	"testing"
+)
+
+type inspectorMock struct {
+	tables  map[string]*schema.Table
+	created []string
+	patched []string
+}
+
+func (im *inspectorMock) GetTableSchema(tableName string) (*schema.Table, error) {
+	if table, ok := im.tables[tableName]; ok {
+		return table, nil
+	}
+	return &schema.Table{Name: tableName, Columns: schema.Columns{}}, nil
+}
+
+func (im *inspectorMock) CreateTableDDL(tableSchema *schema.Table) []string {
+	im.created = append(im.created, tableSchema.Name+":"+columnNames(tableSchema))
+	return []string{"CREATE TABLE " + tableSchema.Name}
+}
+
+func (im *inspectorMock) PatchTableDDL(patchSchema *schema.Table) []string {
+	im.patched = append(im.patched, patchSchema.Name+":"+columnNames(patchSchema))
+	return []string{"ALTER TABLE " + patchSchema.Name}
+}
+
+func (im *inspectorMock) Close() error {
+	return nil
+}
+
+func columnNames(table *schema.Table) string {
+	var names []string
+	for name := range table.Columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := ""
+	for i, name := range names {
+		if i > 0 {
+			result += ","
+		}
+		result += name
+	}
+	return result
+}
+
+func TestDryRunStore(t *testing.T) {
+	processor, err := schema.NewProcessor(&schema.ProcessorConfig{TableNameTemplate: "{{.event_type}}"})
+	require.NoError(t, err)
+
+	inspector := &inspectorMock{tables: map[string]*schema.Table{
+		"pageview": {Name: "pageview", Columns: schema.Columns{"_timestamp": schema.NewColumn(typing.TIMESTAMP), "event_type": schema.NewColumn(typing.STRING)}},
+	}}
+	dryRun := NewDryRun("test", "postgres", processor, inspector)
+
+	payload := []byte(`{"_timestamp":"2020-08-02T18:23:59.757719Z","event_type":"pageview","url":"https://a.b"}
+{"_timestamp":"2020-08-02T18:23:59.757719Z","event_type":"click","x":1}
+`)
+	report := reports.NewLoadReport("file1", "test", "token", 2)
+	require.NoError(t, dryRun.Store("file1", payload, report))
+
+	require.Equal(t, []string{"click:_timestamp,event_type,x"}, inspector.created)
+	require.Equal(t, []string{"pageview:url"}, inspector.patched)
+
+	//statements aren't logged twice: tables are kept as if they were executed
+	require.NoError(t, dryRun.Store("file2", payload, reports.NewLoadReport("file2", "test", "token", 2)))
+	require.Len(t, inspector.created, 1)
+	require.Len(t, inspector.patched, 1)
+
+	tables := dryRun.Tables()
+	require.Len(t, tables, 2)
+	require.Equal(t, "click", tables[0].Name)
+	require.Equal(t, "pageview", tables[1].Name)
+	require.Equal(t, "_timestamp,event_type,url", columnNames(tables[1]))
+}
+
+func TestDryRunConsumeWithoutInspector(t *testing.T) {
+	processor, err := schema.NewProcessor(&schema.ProcessorConfig{TableNameTemplate: "{{.event_type}}"})
+	require.NoError(t, err)
+
+	dryRun := NewDryRun("test", "s3", processor, nil)
+	dryRun.Consume(events.Fact{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "pageview"})
+	dryRun.Consume(events.Fact{"_timestamp": "2020-08-02T18:23:59.757719Z", "event_type": "pageview", "url": "https://a.b"})
+
+	tables := dryRun.Tables()
+	require.Len(t, tables, 1)
+	require.Equal(t, "_timestamp,event_type,url", columnNames(tables[0]))
+	require.Equal(t, typing.STRING, tables[0].Columns["url"].GetType())
+}
@@ -0,0 +1,210 @@
+package storages
+
+import (
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/ksensehq/eventnative/typing"
+	"log"
+	"time"
+)
+
+const elasticsearchStorageType = "Elasticsearch"
+
+//Index events into Elasticsearch (OpenSearch) daily indices <table>-<date> in two modes:
+//batch: (1 file = bulk requests with all file objects)
+//stream: via events queue and FileBatcher (objects are accumulated and indexed every flush_every or by bulk_size objects)
+//index templates are managed by TableHelper (template = table)
+type Elasticsearch struct {
+	name            string
+	esAdapter       *adapters.Elasticsearch
+	tableHelper     *TableHelper
+	idField         string
+	bulkSize        int
+	schemaProcessor *schema.Processor
+	eventQueue      *events.PersistentQueue
+	batcher         *FileBatcher
+	breakOnError    bool
+}
+
+//NewElasticsearch return Elasticsearch and start goroutine for stream consumer if destination is in stream mode
+func NewElasticsearch(name, fallbackDir string, config *adapters.ElasticsearchConfig, processor *schema.Processor, breakOnError, streamMode bool) (*Elasticsearch, error) {
+	esAdapter, err := adapters.NewElasticsearch(config)
+	if err != nil {
+		return nil, err
+	}
+
+	es := &Elasticsearch{
+		name:            name,
+		esAdapter:       esAdapter,
+		tableHelper:     NewTableHelper(esAdapter, NewMonitorKeeper(), elasticsearchStorageType, nil, nil, processor.SchemaEvolution(), processor.ColumnsLimit(), processor.TablesCache(), processor.MetadataCache()),
+		idField:         config.IDField,
+		bulkSize:        config.BulkSize,
+		schemaProcessor: processor,
+		breakOnError:    breakOnError,
+	}
+
+	if streamMode {
+		es.eventQueue, err = newEventQueue(name, fallbackDir)
+		if err != nil {
+			esAdapter.Close()
+			return nil, err
+		}
+
+		es.batcher = NewFileBatcher(name, &FilesConfig{UploadEvery: config.FlushEvery, MaxObjects: config.BulkSize}, es.index)
+		es.startStreamingConsumer()
+	}
+
+	return es, nil
+}
+
+//Consume events.Fact and enqueue it
+func (es *Elasticsearch) Consume(fact events.Fact) {
+	if err := es.eventQueue.Enqueue(fact); err != nil {
+		logSkippedEvent(es.name, fact, err)
+	}
+}
+
+//QueueSize return count of events in the stream mode queue
+func (es *Elasticsearch) QueueSize() int {
+	return es.eventQueue.Size()
+}
+
+//Run goroutine to:
+//1. read from queue
+//2. put processed object into batcher
+func (es *Elasticsearch) startStreamingConsumer() {
+	go func() {
+		for {
+			if appstatus.Instance.Idle {
+				break
+			}
+			fact, err := es.eventQueue.DequeueBlock()
+			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
+				log.Println("Error reading event fact from elasticsearch queue", err)
+				continue
+			}
+
+			dataSchema, flattenObject, err := es.schemaProcessor.ProcessFact(fact)
+			if err != nil {
+				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(es.name, 1)
+				continue
+			}
+
+			//don't process empty object
+			if !dataSchema.Exists() {
+				continue
+			}
+
+			es.batcher.Add(dataSchema, flattenObject)
+		}
+	}()
+}
+
+//Store file payload to Elasticsearch with processing
+func (es *Elasticsearch) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(es.name); err != nil {
+		return err
+	}
+
+	flatData, err := es.schemaProcessor.ProcessFilePayload(fileName, payload, es.breakOnError, report)
+	if err != nil {
+		return err
+	}
+
+	for _, fdata := range flatData {
+		if err := es.index(fdata); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//ensure index template, apply its types and index all objects with bulk requests by bulkSize documents
+func (es *Elasticsearch) index(fdata *schema.ProcessedFile) error {
+	dbSchema, err := es.tableHelper.EnsureTable(fdata.DataSchema, fdata.GetPayload()...)
+	if err != nil {
+		return err
+	}
+
+	if err := es.tableHelper.ApplyDBTyping(es.schemaProcessor, dbSchema, fdata); err != nil {
+		return err
+	}
+
+	var documents []*adapters.ElasticsearchDocument
+	for _, object := range fdata.GetPayload() {
+		documents = append(documents, es.toDocument(fdata.DataSchema.Name, object))
+
+		if len(documents) == es.bulkSize {
+			if err := es.esAdapter.Bulk(documents); err != nil {
+				return err
+			}
+			documents = nil
+		}
+	}
+
+	return es.esAdapter.Bulk(documents)
+}
+
+//return document with daily index (by object timestamp or current time) and id from id field (if exists)
+func (es *Elasticsearch) toDocument(tableName string, object map[string]interface{}) *adapters.ElasticsearchDocument {
+	t := time.Now().UTC()
+	if value, ok := object[timestamp.Key]; ok && value != nil {
+		if converted, err := typing.Convert(typing.TIMESTAMP, value); err == nil {
+			t = converted.(time.Time)
+		}
+	}
+
+	var id string
+	if es.idField != "" {
+		if value, ok := object[es.idField]; ok && value != nil {
+			id = fmt.Sprint(value)
+		}
+	}
+
+	return &adapters.ElasticsearchDocument{Index: es.esAdapter.IndexName(tableName, t), ID: id, Source: object}
+}
+
+//Tables return index templates schemas known by the destination
+func (es *Elasticsearch) Tables() []*schema.Table {
+	return es.tableHelper.Tables()
+}
+
+func (es *Elasticsearch) Name() string {
+	return es.name
+}
+
+func (es *Elasticsearch) Type() string {
+	return elasticsearchStorageType
+}
+
+func (es *Elasticsearch) Close() (multiErr error) {
+	if es.batcher != nil {
+		if err := es.batcher.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing elasticsearch batcher: %v", err))
+		}
+	}
+
+	if es.eventQueue != nil {
+		if err := es.eventQueue.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing elasticsearch event queue: %v", err))
+		}
+	}
+
+	if err := es.esAdapter.Close(); err != nil {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing elasticsearch client: %v", err))
+	}
+
+	return
+}
@@ -0,0 +1,19 @@
+package storages
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/appconfig"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/stateless"
+)
+
+//return stream mode queue of the destination:
+//local disk queue of this server or external queue shared by all servers in stateless mode
+func newEventQueue(destinationName, fallbackDir string) (*events.PersistentQueue, error) {
+	if stateless.Enabled() {
+		return stateless.Instance.NewQueue(destinationName)
+	}
+
+	queueName := fmt.Sprintf("%s-%s", appconfig.Instance.ServerName, destinationName)
+	return events.NewPersistentQueue(queueName, fallbackDir)
+}
@@ -5,9 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"github.com/ksensehq/eventnative/adapters"
-	"github.com/ksensehq/eventnative/appconfig"
 	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/performance"
+	"github.com/ksensehq/eventnative/routing"
 	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/stateless"
+	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/ksensehq/eventnative/webhooks"
 	"github.com/spf13/viper"
 	"log"
 )
@@ -15,144 +19,909 @@ import (
 const (
 	defaultTableName = "events"
 
+	defaultElasticsearchIDField = "eventn_ctx_event_id"
+
 	batchMode  = "batch"
 	streamMode = "stream"
 )
 
 type DestinationConfig struct {
-	OnlyTokens   []string    `mapstructure:"only_tokens"`
-	Type         string      `mapstructure:"type"`
-	Mode         string      `mapstructure:"mode"`
-	DataLayout   *DataLayout `mapstructure:"data_layout"`
-	BreakOnError bool        `mapstructure:"break_on_error"`
+	OnlyTokens    []string             `mapstructure:"only_tokens"`
+	Filter        string               `mapstructure:"filter"`
+	Type          string               `mapstructure:"type"`
+	Mode          string               `mapstructure:"mode"`
+	DataLayout    *DataLayout          `mapstructure:"data_layout"`
+	BreakOnError  bool                 `mapstructure:"break_on_error"`
+	DryRun        bool                 `mapstructure:"dry_run"`
+	Retry         *RetryConfig         `mapstructure:"retry"`
+	Deduplication *DeduplicationConfig `mapstructure:"deduplication"`
+	AdaptiveBatch *AdaptiveBatchConfig `mapstructure:"adaptive_batch"`
+	Workers       *WorkersConfig       `mapstructure:"workers"`
+	JSONSchema    string               `mapstructure:"json_schema"`
+
+	DataSource    *adapters.DataSourceConfig    `mapstructure:"datasource"`
+	S3            *adapters.S3Config            `mapstructure:"s3"`
+	Google        *adapters.GoogleConfig        `mapstructure:"google"`
+	ClickHouse    *adapters.ClickHouseConfig    `mapstructure:"clickhouse"`
+	Snowflake     *adapters.SnowflakeConfig     `mapstructure:"snowflake"`
+	Files         *FilesConfig                  `mapstructure:"files"`
+	Glue          *adapters.GlueConfig          `mapstructure:"glue"`
+	Kafka         *adapters.KafkaConfig         `mapstructure:"kafka"`
+	Kinesis       *adapters.KinesisConfig       `mapstructure:"kinesis"`
+	PubSub        *adapters.PubSubConfig        `mapstructure:"pubsub"`
+	Elasticsearch *adapters.ElasticsearchConfig `mapstructure:"elasticsearch"`
+	Webhook       *adapters.WebhookConfig       `mapstructure:"webhook"`
+	Amplitude     *adapters.AmplitudeConfig     `mapstructure:"amplitude"`
+	Mixpanel      *adapters.MixpanelConfig      `mapstructure:"mixpanel"`
+	Parquet       *adapters.ParquetConfig       `mapstructure:"parquet"`
+	NATS          *adapters.NATSConfig          `mapstructure:"nats"`
+	Redis         *adapters.RedisConfig         `mapstructure:"redis"`
+	Druid         *adapters.DruidConfig         `mapstructure:"druid"`
 
-	DataSource *adapters.DataSourceConfig `mapstructure:"datasource"`
-	S3         *adapters.S3Config         `mapstructure:"s3"`
-	Google     *adapters.GoogleConfig     `mapstructure:"google"`
-	ClickHouse *adapters.ClickHouseConfig `mapstructure:"clickhouse"`
+	//for testing purposes only: emulate slow and failing destination
+	FaultInjection *adapters.FaultInjectionConfig `mapstructure:"fault_injection"`
 }
 
 type DataLayout struct {
-	Mapping           []string `mapstructure:"mapping"`
-	TableNameTemplate string   `mapstructure:"table_name_template"`
+	Mapping            []string                          `mapstructure:"mapping"`
+	Mappings           []*schema.MappingConfig           `mapstructure:"mappings"`
+	Types              map[string]string                 `mapstructure:"types"`
+	TableNameTemplate  string                            `mapstructure:"table_name_template"`
+	TimestampBounds    *schema.TimeBoundsConfig          `mapstructure:"timestamp_bounds"`
+	Timestamps         *schema.TimestampsConfig          `mapstructure:"timestamps"`
+	NonASCIIFields     string                            `mapstructure:"non_ascii_fields"`
+	NumericOverflow    string                            `mapstructure:"numeric_overflow"`
+	SchemaEvolution    string                            `mapstructure:"schema_evolution"`
+	Upsert             []*schema.UpsertConfig            `mapstructure:"upsert"`
+	Deletions          []*schema.DeletionsConfig         `mapstructure:"deletions"`
+	ColumnDescriptions []*schema.ColumnDescriptionConfig `mapstructure:"column_descriptions"`
+	SystemColumns      map[string]string                 `mapstructure:"system_columns"`
+	ExistingTables     *schema.ExistingTablesConfig      `mapstructure:"existing_tables"`
+	ReadOnlySchema     *ReadOnlySchemaConfig             `mapstructure:"read_only_schema"`
+	Arrays             bool                              `mapstructure:"arrays"`
+	JSONColumns        []string                          `mapstructure:"json_columns"`
+	MaxColumns         int                               `mapstructure:"max_columns"`
+	Renames            string                            `mapstructure:"renames"`
+	ColumnNames        *schema.ColumnNamesConfig         `mapstructure:"column_names"`
+	Decimals           *schema.DecimalsConfig            `mapstructure:"decimals"`
+	SchemaCache        *schema.TablesCacheConfig         `mapstructure:"schema_cache"`
+	TableNameField     *schema.TableNameFieldConfig      `mapstructure:"table_name_field"`
+	Flattening         *schema.FlatteningConfig          `mapstructure:"flattening"`
+}
+
+var (
+	unknownDestination = errors.New("Unknown destination type")
+
+	destinationTypes = []string{"redshift", "bigquery", "postgres", "clickhouse", "snowflake", "s3", "gcs", "kafka", "kinesis", "pubsub", "elasticsearch", "webhook", "amplitude", "mixpanel", "parquet", "nats", "redis", "druid", "mssql"}
+	//destination types which support data_layout.upsert (bigquery only in batch mode)
+	upsertDestinationTypes = []string{"postgres", "clickhouse", "bigquery", "mssql"}
+	//destination types which support data_layout.deletions delete mode (others support only table mode)
+	deleteDestinationTypes = []string{"postgres", "clickhouse", "kafka", "mssql"}
+	//destination types which support data_layout.system_columns
+	systemColumnsDestinationTypes = []string{"redshift", "bigquery", "postgres", "clickhouse", "snowflake", "mssql"}
+	//destination types which support data_layout.existing_tables
+	existingTablesDestinationTypes = []string{"redshift", "bigquery", "postgres", "clickhouse", "snowflake", "mssql"}
+	//destination types which support data_layout.read_only_schema (adapters implement adapters.DDLProvider)
+	readOnlySchemaDestinationTypes = []string{"redshift", "postgres", "snowflake", "mssql"}
+	//destination types which support data_layout.arrays (arrays of scalars are written into Array(T) columns)
+	arraysDestinationTypes = []string{"clickhouse"}
+	//destination types which support data_layout.json_columns (nested objects are written into JSON typed columns)
+	jsonColumnsDestinationTypes = []string{"postgres", "clickhouse", "snowflake", "bigquery", "mssql"}
+	//destination types which insert stream mode events one by one and support retry
+	retryDestinationTypes = []string{"redshift", "bigquery", "postgres", "clickhouse", "snowflake", "mssql", "kafka", "nats", "redis", "pubsub"}
+)
+
+//ValidateDestination parse raw destination config (e.g. from admin API) and check destination type and mode
+func ValidateDestination(name string, rawConfig map[string]interface{}) error {
+	destination, err := parseDestination(rawConfig)
+	if err != nil {
+		return err
+	}
+
+	if name == routing.Key {
+		return fmt.Errorf("Destination name [%s] is reserved for routing config", routing.Key)
+	}
+
+	if destination.Type == "" {
+		destination.Type = name
+	}
+	knownType := false
+	for _, t := range destinationTypes {
+		if t == destination.Type {
+			knownType = true
+			break
+		}
+	}
+	if !knownType {
+		return fmt.Errorf("%v: %s. Available types: %v", unknownDestination, destination.Type, destinationTypes)
+	}
+
+	if destination.Mode != "" && destination.Mode != batchMode && destination.Mode != streamMode {
+		return fmt.Errorf("Unknown destination mode: %s. Available mode: [%s, %s]", destination.Mode, batchMode, streamMode)
+	}
+
+	if err := validateStateless(&destination); err != nil {
+		return err
+	}
+
+	if err := validateDeduplication(&destination); err != nil {
+		return err
+	}
+
+	if destination.DataLayout != nil {
+		if len(destination.DataLayout.Mapping) > 0 && len(destination.DataLayout.Mappings) > 0 {
+			return errors.New("data_layout.mapping and data_layout.mappings can't be used together")
+		}
+		if _, _, err := schema.NewMappingsMapper(destination.DataLayout.Mappings); err != nil {
+			return err
+		}
+		if _, err := schema.NewTypeOverrides(destination.DataLayout.Types); err != nil {
+			return err
+		}
+		if err := destination.DataLayout.Timestamps.Validate(); err != nil {
+			return err
+		}
+		if err := validateUpsert(&destination, destination.DataLayout.Upsert); err != nil {
+			return err
+		}
+		if _, err := schema.NewUpsertKeys(destination.DataLayout.Upsert); err != nil {
+			return err
+		}
+		if err := validateDeletions(&destination, destination.DataLayout.Deletions); err != nil {
+			return err
+		}
+		if _, err := schema.NewDeletions(destination.DataLayout.Deletions); err != nil {
+			return err
+		}
+		if _, err := schema.NewColumnDescriptions(destination.DataLayout.ColumnDescriptions); err != nil {
+			return err
+		}
+		if err := validateSystemColumns(&destination, destination.DataLayout.SystemColumns); err != nil {
+			return err
+		}
+		if _, err := schema.NewSystemColumns(destination.DataLayout.SystemColumns); err != nil {
+			return err
+		}
+		if err := validateExistingTables(&destination, destination.DataLayout.ExistingTables); err != nil {
+			return err
+		}
+		if _, err := schema.NewExistingTables(destination.DataLayout.ExistingTables); err != nil {
+			return err
+		}
+		if err := validateReadOnlySchema(&destination, destination.DataLayout.ReadOnlySchema, ""); err != nil {
+			return err
+		}
+		if err := validateArrays(&destination, destination.DataLayout.Arrays); err != nil {
+			return err
+		}
+		if err := validateJSONColumns(&destination, destination.DataLayout.JSONColumns); err != nil {
+			return err
+		}
+		if _, err := schema.NewJSONColumns(destination.DataLayout.JSONColumns); err != nil {
+			return err
+		}
+		if err := destination.DataLayout.Flattening.Validate(); err != nil {
+			return err
+		}
+		if destination.DataLayout.TableNameField != nil && destination.DataLayout.TableNameTemplate != "" {
+			return errors.New("data_layout.table_name_template and data_layout.table_name_field can't be used together")
+		}
+		if _, err := schema.NewTableNameField(destination.DataLayout.TableNameField); err != nil {
+			return err
+		}
+		if err := validateMaxColumns(&destination, destination.DataLayout.MaxColumns); err != nil {
+			return err
+		}
+		if _, err := schema.NewRenames(destination.DataLayout.Renames, nil); err != nil {
+			return err
+		}
+		if _, err := destinationColumnNames(&destination, destination.DataLayout.ColumnNames); err != nil {
+			return err
+		}
+		if err := validateDecimals(&destination, destination.DataLayout.Decimals, destination.DataLayout.Types); err != nil {
+			return err
+		}
+		if err := validateSchemaCache(&destination, destination.DataLayout.SchemaCache); err != nil {
+			return err
+		}
+	}
+
+	if _, err := destinationEngineColumns(&destination); err != nil {
+		return err
+	}
+
+	if _, err := schema.NewFilter(destination.Filter); err != nil {
+		return err
+	}
+
+	if err := validateRetry(&destination); err != nil {
+		return err
+	}
+
+	if err := validateAdaptiveBatch(&destination); err != nil {
+		return err
+	}
+
+	if err := validateWorkers(&destination); err != nil {
+		return err
+	}
+
+	if err := validateJSONSchema(&destination); err != nil {
+		return err
+	}
+
+	return nil
 }
 
-var unknownDestination = errors.New("Unknown destination type")
+//parse raw destination config (from admin API) into DestinationConfig
+func parseDestination(rawConfig map[string]interface{}) (DestinationConfig, error) {
+	v := viper.New()
+	if err := v.MergeConfigMap(rawConfig); err != nil {
+		return DestinationConfig{}, fmt.Errorf("Error reading destination config: %v", err)
+	}
+
+	destination := DestinationConfig{}
+	if err := v.Unmarshal(&destination); err != nil {
+		return DestinationConfig{}, fmt.Errorf("Error parsing destination config: %v", err)
+	}
+
+	return destination, nil
+}
 
 //Create event storages(batch) and consumers(stream) from incoming config
 //Enrich incoming configs with default values if needed
-func Create(ctx context.Context, destinations *viper.Viper, logEventPath string) (map[string][]events.Storage, map[string][]events.Consumer) {
-	stores := map[string][]events.Storage{}
-	consumers := map[string][]events.Consumer{}
+//loggers are event log file writers per token: they consume events of tokens which have batch storages
+func Create(ctx context.Context, destinations *viper.Viper, logEventPath string, loggers map[string]events.Consumer) *Destinations {
+	d := NewDestinations(ctx, logEventPath, loggers)
 	if destinations == nil {
-		return stores, consumers
+		return d
 	}
 
 	dc := map[string]DestinationConfig{}
 	if err := destinations.Unmarshal(&dc); err != nil {
 		log.Println("Error initializing destinations: wrong config format: each destination must contains one key and config as a value e.g. destinations:\n  custom_name:\n      type: redshift ...", err)
-		return stores, consumers
+		return d
 	}
 
-	for name, destination := range dc {
-		if destination.Type == "" {
-			destination.Type = name
+	//routing config is kept in the destinations section under the reserved name
+	delete(dc, routing.Key)
+	if destinations.IsSet(routing.Key) {
+		routingConfig := &routing.Config{}
+		if err := destinations.UnmarshalKey(routing.Key, routingConfig); err != nil {
+			log.Println("Error initializing destinations: wrong routing config format:", err)
+			return d
 		}
-		if destination.Mode == "" {
-			destination.Mode = batchMode
+		names := map[string]bool{}
+		for name := range dc {
+			names[name] = true
 		}
-		log.Println("Initializing", name, "destination of type:", destination.Type, "in mode:", destination.Mode)
-
-		if destination.Mode != batchMode && destination.Mode != streamMode {
-			logError(name, destination.Type, fmt.Errorf("Unknown destination mode: %s. Available mode: [%s, %s]", destination.Mode, batchMode, streamMode))
-			continue
+		if err := routing.Init(routingConfig, names); err != nil {
+			log.Println("Error initializing destinations:", err)
+			return d
 		}
+	}
 
-		var mapping []string
-		tableName := defaultTableName
-		if destination.DataLayout != nil {
-			mapping = destination.DataLayout.Mapping
+	for name, destination := range dc {
+		d.add(name, &destination)
+	}
+	return d
+}
 
-			if destination.DataLayout.TableNameTemplate != "" {
-				tableName = destination.DataLayout.TableNameTemplate
-			}
-		}
+//create batch storage or stream consumer of the destination (destination config is enriched with default values)
+func newDestination(ctx context.Context, name, logEventPath string, destination *DestinationConfig) (events.Storage, events.Consumer, error) {
+	if destination.Type == "" {
+		destination.Type = name
+	}
+	if destination.Mode == "" {
+		destination.Mode = batchMode
+	}
+	log.Println("Initializing", name, "destination of type:", destination.Type, "in mode:", destination.Mode)
 
-		processor, err := schema.NewProcessor(tableName, mapping)
-		if err != nil {
-			logError(name, destination.Type, err)
-			continue
+	if destination.Mode != batchMode && destination.Mode != streamMode {
+		return nil, nil, fmt.Errorf("Unknown destination mode: %s. Available mode: [%s, %s]", destination.Mode, batchMode, streamMode)
+	}
+
+	if err := validateStateless(destination); err != nil {
+		return nil, nil, err
+	}
+
+	var mapping []string
+	var mappings []*schema.MappingConfig
+	var types map[string]string
+	var timeBounds *schema.TimeBoundsConfig
+	var timestamps *schema.TimestampsConfig
+	var nonASCIIFields, numericOverflow, schemaEvolution string
+	var upsert []*schema.UpsertConfig
+	var deletions []*schema.DeletionsConfig
+	var descriptions []*schema.ColumnDescriptionConfig
+	var systemColumns map[string]string
+	var existingTables *schema.ExistingTablesConfig
+	var readOnlySchema *ReadOnlySchemaConfig
+	var arrays bool
+	var jsonColumns []string
+	var maxColumns int
+	var renames string
+	var columnNames *schema.ColumnNamesConfig
+	var decimals *schema.DecimalsConfig
+	var schemaCache *schema.TablesCacheConfig
+	var tableNameField *schema.TableNameFieldConfig
+	var flattening *schema.FlatteningConfig
+	tableName := defaultTableName
+	if destination.DataLayout != nil {
+		mapping = destination.DataLayout.Mapping
+		mappings = destination.DataLayout.Mappings
+		types = destination.DataLayout.Types
+		timeBounds = destination.DataLayout.TimestampBounds
+		timestamps = destination.DataLayout.Timestamps
+		nonASCIIFields = destination.DataLayout.NonASCIIFields
+		numericOverflow = destination.DataLayout.NumericOverflow
+		schemaEvolution = destination.DataLayout.SchemaEvolution
+		upsert = destination.DataLayout.Upsert
+		deletions = destination.DataLayout.Deletions
+		descriptions = destination.DataLayout.ColumnDescriptions
+		systemColumns = destination.DataLayout.SystemColumns
+		existingTables = destination.DataLayout.ExistingTables
+		readOnlySchema = destination.DataLayout.ReadOnlySchema
+		arrays = destination.DataLayout.Arrays
+		jsonColumns = destination.DataLayout.JSONColumns
+		maxColumns = destination.DataLayout.MaxColumns
+		renames = destination.DataLayout.Renames
+		columnNames = destination.DataLayout.ColumnNames
+		decimals = destination.DataLayout.Decimals
+		schemaCache = destination.DataLayout.SchemaCache
+		tableNameField = destination.DataLayout.TableNameField
+		flattening = destination.DataLayout.Flattening
+
+		if destination.DataLayout.TableNameTemplate != "" {
+			tableName = destination.DataLayout.TableNameTemplate
 		}
+		if tableNameField != nil && destination.DataLayout.TableNameTemplate != "" {
+			return nil, nil, errors.New("data_layout.table_name_template and data_layout.table_name_field can't be used together")
+		}
+	}
+
+	if err := validateDeduplication(destination); err != nil {
+		return nil, nil, err
+	}
+
+	upsert, err := deduplicationUpsert(destination, upsert, systemColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateUpsert(destination, upsert); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateDeletions(destination, deletions); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateSystemColumns(destination, systemColumns); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateExistingTables(destination, existingTables); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateReadOnlySchema(destination, readOnlySchema, logEventPath); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateArrays(destination, arrays); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateJSONColumns(destination, jsonColumns); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateMaxColumns(destination, maxColumns); err != nil {
+		return nil, nil, err
+	}
+
+	columnNames, err = destinationColumnNames(destination, columnNames)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateDecimals(destination, decimals, types); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateSchemaCache(destination, schemaCache); err != nil {
+		return nil, nil, err
+	}
+
+	ddlWriter, err := NewDDLWriter(name, readOnlySchema)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	engineColumns, err := destinationEngineColumns(destination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	processor, err := schema.NewProcessor(&schema.ProcessorConfig{
+		TableNameTemplate:  tableName,
+		Mapping:            mapping,
+		Mappings:           mappings,
+		Types:              types,
+		TimeBounds:         timeBounds,
+		NonASCIIFields:     nonASCIIFields,
+		NumericOverflow:    numericOverflow,
+		Upsert:             upsert,
+		Deletions:          deletions,
+		EngineColumns:      engineColumns,
+		ColumnDescriptions: descriptions,
+		SamplesDestination: name,
+		SystemColumns:      systemColumns,
+		ExistingTables:     existingTables,
+		Filter:             destination.Filter,
+		Arrays:             arrays,
+		JSONColumns:        jsonColumns,
+		SchemaEvolution:    schemaEvolution,
+		Timestamps:         timestamps,
+		MaxColumns:         maxColumns,
+		Renames:            renames,
+		ColumnNames:        columnNames,
+		Decimals:           decimals,
+		TablesCache:        schemaCache,
+		TableNameField:     tableNameField,
+		Flattening:         flattening,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := persistRenames(name, logEventPath, processor); err != nil {
+		return nil, nil, err
+	}
+	if err := persistTablesCache(name, logEventPath, processor); err != nil {
+		return nil, nil, err
+	}
+	setProcessor(name, processor)
+
+	if err := setFaultInjector(name, destination.FaultInjection); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateRetry(destination); err != nil {
+		return nil, nil, err
+	}
+
+	if err := setRetryPolicy(name, destination.Retry, logEventPath); err != nil {
+		return nil, nil, err
+	}
 
-		var storage events.Storage
-		var consumer events.Consumer
+	if err := validateAdaptiveBatch(destination); err != nil {
+		return nil, nil, err
+	}
+	setAdaptiveBatch(name, destination.AdaptiveBatch)
+
+	if err := validateWorkers(destination); err != nil {
+		return nil, nil, err
+	}
+	setWorkers(name, destination.Workers)
+
+	var storage events.Storage
+	var consumer events.Consumer
+	if destination.DryRun {
+		var dryRun *DryRun
+		dryRun, err = createDryRun(ctx, name, destination, processor)
+		if destination.Mode == streamMode {
+			consumer = dryRun
+		} else {
+			storage = dryRun
+		}
+	} else {
 		switch destination.Type {
 		case "redshift":
 			if destination.Mode == streamMode {
-				consumer, err = createRedshift(ctx, name, logEventPath, &destination, processor, true)
+				consumer, err = createRedshift(ctx, name, logEventPath, destination, processor, ddlWriter, true)
 			} else {
-				storage, err = createRedshift(ctx, name, logEventPath, &destination, processor, false)
+				storage, err = createRedshift(ctx, name, logEventPath, destination, processor, ddlWriter, false)
 			}
 		case "bigquery":
 			if destination.Mode == streamMode {
-				consumer, err = createBigQuery(ctx, name, logEventPath, &destination, processor, true)
+				consumer, err = createBigQuery(ctx, name, logEventPath, destination, processor, true)
 			} else {
-				storage, err = createBigQuery(ctx, name, logEventPath, &destination, processor, false)
+				storage, err = createBigQuery(ctx, name, logEventPath, destination, processor, false)
 			}
 		case "postgres":
 			if destination.Mode == streamMode {
-				consumer, err = createPostgres(ctx, name, logEventPath, &destination, processor, true)
+				consumer, err = createPostgres(ctx, name, logEventPath, destination, processor, ddlWriter, true)
+			} else {
+				storage, err = createPostgres(ctx, name, logEventPath, destination, processor, ddlWriter, false)
+			}
+		case "mssql":
+			if destination.Mode == streamMode {
+				consumer, err = createGenericSQL(ctx, name, logEventPath, destination, processor, ddlWriter, adapters.MSSQLDialect, true)
 			} else {
-				storage, err = createPostgres(ctx, name, logEventPath, &destination, processor, false)
+				storage, err = createGenericSQL(ctx, name, logEventPath, destination, processor, ddlWriter, adapters.MSSQLDialect, false)
 			}
 		case "clickhouse":
 			if destination.Mode == streamMode {
-				consumer, err = createClickHouse(ctx, name, logEventPath, &destination, processor, true)
+				consumer, err = createClickHouse(ctx, name, logEventPath, destination, processor, true)
+			} else {
+				storage, err = createClickHouse(ctx, name, logEventPath, destination, processor, false)
+			}
+		case "snowflake":
+			if destination.Mode == streamMode {
+				consumer, err = createSnowflake(ctx, name, logEventPath, destination, processor, ddlWriter, true)
 			} else {
-				storage, err = createClickHouse(ctx, name, logEventPath, &destination, processor, false)
+				storage, err = createSnowflake(ctx, name, logEventPath, destination, processor, ddlWriter, false)
 			}
 		case "s3":
 			if destination.Mode == streamMode {
-				err = fmt.Errorf("S3 destination doesn't support %s mode", streamMode)
+				consumer, err = createS3(name, logEventPath, destination, processor, true)
 			} else {
-				storage, err = createS3(name, &destination, processor)
+				storage, err = createS3(name, logEventPath, destination, processor, false)
+			}
+		case "gcs":
+			if destination.Mode == streamMode {
+				consumer, err = createGCS(ctx, name, logEventPath, destination, processor, true)
+			} else {
+				storage, err = createGCS(ctx, name, logEventPath, destination, processor, false)
+			}
+		case "kafka":
+			if destination.Mode == streamMode {
+				consumer, err = createKafka(name, logEventPath, destination, processor, true)
+			} else {
+				storage, err = createKafka(name, logEventPath, destination, processor, false)
+			}
+		case "kinesis":
+			if destination.Mode == streamMode {
+				consumer, err = createKinesis(name, logEventPath, destination, processor, true)
+			} else {
+				storage, err = createKinesis(name, logEventPath, destination, processor, false)
+			}
+		case "pubsub":
+			if destination.Mode == streamMode {
+				consumer, err = createPubSub(ctx, name, logEventPath, destination, processor, true)
+			} else {
+				storage, err = createPubSub(ctx, name, logEventPath, destination, processor, false)
+			}
+		case "elasticsearch":
+			if destination.Mode == streamMode {
+				consumer, err = createElasticsearch(name, logEventPath, destination, processor, true)
+			} else {
+				storage, err = createElasticsearch(name, logEventPath, destination, processor, false)
+			}
+		case "webhook":
+			if destination.Mode == streamMode {
+				consumer, err = createWebhook(name, logEventPath, destination, processor, true)
+			} else {
+				storage, err = createWebhook(name, logEventPath, destination, processor, false)
+			}
+		case "amplitude":
+			if destination.Mode == streamMode {
+				consumer, err = createAmplitude(name, logEventPath, destination, processor, true)
+			} else {
+				storage, err = createAmplitude(name, logEventPath, destination, processor, false)
+			}
+		case "mixpanel":
+			if destination.Mode == streamMode {
+				consumer, err = createMixpanel(name, logEventPath, destination, processor, true)
+			} else {
+				storage, err = createMixpanel(name, logEventPath, destination, processor, false)
+			}
+		case "parquet":
+			if destination.Mode == streamMode {
+				consumer, err = createParquet(name, logEventPath, destination, processor, true)
+			} else {
+				storage, err = createParquet(name, logEventPath, destination, processor, false)
+			}
+		case "nats":
+			if destination.Mode == streamMode {
+				consumer, err = createNATS(name, logEventPath, destination, processor, true)
+			} else {
+				storage, err = createNATS(name, logEventPath, destination, processor, false)
+			}
+		case "redis":
+			if destination.Mode == streamMode {
+				consumer, err = createRedis(name, logEventPath, destination, processor, true)
+			} else {
+				storage, err = createRedis(name, logEventPath, destination, processor, false)
+			}
+		case "druid":
+			if destination.Mode == streamMode {
+				consumer, err = createDruid(name, logEventPath, destination, processor, true)
+			} else {
+				storage, err = createDruid(name, logEventPath, destination, processor, false)
 			}
 		default:
 			err = unknownDestination
 		}
+	}
 
-		if err != nil {
-			logError(name, destination.Type, err)
-			continue
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return storage, consumer, nil
+}
+
+//return err if upsert is configured for destination type or mode which doesn't support it
+func validateUpsert(destination *DestinationConfig, upsert []*schema.UpsertConfig) error {
+	if len(upsert) == 0 {
+		return nil
+	}
+
+	supported := false
+	for _, t := range upsertDestinationTypes {
+		if t == destination.Type {
+			supported = true
+			break
 		}
+	}
+	if !supported {
+		return fmt.Errorf("data_layout.upsert isn't supported by %s destination. Supported types: %v", destination.Type, upsertDestinationTypes)
+	}
+	if destination.Type == "bigquery" && destination.Mode == streamMode {
+		return errors.New("data_layout.upsert isn't supported by bigquery destination in stream mode")
+	}
 
-		tokens := destination.OnlyTokens
-		if len(tokens) == 0 {
-			log.Printf("Warn: only_tokens wasn't provided. All tokens will be stored in %s %s destination", name, destination.Type)
-			for token := range appconfig.Instance.AuthorizedTokens {
-				tokens = append(tokens, token)
-			}
+	return nil
+}
+
+//return err if deduplication is configured for destination type or mode which doesn't support it or config is invalid
+func validateDeduplication(destination *DestinationConfig) error {
+	if destination.Deduplication == nil {
+		return nil
+	}
+
+	if err := destination.Deduplication.Validate(); err != nil {
+		return err
+	}
+	if destination.Deduplication.CacheSize > 0 && destination.Mode != streamMode {
+		return errors.New("deduplication.cache_size is supported only in stream mode")
+	}
+	if !destination.Deduplication.Upsert {
+		return nil
+	}
+
+	supported := false
+	for _, t := range upsertDestinationTypes {
+		if t == destination.Type {
+			supported = true
+			break
 		}
+	}
+	if !supported {
+		return fmt.Errorf("deduplication.upsert isn't supported by %s destination. Supported types: %v", destination.Type, upsertDestinationTypes)
+	}
+	if destination.Type == "bigquery" && destination.Mode == streamMode {
+		return errors.New("deduplication.upsert isn't supported by bigquery destination in stream mode")
+	}
+
+	return nil
+}
+
+//return err if retry is configured for destination type or mode which doesn't support it or config is invalid
+func validateRetry(destination *DestinationConfig) error {
+	if destination.Retry == nil {
+		return nil
+	}
+
+	if destination.Mode != streamMode {
+		return errors.New("retry is supported only in stream mode")
+	}
+
+	supported := false
+	for _, t := range retryDestinationTypes {
+		if t == destination.Type {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("retry isn't supported by %s destination. Supported types: %v", destination.Type, retryDestinationTypes)
+	}
 
-		for _, token := range tokens {
-			if storage != nil {
-				stores[token] = append(stores[token], storage)
+	return destination.Retry.Validate()
+}
+
+//return err if destination keeps state on local disk in stateless mode:
+//batch mode (event log files), retry.dead_letter and data_layout.read_only_schema (DDL files)
+func validateStateless(destination *DestinationConfig) error {
+	if !stateless.Enabled() {
+		return nil
+	}
+
+	if destination.Mode != streamMode {
+		return stateless.Unsupported("batch mode")
+	}
+	if destination.Retry != nil && destination.Retry.DeadLetter {
+		return stateless.Unsupported("retry.dead_letter")
+	}
+	if destination.DataLayout != nil && destination.DataLayout.ReadOnlySchema != nil && destination.DataLayout.ReadOnlySchema.Enabled {
+		return stateless.Unsupported("data_layout.read_only_schema")
+	}
+
+	return nil
+}
+
+//return err if system columns are renamed in destination type which doesn't support it
+func validateSystemColumns(destination *DestinationConfig, systemColumns map[string]string) error {
+	if len(systemColumns) == 0 {
+		return nil
+	}
+
+	for _, t := range systemColumnsDestinationTypes {
+		if t == destination.Type {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("data_layout.system_columns isn't supported by %s destination. Supported types: %v", destination.Type, systemColumnsDestinationTypes)
+}
+
+//return err if destination type doesn't support data_layout.existing_tables
+//or destination is configured with features which create or alter tables
+func validateExistingTables(destination *DestinationConfig, existingTables *schema.ExistingTablesConfig) error {
+	if existingTables == nil || !existingTables.Enabled {
+		return nil
+	}
+
+	supported := false
+	for _, t := range existingTablesDestinationTypes {
+		if t == destination.Type {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("data_layout.existing_tables isn't supported by %s destination. Supported types: %v", destination.Type, existingTablesDestinationTypes)
+	}
+
+	switch destination.Type {
+	case "clickhouse":
+		if config := destination.ClickHouse; config != nil {
+			if config.Staging || config.Buffer != nil {
+				return errors.New("data_layout.existing_tables can't be used with clickhouse staging and buffer: they create tables")
 			}
-			if consumer != nil {
-				consumers[token] = append(consumers[token], consumer)
+		}
+	case "bigquery":
+		if len(destination.DataLayout.Upsert) > 0 {
+			return errors.New("data_layout.existing_tables can't be used with bigquery data_layout.upsert: it creates staging tables")
+		}
+	}
+
+	return nil
+}
+
+//return err if destination type doesn't support data_layout.arrays
+func validateArrays(destination *DestinationConfig, arrays bool) error {
+	if !arrays {
+		return nil
+	}
+
+	for _, t := range arraysDestinationTypes {
+		if t == destination.Type {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("data_layout.arrays isn't supported by %s destination. Supported types: %v", destination.Type, arraysDestinationTypes)
+}
+
+//return err if destination type doesn't support data_layout.json_columns
+func validateJSONColumns(destination *DestinationConfig, jsonColumns []string) error {
+	if len(jsonColumns) == 0 {
+		return nil
+	}
+
+	for _, t := range jsonColumnsDestinationTypes {
+		if t == destination.Type {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("data_layout.json_columns isn't supported by %s destination. Supported types: %v", destination.Type, jsonColumnsDestinationTypes)
+}
+
+//return err if max columns limit is negative or destination type doesn't support data_layout.max_columns
+//(unmapped column is a JSON one, so the same types as json_columns are supported)
+func validateMaxColumns(destination *DestinationConfig, maxColumns int) error {
+	if maxColumns < 0 {
+		return errors.New("data_layout.max_columns can't be negative")
+	}
+	if maxColumns == 0 {
+		return nil
+	}
+
+	for _, t := range jsonColumnsDestinationTypes {
+		if t == destination.Type {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("data_layout.max_columns isn't supported by %s destination. Supported types: %v", destination.Type, jsonColumnsDestinationTypes)
+}
+
+//return err if destination type doesn't support data_layout.read_only_schema or config is invalid
+//set default ddl_dir (in logEventPath) and refresh_every values
+func validateReadOnlySchema(destination *DestinationConfig, readOnlySchema *ReadOnlySchemaConfig, logEventPath string) error {
+	if readOnlySchema == nil || !readOnlySchema.Enabled {
+		return nil
+	}
+
+	supported := false
+	for _, t := range readOnlySchemaDestinationTypes {
+		if t == destination.Type {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("data_layout.read_only_schema isn't supported by %s destination. Supported types: %v", destination.Type, readOnlySchemaDestinationTypes)
+	}
+
+	return readOnlySchema.Validate(logEventPath)
+}
+
+//return err if deletions delete mode is configured for destination type which doesn't support it
+//Kafka tombstones are published with one key value as message key
+func validateDeletions(destination *DestinationConfig, deletions []*schema.DeletionsConfig) error {
+	for _, config := range deletions {
+		if config == nil || (config.Mode != "" && config.Mode != schema.DeleteMode) {
+			continue
+		}
+
+		supported := false
+		for _, t := range deleteDestinationTypes {
+			if t == destination.Type {
+				supported = true
+				break
 			}
 		}
+		if !supported {
+			return fmt.Errorf("data_layout.deletions %s mode isn't supported by %s destination. Supported types: %v. Use %s mode instead",
+				schema.DeleteMode, destination.Type, deleteDestinationTypes, schema.TableMode)
+		}
+		if destination.Type == "kafka" && len(config.Keys) != 1 {
+			return fmt.Errorf("data_layout.deletions of event_type [%s] must have one key in kafka destination (tombstone message key)", config.EventType)
+		}
+	}
+
+	return nil
+}
 
+//return engine columns of destination tables which must be maintained by schema.Processor (ClickHouse engine.tables)
+func destinationEngineColumns(destination *DestinationConfig) (map[string]*schema.EngineColumns, error) {
+	if destination.Type != "clickhouse" || destination.ClickHouse == nil {
+		return nil, nil
 	}
-	return stores, consumers
+
+	if err := destination.ClickHouse.Validate(); err != nil {
+		return nil, err
+	}
+
+	return destination.ClickHouse.EngineColumns(), nil
 }
 
-func logError(destinationName, destinationType string, err error) {
-	log.Printf("Error initializing %s destination of type %s: %v", destinationName, destinationType, err)
+func logError(destinationName string, destination *DestinationConfig, err error) {
+	log.Printf("Error initializing %s destination of type %s: %v", destinationName, destination.Type, err)
+	webhooks.Fire(webhooks.DestinationFailed, map[string]interface{}{"destination": destinationName, "type": destination.Type, "error": err.Error()})
+	registerDestination(&DestinationStatus{Name: destinationName, Type: destination.Type, Mode: destination.Mode, Tokens: destination.OnlyTokens,
+		Status: DestinationStatusFailed, Error: err.Error()}, nil)
 }
 
 //Create aws Redshift destination
-func createRedshift(ctx context.Context, name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, streamMode bool) (*AwsRedshift, error) {
+func createRedshift(ctx context.Context, name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, ddlWriter *DDLWriter,
+	streamMode bool) (*AwsRedshift, error) {
+	redshiftConfig, err := redshiftDataSource(name, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAwsRedshift(ctx, name, logEventPath, destination.S3, redshiftConfig, processor, ddlWriter, destination.BreakOnError, streamMode)
+}
+
+//return validated redshift datasource config with default parameters
+func redshiftDataSource(name string, destination *DestinationConfig) (*adapters.DataSourceConfig, error) {
 	redshiftConfig := destination.DataSource
 	if err := redshiftConfig.Validate(); err != nil {
 		return nil, err
@@ -171,7 +940,7 @@ func createRedshift(ctx context.Context, name, logEventPath string, destination
 		redshiftConfig.Parameters["connect_timeout"] = "600"
 	}
 
-	return NewAwsRedshift(ctx, name, logEventPath, destination.S3, redshiftConfig, processor, destination.BreakOnError, streamMode)
+	return redshiftConfig, nil
 }
 
 //Create google BigQuery destination
@@ -191,7 +960,18 @@ func createBigQuery(ctx context.Context, name, logEventPath string, destination
 }
 
 //Create Postgres destination
-func createPostgres(ctx context.Context, name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, streamMode bool) (*Postgres, error) {
+func createPostgres(ctx context.Context, name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, ddlWriter *DDLWriter,
+	streamMode bool) (*Postgres, error) {
+	config, err := postgresDataSource(name, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPostgres(ctx, config, processor, ddlWriter, logEventPath, name, destination.BreakOnError, streamMode)
+}
+
+//return validated postgres datasource config with default parameters
+func postgresDataSource(name string, destination *DestinationConfig) (*adapters.DataSourceConfig, error) {
 	config := destination.DataSource
 	if err := config.Validate(); err != nil {
 		return nil, err
@@ -210,7 +990,37 @@ func createPostgres(ctx context.Context, name, logEventPath string, destination
 		config.Parameters["connect_timeout"] = "600"
 	}
 
-	return NewPostgres(ctx, config, processor, logEventPath, name, destination.BreakOnError, streamMode)
+	return config, nil
+}
+
+//Create SQL destination of the dialect (e.g. MSSQL) with datasource config
+func createGenericSQL(ctx context.Context, name, logEventPath string, destination *DestinationConfig, processor *schema.Processor,
+	ddlWriter *DDLWriter, dialect *adapters.SQLDialect, streamMode bool) (*GenericSQL, error) {
+	config, err := genericSQLDataSource(name, destination, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGenericSQL(ctx, name, logEventPath, dialect, config, processor, ddlWriter, destination.BreakOnError, streamMode)
+}
+
+//return validated datasource config of the dialect with default parameters
+func genericSQLDataSource(name string, destination *DestinationConfig, dialect *adapters.SQLDialect) (*adapters.DataSourceConfig, error) {
+	config := destination.DataSource
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	//enrich with default parameters
+	if config.Port <= 0 {
+		config.Port = dialect.DefaultPort
+		log.Printf("name: %s type: %s port wasn't provided. Will be used default one: %d", name, destination.Type, config.Port)
+	}
+	if config.Schema == "" {
+		config.Schema = dialect.DefaultSchema
+		log.Printf("name: %s type: %s schema wasn't provided. Will be used default one: %s", name, destination.Type, config.Schema)
+	}
+
+	return config, nil
 }
 
 //Create ClickHouse destination
@@ -219,16 +1029,385 @@ func createClickHouse(ctx context.Context, name, logEventPath string, destinatio
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
+	//enrich with default parameters: default partition and sorting keys are built on renamed system columns
+	timestampColumn, eventIDColumn := processor.SystemColumn(timestamp.Key), processor.SystemColumn(schema.EventIDColumn)
+	if timestampColumn != timestamp.Key || eventIDColumn != schema.EventIDColumn {
+		if config.Engine == nil {
+			config.Engine = &adapters.EngineConfig{}
+		}
+		if config.Engine.RawStatement == "" && config.Engine.PartitionBy == "" && len(config.Engine.PartitionFields) == 0 {
+			config.Engine.PartitionBy = "toYYYYMM(" + timestampColumn + ")"
+			log.Printf("name: %s type: clickhouse engine partition wasn't provided. Will be used default one: %s", name, config.Engine.PartitionBy)
+		}
+		if config.Engine.RawStatement == "" && config.Engine.OrderBy == "" && len(config.Engine.OrderFields) == 0 {
+			config.Engine.OrderBy = eventIDColumn
+			log.Printf("name: %s type: clickhouse engine order wasn't provided. Will be used default one: %s", name, config.Engine.OrderBy)
+		}
+	}
 
 	return NewClickHouse(ctx, name, logEventPath, config, processor, destination.BreakOnError, streamMode)
 }
 
+//Create Snowflake destination
+//s3 config is optional: if provided - s3 external stage will be used
+func createSnowflake(ctx context.Context, name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, ddlWriter *DDLWriter,
+	streamMode bool) (*Snowflake, error) {
+	config, err := snowflakeConfig(name, destination)
+	if err != nil {
+		return nil, err
+	}
+	if destination.S3 != nil {
+		if err := destination.S3.Validate(); err != nil {
+			return nil, err
+		}
+		if config.Stage == "" {
+			return nil, errors.New("Snowflake stage is required parameter if s3 external stage is used")
+		}
+	}
+
+	return NewSnowflake(ctx, name, logEventPath, destination.S3, config, processor, ddlWriter, destination.BreakOnError, streamMode)
+}
+
+//return validated snowflake config with default parameters
+func snowflakeConfig(name string, destination *DestinationConfig) (*adapters.SnowflakeConfig, error) {
+	config := destination.Snowflake
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	//enrich with default parameters
+	if config.Port <= 0 {
+		config.Port = 443
+		log.Printf("name: %s type: snowflake port wasn't provided. Will be used default one: %d", name, config.Port)
+	}
+	if config.Schema == "" {
+		config.Schema = "PUBLIC"
+		log.Printf("name: %s type: snowflake schema wasn't provided. Will be used default one: %s", name, config.Schema)
+	}
+
+	return config, nil
+}
+
+//Create DryRun of the destination. Tables schemas are read from the destination and DDL statements are provided
+//by its adapter if the type supports read-only schema mode (see readOnlySchemaDestinationTypes)
+//nothing is created in the destination
+func createDryRun(ctx context.Context, name string, destination *DestinationConfig, processor *schema.Processor) (*DryRun, error) {
+	knownType := false
+	for _, t := range destinationTypes {
+		if t == destination.Type {
+			knownType = true
+			break
+		}
+	}
+	if !knownType {
+		return nil, unknownDestination
+	}
+
+	var inspector DDLInspector
+	switch destination.Type {
+	case "postgres":
+		config, err := postgresDataSource(name, destination)
+		if err != nil {
+			return nil, err
+		}
+		if inspector, err = adapters.NewPostgres(ctx, config); err != nil {
+			return nil, err
+		}
+	case "redshift":
+		config, err := redshiftDataSource(name, destination)
+		if err != nil {
+			return nil, err
+		}
+		if inspector, err = adapters.NewAwsRedshift(ctx, config, nil); err != nil {
+			return nil, err
+		}
+	case "snowflake":
+		config, err := snowflakeConfig(name, destination)
+		if err != nil {
+			return nil, err
+		}
+		if inspector, err = adapters.NewSnowflake(ctx, config, nil); err != nil {
+			return nil, err
+		}
+	case "mssql":
+		config, err := genericSQLDataSource(name, destination, adapters.MSSQLDialect)
+		if err != nil {
+			return nil, err
+		}
+		if inspector, err = adapters.NewGenericSQL(ctx, adapters.MSSQLDialect, config); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Printf("[%s] dry run: DDL statements and inserts are logged instead of executing", name)
+	return NewDryRun(name, destination.Type, processor, inspector), nil
+}
+
 //Create s3 destination
-func createS3(name string, destination *DestinationConfig, processor *schema.Processor) (*S3, error) {
+//glue config is optional: if provided - tables and partitions will be registered in aws Glue data catalog
+func createS3(name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, streamMode bool) (*S3, error) {
 	s3Config := destination.S3
 	if err := s3Config.Validate(); err != nil {
 		return nil, err
 	}
 
-	return NewS3(name, s3Config, processor, destination.BreakOnError)
+	filesConfig, err := getFilesConfig(destination)
+	if err != nil {
+		return nil, err
+	}
+
+	if destination.Glue != nil {
+		if err := destination.Glue.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewS3(name, logEventPath, s3Config, filesConfig, destination.Glue, processor, destination.BreakOnError, streamMode)
+}
+
+//Create google cloud storage destination
+func createGCS(ctx context.Context, name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, streamMode bool) (*GCS, error) {
+	gConfig := destination.Google
+	if err := gConfig.ValidateStorage(); err != nil {
+		return nil, err
+	}
+
+	filesConfig, err := getFilesConfig(destination)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGCS(ctx, name, logEventPath, gConfig, filesConfig, processor, destination.BreakOnError, streamMode)
+}
+
+//Create Kafka destination
+func createKafka(name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, streamMode bool) (*Kafka, error) {
+	config := destination.Kafka
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	//enrich with default parameters
+	if config.TopicTemplate == "" {
+		config.TopicTemplate = kafkaTablePlaceholder
+		log.Printf("name: %s type: kafka topic_template wasn't provided. Will be used default one: %s", name, config.TopicTemplate)
+	}
+
+	return NewKafka(name, logEventPath, config, processor, destination.BreakOnError, streamMode)
+}
+
+//Create NATS JetStream destination
+func createNATS(name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, streamMode bool) (*NATS, error) {
+	config := destination.NATS
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	//enrich with default parameters
+	if config.SubjectTemplate == "" {
+		config.SubjectTemplate = natsTablePlaceholder
+		log.Printf("name: %s type: nats subject_template wasn't provided. Will be used default one: %s", name, config.SubjectTemplate)
+	}
+	if config.MsgIDField == "" {
+		config.MsgIDField = defaultNATSMsgIDField
+		log.Printf("name: %s type: nats msg_id_field wasn't provided. Will be used default one: %s", name, config.MsgIDField)
+	}
+
+	return NewNATS(name, logEventPath, config, processor, destination.BreakOnError, streamMode)
+}
+
+//Create Redis Streams destination
+func createRedis(name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, streamMode bool) (*Redis, error) {
+	config := destination.Redis
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	//enrich with default parameters
+	if config.StreamTemplate == "" {
+		config.StreamTemplate = redisTablePlaceholder
+		log.Printf("name: %s type: redis stream_template wasn't provided. Will be used default one: %s", name, config.StreamTemplate)
+	}
+
+	return NewRedis(name, logEventPath, config, processor, destination.BreakOnError, streamMode)
+}
+
+//Create aws Kinesis destination
+func createKinesis(name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, streamMode bool) (*Kinesis, error) {
+	config := destination.Kinesis
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	//enrich with default parameters
+	if config.FlushEvery == 0 {
+		config.FlushEvery = performance.Instance.FlushEvery
+		log.Printf("name: %s type: kinesis flush_every wasn't provided. Will be used default one: %s", name, config.FlushEvery)
+	}
+
+	return NewKinesis(name, logEventPath, config, processor, destination.BreakOnError, streamMode)
+}
+
+//Create Google Pub/Sub destination
+func createPubSub(ctx context.Context, name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, streamMode bool) (*PubSub, error) {
+	config := destination.PubSub
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return NewPubSub(ctx, name, logEventPath, config, processor, destination.BreakOnError, streamMode)
+}
+
+//Create Elasticsearch (OpenSearch) destination
+func createElasticsearch(name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, streamMode bool) (*Elasticsearch, error) {
+	config := destination.Elasticsearch
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	//enrich with default parameters
+	if config.IDField == "" {
+		config.IDField = defaultElasticsearchIDField
+		log.Printf("name: %s type: elasticsearch id_field wasn't provided. Will be used default one: %s", name, config.IDField)
+	}
+	if config.BulkSize == 0 {
+		config.BulkSize = performance.Instance.BulkSize
+		log.Printf("name: %s type: elasticsearch bulk_size wasn't provided. Will be used default one: %d", name, config.BulkSize)
+	}
+	if config.FlushEvery == 0 {
+		config.FlushEvery = performance.Instance.FlushEvery
+		log.Printf("name: %s type: elasticsearch flush_every wasn't provided. Will be used default one: %s", name, config.FlushEvery)
+	}
+
+	return NewElasticsearch(name, logEventPath, config, processor, destination.BreakOnError, streamMode)
+}
+
+//Create Apache Druid destination
+func createDruid(name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, streamMode bool) (*Druid, error) {
+	config := destination.Druid
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return NewDruid(name, logEventPath, config, processor, destination.BreakOnError, streamMode)
+}
+
+//Create webhook (generic HTTP) destination
+func createWebhook(name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, streamMode bool) (*Webhook, error) {
+	config := destination.Webhook
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	//enrich with default parameters
+	if config.BatchSize == 0 {
+		config.BatchSize = performance.Instance.BulkSize
+		log.Printf("name: %s type: webhook batch_size wasn't provided. Will be used default one: %d", name, config.BatchSize)
+	}
+	if config.FlushEvery == 0 {
+		config.FlushEvery = performance.Instance.FlushEvery
+		log.Printf("name: %s type: webhook flush_every wasn't provided. Will be used default one: %s", name, config.FlushEvery)
+	}
+
+	return NewWebhook(name, logEventPath, config, processor, destination.BreakOnError, streamMode)
+}
+
+//Create Amplitude destination
+func createAmplitude(name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, streamMode bool) (*Amplitude, error) {
+	config := destination.Amplitude
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	//enrich with default parameters
+	if config.UserIDField == "" {
+		config.UserIDField = defaultAmplitudeUserIDField
+		log.Printf("name: %s type: amplitude user_id_field wasn't provided. Will be used default one: %s", name, config.UserIDField)
+	}
+	if config.DeviceIDField == "" {
+		config.DeviceIDField = defaultAmplitudeDeviceIDField
+		log.Printf("name: %s type: amplitude device_id_field wasn't provided. Will be used default one: %s", name, config.DeviceIDField)
+	}
+	if config.EventTypeField == "" {
+		config.EventTypeField = defaultAmplitudeEventTypeField
+		log.Printf("name: %s type: amplitude event_type_field wasn't provided. Will be used default one: %s", name, config.EventTypeField)
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = performance.Instance.BulkSize
+		if config.BatchSize > adapters.AmplitudeMaxBatchSize {
+			config.BatchSize = adapters.AmplitudeMaxBatchSize
+		}
+		log.Printf("name: %s type: amplitude batch_size wasn't provided. Will be used default one: %d", name, config.BatchSize)
+	}
+	if config.FlushEvery == 0 {
+		config.FlushEvery = performance.Instance.FlushEvery
+		log.Printf("name: %s type: amplitude flush_every wasn't provided. Will be used default one: %s", name, config.FlushEvery)
+	}
+
+	return NewAmplitude(name, logEventPath, config, processor, destination.BreakOnError, streamMode)
+}
+
+//Create Mixpanel destination
+func createMixpanel(name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, streamMode bool) (*Mixpanel, error) {
+	config := destination.Mixpanel
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	//enrich with default parameters
+	if config.DistinctIDField == "" {
+		config.DistinctIDField = defaultMixpanelDistinctIDField
+		log.Printf("name: %s type: mixpanel distinct_id_field wasn't provided. Will be used default one: %s", name, config.DistinctIDField)
+	}
+	if config.EventField == "" {
+		config.EventField = defaultMixpanelEventField
+		log.Printf("name: %s type: mixpanel event_field wasn't provided. Will be used default one: %s", name, config.EventField)
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = performance.Instance.BulkSize
+		if config.BatchSize > config.MaxBatchSize() {
+			config.BatchSize = config.MaxBatchSize()
+		}
+		log.Printf("name: %s type: mixpanel batch_size wasn't provided. Will be used default one: %d", name, config.BatchSize)
+	}
+	if config.FlushEvery == 0 {
+		config.FlushEvery = performance.Instance.FlushEvery
+		log.Printf("name: %s type: mixpanel flush_every wasn't provided. Will be used default one: %s", name, config.FlushEvery)
+	}
+
+	return NewMixpanel(name, logEventPath, config, processor, destination.BreakOnError, streamMode)
+}
+
+//Create local Parquet files destination
+func createParquet(name, logEventPath string, destination *DestinationConfig, processor *schema.Processor, streamMode bool) (*Parquet, error) {
+	config := destination.Parquet
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	filesConfig, err := getFilesConfig(destination)
+	if err != nil {
+		return nil, err
+	}
+	if filesConfig.Compression != "" {
+		return nil, errors.New("parquet files are compressed with parquet.compression: files.compression isn't supported")
+	}
+	if filesConfig.Format != JSONFormat {
+		return nil, errors.New("files.format isn't supported by parquet destination")
+	}
+	//enrich with default parameters
+	if filesConfig.NameTemplate == "" {
+		filesConfig.NameTemplate = defaultParquetNameTemplate
+		log.Printf("name: %s type: parquet files.name_template wasn't provided. Will be used default one: %s", name, filesConfig.NameTemplate)
+	}
+	if filesConfig.PartitionTemplate == "" {
+		filesConfig.PartitionTemplate = defaultParquetPartitionTemplate
+		log.Printf("name: %s type: parquet files.partition_template wasn't provided. Will be used default one: %s", name, filesConfig.PartitionTemplate)
+	}
+
+	return NewParquet(name, logEventPath, config, filesConfig, processor, destination.BreakOnError, streamMode)
+}
+
+//return validated files config or default one
+func getFilesConfig(destination *DestinationConfig) (*FilesConfig, error) {
+	filesConfig := destination.Files
+	if filesConfig == nil {
+		filesConfig = &FilesConfig{}
+	}
+	if err := filesConfig.Validate(); err != nil {
+		return nil, err
+	}
+
+	return filesConfig, nil
 }
@@ -0,0 +1,43 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/adapters"
+	"log"
+	"sync"
+)
+
+//fault injectors per destination name (only destinations with fault_injection config)
+var (
+	faultInjectorsMutex sync.RWMutex
+	faultInjectors      = map[string]*adapters.FaultInjector{}
+)
+
+//create (or remove if config is nil) destination FaultInjector
+func setFaultInjector(destinationName string, config *adapters.FaultInjectionConfig) error {
+	faultInjectorsMutex.Lock()
+	defer faultInjectorsMutex.Unlock()
+
+	if config == nil {
+		delete(faultInjectors, destinationName)
+		return nil
+	}
+
+	injector, err := adapters.NewFaultInjector(config)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Warn: fault injection is enabled in %s destination (error_rate: %v latency: %v latency_rate: %v). It must be used only for testing purposes!",
+		destinationName, config.ErrorRate, config.Latency, config.LatencyRate)
+	faultInjectors[destinationName] = injector
+	return nil
+}
+
+//injectFault is called before destination write operations. It returns nil if fault injection isn't configured
+func injectFault(destinationName string) error {
+	faultInjectorsMutex.RLock()
+	injector := faultInjectors[destinationName]
+	faultInjectorsMutex.RUnlock()
+
+	return injector.Inject()
+}
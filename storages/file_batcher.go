@@ -0,0 +1,256 @@
+package storages
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/appconfig"
+	"github.com/ksensehq/eventnative/compression"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/performance"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/webhooks"
+	"log"
+	"sync"
+	"time"
+)
+
+const fileBatchTimeLayout = "2006-01-02T15-04-05.000"
+
+//FilesConfig dto for deserialized config of file destinations (s3, gcs, parquet):
+//object naming and partition path templates, timezone, schema manifests, objects format and compression (s3, gcs) and stream mode rotation
+//format: json (NDJSON, default), csv (see CSVConfig) or avro (object container files, see adapters.AvroConfig)
+type FilesConfig struct {
+	NameTemplate      string               `mapstructure:"name_template"`
+	PartitionTemplate string               `mapstructure:"partition_template"`
+	Timezone          string               `mapstructure:"timezone"`
+	SchemaManifest    bool                 `mapstructure:"schema_manifest"`
+	Format            string               `mapstructure:"format"`
+	CSV               *CSVConfig           `mapstructure:"csv"`
+	Avro              *adapters.AvroConfig `mapstructure:"avro"`
+	Compression       string               `mapstructure:"compression"`
+	UploadEvery       time.Duration        `mapstructure:"upload_every"`
+	MaxObjects        int                  `mapstructure:"max_objects"`
+
+	location *time.Location
+}
+
+//Validate FilesConfig values and set default ones
+func (fc *FilesConfig) Validate() error {
+	if fc.UploadEvery < 0 || fc.MaxObjects < 0 {
+		return errors.New("files upload_every and max_objects can't be negative")
+	}
+	if err := compression.Validate(fc.Compression, compression.Gzip, compression.Zstd); err != nil {
+		return fmt.Errorf("files %v", err)
+	}
+
+	switch fc.Format {
+	case "":
+		fc.Format = JSONFormat
+	case JSONFormat:
+	case CSVFormat:
+		if fc.CSV == nil {
+			fc.CSV = &CSVConfig{}
+		}
+		if err := fc.CSV.Validate(); err != nil {
+			return err
+		}
+	case AvroFormat:
+		if fc.Compression != "" {
+			return errors.New("avro files are compressed with files.avro.codec: files.compression isn't supported")
+		}
+		if fc.Avro == nil {
+			fc.Avro = &adapters.AvroConfig{}
+		}
+		if err := fc.Avro.Validate(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Unknown files format: %s. Available: [%s, %s, %s]", fc.Format, JSONFormat, CSVFormat, AvroFormat)
+	}
+
+	fc.location = time.UTC
+	if fc.Timezone != "" {
+		location, err := time.LoadLocation(fc.Timezone)
+		if err != nil {
+			return fmt.Errorf("Error parsing files timezone [%s]: %v", fc.Timezone, err)
+		}
+		fc.location = location
+	}
+	if fc.UploadEvery == 0 {
+		fc.UploadEvery = performance.Instance.FilesUploadEvery
+	}
+	if fc.MaxObjects == 0 {
+		fc.MaxObjects = performance.Instance.FilesMaxObjects
+	}
+
+	return nil
+}
+
+//UploadFunc upload one processed file (all objects of one table)
+type UploadFunc func(pf *schema.ProcessedFile) error
+
+//FileBatcher accumulates processed objects per table in memory and rotates them into files:
+//every uploadEvery or when maxObjects objects (or adaptive batch size if destination has adaptive_batch config) are accumulated
+//files which weren't uploaded are merged back and will be uploaded with the next rotation
+type FileBatcher struct {
+	name        string
+	uploadEvery time.Duration
+	maxObjects  int
+	upload      UploadFunc
+	//nil if destination doesn't have adaptive_batch config
+	adaptive *adaptiveBatchSize
+
+	mutex   sync.Mutex
+	files   map[string]*schema.ProcessedFile
+	objects int
+
+	flushCh  chan bool
+	closeCh  chan bool
+	closed   bool
+	uploadMu sync.Mutex
+}
+
+//NewFileBatcher return FileBatcher and start rotation goroutine
+func NewFileBatcher(name string, config *FilesConfig, upload UploadFunc) *FileBatcher {
+	fb := &FileBatcher{
+		name:        name,
+		uploadEvery: config.UploadEvery,
+		maxObjects:  config.MaxObjects,
+		upload:      upload,
+		adaptive:    newDestinationBatchSize(name, config.MaxObjects),
+		files:       map[string]*schema.ProcessedFile{},
+		flushCh:     make(chan bool, 1),
+		closeCh:     make(chan bool),
+	}
+	fb.start()
+
+	return fb
+}
+
+//Add put object into table file. Run rotation if maxObjects is reached
+func (fb *FileBatcher) Add(table *schema.Table, object map[string]interface{}) {
+	fb.mutex.Lock()
+	f, ok := fb.files[table.Name]
+	if !ok {
+		f = schema.NewProcessedFile("", &schema.Table{Name: table.Name, Columns: schema.Columns{}})
+		fb.files[table.Name] = f
+	}
+	f.Add(table, object)
+	fb.objects++
+	full := fb.objects >= fb.batchSize()
+	fb.mutex.Unlock()
+
+	if full {
+		select {
+		case fb.flushCh <- true:
+		default:
+		}
+	}
+}
+
+//return objects count which triggers rotation
+func (fb *FileBatcher) batchSize() int {
+	if fb.adaptive != nil {
+		return fb.adaptive.Size()
+	}
+
+	return fb.maxObjects
+}
+
+//upload file with fault injection (if it is configured)
+func (fb *FileBatcher) uploadFile(f *schema.ProcessedFile) error {
+	if err := injectFault(fb.name); err != nil {
+		return err
+	}
+
+	return fb.upload(f)
+}
+
+//Flush rotate and upload all accumulated files
+func (fb *FileBatcher) Flush() {
+	fb.uploadMu.Lock()
+	defer fb.uploadMu.Unlock()
+
+	fb.mutex.Lock()
+	files := fb.files
+	objects := fb.objects
+	fb.files = map[string]*schema.ProcessedFile{}
+	fb.objects = 0
+	fb.mutex.Unlock()
+
+	if len(files) == 0 {
+		return
+	}
+
+	started := time.Now()
+	fileName := fmt.Sprintf("%s-%s-%s.log", appconfig.Instance.ServerName, fb.name, started.UTC().Format(fileBatchTimeLayout))
+	var failed []*schema.ProcessedFile
+	for _, f := range files {
+		f.FileName = fileName
+		uploadStarted := time.Now()
+		if err := fb.uploadFile(f); err != nil {
+			log.Printf("Error uploading file [%s] with %d objects of table [%s] in %s destination: %v. It will be retried with the next rotation",
+				fileName, f.Size(), f.DataSchema.Name, fb.name, err)
+			failed = append(failed, f)
+			continue
+		}
+
+		counters.SuccessEvents(fb.name, f.Size())
+		counters.WriteLatency(fb.name, time.Since(uploadStarted), fileName)
+		webhooks.Fire(webhooks.FileLoaded, map[string]interface{}{"file": fileName, "destination": fb.name, "table": f.DataSchema.Name, "objects": f.Size()})
+	}
+
+	if fb.adaptive != nil {
+		fb.adaptive.Observe(objects, time.Since(started), len(failed) > 0)
+	}
+
+	if len(failed) == 0 {
+		return
+	}
+
+	//merge back not uploaded files
+	fb.mutex.Lock()
+	for _, f := range failed {
+		current, ok := fb.files[f.DataSchema.Name]
+		if ok {
+			f.Merge(current)
+		}
+		fb.files[f.DataSchema.Name] = f
+		fb.objects += f.Size()
+	}
+	fb.mutex.Unlock()
+}
+
+//Close stop rotation goroutine and upload accumulated files
+func (fb *FileBatcher) Close() error {
+	fb.mutex.Lock()
+	if fb.closed {
+		fb.mutex.Unlock()
+		return nil
+	}
+	fb.closed = true
+	fb.mutex.Unlock()
+
+	close(fb.closeCh)
+	fb.Flush()
+
+	return nil
+}
+
+func (fb *FileBatcher) start() {
+	go func() {
+		ticker := time.NewTicker(fb.uploadEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-fb.closeCh:
+				return
+			case <-ticker.C:
+				fb.Flush()
+			case <-fb.flushCh:
+				fb.Flush()
+			}
+		}
+	}()
+}
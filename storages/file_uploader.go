@@ -0,0 +1,125 @@
+package storages
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/compression"
+	"github.com/ksensehq/eventnative/schema"
+	"log"
+	"time"
+)
+
+//fileUploader names processed files objects (with partition path if partitioner is configured)
+//and uploads them via upload func of underlying adapter (s3, gcs)
+//if manifests are configured - table schema manifest is uploaded next to objects
+//objects are NDJSON, CSV (csvEncoder keeps columns order per table between objects) or avro object container files
+//(avroEncoder evolves record schema per table between objects)
+//objects are compressed if compression is configured (object names get .gz or .zst extension). Manifests aren't compressed
+//onPartition is optional and is called after every partition object uploading
+type fileUploader struct {
+	nameTemplate *ObjectNameTemplate
+	partitioner  *Partitioner
+	manifests    *schemaManifests
+	csvEncoder   *csvEncoder
+	avroEncoder  *avroEncoder
+	compression  string
+	location     *time.Location
+	uploadBytes  func(objectName string, payload []byte) error
+	onPartition  func(tableName, partitionPath string) error
+}
+
+func newFileUploader(filesConfig *FilesConfig, uploadBytes func(objectName string, payload []byte) error) *fileUploader {
+	nameTemplate := NewObjectNameTemplate(filesConfig.NameTemplate)
+	var manifests *schemaManifests
+	if filesConfig.SchemaManifest {
+		manifests = newSchemaManifests(nameTemplate.TableDirectory())
+	}
+
+	fu := &fileUploader{
+		nameTemplate: nameTemplate,
+		partitioner:  NewPartitioner(filesConfig.PartitionTemplate, filesConfig.location),
+		manifests:    manifests,
+		compression:  filesConfig.Compression,
+		location:     filesConfig.location,
+		uploadBytes:  uploadBytes,
+	}
+
+	switch filesConfig.Format {
+	case CSVFormat:
+		fu.csvEncoder = newCSVEncoder(filesConfig.CSV)
+	case AvroFormat:
+		fu.avroEncoder = newAvroEncoder(filesConfig.Avro)
+	}
+
+	return fu
+}
+
+//upload processed file as one object or as one object per partition
+func (fu *fileUploader) upload(fdata *schema.ProcessedFile) error {
+	if fu.partitioner == nil {
+		return fu.uploadObject(fu.objectName(fdata, ""), fdata)
+	}
+
+	for path, partition := range fu.partitioner.Split(fdata) {
+		if err := fu.uploadObject(fu.objectName(partition, path), partition); err != nil {
+			return err
+		}
+
+		if fu.onPartition != nil {
+			if err := fu.onPartition(partition.DataSchema.Name, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+//upload object and its schema manifest if it wasn't uploaded to object directory with actual version
+func (fu *fileUploader) uploadObject(objectName string, fdata *schema.ProcessedFile) error {
+	var payload []byte
+	var err error
+	if fu.csvEncoder != nil {
+		if payload, err = fu.csvEncoder.Encode(fdata); err != nil {
+			return fmt.Errorf("Error encoding object %s into CSV: %v", objectName, err)
+		}
+	} else if fu.avroEncoder != nil {
+		if payload, err = fu.avroEncoder.EncodeFile(fdata); err != nil {
+			return fmt.Errorf("Error encoding object %s into avro: %v", objectName, err)
+		}
+	} else {
+		payload = fdata.GetPayloadBytes()
+	}
+
+	if payload, err = compression.Compress(fu.compression, payload); err != nil {
+		return fmt.Errorf("Error compressing object %s: %v", objectName, err)
+	}
+	if err := fu.uploadBytes(objectName, payload); err != nil {
+		return err
+	}
+
+	if fu.manifests == nil {
+		return nil
+	}
+
+	//object has been already uploaded so manifest errors are only logged (manifest will be uploaded with the next object)
+	manifestName, manifestPayload, err := fu.manifests.update(objectName, fdata.DataSchema)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	if manifestName == "" {
+		return nil
+	}
+
+	if err := fu.uploadBytes(manifestName, manifestPayload); err != nil {
+		log.Printf("Error uploading schema manifest %s: %v", manifestName, err)
+		return nil
+	}
+	fu.manifests.markUploaded(manifestName, fdata.DataSchema.Name)
+
+	return nil
+}
+
+func (fu *fileUploader) objectName(fdata *schema.ProcessedFile, partitionPath string) string {
+	return fu.nameTemplate.Name(fdata.FileName, fdata.DataSchema.Name, partitionPath, time.Now().In(fu.location)) + compression.Extension(fu.compression)
+}
@@ -0,0 +1,105 @@
+package storages
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/events"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+//messages of connection errors which are wrapped by adapters without %w (lower cased)
+var unavailableErrMessages = []string{
+	"connection refused",
+	"connection reset",
+	"broken pipe",
+	"no such host",
+	"i/o timeout",
+	"network is unreachable",
+	"no route to host",
+	"bad connection",
+	"unexpected eof",
+	"deadline exceeded",
+	"too many connections",
+}
+
+//stream destinations which last insert has failed after all retries
+var (
+	unhealthyMutex sync.RWMutex
+	unhealthy      = map[string]bool{}
+)
+
+func setHealthy(destinationName string, healthy bool) {
+	unhealthyMutex.RLock()
+	changed := unhealthy[destinationName] == healthy
+	unhealthyMutex.RUnlock()
+	if !changed {
+		return
+	}
+
+	unhealthyMutex.Lock()
+	unhealthy[destinationName] = !healthy
+	unhealthyMutex.Unlock()
+}
+
+func isHealthy(destinationName string) bool {
+	unhealthyMutex.RLock()
+	defer unhealthyMutex.RUnlock()
+
+	return !unhealthy[destinationName]
+}
+
+//isUnavailableErr return true if err means that the storage can't be reached (e.g. connection refused, timeout)
+//only such events are forwarded to peers: other errors (e.g. schema or data ones) would fail on peers too
+func isUnavailableErr(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, adapters.ErrInjectedFault) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, unavailableMsg := range unavailableErrMessages {
+		if strings.Contains(msg, unavailableMsg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//EnqueueForwarded put events which are forwarded by another node into the stream destination queue
+//return error if destination doesn't exist, isn't in stream mode or can't reach its storage too (events are kept by the sender)
+func EnqueueForwarded(destinationName string, facts []events.Fact) error {
+	registry.mutex.RLock()
+	destination, ok := registry.destinations[destinationName]
+	status := registry.statuses[destinationName]
+	registry.mutex.RUnlock()
+	if !ok || status.Mode != streamMode {
+		return fmt.Errorf("Stream destination [%s] wasn't found", destinationName)
+	}
+
+	consumer, ok := destination.(events.Consumer)
+	if !ok {
+		return fmt.Errorf("Destination [%s] doesn't consume events", destinationName)
+	}
+
+	if !isHealthy(destinationName) {
+		return fmt.Errorf("Destination [%s] is unavailable on this node too", destinationName)
+	}
+
+	for _, fact := range facts {
+		consumer.Consume(fact)
+	}
+
+	return nil
+}
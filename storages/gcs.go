@@ -0,0 +1,158 @@
+package storages
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"log"
+)
+
+//Store files to google cloud storage in two modes:
+//batch: (1 file = 1 gcs object per table)
+//stream: via events queue and FileBatcher (objects are rotated into NDJSON files and uploaded periodically)
+type GCS struct {
+	name            string
+	gcsAdapter      *adapters.GoogleCloudStorage
+	uploader        *fileUploader
+	schemaProcessor *schema.Processor
+	eventQueue      *events.PersistentQueue
+	fileBatcher     *FileBatcher
+	breakOnError    bool
+}
+
+func NewGCS(ctx context.Context, name, fallbackDir string, gConfig *adapters.GoogleConfig, filesConfig *FilesConfig, processor *schema.Processor,
+	breakOnError, streamMode bool) (*GCS, error) {
+	gcsAdapter, err := adapters.NewGoogleCloudStorage(ctx, gConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	gcs := &GCS{
+		name:            name,
+		gcsAdapter:      gcsAdapter,
+		uploader:        newFileUploader(filesConfig, gcsAdapter.UploadBytes),
+		schemaProcessor: processor,
+		breakOnError:    breakOnError,
+	}
+
+	if streamMode {
+		gcs.eventQueue, err = newEventQueue(name, fallbackDir)
+		if err != nil {
+			gcsAdapter.Close()
+			return nil, err
+		}
+
+		gcs.fileBatcher = NewFileBatcher(name, filesConfig, gcs.uploader.upload)
+		gcs.startStreamingConsumer()
+	}
+
+	return gcs, nil
+}
+
+//Consume events.Fact and enqueue it
+func (gcs *GCS) Consume(fact events.Fact) {
+	if err := gcs.eventQueue.Enqueue(fact); err != nil {
+		logSkippedEvent(gcs.name, fact, err)
+	}
+}
+
+//QueueSize return count of events in the stream mode queue
+func (gcs *GCS) QueueSize() int {
+	return gcs.eventQueue.Size()
+}
+
+//Run goroutine to:
+//1. read from queue
+//2. put processed object into FileBatcher
+func (gcs *GCS) startStreamingConsumer() {
+	go func() {
+		for {
+			if appstatus.Instance.Idle {
+				break
+			}
+			fact, err := gcs.eventQueue.DequeueBlock()
+			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
+				log.Println("Error reading event fact from gcs queue", err)
+				continue
+			}
+
+			dataSchema, flattenObject, err := gcs.schemaProcessor.ProcessFact(fact)
+			if err != nil {
+				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(gcs.name, 1)
+				continue
+			}
+
+			//don't process empty object
+			if !dataSchema.Exists() {
+				continue
+			}
+
+			gcs.fileBatcher.Add(dataSchema, flattenObject)
+		}
+	}()
+}
+
+//Store file from byte payload to google cloud storage with processing
+func (gcs *GCS) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(gcs.name); err != nil {
+		return err
+	}
+
+	flatData, err := gcs.schemaProcessor.ProcessFilePayload(fileName, payload, gcs.breakOnError, report)
+	if err != nil {
+		return err
+	}
+
+	for _, fdata := range flatData {
+		if err := gcs.uploader.upload(fdata); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (gcs *GCS) Name() string {
+	return gcs.name
+}
+
+func (gcs *GCS) Type() string {
+	return "GCS"
+}
+
+//FlushFiles upload files which are accumulated by stream mode file batcher right away
+func (gcs *GCS) FlushFiles() {
+	if gcs.fileBatcher != nil {
+		gcs.fileBatcher.Flush()
+	}
+}
+
+func (gcs *GCS) Close() (multiErr error) {
+	if gcs.fileBatcher != nil {
+		if err := gcs.fileBatcher.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing gcs file batcher: %v", err))
+		}
+	}
+
+	if gcs.eventQueue != nil {
+		if err := gcs.eventQueue.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing gcs event queue: %v", err))
+		}
+	}
+
+	if err := gcs.gcsAdapter.Close(); err != nil {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing google cloud storage client: %v", err))
+	}
+
+	return
+}
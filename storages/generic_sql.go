@@ -0,0 +1,274 @@
+package storages
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/dbt"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/ksensehq/eventnative/watermarks"
+	"log"
+)
+
+//Store files to SQL database which is described with adapters.SQLDialect (e.g. MSSQL) in two modes:
+//batch: (1 file = 1 transaction)
+//stream: (1 object = 1 transaction)
+type GenericSQL struct {
+	name            string
+	adapter         *adapters.GenericSQL
+	tableHelper     *TableHelper
+	schemaProcessor *schema.Processor
+	eventQueue      *events.PersistentQueue
+	breakOnError    bool
+}
+
+//NewGenericSQL return GenericSQL storage of the dialect database and start goroutines for stream consumer if destination is in stream mode
+func NewGenericSQL(ctx context.Context, name, fallbackDir string, dialect *adapters.SQLDialect, config *adapters.DataSourceConfig,
+	processor *schema.Processor, ddlWriter *DDLWriter, breakOnError, streamMode bool) (*GenericSQL, error) {
+	var eventQueue *events.PersistentQueue
+	if streamMode {
+		var err error
+		eventQueue, err = newEventQueue(name, fallbackDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	adapter, err := adapters.NewGenericSQL(ctx, dialect, config)
+	if err != nil {
+		return nil, err
+	}
+
+	//create db schema if doesn't exist (nothing is created with existing tables or read-only schema)
+	if processor.ExistingTables() == nil && ddlWriter == nil {
+		if err := adapter.CreateDbSchema(config.Schema); err != nil {
+			adapter.Close()
+			return nil, err
+		}
+	}
+
+	g := &GenericSQL{
+		name:            name,
+		adapter:         adapter,
+		tableHelper:     NewTableHelper(adapter, NewMonitorKeeper(), dialect.Name, processor.ExistingTables(), ddlWriter, processor.SchemaEvolution(), processor.ColumnsLimit(), processor.TablesCache(), processor.MetadataCache()),
+		schemaProcessor: processor,
+		eventQueue:      eventQueue,
+		breakOnError:    breakOnError,
+	}
+
+	if streamMode {
+		for i := 0; i < streamWorkers(name); i++ {
+			g.startStreamingConsumer()
+		}
+	}
+
+	return g, nil
+}
+
+//Consume events.Fact and enqueue it
+func (g *GenericSQL) Consume(fact events.Fact) {
+	if err := g.eventQueue.Enqueue(fact); err != nil {
+		logSkippedEvent(g.name, fact, err)
+	}
+}
+
+//QueueSize return count of events in the stream mode queue
+func (g *GenericSQL) QueueSize() int {
+	return g.eventQueue.Size()
+}
+
+//Run goroutine to:
+//1. read from queue
+//2. insert in database
+func (g *GenericSQL) startStreamingConsumer() {
+	go func() {
+		for {
+			if appstatus.Instance.Idle {
+				break
+			}
+			fact, ack, err := g.eventQueue.DequeueBlockAck()
+			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
+				log.Printf("Error reading event fact from %s queue: %v", g.name, err)
+				continue
+			}
+
+			dataSchema, flattenObject, err := g.schemaProcessor.ProcessFact(fact)
+			if err != nil {
+				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(g.name, 1)
+				acknowledge(g.name, ack)
+				continue
+			}
+
+			//don't process empty object
+			if !dataSchema.Exists() {
+				acknowledge(g.name, ack)
+				continue
+			}
+
+			eventTime := g.schemaProcessor.EventTime(dataSchema, flattenObject)
+			if err := insertWithRetry(g.name, fact, ack, func() error { return g.insert(dataSchema, flattenObject) }); err != nil {
+				if err != errForwarded {
+					log.Printf("Error inserting to %s table [%s]: %v", g.Type(), dataSchema.Name, err)
+					counters.ErrorEvents(g.name, 1)
+				}
+				continue
+			}
+
+			counters.SuccessEvents(g.name, 1)
+			watermarks.Commit(g.name, dataSchema.Name, eventTime)
+		}
+	}()
+}
+
+//Store file payload with processing
+func (g *GenericSQL) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(g.name); err != nil {
+		return err
+	}
+
+	flatData, err := g.schemaProcessor.ProcessFilePayload(fileName, payload, g.breakOnError, report)
+	if err != nil {
+		return err
+	}
+	deletions := schema.ExtractDeletions(flatData)
+
+	//process db tables & schema
+	for _, fdata := range flatData {
+		dbSchema, err := g.tableHelper.EnsureTable(fdata.DataSchema, fdata.GetPayload()...)
+		if err != nil {
+			return err
+		}
+
+		if err := g.tableHelper.ApplyDBTyping(g.schemaProcessor, dbSchema, fdata); err != nil {
+			return err
+		}
+	}
+
+	deletions, err = g.tableHelper.PrepareDeletions(g.schemaProcessor, deletions)
+	if err != nil {
+		return err
+	}
+
+	//insert all data and apply deletions after that in one transaction
+	tx, err := g.adapter.OpenTx()
+	if err != nil {
+		return fmt.Errorf("Error opening %s transaction: %v", g.Type(), err)
+	}
+
+	for _, fdata := range flatData {
+		for _, object := range fdata.GetPayload() {
+			if err := g.adapter.InsertInTransaction(tx, fdata.DataSchema, object); err != nil {
+				if g.breakOnError {
+					tx.Rollback()
+					return err
+				}
+				log.Printf("Warn: unable to insert object %v reason: %v. This line will be skipped", object, err)
+				report.Skip(reports.InsertReason, err)
+			}
+		}
+	}
+
+	for _, fdata := range deletions {
+		for _, object := range fdata.GetPayload() {
+			if err := g.adapter.DeleteInTransaction(tx, fdata.DataSchema, object); err != nil {
+				if g.breakOnError {
+					tx.Rollback()
+					return err
+				}
+				log.Printf("Warn: unable to delete object %v reason: %v. This line will be skipped", object, err)
+				report.Skip(reports.DeleteReason, err)
+			}
+		}
+	}
+
+	return tx.DirectCommit()
+}
+
+//insert fact or delete rows with its keys values if it is a deletion event
+func (g *GenericSQL) insert(dataSchema *schema.Table, fact events.Fact) error {
+	if err := injectFault(g.name); err != nil {
+		return err
+	}
+
+	if len(dataSchema.DeletionKeys) > 0 {
+		dbSchema, err := g.tableHelper.DeletionsTable(dataSchema)
+		if err != nil || dbSchema == nil {
+			return err
+		}
+		if err := g.schemaProcessor.ApplyDBTypingToObject(dbSchema, dataSchema, fact); err != nil {
+			return err
+		}
+
+		return g.adapter.Delete(dataSchema, fact)
+	}
+
+	dbSchema, err := g.tableHelper.EnsureTable(dataSchema, fact)
+	if err != nil {
+		return err
+	}
+
+	if err := g.tableHelper.ApplyDBTypingToObject(g.schemaProcessor, dbSchema, dataSchema, fact); err != nil {
+		return err
+	}
+
+	return g.adapter.Insert(dataSchema, fact)
+}
+
+//Close adapters.GenericSQL and queue
+func (g *GenericSQL) Close() (multiErr error) {
+	if err := g.adapter.Close(); err != nil {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing %s datasource: %v", g.Type(), err))
+	}
+
+	if g.eventQueue != nil {
+		if err := g.eventQueue.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing %s event queue: %v", g.Type(), err))
+		}
+	}
+
+	return
+}
+
+//Tables return tables schemas known by the destination
+func (g *GenericSQL) Tables() []*schema.Table {
+	return g.tableHelper.Tables()
+}
+
+//DropColumnDDL return statements which drop the column of the destination table
+func (g *GenericSQL) DropColumnDDL(tableName, columnName string) ([]string, error) {
+	return g.tableHelper.DropColumnDDL(tableName, columnName)
+}
+
+//DropColumn drop the column of the destination table
+func (g *GenericSQL) DropColumn(tableName, columnName string) error {
+	return g.tableHelper.DropColumn(tableName, columnName)
+}
+
+//DbtSource return dbt source of tables known by the destination
+func (g *GenericSQL) DbtSource(freshness *dbt.Freshness) *dbt.Source {
+	database, schemaName := g.adapter.Location()
+	return dbt.NewSource(g.name, database, schemaName, g.Tables(), g.schemaProcessor.SystemColumn(timestamp.Key), g.schemaProcessor.ColumnDescription, freshness)
+}
+
+func (g *GenericSQL) Name() string {
+	return g.name
+}
+
+func (g *GenericSQL) Type() string {
+	return g.adapter.Name()
+}
+
+//Transactional return true: all tables of a file and its deletions are stored in one transaction
+func (g *GenericSQL) Transactional() bool {
+	return true
+}
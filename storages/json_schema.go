@@ -0,0 +1,153 @@
+package storages
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/jsonschema"
+	"github.com/ksensehq/eventnative/reports"
+	"log"
+	"path"
+)
+
+const rejectsDir = "rejects"
+
+//jsonSchemaGate validates destination events against the destination json_schema (path to JSON Schema file)
+//batch mode: invalid events are written into $log.path/rejects/$destination.log as {"error": .., "event": ..} lines
+//stream mode: invalid events are logged with validation error and counted as skipped
+type jsonSchemaGate struct {
+	name    string
+	schema  *jsonschema.Schema
+	rejects *DeadLetter
+}
+
+//return err if json_schema file can't be loaded
+func validateJSONSchema(destination *DestinationConfig) error {
+	if destination.JSONSchema == "" {
+		return nil
+	}
+
+	_, err := jsonschema.Load(destination.JSONSchema)
+	return err
+}
+
+//return jsonSchemaGate or nil if json_schema isn't configured
+func newJSONSchemaGate(name, logEventPath string, destination *DestinationConfig) (*jsonSchemaGate, error) {
+	if destination.JSONSchema == "" {
+		return nil, nil
+	}
+
+	schema, err := jsonschema.Load(destination.JSONSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	gate := &jsonSchemaGate{name: name, schema: schema}
+	if destination.Mode != streamMode {
+		if gate.rejects, err = NewDeadLetter(path.Join(logEventPath, rejectsDir), name); err != nil {
+			return nil, err
+		}
+	}
+
+	return gate, nil
+}
+
+//ValidatingConsumer passes into the stream destination only events which match its JSON schema
+type ValidatingConsumer struct {
+	events.Consumer
+	gate *jsonSchemaGate
+}
+
+//return consumer as is if json_schema isn't configured
+func newValidatingConsumer(consumer events.Consumer, gate *jsonSchemaGate) events.Consumer {
+	if gate == nil {
+		return consumer
+	}
+
+	return &ValidatingConsumer{Consumer: consumer, gate: gate}
+}
+
+//Consume events.Fact if it matches the schema
+func (vc *ValidatingConsumer) Consume(fact events.Fact) {
+	if err := vc.gate.schema.Validate(fact); err != nil {
+		log.Printf("[%s] Event doesn't match JSON schema and has been skipped: %v", vc.gate.name, err)
+		counters.SkippedEvents(vc.gate.name, 1)
+		return
+	}
+
+	vc.Consumer.Consume(fact)
+}
+
+//ValidatingStorage passes into the batch destination only lines of event log file which match its JSON schema
+//invalid lines are written into rejects file (only after the valid ones have been stored: failed files are retried
+//with the same invalid lines) and skipped in the load report
+//lines which aren't JSON are passed as is: the destination reports them as malformed
+type ValidatingStorage struct {
+	events.Storage
+	gate *jsonSchemaGate
+}
+
+//return storage as is if json_schema isn't configured
+func newValidatingStorage(storage events.Storage, gate *jsonSchemaGate) events.Storage {
+	if gate == nil {
+		return storage
+	}
+
+	return &ValidatingStorage{Storage: storage, gate: gate}
+}
+
+//Transactional return true if the wrapped storage is transactional (see events.TransactionalStorage)
+func (vs *ValidatingStorage) Transactional() bool {
+	return events.IsTransactional(vs.Storage)
+}
+
+//Store valid lines of payload and write invalid ones into rejects file if it is succeeded. Nothing is stored if all lines are invalid
+func (vs *ValidatingStorage) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	valid := &bytes.Buffer{}
+	var invalid []events.Fact
+	reader := bufio.NewReaderSize(bytes.NewReader(payload), 64*1024)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			if err := vs.validate(trimmed); err != nil {
+				report.Skip(reports.SchemaReason, err)
+				invalid = append(invalid, events.Fact{"error": err.Error(), "event": json.RawMessage(trimmed)})
+			} else {
+				valid.Write(trimmed)
+				valid.WriteByte('\n')
+			}
+		}
+		if readErr != nil {
+			break
+		}
+	}
+
+	if valid.Len() > 0 {
+		if err := vs.Storage.Store(fileName, valid.Bytes(), report); err != nil {
+			return err
+		}
+	}
+
+	vs.reject(invalid)
+	return nil
+}
+
+func (vs *ValidatingStorage) validate(line []byte) error {
+	fact := map[string]interface{}{}
+	if err := json.Unmarshal(line, &fact); err != nil {
+		return nil
+	}
+
+	return vs.gate.schema.Validate(fact)
+}
+
+//write invalid lines ({"error": .., "event": ..}) into rejects file
+func (vs *ValidatingStorage) reject(invalid []events.Fact) {
+	for _, fact := range invalid {
+		if err := vs.gate.rejects.Write(fact); err != nil {
+			log.Printf("[%s] Error writing event into rejects file: %v", vs.gate.name, err)
+		}
+	}
+}
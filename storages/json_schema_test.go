@@ -0,0 +1,94 @@
+package storages
+
+import (
+	"errors"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+type storageMock struct {
+	payload []byte
+	err     error
+}
+
+func (sm *storageMock) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if sm.err != nil {
+		return sm.err
+	}
+	sm.payload = payload
+	return nil
+}
+
+func (sm *storageMock) Name() string {
+	return "schema_test"
+}
+
+func (sm *storageMock) Type() string {
+	return "mock"
+}
+
+func (sm *storageMock) Close() error {
+	return nil
+}
+
+func TestJSONSchemaGate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "json_schema")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	schemaPath := path.Join(dir, "schema.json")
+	require.NoError(t, ioutil.WriteFile(schemaPath, []byte(`{"type": "object", "required": ["event_type"], "properties": {"event_type": {"type": "string"}}}`), 0644))
+
+	require.NoError(t, validateJSONSchema(&DestinationConfig{}))
+	require.Error(t, validateJSONSchema(&DestinationConfig{JSONSchema: path.Join(dir, "unknown.json")}))
+
+	gate, err := newJSONSchemaGate("schema_test", dir, &DestinationConfig{Mode: streamMode})
+	require.NoError(t, err)
+	require.Nil(t, gate)
+
+	//stream mode
+	gate, err = newJSONSchemaGate("schema_test", dir, &DestinationConfig{Mode: streamMode, JSONSchema: schemaPath})
+	require.NoError(t, err)
+	require.Nil(t, gate.rejects)
+
+	consumer := &consumerMock{}
+	validating := newValidatingConsumer(consumer, gate)
+	validating.Consume(events.Fact{"event_type": "pageview"})
+	validating.Consume(events.Fact{"event_type": 1.0})
+	require.Equal(t, []events.Fact{{"event_type": "pageview"}}, consumer.consumed)
+
+	//batch mode
+	gate, err = newJSONSchemaGate("schema_test", dir, &DestinationConfig{Mode: batchMode, JSONSchema: schemaPath})
+	require.NoError(t, err)
+
+	payload := []byte("{\"event_type\":\"click\"}\n{\"id\":1}\nmalformed\n{\"event_type\":\"pageview\"}")
+	rejectsPath := path.Join(dir, rejectsDir, "schema_test.log")
+
+	//rejects aren't written if the file isn't stored: they would be duplicated by retries of the file
+	storage := &storageMock{err: errors.New("connection refused")}
+	report := reports.NewLoadReport("file.log", "schema_test", "token", 4)
+	err = newValidatingStorage(storage, gate).Store("file.log", payload, report)
+	require.EqualError(t, err, "connection refused")
+	rejects, err := ioutil.ReadFile(rejectsPath)
+	if !os.IsNotExist(err) {
+		require.NoError(t, err)
+		require.Empty(t, rejects)
+	}
+
+	storage.err = nil
+	report = reports.NewLoadReport("file.log", "schema_test", "token", 4)
+	err = newValidatingStorage(storage, gate).Store("file.log", payload, report)
+	require.NoError(t, err)
+	require.Equal(t, "{\"event_type\":\"click\"}\nmalformed\n{\"event_type\":\"pageview\"}\n", string(storage.payload))
+	require.Equal(t, 1, report.Skipped)
+	require.Equal(t, map[string]int{reports.SchemaReason: 1}, report.Reasons)
+
+	rejects, err = ioutil.ReadFile(rejectsPath)
+	require.NoError(t, err)
+	require.Equal(t, "{\"error\":\"/: required property [event_type] is missing\",\"event\":{\"id\":1}}\n", string(rejects))
+}
@@ -0,0 +1,276 @@
+package storages
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"log"
+	"strings"
+)
+
+const kafkaTablePlaceholder = "{table}"
+
+//flattened event fields which are used as partition_key aliases
+var kafkaPartitionKeyAliases = map[string]string{
+	"event_id": "eventn_ctx_event_id",
+	"user_id":  "eventn_ctx_user_anonymous_id",
+}
+
+//Publish processed events to Kafka topics in two modes:
+//batch: (1 file = 1 batch of messages)
+//stream: via events queue in stream mode (1 object = 1 message)
+//topic name is built from topic template and table name
+//messages are JSON, avro (avro schema of topic-value subject is registered in schema registry)
+//or protobuf ones (message of event type from configured descriptor set)
+type Kafka struct {
+	name            string
+	kafkaAdapter    *adapters.Kafka
+	topicTemplate   string
+	partitionKey    string
+	avroEncoder     *avroEncoder
+	protobufEncoder *protobufEncoder
+	schemaProcessor *schema.Processor
+	eventQueue      *events.PersistentQueue
+	breakOnError    bool
+}
+
+//NewKafka return Kafka and start goroutine for stream consumer if destination is in stream mode
+func NewKafka(name, fallbackDir string, config *adapters.KafkaConfig, processor *schema.Processor, breakOnError, streamMode bool) (*Kafka, error) {
+	kafkaAdapter, err := adapters.NewKafka(config)
+	if err != nil {
+		return nil, err
+	}
+
+	partitionKey := config.PartitionKey
+	if field, ok := kafkaPartitionKeyAliases[partitionKey]; ok {
+		partitionKey = field
+	}
+
+	k := &Kafka{
+		name:            name,
+		kafkaAdapter:    kafkaAdapter,
+		topicTemplate:   config.TopicTemplate,
+		partitionKey:    partitionKey,
+		schemaProcessor: processor,
+		breakOnError:    breakOnError,
+	}
+	switch config.Format {
+	case adapters.KafkaAvroFormat:
+		k.avroEncoder = newAvroEncoder(config.Avro)
+	case adapters.KafkaProtobufFormat:
+		if k.protobufEncoder, err = newProtobufEncoder(config.Protobuf); err != nil {
+			kafkaAdapter.Close()
+			return nil, err
+		}
+	}
+
+	if streamMode {
+		k.eventQueue, err = newEventQueue(name, fallbackDir)
+		if err != nil {
+			kafkaAdapter.Close()
+			return nil, err
+		}
+
+		k.startStreamingConsumer()
+	}
+
+	return k, nil
+}
+
+//Consume events.Fact and enqueue it
+func (k *Kafka) Consume(fact events.Fact) {
+	if err := k.eventQueue.Enqueue(fact); err != nil {
+		logSkippedEvent(k.name, fact, err)
+	}
+}
+
+//QueueSize return count of events in the stream mode queue
+func (k *Kafka) QueueSize() int {
+	return k.eventQueue.Size()
+}
+
+//Run goroutine to:
+//1. read from queue
+//2. publish to Kafka
+func (k *Kafka) startStreamingConsumer() {
+	go func() {
+		for {
+			if appstatus.Instance.Idle {
+				break
+			}
+			fact, ack, err := k.eventQueue.DequeueBlockAck()
+			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
+				log.Println("Error reading event fact from kafka queue", err)
+				continue
+			}
+
+			dataSchema, flattenObject, err := k.schemaProcessor.ProcessFact(fact)
+			if err != nil {
+				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(k.name, 1)
+				acknowledge(k.name, ack)
+				continue
+			}
+
+			//don't process empty object
+			if !dataSchema.Exists() {
+				acknowledge(k.name, ack)
+				continue
+			}
+
+			var message *adapters.KafkaMessage
+			if len(dataSchema.DeletionKeys) > 0 {
+				message = k.toTombstone(dataSchema, flattenObject)
+			} else {
+				message, err = k.toMessage(dataSchema.Name, flattenObject)
+			}
+			if err != nil {
+				log.Printf("Unable to serialize object %v: %v", flattenObject, err)
+				counters.ErrorEvents(k.name, 1)
+				acknowledge(k.name, ack)
+				continue
+			}
+
+			if err := insertWithRetry(k.name, fact, ack, func() error { return k.send(message) }); err != nil {
+				if err != errForwarded {
+					log.Printf("Error publishing to kafka topic [%s]: %v", message.Topic, err)
+					counters.ErrorEvents(k.name, 1)
+				}
+				continue
+			}
+
+			counters.SuccessEvents(k.name, 1)
+		}
+	}()
+}
+
+//send message to Kafka (with fault injection if it is configured)
+func (k *Kafka) send(message *adapters.KafkaMessage) error {
+	if err := injectFault(k.name); err != nil {
+		return err
+	}
+
+	return k.kafkaAdapter.Send(message)
+}
+
+//Store file payload to Kafka with processing: every table file is published as a batch of messages
+func (k *Kafka) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(k.name); err != nil {
+		return err
+	}
+
+	flatData, err := k.schemaProcessor.ProcessFilePayload(fileName, payload, k.breakOnError, report)
+	if err != nil {
+		return err
+	}
+	deletions := schema.ExtractDeletions(flatData)
+
+	for _, fdata := range flatData {
+		var messages []*adapters.KafkaMessage
+		for _, object := range fdata.GetPayload() {
+			message, err := k.toMessage(fdata.DataSchema.Name, object)
+			if err != nil {
+				if k.breakOnError {
+					return err
+				}
+				log.Printf("Warn: unable to serialize object %v from file %s: %v", object, fileName, err)
+				report.Skip(reports.ConversionReason, err)
+				continue
+			}
+			messages = append(messages, message)
+		}
+
+		if len(messages) == 0 {
+			continue
+		}
+
+		if err := k.kafkaAdapter.SendBatch(messages); err != nil {
+			return err
+		}
+	}
+
+	//tombstones are published after all file messages
+	for _, fdata := range deletions {
+		var tombstones []*adapters.KafkaMessage
+		for _, object := range fdata.GetPayload() {
+			tombstones = append(tombstones, k.toTombstone(fdata.DataSchema, object))
+		}
+
+		if err := k.kafkaAdapter.SendBatch(tombstones); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//return message with templated topic, partition key value (or empty) and json, avro or protobuf payload
+func (k *Kafka) toMessage(tableName string, object map[string]interface{}) (*adapters.KafkaMessage, error) {
+	topic := strings.ReplaceAll(k.topicTemplate, kafkaTablePlaceholder, tableName)
+
+	var payload []byte
+	var err error
+	if k.avroEncoder != nil {
+		payload, err = k.avroEncoder.EncodeMessage(topic+avroSubjectSuffix, tableName, object)
+	} else if k.protobufEncoder != nil {
+		payload, err = k.protobufEncoder.Encode(object)
+	} else {
+		payload, err = json.Marshal(object)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var key string
+	if k.partitionKey != "" {
+		if value, ok := object[k.partitionKey]; ok && value != nil {
+			key = fmt.Sprint(value)
+		}
+	}
+
+	return &adapters.KafkaMessage{
+		Topic:   topic,
+		Key:     key,
+		Payload: payload,
+	}, nil
+}
+
+//return tombstone message (without payload) with templated topic and deletion key value as message key
+//so log compaction removes all messages with the key from the topic
+func (k *Kafka) toTombstone(table *schema.Table, object map[string]interface{}) *adapters.KafkaMessage {
+	return &adapters.KafkaMessage{
+		Topic: strings.ReplaceAll(k.topicTemplate, kafkaTablePlaceholder, table.Name),
+		Key:   fmt.Sprint(object[table.DeletionKeys[0]]),
+	}
+}
+
+func (k *Kafka) Name() string {
+	return k.name
+}
+
+func (k *Kafka) Type() string {
+	return k.kafkaAdapter.Name()
+}
+
+func (k *Kafka) Close() (multiErr error) {
+	if k.eventQueue != nil {
+		if err := k.eventQueue.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing kafka event queue: %v", err))
+		}
+	}
+
+	if err := k.kafkaAdapter.Close(); err != nil {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing kafka producer: %v", err))
+	}
+
+	return
+}
@@ -0,0 +1,76 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestKafkaToMessage(t *testing.T) {
+	tests := []struct {
+		name          string
+		topicTemplate string
+		partitionKey  string
+		object        map[string]interface{}
+		expectedTopic string
+		expectedKey   string
+	}{
+		{
+			"Random partition",
+			"{table}",
+			"",
+			map[string]interface{}{"eventn_ctx_event_id": "1"},
+			"events",
+			"",
+		},
+		{
+			"Event id key",
+			"eventnative_{table}",
+			"eventn_ctx_event_id",
+			map[string]interface{}{"eventn_ctx_event_id": "1"},
+			"eventnative_events",
+			"1",
+		},
+		{
+			"Missing key field",
+			"constant",
+			"eventn_ctx_user_anonymous_id",
+			map[string]interface{}{"eventn_ctx_event_id": "1"},
+			"constant",
+			"",
+		},
+		{
+			"Number key field",
+			"{table}",
+			"user_id",
+			map[string]interface{}{"user_id": 15},
+			"events",
+			"15",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &Kafka{topicTemplate: tt.topicTemplate, partitionKey: tt.partitionKey}
+			message, err := k.toMessage("events", tt.object)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedTopic, message.Topic)
+			require.Equal(t, tt.expectedKey, message.Key)
+		})
+	}
+}
+
+func TestKafkaToMessageUnicode(t *testing.T) {
+	k := &Kafka{topicTemplate: "{table}", partitionKey: "user_id"}
+	message, err := k.toMessage("events", map[string]interface{}{"user_id": "👤1", "title": "Привет 👋🏽"})
+	require.NoError(t, err)
+	require.Equal(t, "👤1", message.Key)
+	require.Equal(t, `{"title":"Привет 👋🏽","user_id":"👤1"}`, string(message.Payload))
+}
+
+func TestKafkaToTombstone(t *testing.T) {
+	k := &Kafka{topicTemplate: "eventnative_{table}", partitionKey: "eventn_ctx_event_id"}
+	message := k.toTombstone(&schema.Table{Name: "users", DeletionKeys: []string{"user_id"}}, map[string]interface{}{"user_id": 15})
+	require.Equal(t, "eventnative_users", message.Topic)
+	require.Equal(t, "15", message.Key)
+	require.Nil(t, message.Payload)
+}
@@ -0,0 +1,177 @@
+package storages
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-multierror"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"log"
+)
+
+//Put processed events to aws Kinesis data stream in two modes:
+//batch: (1 file = PutRecords requests with all file objects)
+//stream: via events queue and FileBatcher (objects are accumulated and put every flush_every or by 500 records)
+type Kinesis struct {
+	name            string
+	kinesisAdapter  *adapters.Kinesis
+	partitionKey    string
+	schemaProcessor *schema.Processor
+	eventQueue      *events.PersistentQueue
+	batcher         *FileBatcher
+	breakOnError    bool
+}
+
+//NewKinesis return Kinesis and start goroutine for stream consumer if destination is in stream mode
+func NewKinesis(name, fallbackDir string, config *adapters.KinesisConfig, processor *schema.Processor, breakOnError, streamMode bool) (*Kinesis, error) {
+	kinesisAdapter, err := adapters.NewKinesis(config)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &Kinesis{
+		name:            name,
+		kinesisAdapter:  kinesisAdapter,
+		partitionKey:    config.PartitionKey,
+		schemaProcessor: processor,
+		breakOnError:    breakOnError,
+	}
+
+	if streamMode {
+		k.eventQueue, err = newEventQueue(name, fallbackDir)
+		if err != nil {
+			return nil, err
+		}
+
+		k.batcher = NewFileBatcher(name, &FilesConfig{UploadEvery: config.FlushEvery, MaxObjects: adapters.KinesisMaxBatchRecords}, k.put)
+		k.startStreamingConsumer()
+	}
+
+	return k, nil
+}
+
+//Consume events.Fact and enqueue it
+func (k *Kinesis) Consume(fact events.Fact) {
+	if err := k.eventQueue.Enqueue(fact); err != nil {
+		logSkippedEvent(k.name, fact, err)
+	}
+}
+
+//QueueSize return count of events in the stream mode queue
+func (k *Kinesis) QueueSize() int {
+	return k.eventQueue.Size()
+}
+
+//Run goroutine to:
+//1. read from queue
+//2. put processed object into batcher
+func (k *Kinesis) startStreamingConsumer() {
+	go func() {
+		for {
+			if appstatus.Instance.Idle {
+				break
+			}
+			fact, err := k.eventQueue.DequeueBlock()
+			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
+				log.Println("Error reading event fact from kinesis queue", err)
+				continue
+			}
+
+			dataSchema, flattenObject, err := k.schemaProcessor.ProcessFact(fact)
+			if err != nil {
+				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(k.name, 1)
+				continue
+			}
+
+			//don't process empty object
+			if !dataSchema.Exists() {
+				continue
+			}
+
+			k.batcher.Add(dataSchema, flattenObject)
+		}
+	}()
+}
+
+//Store file payload to Kinesis with processing
+func (k *Kinesis) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(k.name); err != nil {
+		return err
+	}
+
+	flatData, err := k.schemaProcessor.ProcessFilePayload(fileName, payload, k.breakOnError, report)
+	if err != nil {
+		return err
+	}
+
+	for _, fdata := range flatData {
+		if err := k.put(fdata); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//put all processed file objects as json records
+func (k *Kinesis) put(fdata *schema.ProcessedFile) error {
+	var records []*adapters.KinesisRecord
+	for _, object := range fdata.GetPayload() {
+		data, err := json.Marshal(object)
+		if err != nil {
+			log.Printf("Warn: unable to serialize object %v: %v", object, err)
+			fdata.Report.Skip(reports.ConversionReason, err)
+			continue
+		}
+
+		records = append(records, &adapters.KinesisRecord{PartitionKey: k.recordPartitionKey(object), Data: data})
+	}
+
+	return k.kinesisAdapter.PutRecords(records)
+}
+
+//return partition key field value or random one
+func (k *Kinesis) recordPartitionKey(object map[string]interface{}) string {
+	if k.partitionKey != "" {
+		if value, ok := object[k.partitionKey]; ok && value != nil {
+			if key := fmt.Sprint(value); key != "" {
+				return key
+			}
+		}
+	}
+
+	return uuid.New().String()
+}
+
+func (k *Kinesis) Name() string {
+	return k.name
+}
+
+func (k *Kinesis) Type() string {
+	return k.kinesisAdapter.Name()
+}
+
+func (k *Kinesis) Close() (multiErr error) {
+	if k.batcher != nil {
+		if err := k.batcher.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing kinesis batcher: %v", err))
+		}
+	}
+
+	if k.eventQueue != nil {
+		if err := k.eventQueue.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing kinesis event queue: %v", err))
+		}
+	}
+
+	return
+}
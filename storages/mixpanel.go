@@ -0,0 +1,245 @@
+package storages
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/ksensehq/eventnative/typing"
+	"log"
+	"regexp"
+	"time"
+)
+
+const (
+	defaultMixpanelDistinctIDField = "eventn_ctx_user_anonymous_id"
+	defaultMixpanelEventField      = "event_type"
+
+	//Mixpanel deduplicates events by $insert_id
+	mixpanelInsertIDField = "eventn_ctx_event_id"
+)
+
+//Mixpanel $insert_id must be alphanumeric with dashes up to 36 characters
+var mixpanelInsertIDRegexp = regexp.MustCompile("^[a-zA-Z0-9-]{1,36}$")
+
+//Send processed events to Mixpanel API in two modes:
+//batch: (1 file = requests with all file events by batch_size events)
+//stream: via events queue and FileBatcher (events are accumulated and sent every flush_every or by batch_size events)
+//events without event name or distinct_id are skipped
+type Mixpanel struct {
+	name            string
+	mixpanelAdapter *adapters.Mixpanel
+	distinctIDField string
+	eventField      string
+	batchSize       int
+	schemaProcessor *schema.Processor
+	eventQueue      *events.PersistentQueue
+	batcher         *FileBatcher
+	breakOnError    bool
+}
+
+//NewMixpanel return Mixpanel and start goroutine for stream consumer if destination is in stream mode
+func NewMixpanel(name, fallbackDir string, config *adapters.MixpanelConfig, processor *schema.Processor, breakOnError, streamMode bool) (*Mixpanel, error) {
+	mixpanelAdapter := adapters.NewMixpanel(config)
+
+	m := &Mixpanel{
+		name:            name,
+		mixpanelAdapter: mixpanelAdapter,
+		distinctIDField: config.DistinctIDField,
+		eventField:      config.EventField,
+		batchSize:       config.BatchSize,
+		schemaProcessor: processor,
+		breakOnError:    breakOnError,
+	}
+
+	if streamMode {
+		var err error
+		m.eventQueue, err = newEventQueue(name, fallbackDir)
+		if err != nil {
+			mixpanelAdapter.Close()
+			return nil, err
+		}
+
+		m.batcher = NewFileBatcher(name, &FilesConfig{UploadEvery: config.FlushEvery, MaxObjects: config.BatchSize}, m.send)
+		m.startStreamingConsumer()
+	}
+
+	return m, nil
+}
+
+//Consume events.Fact and enqueue it
+func (m *Mixpanel) Consume(fact events.Fact) {
+	if err := m.eventQueue.Enqueue(fact); err != nil {
+		logSkippedEvent(m.name, fact, err)
+	}
+}
+
+//QueueSize return count of events in the stream mode queue
+func (m *Mixpanel) QueueSize() int {
+	return m.eventQueue.Size()
+}
+
+//Run goroutine to:
+//1. read from queue
+//2. put processed object into batcher if it can be sent to Mixpanel
+func (m *Mixpanel) startStreamingConsumer() {
+	go func() {
+		for {
+			if appstatus.Instance.Idle {
+				break
+			}
+			fact, err := m.eventQueue.DequeueBlock()
+			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
+				log.Println("Error reading event fact from mixpanel queue", err)
+				continue
+			}
+
+			dataSchema, flattenObject, err := m.schemaProcessor.ProcessFact(fact)
+			if err != nil {
+				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(m.name, 1)
+				continue
+			}
+
+			//don't process empty object
+			if !dataSchema.Exists() {
+				continue
+			}
+
+			if _, err := m.toEvent(flattenObject); err != nil {
+				log.Printf("Unable to convert object %v to mixpanel event: %v", flattenObject, err)
+				counters.ErrorEvents(m.name, 1)
+				continue
+			}
+
+			m.batcher.Add(dataSchema, flattenObject)
+		}
+	}()
+}
+
+//Store file payload to Mixpanel with processing
+func (m *Mixpanel) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(m.name); err != nil {
+		return err
+	}
+
+	flatData, err := m.schemaProcessor.ProcessFilePayload(fileName, payload, m.breakOnError, report)
+	if err != nil {
+		return err
+	}
+
+	for _, fdata := range flatData {
+		if err := m.send(fdata); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//send all file objects as Mixpanel events with requests by batchSize events
+func (m *Mixpanel) send(fdata *schema.ProcessedFile) error {
+	var batch []*adapters.MixpanelEvent
+	for _, object := range fdata.GetPayload() {
+		event, err := m.toEvent(object)
+		if err != nil {
+			if m.breakOnError {
+				return err
+			}
+			log.Printf("Warn: unable to convert object %v from file %s to mixpanel event: %v", object, fdata.FileName, err)
+			fdata.Report.Skip(reports.ConversionReason, err)
+			continue
+		}
+
+		batch = append(batch, event)
+		if len(batch) == m.batchSize {
+			if err := m.mixpanelAdapter.Send(batch); err != nil {
+				return err
+			}
+			batch = nil
+		}
+	}
+
+	return m.mixpanelAdapter.Send(batch)
+}
+
+//return Mixpanel event with mapped event name and properties: distinct_id, time (from object timestamp or current time),
+//$insert_id (event id or hash of the object if event id isn't valid Mixpanel insert id) and other fields
+func (m *Mixpanel) toEvent(object map[string]interface{}) (*adapters.MixpanelEvent, error) {
+	eventName := stringField(object, m.eventField)
+	if eventName == "" {
+		return nil, fmt.Errorf("%s field is required", m.eventField)
+	}
+	distinctID := stringField(object, m.distinctIDField)
+	if distinctID == "" {
+		return nil, fmt.Errorf("%s field is required", m.distinctIDField)
+	}
+
+	insertID := stringField(object, mixpanelInsertIDField)
+	if !mixpanelInsertIDRegexp.MatchString(insertID) {
+		//json.Marshal sorts map keys, so the hash is the same on retries
+		b, err := json.Marshal(object)
+		if err != nil {
+			return nil, err
+		}
+		insertID = fmt.Sprintf("%x", md5.Sum(b))
+	}
+
+	properties := map[string]interface{}{}
+	for name, value := range object {
+		switch name {
+		case m.distinctIDField, m.eventField, mixpanelInsertIDField, timestamp.Key:
+		default:
+			properties[name] = value
+		}
+	}
+
+	properties["distinct_id"] = distinctID
+	properties["$insert_id"] = insertID
+	properties["time"] = time.Now().UTC().UnixNano() / int64(time.Millisecond)
+	if value, ok := object[timestamp.Key]; ok && value != nil {
+		if converted, err := typing.Convert(typing.TIMESTAMP, value); err == nil {
+			properties["time"] = converted.(time.Time).UnixNano() / int64(time.Millisecond)
+		}
+	}
+
+	return &adapters.MixpanelEvent{Event: eventName, Properties: properties}, nil
+}
+
+func (m *Mixpanel) Name() string {
+	return m.name
+}
+
+func (m *Mixpanel) Type() string {
+	return m.mixpanelAdapter.Name()
+}
+
+func (m *Mixpanel) Close() (multiErr error) {
+	if m.batcher != nil {
+		if err := m.batcher.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing mixpanel batcher: %v", err))
+		}
+	}
+
+	if m.eventQueue != nil {
+		if err := m.eventQueue.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing mixpanel event queue: %v", err))
+		}
+	}
+
+	if err := m.mixpanelAdapter.Close(); err != nil {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing mixpanel client: %v", err))
+	}
+
+	return
+}
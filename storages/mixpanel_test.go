@@ -0,0 +1,55 @@
+package storages
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMixpanelToEvent(t *testing.T) {
+	m := &Mixpanel{distinctIDField: "eventn_ctx_user_anonymous_id", eventField: "event_type"}
+	tests := []struct {
+		name          string
+		object        map[string]interface{}
+		expectedProps map[string]interface{}
+		expectedErr   string
+	}{
+		{
+			"All fields",
+			map[string]interface{}{"eventn_ctx_user_anonymous_id": "anonym1", "event_type": "pageview",
+				"eventn_ctx_event_id": "6f1a3b2c-0d4e-4f5a-8b6c-7d8e9f0a1b2c", "_timestamp": "2020-06-16T23:00:00.000000Z", "page_title": "Привет"},
+			map[string]interface{}{"distinct_id": "anonym1", "$insert_id": "6f1a3b2c-0d4e-4f5a-8b6c-7d8e9f0a1b2c", "time": int64(1592348400000), "page_title": "Привет"},
+			"",
+		},
+		{
+			"Hash insert id",
+			map[string]interface{}{"eventn_ctx_user_anonymous_id": "anonym1", "event_type": "pageview",
+				"eventn_ctx_event_id": "id with spaces", "_timestamp": "2020-06-16T23:00:00.000000Z"},
+			map[string]interface{}{"distinct_id": "anonym1", "$insert_id": "895c894d79c89d6756437796c4ceaa69", "time": int64(1592348400000)},
+			"",
+		},
+		{
+			"Without distinct id",
+			map[string]interface{}{"event_type": "pageview"},
+			nil,
+			"eventn_ctx_user_anonymous_id field is required",
+		},
+		{
+			"Without event name",
+			map[string]interface{}{"eventn_ctx_user_anonymous_id": "anonym1"},
+			nil,
+			"event_type field is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := m.toEvent(tt.object)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "pageview", event.Event)
+			require.Equal(t, tt.expectedProps, event.Properties)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package storages
+
+import (
+	"github.com/google/uuid"
+	"sync"
+	"time"
+)
+
+//TTLModifier is implemented by destinations which apply configured TTL to existing tables explicitly with admin API
+//(ClickHouse deletes already expired rows on modifying TTL)
+type TTLModifier interface {
+	ModifyTTLDDL(tableName string) ([]string, error)
+	ModifyTTL(tableName string) error
+}
+
+//TTLModification is a planned (with confirmation token) or executed (modified is true) TTL modification of the destination table
+//confirmation token is returned by PlanModifyTTL and is required by ModifyTTL
+type TTLModification struct {
+	Destination       string     `json:"destination"`
+	Table             string     `json:"table"`
+	Statements        []string   `json:"statements"`
+	ConfirmationToken string     `json:"confirmation_token,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	Modified          bool       `json:"modified"`
+}
+
+var (
+	//confirmation token: planned TTL modification
+	plannedTTLModifications      = map[string]*TTLModification{}
+	plannedTTLModificationsMutex sync.Mutex
+)
+
+//PlanModifyTTL return statements which apply TTL to the destination table and one-time confirmation token
+//which expires after confirmationTokenTTL. Nothing is changed in the destination
+//return false if the destination isn't running or doesn't support TTL
+func PlanModifyTTL(destinationName, tableName string) (*TTLModification, bool, error) {
+	modifier, ok := getTTLModifier(destinationName)
+	if !ok {
+		return nil, false, nil
+	}
+
+	statements, err := modifier.ModifyTTLDDL(tableName)
+	if err != nil {
+		return nil, true, err
+	}
+
+	expiresAt := time.Now().UTC().Add(confirmationTokenTTL)
+	modification := &TTLModification{Destination: destinationName, Table: tableName, Statements: statements,
+		ConfirmationToken: uuid.New().String(), ExpiresAt: &expiresAt}
+
+	plannedTTLModificationsMutex.Lock()
+	for token, planned := range plannedTTLModifications {
+		if time.Now().After(*planned.ExpiresAt) {
+			delete(plannedTTLModifications, token)
+		}
+	}
+	plannedTTLModifications[modification.ConfirmationToken] = modification
+	plannedTTLModificationsMutex.Unlock()
+
+	return modification, true, nil
+}
+
+//ModifyTTL apply TTL to the destination table if confirmation token of the same planned modification is provided
+//the token is used once: it is invalidated even if modifying fails
+//return false if the destination isn't running or doesn't support TTL
+func ModifyTTL(destinationName, tableName, confirmationToken string) (*TTLModification, bool, error) {
+	modifier, ok := getTTLModifier(destinationName)
+	if !ok {
+		return nil, false, nil
+	}
+
+	plannedTTLModificationsMutex.Lock()
+	planned, ok := plannedTTLModifications[confirmationToken]
+	delete(plannedTTLModifications, confirmationToken)
+	plannedTTLModificationsMutex.Unlock()
+
+	if !ok || time.Now().After(*planned.ExpiresAt) || planned.Destination != destinationName || planned.Table != tableName {
+		return nil, true, ErrInvalidConfirmationToken
+	}
+
+	if err := modifier.ModifyTTL(tableName); err != nil {
+		return nil, true, err
+	}
+
+	return &TTLModification{Destination: destinationName, Table: tableName, Statements: planned.Statements, Modified: true}, true, nil
+}
+
+func getTTLModifier(destinationName string) (TTLModifier, bool) {
+	registry.mutex.RLock()
+	destination, ok := registry.destinations[destinationName]
+	registry.mutex.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	modifier, ok := destination.(TTLModifier)
+	return modifier, ok
+}
@@ -0,0 +1,60 @@
+package storages
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type ttlModifierMock struct {
+	modified []string
+}
+
+func (tm *ttlModifierMock) ModifyTTLDDL(tableName string) ([]string, error) {
+	if tableName != "events" {
+		return nil, errors.New("Table " + tableName + " doesn't exist in ClickHouse")
+	}
+	return []string{"ALTER TABLE events MODIFY TTL _timestamp + INTERVAL 90 DAY"}, nil
+}
+
+func (tm *ttlModifierMock) ModifyTTL(tableName string) error {
+	tm.modified = append(tm.modified, tableName)
+	return nil
+}
+
+func TestModifyTTL(t *testing.T) {
+	modifier := &ttlModifierMock{}
+	registerDestination(&DestinationStatus{Name: "ch"}, modifier)
+	defer unregisterDestination("ch")
+
+	_, ok, _ := PlanModifyTTL("unknown", "events")
+	require.False(t, ok)
+	_, ok, err := PlanModifyTTL("ch", "users")
+	require.True(t, ok)
+	require.EqualError(t, err, "Table users doesn't exist in ClickHouse")
+
+	planned, ok, err := PlanModifyTTL("ch", "events")
+	require.True(t, ok)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ALTER TABLE events MODIFY TTL _timestamp + INTERVAL 90 DAY"}, planned.Statements)
+	require.NotEmpty(t, planned.ConfirmationToken)
+	require.False(t, planned.Modified)
+	require.Empty(t, modifier.modified)
+
+	//the token is bound to the planned table
+	_, _, err = ModifyTTL("ch", "users", planned.ConfirmationToken)
+	require.Equal(t, ErrInvalidConfirmationToken, err)
+	//and is used once
+	_, _, err = ModifyTTL("ch", "events", planned.ConfirmationToken)
+	require.Equal(t, ErrInvalidConfirmationToken, err)
+	require.Empty(t, modifier.modified)
+
+	planned, _, err = PlanModifyTTL("ch", "events")
+	require.NoError(t, err)
+	modified, ok, err := ModifyTTL("ch", "events", planned.ConfirmationToken)
+	require.True(t, ok)
+	require.NoError(t, err)
+	require.True(t, modified.Modified)
+	require.Empty(t, modified.ConfirmationToken)
+	require.Equal(t, []string{"events"}, modifier.modified)
+}
@@ -0,0 +1,219 @@
+package storages
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"log"
+	"strings"
+)
+
+const (
+	natsTablePlaceholder  = "{table}"
+	defaultNATSMsgIDField = "eventn_ctx_event_id"
+)
+
+//Publish processed events to NATS JetStream subjects in two modes:
+//batch: (1 file = asynchronously published messages which are all acknowledged before the file is marked as uploaded)
+//stream: via events queue in stream mode (1 object = 1 acknowledged message)
+//subject is built from subject template and table name
+type NATS struct {
+	name            string
+	natsAdapter     *adapters.NATS
+	subjectTemplate string
+	msgIDField      string
+	schemaProcessor *schema.Processor
+	eventQueue      *events.PersistentQueue
+	breakOnError    bool
+}
+
+//NewNATS return NATS and start goroutine for stream consumer if destination is in stream mode
+func NewNATS(name, fallbackDir string, config *adapters.NATSConfig, processor *schema.Processor, breakOnError, streamMode bool) (*NATS, error) {
+	natsAdapter, err := adapters.NewNATS(config)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &NATS{
+		name:            name,
+		natsAdapter:     natsAdapter,
+		subjectTemplate: config.SubjectTemplate,
+		msgIDField:      config.MsgIDField,
+		schemaProcessor: processor,
+		breakOnError:    breakOnError,
+	}
+
+	if streamMode {
+		n.eventQueue, err = newEventQueue(name, fallbackDir)
+		if err != nil {
+			natsAdapter.Close()
+			return nil, err
+		}
+
+		n.startStreamingConsumer()
+	}
+
+	return n, nil
+}
+
+//Consume events.Fact and enqueue it
+func (n *NATS) Consume(fact events.Fact) {
+	if err := n.eventQueue.Enqueue(fact); err != nil {
+		logSkippedEvent(n.name, fact, err)
+	}
+}
+
+//QueueSize return count of events in the stream mode queue
+func (n *NATS) QueueSize() int {
+	return n.eventQueue.Size()
+}
+
+//Run goroutine to:
+//1. read from queue
+//2. publish to NATS JetStream and wait for acknowledgement
+func (n *NATS) startStreamingConsumer() {
+	go func() {
+		for {
+			if appstatus.Instance.Idle {
+				break
+			}
+			fact, ack, err := n.eventQueue.DequeueBlockAck()
+			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
+				log.Println("Error reading event fact from nats queue", err)
+				continue
+			}
+
+			dataSchema, flattenObject, err := n.schemaProcessor.ProcessFact(fact)
+			if err != nil {
+				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(n.name, 1)
+				acknowledge(n.name, ack)
+				continue
+			}
+
+			//don't process empty object
+			if !dataSchema.Exists() {
+				acknowledge(n.name, ack)
+				continue
+			}
+
+			message, err := n.toMessage(dataSchema.Name, flattenObject)
+			if err != nil {
+				log.Printf("Unable to serialize object %v: %v", flattenObject, err)
+				counters.ErrorEvents(n.name, 1)
+				acknowledge(n.name, ack)
+				continue
+			}
+
+			if err := insertWithRetry(n.name, fact, ack, func() error { return n.publish(message) }); err != nil {
+				if err != errForwarded {
+					log.Printf("Error publishing to nats subject [%s]: %v", message.Subject, err)
+					counters.ErrorEvents(n.name, 1)
+				}
+				continue
+			}
+
+			counters.SuccessEvents(n.name, 1)
+		}
+	}()
+}
+
+//publish message to NATS (with fault injection if it is configured)
+func (n *NATS) publish(message *adapters.NATSMessage) error {
+	if err := injectFault(n.name); err != nil {
+		return err
+	}
+
+	return n.natsAdapter.Publish(message)
+}
+
+//Store file payload to NATS with processing: every table file is published as a batch of messages
+//file is retried (and messages are deduplicated by msg_id_field) if any message isn't acknowledged
+func (n *NATS) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(n.name); err != nil {
+		return err
+	}
+
+	flatData, err := n.schemaProcessor.ProcessFilePayload(fileName, payload, n.breakOnError, report)
+	if err != nil {
+		return err
+	}
+
+	for _, fdata := range flatData {
+		var messages []*adapters.NATSMessage
+		for _, object := range fdata.GetPayload() {
+			message, err := n.toMessage(fdata.DataSchema.Name, object)
+			if err != nil {
+				if n.breakOnError {
+					return err
+				}
+				log.Printf("Warn: unable to serialize object %v from file %s: %v", object, fileName, err)
+				report.Skip(reports.ConversionReason, err)
+				continue
+			}
+			messages = append(messages, message)
+		}
+
+		if len(messages) == 0 {
+			continue
+		}
+
+		if err := n.natsAdapter.PublishBatch(messages); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//return message with templated subject, msg id field value (or empty) and json payload
+func (n *NATS) toMessage(tableName string, object map[string]interface{}) (*adapters.NATSMessage, error) {
+	payload, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	var msgID string
+	if n.msgIDField != "" {
+		if value, ok := object[n.msgIDField]; ok && value != nil {
+			msgID = fmt.Sprint(value)
+		}
+	}
+
+	return &adapters.NATSMessage{
+		Subject: strings.ReplaceAll(n.subjectTemplate, natsTablePlaceholder, tableName),
+		MsgID:   msgID,
+		Payload: payload,
+	}, nil
+}
+
+func (n *NATS) Name() string {
+	return n.name
+}
+
+func (n *NATS) Type() string {
+	return n.natsAdapter.Name()
+}
+
+func (n *NATS) Close() (multiErr error) {
+	if n.eventQueue != nil {
+		if err := n.eventQueue.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing nats event queue: %v", err))
+		}
+	}
+
+	if err := n.natsAdapter.Close(); err != nil {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing nats connection: %v", err))
+	}
+
+	return
+}
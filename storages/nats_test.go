@@ -0,0 +1,59 @@
+package storages
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNATSToMessage(t *testing.T) {
+	tests := []struct {
+		name            string
+		subjectTemplate string
+		msgIDField      string
+		object          map[string]interface{}
+		expectedSubject string
+		expectedMsgID   string
+	}{
+		{
+			"Event id msg id",
+			"{table}",
+			"eventn_ctx_event_id",
+			map[string]interface{}{"eventn_ctx_event_id": "1"},
+			"events",
+			"1",
+		},
+		{
+			"Hierarchical subject",
+			"eventnative.edge1.{table}",
+			"",
+			map[string]interface{}{"eventn_ctx_event_id": "1"},
+			"eventnative.edge1.events",
+			"",
+		},
+		{
+			"Missing msg id field",
+			"{table}",
+			"eventn_ctx_event_id",
+			map[string]interface{}{"user_id": "u1"},
+			"events",
+			"",
+		},
+		{
+			"Number msg id field",
+			"{table}",
+			"id",
+			map[string]interface{}{"id": 15},
+			"events",
+			"15",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &NATS{subjectTemplate: tt.subjectTemplate, msgIDField: tt.msgIDField}
+			message, err := n.toMessage("events", tt.object)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedSubject, message.Subject)
+			require.Equal(t, tt.expectedMsgID, message.MsgID)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/adapters"
+	"log"
+	"sync"
+	"time"
+)
+
+//NodeBalancer chooses one of destination nodes (e.g. ClickHouse dsns) for every write operation.
+//Nodes are health-checked periodically and are excluded from rotation if ping fails or
+//after max_errors consecutive write errors. If all nodes are unhealthy - all of them are used
+type NodeBalancer struct {
+	name      string
+	strategy  string
+	maxErrors int
+	ping      func(node int) error
+
+	mutex sync.Mutex
+	nodes []*nodeState
+	next  int
+
+	closed    chan bool
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+type nodeState struct {
+	healthy           bool
+	consecutiveErrors int
+	//errors since the last health check (least_errors strategy)
+	errors int
+}
+
+//NewNodeBalancer return NodeBalancer of nodesCount nodes and start health checking goroutine
+func NewNodeBalancer(name string, nodesCount int, config *adapters.BalancerConfig, ping func(node int) error) *NodeBalancer {
+	nb := newNodeBalancer(name, nodesCount, config, ping)
+	nb.start(config.HealthCheckEvery)
+
+	return nb
+}
+
+func newNodeBalancer(name string, nodesCount int, config *adapters.BalancerConfig, ping func(node int) error) *NodeBalancer {
+	var nodes []*nodeState
+	for i := 0; i < nodesCount; i++ {
+		nodes = append(nodes, &nodeState{healthy: true})
+	}
+
+	return &NodeBalancer{
+		name:      name,
+		strategy:  config.Strategy,
+		maxErrors: config.MaxErrors,
+		ping:      ping,
+		nodes:     nodes,
+		closed:    make(chan bool),
+	}
+}
+
+//Next return index of node for the next write operation
+func (nb *NodeBalancer) Next() int {
+	nb.mutex.Lock()
+	defer nb.mutex.Unlock()
+
+	var candidates []int
+	for i, node := range nb.nodes {
+		if node.healthy {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		for i := range nb.nodes {
+			candidates = append(candidates, i)
+		}
+	}
+
+	if nb.strategy == adapters.LeastErrorsStrategy {
+		//the least errors node, round robin among equal ones
+		var leastErrors []int
+		for _, i := range candidates {
+			if len(leastErrors) == 0 || nb.nodes[i].errors < nb.nodes[leastErrors[0]].errors {
+				leastErrors = []int{i}
+			} else if nb.nodes[i].errors == nb.nodes[leastErrors[0]].errors {
+				leastErrors = append(leastErrors, i)
+			}
+		}
+		candidates = leastErrors
+	}
+
+	node := candidates[nb.next%len(candidates)]
+	nb.next++
+
+	return node
+}
+
+//Report write operation result on the node. Node is excluded from rotation after max_errors consecutive errors
+func (nb *NodeBalancer) Report(node int, err error) {
+	nb.mutex.Lock()
+	defer nb.mutex.Unlock()
+
+	state := nb.nodes[node]
+	if err == nil {
+		state.consecutiveErrors = 0
+		return
+	}
+
+	state.errors++
+	state.consecutiveErrors++
+	if state.healthy && state.consecutiveErrors >= nb.maxErrors {
+		state.healthy = false
+		log.Printf("[%s] node #%d is excluded from rotation after %d consecutive errors. Last error: %v", nb.name, node, state.consecutiveErrors, err)
+	}
+}
+
+//Close stop health checking goroutine
+func (nb *NodeBalancer) Close() error {
+	nb.closeOnce.Do(func() {
+		close(nb.closed)
+	})
+	nb.wg.Wait()
+
+	return nil
+}
+
+func (nb *NodeBalancer) start(healthCheckEvery time.Duration) {
+	nb.wg.Add(1)
+	go func() {
+		defer nb.wg.Done()
+		ticker := time.NewTicker(healthCheckEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				nb.checkHealth()
+			case <-nb.closed:
+				return
+			}
+		}
+	}()
+}
+
+//ping all nodes (without lock) and update their states
+func (nb *NodeBalancer) checkHealth() {
+	results := make([]error, len(nb.nodes))
+	for i := range nb.nodes {
+		results[i] = nb.ping(i)
+	}
+
+	nb.mutex.Lock()
+	defer nb.mutex.Unlock()
+
+	for i, err := range results {
+		state := nb.nodes[i]
+		state.errors = 0
+		if err != nil {
+			if state.healthy {
+				log.Printf("[%s] node #%d is excluded from rotation: health check failed: %v", nb.name, i, err)
+			}
+			state.healthy = false
+			continue
+		}
+
+		if !state.healthy {
+			log.Printf("[%s] node #%d is healthy again and returned to rotation", nb.name, i)
+		}
+		state.healthy = true
+		state.consecutiveErrors = 0
+	}
+}
@@ -0,0 +1,63 @@
+package storages
+
+import (
+	"errors"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNodeBalancerRoundRobin(t *testing.T) {
+	pingErrors := []error{nil, nil, nil}
+	nb := newNodeBalancer("test", 3, &adapters.BalancerConfig{Strategy: adapters.RoundRobinStrategy, MaxErrors: 2},
+		func(node int) error { return pingErrors[node] })
+
+	require.Equal(t, []int{0, 1, 2, 0, 1, 2}, nextNodes(nb, 6))
+
+	//node 1 is excluded after 2 consecutive errors
+	nb.Report(1, errors.New("connection refused"))
+	nb.Report(1, nil)
+	nb.Report(1, errors.New("connection refused"))
+	require.Equal(t, []int{0, 1, 2, 0}, nextNodes(nb, 4))
+	nb.Report(1, errors.New("connection refused"))
+	require.Equal(t, []int{0, 2, 0, 2}, nextNodes(nb, 4))
+
+	//node 2 is excluded by health check, node 1 is returned
+	pingErrors[2] = errors.New("connection refused")
+	nb.checkHealth()
+	require.Equal(t, []int{0, 1, 0, 1}, nextNodes(nb, 4))
+
+	//all nodes are unhealthy: all of them are used
+	pingErrors = []error{errors.New("timeout"), errors.New("timeout"), errors.New("timeout")}
+	nb.checkHealth()
+	require.Equal(t, []int{0, 1, 2}, nextNodes(nb, 3))
+}
+
+func TestNodeBalancerLeastErrors(t *testing.T) {
+	nb := newNodeBalancer("test", 3, &adapters.BalancerConfig{Strategy: adapters.LeastErrorsStrategy, MaxErrors: 3},
+		func(node int) error { return nil })
+
+	nb.Report(0, errors.New("timeout"))
+	nb.Report(0, nil)
+	nb.Report(2, errors.New("timeout"))
+	nb.Report(2, errors.New("timeout"))
+	require.Equal(t, []int{1, 1, 1}, nextNodes(nb, 3))
+
+	nb.Report(1, errors.New("timeout"))
+	require.Equal(t, []int{0, 1, 0, 1}, nextNodes(nb, 4))
+
+	//errors are reset by health check
+	nb.checkHealth()
+	require.Equal(t, []int{0, 1, 2}, nextNodes(nb, 3))
+}
+
+func nextNodes(nb *NodeBalancer, count int) []int {
+	//start every sequence from the beginning for readable expectations
+	nb.next = 0
+	var nodes []int
+	for i := 0; i < count; i++ {
+		nodes = append(nodes, nb.Next())
+	}
+
+	return nodes
+}
@@ -0,0 +1,59 @@
+package storages
+
+import (
+	"github.com/google/uuid"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	defaultObjectNameTemplate = "{file}" + tableFileKeyDelimiter + "{table}"
+	partitionPlaceholder      = "{partition}"
+
+	objectNameDateLayout = "2006-01-02"
+)
+
+//ObjectNameTemplate builds file destinations (s3, gcs) object names from template with placeholders:
+//{file} - source file name (batch mode) or rotated file name (stream mode)
+//{table} - table name
+//{date} - current date (in files timezone) in YYYY-MM-DD format
+//{uuid} - random UUID
+//{partition} - partition path (see Partitioner). If template doesn't contain it, partition path is used as a prefix
+//e.g. {date}/{table}/{uuid}.log or events/{table}/{partition}/{uuid}.log
+type ObjectNameTemplate struct {
+	template string
+}
+
+//NewObjectNameTemplate return ObjectNameTemplate with default template ({file}-table-{table}) if input is empty
+func NewObjectNameTemplate(template string) *ObjectNameTemplate {
+	if template == "" {
+		template = defaultObjectNameTemplate
+	}
+
+	return &ObjectNameTemplate{template: template}
+}
+
+//TableDirectory return true if objects of every table are in a separate directory (template directory contains {table})
+func (ont *ObjectNameTemplate) TableDirectory() bool {
+	return strings.Contains(path.Dir(ont.template), "{table}")
+}
+
+//Name return object name with replaced placeholders
+//partition is an empty string if objects aren't partitioned
+func (ont *ObjectNameTemplate) Name(fileName, tableName, partition string, now time.Time) string {
+	template := ont.template
+	if partition == "" {
+		template = strings.ReplaceAll(template, partitionPlaceholder+"/", "")
+	} else if !strings.Contains(template, partitionPlaceholder) {
+		template = partitionPlaceholder + "/" + template
+	}
+
+	return strings.NewReplacer(
+		"{file}", fileName,
+		"{table}", tableName,
+		"{date}", now.Format(objectNameDateLayout),
+		"{uuid}", uuid.New().String(),
+		partitionPlaceholder, partition,
+	).Replace(template)
+}
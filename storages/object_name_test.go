@@ -0,0 +1,61 @@
+package storages
+
+import (
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestObjectName(t *testing.T) {
+	now := time.Date(2020, 8, 1, 13, 5, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		template  string
+		partition string
+		expected  string
+	}{
+		{
+			"Default template",
+			"",
+			"",
+			"^events.log-table-users$",
+		},
+		{
+			"Date table uuid",
+			"{date}/{table}/{uuid}",
+			"",
+			"^2020-08-01/users/[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$",
+		},
+		{
+			"Static prefix with file",
+			"raw/{table}/{file}",
+			"",
+			"^raw/users/events.log$",
+		},
+		{
+			"Partition prefix",
+			"raw/{table}/{file}",
+			"dt=2020-08-01",
+			"^dt=2020-08-01/raw/users/events.log$",
+		},
+		{
+			"Partition placeholder",
+			"raw/{table}/{partition}/{file}",
+			"dt=2020-08-01/hour=13",
+			"^raw/users/dt=2020-08-01/hour=13/events.log$",
+		},
+		{
+			"Partition placeholder without partition",
+			"raw/{table}/{partition}/{file}",
+			"",
+			"^raw/users/events.log$",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := NewObjectNameTemplate(tt.template).Name("events.log", "users", tt.partition, now)
+			require.Regexp(t, regexp.MustCompile(tt.expected), actual)
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package storages
+
+import (
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"log"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	defaultParquetNameTemplate      = "{table}/{partition}/{file}.parquet"
+	defaultParquetPartitionTemplate = "date={date}"
+)
+
+//Write processed events into local Parquet files (one file per table and partition) in two modes:
+//batch: (1 file = 1 Parquet file per table and partition)
+//stream: via events queue and FileBatcher (objects are rotated into Parquet files periodically)
+//Parquet schema is generated from the file table schema
+type Parquet struct {
+	name            string
+	parquetAdapter  *adapters.Parquet
+	nameTemplate    *ObjectNameTemplate
+	partitioner     *Partitioner
+	location        *time.Location
+	schemaProcessor *schema.Processor
+	eventQueue      *events.PersistentQueue
+	fileBatcher     *FileBatcher
+	breakOnError    bool
+}
+
+//NewParquet return Parquet and start goroutine for stream consumer if destination is in stream mode
+func NewParquet(name, fallbackDir string, config *adapters.ParquetConfig, filesConfig *FilesConfig, processor *schema.Processor,
+	breakOnError, streamMode bool) (*Parquet, error) {
+	parquetAdapter, err := adapters.NewParquet(config)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Parquet{
+		name:            name,
+		parquetAdapter:  parquetAdapter,
+		nameTemplate:    NewObjectNameTemplate(filesConfig.NameTemplate),
+		partitioner:     NewPartitioner(filesConfig.PartitionTemplate, filesConfig.location),
+		location:        filesConfig.location,
+		schemaProcessor: processor,
+		breakOnError:    breakOnError,
+	}
+
+	if streamMode {
+		p.eventQueue, err = newEventQueue(name, fallbackDir)
+		if err != nil {
+			return nil, err
+		}
+
+		p.fileBatcher = NewFileBatcher(name, filesConfig, p.write)
+		p.startStreamingConsumer()
+	}
+
+	return p, nil
+}
+
+//Consume events.Fact and enqueue it
+func (p *Parquet) Consume(fact events.Fact) {
+	if err := p.eventQueue.Enqueue(fact); err != nil {
+		logSkippedEvent(p.name, fact, err)
+	}
+}
+
+//QueueSize return count of events in the stream mode queue
+func (p *Parquet) QueueSize() int {
+	return p.eventQueue.Size()
+}
+
+//Run goroutine to:
+//1. read from queue
+//2. put processed object into FileBatcher
+func (p *Parquet) startStreamingConsumer() {
+	go func() {
+		for {
+			if appstatus.Instance.Idle {
+				break
+			}
+			fact, err := p.eventQueue.DequeueBlock()
+			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
+				log.Println("Error reading event fact from parquet queue", err)
+				continue
+			}
+
+			dataSchema, flattenObject, err := p.schemaProcessor.ProcessFact(fact)
+			if err != nil {
+				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(p.name, 1)
+				continue
+			}
+
+			//don't process empty object
+			if !dataSchema.Exists() {
+				continue
+			}
+
+			p.fileBatcher.Add(dataSchema, flattenObject)
+		}
+	}()
+}
+
+//Store file payload into Parquet files with processing
+func (p *Parquet) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(p.name); err != nil {
+		return err
+	}
+
+	flatData, err := p.schemaProcessor.ProcessFilePayload(fileName, payload, p.breakOnError, report)
+	if err != nil {
+		return err
+	}
+
+	for _, fdata := range flatData {
+		if err := p.write(fdata); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//write processed file as one Parquet file per partition
+//objects which values can't be converted into the table columns types are skipped
+func (p *Parquet) write(fdata *schema.ProcessedFile) error {
+	for partitionPath, partition := range p.partitioner.Split(fdata) {
+		var objects []map[string]interface{}
+		for _, object := range partition.GetPayload() {
+			parquetObject, err := p.toParquetObject(partition.DataSchema, object)
+			if err != nil {
+				if p.breakOnError {
+					return err
+				}
+				log.Printf("Warn: unable to convert object %v from file %s to parquet: %v", object, fdata.FileName, err)
+				fdata.Report.Skip(reports.ConversionReason, err)
+				continue
+			}
+			objects = append(objects, parquetObject)
+		}
+
+		if len(objects) == 0 {
+			continue
+		}
+
+		//source file extension (.log) is replaced with name template one
+		sourceFileName := strings.TrimSuffix(fdata.FileName, path.Ext(fdata.FileName))
+		fileName := p.nameTemplate.Name(sourceFileName, partition.DataSchema.Name, partitionPath, time.Now().In(p.location))
+		if err := p.parquetAdapter.Write(fileName, partition.DataSchema, objects); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//return object with all values converted into table columns types
+func (p *Parquet) toParquetObject(table *schema.Table, object map[string]interface{}) (map[string]interface{}, error) {
+	parquetObject := make(map[string]interface{}, len(object))
+	for name, value := range object {
+		converted, err := adapters.ParquetValue(table.Columns[name], value)
+		if err != nil {
+			return nil, fmt.Errorf("Error converting field [%s] with [%v] value to [%s]: %v", name, value, table.Columns[name].GetType(), err)
+		}
+		parquetObject[name] = converted
+	}
+
+	return parquetObject, nil
+}
+
+func (p *Parquet) Name() string {
+	return p.name
+}
+
+func (p *Parquet) Type() string {
+	return p.parquetAdapter.Name()
+}
+
+//FlushFiles upload files which are accumulated by stream mode file batcher right away
+func (p *Parquet) FlushFiles() {
+	if p.fileBatcher != nil {
+		p.fileBatcher.Flush()
+	}
+}
+
+func (p *Parquet) Close() (multiErr error) {
+	if p.fileBatcher != nil {
+		if err := p.fileBatcher.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing parquet file batcher: %v", err))
+		}
+	}
+
+	if p.eventQueue != nil {
+		if err := p.eventQueue.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing parquet event queue: %v", err))
+		}
+	}
+
+	if err := p.parquetAdapter.Close(); err != nil {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing parquet adapter: %v", err))
+	}
+
+	return
+}
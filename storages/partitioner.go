@@ -0,0 +1,94 @@
+package storages
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/ksensehq/eventnative/typing"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const unknownPartitionValue = "unknown"
+
+var partitionPlaceholderRegex = regexp.MustCompile(`{([^{}]+)}`)
+
+//Partitioner builds file destinations (s3, gcs) partition paths from template with placeholders:
+//{date} - object timestamp date in YYYY-MM-DD format
+//{year}, {month}, {day}, {hour} - object timestamp parts (zero padded)
+//{<field>} - any other placeholder is a flattened object field value e.g. {api_key}
+//object timestamp is taken from _timestamp field (or current time if it is absent) in configured timezone
+//e.g. dt={date}/hour={hour}/token={api_key}
+type Partitioner struct {
+	template string
+	location *time.Location
+}
+
+//NewPartitioner return Partitioner or nil if template is empty
+func NewPartitioner(template string, location *time.Location) *Partitioner {
+	if template == "" {
+		return nil
+	}
+	if location == nil {
+		location = time.UTC
+	}
+
+	return &Partitioner{template: strings.Trim(template, "/"), location: location}
+}
+
+//Path return partition path of object with replaced placeholders
+func (p *Partitioner) Path(object map[string]interface{}) string {
+	t := p.objectTime(object)
+
+	return partitionPlaceholderRegex.ReplaceAllStringFunc(p.template, func(placeholder string) string {
+		field := placeholder[1 : len(placeholder)-1]
+		switch field {
+		case "date":
+			return t.Format("2006-01-02")
+		case "year":
+			return t.Format("2006")
+		case "month":
+			return t.Format("01")
+		case "day":
+			return t.Format("02")
+		case "hour":
+			return t.Format("15")
+		default:
+			value, ok := object[field]
+			if !ok || value == nil {
+				return unknownPartitionValue
+			}
+
+			//path separator in value mustn't create extra partition level
+			return strings.ReplaceAll(fmt.Sprint(value), "/", "_")
+		}
+	})
+}
+
+//Split return processed files per partition path
+func (p *Partitioner) Split(pf *schema.ProcessedFile) map[string]*schema.ProcessedFile {
+	partitions := map[string]*schema.ProcessedFile{}
+	for _, object := range pf.GetPayload() {
+		path := p.Path(object)
+		partition, ok := partitions[path]
+		if !ok {
+			partition = schema.NewProcessedFile(pf.FileName, &schema.Table{Name: pf.DataSchema.Name, Columns: schema.Columns{}})
+			partitions[path] = partition
+		}
+		partition.Add(pf.DataSchema, object)
+	}
+
+	return partitions
+}
+
+//return object timestamp in partitioner timezone or current time if object doesn't have valid one
+func (p *Partitioner) objectTime(object map[string]interface{}) time.Time {
+	if value, ok := object[timestamp.Key]; ok && value != nil {
+		if converted, err := typing.Convert(typing.TIMESTAMP, value); err == nil {
+			return converted.(time.Time).In(p.location)
+		}
+	}
+
+	return time.Now().In(p.location)
+}
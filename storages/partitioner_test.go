@@ -0,0 +1,63 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPartitionerPath(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		template string
+		location *time.Location
+		object   map[string]interface{}
+		expected string
+	}{
+		{
+			"Date hour token UTC",
+			"dt={date}/hour={hour}/token={api_key}",
+			time.UTC,
+			map[string]interface{}{"_timestamp": "2020-08-01T13:05:00.000000Z", "api_key": "token1"},
+			"dt=2020-08-01/hour=13/token=token1",
+		},
+		{
+			"Date with timezone",
+			"/{year}/{month}/{day}/{hour}/",
+			moscow,
+			map[string]interface{}{"_timestamp": "2020-08-01T22:05:00.000000Z"},
+			"2020/08/02/01",
+		},
+		{
+			"Unknown and sanitized fields",
+			"source={source}/url={url}",
+			time.UTC,
+			map[string]interface{}{"url": "https://site.com/page"},
+			"source=unknown/url=https:__site.com_page",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := NewPartitioner(tt.template, tt.location).Path(tt.object)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestPartitionerSplit(t *testing.T) {
+	pf := schema.NewProcessedFile("events.log", &schema.Table{Name: "events", Columns: schema.Columns{}})
+	table := &schema.Table{Name: "events", Columns: schema.Columns{}}
+	pf.Add(table, map[string]interface{}{"_timestamp": "2020-08-01T13:05:00.000000Z"})
+	pf.Add(table, map[string]interface{}{"_timestamp": "2020-08-01T13:45:00.000000Z"})
+	pf.Add(table, map[string]interface{}{"_timestamp": "2020-08-01T14:05:00.000000Z"})
+
+	partitions := NewPartitioner("dt={date}/hour={hour}", time.UTC).Split(pf)
+	require.Equal(t, 2, len(partitions))
+	require.Equal(t, 2, partitions["dt=2020-08-01/hour=13"].Size())
+	require.Equal(t, 1, partitions["dt=2020-08-01/hour=14"].Size())
+	require.Equal(t, "events.log", partitions["dt=2020-08-01/hour=14"].FileName)
+}
@@ -5,11 +5,17 @@ import (
 	"fmt"
 	"github.com/hashicorp/go-multierror"
 	"github.com/ksensehq/eventnative/adapters"
-	"github.com/ksensehq/eventnative/appconfig"
 	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/dbt"
 	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
 	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/ksensehq/eventnative/watermarks"
 	"log"
+	"sort"
+	"time"
 )
 
 const postgresStorageType = "Postgres"
@@ -17,6 +23,7 @@ const postgresStorageType = "Postgres"
 //Store files to Postgres in two modes:
 //batch: (1 file = 1 transaction)
 //stream: (1 object = 1 transaction)
+//If TimescaleDB is used, tables are created as hypertables and batch mode inserts use multi-row statements
 type Postgres struct {
 	name            string
 	adapter         *adapters.Postgres
@@ -24,15 +31,16 @@ type Postgres struct {
 	schemaProcessor *schema.Processor
 	eventQueue      *events.PersistentQueue
 	breakOnError    bool
+	//max rows count in one multi-row INSERT statement (0 - one statement per row)
+	insertBatchSize int
 }
 
-func NewPostgres(ctx context.Context, config *adapters.DataSourceConfig, processor *schema.Processor,
+func NewPostgres(ctx context.Context, config *adapters.DataSourceConfig, processor *schema.Processor, ddlWriter *DDLWriter,
 	fallbackDir, storageName string, breakOnError, streamMode bool) (*Postgres, error) {
 	var eventQueue *events.PersistentQueue
 	if streamMode {
 		var err error
-		queueName := fmt.Sprintf("%s-%s", appconfig.Instance.ServerName, storageName)
-		eventQueue, err = events.NewPersistentQueue(queueName, fallbackDir)
+		eventQueue, err = newEventQueue(storageName, fallbackDir)
 		if err != nil {
 			return nil, err
 		}
@@ -43,14 +51,29 @@ func NewPostgres(ctx context.Context, config *adapters.DataSourceConfig, process
 		return nil, err
 	}
 
-	//create db schema if doesn't exist
-	err = adapter.CreateDbSchema(config.Schema)
+	//create db schema if doesn't exist (nothing is created with existing tables or read-only schema)
+	if processor.ExistingTables() == nil && ddlWriter == nil {
+		if err := adapter.CreateDbSchema(config.Schema); err != nil {
+			return nil, err
+		}
+	}
+
+	timeColumn := processor.SystemColumn(timestamp.Key)
+	timescale, err := adapter.EnableTimescale(config.Timescale, timeColumn)
 	if err != nil {
-		return nil, err
+		if config.Timescale.Mode != adapters.TimescaleAuto {
+			return nil, err
+		}
+		log.Printf("Warn: [%s] %v. Tables will be created as regular ones", storageName, err)
+	}
+	insertBatchSize := 0
+	if timescale {
+		log.Printf("[%s] TimescaleDB is used: tables with %s column will be created as hypertables", storageName, timeColumn)
+		insertBatchSize = config.Timescale.InsertBatchSize
 	}
 
 	monitorKeeper := NewMonitorKeeper()
-	tableHelper := NewTableHelper(adapter, monitorKeeper, postgresStorageType)
+	tableHelper := NewTableHelper(adapter, monitorKeeper, postgresStorageType, processor.ExistingTables(), ddlWriter, processor.SchemaEvolution(), processor.ColumnsLimit(), processor.TablesCache(), processor.MetadataCache())
 
 	p := &Postgres{
 		name:            storageName,
@@ -59,10 +82,13 @@ func NewPostgres(ctx context.Context, config *adapters.DataSourceConfig, process
 		schemaProcessor: processor,
 		eventQueue:      eventQueue,
 		breakOnError:    breakOnError,
+		insertBatchSize: insertBatchSize,
 	}
 
 	if streamMode {
-		p.startStreamingConsumer()
+		for i := 0; i < streamWorkers(storageName); i++ {
+			p.startStreamingConsumer()
+		}
 	}
 
 	return p, nil
@@ -71,10 +97,15 @@ func NewPostgres(ctx context.Context, config *adapters.DataSourceConfig, process
 //Consume events.Fact and enqueue it
 func (p *Postgres) Consume(fact events.Fact) {
 	if err := p.eventQueue.Enqueue(fact); err != nil {
-		logSkippedEvent(fact, err)
+		logSkippedEvent(p.name, fact, err)
 	}
 }
 
+//QueueSize return count of events in the stream mode queue
+func (p *Postgres) QueueSize() int {
+	return p.eventQueue.Size()
+}
+
 //Run goroutine to:
 //1. read from queue
 //2. insert in Postgres
@@ -84,8 +115,11 @@ func (p *Postgres) startStreamingConsumer() {
 			if appstatus.Instance.Idle {
 				break
 			}
-			fact, err := p.eventQueue.DequeueBlock()
+			fact, ack, err := p.eventQueue.DequeueBlockAck()
 			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
 				log.Println("Error reading event fact from postgres queue", err)
 				continue
 			}
@@ -93,48 +127,78 @@ func (p *Postgres) startStreamingConsumer() {
 			dataSchema, flattenObject, err := p.schemaProcessor.ProcessFact(fact)
 			if err != nil {
 				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(p.name, 1)
+				acknowledge(p.name, ack)
 				continue
 			}
 
 			//don't process empty object
 			if !dataSchema.Exists() {
+				acknowledge(p.name, ack)
 				continue
 			}
 
-			if err := p.insert(dataSchema, flattenObject); err != nil {
-				log.Printf("Error inserting to postgres table [%s]: %v", dataSchema.Name, err)
+			//event time is taken before inserting: object can be changed by typing
+			eventTime := p.schemaProcessor.EventTime(dataSchema, flattenObject)
+			if err := insertWithRetry(p.name, fact, ack, func() error { return p.insert(dataSchema, flattenObject) }); err != nil {
+				if err != errForwarded {
+					log.Printf("Error inserting to postgres table [%s]: %v", dataSchema.Name, err)
+					counters.ErrorEvents(p.name, 1)
+				}
 				continue
 			}
+
+			counters.SuccessEvents(p.name, 1)
+			watermarks.Commit(p.name, dataSchema.Name, eventTime)
 		}
 	}()
 }
 
 //Store file payload to Postgres with processing
-func (p *Postgres) Store(fileName string, payload []byte) error {
-	flatData, err := p.schemaProcessor.ProcessFilePayload(fileName, payload, p.breakOnError)
+func (p *Postgres) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(p.name); err != nil {
+		return err
+	}
+
+	flatData, err := p.schemaProcessor.ProcessFilePayload(fileName, payload, p.breakOnError, report)
 	if err != nil {
 		return err
 	}
+	deletions := schema.ExtractDeletions(flatData)
 
 	//process db tables & schema
 	for _, fdata := range flatData {
-		dbSchema, err := p.tableHelper.EnsureTable(fdata.DataSchema)
+		dbSchema, err := p.tableHelper.EnsureTable(fdata.DataSchema, fdata.GetPayload()...)
 		if err != nil {
 			return err
 		}
 
-		if err := p.schemaProcessor.ApplyDBTyping(dbSchema, fdata); err != nil {
+		if err := p.tableHelper.ApplyDBTyping(p.schemaProcessor, dbSchema, fdata); err != nil {
 			return err
 		}
 	}
 
-	//insert all data in one transaction
+	deletions, err = p.tableHelper.PrepareDeletions(p.schemaProcessor, deletions)
+	if err != nil {
+		return err
+	}
+
+	//insert all data and apply deletions after that in one transaction
 	tx, err := p.adapter.OpenTx()
 	if err != nil {
 		return fmt.Errorf("Error opening postgres transaction: %v", err)
 	}
 
 	for _, fdata := range flatData {
+		//rows of upsert tables are inserted one by one: ON CONFLICT can't affect the same row twice in one statement
+		if p.insertBatchSize > 0 && len(fdata.DataSchema.UpsertKeys) == 0 {
+			if err := p.bulkInsert(tx, fdata, report); err != nil {
+				tx.Rollback()
+				return err
+			}
+			continue
+		}
+
 		for _, object := range fdata.GetPayload() {
 			if err := p.adapter.InsertInTransaction(tx, fdata.DataSchema, object); err != nil {
 				if p.breakOnError {
@@ -142,6 +206,21 @@ func (p *Postgres) Store(fileName string, payload []byte) error {
 					return err
 				} else {
 					log.Printf("Warn: unable to insert object %v reason: %v. This line will be skipped", object, err)
+					report.Skip(reports.InsertReason, err)
+				}
+			}
+		}
+	}
+
+	for _, fdata := range deletions {
+		for _, object := range fdata.GetPayload() {
+			if err := p.adapter.DeleteInTransaction(tx, fdata.DataSchema, object); err != nil {
+				if p.breakOnError {
+					tx.Rollback()
+					return err
+				} else {
+					log.Printf("Warn: unable to delete object %v reason: %v. This line will be skipped", object, err)
+					report.Skip(reports.DeleteReason, err)
 				}
 			}
 		}
@@ -150,14 +229,66 @@ func (p *Postgres) Store(fileName string, payload []byte) error {
 	return tx.DirectCommit()
 }
 
-//insert fact in Postgres
+//insert file objects sorted by time column with multi-row statements of insertBatchSize rows
+//so every statement hits as few hypertable chunks as possible
+//return err only if breakOnError is true, otherwise rows of failed statement are skipped
+func (p *Postgres) bulkInsert(tx *adapters.Transaction, fdata *schema.ProcessedFile, report *reports.LoadReport) error {
+	objects := fdata.GetPayload()
+	timeColumn := p.schemaProcessor.SystemColumn(timestamp.Key)
+	sort.SliceStable(objects, func(i, j int) bool {
+		return timeColumnValue(objects[i], timeColumn).Before(timeColumnValue(objects[j], timeColumn))
+	})
+
+	for start := 0; start < len(objects); start += p.insertBatchSize {
+		end := start + p.insertBatchSize
+		if end > len(objects) {
+			end = len(objects)
+		}
+
+		if err := p.adapter.BulkInsertInTransaction(tx, fdata.DataSchema, objects[start:end]); err != nil {
+			if p.breakOnError {
+				return err
+			}
+			log.Printf("Warn: unable to insert %d objects reason: %v. These lines will be skipped", end-start, err)
+			for i := start; i < end; i++ {
+				report.Skip(reports.InsertReason, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+//return object time column value or zero time if it doesn't have the value
+func timeColumnValue(object map[string]interface{}, timeColumn string) time.Time {
+	t, _ := object[timeColumn].(time.Time)
+	return t
+}
+
+//insert fact in Postgres or delete rows with its keys values if it is a deletion event
 func (p *Postgres) insert(dataSchema *schema.Table, fact events.Fact) (err error) {
-	dbSchema, err := p.tableHelper.EnsureTable(dataSchema)
+	if err := injectFault(p.name); err != nil {
+		return err
+	}
+
+	if len(dataSchema.DeletionKeys) > 0 {
+		dbSchema, err := p.tableHelper.DeletionsTable(dataSchema)
+		if err != nil || dbSchema == nil {
+			return err
+		}
+		if err := p.schemaProcessor.ApplyDBTypingToObject(dbSchema, dataSchema, fact); err != nil {
+			return err
+		}
+
+		return p.adapter.Delete(dataSchema, fact)
+	}
+
+	dbSchema, err := p.tableHelper.EnsureTable(dataSchema, fact)
 	if err != nil {
 		return err
 	}
 
-	if err := p.schemaProcessor.ApplyDBTypingToObject(dbSchema, fact); err != nil {
+	if err := p.tableHelper.ApplyDBTypingToObject(p.schemaProcessor, dbSchema, dataSchema, fact); err != nil {
 		return err
 	}
 
@@ -179,6 +310,37 @@ func (p *Postgres) Close() (multiErr error) {
 	return
 }
 
+//Tables return tables schemas known by the destination
+func (p *Postgres) Tables() []*schema.Table {
+	return p.tableHelper.Tables()
+}
+
+//DropColumnDDL return statements which drop the column of the destination table
+func (p *Postgres) DropColumnDDL(tableName, columnName string) ([]string, error) {
+	return p.tableHelper.DropColumnDDL(tableName, columnName)
+}
+
+//DropColumn drop the column of the destination table
+func (p *Postgres) DropColumn(tableName, columnName string) error {
+	return p.tableHelper.DropColumn(tableName, columnName)
+}
+
+//DbtSource return dbt source of tables known by the destination
+func (p *Postgres) DbtSource(freshness *dbt.Freshness) *dbt.Source {
+	database, schemaName := p.adapter.Location()
+	return dbt.NewSource(p.name, database, schemaName, p.Tables(), p.schemaProcessor.SystemColumn(timestamp.Key), p.schemaProcessor.ColumnDescription, freshness)
+}
+
+//SelectLast return last rows of the table where keyColumn equals value
+func (p *Postgres) SelectLast(tableName, keyColumn, orderColumn string, value interface{}, limit int) ([]map[string]interface{}, error) {
+	return p.adapter.SelectLast(tableName, keyColumn, orderColumn, value, limit)
+}
+
+//SelectMetric return rows of predefined aggregate query
+func (p *Postgres) SelectMetric(query *adapters.MetricQuery) ([]map[string]interface{}, error) {
+	return p.adapter.SelectMetric(query)
+}
+
 func (p *Postgres) Name() string {
 	return p.name
 }
@@ -187,6 +349,12 @@ func (p *Postgres) Type() string {
 	return postgresStorageType
 }
 
-func logSkippedEvent(fact events.Fact, err error) {
+//Transactional return true: all tables of a file and its deletions are stored in one transaction
+func (p *Postgres) Transactional() bool {
+	return true
+}
+
+func logSkippedEvent(destinationName string, fact events.Fact, err error) {
 	log.Printf("Warn: unable to enqueue object %v reason: %v. This object will be skipped", fact, err)
+	counters.SkippedEvents(destinationName, 1)
 }
@@ -0,0 +1,300 @@
+package storages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/adapters"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	protobufEventTypeField = "event_type"
+	protobufTimestamp      = "google.protobuf.Timestamp"
+)
+
+//protobufEncoder serializes flattened objects into protobuf messages of their event types (see adapters.KafkaProtobufConfig)
+//message fields are filled from flattened fields with the same names and nested messages fields from flattened fields
+//with <message field name>_ prefix (like flattened nested objects). Object fields which aren't in the message are dropped
+//google.protobuf.Timestamp fields are filled from timestamps, repeated fields from arrays (or JSON strings of arrays)
+type protobufEncoder struct {
+	messages map[string]protoreflect.MessageDescriptor
+}
+
+//newProtobufEncoder return protobufEncoder with messages from descriptor set file
+//return err if the file can't be parsed or any configured message isn't in the file
+func newProtobufEncoder(config *adapters.KafkaProtobufConfig) (*protobufEncoder, error) {
+	payload, err := ioutil.ReadFile(config.DescriptorSet)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading protobuf descriptor_set file [%s]: %v", config.DescriptorSet, err)
+	}
+
+	descriptorSet := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(payload, descriptorSet); err != nil {
+		return nil, fmt.Errorf("Error parsing protobuf descriptor_set file [%s]: %v", config.DescriptorSet, err)
+	}
+
+	//files are in dependency order if descriptor set is generated with --include_imports
+	files := &protoregistry.Files{}
+	for _, fileProto := range descriptorSet.File {
+		file, err := protodesc.NewFile(fileProto, files)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing protobuf descriptor of %s: %v", fileProto.GetName(), err)
+		}
+		if err := files.RegisterFile(file); err != nil {
+			return nil, fmt.Errorf("Error registering protobuf descriptor of %s: %v", fileProto.GetName(), err)
+		}
+	}
+
+	messages := map[string]protoreflect.MessageDescriptor{}
+	for eventType, messageName := range config.Messages {
+		descriptor, err := files.FindDescriptorByName(protoreflect.FullName(messageName))
+		if err != nil {
+			return nil, fmt.Errorf("Protobuf message %s of event type [%s] isn't found in descriptor_set: %v", messageName, eventType, err)
+		}
+		message, ok := descriptor.(protoreflect.MessageDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("Protobuf descriptor %s of event type [%s] isn't a message", messageName, eventType)
+		}
+		messages[eventType] = message
+	}
+
+	return &protobufEncoder{messages: messages}, nil
+}
+
+//Encode return binary protobuf message of object event type (or default message)
+func (pe *protobufEncoder) Encode(object map[string]interface{}) ([]byte, error) {
+	eventType, _ := object[protobufEventTypeField].(string)
+	descriptor, ok := pe.messages[eventType]
+	if !ok {
+		descriptor, ok = pe.messages[adapters.KafkaProtobufDefaultMessage]
+	}
+	if !ok {
+		return nil, fmt.Errorf("Protobuf message of event type [%s] isn't configured", eventType)
+	}
+
+	message := dynamicpb.NewMessage(descriptor)
+	if err := setProtobufFields(message, "", object); err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(message)
+}
+
+//fill message fields from object fields with prefix
+func setProtobufFields(message protoreflect.Message, prefix string, object map[string]interface{}) error {
+	fields := message.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		name := prefix + string(field.Name())
+
+		//nested message is filled only if object has its fields (recursive messages are supported)
+		if field.Kind() == protoreflect.MessageKind && !field.IsList() && !field.IsMap() && field.Message().FullName() != protobufTimestamp {
+			if !hasPrefixedFields(object, name+"_") {
+				continue
+			}
+			nested := message.NewField(field).Message()
+			if err := setProtobufFields(nested, name+"_", object); err != nil {
+				return err
+			}
+			message.Set(field, protoreflect.ValueOfMessage(nested))
+			continue
+		}
+
+		value, ok := object[name]
+		if !ok || value == nil {
+			continue
+		}
+
+		if field.IsMap() {
+			return fmt.Errorf("Protobuf map field [%s] isn't supported", name)
+		}
+
+		if !field.IsList() {
+			converted, err := protobufValue(field, value)
+			if err != nil {
+				return fmt.Errorf("Error converting field [%s] to protobuf %s: %v", name, field.Kind(), err)
+			}
+			message.Set(field, converted)
+			continue
+		}
+
+		elements, err := protobufList(value)
+		if err != nil {
+			return fmt.Errorf("Error converting field [%s] to protobuf repeated %s: %v", name, field.Kind(), err)
+		}
+		list := message.Mutable(field).List()
+		for _, element := range elements {
+			converted, err := protobufValue(field, element)
+			if err != nil {
+				return fmt.Errorf("Error converting field [%s] element to protobuf %s: %v", name, field.Kind(), err)
+			}
+			list.Append(converted)
+		}
+	}
+
+	return nil
+}
+
+func hasPrefixedFields(object map[string]interface{}, prefix string) bool {
+	for name := range object {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//return protobuf value of field kind
+func protobufValue(field protoreflect.FieldDescriptor, value interface{}) (protoreflect.Value, error) {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		switch v := value.(type) {
+		case bool:
+			return protoreflect.ValueOfBool(v), nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			return protoreflect.ValueOfBool(b), nil
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		i, err := protobufInt(value, math.MinInt32, math.MaxInt32)
+		return protoreflect.ValueOfInt32(int32(i)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		i, err := protobufInt(value, math.MinInt64, math.MaxInt64)
+		return protoreflect.ValueOfInt64(i), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		i, err := protobufInt(value, 0, math.MaxUint32)
+		return protoreflect.ValueOfUint32(uint32(i)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		i, err := protobufInt(value, 0, math.MaxInt64)
+		return protoreflect.ValueOfUint64(uint64(i)), err
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		f, err := protobufFloat(value)
+		if field.Kind() == protoreflect.FloatKind {
+			return protoreflect.ValueOfFloat32(float32(f)), err
+		}
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(csvValue(value)), nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(csvValue(value))), nil
+	case protoreflect.EnumKind:
+		if name, ok := value.(string); ok {
+			enumValue := field.Enum().Values().ByName(protoreflect.Name(name))
+			if enumValue == nil {
+				return protoreflect.Value{}, fmt.Errorf("%s isn't a value of %s", name, field.Enum().FullName())
+			}
+			return protoreflect.ValueOfEnum(enumValue.Number()), nil
+		}
+		i, err := protobufInt(value, math.MinInt32, math.MaxInt32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(i)), err
+	case protoreflect.MessageKind:
+		if field.Message().FullName() == protobufTimestamp {
+			return protobufTime(dynamicpb.NewMessage(field.Message()), value)
+		}
+		return protoreflect.Value{}, errors.New("repeated messages aren't supported")
+	}
+
+	return protoreflect.Value{}, fmt.Errorf("value %v of type %T isn't supported", value, value)
+}
+
+func protobufInt(value interface{}, min, max int64) (int64, error) {
+	var i int64
+	switch v := value.(type) {
+	case float64:
+		if math.Trunc(v) != v || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, fmt.Errorf("%v isn't an integer", v)
+		}
+		i = int64(v)
+	case int:
+		i = int64(v)
+	case int64:
+		i = v
+	case int32:
+		i = int64(v)
+	case string:
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		i = parsed
+	default:
+		return 0, fmt.Errorf("value %v of type %T isn't an integer", value, value)
+	}
+
+	if i < min || i > max {
+		return 0, fmt.Errorf("%d is out of range [%d, %d]", i, min, max)
+	}
+
+	return i, nil
+}
+
+func protobufFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("value %v of type %T isn't a number", value, value)
+	}
+}
+
+//return google.protobuf.Timestamp message value of time or RFC3339 string
+func protobufTime(timestamp protoreflect.Message, value interface{}) (protoreflect.Value, error) {
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		t = parsed
+	default:
+		return protoreflect.Value{}, fmt.Errorf("value %v of type %T isn't a timestamp", value, value)
+	}
+
+	fields := timestamp.Descriptor().Fields()
+	timestamp.Set(fields.ByName("seconds"), protoreflect.ValueOfInt64(t.Unix()))
+	timestamp.Set(fields.ByName("nanos"), protoreflect.ValueOfInt32(int32(t.Nanosecond())))
+
+	return protoreflect.ValueOfMessage(timestamp), nil
+}
+
+//return array elements of repeated field value: array or JSON string of array (arrays are flattened into JSON strings)
+func protobufList(value interface{}) ([]interface{}, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		return v, nil
+	case string:
+		var elements []interface{}
+		if err := json.Unmarshal([]byte(v), &elements); err != nil {
+			return nil, fmt.Errorf("%s isn't a JSON array", v)
+		}
+		return elements, nil
+	default:
+		return []interface{}{v}, nil
+	}
+}
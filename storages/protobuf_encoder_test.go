@@ -0,0 +1,170 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testProtobufField(name string, number int32, fieldType descriptorpb.FieldDescriptorProto_Type, typeName string, repeated bool) *descriptorpb.FieldDescriptorProto {
+	label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	if repeated {
+		label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	}
+	field := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number), Type: fieldType.Enum(), Label: label.Enum()}
+	if typeName != "" {
+		field.TypeName = proto.String(typeName)
+	}
+	return field
+}
+
+//write descriptor set (with imports) of events.proto into temp dir
+func writeTestDescriptorSet(t *testing.T, dir string) string {
+	events := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("events.proto"),
+		Package:    proto.String("events"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Device"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("UNKNOWN"), Number: proto.Int32(0)},
+				{Name: proto.String("DESKTOP"), Number: proto.Int32(1)},
+				{Name: proto.String("MOBILE"), Number: proto.Int32(2)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Location"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					testProtobufField("country", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", false),
+					testProtobufField("zip", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, "", false),
+				},
+			},
+			{
+				Name: proto.String("Pageview"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					testProtobufField("event_type", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", false),
+					testProtobufField("id", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, "", false),
+					testProtobufField("price", 3, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, "", false),
+					testProtobufField("bot", 4, descriptorpb.FieldDescriptorProto_TYPE_BOOL, "", false),
+					testProtobufField("device", 5, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".events.Device", false),
+					testProtobufField("tags", 6, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", true),
+					testProtobufField("location", 7, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".events.Location", false),
+					testProtobufField("_timestamp", 8, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp", false),
+				},
+			},
+			{
+				Name: proto.String("Event"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					testProtobufField("event_type", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", false),
+				},
+			},
+		},
+	}
+
+	descriptorSet := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+		events,
+	}}
+	payload, err := proto.Marshal(descriptorSet)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "events.desc")
+	require.NoError(t, ioutil.WriteFile(path, payload, 0644))
+	return path
+}
+
+func decodeTestProtobuf(t *testing.T, encoder *protobufEncoder, messageType string, payload []byte) protoreflect.Message {
+	message := dynamicpb.NewMessage(encoder.messages[messageType])
+	require.NoError(t, proto.Unmarshal(payload, message))
+	return message
+}
+
+func TestProtobufEncoder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "protobuf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &adapters.KafkaProtobufConfig{
+		DescriptorSet: writeTestDescriptorSet(t, dir),
+		Messages:      map[string]string{"pageview": "events.Pageview", adapters.KafkaProtobufDefaultMessage: "events.Event"},
+	}
+	encoder, err := newProtobufEncoder(config)
+	require.NoError(t, err)
+
+	ts := time.Date(2020, 10, 10, 10, 10, 10, 123456000, time.UTC)
+	payload, err := encoder.Encode(map[string]interface{}{
+		"event_type":       "pageview",
+		"id":               1.0,
+		"price":            "9.99",
+		"bot":              true,
+		"device":           "MOBILE",
+		"tags":             `["a","b"]`,
+		"location_country": "US",
+		"location_zip":     10001.0,
+		"_timestamp":       ts,
+		"url":              "/home",
+	})
+	require.NoError(t, err)
+
+	message := decodeTestProtobuf(t, encoder, "pageview", payload)
+	fields := message.Descriptor().Fields()
+	require.Equal(t, "pageview", message.Get(fields.ByName("event_type")).String())
+	require.Equal(t, int64(1), message.Get(fields.ByName("id")).Int())
+	require.Equal(t, 9.99, message.Get(fields.ByName("price")).Float())
+	require.True(t, message.Get(fields.ByName("bot")).Bool())
+	require.Equal(t, protoreflect.EnumNumber(2), message.Get(fields.ByName("device")).Enum())
+
+	tags := message.Get(fields.ByName("tags")).List()
+	require.Equal(t, 2, tags.Len())
+	require.Equal(t, "a", tags.Get(0).String())
+	require.Equal(t, "b", tags.Get(1).String())
+
+	location := message.Get(fields.ByName("location")).Message()
+	require.Equal(t, "US", location.Get(location.Descriptor().Fields().ByName("country")).String())
+	require.Equal(t, int64(10001), location.Get(location.Descriptor().Fields().ByName("zip")).Int())
+
+	timestamp := message.Get(fields.ByName("_timestamp")).Message()
+	require.Equal(t, ts.Unix(), timestamp.Get(timestamp.Descriptor().Fields().ByName("seconds")).Int())
+	require.Equal(t, int64(123456000), timestamp.Get(timestamp.Descriptor().Fields().ByName("nanos")).Int())
+
+	//without nested fields nested message isn't set
+	payload, err = encoder.Encode(map[string]interface{}{"event_type": "pageview", "device": 1.0, "_timestamp": "2020-10-10T10:10:10Z"})
+	require.NoError(t, err)
+	message = decodeTestProtobuf(t, encoder, "pageview", payload)
+	require.False(t, message.Has(fields.ByName("location")))
+	require.Equal(t, protoreflect.EnumNumber(1), message.Get(fields.ByName("device")).Enum())
+
+	//default message
+	payload, err = encoder.Encode(map[string]interface{}{"event_type": "click", "id": 1.0})
+	require.NoError(t, err)
+	message = decodeTestProtobuf(t, encoder, adapters.KafkaProtobufDefaultMessage, payload)
+	require.Equal(t, "click", message.Get(message.Descriptor().Fields().ByName("event_type")).String())
+
+	_, err = encoder.Encode(map[string]interface{}{"event_type": "pageview", "id": 1.5})
+	require.EqualError(t, err, "Error converting field [id] to protobuf int64: 1.5 isn't an integer")
+	_, err = encoder.Encode(map[string]interface{}{"event_type": "pageview", "location_zip": 1e10})
+	require.EqualError(t, err, "Error converting field [location_zip] to protobuf int32: 10000000000 is out of range [-2147483648, 2147483647]")
+	_, err = encoder.Encode(map[string]interface{}{"event_type": "pageview", "device": "TV"})
+	require.EqualError(t, err, "Error converting field [device] to protobuf enum: TV isn't a value of events.Device")
+
+	delete(encoder.messages, adapters.KafkaProtobufDefaultMessage)
+	_, err = encoder.Encode(map[string]interface{}{"event_type": "click"})
+	require.EqualError(t, err, "Protobuf message of event type [click] isn't configured")
+
+	config.Messages = map[string]string{"pageview": "events.Click"}
+	_, err = newProtobufEncoder(config)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Protobuf message events.Click of event type [pageview] isn't found in descriptor_set")
+}
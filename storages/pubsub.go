@@ -0,0 +1,210 @@
+package storages
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"log"
+)
+
+//Publish processed events to Google Pub/Sub topic in two modes:
+//batch: (1 file = all objects are published and waited)
+//stream: via events queue in stream mode (1 object = 1 published and waited message, failed ones are retried if retry is configured)
+type PubSub struct {
+	name            string
+	pubSubAdapter   *adapters.PubSub
+	orderingKey     string
+	attributes      map[string]string
+	schemaProcessor *schema.Processor
+	eventQueue      *events.PersistentQueue
+	breakOnError    bool
+}
+
+//NewPubSub return PubSub and start goroutine for stream consumer if destination is in stream mode
+func NewPubSub(ctx context.Context, name, fallbackDir string, config *adapters.PubSubConfig, processor *schema.Processor,
+	breakOnError, streamMode bool) (*PubSub, error) {
+	pubSubAdapter, err := adapters.NewPubSub(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	ps := &PubSub{
+		name:            name,
+		pubSubAdapter:   pubSubAdapter,
+		orderingKey:     config.OrderingKey,
+		attributes:      config.Attributes,
+		schemaProcessor: processor,
+		breakOnError:    breakOnError,
+	}
+
+	if streamMode {
+		ps.eventQueue, err = newEventQueue(name, fallbackDir)
+		if err != nil {
+			pubSubAdapter.Close()
+			return nil, err
+		}
+
+		ps.startStreamingConsumer()
+	}
+
+	return ps, nil
+}
+
+//Consume events.Fact and enqueue it
+func (ps *PubSub) Consume(fact events.Fact) {
+	if err := ps.eventQueue.Enqueue(fact); err != nil {
+		logSkippedEvent(ps.name, fact, err)
+	}
+}
+
+//QueueSize return count of events in the stream mode queue
+func (ps *PubSub) QueueSize() int {
+	return ps.eventQueue.Size()
+}
+
+//Run goroutine to:
+//1. read from queue
+//2. publish to Pub/Sub
+func (ps *PubSub) startStreamingConsumer() {
+	go func() {
+		for {
+			if appstatus.Instance.Idle {
+				break
+			}
+			fact, ack, err := ps.eventQueue.DequeueBlockAck()
+			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
+				log.Println("Error reading event fact from pubsub queue", err)
+				continue
+			}
+
+			dataSchema, flattenObject, err := ps.schemaProcessor.ProcessFact(fact)
+			if err != nil {
+				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(ps.name, 1)
+				acknowledge(ps.name, ack)
+				continue
+			}
+
+			//don't process empty object
+			if !dataSchema.Exists() {
+				acknowledge(ps.name, ack)
+				continue
+			}
+
+			message, err := ps.toMessage(flattenObject)
+			if err != nil {
+				log.Printf("Unable to serialize object %v: %v", flattenObject, err)
+				counters.ErrorEvents(ps.name, 1)
+				acknowledge(ps.name, ack)
+				continue
+			}
+
+			if err := insertWithRetry(ps.name, fact, ack, func() error { return ps.publish(message) }); err != nil {
+				if err != errForwarded {
+					log.Printf("Error publishing to pubsub topic: %v", err)
+					counters.ErrorEvents(ps.name, 1)
+				}
+				continue
+			}
+
+			counters.SuccessEvents(ps.name, 1)
+		}
+	}()
+}
+
+//publish message to Pub/Sub (with fault injection if it is configured)
+func (ps *PubSub) publish(message *adapters.PubSubMessage) error {
+	if err := injectFault(ps.name); err != nil {
+		return err
+	}
+
+	return ps.pubSubAdapter.Publish(message)
+}
+
+//Store file payload to Pub/Sub with processing
+func (ps *PubSub) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(ps.name); err != nil {
+		return err
+	}
+
+	flatData, err := ps.schemaProcessor.ProcessFilePayload(fileName, payload, ps.breakOnError, report)
+	if err != nil {
+		return err
+	}
+
+	var messages []*adapters.PubSubMessage
+	for _, fdata := range flatData {
+		for _, object := range fdata.GetPayload() {
+			message, err := ps.toMessage(object)
+			if err != nil {
+				if ps.breakOnError {
+					return err
+				}
+				log.Printf("Warn: unable to serialize object %v from file %s: %v", object, fileName, err)
+				report.Skip(reports.ConversionReason, err)
+				continue
+			}
+			messages = append(messages, message)
+		}
+	}
+
+	return ps.pubSubAdapter.PublishBatch(messages)
+}
+
+//return message with json payload, ordering key and attributes from object fields
+func (ps *PubSub) toMessage(object map[string]interface{}) (*adapters.PubSubMessage, error) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &adapters.PubSubMessage{Data: data}
+	if ps.orderingKey != "" {
+		if value, ok := object[ps.orderingKey]; ok && value != nil {
+			message.OrderingKey = fmt.Sprint(value)
+		}
+	}
+
+	if len(ps.attributes) > 0 {
+		message.Attributes = map[string]string{}
+		for attribute, field := range ps.attributes {
+			if value, ok := object[field]; ok && value != nil {
+				message.Attributes[attribute] = fmt.Sprint(value)
+			}
+		}
+	}
+
+	return message, nil
+}
+
+func (ps *PubSub) Name() string {
+	return ps.name
+}
+
+func (ps *PubSub) Type() string {
+	return ps.pubSubAdapter.Name()
+}
+
+func (ps *PubSub) Close() (multiErr error) {
+	if ps.eventQueue != nil {
+		if err := ps.eventQueue.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing pubsub event queue: %v", err))
+		}
+	}
+
+	if err := ps.pubSubAdapter.Close(); err != nil {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing pubsub client: %v", err))
+	}
+
+	return
+}
@@ -0,0 +1,47 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPubSubToMessage(t *testing.T) {
+	ps := &PubSub{orderingKey: "user_id", attributes: map[string]string{"title": "title", "missing": "unknown"}}
+	message, err := ps.toMessage(map[string]interface{}{"user_id": "👤1", "title": "Привет 👋🏽", "id": 1})
+	require.NoError(t, err)
+	require.Equal(t, "👤1", message.OrderingKey)
+	require.Equal(t, map[string]string{"title": "Привет 👋🏽"}, message.Attributes)
+	require.Equal(t, `{"id":1,"title":"Привет 👋🏽","user_id":"👤1"}`, string(message.Data))
+}
+
+//injected faults are returned by publish (adapter isn't called) so they are retried and written into dead-letter
+func TestPubSubPublishWithRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pubsub")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, setFaultInjector("pubsub_test", &adapters.FaultInjectionConfig{ErrorRate: 1}))
+	defer setFaultInjector("pubsub_test", nil)
+	require.NoError(t, setRetryPolicy("pubsub_test", &RetryConfig{Retries: 2, InitialBackoff: time.Millisecond, DeadLetter: true}, dir))
+	defer setRetryPolicy("pubsub_test", nil, dir)
+
+	ps := &PubSub{name: "pubsub_test"}
+	message, err := ps.toMessage(map[string]interface{}{"id": 1})
+	require.NoError(t, err)
+
+	ack := &ackMock{}
+	err = insertWithRetry(ps.name, events.Fact{"id": 1}, ack.ack, func() error { return ps.publish(message) })
+	require.Equal(t, adapters.ErrInjectedFault, err)
+	require.Equal(t, 1, ack.acked)
+
+	deadLetter, err := ioutil.ReadFile(path.Join(dir, deadLetterDir, "pubsub_test.log"))
+	require.NoError(t, err)
+	require.Equal(t, `{"id":1}`, strings.TrimSpace(string(deadLetter)))
+}
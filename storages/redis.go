@@ -0,0 +1,205 @@
+package storages
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"log"
+	"strings"
+)
+
+const redisTablePlaceholder = "{table}"
+
+//Add processed events into Redis Streams in two modes:
+//batch: (1 file = 1 pipeline of XADD commands)
+//stream: via events queue in stream mode (1 object = 1 XADD command)
+//stream key is built from stream template and table name
+type Redis struct {
+	name            string
+	redisAdapter    *adapters.Redis
+	streamTemplate  string
+	schemaProcessor *schema.Processor
+	eventQueue      *events.PersistentQueue
+	breakOnError    bool
+}
+
+//NewRedis return Redis and start goroutine for stream consumer if destination is in stream mode
+func NewRedis(name, fallbackDir string, config *adapters.RedisConfig, processor *schema.Processor, breakOnError, streamMode bool) (*Redis, error) {
+	redisAdapter, err := adapters.NewRedis(config)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Redis{
+		name:            name,
+		redisAdapter:    redisAdapter,
+		streamTemplate:  config.StreamTemplate,
+		schemaProcessor: processor,
+		breakOnError:    breakOnError,
+	}
+
+	if streamMode {
+		r.eventQueue, err = newEventQueue(name, fallbackDir)
+		if err != nil {
+			redisAdapter.Close()
+			return nil, err
+		}
+
+		r.startStreamingConsumer()
+	}
+
+	return r, nil
+}
+
+//Consume events.Fact and enqueue it
+func (r *Redis) Consume(fact events.Fact) {
+	if err := r.eventQueue.Enqueue(fact); err != nil {
+		logSkippedEvent(r.name, fact, err)
+	}
+}
+
+//QueueSize return count of events in the stream mode queue
+func (r *Redis) QueueSize() int {
+	return r.eventQueue.Size()
+}
+
+//Run goroutine to:
+//1. read from queue
+//2. add into Redis stream
+func (r *Redis) startStreamingConsumer() {
+	go func() {
+		for {
+			if appstatus.Instance.Idle {
+				break
+			}
+			fact, ack, err := r.eventQueue.DequeueBlockAck()
+			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
+				log.Println("Error reading event fact from redis queue", err)
+				continue
+			}
+
+			dataSchema, flattenObject, err := r.schemaProcessor.ProcessFact(fact)
+			if err != nil {
+				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(r.name, 1)
+				acknowledge(r.name, ack)
+				continue
+			}
+
+			//don't process empty object
+			if !dataSchema.Exists() {
+				acknowledge(r.name, ack)
+				continue
+			}
+
+			message, err := r.toMessage(dataSchema.Name, flattenObject)
+			if err != nil {
+				log.Printf("Unable to serialize object %v: %v", flattenObject, err)
+				counters.ErrorEvents(r.name, 1)
+				acknowledge(r.name, ack)
+				continue
+			}
+
+			if err := insertWithRetry(r.name, fact, ack, func() error { return r.add(message) }); err != nil {
+				if err != errForwarded {
+					log.Printf("Error adding to redis stream [%s]: %v", message.Stream, err)
+					counters.ErrorEvents(r.name, 1)
+				}
+				continue
+			}
+
+			counters.SuccessEvents(r.name, 1)
+		}
+	}()
+}
+
+//add message to Redis (with fault injection if it is configured)
+func (r *Redis) add(message *adapters.RedisMessage) error {
+	if err := injectFault(r.name); err != nil {
+		return err
+	}
+
+	return r.redisAdapter.Add(message)
+}
+
+//Store file payload to Redis with processing: every table file is added as a pipeline of entries
+func (r *Redis) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(r.name); err != nil {
+		return err
+	}
+
+	flatData, err := r.schemaProcessor.ProcessFilePayload(fileName, payload, r.breakOnError, report)
+	if err != nil {
+		return err
+	}
+
+	for _, fdata := range flatData {
+		var messages []*adapters.RedisMessage
+		for _, object := range fdata.GetPayload() {
+			message, err := r.toMessage(fdata.DataSchema.Name, object)
+			if err != nil {
+				if r.breakOnError {
+					return err
+				}
+				log.Printf("Warn: unable to serialize object %v from file %s: %v", object, fileName, err)
+				report.Skip(reports.ConversionReason, err)
+				continue
+			}
+			messages = append(messages, message)
+		}
+
+		if len(messages) == 0 {
+			continue
+		}
+
+		if err := r.redisAdapter.AddBatch(messages); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//return message with templated stream key and json payload
+func (r *Redis) toMessage(tableName string, object map[string]interface{}) (*adapters.RedisMessage, error) {
+	payload, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	return &adapters.RedisMessage{
+		Stream:  strings.ReplaceAll(r.streamTemplate, redisTablePlaceholder, tableName),
+		Payload: payload,
+	}, nil
+}
+
+func (r *Redis) Name() string {
+	return r.name
+}
+
+func (r *Redis) Type() string {
+	return r.redisAdapter.Name()
+}
+
+func (r *Redis) Close() (multiErr error) {
+	if r.eventQueue != nil {
+		if err := r.eventQueue.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing redis event queue: %v", err))
+		}
+	}
+
+	if err := r.redisAdapter.Close(); err != nil {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing redis client: %v", err))
+	}
+
+	return
+}
@@ -7,8 +7,13 @@ import (
 	"github.com/ksensehq/eventnative/adapters"
 	"github.com/ksensehq/eventnative/appconfig"
 	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/dbt"
 	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
 	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/ksensehq/eventnative/watermarks"
 	"log"
 	"strings"
 	"time"
@@ -32,13 +37,12 @@ type AwsRedshift struct {
 
 //NewAwsRedshift return AwsRedshift and start goroutine for aws redshift batch storage or for stream consumer depend on destination mode
 func NewAwsRedshift(ctx context.Context, name, fallbackDir string, s3Config *adapters.S3Config, redshiftConfig *adapters.DataSourceConfig,
-	processor *schema.Processor, breakOnError, streamMode bool) (*AwsRedshift, error) {
+	processor *schema.Processor, ddlWriter *DDLWriter, breakOnError, streamMode bool) (*AwsRedshift, error) {
 	var s3Adapter *adapters.S3
 	var eventQueue *events.PersistentQueue
 	if streamMode {
 		var err error
-		queueName := fmt.Sprintf("%s-%s", appconfig.Instance.ServerName, name)
-		eventQueue, err = events.NewPersistentQueue(queueName, fallbackDir)
+		eventQueue, err = newEventQueue(name, fallbackDir)
 		if err != nil {
 			return nil, err
 		}
@@ -55,14 +59,15 @@ func NewAwsRedshift(ctx context.Context, name, fallbackDir string, s3Config *ada
 		return nil, err
 	}
 
-	//create db schema if doesn't exist
-	err = redshiftAdapter.CreateDbSchema(redshiftConfig.Schema)
-	if err != nil {
-		return nil, err
+	//create db schema if doesn't exist (nothing is created with existing tables or read-only schema)
+	if processor.ExistingTables() == nil && ddlWriter == nil {
+		if err := redshiftAdapter.CreateDbSchema(redshiftConfig.Schema); err != nil {
+			return nil, err
+		}
 	}
 
 	monitorKeeper := NewMonitorKeeper()
-	tableHelper := NewTableHelper(redshiftAdapter, monitorKeeper, redshiftStorageType)
+	tableHelper := NewTableHelper(redshiftAdapter, monitorKeeper, redshiftStorageType, processor.ExistingTables(), ddlWriter, processor.SchemaEvolution(), processor.ColumnsLimit(), processor.TablesCache(), processor.MetadataCache())
 
 	ar := &AwsRedshift{
 		name:            name,
@@ -75,7 +80,9 @@ func NewAwsRedshift(ctx context.Context, name, fallbackDir string, s3Config *ada
 	}
 
 	if streamMode {
-		ar.startStreamingConsumer()
+		for i := 0; i < streamWorkers(name); i++ {
+			ar.startStreamingConsumer()
+		}
 	} else {
 		ar.startBatchStorage()
 	}
@@ -92,8 +99,11 @@ func (ar *AwsRedshift) startStreamingConsumer() {
 			if appstatus.Instance.Idle {
 				break
 			}
-			fact, err := ar.eventQueue.DequeueBlock()
+			fact, ack, err := ar.eventQueue.DequeueBlockAck()
 			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
 				log.Println("Error reading event fact from redshift queue", err)
 				continue
 			}
@@ -101,18 +111,28 @@ func (ar *AwsRedshift) startStreamingConsumer() {
 			dataSchema, flattenObject, err := ar.schemaProcessor.ProcessFact(fact)
 			if err != nil {
 				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(ar.name, 1)
+				acknowledge(ar.name, ack)
 				continue
 			}
 
 			//don't process empty object
 			if !dataSchema.Exists() {
+				acknowledge(ar.name, ack)
 				continue
 			}
 
-			if err := ar.insert(dataSchema, flattenObject); err != nil {
-				log.Printf("Error inserting to redshift table [%s]: %v", dataSchema.Name, err)
+			eventTime := ar.schemaProcessor.EventTime(dataSchema, flattenObject)
+			if err := insertWithRetry(ar.name, fact, ack, func() error { return ar.insert(dataSchema, flattenObject) }); err != nil {
+				if err != errForwarded {
+					log.Printf("Error inserting to redshift table [%s]: %v", dataSchema.Name, err)
+					counters.ErrorEvents(ar.name, 1)
+				}
 				continue
 			}
+
+			counters.SuccessEvents(ar.name, 1)
+			watermarks.Commit(ar.name, dataSchema.Name, eventTime)
 		}
 	}()
 }
@@ -174,18 +194,27 @@ func (ar *AwsRedshift) startBatchStorage() {
 //Consume events.Fact and enqueue it
 func (ar *AwsRedshift) Consume(fact events.Fact) {
 	if err := ar.eventQueue.Enqueue(fact); err != nil {
-		logSkippedEvent(fact, err)
+		logSkippedEvent(ar.name, fact, err)
 	}
 }
 
+//QueueSize return count of events in the stream mode queue
+func (ar *AwsRedshift) QueueSize() int {
+	return ar.eventQueue.Size()
+}
+
 //insert fact in Redshift
 func (ar *AwsRedshift) insert(dataSchema *schema.Table, fact events.Fact) (err error) {
-	dbSchema, err := ar.tableHelper.EnsureTable(dataSchema)
+	if err := injectFault(ar.name); err != nil {
+		return err
+	}
+
+	dbSchema, err := ar.tableHelper.EnsureTable(dataSchema, fact)
 	if err != nil {
 		return err
 	}
 
-	if err := ar.schemaProcessor.ApplyDBTypingToObject(dbSchema, fact); err != nil {
+	if err := ar.tableHelper.ApplyDBTypingToObject(ar.schemaProcessor, dbSchema, dataSchema, fact); err != nil {
 		return err
 	}
 
@@ -193,19 +222,23 @@ func (ar *AwsRedshift) insert(dataSchema *schema.Table, fact events.Fact) (err e
 }
 
 //Store file from byte payload to s3 with processing
-func (ar *AwsRedshift) Store(fileName string, payload []byte) error {
-	flatData, err := ar.schemaProcessor.ProcessFilePayload(fileName, payload, ar.breakOnError)
+func (ar *AwsRedshift) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(ar.name); err != nil {
+		return err
+	}
+
+	flatData, err := ar.schemaProcessor.ProcessFilePayload(fileName, payload, ar.breakOnError, report)
 	if err != nil {
 		return err
 	}
 
 	for _, fdata := range flatData {
-		dbSchema, err := ar.tableHelper.EnsureTable(fdata.DataSchema)
+		dbSchema, err := ar.tableHelper.EnsureTable(fdata.DataSchema, fdata.GetPayload()...)
 		if err != nil {
 			return err
 		}
 
-		if err := ar.schemaProcessor.ApplyDBTyping(dbSchema, fdata); err != nil {
+		if err := ar.tableHelper.ApplyDBTyping(ar.schemaProcessor, dbSchema, fdata); err != nil {
 			return err
 		}
 	}
@@ -221,6 +254,37 @@ func (ar *AwsRedshift) Store(fileName string, payload []byte) error {
 	return nil
 }
 
+//Tables return tables schemas known by the destination
+func (ar *AwsRedshift) Tables() []*schema.Table {
+	return ar.tableHelper.Tables()
+}
+
+//DropColumnDDL return statements which drop the column of the destination table
+func (ar *AwsRedshift) DropColumnDDL(tableName, columnName string) ([]string, error) {
+	return ar.tableHelper.DropColumnDDL(tableName, columnName)
+}
+
+//DropColumn drop the column of the destination table
+func (ar *AwsRedshift) DropColumn(tableName, columnName string) error {
+	return ar.tableHelper.DropColumn(tableName, columnName)
+}
+
+//DbtSource return dbt source of tables known by the destination
+func (ar *AwsRedshift) DbtSource(freshness *dbt.Freshness) *dbt.Source {
+	database, schemaName := ar.redshiftAdapter.Location()
+	return dbt.NewSource(ar.name, database, schemaName, ar.Tables(), ar.schemaProcessor.SystemColumn(timestamp.Key), ar.schemaProcessor.ColumnDescription, freshness)
+}
+
+//SelectLast return last rows of the table where keyColumn equals value
+func (ar *AwsRedshift) SelectLast(tableName, keyColumn, orderColumn string, value interface{}, limit int) ([]map[string]interface{}, error) {
+	return ar.redshiftAdapter.SelectLast(tableName, keyColumn, orderColumn, value, limit)
+}
+
+//SelectMetric return rows of predefined aggregate query
+func (ar *AwsRedshift) SelectMetric(query *adapters.MetricQuery) ([]map[string]interface{}, error) {
+	return ar.redshiftAdapter.SelectMetric(query)
+}
+
 func (ar *AwsRedshift) Name() string {
 	return ar.name
 }
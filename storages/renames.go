@@ -0,0 +1,30 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/stateless"
+	"path"
+)
+
+const renamesDir = "renames"
+
+//keep detected renames of the destination in $logEventPath/renames/$destination.json (if renames are enabled)
+//they are kept only in memory in stateless mode
+func persistRenames(destinationName, logEventPath string, processor *schema.Processor) error {
+	if processor.Renames() == nil || stateless.Enabled() {
+		return nil
+	}
+
+	return processor.Renames().Persist(path.Join(logEventPath, renamesDir, destinationName+".json"))
+}
+
+//GetRenames return renamed fields which have been detected in the destination tables (see schema.Renames)
+//return false if the destination isn't running or data_layout.renames isn't configured
+func GetRenames(destinationName string) ([]*schema.Rename, bool) {
+	processor := getProcessor(destinationName)
+	if processor == nil || processor.Renames() == nil {
+		return nil, false
+	}
+
+	return processor.Renames().List(), true
+}
@@ -0,0 +1,284 @@
+package storages
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/eventid"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/forwarding"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+const (
+	deadLetterDir = "dead-letter"
+
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Minute
+)
+
+//errForwarded is returned by insertWithRetry if the event has been handed over to a peer node
+var errForwarded = errors.New("event has been forwarded to a peer node")
+
+//retry policies per destination name (only stream destinations with retry config)
+var (
+	retryPoliciesMutex sync.RWMutex
+	retryPolicies      = map[string]*RetryPolicy{}
+)
+
+//RetryConfig dto for deserialized destination retry config (stream mode only)
+//retries: count of retries after the failed insert. Every next backoff is doubled: initial_backoff, 2*initial_backoff, .. up to max_backoff
+//dead_letter: if true, events are written into $log.path/dead-letter/$destination.log after all retries are failed
+type RetryConfig struct {
+	Retries        int           `mapstructure:"retries"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+	DeadLetter     bool          `mapstructure:"dead_letter"`
+}
+
+//Validate RetryConfig fields and set default values
+func (rc *RetryConfig) Validate() error {
+	if rc.Retries < 0 {
+		return errors.New("retry.retries can't be negative")
+	}
+	if rc.InitialBackoff < 0 || rc.MaxBackoff < 0 {
+		return errors.New("retry.initial_backoff and retry.max_backoff can't be negative")
+	}
+	if rc.InitialBackoff == 0 {
+		rc.InitialBackoff = defaultInitialBackoff
+	}
+	if rc.MaxBackoff == 0 {
+		rc.MaxBackoff = defaultMaxBackoff
+	}
+	if rc.MaxBackoff < rc.InitialBackoff {
+		return fmt.Errorf("retry.max_backoff [%s] can't be less than retry.initial_backoff [%s]", rc.MaxBackoff, rc.InitialBackoff)
+	}
+
+	return nil
+}
+
+//RetryPolicy retries failed inserts of stream mode events with exponential backoff (stream worker waits meanwhile)
+//and writes the event into dead-letter file if all retries are failed
+type RetryPolicy struct {
+	retries        int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	deadLetter     *DeadLetter
+}
+
+//create (or remove if config is nil) destination RetryPolicy
+func setRetryPolicy(destinationName string, config *RetryConfig, logEventPath string) error {
+	retryPoliciesMutex.Lock()
+	defer retryPoliciesMutex.Unlock()
+
+	if config == nil {
+		delete(retryPolicies, destinationName)
+		return nil
+	}
+
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	policy := &RetryPolicy{retries: config.Retries, initialBackoff: config.InitialBackoff, maxBackoff: config.MaxBackoff}
+	if config.DeadLetter {
+		deadLetter, err := NewDeadLetter(path.Join(logEventPath, deadLetterDir), destinationName)
+		if err != nil {
+			return err
+		}
+		policy.deadLetter = deadLetter
+	}
+
+	retryPolicies[destinationName] = policy
+	return nil
+}
+
+func getRetryPolicy(destinationName string) *RetryPolicy {
+	retryPoliciesMutex.RLock()
+	defer retryPoliciesMutex.RUnlock()
+
+	return retryPolicies[destinationName]
+}
+
+//insertWithRetry run insert and retry it according to destination RetryPolicy (if it is configured)
+//if all retries are failed, the event is forwarded to a peer node (if forwarding is configured and the storage is unavailable)
+//or written into dead-letter file
+//the event is acknowledged in the queue (see events.AckFunc) if it has been inserted, forwarded or written into dead-letter file
+//return errForwarded if the event has been accepted by a peer node or the last insert error
+func insertWithRetry(destinationName string, fact events.Fact, ack events.AckFunc, insert func() error) error {
+	insert = observeLatency(destinationName, eventid.Get(fact), insert)
+	err := insert()
+	if err == nil {
+		setHealthy(destinationName, true)
+		acknowledge(destinationName, ack)
+		return nil
+	}
+
+	policy := getRetryPolicy(destinationName)
+	if policy != nil {
+		backoff := policy.initialBackoff
+		for attempt := 1; attempt <= policy.retries; attempt++ {
+			//don't block shutdown: the event is written into dead-letter file
+			if appstatus.Instance.Idle {
+				break
+			}
+
+			time.Sleep(backoff)
+			if err = insert(); err == nil {
+				setHealthy(destinationName, true)
+				acknowledge(destinationName, ack)
+				return nil
+			}
+
+			backoff *= 2
+			if backoff > policy.maxBackoff {
+				backoff = policy.maxBackoff
+			}
+		}
+	}
+	unavailable := isUnavailableErr(err)
+	setHealthy(destinationName, !unavailable)
+
+	//the destination might be reachable from other nodes (e.g. network partition). Other errors (e.g. schema ones) would fail
+	//on peers too and events which have been forwarded to this node aren't forwarded back
+	if forwarding.Instance != nil && unavailable && !forwarding.IsForwarded(fact) {
+		peer, forwardErr := forwarding.Instance.Forward(destinationName, []events.Fact{fact})
+		if forwardErr == nil {
+			log.Printf("[%s] Event has been forwarded to %s: %v", destinationName, peer, err)
+			acknowledge(destinationName, ack)
+			return errForwarded
+		}
+		log.Printf("[%s] Error forwarding event: %v", destinationName, forwardErr)
+	}
+
+	if policy != nil && policy.deadLetter != nil {
+		if dlErr := policy.deadLetter.Write(fact); dlErr != nil {
+			log.Printf("[%s] Error writing event into dead-letter file: %v", destinationName, dlErr)
+		} else {
+			log.Printf("[%s] Event has been written into dead-letter file %s after %d retries", destinationName, policy.deadLetter.filePath, policy.retries)
+			acknowledge(destinationName, ack)
+		}
+	}
+
+	return err
+}
+
+//acknowledge the event in the destination queue: external queues keep not acknowledged events
+func acknowledge(destinationName string, ack events.AckFunc) {
+	if err := ack(); err != nil {
+		log.Printf("[%s] Error acknowledging event in the queue: %v", destinationName, err)
+	}
+}
+
+//DeadLetter keeps events which weren't inserted after all retries in a file (one json event per line)
+//they can be replayed into the destination via admin API
+type DeadLetter struct {
+	mutex    sync.Mutex
+	filePath string
+}
+
+//NewDeadLetter create dir if it doesn't exist and return DeadLetter of the destination
+func NewDeadLetter(dir, destinationName string) (*DeadLetter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Error creating dead-letter dir [%s]: %v", dir, err)
+	}
+
+	return &DeadLetter{filePath: path.Join(dir, destinationName+".log")}, nil
+}
+
+//Write append event into the file
+func (dl *DeadLetter) Write(fact events.Fact) error {
+	b, err := json.Marshal(fact)
+	if err != nil {
+		return fmt.Errorf("Error marshaling event: %v", err)
+	}
+
+	dl.mutex.Lock()
+	defer dl.mutex.Unlock()
+
+	file, err := os.OpenFile(dl.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(b, '\n'))
+	return err
+}
+
+//Replay pass all events from the file into consumer and remove the file
+//return count of replayed events. Malformed lines are skipped
+func (dl *DeadLetter) Replay(consumer events.Consumer) (int, error) {
+	dl.mutex.Lock()
+	b, err := ioutil.ReadFile(dl.filePath)
+	if err == nil {
+		err = os.Remove(dl.filePath)
+	}
+	dl.mutex.Unlock()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	replayed := 0
+	for _, line := range bytes.Split(b, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		fact := events.Fact{}
+		if err := json.Unmarshal(line, &fact); err != nil {
+			log.Printf("Error parsing dead-letter event %s: %v", string(line), err)
+			continue
+		}
+		consumer.Consume(fact)
+		replayed++
+	}
+
+	return replayed, nil
+}
+
+//ReplayDeadLetter pass events from destination dead-letter file into the destination stream queue
+//return count of replayed events and false if destination doesn't exist or doesn't have dead-letter
+func ReplayDeadLetter(name string) (int, bool, error) {
+	policy := getRetryPolicy(name)
+	if policy == nil || policy.deadLetter == nil {
+		return 0, false, nil
+	}
+
+	registry.mutex.RLock()
+	destination, ok := registry.destinations[name]
+	registry.mutex.RUnlock()
+	if !ok {
+		return 0, false, nil
+	}
+
+	consumer, ok := destination.(events.Consumer)
+	if !ok {
+		return 0, false, nil
+	}
+
+	replayed, err := policy.deadLetter.Replay(consumer)
+	return replayed, true, err
+}
+
+//return write func which observes latency of successful writes (id is an exemplar id, see counters.WriteLatency)
+func observeLatency(destinationName, id string, write func() error) func() error {
+	return func() error {
+		started := time.Now()
+		err := write()
+		if err == nil {
+			counters.WriteLatency(destinationName, time.Since(started), id)
+		}
+		return err
+	}
+}
@@ -0,0 +1,181 @@
+package storages
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/forwarding"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type consumerMock struct {
+	consumed []events.Fact
+}
+
+func (cm *consumerMock) Consume(fact events.Fact) {
+	cm.consumed = append(cm.consumed, fact)
+}
+
+func (cm *consumerMock) Close() error {
+	return nil
+}
+
+//AckFunc which counts acknowledgements
+type ackMock struct {
+	acked int
+}
+
+func (am *ackMock) ack() error {
+	am.acked++
+	return nil
+}
+
+func TestInsertWithRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "retry")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := &RetryConfig{Retries: 2, InitialBackoff: time.Millisecond, DeadLetter: true}
+	require.NoError(t, setRetryPolicy("retry_test", config, dir))
+	defer setRetryPolicy("retry_test", nil, dir)
+	require.Equal(t, time.Minute, config.MaxBackoff)
+
+	//succeeded with the second retry
+	attempts := 0
+	ack := &ackMock{}
+	err = insertWithRetry("retry_test", events.Fact{"id": "1"}, ack.ack, func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("insert error")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+	require.Equal(t, 1, ack.acked)
+
+	//all retries are failed: event is written into dead-letter file
+	attempts = 0
+	ack = &ackMock{}
+	err = insertWithRetry("retry_test", events.Fact{"id": "2"}, ack.ack, func() error {
+		attempts++
+		return errors.New("insert error")
+	})
+	require.EqualError(t, err, "insert error")
+	require.Equal(t, 3, attempts)
+	require.Equal(t, 1, ack.acked)
+
+	//without dead-letter the event isn't acknowledged: it is kept in the external queue
+	ack = &ackMock{}
+	err = insertWithRetry("without_retry_test", events.Fact{"id": "3"}, ack.ack, func() error { return errors.New("insert error") })
+	require.EqualError(t, err, "insert error")
+	require.Equal(t, 0, ack.acked)
+
+	consumer := &consumerMock{}
+	registerDestination(&DestinationStatus{Name: "retry_test", Mode: streamMode}, consumer)
+	replayed, ok, err := ReplayDeadLetter("retry_test")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, replayed)
+	require.Equal(t, []events.Fact{{"id": "2"}}, consumer.consumed)
+
+	//dead-letter file is removed after replay
+	replayed, ok, err = ReplayDeadLetter("retry_test")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 0, replayed)
+
+	_, ok, _ = ReplayDeadLetter("unknown")
+	require.False(t, ok)
+}
+
+//both nodes can't insert the event: node-2 inserts forwarded events itself and would forward them back to node-1 on failure
+//(forwarding.Instance is node-1 forwarder which sends events to node-2 server in this test)
+func TestInsertWithRetryForwarding(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+
+	schemaErr := errors.New(`pq: column "amount" is of type integer but expression is of type text`)
+	connectionErr := fmt.Errorf("Error inserting in events table: %v", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED})
+
+	var insertErr, node2Err error
+	var received []events.Fact
+	node2, err := forwarding.NewForwarder(&forwarding.Config{Secret: "secret"}, "node-2")
+	require.NoError(t, err)
+	router := gin.New()
+	router.POST(forwarding.Path, node2.Handler(func(destination string, facts []events.Fact) error {
+		for _, fact := range facts {
+			received = append(received, fact)
+			node2Err = insertWithRetry(destination, fact, events.NoAck, func() error { return insertErr })
+		}
+		return nil
+	}))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	node1, err := forwarding.NewForwarder(&forwarding.Config{Peers: []string{server.URL}, Secret: "secret"}, "node-1")
+	require.NoError(t, err)
+	forwarding.Instance = node1
+	defer func() { forwarding.Instance = nil }()
+
+	//schema error isn't forwarded: the destination is healthy
+	insertErr = schemaErr
+	err = insertWithRetry("forwarding_test", events.Fact{"amount": "1"}, events.NoAck, func() error { return insertErr })
+	require.Equal(t, schemaErr, err)
+	require.Empty(t, received)
+	require.True(t, isHealthy("forwarding_test"))
+
+	//unavailable destination: the event is forwarded to node-2 and isn't forwarded back after node-2 insert failure
+	insertErr = connectionErr
+	err = insertWithRetry("forwarding_test", events.Fact{"amount": "1"}, events.NoAck, func() error { return insertErr })
+	require.Equal(t, errForwarded, err)
+	require.Equal(t, []events.Fact{{"amount": "1", events.ForwardedByKey: "node-1"}}, received)
+	require.Equal(t, connectionErr, node2Err)
+	require.False(t, isHealthy("forwarding_test"))
+	setHealthy("forwarding_test", true)
+
+	//forwarded event which fails with schema error on node-2 isn't forwarded back too
+	insertErr = schemaErr
+	received = nil
+	_, err = node1.Forward("forwarding_test", []events.Fact{{"amount": "1"}})
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	require.Equal(t, schemaErr, node2Err)
+}
+
+func TestIsUnavailableErr(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil", nil, false},
+		{"net error", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		{"wrapped net error", fmt.Errorf("Error inserting: %w", &net.DNSError{Err: "no such host", Name: "db"}), true},
+		{"EOF", io.EOF, true},
+		{"injected fault", adapters.ErrInjectedFault, true},
+		{"wrapped without %w", fmt.Errorf("Error inserting: %v", errors.New("dial tcp 10.0.0.1:5432: connect: connection refused")), true},
+		{"schema error", errors.New(`pq: column "amount" is of type integer but expression is of type text`), false},
+		{"data error", errors.New("code: 27, message: Cannot parse input"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, isUnavailableErr(tt.err))
+		})
+	}
+}
+
+func TestRetryConfigValidate(t *testing.T) {
+	require.EqualError(t, (&RetryConfig{Retries: -1}).Validate(), "retry.retries can't be negative")
+	require.EqualError(t, (&RetryConfig{Retries: 1, InitialBackoff: time.Minute, MaxBackoff: time.Second}).Validate(),
+		"retry.max_backoff [1s] can't be less than retry.initial_backoff [1m0s]")
+}
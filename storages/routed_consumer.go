@@ -0,0 +1,47 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/routing"
+)
+
+//RoutedConsumer passes into the stream destination only events which are routed into it (see routing.Router)
+type RoutedConsumer struct {
+	events.Consumer
+	name string
+}
+
+//return consumer as is if routing isn't configured
+func newRoutedConsumer(name string, consumer events.Consumer) events.Consumer {
+	if routing.Instance == nil {
+		return consumer
+	}
+
+	return &RoutedConsumer{Consumer: consumer, name: name}
+}
+
+//Consume events.Fact if it is routed into the destination
+func (rc *RoutedConsumer) Consume(fact events.Fact) {
+	if routing.Routed(rc.name, fact) {
+		rc.Consumer.Consume(fact)
+	}
+}
+
+//destinationsConsumer is events.DestinationsConsumer of the stream destination (one name) or the token event logger
+//(names of the token batch destinations)
+type destinationsConsumer struct {
+	events.Consumer
+	names []string
+}
+
+//Destinations return names of destinations which the fact is routed into
+func (dc *destinationsConsumer) Destinations(fact events.Fact) []string {
+	var routed []string
+	for _, name := range dc.names {
+		if routing.Routed(name, fact) {
+			routed = append(routed, name)
+		}
+	}
+
+	return routed
+}
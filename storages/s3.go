@@ -1,19 +1,41 @@
 package storages
 
 import (
+	"fmt"
+	"github.com/hashicorp/go-multierror"
 	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
 	"github.com/ksensehq/eventnative/schema"
+	"log"
+	"strings"
 )
 
-//Store files to aws s3 in batch mode
+const glueTableLocationPlaceholder = "{table}/"
+
+//Store files to aws s3 in two modes:
+//batch: (1 file = 1 s3 object per table)
+//stream: via events queue and FileBatcher (objects are rotated into NDJSON files and uploaded periodically)
+//if glue config is provided - tables and partitions are registered in aws Glue data catalog (files are queryable in Athena)
 type S3 struct {
 	name            string
 	s3Adapter       *adapters.S3
+	glueAdapter     *adapters.Glue
+	glueTableHelper *TableHelper
+	gluePartitions  map[string]bool
+	uploader        *fileUploader
 	schemaProcessor *schema.Processor
+	eventQueue      *events.PersistentQueue
+	fileBatcher     *FileBatcher
 	breakOnError    bool
 }
 
-func NewS3(name string, s3Config *adapters.S3Config, processor *schema.Processor, breakOnError bool) (*S3, error) {
+//NewS3 return S3 and start goroutine for stream consumer if destination is in stream mode
+//glueConfig is optional
+func NewS3(name, fallbackDir string, s3Config *adapters.S3Config, filesConfig *FilesConfig, glueConfig *adapters.GlueConfig,
+	processor *schema.Processor, breakOnError, streamMode bool) (*S3, error) {
 	s3Adapter, err := adapters.NewS3(s3Config)
 	if err != nil {
 		return nil, err
@@ -22,23 +44,99 @@ func NewS3(name string, s3Config *adapters.S3Config, processor *schema.Processor
 	s3 := &S3{
 		name:            name,
 		s3Adapter:       s3Adapter,
+		uploader:        newFileUploader(filesConfig, s3Adapter.UploadBytes),
 		schemaProcessor: processor,
 		breakOnError:    breakOnError,
 	}
 
+	if glueConfig != nil {
+		tablesPrefix, partitionKeys, err := GlueLayout(filesConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		s3.glueAdapter, err = adapters.NewGlue(glueConfig, s3Config, tablesPrefix, partitionKeys)
+		if err != nil {
+			return nil, err
+		}
+		s3.glueTableHelper = NewTableHelper(s3.glueAdapter, NewMonitorKeeper(), s3.glueAdapter.Name(), nil, nil, nil, nil, nil, nil)
+		s3.gluePartitions = map[string]bool{}
+		s3.uploader.onPartition = s3.createGluePartition
+	}
+
+	if streamMode {
+		s3.eventQueue, err = newEventQueue(name, fallbackDir)
+		if err != nil {
+			return nil, err
+		}
+
+		s3.fileBatcher = NewFileBatcher(name, filesConfig, s3.upload)
+		s3.startStreamingConsumer()
+	}
+
 	return s3, nil
 }
 
+//Consume events.Fact and enqueue it
+func (s3 *S3) Consume(fact events.Fact) {
+	if err := s3.eventQueue.Enqueue(fact); err != nil {
+		logSkippedEvent(s3.name, fact, err)
+	}
+}
+
+//QueueSize return count of events in the stream mode queue
+func (s3 *S3) QueueSize() int {
+	return s3.eventQueue.Size()
+}
+
+//Run goroutine to:
+//1. read from queue
+//2. put processed object into FileBatcher
+func (s3 *S3) startStreamingConsumer() {
+	go func() {
+		for {
+			if appstatus.Instance.Idle {
+				break
+			}
+			fact, err := s3.eventQueue.DequeueBlock()
+			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
+				log.Println("Error reading event fact from s3 queue", err)
+				continue
+			}
+
+			dataSchema, flattenObject, err := s3.schemaProcessor.ProcessFact(fact)
+			if err != nil {
+				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(s3.name, 1)
+				continue
+			}
+
+			//don't process empty object
+			if !dataSchema.Exists() {
+				continue
+			}
+
+			s3.fileBatcher.Add(dataSchema, flattenObject)
+		}
+	}()
+}
+
 //Store file from byte payload to s3 with processing
-func (s3 *S3) Store(fileName string, payload []byte) error {
-	flatData, err := s3.schemaProcessor.ProcessFilePayload(fileName, payload, s3.breakOnError)
+func (s3 *S3) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(s3.name); err != nil {
+		return err
+	}
+
+	flatData, err := s3.schemaProcessor.ProcessFilePayload(fileName, payload, s3.breakOnError, report)
 	if err != nil {
 		return err
 	}
 
 	for _, fdata := range flatData {
-		err := s3.s3Adapter.UploadBytes(fdata.FileName+tableFileKeyDelimiter+fdata.DataSchema.Name, fdata.GetPayloadBytes())
-		if err != nil {
+		if err := s3.upload(fdata); err != nil {
 			return err
 		}
 	}
@@ -46,6 +144,89 @@ func (s3 *S3) Store(fileName string, payload []byte) error {
 	return nil
 }
 
+//ensure glue table (if configured) and upload file
+func (s3 *S3) upload(fdata *schema.ProcessedFile) error {
+	if s3.glueTableHelper != nil {
+		//table helper keeps table schema in memory so it mustn't share columns with processed file
+		columns := schema.Columns{}
+		columns.Merge(fdata.DataSchema.Columns)
+		if _, err := s3.glueTableHelper.EnsureTable(&schema.Table{Name: fdata.DataSchema.Name, Columns: columns}, fdata.GetPayload()...); err != nil {
+			return err
+		}
+	}
+
+	return s3.uploader.upload(fdata)
+}
+
+//register glue partition if it wasn't registered before
+//partition values are parsed from Hive style path: key1=value1/key2=value2
+func (s3 *S3) createGluePartition(tableName, partitionPath string) error {
+	key := tableName + "/" + partitionPath
+	if s3.gluePartitions[key] {
+		return nil
+	}
+
+	var values []string
+	for _, segment := range strings.Split(partitionPath, "/") {
+		values = append(values, segment[strings.Index(segment, "=")+1:])
+	}
+
+	if err := s3.glueAdapter.CreatePartition(tableName, partitionPath, values); err != nil {
+		return err
+	}
+
+	s3.gluePartitions[key] = true
+	return nil
+}
+
+//GlueLayout return static tables prefix and partition keys from files config
+//Glue tables require objects layout: <static prefix>{table}/{partition}/<file name> where partition path is Hive style
+//e.g. name_template: events/{table}/{partition}/{uuid}.log and partition_template: dt={date}/hour={hour}
+func GlueLayout(filesConfig *FilesConfig) (string, []string, error) {
+	if filesConfig.Format != "" && filesConfig.Format != JSONFormat {
+		return "", nil, fmt.Errorf("files format %s isn't supported if glue is configured: Glue tables are NDJSON ones", filesConfig.Format)
+	}
+
+	template := filesConfig.NameTemplate
+	index := strings.Index(template, glueTableLocationPlaceholder)
+	if index < 0 {
+		return "", nil, fmt.Errorf("files name_template must contain %s placeholder as a directory if glue is configured e.g. events/{table}/{uuid}.log", glueTableLocationPlaceholder)
+	}
+
+	tablesPrefix := template[:index]
+	if strings.Contains(tablesPrefix, "{") {
+		return "", nil, fmt.Errorf("files name_template part before %s must be static if glue is configured", glueTableLocationPlaceholder)
+	}
+
+	if filesConfig.PartitionTemplate == "" {
+		return tablesPrefix, nil, nil
+	}
+
+	if !strings.HasPrefix(template[index+len(glueTableLocationPlaceholder):], partitionPlaceholder+"/") {
+		return "", nil, fmt.Errorf("files name_template must contain %s%s/ if glue is configured with partition_template", glueTableLocationPlaceholder, partitionPlaceholder)
+	}
+
+	var partitionKeys []string
+	for _, segment := range strings.Split(strings.Trim(filesConfig.PartitionTemplate, "/"), "/") {
+		index := strings.Index(segment, "=")
+		if index <= 0 || strings.Contains(segment[:index], "{") {
+			return "", nil, fmt.Errorf("files partition_template segment [%s] must be in Hive style (key=value) if glue is configured", segment)
+		}
+		partitionKeys = append(partitionKeys, segment[:index])
+	}
+
+	return tablesPrefix, partitionKeys, nil
+}
+
+//Tables return glue tables schemas known by the destination (empty if glue isn't configured)
+func (s3 *S3) Tables() []*schema.Table {
+	if s3.glueTableHelper == nil {
+		return []*schema.Table{}
+	}
+
+	return s3.glueTableHelper.Tables()
+}
+
 func (s3 *S3) Name() string {
 	return s3.name
 }
@@ -54,6 +235,25 @@ func (s3 *S3) Type() string {
 	return "S3"
 }
 
-func (s3 *S3) Close() error {
-	return nil
+//FlushFiles upload files which are accumulated by stream mode file batcher right away
+func (s3 *S3) FlushFiles() {
+	if s3.fileBatcher != nil {
+		s3.fileBatcher.Flush()
+	}
+}
+
+func (s3 *S3) Close() (multiErr error) {
+	if s3.fileBatcher != nil {
+		if err := s3.fileBatcher.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing s3 file batcher: %v", err))
+		}
+	}
+
+	if s3.eventQueue != nil {
+		if err := s3.eventQueue.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing s3 event queue: %v", err))
+		}
+	}
+
+	return
 }
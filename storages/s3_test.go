@@ -0,0 +1,248 @@
+package storages
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ksensehq/eventnative/appconfig"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//in memory events.QueueBackend
+type memoryQueue struct {
+	facts  chan []byte
+	closed chan struct{}
+}
+
+func newMemoryQueue() *memoryQueue {
+	return &memoryQueue{facts: make(chan []byte, 100), closed: make(chan struct{})}
+}
+
+func (mq *memoryQueue) Enqueue(factBytes []byte) error {
+	mq.facts <- factBytes
+	return nil
+}
+
+func (mq *memoryQueue) DequeueBlock() ([]byte, events.AckFunc, error) {
+	select {
+	case b := <-mq.facts:
+		return b, events.NoAck, nil
+	case <-mq.closed:
+		return nil, nil, errors.New("closed")
+	}
+}
+
+func (mq *memoryQueue) Size() int {
+	return len(mq.facts)
+}
+
+func (mq *memoryQueue) Close() error {
+	close(mq.closed)
+	return nil
+}
+
+//s3 uploads mock: keeps ids of uploaded objects events by table. The first failures uploads return err
+type s3UploadsMock struct {
+	mutex    sync.Mutex
+	failures int
+	uploads  int
+	tables   map[string][]float64
+}
+
+func (sum *s3UploadsMock) uploadBytes(objectName string, payload []byte) error {
+	sum.mutex.Lock()
+	defer sum.mutex.Unlock()
+
+	if sum.failures > 0 {
+		sum.failures--
+		return errors.New("s3 is unavailable")
+	}
+
+	sum.uploads++
+	table := objectName[:strings.Index(objectName, "/")]
+	for _, line := range strings.Split(string(payload), "\n") {
+		object := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &object); err != nil {
+			return err
+		}
+		sum.tables[table] = append(sum.tables[table], object["id"].(float64))
+	}
+
+	return nil
+}
+
+func (sum *s3UploadsMock) uploaded() (int, map[string][]float64) {
+	sum.mutex.Lock()
+	defer sum.mutex.Unlock()
+
+	tables := map[string][]float64{}
+	for table, ids := range sum.tables {
+		tables[table] = append([]float64{}, ids...)
+	}
+	return sum.uploads, tables
+}
+
+//return S3 in stream mode with in memory queue and mocked uploads
+func newStreamS3(t *testing.T, filesConfig *FilesConfig, uploads *s3UploadsMock) *S3 {
+	if appconfig.Instance == nil {
+		appconfig.Instance = &appconfig.AppConfig{ServerName: "test", AuthorizedTokens: map[string]bool{}}
+	}
+	filesConfig.NameTemplate = "{table}/{file}"
+	require.NoError(t, filesConfig.Validate())
+	processor, err := schema.NewProcessor(&schema.ProcessorConfig{TableNameTemplate: "{{.event_type}}"})
+	require.NoError(t, err)
+
+	s3 := &S3{
+		name:            "s3_stream",
+		uploader:        newFileUploader(filesConfig, uploads.uploadBytes),
+		schemaProcessor: processor,
+		eventQueue:      events.NewQueue("s3_stream", newMemoryQueue()),
+	}
+	s3.fileBatcher = NewFileBatcher(s3.name, filesConfig, s3.upload)
+	s3.startStreamingConsumer()
+
+	return s3
+}
+
+//return event of the type with id and _timestamp (it is required for table name extraction)
+func streamEvent(eventType string, id int) events.Fact {
+	return events.Fact{"event_type": eventType, "id": id, timestamp.Key: "2020-08-02T18:23:59.757719Z"}
+}
+
+//return count of events which are accumulated by the file batcher
+func batchedObjects(s3 *S3) int {
+	s3.fileBatcher.mutex.Lock()
+	defer s3.fileBatcher.mutex.Unlock()
+	return s3.fileBatcher.objects
+}
+
+func TestS3StreamModeBatching(t *testing.T) {
+	uploads := &s3UploadsMock{tables: map[string][]float64{}}
+	s3 := newStreamS3(t, &FilesConfig{UploadEvery: time.Hour, MaxObjects: 3}, uploads)
+	defer s3.Close()
+
+	//files are rotated when max_objects events are accumulated: one object per table
+	s3.Consume(streamEvent("pageview", 1))
+	s3.Consume(streamEvent("click", 2))
+	s3.Consume(streamEvent("pageview", 3))
+	require.Eventually(t, func() bool { count, _ := uploads.uploaded(); return count == 2 }, 5*time.Second, 10*time.Millisecond)
+	_, tables := uploads.uploaded()
+	require.Equal(t, map[string][]float64{"pageview": {1, 3}, "click": {2}}, tables)
+
+	//events below max_objects are accumulated until upload_every or flush
+	s3.Consume(streamEvent("click", 4))
+	require.Eventually(t, func() bool { return batchedObjects(s3) == 1 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	count, _ := uploads.uploaded()
+	require.Equal(t, 2, count)
+
+	s3.FlushFiles()
+	count, tables = uploads.uploaded()
+	require.Equal(t, 3, count)
+	require.Equal(t, map[string][]float64{"pageview": {1, 3}, "click": {2, 4}}, tables)
+	require.Equal(t, 0, batchedObjects(s3))
+}
+
+func TestS3StreamModeFlush(t *testing.T) {
+	//files are uploaded every upload_every. Not uploaded files are retried with the next rotation
+	uploads := &s3UploadsMock{tables: map[string][]float64{}, failures: 1}
+	s3 := newStreamS3(t, &FilesConfig{UploadEvery: 50 * time.Millisecond, MaxObjects: 100}, uploads)
+	defer s3.Close()
+
+	s3.Consume(streamEvent("pageview", 1))
+	s3.Consume(streamEvent("pageview", 2))
+	require.Eventually(t, func() bool { _, tables := uploads.uploaded(); return len(tables["pageview"]) == 2 }, 5*time.Second, 10*time.Millisecond)
+	_, tables := uploads.uploaded()
+	require.Equal(t, map[string][]float64{"pageview": {1, 2}}, tables)
+	require.Equal(t, 0, batchedObjects(s3))
+
+	//accumulated files are uploaded on close
+	uploads = &s3UploadsMock{tables: map[string][]float64{}}
+	s3 = newStreamS3(t, &FilesConfig{UploadEvery: time.Hour, MaxObjects: 100}, uploads)
+	s3.Consume(streamEvent("click", 3))
+	require.Eventually(t, func() bool { return batchedObjects(s3) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, s3.Close())
+	count, tables := uploads.uploaded()
+	require.Equal(t, 1, count)
+	require.Equal(t, map[string][]float64{"click": {3}}, tables)
+}
+
+func TestGlueLayout(t *testing.T) {
+	tests := []struct {
+		name                  string
+		config                *FilesConfig
+		expectedPrefix        string
+		expectedPartitionKeys []string
+		expectedErr           string
+	}{
+		{
+			"Default name template",
+			&FilesConfig{},
+			"",
+			nil,
+			"files name_template must contain {table}/ placeholder as a directory if glue is configured e.g. events/{table}/{uuid}.log",
+		},
+		{
+			"Dynamic prefix",
+			&FilesConfig{NameTemplate: "{date}/{table}/{uuid}.log"},
+			"",
+			nil,
+			"files name_template part before {table}/ must be static if glue is configured",
+		},
+		{
+			"Without partitions",
+			&FilesConfig{NameTemplate: "events/{table}/{date}/{uuid}.log"},
+			"events/",
+			nil,
+			"",
+		},
+		{
+			"Partition isn't after table",
+			&FilesConfig{NameTemplate: "events/{table}/{uuid}.log", PartitionTemplate: "dt={date}"},
+			"",
+			nil,
+			"files name_template must contain {table}/{partition}/ if glue is configured with partition_template",
+		},
+		{
+			"Not Hive style partition",
+			&FilesConfig{NameTemplate: "{table}/{partition}/{uuid}.log", PartitionTemplate: "dt={date}/{hour}"},
+			"",
+			nil,
+			"files partition_template segment [{hour}] must be in Hive style (key=value) if glue is configured",
+		},
+		{
+			"CSV format",
+			&FilesConfig{NameTemplate: "events/{table}/{uuid}.csv", Format: CSVFormat},
+			"",
+			nil,
+			"files format csv isn't supported if glue is configured: Glue tables are NDJSON ones",
+		},
+		{
+			"Partitions",
+			&FilesConfig{NameTemplate: "{table}/{partition}/{uuid}.log", PartitionTemplate: "dt={date}/hour={hour}/token={api_key}"},
+			"",
+			[]string{"dt", "hour", "token"},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix, partitionKeys, err := GlueLayout(tt.config)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedPrefix, prefix)
+			require.Equal(t, tt.expectedPartitionKeys, partitionKeys)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package storages
+
+import (
+	"fmt"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/stateless"
+	"path"
+)
+
+const schemaCacheDir = "schemas"
+
+//destination types which support data_layout.schema_cache (tables are created and patched with TableHelper)
+var schemaCacheDestinationTypes = []string{"redshift", "bigquery", "postgres", "clickhouse", "snowflake", "elasticsearch", "mssql"}
+
+//return err if destination type doesn't support data_layout.schema_cache or config is malformed
+func validateSchemaCache(destination *DestinationConfig, config *schema.TablesCacheConfig) error {
+	if _, err := schema.NewTablesCache(config); err != nil {
+		return err
+	}
+	if _, err := schema.NewMetadataCache(config); err != nil {
+		return err
+	}
+	if config == nil || !config.Enabled {
+		return nil
+	}
+
+	for _, t := range schemaCacheDestinationTypes {
+		if t == destination.Type {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("data_layout.schema_cache isn't supported by %s destination. Supported types: %v", destination.Type, schemaCacheDestinationTypes)
+}
+
+//keep cached tables schemas of the destination in $logEventPath/schemas/$destination.json (if schema cache is enabled)
+//they are kept only in memory in stateless mode
+func persistTablesCache(destinationName, logEventPath string, processor *schema.Processor) error {
+	if processor.TablesCache() == nil || stateless.Enabled() {
+		return nil
+	}
+
+	return processor.TablesCache().Persist(path.Join(logEventPath, schemaCacheDir, destinationName+".json"))
+}
@@ -0,0 +1,64 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestValidateSchemaCache(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination *DestinationConfig
+		schemaCache *schema.TablesCacheConfig
+		expectedErr string
+	}{
+		{
+			"not configured",
+			&DestinationConfig{Type: "s3"},
+			nil,
+			"",
+		},
+		{
+			"disabled",
+			&DestinationConfig{Type: "s3"},
+			&schema.TablesCacheConfig{},
+			"",
+		},
+		{
+			"unsupported destination",
+			&DestinationConfig{Type: "kafka"},
+			&schema.TablesCacheConfig{Enabled: true},
+			"data_layout.schema_cache isn't supported by kafka destination. Supported types: [redshift bigquery postgres clickhouse snowflake elasticsearch mssql]",
+		},
+		{
+			"negative max age",
+			&DestinationConfig{Type: "postgres"},
+			&schema.TablesCacheConfig{Enabled: true, MaxAge: -time.Minute},
+			"data_layout.schema_cache max_age can't be negative",
+		},
+		{
+			"negative refresh interval",
+			&DestinationConfig{Type: "s3"},
+			&schema.TablesCacheConfig{RefreshInterval: -time.Minute},
+			"data_layout.schema_cache refresh_interval can't be negative",
+		},
+		{
+			"ok",
+			&DestinationConfig{Type: "clickhouse"},
+			&schema.TablesCacheConfig{Enabled: true, MaxAge: time.Hour, RefreshInterval: 10 * time.Minute},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSchemaCache(tt.destination, tt.schemaCache)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
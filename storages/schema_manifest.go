@@ -0,0 +1,133 @@
+package storages
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"path"
+	"sort"
+)
+
+const schemaManifestName = "_schema.json"
+
+//Hive types of manifest columns
+var schemaToManifest = map[typing.DataType]string{
+	typing.STRING:    "string",
+	typing.INT64:     "bigint",
+	typing.FLOAT64:   "double",
+	typing.TIMESTAMP: "timestamp",
+}
+
+//SchemaManifest is a serialized table schema which is uploaded next to file destinations objects
+type SchemaManifest struct {
+	Table   string           `json:"table"`
+	Version int              `json:"version"`
+	Columns []ManifestColumn `json:"columns"`
+}
+
+type ManifestColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+//schemaManifests keeps accumulated tables schemas in memory (version is incremented on every new column)
+//and decides which manifest objects must be (re)uploaded
+//manifest is named _schema.json if objects directory is per table, otherwise <table>_schema.json
+type schemaManifests struct {
+	tableDirectory bool
+	tables         map[string]*SchemaManifest
+	tablesColumns  map[string]schema.Columns
+	uploaded       map[string]int
+}
+
+func newSchemaManifests(tableDirectory bool) *schemaManifests {
+	return &schemaManifests{
+		tableDirectory: tableDirectory,
+		tables:         map[string]*SchemaManifest{},
+		tablesColumns:  map[string]schema.Columns{},
+		uploaded:       map[string]int{},
+	}
+}
+
+//update table manifest with table columns and return manifest object name and payload
+//return empty name if manifest with actual version has been already uploaded next to the object
+func (sm *schemaManifests) update(objectName string, table *schema.Table) (string, []byte, error) {
+	manifest := sm.merge(table)
+
+	manifestName := schemaManifestName
+	if !sm.tableDirectory {
+		manifestName = table.Name + schemaManifestName
+	}
+	if dir := path.Dir(objectName); dir != "." {
+		manifestName = dir + "/" + manifestName
+	}
+
+	if sm.uploaded[manifestName] == manifest.Version {
+		return "", nil, nil
+	}
+
+	payload, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", nil, fmt.Errorf("Error serializing table [%s] schema manifest: %v", table.Name, err)
+	}
+
+	return manifestName, payload, nil
+}
+
+//markUploaded save uploaded manifest version
+func (sm *schemaManifests) markUploaded(manifestName, tableName string) {
+	sm.uploaded[manifestName] = sm.tables[tableName].Version
+}
+
+//merge new columns into table manifest and increment version if there were new ones or types were changed
+func (sm *schemaManifests) merge(table *schema.Table) *SchemaManifest {
+	columns, ok := sm.tablesColumns[table.Name]
+	if !ok {
+		columns = schema.Columns{}
+		sm.tablesColumns[table.Name] = columns
+	}
+	//copy columns for not sharing type occurrences with processed file table
+	tableColumns := schema.Columns{}
+	for name, column := range table.Columns {
+		tableColumns[name] = schema.NewColumn(column.GetType())
+	}
+	columns.Merge(tableColumns)
+
+	var manifestColumns []ManifestColumn
+	for name, column := range columns {
+		manifestType, ok := schemaToManifest[column.GetType()]
+		if !ok {
+			manifestType = schemaToManifest[typing.STRING]
+		}
+		manifestColumns = append(manifestColumns, ManifestColumn{Name: name, Type: manifestType})
+	}
+	sort.Slice(manifestColumns, func(i, j int) bool {
+		return manifestColumns[i].Name < manifestColumns[j].Name
+	})
+
+	manifest, ok := sm.tables[table.Name]
+	if !ok {
+		manifest = &SchemaManifest{Table: table.Name}
+		sm.tables[table.Name] = manifest
+	}
+	if !equalManifestColumns(manifest.Columns, manifestColumns) {
+		manifest.Columns = manifestColumns
+		manifest.Version++
+	}
+
+	return manifest
+}
+
+func equalManifestColumns(current, other []ManifestColumn) bool {
+	if len(current) != len(other) {
+		return false
+	}
+	for i := range current {
+		if current[i] != other[i] {
+			return false
+		}
+	}
+
+	return true
+}
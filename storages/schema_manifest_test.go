@@ -0,0 +1,48 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSchemaManifests(t *testing.T) {
+	manifests := newSchemaManifests(true)
+	table := &schema.Table{Name: "events", Columns: schema.Columns{
+		"field1": schema.NewColumn(typing.STRING),
+		"field2": schema.NewColumn(typing.INT64),
+	}}
+
+	name, payload, err := manifests.update("events/2020-08-01/file1.log", table)
+	require.NoError(t, err)
+	require.Equal(t, "events/2020-08-01/_schema.json", name)
+	require.JSONEq(t, `{"table":"events","version":1,"columns":[{"name":"field1","type":"string"},{"name":"field2","type":"bigint"}]}`, string(payload))
+	manifests.markUploaded(name, table.Name)
+
+	//the same schema in the same directory
+	name, _, err = manifests.update("events/2020-08-01/file2.log", table)
+	require.NoError(t, err)
+	require.Empty(t, name)
+
+	//the same schema in a new directory
+	name, _, err = manifests.update("events/2020-08-02/file3.log", table)
+	require.NoError(t, err)
+	require.Equal(t, "events/2020-08-02/_schema.json", name)
+
+	//new column
+	newTable := &schema.Table{Name: "events", Columns: schema.Columns{"field3": schema.NewColumn(typing.TIMESTAMP)}}
+	name, payload, err = manifests.update("events/2020-08-01/file4.log", newTable)
+	require.NoError(t, err)
+	require.Equal(t, "events/2020-08-01/_schema.json", name)
+	require.JSONEq(t, `{"table":"events","version":2,"columns":[{"name":"field1","type":"string"},{"name":"field2","type":"bigint"},{"name":"field3","type":"timestamp"}]}`, string(payload))
+}
+
+func TestSchemaManifestsSharedDirectory(t *testing.T) {
+	manifests := newSchemaManifests(false)
+	table := &schema.Table{Name: "users", Columns: schema.Columns{"field1": schema.NewColumn(typing.FLOAT64)}}
+
+	name, _, err := manifests.update("file1.log-table-users", table)
+	require.NoError(t, err)
+	require.Equal(t, "users_schema.json", name)
+}
@@ -0,0 +1,303 @@
+package storages
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/dbt"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/ksensehq/eventnative/watermarks"
+	"log"
+)
+
+const snowflakeStorageType = "Snowflake"
+
+//Store files to Snowflake in two modes:
+//batch: via Snowflake internal stage or aws s3 external stage (1 file = 1 COPY INTO per table)
+//stream: via events queue in stream mode (1 object = 1 transaction)
+type Snowflake struct {
+	name             string
+	s3Adapter        *adapters.S3
+	snowflakeAdapter *adapters.Snowflake
+	tableHelper      *TableHelper
+	schemaProcessor  *schema.Processor
+	eventQueue       *events.PersistentQueue
+	breakOnError     bool
+}
+
+//NewSnowflake return Snowflake and start goroutine for stream consumer if destination is in stream mode
+//s3Config is optional: if provided - files are uploaded to s3 external stage, otherwise - to Snowflake internal stage
+func NewSnowflake(ctx context.Context, name, fallbackDir string, s3Config *adapters.S3Config, snowflakeConfig *adapters.SnowflakeConfig,
+	processor *schema.Processor, ddlWriter *DDLWriter, breakOnError, streamMode bool) (*Snowflake, error) {
+	var s3Adapter *adapters.S3
+	var eventQueue *events.PersistentQueue
+	if streamMode {
+		var err error
+		eventQueue, err = newEventQueue(name, fallbackDir)
+		if err != nil {
+			return nil, err
+		}
+	} else if s3Config != nil {
+		var err error
+		s3Adapter, err = adapters.NewS3(s3Config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	//stage type depends on s3 adapter presence
+	var stageS3Config *adapters.S3Config
+	if s3Adapter != nil {
+		stageS3Config = s3Config
+	}
+
+	snowflakeAdapter, err := adapters.NewSnowflake(ctx, snowflakeConfig, stageS3Config)
+	if err != nil {
+		return nil, err
+	}
+
+	//create db schema if doesn't exist (nothing is created with existing tables or read-only schema)
+	if processor.ExistingTables() == nil && ddlWriter == nil {
+		if err := snowflakeAdapter.CreateDbSchema(snowflakeConfig.Schema); err != nil {
+			snowflakeAdapter.Close()
+			return nil, err
+		}
+	}
+
+	monitorKeeper := NewMonitorKeeper()
+	tableHelper := NewTableHelper(snowflakeAdapter, monitorKeeper, snowflakeStorageType, processor.ExistingTables(), ddlWriter, processor.SchemaEvolution(), processor.ColumnsLimit(), processor.TablesCache(), processor.MetadataCache())
+
+	s := &Snowflake{
+		name:             name,
+		s3Adapter:        s3Adapter,
+		snowflakeAdapter: snowflakeAdapter,
+		tableHelper:      tableHelper,
+		schemaProcessor:  processor,
+		eventQueue:       eventQueue,
+		breakOnError:     breakOnError,
+	}
+
+	if streamMode {
+		for i := 0; i < streamWorkers(name); i++ {
+			s.startStreamingConsumer()
+		}
+	}
+
+	return s, nil
+}
+
+//Run goroutine to:
+//1. read from queue
+//2. insert in Snowflake
+func (s *Snowflake) startStreamingConsumer() {
+	go func() {
+		for {
+			if appstatus.Instance.Idle {
+				break
+			}
+			fact, ack, err := s.eventQueue.DequeueBlockAck()
+			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
+				log.Println("Error reading event fact from snowflake queue", err)
+				continue
+			}
+
+			dataSchema, flattenObject, err := s.schemaProcessor.ProcessFact(fact)
+			if err != nil {
+				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(s.name, 1)
+				acknowledge(s.name, ack)
+				continue
+			}
+
+			//don't process empty object
+			if !dataSchema.Exists() {
+				acknowledge(s.name, ack)
+				continue
+			}
+
+			eventTime := s.schemaProcessor.EventTime(dataSchema, flattenObject)
+			if err := insertWithRetry(s.name, fact, ack, func() error { return s.insert(dataSchema, flattenObject) }); err != nil {
+				if err != errForwarded {
+					log.Printf("Error inserting to snowflake table [%s]: %v", dataSchema.Name, err)
+					counters.ErrorEvents(s.name, 1)
+				}
+				continue
+			}
+
+			counters.SuccessEvents(s.name, 1)
+			watermarks.Commit(s.name, dataSchema.Name, eventTime)
+		}
+	}()
+}
+
+//Consume events.Fact and enqueue it
+func (s *Snowflake) Consume(fact events.Fact) {
+	if err := s.eventQueue.Enqueue(fact); err != nil {
+		logSkippedEvent(s.name, fact, err)
+	}
+}
+
+//QueueSize return count of events in the stream mode queue
+func (s *Snowflake) QueueSize() int {
+	return s.eventQueue.Size()
+}
+
+//insert fact in Snowflake
+func (s *Snowflake) insert(dataSchema *schema.Table, fact events.Fact) (err error) {
+	if err := injectFault(s.name); err != nil {
+		return err
+	}
+
+	dbSchema, err := s.tableHelper.EnsureTable(dataSchema, fact)
+	if err != nil {
+		return err
+	}
+
+	if err := s.tableHelper.ApplyDBTypingToObject(s.schemaProcessor, dbSchema, dataSchema, fact); err != nil {
+		return err
+	}
+
+	return s.snowflakeAdapter.Insert(dataSchema, fact)
+}
+
+//Store file payload to Snowflake with processing:
+//1. ensure tables
+//2. upload every table file to stage
+//3. copy stage file into table
+func (s *Snowflake) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(s.name); err != nil {
+		return err
+	}
+
+	flatData, err := s.schemaProcessor.ProcessFilePayload(fileName, payload, s.breakOnError, report)
+	if err != nil {
+		return err
+	}
+
+	for _, fdata := range flatData {
+		dbSchema, err := s.tableHelper.EnsureTable(fdata.DataSchema, fdata.GetPayload()...)
+		if err != nil {
+			return err
+		}
+
+		if err := s.tableHelper.ApplyDBTyping(s.schemaProcessor, dbSchema, fdata); err != nil {
+			return err
+		}
+	}
+
+	for _, fdata := range flatData {
+		rawJSONValues(fdata)
+		fileKey := fdata.FileName + tableFileKeyDelimiter + fdata.DataSchema.Name
+		if err := s.uploadAndCopy(fileKey, fdata.DataSchema.Name, fdata.GetPayloadBytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Snowflake) uploadAndCopy(fileKey, tableName string, payload []byte) error {
+	if s.s3Adapter != nil {
+		if err := s.s3Adapter.UploadBytes(fileKey, payload); err != nil {
+			return err
+		}
+
+		if err := s.snowflakeAdapter.Copy(fileKey, tableName); err != nil {
+			return err
+		}
+
+		if err := s.s3Adapter.DeleteObject(fileKey); err != nil {
+			log.Println("System error: file", fileKey, "wasn't deleted from s3 stage", err)
+		}
+
+		return nil
+	}
+
+	if err := s.snowflakeAdapter.PutFile(fileKey, payload); err != nil {
+		return err
+	}
+
+	if err := s.snowflakeAdapter.Copy(fileKey, tableName); err != nil {
+		if removeErr := s.snowflakeAdapter.RemoveFile(fileKey); removeErr != nil {
+			log.Println("System error: file", fileKey, "wasn't removed from snowflake stage", removeErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+//Tables return tables schemas known by the destination
+func (s *Snowflake) Tables() []*schema.Table {
+	return s.tableHelper.Tables()
+}
+
+//DropColumnDDL return statements which drop the column of the destination table
+func (s *Snowflake) DropColumnDDL(tableName, columnName string) ([]string, error) {
+	return s.tableHelper.DropColumnDDL(tableName, columnName)
+}
+
+//DropColumn drop the column of the destination table
+func (s *Snowflake) DropColumn(tableName, columnName string) error {
+	return s.tableHelper.DropColumn(tableName, columnName)
+}
+
+//DbtSource return dbt source of tables known by the destination
+func (s *Snowflake) DbtSource(freshness *dbt.Freshness) *dbt.Source {
+	database, schemaName := s.snowflakeAdapter.Location()
+	return dbt.NewSource(s.name, database, schemaName, s.Tables(), s.schemaProcessor.SystemColumn(timestamp.Key), s.schemaProcessor.ColumnDescription, freshness)
+}
+
+func (s *Snowflake) Name() string {
+	return s.name
+}
+
+func (s *Snowflake) Type() string {
+	return snowflakeStorageType
+}
+
+func (s *Snowflake) Close() (multiErr error) {
+	if err := s.snowflakeAdapter.Close(); err != nil {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing snowflake datasource: %v", err))
+	}
+
+	if s.eventQueue != nil {
+		if err := s.eventQueue.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing snowflake event queue: %v", err))
+		}
+	}
+
+	return
+}
+
+//replace JSON columns values with raw JSON documents so COPY loads them into variant columns as objects (not as strings)
+func rawJSONValues(fdata *schema.ProcessedFile) {
+	var jsonColumns []string
+	for name, column := range fdata.DataSchema.Columns {
+		if column.GetType() == typing.JSON {
+			jsonColumns = append(jsonColumns, name)
+		}
+	}
+	if len(jsonColumns) == 0 {
+		return
+	}
+
+	for _, object := range fdata.GetPayload() {
+		for _, name := range jsonColumns {
+			if value, ok := object[name].(string); ok {
+				object[name] = json.RawMessage(value)
+			}
+		}
+	}
+}
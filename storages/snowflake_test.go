@@ -0,0 +1,88 @@
+package storages
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSnowflakeConfig(t *testing.T) {
+	tests := []struct {
+		name           string
+		destination    *DestinationConfig
+		expectedConfig *adapters.SnowflakeConfig
+		expectedError  string
+	}{
+		{
+			"without config",
+			&DestinationConfig{Type: "snowflake"},
+			nil,
+			"Snowflake config is required",
+		},
+		{
+			"without warehouse",
+			&DestinationConfig{Type: "snowflake", Snowflake: &adapters.SnowflakeConfig{Account: "acc", Db: "events", Username: "user"}},
+			nil,
+			"Snowflake warehouse is required parameter",
+		},
+		{
+			"default port and schema",
+			&DestinationConfig{Type: "snowflake", Snowflake: &adapters.SnowflakeConfig{Account: "acc", Db: "events", Username: "user", Warehouse: "wh"}},
+			&adapters.SnowflakeConfig{Account: "acc", Port: 443, Db: "events", Schema: "PUBLIC", Username: "user", Warehouse: "wh", Parameters: map[string]*string{}},
+			"",
+		},
+		{
+			"configured port and schema",
+			&DestinationConfig{Type: "snowflake", Snowflake: &adapters.SnowflakeConfig{Account: "acc", Port: 8443, Db: "events", Schema: "RAW", Username: "user", Warehouse: "wh"}},
+			&adapters.SnowflakeConfig{Account: "acc", Port: 8443, Db: "events", Schema: "RAW", Username: "user", Warehouse: "wh", Parameters: map[string]*string{}},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := snowflakeConfig("sf", tt.destination)
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedConfig, config)
+		})
+	}
+}
+
+func TestCreateSnowflakeS3Stage(t *testing.T) {
+	snowflakeConfig := &adapters.SnowflakeConfig{Account: "acc", Db: "events", Username: "user", Warehouse: "wh"}
+
+	_, err := createSnowflake(context.Background(), "sf", "", &DestinationConfig{Type: "snowflake", Snowflake: snowflakeConfig,
+		S3: &adapters.S3Config{AccessKeyID: "key", SecretKey: "secret", Region: "us-east-1"}}, nil, nil, false)
+	require.EqualError(t, err, "S3 bucket is required parameter")
+
+	_, err = createSnowflake(context.Background(), "sf", "", &DestinationConfig{Type: "snowflake", Snowflake: snowflakeConfig,
+		S3: &adapters.S3Config{AccessKeyID: "key", SecretKey: "secret", Bucket: "stage", Region: "us-east-1"}}, nil, nil, false)
+	require.EqualError(t, err, "Snowflake stage is required parameter if s3 external stage is used")
+}
+
+func TestRawJSONValues(t *testing.T) {
+	table := &schema.Table{Name: "events", Columns: schema.Columns{
+		"eventn_ctx": schema.NewColumn(typing.JSON),
+		"user_id":    schema.NewColumn(typing.STRING),
+	}}
+	fdata := schema.NewProcessedFile("file1", table)
+	fdata.Add(table, map[string]interface{}{"eventn_ctx": `{"event_id":"1"}`, "user_id": `{"id":1}`})
+	fdata.Add(table, map[string]interface{}{"user_id": "2"})
+
+	rawJSONValues(fdata)
+
+	//JSON columns values are loaded as objects, other string values are kept as is
+	require.Equal(t, []map[string]interface{}{
+		{"eventn_ctx": json.RawMessage(`{"event_id":"1"}`), "user_id": `{"id":1}`},
+		{"user_id": "2"},
+	}, fdata.GetPayload())
+	require.Equal(t, "{\"eventn_ctx\":{\"event_id\":\"1\"},\"user_id\":\"{\\\"id\\\":1}\"}\n{\"user_id\":\"2\"}", string(fdata.GetPayloadBytes()))
+}
@@ -0,0 +1,236 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/dbt"
+	"github.com/ksensehq/eventnative/schema"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DestinationStatusOK     = "ok"
+	DestinationStatusFailed = "failed"
+)
+
+//TablesKeeper is implemented by destinations which keep tables schemas in memory
+type TablesKeeper interface {
+	Tables() []*schema.Table
+}
+
+//DbtSourcer is implemented by SQL destinations which tables can be described as dbt source
+type DbtSourcer interface {
+	DbtSource(freshness *dbt.Freshness) *dbt.Source
+}
+
+//QueueSizer is implemented by destinations which have stream mode events queue
+type QueueSizer interface {
+	QueueSize() int
+}
+
+//FilesFlusher is implemented by destinations which accumulate stream mode events into files
+type FilesFlusher interface {
+	FlushFiles()
+}
+
+//LastRowsSelector is implemented by SQL destinations which tables can be queried by the users events API
+type LastRowsSelector interface {
+	SelectLast(tableName, keyColumn, orderColumn string, value interface{}, limit int) ([]map[string]interface{}, error)
+}
+
+//MetricsSelector is implemented by SQL destinations which can run predefined aggregate queries of the metrics API
+type MetricsSelector interface {
+	SelectMetric(query *adapters.MetricQuery) ([]map[string]interface{}, error)
+}
+
+//DestinationStatus is a result of destination initialization
+type DestinationStatus struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Mode      string    `json:"mode"`
+	Tokens    []string  `json:"tokens"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+//keeps all initialized (and failed) destinations for admin API
+type destinationsRegistry struct {
+	mutex        sync.RWMutex
+	statuses     map[string]*DestinationStatus
+	destinations map[string]interface{}
+}
+
+var registry = &destinationsRegistry{
+	statuses:     map[string]*DestinationStatus{},
+	destinations: map[string]interface{}{},
+}
+
+//destination is events.Storage or events.Consumer (nil if initialization failed)
+func registerDestination(status *DestinationStatus, destination interface{}) {
+	status.CreatedAt = time.Now().UTC()
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.statuses[status.Name] = status
+	if destination != nil {
+		registry.destinations[status.Name] = destination
+	} else {
+		delete(registry.destinations, status.Name)
+	}
+}
+
+//remove destination status (destination has been removed at runtime)
+func unregisterDestination(name string) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	delete(registry.statuses, name)
+	delete(registry.destinations, name)
+}
+
+//GetDestinationStatuses return copies of all destinations statuses sorted by name
+func GetDestinationStatuses() []*DestinationStatus {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	var statuses []*DestinationStatus
+	for _, status := range registry.statuses {
+		copied := *status
+		statuses = append(statuses, &copied)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+
+	return statuses
+}
+
+//GetDestinationStatus return copy of destination status and false if destination wasn't initialized
+func GetDestinationStatus(name string) (*DestinationStatus, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	status, ok := registry.statuses[name]
+	if !ok {
+		return nil, false
+	}
+
+	copied := *status
+	return &copied, true
+}
+
+//GetTables return destination tables schemas which are known by the destination
+//return false if destination doesn't exist or doesn't keep tables schemas
+func GetTables(name string) ([]*schema.Table, bool) {
+	registry.mutex.RLock()
+	destination, ok := registry.destinations[name]
+	registry.mutex.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	keeper, ok := destination.(TablesKeeper)
+	if !ok {
+		return nil, false
+	}
+
+	return keeper.Tables(), true
+}
+
+//GetDbtSource return dbt source of destination tables
+//return false if destination doesn't exist or isn't a SQL one
+func GetDbtSource(name string, freshness *dbt.Freshness) (*dbt.Source, bool) {
+	registry.mutex.RLock()
+	destination, ok := registry.destinations[name]
+	registry.mutex.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	sourcer, ok := destination.(DbtSourcer)
+	if !ok {
+		return nil, false
+	}
+
+	return sourcer.DbtSource(freshness), true
+}
+
+//SelectLastRows return last limit rows of the destination table where keyColumn equals value ordered by orderColumn desc
+//return false if destination doesn't exist or can't be queried
+func SelectLastRows(name, tableName, keyColumn, orderColumn string, value interface{}, limit int) ([]map[string]interface{}, bool, error) {
+	registry.mutex.RLock()
+	destination, ok := registry.destinations[name]
+	registry.mutex.RUnlock()
+	if !ok {
+		return nil, false, nil
+	}
+
+	selector, ok := destination.(LastRowsSelector)
+	if !ok {
+		return nil, false, nil
+	}
+
+	rows, err := selector.SelectLast(tableName, keyColumn, orderColumn, value, limit)
+	return rows, true, err
+}
+
+//SelectMetricRows return rows of the predefined aggregate query against the destination
+//return false if destination doesn't exist or can't be queried
+func SelectMetricRows(name string, query *adapters.MetricQuery) ([]map[string]interface{}, bool, error) {
+	registry.mutex.RLock()
+	destination, ok := registry.destinations[name]
+	registry.mutex.RUnlock()
+	if !ok {
+		return nil, false, nil
+	}
+
+	selector, ok := destination.(MetricsSelector)
+	if !ok {
+		return nil, false, nil
+	}
+
+	rows, err := selector.SelectMetric(query)
+	return rows, true, err
+}
+
+//GetQueueSize return count of events in the destination stream mode queue
+//return false if destination doesn't exist or isn't in stream mode
+func GetQueueSize(name string) (int, bool) {
+	registry.mutex.RLock()
+	destination, ok := registry.destinations[name]
+	status := registry.statuses[name]
+	registry.mutex.RUnlock()
+	if !ok || status.Mode != streamMode {
+		return 0, false
+	}
+
+	sizer, ok := destination.(QueueSizer)
+	if !ok {
+		return 0, false
+	}
+
+	return sizer.QueueSize(), true
+}
+
+//FlushFiles upload files which are accumulated by all destinations right away
+//return names of flushed destinations sorted
+func FlushFiles() []string {
+	registry.mutex.RLock()
+	flushers := map[string]FilesFlusher{}
+	for name, destination := range registry.destinations {
+		if flusher, ok := destination.(FilesFlusher); ok {
+			flushers[name] = flusher
+		}
+	}
+	registry.mutex.RUnlock()
+
+	var names []string
+	for name, flusher := range flushers {
+		flusher.FlushFiles()
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -4,26 +4,98 @@ import (
 	"fmt"
 	"github.com/ksensehq/eventnative/adapters"
 	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/webhooks"
 	"log"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
-const unlockRetryCount = 5
+const (
+	unlockRetryCount = 5
+	//max length of string sample values in columns_added webhook
+	maxSampleLength = 256
+)
+
+//AddedColumn is a column description in columns_added webhook payload
+//sample: value of the first provided object which has the column (nil if there are no such objects)
+type AddedColumn struct {
+	Name   string      `json:"name"`
+	Type   string      `json:"type"`
+	Sample interface{} `json:"sample,omitempty"`
+}
 
 //Keeping tables schema state inmemory and update it according to incoming new data
 //note: Assume that after any outer changes in db we need to increment table version in MonitorKeeper
+//if existingTables is provided (compatibility mode) - tables are only read from db and data is fitted to them (see schema.ExistingTables)
+//if ddlWriter is provided (read-only schema mode) - tables are used as in compatibility mode but required DDL statements
+//are written with DDLWriter and tables schemas are re-read from db every DDLWriter.RefreshEvery()
+//if schemaEvolution is provided - tables aren't patched with types which conflict with existing columns types
+//(conflicting values are handled by the policy while DB typing), otherwise such types changes are errors
+//if columnsLimit is provided - fields which don't fit into the limit are moved into unmapped column before creating or patching tables
+//if tablesCache is provided - cached tables schemas are used at startup and actual ones are put into the cache (it isn't used
+//in compatibility and read-only schema modes: tables schemas are re-read from db there)
+//if metadataCache is provided - tables schemas are read from db through it (it is shared by all TableHelpers of the destination)
+//and known tables schemas are re-read every MetadataCache.RefreshInterval(). It isn't used in read-only schema mode:
+//tables schemas are re-read every DDLWriter.RefreshEvery() there
+//generated statements which can lose data or narrow columns (see adapters.IsDestructiveDDL) are never executed or written:
+//columns are dropped only explicitly with DropColumn. Tables of managers which neither provide (adapters.DDLProvider)
+//nor classify (adapters.SchemaChangesClassifier) their statements are never created or patched
 type TableHelper struct {
-	manager       adapters.TableManager
-	monitorKeeper MonitorKeeper
-	tables        map[string]*schema.Table
-	storageType   string
+	manager         adapters.TableManager
+	monitorKeeper   MonitorKeeper
+	storageType     string
+	existingTables  *schema.ExistingTables
+	ddlWriter       *DDLWriter
+	schemaEvolution *schema.SchemaEvolution
+	columnsLimit    *schema.ColumnsLimit
+	tablesCache     *schema.TablesCache
+	metadataCache   *schema.MetadataCache
+
+	mutex  sync.RWMutex
+	tables map[string]*schema.Table
+	//time of reading tables schemas from db
+	fetchedAt map[string]time.Time
+	//table.field of already logged skipped fields (compatibility mode) or unmapped fields (columns limit)
+	skippedFields map[string]bool
 }
 
-func NewTableHelper(manager adapters.TableManager, monitorKeeper MonitorKeeper, storageType string) *TableHelper {
+func NewTableHelper(manager adapters.TableManager, monitorKeeper MonitorKeeper, storageType string, existingTables *schema.ExistingTables,
+	ddlWriter *DDLWriter, schemaEvolution *schema.SchemaEvolution, columnsLimit *schema.ColumnsLimit, tablesCache *schema.TablesCache,
+	metadataCache *schema.MetadataCache) *TableHelper {
+	//read-only schema mode fits data to tables as is if compatibility mode isn't configured
+	if ddlWriter != nil && existingTables == nil {
+		existingTables, _ = schema.NewExistingTables(&schema.ExistingTablesConfig{Enabled: true})
+	}
+	if ddlWriter != nil {
+		metadataCache = nil
+	}
+
+	//tables schemas are always read from db in compatibility and read-only schema modes
+	if existingTables != nil {
+		tablesCache = nil
+	}
+	tables := map[string]*schema.Table{}
+	fetchedAt := map[string]time.Time{}
+	for _, table := range tablesCache.Tables() {
+		tables[table.Name] = table
+		fetchedAt[table.Name] = time.Now()
+	}
+
 	return &TableHelper{
-		manager:       manager,
-		monitorKeeper: monitorKeeper,
-		tables:        map[string]*schema.Table{},
-		storageType:   storageType,
+		manager:         manager,
+		monitorKeeper:   monitorKeeper,
+		tables:          tables,
+		storageType:     storageType,
+		existingTables:  existingTables,
+		ddlWriter:       ddlWriter,
+		schemaEvolution: schemaEvolution,
+		columnsLimit:    columnsLimit,
+		tablesCache:     tablesCache,
+		metadataCache:   metadataCache,
+		fetchedAt:       fetchedAt,
+		skippedFields:   map[string]bool{},
 	}
 }
 
@@ -31,21 +103,40 @@ func NewTableHelper(manager adapters.TableManager, monitorKeeper MonitorKeeper,
 //if table doesn't exist - create a new one and increment version
 //if exists - calculate diff, patch existing one with diff and increment version
 //return actual db table schema (with actual db types)
-func (th *TableHelper) EnsureTable(dataSchema *schema.Table) (*schema.Table, error) {
+//in compatibility mode table is never created or patched: return existing table schema or err if table doesn't exist
+//in read-only schema mode required CREATE TABLE or ALTER TABLE statements are also written with DDLWriter
+//samples are objects of dataSchema: their values of added columns are sent with columns_added webhook
+//(they are changed if fields are moved into unmapped column, so all objects must be passed)
+func (th *TableHelper) EnsureTable(dataSchema *schema.Table, samples ...map[string]interface{}) (*schema.Table, error) {
+	if th.existingTables != nil {
+		return th.existingTable(dataSchema)
+	}
+
 	var err error
+	th.mutex.RLock()
 	dbTableSchema, ok := th.tables[dataSchema.Name]
+	fetchedAt := th.fetchedAt[dataSchema.Name]
+	th.mutex.RUnlock()
 
-	//get or create
-	if !ok {
-		dbTableSchema, err = th.getOrCreate(dataSchema)
+	//get or create (known tables schemas are re-read every refresh interval)
+	if !ok || th.refreshRequired(fetchedAt) {
+		dbTableSchema, err = th.getOrCreate(dataSchema, samples)
 		if err != nil {
 			return nil, err
 		}
 
 		//save
+		th.mutex.Lock()
 		th.tables[dbTableSchema.Name] = dbTableSchema
+		th.fetchedAt[dbTableSchema.Name] = time.Now()
+		th.tablesCache.Put(dbTableSchema)
+		th.mutex.Unlock()
 	}
 
+	th.fitConflicts(dbTableSchema, dataSchema)
+	if err := th.fitColumnsLimit(dbTableSchema, dataSchema, samples); err != nil {
+		return nil, err
+	}
 	schemaDiff, err := dbTableSchema.Diff(dataSchema)
 	if err != nil {
 		return nil, err
@@ -69,13 +160,18 @@ func (th *TableHelper) EnsureTable(dataSchema *schema.Table) (*schema.Table, err
 
 	//get schema and calculate diff one more time if version was changed (this statement handles optimistic locking)
 	if ver != dbTableSchema.Version {
-		dbTableSchema, err = th.manager.GetTableSchema(dataSchema.Name)
+		th.metadataCache.Invalidate(dataSchema.Name)
+		dbTableSchema, err = th.getTableSchema(dataSchema.Name)
 		if err != nil {
 			return nil, fmt.Errorf("Error getting table %s schema from %s: %v", dataSchema.Name, th.storageType, err)
 		}
 
 		dbTableSchema.Version = ver
 
+		th.fitConflicts(dbTableSchema, dataSchema)
+		if err := th.fitColumnsLimit(dbTableSchema, dataSchema, samples); err != nil {
+			return nil, err
+		}
 		schemaDiff, err = dbTableSchema.Diff(dataSchema)
 		if err != nil {
 			return nil, err
@@ -88,9 +184,15 @@ func (th *TableHelper) EnsureTable(dataSchema *schema.Table) (*schema.Table, err
 	}
 
 	//patch and increment table version
-	if err := th.manager.PatchTableSchema(schemaDiff); err != nil {
+	if err := th.checkSchemaChange(schemaDiff, true); err != nil {
+		return nil, err
+	}
+	err = th.manager.PatchTableSchema(schemaDiff)
+	th.metadataCache.Invalidate(dbTableSchema.Name)
+	if err != nil {
 		return nil, err
 	}
+	th.fireColumnsAdded(schemaDiff, false, samples)
 
 	newVersion, err := th.monitorKeeper.IncrementVersion(dbTableSchema.Name)
 	if err != nil {
@@ -98,40 +200,420 @@ func (th *TableHelper) EnsureTable(dataSchema *schema.Table) (*schema.Table, err
 	}
 
 	//Save
+	th.mutex.Lock()
 	for k, v := range schemaDiff.Columns {
-		dbTableSchema.Columns[k] = v
+		dbTableSchema.Columns[k] = th.withBounds(v)
 	}
 	dbTableSchema.Version = newVersion
+	th.tables[dbTableSchema.Name] = dbTableSchema
+	th.tablesCache.Put(dbTableSchema)
+	th.mutex.Unlock()
 
 	return dbTableSchema, nil
 }
 
+//ApplyDBTyping apply DB schema types to the file objects (see schema.Processor.ApplyDBTyping)
+//and patch the table if numeric overflow string fallback columns were added into the file data schema
+//in compatibility mode file objects are fitted to the existing table before and after typing
+func (th *TableHelper) ApplyDBTyping(processor *schema.Processor, dbSchema *schema.Table, pf *schema.ProcessedFile) error {
+	th.fit(dbSchema, pf.DataSchema, pf.GetPayload()...)
+
+	columns := len(pf.DataSchema.Columns)
+	if err := processor.ApplyDBTyping(dbSchema, pf); err != nil {
+		return err
+	}
+
+	if len(pf.DataSchema.Columns) > columns {
+		if th.existingTables != nil {
+			th.fit(dbSchema, pf.DataSchema, pf.GetPayload()...)
+			return nil
+		}
+		if _, err := th.EnsureTable(pf.DataSchema, pf.GetPayload()...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//ApplyDBTypingToObject apply DB schema types to the object (see schema.Processor.ApplyDBTypingToObject)
+//and patch the table if numeric overflow string fallback columns were added into the data schema
+//in compatibility mode the object is fitted to the existing table before and after typing
+func (th *TableHelper) ApplyDBTypingToObject(processor *schema.Processor, dbSchema, dataSchema *schema.Table, object map[string]interface{}) error {
+	th.fit(dbSchema, dataSchema, object)
+
+	columns := len(dataSchema.Columns)
+	if err := processor.ApplyDBTypingToObject(dbSchema, dataSchema, object); err != nil {
+		return err
+	}
+
+	if len(dataSchema.Columns) > columns {
+		if th.existingTables != nil {
+			th.fit(dbSchema, dataSchema, object)
+			return nil
+		}
+		if _, err := th.EnsureTable(dataSchema, object); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//DeletionsTable return DB table schema of deletion events table without creating or patching it
+//return nil if table doesn't exist or doesn't have all deletion keys columns (there is nothing to delete)
+func (th *TableHelper) DeletionsTable(dataSchema *schema.Table) (*schema.Table, error) {
+	th.mutex.RLock()
+	dbTableSchema, ok := th.tables[dataSchema.Name]
+	th.mutex.RUnlock()
+
+	if !ok {
+		var err error
+		dbTableSchema, err = th.getTableSchema(dataSchema.Name)
+		if err != nil {
+			return nil, fmt.Errorf("Error getting table %s schema from %s: %v", dataSchema.Name, th.storageType, err)
+		}
+		if !dbTableSchema.Exists() {
+			return nil, nil
+		}
+	}
+
+	for _, key := range dataSchema.DeletionKeys {
+		if _, ok := dbTableSchema.Columns[key]; !ok {
+			return nil, nil
+		}
+	}
+
+	return dbTableSchema, nil
+}
+
+//PrepareDeletions apply DB schema types to keys values of deletion events files
+//return only files of existing tables: there is nothing to delete in other ones
+func (th *TableHelper) PrepareDeletions(processor *schema.Processor, deletions []*schema.ProcessedFile) ([]*schema.ProcessedFile, error) {
+	var result []*schema.ProcessedFile
+	for _, pf := range deletions {
+		dbSchema, err := th.DeletionsTable(pf.DataSchema)
+		if err != nil {
+			return nil, err
+		}
+		if dbSchema == nil {
+			continue
+		}
+
+		if err := processor.ApplyDBTyping(dbSchema, pf); err != nil {
+			return nil, err
+		}
+		result = append(result, pf)
+	}
+
+	return result, nil
+}
+
+//Tables return copies of all known tables schemas sorted by name
+func (th *TableHelper) Tables() []*schema.Table {
+	th.mutex.RLock()
+	defer th.mutex.RUnlock()
+
+	tables := []*schema.Table{}
+	for _, table := range th.tables {
+		columns := schema.Columns{}
+		for name, column := range table.Columns {
+			columns[name] = column
+		}
+		tables = append(tables, &schema.Table{Name: table.Name, Columns: columns, Version: table.Version})
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+
+	return tables
+}
+
+//DropColumnDDL return statements which drop the column of existing table (see DropColumn)
+//return err if the manager can't drop columns or table or column doesn't exist
+func (th *TableHelper) DropColumnDDL(tableName, columnName string) ([]string, error) {
+	dropper, err := th.columnDropper(tableName, columnName)
+	if err != nil {
+		return nil, err
+	}
+
+	return dropper.DropColumnDDL(tableName, columnName), nil
+}
+
+//DropColumn drop the column of existing table explicitly (admin API) and re-read the table schema from db
+func (th *TableHelper) DropColumn(tableName, columnName string) error {
+	if err := th.monitorKeeper.Lock(tableName); err != nil {
+		return fmt.Errorf("System error locking table %s in %s: %v", tableName, th.storageType, err)
+	}
+	defer th.unlock(tableName, 1)
+
+	dropper, err := th.columnDropper(tableName, columnName)
+	if err != nil {
+		return err
+	}
+	err = dropper.DropColumn(tableName, columnName)
+	th.metadataCache.Invalidate(tableName)
+	if err != nil {
+		return err
+	}
+	log.Printf("Column [%s] has been dropped from table [%s] in %s", columnName, tableName, th.storageType)
+
+	ver, err := th.monitorKeeper.IncrementVersion(tableName)
+	if err != nil {
+		return fmt.Errorf("Error incrementing version of table %s in %s: %v", tableName, th.storageType, err)
+	}
+	dbTableSchema, err := th.getTableSchema(tableName)
+	if err != nil {
+		th.mutex.Lock()
+		delete(th.tables, tableName)
+		th.mutex.Unlock()
+		return fmt.Errorf("Error getting table %s schema from %s: %v", tableName, th.storageType, err)
+	}
+	dbTableSchema.Version = ver
+
+	th.mutex.Lock()
+	th.tables[tableName] = dbTableSchema
+	th.fetchedAt[tableName] = time.Now()
+	th.tablesCache.Put(dbTableSchema)
+	th.mutex.Unlock()
+
+	return nil
+}
+
+//return cached or db schema of existing table without creating or patching it (compatibility and read-only schema modes)
+//in read-only schema mode:
+//write CREATE TABLE statements and return err if table doesn't exist (data will be stored after statements are applied)
+//write ALTER TABLE statements of missing columns (fields are skipped until statements are applied)
+func (th *TableHelper) existingTable(dataSchema *schema.Table) (*schema.Table, error) {
+	th.mutex.RLock()
+	dbTableSchema, ok := th.tables[dataSchema.Name]
+	fetchedAt := th.fetchedAt[dataSchema.Name]
+	th.mutex.RUnlock()
+
+	if !ok || th.refreshRequired(fetchedAt) {
+		var err error
+		dbTableSchema, err = th.getTableSchema(dataSchema.Name)
+		if err != nil {
+			return nil, fmt.Errorf("Error getting table %s schema from %s: %v", dataSchema.Name, th.storageType, err)
+		}
+		if !dbTableSchema.Exists() {
+			if th.ddlWriter == nil {
+				return nil, fmt.Errorf("Table %s doesn't exist in %s: tables aren't created with data_layout.existing_tables", dataSchema.Name, th.storageType)
+			}
+			if err := th.writeDDL(dataSchema.Name, func(provider adapters.DDLProvider) []string {
+				return provider.CreateTableDDL(th.existingTables.Missing(dbTableSchema, dataSchema))
+			}); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("Table %s doesn't exist in %s: tables aren't created with data_layout.read_only_schema. Apply written DDL statements", dataSchema.Name, th.storageType)
+		}
+
+		th.mutex.Lock()
+		th.tables[dbTableSchema.Name] = dbTableSchema
+		th.fetchedAt[dbTableSchema.Name] = time.Now()
+		th.mutex.Unlock()
+	}
+
+	if th.ddlWriter != nil {
+		missing := th.existingTables.Missing(dbTableSchema, dataSchema)
+		if missing.Exists() {
+			if err := th.writeDDL(dataSchema.Name, func(provider adapters.DDLProvider) []string {
+				return provider.PatchTableDDL(missing)
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return dbTableSchema, nil
+}
+
+//return true if cached table schema should be re-read from db
+func (th *TableHelper) refreshRequired(fetchedAt time.Time) bool {
+	switch {
+	case th.ddlWriter != nil:
+		return time.Since(fetchedAt) >= th.ddlWriter.RefreshEvery()
+	case th.metadataCache != nil:
+		return time.Since(fetchedAt) >= th.metadataCache.RefreshInterval()
+	default:
+		return false
+	}
+}
+
+//return table schema from metadata cache (if it is provided) or db
+func (th *TableHelper) getTableSchema(tableName string) (*schema.Table, error) {
+	return th.metadataCache.Get(tableName, th.manager.GetTableSchema)
+}
+
+//write DDL statements of the manager with DDLWriter (read-only schema mode)
+func (th *TableHelper) writeDDL(tableName string, statements func(provider adapters.DDLProvider) []string) error {
+	provider, ok := th.manager.(adapters.DDLProvider)
+	if !ok {
+		return fmt.Errorf("%s doesn't support data_layout.read_only_schema", th.storageType)
+	}
+
+	ddl := statements(provider)
+	if err := refuseDestructive(th.storageType, tableName, adapters.DestructiveStatements(ddl)); err != nil {
+		return err
+	}
+	if err := th.ddlWriter.Write(th.storageType, tableName, ddl); err != nil {
+		return fmt.Errorf("Error writing DDL statements of table %s in %s: %v", tableName, th.storageType, err)
+	}
+
+	return nil
+}
+
+//return err if any statement which the manager executes on creating (patch is false) or patching (patch is true) the table
+//is destructive or the manager neither provides nor classifies its statements
+func (th *TableHelper) checkSchemaChange(tableSchema *schema.Table, patch bool) error {
+	var destructive []string
+	switch manager := th.manager.(type) {
+	case adapters.DDLProvider:
+		if patch {
+			destructive = adapters.DestructiveStatements(manager.PatchTableDDL(tableSchema))
+		} else {
+			destructive = adapters.DestructiveStatements(manager.CreateTableDDL(tableSchema))
+		}
+	case adapters.SchemaChangesClassifier:
+		destructive = manager.DestructiveChanges(tableSchema, patch)
+	default:
+		return fmt.Errorf("Schema change of table %s in %s is refused: %s doesn't provide or classify its DDL statements", tableSchema.Name, th.storageType, th.storageType)
+	}
+
+	return refuseDestructive(th.storageType, tableSchema.Name, destructive)
+}
+
+//return err with the first destructive statement if there are any
+func refuseDestructive(storageType, tableName string, destructive []string) error {
+	if len(destructive) > 0 {
+		return fmt.Errorf("Destructive statement [%s] of table %s in %s is refused: columns are dropped only explicitly with admin API", destructive[0], tableName, storageType)
+	}
+
+	return nil
+}
+
+//return the manager as adapters.ColumnDropper if the column of the table exists in db
+func (th *TableHelper) columnDropper(tableName, columnName string) (adapters.ColumnDropper, error) {
+	dropper, ok := th.manager.(adapters.ColumnDropper)
+	if !ok {
+		return nil, fmt.Errorf("%s doesn't support dropping columns", th.storageType)
+	}
+
+	dbTableSchema, err := th.manager.GetTableSchema(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting table %s schema from %s: %v", tableName, th.storageType, err)
+	}
+	if !dbTableSchema.Exists() {
+		return nil, fmt.Errorf("Table %s doesn't exist in %s", tableName, th.storageType)
+	}
+	if _, ok := dbTableSchema.Columns[columnName]; !ok {
+		return nil, fmt.Errorf("Column %s doesn't exist in table %s in %s", columnName, tableName, th.storageType)
+	}
+
+	return dropper, nil
+}
+
+//fit data schema and objects to the existing table and log skipped fields once per table (compatibility mode only)
+func (th *TableHelper) fit(dbSchema, dataSchema *schema.Table, objects ...map[string]interface{}) {
+	if th.existingTables == nil {
+		return
+	}
+
+	skipped := th.existingTables.FitTable(dbSchema, dataSchema)
+	for _, object := range objects {
+		th.existingTables.FitObject(dbSchema, object)
+	}
+
+	var notLogged []string
+	th.mutex.Lock()
+	for _, field := range skipped {
+		key := dbSchema.Name + "." + field
+		if !th.skippedFields[key] {
+			th.skippedFields[key] = true
+			notLogged = append(notLogged, field)
+		}
+	}
+	th.mutex.Unlock()
+
+	if len(notLogged) > 0 {
+		log.Printf("Warn: existing table %s in %s doesn't have columns for fields %v. They will be skipped", dbSchema.Name, th.storageType, notLogged)
+	}
+}
+
+//replace data schema types which conflict with existing columns types with db ones (if schema evolution policy is provided)
+func (th *TableHelper) fitConflicts(dbSchema, dataSchema *schema.Table) {
+	if th.schemaEvolution != nil {
+		schema.FitConflicts(dbSchema, dataSchema)
+	}
+}
+
+//move fields which don't fit into columns limit into unmapped column (if columns limit is provided)
+//moved fields are logged once per table
+func (th *TableHelper) fitColumnsLimit(dbSchema, dataSchema *schema.Table, objects []map[string]interface{}) error {
+	if th.columnsLimit == nil {
+		return nil
+	}
+
+	moved, err := th.columnsLimit.Fit(dbSchema, dataSchema, objects)
+	if err != nil {
+		return err
+	}
+
+	var notLogged []string
+	th.mutex.Lock()
+	for _, field := range moved {
+		key := dataSchema.Name + "." + field
+		if !th.skippedFields[key] {
+			th.skippedFields[key] = true
+			notLogged = append(notLogged, field)
+		}
+	}
+	th.mutex.Unlock()
+
+	if len(notLogged) > 0 {
+		log.Printf("Warn: table %s in %s has reached data_layout.max_columns limit. Fields %v will be written into %s column", dataSchema.Name,
+			th.storageType, notLogged, schema.UnmappedColumn)
+	}
+
+	return nil
+}
+
 //lock table -> get existing schema -> create a new one if doesn't exist -> return schema with version
-func (th *TableHelper) getOrCreate(dataSchema *schema.Table) (*schema.Table, error) {
+func (th *TableHelper) getOrCreate(dataSchema *schema.Table, samples []map[string]interface{}) (*schema.Table, error) {
 	if err := th.monitorKeeper.Lock(dataSchema.Name); err != nil {
 		return nil, fmt.Errorf("System error locking table %s in %s: %v", dataSchema.Name, th.storageType, err)
 	}
 	defer th.unlock(dataSchema.Name, 1)
 
 	//Get schema
-	dbTableSchema, err := th.manager.GetTableSchema(dataSchema.Name)
+	dbTableSchema, err := th.getTableSchema(dataSchema.Name)
 	if err != nil {
 		return nil, fmt.Errorf("Error getting table %s schema from %s: %v", dataSchema.Name, th.storageType, err)
 	}
 
 	//create new or get version
 	if !dbTableSchema.Exists() {
-		if err := th.manager.CreateTable(dataSchema); err != nil {
+		if err := th.fitColumnsLimit(dbTableSchema, dataSchema, samples); err != nil {
+			return nil, err
+		}
+		if err := th.checkSchemaChange(dataSchema, false); err != nil {
+			return nil, err
+		}
+		err := th.manager.CreateTable(dataSchema)
+		//cached schema of missing table is stale after creating (or the table has been created by another process if it is failed)
+		th.metadataCache.Invalidate(dataSchema.Name)
+		if err != nil {
 			return nil, fmt.Errorf("Error creating table %s in %s: %v", dataSchema.Name, th.storageType, err)
 		}
+		th.fireColumnsAdded(dataSchema, true, samples)
 
 		ver, err := th.monitorKeeper.IncrementVersion(dataSchema.Name)
 		if err != nil {
 			return nil, fmt.Errorf("Error incrementing version of table %s in %s: %v", dataSchema.Name, th.storageType, err)
 		}
 
-		dbTableSchema = dataSchema
-		dbTableSchema.Version = ver
+		dbTableSchema = &schema.Table{Name: dataSchema.Name, Columns: schema.Columns{}, Version: ver}
+		for k, v := range dataSchema.Columns {
+			dbTableSchema.Columns[k] = th.withBounds(v)
+		}
 	} else {
 		ver, err := th.monitorKeeper.GetVersion(dbTableSchema.Name)
 		if err != nil {
@@ -144,6 +626,66 @@ func (th *TableHelper) getOrCreate(dataSchema *schema.Table) (*schema.Table, err
 	return dbTableSchema, nil
 }
 
+//return column with value range of the DB type which is created by the manager (if it is limited)
+//DECIMAL columns ranges are defined by their precision and scale
+func (th *TableHelper) withBounds(column schema.Column) schema.Column {
+	provider, ok := th.manager.(adapters.NumericBoundsProvider)
+	if !ok || column.Decimal() != nil {
+		return column
+	}
+
+	bounds := provider.NumericBounds(column.GetType())
+	if bounds == nil {
+		return column
+	}
+
+	return schema.NewBoundedColumn(column.GetType(), bounds)
+}
+
+//fire webhook with added columns names and their types and sample values
+//columns which are added into existing table are logged as well
+func (th *TableHelper) fireColumnsAdded(table *schema.Table, created bool, samples []map[string]interface{}) {
+	var columns []string
+	for name := range table.Columns {
+		columns = append(columns, name)
+	}
+	sort.Strings(columns)
+
+	addedColumns := []*AddedColumn{}
+	for _, name := range columns {
+		addedColumn := &AddedColumn{Name: name, Type: table.Columns[name].GetType().String(), Sample: sampleValue(name, samples)}
+		addedColumns = append(addedColumns, addedColumn)
+		if !created {
+			log.Printf("New column [%s] of type %s has been added into table [%s] in %s. Sample value: %v", name, addedColumn.Type, table.Name,
+				th.storageType, addedColumn.Sample)
+		}
+	}
+
+	webhooks.Fire(webhooks.ColumnsAdded, map[string]interface{}{"storage_type": th.storageType, "table": table.Name, "table_created": created, "columns": columns,
+		"added_columns": addedColumns})
+}
+
+//return the first not nil value of the column in objects. Long strings are truncated (on runes boundary)
+func sampleValue(column string, objects []map[string]interface{}) interface{} {
+	for _, object := range objects {
+		value, ok := object[column]
+		if !ok || value == nil {
+			continue
+		}
+
+		if str, ok := value.(string); ok && len(str) > maxSampleLength {
+			end := maxSampleLength
+			for end > 0 && !utf8.RuneStart(str[end]) {
+				end--
+			}
+			return str[:end] + "..."
+		}
+		return value
+	}
+
+	return nil
+}
+
 func (th *TableHelper) unlock(tableName string, retry int) {
 	if err := th.monitorKeeper.Unlock(tableName); err != nil {
 		if retry == unlockRetryCount {
@@ -0,0 +1,164 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampleValue(t *testing.T) {
+	objects := []map[string]interface{}{
+		{"id": 1, "utm_source": nil},
+		{"id": 2, "utm_source": "google", "payload": strings.Repeat("a", 300), "title": "a" + strings.Repeat("я", 200)},
+	}
+
+	require.Equal(t, 1, sampleValue("id", objects))
+	require.Equal(t, "google", sampleValue("utm_source", objects))
+	require.Equal(t, strings.Repeat("a", 256)+"...", sampleValue("payload", objects))
+	//2-byte runes: the last one which doesn't fit is dropped
+	require.Equal(t, "a"+strings.Repeat("я", 127)+"...", sampleValue("title", objects))
+	require.Nil(t, sampleValue("unknown", objects))
+	require.Nil(t, sampleValue("id", nil))
+}
+
+type tableManagerMock struct {
+	tables   map[string]*schema.Table
+	patchDDL []string
+	reads    int
+	patched  int
+	dropped  []string
+}
+
+func (tm *tableManagerMock) GetTableSchema(tableName string) (*schema.Table, error) {
+	tm.reads++
+	table, ok := tm.tables[tableName]
+	if !ok {
+		return &schema.Table{Name: tableName, Columns: schema.Columns{}}, nil
+	}
+
+	columns := schema.Columns{}
+	for name, column := range table.Columns {
+		columns[name] = column
+	}
+	return &schema.Table{Name: tableName, Columns: columns}, nil
+}
+
+func (tm *tableManagerMock) CreateTable(tableSchema *schema.Table) error {
+	tm.tables[tableSchema.Name] = tableSchema
+	return nil
+}
+
+func (tm *tableManagerMock) PatchTableSchema(patchSchema *schema.Table) error {
+	tm.patched++
+	for name, column := range patchSchema.Columns {
+		tm.tables[patchSchema.Name].Columns[name] = column
+	}
+	return nil
+}
+
+func (tm *tableManagerMock) CreateTableDDL(tableSchema *schema.Table) []string {
+	return []string{"CREATE TABLE " + tableSchema.Name}
+}
+
+func (tm *tableManagerMock) PatchTableDDL(patchSchema *schema.Table) []string {
+	return tm.patchDDL
+}
+
+func (tm *tableManagerMock) DropColumnDDL(tableName, columnName string) []string {
+	return []string{"ALTER TABLE " + tableName + " DROP COLUMN " + columnName}
+}
+
+func (tm *tableManagerMock) DropColumn(tableName, columnName string) error {
+	delete(tm.tables[tableName].Columns, columnName)
+	tm.dropped = append(tm.dropped, tableName+"."+columnName)
+	return nil
+}
+
+func TestTableHelperRefusesDestructiveDDL(t *testing.T) {
+	manager := &tableManagerMock{tables: map[string]*schema.Table{}}
+	th := NewTableHelper(manager, NewMonitorKeeper(), "postgres", nil, nil, nil, nil, nil, nil)
+
+	_, err := th.EnsureTable(&schema.Table{Name: "events", Columns: schema.Columns{"id": schema.NewColumn(typing.INT64)}})
+	require.NoError(t, err)
+
+	manager.patchDDL = []string{"ALTER TABLE events ADD COLUMN url text", "ALTER TABLE events ALTER COLUMN id TYPE integer"}
+	_, err = th.EnsureTable(&schema.Table{Name: "events", Columns: schema.Columns{"url": schema.NewColumn(typing.STRING)}})
+	require.EqualError(t, err, "Destructive statement [ALTER TABLE events ALTER COLUMN id TYPE integer] of table events in postgres is refused: columns are dropped only explicitly with admin API")
+	require.Equal(t, 0, manager.patched)
+
+	manager.patchDDL = []string{"ALTER TABLE events ADD COLUMN url text"}
+	_, err = th.EnsureTable(&schema.Table{Name: "events", Columns: schema.Columns{"url": schema.NewColumn(typing.STRING)}})
+	require.NoError(t, err)
+	require.Equal(t, 1, manager.patched)
+}
+
+func TestTableHelperRefusesUnclassifiedSchemaChanges(t *testing.T) {
+	manager := &tableManagerMock{tables: map[string]*schema.Table{}}
+	//the manager neither provides nor classifies its statements
+	th := NewTableHelper(struct{ adapters.TableManager }{manager}, NewMonitorKeeper(), "custom", nil, nil, nil, nil, nil, nil)
+
+	_, err := th.EnsureTable(&schema.Table{Name: "events", Columns: schema.Columns{"id": schema.NewColumn(typing.INT64)}})
+	require.EqualError(t, err, "Schema change of table events in custom is refused: custom doesn't provide or classify its DDL statements")
+	require.Empty(t, manager.tables)
+}
+
+func TestTableHelperDropColumn(t *testing.T) {
+	manager := &tableManagerMock{tables: map[string]*schema.Table{
+		"events": {Name: "events", Columns: schema.Columns{"id": schema.NewColumn(typing.INT64), "old_field": schema.NewColumn(typing.STRING)}},
+	}}
+	th := NewTableHelper(manager, NewMonitorKeeper(), "postgres", nil, nil, nil, nil, nil, nil)
+	_, err := th.EnsureTable(&schema.Table{Name: "events", Columns: schema.Columns{"id": schema.NewColumn(typing.INT64)}})
+	require.NoError(t, err)
+
+	_, err = th.DropColumnDDL("users", "id")
+	require.EqualError(t, err, "Table users doesn't exist in postgres")
+	_, err = th.DropColumnDDL("events", "unknown")
+	require.EqualError(t, err, "Column unknown doesn't exist in table events in postgres")
+
+	statements, err := th.DropColumnDDL("events", "old_field")
+	require.NoError(t, err)
+	require.Equal(t, []string{"ALTER TABLE events DROP COLUMN old_field"}, statements)
+	require.Empty(t, manager.dropped, "statements are only returned")
+
+	require.NoError(t, th.DropColumn("events", "old_field"))
+	require.Equal(t, []string{"events.old_field"}, manager.dropped)
+	tables := th.Tables()
+	require.Len(t, tables, 1)
+	require.Equal(t, "id", columnNames(tables[0]), "table schema is re-read after dropping")
+}
+
+func TestTableHelperMetadataCache(t *testing.T) {
+	manager := &tableManagerMock{tables: map[string]*schema.Table{
+		"events": {Name: "events", Columns: schema.Columns{"id": schema.NewColumn(typing.INT64)}},
+	}}
+	metadataCache, err := schema.NewMetadataCache(&schema.TablesCacheConfig{RefreshInterval: 50 * time.Millisecond})
+	require.NoError(t, err)
+	//e.g. ClickHouse nodes
+	th1 := NewTableHelper(manager, NewMonitorKeeper(), "clickhouse", nil, nil, nil, nil, nil, metadataCache)
+	th2 := NewTableHelper(manager, NewMonitorKeeper(), "clickhouse", nil, nil, nil, nil, nil, metadataCache)
+
+	for _, th := range []*TableHelper{th1, th2, th1, th2} {
+		_, err := th.EnsureTable(&schema.Table{Name: "events", Columns: schema.Columns{"id": schema.NewColumn(typing.INT64)}})
+		require.NoError(t, err)
+		_, err = th.DeletionsTable(&schema.Table{Name: "users"})
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, manager.reads, "schemas of events table and missing users table are read once")
+
+	//patching invalidates the cached schema
+	_, err = th1.EnsureTable(&schema.Table{Name: "events", Columns: schema.Columns{"url": schema.NewColumn(typing.STRING)}})
+	require.NoError(t, err)
+	require.Equal(t, 1, manager.patched)
+
+	//known tables schemas are re-read after refresh interval
+	time.Sleep(60 * time.Millisecond)
+	dbSchema, err := th2.EnsureTable(&schema.Table{Name: "events", Columns: schema.Columns{"id": schema.NewColumn(typing.INT64)}})
+	require.NoError(t, err)
+	require.Equal(t, "id,url", columnNames(dbSchema))
+	require.Equal(t, 3, manager.reads)
+	require.Equal(t, 1, manager.patched)
+}
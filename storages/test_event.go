@@ -0,0 +1,258 @@
+package storages
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/ksensehq/eventnative/appconfig"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/ksensehq/eventnative/typing"
+	"strings"
+	"sync"
+	"time"
+)
+
+//test event statuses
+const (
+	TestEventLanded    = "landed"     //row has been selected from the destination table
+	TestEventNotLanded = "not_landed" //row hasn't been selected from the destination table until timeout
+	TestEventStored    = "stored"     //batch storage has stored the event, but the destination can't be queried
+	TestEventQueued    = "queued"     //stream consumer has accepted the event, but the destination can't be queried
+	TestEventFiltered  = "filtered"   //event doesn't match destination filter or is out of time bounds
+	TestEventFailed    = "failed"     //event hasn't been processed or stored
+
+	testEventType         = "eventnative_test"
+	testEventPollInterval = time.Second
+	//the same name as rotated event log files have: $serverName-event-$token-$time.log
+	testEventFileTimeLayout = "2006-01-02T15-04-05.000"
+)
+
+//schema processors per destination name. They resolve tables of test events
+var (
+	processorsMutex sync.RWMutex
+	processors      = map[string]*schema.Processor{}
+)
+
+//TestEventResult is a result of sending the test event through the destination pipeline
+//row is the landed row selected from the destination table by event id (SQL destinations only)
+//report is a load report of batch destinations
+type TestEventResult struct {
+	Destination string                 `json:"destination"`
+	Status      string                 `json:"status"`
+	Table       string                 `json:"table,omitempty"`
+	Event       events.Fact            `json:"event"`
+	Row         map[string]interface{} `json:"row,omitempty"`
+	Report      *reports.LoadReport    `json:"report,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+}
+
+//set (or remove if processor is nil) destination schema processor
+func setProcessor(destinationName string, processor *schema.Processor) {
+	processorsMutex.Lock()
+	defer processorsMutex.Unlock()
+
+	if processor == nil {
+		delete(processors, destinationName)
+		return
+	}
+
+	processors[destinationName] = processor
+}
+
+func getProcessor(destinationName string) *schema.Processor {
+	processorsMutex.RLock()
+	defer processorsMutex.RUnlock()
+
+	return processors[destinationName]
+}
+
+//NewTestEvent return representative event of the destination: a canned pageview if table is empty
+//or an event with typed test values of all table columns from the schema catalog (see GetTables) otherwise
+//system columns (timestamp, event id, token and source) are put by SendTestEvent
+//return err if the destination doesn't know the table
+func NewTestEvent(destinationName, table string) (events.Fact, error) {
+	if table == "" {
+		return events.Fact{
+			"event_type": testEventType,
+			"eventn_ctx": map[string]interface{}{
+				"url":        "https://eventnative.test/smoke?utm_source=eventnative",
+				"page_title": "EventNative test event",
+				"referer":    "",
+				"user":       map[string]interface{}{"anonymous_id": "eventnative_test"},
+			},
+		}, nil
+	}
+
+	tables, _ := GetTables(destinationName)
+	for _, t := range tables {
+		if t.Name != table {
+			continue
+		}
+
+		systemColumns := map[string]bool{}
+		if processor := getProcessor(destinationName); processor != nil {
+			for _, column := range []string{timestamp.Key, schema.EventIDColumn, schema.TokenColumn, schema.SourceColumn} {
+				systemColumns[processor.SystemColumn(column)] = true
+			}
+		}
+
+		fact := events.Fact{}
+		for name, column := range t.Columns {
+			if !systemColumns[name] {
+				fact[name] = testValue(column.GetType())
+			}
+		}
+		return fact, nil
+	}
+
+	return nil, fmt.Errorf("Table [%s] isn't in destination [%s] schema catalog", table, destinationName)
+}
+
+//return test value of column type as it is deserialized from JSON
+func testValue(dataType typing.DataType) interface{} {
+	switch dataType {
+	case typing.INT64:
+		return 1.0
+	case typing.FLOAT64, typing.DECIMAL:
+		return 1.5
+	case typing.TIMESTAMP:
+		return time.Now().UTC().Format(timestamp.Layout)
+	case typing.ARRAY_INT64:
+		return []interface{}{1.0, 2.0}
+	case typing.ARRAY_FLOAT64:
+		return []interface{}{1.5, 2.5}
+	case typing.ARRAY_STRING:
+		return []interface{}{testEventType}
+	case typing.JSON:
+		return map[string]interface{}{"test": true}
+	default:
+		return testEventType
+	}
+}
+
+//SendTestEvent put system columns into the fact and pass it through the destination pipeline: batch storages store it
+//as an event log file right away and stream consumers put it into the stream queue. Then the row is selected from the
+//destination table by event id until timeout (if the destination can be queried)
+//return false if the destination isn't running
+func (d *Destinations) SendTestEvent(destinationName string, fact events.Fact, timeout time.Duration) (*TestEventResult, bool) {
+	d.mutex.RLock()
+	entry, ok := d.entries[destinationName]
+	d.mutex.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	token := ""
+	if len(entry.tokens) > 0 {
+		token = entry.tokens[0]
+	}
+	now := time.Now().UTC()
+	eventID := uuid.New().String()
+	ctx, ok := fact["eventn_ctx"].(map[string]interface{})
+	if !ok {
+		ctx = map[string]interface{}{}
+		fact["eventn_ctx"] = ctx
+	}
+	ctx["event_id"] = eventID
+	fact[schema.TokenColumn] = token
+	fact[schema.SourceColumn] = testEventType
+	fact[timestamp.Key] = now.Format(timestamp.Layout)
+
+	result := &TestEventResult{Destination: destinationName, Event: fact}
+	payload, err := json.Marshal(fact)
+	if err != nil {
+		return result.fail(err), true
+	}
+
+	//table is resolved by the destination data layout on a copy: the pipeline changes objects
+	processor := getProcessor(destinationName)
+	if processor != nil {
+		copied := events.Fact{}
+		if err := json.Unmarshal(payload, &copied); err != nil {
+			return result.fail(err), true
+		}
+		table, err := processor.ResolveTable(copied)
+		if err != nil {
+			return result.fail(err), true
+		}
+		if table == nil || !table.Exists() {
+			result.Status = TestEventFiltered
+			return result, true
+		}
+		result.Table = table.Name
+	}
+
+	if entry.storage != nil {
+		fileName := fmt.Sprintf("%s-event-%s-%s.log", appconfig.Instance.ServerName, token, now.Format(testEventFileTimeLayout))
+		report := reports.NewLoadReport(fileName, destinationName, token, 1)
+		err := entry.storage.Store(fileName, payload, report)
+		report.Finish(err)
+		result.Report = report
+		if err != nil {
+			return result.fail(err), true
+		}
+		if report.Loaded == 0 {
+			result.Status = TestEventFailed
+			result.Error = strings.Join(report.Samples, "; ")
+			return result, true
+		}
+		result.Status = TestEventStored
+	} else {
+		//the queued event is processed asynchronously, so the result event isn't passed
+		queued := events.Fact{}
+		if err := json.Unmarshal(payload, &queued); err != nil {
+			return result.fail(err), true
+		}
+		entry.consumer.Consume(queued)
+		result.Status = TestEventQueued
+	}
+
+	if processor == nil || result.Table == "" {
+		return result, true
+	}
+
+	row, selectable, err := selectTestRow(destinationName, result.Table, processor, eventID, timeout)
+	switch {
+	case !selectable:
+	case row != nil:
+		result.Status = TestEventLanded
+		result.Row = row
+	default:
+		result.Status = TestEventNotLanded
+		if err != nil {
+			result.Error = err.Error()
+		}
+	}
+
+	return result, true
+}
+
+func (ter *TestEventResult) fail(err error) *TestEventResult {
+	ter.Status = TestEventFailed
+	ter.Error = err.Error()
+	return ter
+}
+
+//select the row with event id from the destination table every testEventPollInterval until timeout
+//return false if the destination can't be queried (see LastRowsSelector) and the last select error if the row hasn't landed
+func selectTestRow(destinationName, table string, processor *schema.Processor, eventID string, timeout time.Duration) (map[string]interface{}, bool, error) {
+	keyColumn := processor.SystemColumn(schema.EventIDColumn)
+	orderColumn := processor.SystemColumn(timestamp.Key)
+	deadline := time.Now().Add(timeout)
+	for {
+		rows, ok, err := SelectLastRows(destinationName, table, keyColumn, orderColumn, eventID, 1)
+		if !ok {
+			return nil, false, nil
+		}
+		if err == nil && len(rows) > 0 {
+			return rows[0], true, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, true, err
+		}
+		time.Sleep(testEventPollInterval)
+	}
+}
@@ -0,0 +1,95 @@
+package storages
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/ksensehq/eventnative/appconfig"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"github.com/ksensehq/eventnative/typing"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+//batch storage which keeps stored objects as table rows and can select them by key column
+type selectableStorageMock struct {
+	storageMock
+	rows []map[string]interface{}
+}
+
+func (ssm *selectableStorageMock) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	for _, line := range bytes.Split(payload, []byte("\n")) {
+		row := map[string]interface{}{}
+		if err := json.Unmarshal(line, &row); err != nil {
+			return err
+		}
+		ssm.rows = append(ssm.rows, row)
+	}
+	return nil
+}
+
+func (ssm *selectableStorageMock) SelectLast(tableName, keyColumn, orderColumn string, value interface{}, limit int) ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	for _, row := range ssm.rows {
+		if ctx, ok := row["eventn_ctx"].(map[string]interface{}); ok && keyColumn == schema.EventIDColumn && ctx["event_id"] == value {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func (ssm *selectableStorageMock) Tables() []*schema.Table {
+	return []*schema.Table{{Name: "events", Columns: schema.Columns{
+		"_timestamp":          schema.NewColumn(typing.TIMESTAMP),
+		"eventn_ctx_event_id": schema.NewColumn(typing.STRING),
+		"page_views":          schema.NewColumn(typing.INT64),
+		"revenue":             schema.NewColumn(typing.FLOAT64),
+		"tags":                schema.NewColumn(typing.ARRAY_STRING),
+	}}}
+}
+
+func TestSendTestEvent(t *testing.T) {
+	if appconfig.Instance == nil {
+		appconfig.Instance = &appconfig.AppConfig{ServerName: "test", AuthorizedTokens: map[string]bool{}}
+	}
+
+	processor, err := schema.NewProcessor(&schema.ProcessorConfig{TableNameTemplate: "events", Filter: "event_type != 'skip'"})
+	require.NoError(t, err)
+	setProcessor("test_event", processor)
+	defer setProcessor("test_event", nil)
+
+	storage := &selectableStorageMock{}
+	registerDestination(&DestinationStatus{Name: "test_event", Mode: batchMode}, storage)
+	defer unregisterDestination("test_event")
+	destinations := NewDestinations(context.Background(), "", nil)
+	destinations.entries["test_event"] = &destinationEntry{tokens: []string{"token1"}, storage: storage}
+
+	fact, err := NewTestEvent("test_event", "")
+	require.NoError(t, err)
+	result, ok := destinations.SendTestEvent("test_event", fact, time.Second)
+	require.True(t, ok)
+	require.Equal(t, TestEventLanded, result.Status, result.Error)
+	require.Equal(t, "events", result.Table)
+	require.Equal(t, 1, result.Report.Loaded)
+	require.Equal(t, "token1", result.Event["api_key"])
+	require.Equal(t, result.Event["eventn_ctx"].(map[string]interface{})["event_id"], result.Row["eventn_ctx"].(map[string]interface{})["event_id"])
+
+	//event from schema catalog doesn't have system columns
+	fact, err = NewTestEvent("test_event", "events")
+	require.NoError(t, err)
+	require.Equal(t, events.Fact{"page_views": 1.0, "revenue": 1.5, "tags": []interface{}{testEventType}}, fact)
+
+	_, err = NewTestEvent("test_event", "users")
+	require.EqualError(t, err, "Table [users] isn't in destination [test_event] schema catalog")
+
+	result, ok = destinations.SendTestEvent("test_event", events.Fact{"event_type": "skip"}, time.Second)
+	require.True(t, ok)
+	require.Equal(t, TestEventFiltered, result.Status)
+	require.Len(t, storage.rows, 1)
+
+	_, ok = destinations.SendTestEvent("unknown", events.Fact{}, time.Second)
+	require.False(t, ok)
+}
@@ -0,0 +1,165 @@
+package storages
+
+import (
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/ksensehq/eventnative/adapters"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/reports"
+	"github.com/ksensehq/eventnative/schema"
+	"log"
+)
+
+//Send processed events to an arbitrary HTTP endpoint in two modes:
+//batch: (1 file = requests with all file objects by batch_size objects)
+//stream: via events queue and FileBatcher (objects are accumulated and sent every flush_every or by batch_size objects)
+type Webhook struct {
+	name            string
+	webhookAdapter  *adapters.Webhook
+	batchSize       int
+	schemaProcessor *schema.Processor
+	eventQueue      *events.PersistentQueue
+	batcher         *FileBatcher
+	breakOnError    bool
+}
+
+//NewWebhook return Webhook and start goroutine for stream consumer if destination is in stream mode
+func NewWebhook(name, fallbackDir string, config *adapters.WebhookConfig, processor *schema.Processor, breakOnError, streamMode bool) (*Webhook, error) {
+	webhookAdapter := adapters.NewWebhook(config)
+
+	wh := &Webhook{
+		name:            name,
+		webhookAdapter:  webhookAdapter,
+		batchSize:       config.BatchSize,
+		schemaProcessor: processor,
+		breakOnError:    breakOnError,
+	}
+
+	if streamMode {
+		var err error
+		wh.eventQueue, err = newEventQueue(name, fallbackDir)
+		if err != nil {
+			webhookAdapter.Close()
+			return nil, err
+		}
+
+		wh.batcher = NewFileBatcher(name, &FilesConfig{UploadEvery: config.FlushEvery, MaxObjects: config.BatchSize}, wh.send)
+		wh.startStreamingConsumer()
+	}
+
+	return wh, nil
+}
+
+//Consume events.Fact and enqueue it
+func (wh *Webhook) Consume(fact events.Fact) {
+	if err := wh.eventQueue.Enqueue(fact); err != nil {
+		logSkippedEvent(wh.name, fact, err)
+	}
+}
+
+//QueueSize return count of events in the stream mode queue
+func (wh *Webhook) QueueSize() int {
+	return wh.eventQueue.Size()
+}
+
+//Run goroutine to:
+//1. read from queue
+//2. put processed object into batcher
+func (wh *Webhook) startStreamingConsumer() {
+	go func() {
+		for {
+			if appstatus.Instance.Idle {
+				break
+			}
+			fact, err := wh.eventQueue.DequeueBlock()
+			if err != nil {
+				if err == events.ErrQueueClosed {
+					break
+				}
+				log.Println("Error reading event fact from webhook queue", err)
+				continue
+			}
+
+			dataSchema, flattenObject, err := wh.schemaProcessor.ProcessFact(fact)
+			if err != nil {
+				log.Printf("Unable to process object %v: %v", fact, err)
+				counters.ErrorEvents(wh.name, 1)
+				continue
+			}
+
+			//don't process empty object
+			if !dataSchema.Exists() {
+				continue
+			}
+
+			wh.batcher.Add(dataSchema, flattenObject)
+		}
+	}()
+}
+
+//Store file payload to webhook with processing
+func (wh *Webhook) Store(fileName string, payload []byte, report *reports.LoadReport) error {
+	if err := injectFault(wh.name); err != nil {
+		return err
+	}
+
+	flatData, err := wh.schemaProcessor.ProcessFilePayload(fileName, payload, wh.breakOnError, report)
+	if err != nil {
+		return err
+	}
+
+	for _, fdata := range flatData {
+		if err := wh.send(fdata); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//send all objects of the file with requests by batchSize objects
+func (wh *Webhook) send(fdata *schema.ProcessedFile) error {
+	objects := fdata.GetPayload()
+	for start := 0; start < len(objects); start += wh.batchSize {
+		end := start + wh.batchSize
+		if end > len(objects) {
+			end = len(objects)
+		}
+
+		if err := wh.webhookAdapter.Send(fdata.DataSchema.Name, objects[start:end]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (wh *Webhook) Name() string {
+	return wh.name
+}
+
+func (wh *Webhook) Type() string {
+	return wh.webhookAdapter.Name()
+}
+
+func (wh *Webhook) Close() (multiErr error) {
+	if wh.batcher != nil {
+		if err := wh.batcher.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing webhook batcher: %v", err))
+		}
+	}
+
+	if wh.eventQueue != nil {
+		if err := wh.eventQueue.Close(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing webhook event queue: %v", err))
+		}
+	}
+
+	if err := wh.webhookAdapter.Close(); err != nil {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("Error closing webhook client: %v", err))
+	}
+
+	return
+}
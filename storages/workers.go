@@ -0,0 +1,108 @@
+package storages
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/performance"
+	"sync"
+)
+
+const defaultUploadWorkers = 1
+
+//destination types which consume stream mode events from the queue by several workers
+var streamWorkersDestinationTypes = []string{"postgres", "mssql", "redshift", "bigquery", "clickhouse", "snowflake"}
+
+//workers configs per destination name (only destinations with workers config)
+var (
+	workersMutex sync.RWMutex
+	workers      = map[string]*WorkersConfig{}
+)
+
+//WorkersConfig dto for deserialized destination workers config. Every destination has its own pool of workers (bulkhead)
+//so a slow destination (e.g. a throttled API) doesn't stall other ones
+//stream: goroutines which consume events from the destination queue in stream mode (default: performance.stream_workers)
+//upload: event log files which are stored into the destination concurrently in batch mode (default: 1)
+type WorkersConfig struct {
+	Stream int `mapstructure:"stream"`
+	Upload int `mapstructure:"upload"`
+}
+
+//Validate WorkersConfig values
+func (wc *WorkersConfig) Validate() error {
+	if wc.Stream < 0 || wc.Upload < 0 {
+		return errors.New("workers stream and upload can't be negative")
+	}
+
+	return nil
+}
+
+//return err if workers are configured for destination type or mode which doesn't support them or config is invalid
+func validateWorkers(destination *DestinationConfig) error {
+	if destination.Workers == nil {
+		return nil
+	}
+
+	if err := destination.Workers.Validate(); err != nil {
+		return err
+	}
+
+	if destination.Mode == streamMode {
+		if destination.Workers.Upload > 0 {
+			return errors.New("workers.upload is supported only in batch mode")
+		}
+		if destination.Workers.Stream == 0 {
+			return nil
+		}
+		for _, t := range streamWorkersDestinationTypes {
+			if t == destination.Type {
+				return nil
+			}
+		}
+		return fmt.Errorf("workers.stream isn't supported by %s destination. Supported types: %v", destination.Type, streamWorkersDestinationTypes)
+	}
+
+	if destination.Workers.Stream > 0 {
+		return errors.New("workers.stream is supported only in stream mode")
+	}
+
+	return nil
+}
+
+//set (or remove if config is nil) destination workers config. It is used by storages which are created afterwards
+//and by the log files uploader (see UploadWorkers)
+func setWorkers(destinationName string, config *WorkersConfig) {
+	workersMutex.Lock()
+	defer workersMutex.Unlock()
+
+	if config == nil {
+		delete(workers, destinationName)
+		return
+	}
+
+	workers[destinationName] = config
+}
+
+func getWorkers(destinationName string) *WorkersConfig {
+	workersMutex.RLock()
+	defer workersMutex.RUnlock()
+
+	return workers[destinationName]
+}
+
+//return count of stream consumer goroutines of the destination
+func streamWorkers(destinationName string) int {
+	if config := getWorkers(destinationName); config != nil && config.Stream > 0 {
+		return config.Stream
+	}
+
+	return performance.Instance.StreamWorkers
+}
+
+//UploadWorkers return count of event log files which can be stored into the destination concurrently
+func UploadWorkers(destinationName string) int {
+	if config := getWorkers(destinationName); config != nil && config.Upload > 0 {
+		return config.Upload
+	}
+
+	return defaultUploadWorkers
+}
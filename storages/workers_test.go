@@ -0,0 +1,74 @@
+package storages
+
+import (
+	"github.com/ksensehq/eventnative/performance"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestValidateWorkers(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination *DestinationConfig
+		expectedErr string
+	}{
+		{
+			"not configured",
+			&DestinationConfig{Type: "postgres", Mode: streamMode},
+			"",
+		},
+		{
+			"negative values",
+			&DestinationConfig{Type: "postgres", Mode: streamMode, Workers: &WorkersConfig{Stream: -1}},
+			"workers stream and upload can't be negative",
+		},
+		{
+			"upload in stream mode",
+			&DestinationConfig{Type: "postgres", Mode: streamMode, Workers: &WorkersConfig{Upload: 2}},
+			"workers.upload is supported only in batch mode",
+		},
+		{
+			"stream in batch mode",
+			&DestinationConfig{Type: "postgres", Mode: batchMode, Workers: &WorkersConfig{Stream: 2}},
+			"workers.stream is supported only in stream mode",
+		},
+		{
+			"unsupported stream type",
+			&DestinationConfig{Type: "webhook", Mode: streamMode, Workers: &WorkersConfig{Stream: 2}},
+			"workers.stream isn't supported by webhook destination. Supported types: [postgres mssql redshift bigquery clickhouse snowflake]",
+		},
+		{
+			"stream ok",
+			&DestinationConfig{Type: "clickhouse", Mode: streamMode, Workers: &WorkersConfig{Stream: 8}},
+			"",
+		},
+		{
+			"upload ok",
+			&DestinationConfig{Type: "s3", Mode: batchMode, Workers: &WorkersConfig{Upload: 4}},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWorkers(tt.destination)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDestinationWorkers(t *testing.T) {
+	require.Equal(t, performance.Instance.StreamWorkers, streamWorkers("workers_test"))
+	require.Equal(t, defaultUploadWorkers, UploadWorkers("workers_test"))
+
+	setWorkers("workers_test", &WorkersConfig{Stream: 8, Upload: 4})
+	require.Equal(t, 8, streamWorkers("workers_test"))
+	require.Equal(t, 4, UploadWorkers("workers_test"))
+
+	setWorkers("workers_test", nil)
+	require.Equal(t, performance.Instance.StreamWorkers, streamWorkers("workers_test"))
+	require.Equal(t, defaultUploadWorkers, UploadWorkers("workers_test"))
+}
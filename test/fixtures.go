@@ -0,0 +1,36 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+//BenchEventsPath is a path (relative to package directory) of realistic events fixture which is used in benchmarks
+const BenchEventsPath = "../test_data/bench_events.log"
+
+//ReadObjects return json objects from file with one object per line
+func ReadObjects(tb testing.TB, path string) []map[string]interface{} {
+	payload, err := ioutil.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("Error reading %s: %v", path, err)
+	}
+
+	var objects []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(payload))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		object := map[string]interface{}{}
+		if err := json.Unmarshal(scanner.Bytes(), &object); err != nil {
+			tb.Fatalf("Error unmarshalling line from %s: %v", path, err)
+		}
+		objects = append(objects, object)
+	}
+	if err := scanner.Err(); err != nil {
+		tb.Fatalf("Error scanning %s: %v", path, err)
+	}
+
+	return objects
+}
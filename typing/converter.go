@@ -1,9 +1,12 @@
 package typing
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/ksensehq/eventnative/timestamp"
+	"math"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -36,6 +39,32 @@ var (
 
 		rule{from: STRING, to: TIMESTAMP}: stringToTimestamp,
 
+		rule{from: ARRAY_INT64, to: ARRAY_FLOAT64}:  arrayConverter(numberToFloat),
+		rule{from: ARRAY_INT64, to: ARRAY_STRING}:   arrayConverter(numberToString),
+		rule{from: ARRAY_FLOAT64, to: ARRAY_STRING}: arrayConverter(numberToString),
+
+		//arrays are written into string columns as JSON
+		rule{from: ARRAY_INT64, to: STRING}:   arrayToString,
+		rule{from: ARRAY_FLOAT64, to: STRING}: arrayToString,
+		rule{from: ARRAY_STRING, to: STRING}:  arrayToString,
+
+		//JSON columns values are JSON documents, scalars and arrays are written as JSON too
+		rule{from: STRING, to: JSON}:        stringToJSON,
+		rule{from: INT64, to: JSON}:         numberToString,
+		rule{from: FLOAT64, to: JSON}:       numberToString,
+		rule{from: ARRAY_INT64, to: JSON}:   arrayToString,
+		rule{from: ARRAY_FLOAT64, to: JSON}: arrayToString,
+		rule{from: ARRAY_STRING, to: JSON}:  arrayToString,
+		rule{from: JSON, to: STRING}:        jsonToString,
+
+		//decimal values are numbers (DB rounds them to column scale)
+		rule{from: INT64, to: DECIMAL}:   numberToFloat,
+		rule{from: FLOAT64, to: DECIMAL}: numberToFloat,
+		rule{from: STRING, to: DECIMAL}:  stringToDecimal,
+		rule{from: DECIMAL, to: FLOAT64}: numberToFloat,
+		rule{from: DECIMAL, to: STRING}:  numberToString,
+		rule{from: DECIMAL, to: JSON}:    numberToString,
+
 		// Future
 		/*rule{from: STRING, to: INT64}:     stringToInt,
 		rule{from: STRING, to: FLOAT64}:   stringToFloat,
@@ -86,10 +115,38 @@ func Convert(toType DataType, v interface{}) (interface{}, error) {
 	return f(v)
 }
 
+//GetCommonAncestorType return the lowest common type of t1 and t2 in the typecast tree
+//arrays common type is an array of elements common type. Arrays and scalars common type is STRING
+//JSON and other types common type is STRING
+//DECIMAL and numbers common type is DECIMAL, DECIMAL and other types common type is STRING
 func GetCommonAncestorType(t1, t2 DataType) DataType {
+	if t1 == JSON && t2 == JSON {
+		return JSON
+	}
+	if t1 == JSON || t2 == JSON {
+		return STRING
+	}
+	if t1 == DECIMAL || t2 == DECIMAL {
+		if isDecimalCompatible(t1) && isDecimalCompatible(t2) {
+			return DECIMAL
+		}
+		return STRING
+	}
+	if IsArray(t1) && IsArray(t2) {
+		return ArrayOf(lowestCommonAncestor(typecastTree, ElementType(t1), ElementType(t2)))
+	}
+	if IsArray(t1) || IsArray(t2) {
+		return STRING
+	}
+
 	return lowestCommonAncestor(typecastTree, t1, t2)
 }
 
+//return true if values of dt type can be kept in DECIMAL column
+func isDecimalCompatible(dt DataType) bool {
+	return dt == DECIMAL || dt == INT64 || dt == FLOAT64
+}
+
 func lowestCommonAncestor(root *typeNode, t1, t2 DataType) DataType {
 	// Start from the root node of the tree
 	node := root
@@ -157,6 +214,64 @@ func timestampToString(v interface{}) (interface{}, error) {
 	}
 }
 
+//arrayConverter return ConvertFunc which converts every array element with convertElement
+func arrayConverter(convertElement ConvertFunc) ConvertFunc {
+	return func(v interface{}) (interface{}, error) {
+		array, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Value: %v with type: %t isn't array", v, v)
+		}
+
+		converted := make([]interface{}, len(array))
+		for i, element := range array {
+			convertedElement, err := convertElement(element)
+			if err != nil {
+				return nil, err
+			}
+			converted[i] = convertedElement
+		}
+
+		return converted, nil
+	}
+}
+
+func arrayToString(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("Error arrayToString(): %v", err)
+	}
+
+	return string(b), nil
+}
+
+//return v if it is a JSON document or v as a JSON string otherwise (e.g. a plain string field is typed as JSON)
+func stringToJSON(v interface{}) (interface{}, error) {
+	str, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("Error stringToJSON(): Unknown value type: %t", v)
+	}
+	if json.Valid([]byte(str)) {
+		return str, nil
+	}
+
+	b, err := json.Marshal(str)
+	if err != nil {
+		return nil, fmt.Errorf("Error stringToJSON(): %v", err)
+	}
+
+	return string(b), nil
+}
+
+//JSON values are kept as is in string columns
+func jsonToString(v interface{}) (interface{}, error) {
+	str, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("Error jsonToString(): Unknown value type: %t", v)
+	}
+
+	return str, nil
+}
+
 func numberToFloat(v interface{}) (interface{}, error) {
 	switch v.(type) {
 	case int:
@@ -196,6 +311,20 @@ func stringToFloat(v interface{}) (interface{}, error) {
 	return floatValue, nil
 }
 
+//return float64 value of numeric string e.g. "19.99" -> 19.99
+func stringToDecimal(v interface{}) (interface{}, error) {
+	str, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("Error stringToDecimal(): Unknown value type: %t", v)
+	}
+	floatValue, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if err != nil || math.IsInf(floatValue, 0) || math.IsNaN(floatValue) {
+		return nil, fmt.Errorf("Error stringToDecimal() for value: %v: not a decimal number", v)
+	}
+
+	return floatValue, nil
+}
+
 func stringToTimestamp(v interface{}) (interface{}, error) {
 	t, err := time.Parse(timestamp.Layout, v.(string))
 	if err != nil {
@@ -64,6 +64,83 @@ func TestConvert(t *testing.T) {
 			float64(123),
 			"",
 		},
+		{
+			"array of ints -> array of floats",
+			[]interface{}{1, int64(2)},
+			ARRAY_FLOAT64,
+			[]interface{}{float64(1), float64(2)},
+			"",
+		},
+		{
+			"array of numbers -> array of strings",
+			[]interface{}{1, 2.5},
+			ARRAY_STRING,
+			[]interface{}{"1", "2.5"},
+			"",
+		},
+		{
+			"array -> string",
+			[]interface{}{"a", 1.5},
+			STRING,
+			`["a",1.5]`,
+			"",
+		},
+		{
+			"array of strings -> array of ints - error",
+			[]interface{}{"a"},
+			ARRAY_INT64,
+			nil,
+			"No rule for converting ARRAY(STRING) to ARRAY(INT64)",
+		},
+		{
+			"json document -> json",
+			`{"plan":"pro"}`,
+			JSON,
+			`{"plan":"pro"}`,
+			"",
+		},
+		{
+			"plain string -> json string",
+			"pro",
+			JSON,
+			`"pro"`,
+			"",
+		},
+		{
+			"array -> json",
+			[]interface{}{"a", 1.5},
+			JSON,
+			`["a",1.5]`,
+			"",
+		},
+		{
+			"timestamp -> json - error",
+			time.Date(2020, 07, 20, 10, 15, 23, 22, time.UTC),
+			JSON,
+			nil,
+			"No rule for converting TIMESTAMP to JSON",
+		},
+		{
+			"int -> decimal",
+			20.0,
+			DECIMAL,
+			20.0,
+			"",
+		},
+		{
+			"numeric string -> decimal",
+			" 19.99",
+			DECIMAL,
+			19.99,
+			"",
+		},
+		{
+			"not numeric string -> decimal - error",
+			"N/A",
+			DECIMAL,
+			nil,
+			"Error stringToDecimal() for value: N/A: not a decimal number",
+		},
 		/* Future
 		{
 				"string -> int ok",
@@ -186,6 +263,24 @@ func TestIsConvertible(t *testing.T) {
 			INT64,
 			false,
 		},
+		{
+			"array(int64)->array(float64)",
+			ARRAY_INT64,
+			ARRAY_FLOAT64,
+			true,
+		},
+		{
+			"array(float64)->string",
+			ARRAY_FLOAT64,
+			STRING,
+			true,
+		},
+		{
+			"string->array(string)",
+			STRING,
+			ARRAY_STRING,
+			false,
+		},
 		{
 			"string->int64",
 			STRING,
@@ -198,6 +293,24 @@ func TestIsConvertible(t *testing.T) {
 			FLOAT64,
 			false,
 		},
+		{
+			"float64->decimal",
+			FLOAT64,
+			DECIMAL,
+			true,
+		},
+		{
+			"decimal->float64",
+			DECIMAL,
+			FLOAT64,
+			true,
+		},
+		{
+			"decimal->int64",
+			DECIMAL,
+			INT64,
+			false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -262,6 +375,60 @@ func TestGetCommonAncestorType(t *testing.T) {
 			TIMESTAMP,
 			STRING,
 		},
+		{
+			"array(int64)+array(float64)=array(float64)",
+			ARRAY_INT64,
+			ARRAY_FLOAT64,
+			ARRAY_FLOAT64,
+		},
+		{
+			"array(int64)+array(string)=array(string)",
+			ARRAY_INT64,
+			ARRAY_STRING,
+			ARRAY_STRING,
+		},
+		{
+			"array(int64)+int64=string",
+			ARRAY_INT64,
+			INT64,
+			STRING,
+		},
+		{
+			"json+json=json",
+			JSON,
+			JSON,
+			JSON,
+		},
+		{
+			"json+timestamp=string",
+			JSON,
+			TIMESTAMP,
+			STRING,
+		},
+		{
+			"decimal+int64=decimal",
+			DECIMAL,
+			INT64,
+			DECIMAL,
+		},
+		{
+			"float64+decimal=decimal",
+			FLOAT64,
+			DECIMAL,
+			DECIMAL,
+		},
+		{
+			"decimal+json=string",
+			DECIMAL,
+			JSON,
+			STRING,
+		},
+		{
+			"decimal+timestamp=string",
+			DECIMAL,
+			TIMESTAMP,
+			STRING,
+		},
 	}
 
 	for _, tt := range tests {
@@ -270,3 +437,28 @@ func TestGetCommonAncestorType(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkConvert(b *testing.B) {
+	benchmarks := []struct {
+		name   string
+		toType DataType
+		value  interface{}
+	}{
+		{"string->timestamp", TIMESTAMP, "2020-10-01T10:00:00.288389Z"},
+		{"timestamp->string", STRING, time.Date(2020, 10, 1, 10, 0, 0, 288389000, time.UTC)},
+		{"float->string", STRING, 40.7809},
+		{"int->string", STRING, int64(1440)},
+		{"int->float", FLOAT64, int64(240)},
+		{"same type", STRING, "eventnative"},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Convert(bm.toType, bm.value); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -16,20 +16,47 @@ const (
 	FLOAT64
 	STRING
 	TIMESTAMP
+
+	//arrays of scalars (e.g. ClickHouse Array(T) columns). They aren't in the typecast tree (see GetCommonAncestorType)
+	ARRAY_INT64
+	ARRAY_FLOAT64
+	ARRAY_STRING
+
+	//JSON document kept as a single column (e.g. Postgres jsonb, Snowflake variant). Values are JSON strings.
+	//It isn't in the typecast tree (see GetCommonAncestorType)
+	JSON
+
+	//exact numeric with precision and scale (e.g. Postgres numeric(38,9)) which are kept in schema.Column.
+	//Values are numbers. It isn't in the typecast tree (see GetCommonAncestorType)
+	DECIMAL
 )
 
 var (
 	inputStringToType = map[string]DataType{
-		"string":    STRING,
-		"integer":   INT64,
-		"double":    FLOAT64,
-		"timestamp": TIMESTAMP,
+		"string":         STRING,
+		"integer":        INT64,
+		"double":         FLOAT64,
+		"timestamp":      TIMESTAMP,
+		"array(integer)": ARRAY_INT64,
+		"array(double)":  ARRAY_FLOAT64,
+		"array(string)":  ARRAY_STRING,
+		"json":           JSON,
+		"decimal":        DECIMAL,
+		//aliases of DataType names
+		"int64":   INT64,
+		"float64": FLOAT64,
+		"numeric": DECIMAL,
 	}
 	typeToInputString = map[DataType]string{
-		STRING:    "string",
-		INT64:     "integer",
-		FLOAT64:   "double",
-		TIMESTAMP: "timestamp",
+		STRING:        "string",
+		INT64:         "integer",
+		FLOAT64:       "double",
+		TIMESTAMP:     "timestamp",
+		ARRAY_INT64:   "array(integer)",
+		ARRAY_FLOAT64: "array(double)",
+		ARRAY_STRING:  "array(string)",
+		JSON:          "json",
+		DECIMAL:       "decimal",
 	}
 )
 
@@ -45,14 +72,60 @@ func (dt DataType) String() string {
 		return "FLOAT64"
 	case TIMESTAMP:
 		return "TIMESTAMP"
+	case ARRAY_INT64:
+		return "ARRAY(INT64)"
+	case ARRAY_FLOAT64:
+		return "ARRAY(FLOAT64)"
+	case ARRAY_STRING:
+		return "ARRAY(STRING)"
+	case JSON:
+		return "JSON"
+	case DECIMAL:
+		return "DECIMAL"
 	case UNKNOWN:
 		return "UNKNOWN"
 	}
 }
 
+//IsArray return true if dt is an array type
+func IsArray(dt DataType) bool {
+	return dt == ARRAY_INT64 || dt == ARRAY_FLOAT64 || dt == ARRAY_STRING
+}
+
+//ElementType return type of array elements or UNKNOWN if dt isn't an array type
+func ElementType(dt DataType) DataType {
+	switch dt {
+	case ARRAY_INT64:
+		return INT64
+	case ARRAY_FLOAT64:
+		return FLOAT64
+	case ARRAY_STRING:
+		return STRING
+	default:
+		return UNKNOWN
+	}
+}
+
+//ArrayOf return array type of elements with dt type
+//timestamps and unknown elements are kept in arrays of strings
+func ArrayOf(dt DataType) DataType {
+	switch dt {
+	case INT64:
+		return ARRAY_INT64
+	case FLOAT64:
+		return ARRAY_FLOAT64
+	default:
+		return ARRAY_STRING
+	}
+}
+
+//TypeFromString return DataType from input type name. Decimal precision and scale are ignored e.g. decimal(18,2) -> DECIMAL
 func TypeFromString(t string) (DataType, error) {
 	trimmed := strings.TrimSpace(t)
 	lowerTrimmed := strings.ToLower(trimmed)
+	if i := strings.Index(lowerTrimmed, "("); i > 0 && strings.HasSuffix(lowerTrimmed, ")") && inputStringToType[strings.TrimSpace(lowerTrimmed[:i])] == DECIMAL {
+		lowerTrimmed = strings.TrimSpace(lowerTrimmed[:i])
+	}
 	dataType, ok := inputStringToType[lowerTrimmed]
 	if !ok {
 		return UNKNOWN, fmt.Errorf("Unknown casting type: %s", t)
@@ -91,6 +164,21 @@ func TypeFromValue(v interface{}) (DataType, error) {
 		return INT64, nil
 	case time.Time:
 		return TIMESTAMP, nil
+	case []interface{}:
+		//empty arrays are arrays of strings
+		elementType := STRING
+		for i, element := range v.([]interface{}) {
+			t, err := TypeFromValue(element)
+			if err != nil {
+				return UNKNOWN, err
+			}
+			if i == 0 {
+				elementType = t
+			} else {
+				elementType = GetCommonAncestorType(elementType, t)
+			}
+		}
+		return ArrayOf(elementType), nil
 	default:
 		return UNKNOWN, fmt.Errorf("Unknown DataType for value: %v type: %t", v, v)
 	}
@@ -3,6 +3,7 @@ package typing
 import (
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestDataTypeValues(t *testing.T) {
@@ -11,6 +12,11 @@ func TestDataTypeValues(t *testing.T) {
 	require.Equal(t, DataType(2), FLOAT64)
 	require.Equal(t, DataType(3), STRING)
 	require.Equal(t, DataType(4), TIMESTAMP)
+	require.Equal(t, DataType(5), ARRAY_INT64)
+	require.Equal(t, DataType(6), ARRAY_FLOAT64)
+	require.Equal(t, DataType(7), ARRAY_STRING)
+	require.Equal(t, DataType(8), JSON)
+	require.Equal(t, DataType(9), DECIMAL)
 }
 
 func TestTypeFromString(t *testing.T) {
@@ -50,12 +56,48 @@ func TestTypeFromString(t *testing.T) {
 			FLOAT64,
 			"",
 		},
+		{
+			"Array of doubles ok",
+			"Array(Double)",
+			ARRAY_FLOAT64,
+			"",
+		},
 		{
 			"Timestamp ok",
 			"timestamp",
 			TIMESTAMP,
 			"",
 		},
+		{
+			"Int64 alias ok",
+			"int64",
+			INT64,
+			"",
+		},
+		{
+			"Float64 alias ok",
+			"float64",
+			FLOAT64,
+			"",
+		},
+		{
+			"Decimal with precision and scale ok",
+			"Decimal(18, 2)",
+			DECIMAL,
+			"",
+		},
+		{
+			"Numeric alias ok",
+			"numeric",
+			DECIMAL,
+			"",
+		},
+		{
+			"String with parameters",
+			"string(10)",
+			UNKNOWN,
+			"Unknown casting type: string(10)",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -198,6 +240,36 @@ func TestTypeFromValue(t *testing.T) {
 			INT64,
 			"",
 		},
+		{
+			"Empty array",
+			[]interface{}{},
+			ARRAY_STRING,
+			"",
+		},
+		{
+			"Array of ints",
+			[]interface{}{1.0, int64(2)},
+			ARRAY_INT64,
+			"",
+		},
+		{
+			"Array of numbers",
+			[]interface{}{1.0, 2.5},
+			ARRAY_FLOAT64,
+			"",
+		},
+		{
+			"Array of mixed values",
+			[]interface{}{1.0, "a"},
+			ARRAY_STRING,
+			"",
+		},
+		{
+			"Array with nil",
+			[]interface{}{"a", nil},
+			UNKNOWN,
+			"Unknown DataType for value: <nil> type: %!t(<nil>)",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -211,3 +283,14 @@ func TestTypeFromValue(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkTypeFromValue(b *testing.B) {
+	values := []interface{}{"eventnative", 40.7809, float64(1440), int64(-240), time.Date(2020, 10, 1, 10, 0, 0, 0, time.UTC)}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := TypeFromValue(values[i%len(values)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
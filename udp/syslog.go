@@ -0,0 +1,196 @@
+package udp
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/events"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	syslogEventType = "syslog"
+	//RFC 5424 NILVALUE
+	syslogNil = "-"
+	utf8BOM   = "\xef\xbb\xbf"
+)
+
+//RFC 3164 TAG: app name with optional [pid] and colon
+var bsdTagRegex = regexp.MustCompile(`^([^\s:\[\]]{1,48})(\[([^\]]*)\])?:\s?`)
+
+//parseSyslog return event of RFC 5424 or RFC 3164 (BSD) syslog message:
+//facility, severity, timestamp (as it is sent), hostname, app_name, proc_id, msg_id, structured_data and message
+//fields which aren't in the message are omitted
+func parseSyslog(message string) (events.Fact, error) {
+	message = strings.TrimRight(message, "\r\n\x00")
+	if !strings.HasPrefix(message, "<") {
+		return nil, errors.New("syslog message must start with <PRI>")
+	}
+	end := strings.IndexByte(message, '>')
+	if end < 2 || end > 4 {
+		return nil, errors.New("syslog message PRI is invalid")
+	}
+	pri, err := strconv.Atoi(message[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return nil, fmt.Errorf("syslog message PRI [%s] is invalid", message[1:end])
+	}
+
+	fact := events.Fact{"event_type": syslogEventType, "facility": pri / 8, "severity": pri % 8}
+	rest := message[end+1:]
+
+	//RFC 5424 message has VERSION right after PRI. Malformed one is kept as BSD message
+	if version, body, ok := cut(rest); ok && version != "" && isDigits(version) {
+		v, _ := strconv.Atoi(version)
+		parsed := events.Fact{"version": v}
+		if err := parseRFC5424(body, parsed); err == nil {
+			for k, v := range parsed {
+				fact[k] = v
+			}
+			return fact, nil
+		}
+	}
+
+	parseRFC3164(rest, fact)
+	return fact, nil
+}
+
+//TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]
+func parseRFC5424(body string, fact events.Fact) error {
+	for _, key := range []string{"timestamp", "hostname", "app_name", "proc_id", "msg_id"} {
+		value, rest, ok := cut(body)
+		if !ok || value == "" {
+			return fmt.Errorf("syslog message doesn't have %s", key)
+		}
+		if value != syslogNil {
+			fact[key] = value
+		}
+		body = rest
+	}
+
+	if body == "" {
+		return errors.New("syslog message doesn't have structured data")
+	}
+	if strings.HasPrefix(body, syslogNil) {
+		body = body[len(syslogNil):]
+	} else {
+		structuredData, rest, err := parseStructuredData(body)
+		if err != nil {
+			return err
+		}
+		fact["structured_data"] = structuredData
+		body = rest
+	}
+
+	if body != "" {
+		if body[0] != ' ' {
+			return errors.New("syslog message structured data is invalid")
+		}
+		if msg := strings.TrimPrefix(body[1:], utf8BOM); msg != "" {
+			fact["message"] = msg
+		}
+	}
+
+	return nil
+}
+
+//[id param="value" ...][id ...] into {id: {param: value}}
+//return the rest of the body
+func parseStructuredData(body string) (map[string]interface{}, string, error) {
+	invalid := errors.New("syslog message structured data is invalid")
+	structuredData := map[string]interface{}{}
+	for strings.HasPrefix(body, "[") {
+		idEnd := strings.IndexAny(body, " ]")
+		if idEnd < 2 {
+			return nil, "", invalid
+		}
+		params := map[string]interface{}{}
+		structuredData[body[1:idEnd]] = params
+		body = body[idEnd:]
+
+		for strings.HasPrefix(body, " ") {
+			eq := strings.Index(body, "=\"")
+			if eq < 2 {
+				return nil, "", invalid
+			}
+			name := body[1:eq]
+			body = body[eq+2:]
+
+			//PARAM-VALUE escapes: \" \\ \]
+			var value strings.Builder
+			closed := false
+			for i := 0; i < len(body); i++ {
+				if body[i] == '\\' && i+1 < len(body) && strings.IndexByte(`"\]`, body[i+1]) >= 0 {
+					i++
+					value.WriteByte(body[i])
+					continue
+				}
+				if body[i] == '"' {
+					body = body[i+1:]
+					closed = true
+					break
+				}
+				value.WriteByte(body[i])
+			}
+			if !closed {
+				return nil, "", invalid
+			}
+			params[name] = value.String()
+		}
+
+		if !strings.HasPrefix(body, "]") {
+			return nil, "", invalid
+		}
+		body = body[1:]
+	}
+
+	return structuredData, body, nil
+}
+
+//BSD syslog: [Mmm dd hh:mm:ss HOSTNAME ][TAG: ]MSG
+//devices often send partial headers so all parts are optional except the message
+func parseRFC3164(body string, fact events.Fact) {
+	if len(body) > len(time.Stamp) && body[len(time.Stamp)] == ' ' {
+		if _, err := time.Parse(time.Stamp, body[:len(time.Stamp)]); err == nil {
+			fact["timestamp"] = body[:len(time.Stamp)]
+			hostname, rest, _ := cut(body[len(time.Stamp)+1:])
+			if hostname != "" {
+				fact["hostname"] = hostname
+			}
+			body = rest
+		}
+	}
+
+	if match := bsdTagRegex.FindStringSubmatch(body); match != nil {
+		fact["app_name"] = match[1]
+		if match[3] != "" {
+			fact["proc_id"] = match[3]
+		}
+		body = body[len(match[0]):]
+	}
+
+	if body != "" {
+		fact["message"] = body
+	}
+}
+
+//return value before the first space and the rest after it. ok is false if there isn't a space
+func cut(s string) (string, string, bool) {
+	i := strings.IndexByte(s, ' ')
+	if i < 0 {
+		return s, "", false
+	}
+
+	return s[:i], s[i+1:], true
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
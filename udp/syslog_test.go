@@ -0,0 +1,78 @@
+package udp
+
+import (
+	"github.com/ksensehq/eventnative/events"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseSyslog(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      events.Fact
+		expectedError string
+	}{
+		{
+			"RFC 5424 with structured data",
+			`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="App\]lication"][meta seq="1"] ` + utf8BOM + "An application event\n",
+			events.Fact{"event_type": "syslog", "facility": 20, "severity": 5, "version": 1, "timestamp": "2003-10-11T22:14:15.003Z",
+				"hostname": "mymachine.example.com", "app_name": "evntslog", "msg_id": "ID47",
+				"structured_data": map[string]interface{}{
+					"exampleSDID@32473": map[string]interface{}{"iut": "3", "eventSource": "App]lication"},
+					"meta":              map[string]interface{}{"seq": "1"},
+				},
+				"message": "An application event"},
+			"",
+		},
+		{
+			"RFC 5424 without structured data and message",
+			"<34>1 - - su 123 - -",
+			events.Fact{"event_type": "syslog", "facility": 4, "severity": 2, "version": 1, "app_name": "su", "proc_id": "123"},
+			"",
+		},
+		{
+			"RFC 3164",
+			"<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick on /dev/pts/8",
+			events.Fact{"event_type": "syslog", "facility": 4, "severity": 2, "timestamp": "Oct 11 22:14:15", "hostname": "mymachine",
+				"app_name": "su", "proc_id": "230", "message": "'su root' failed for lonvick on /dev/pts/8"},
+			"",
+		},
+		{
+			"RFC 3164 without header",
+			"<13>temperature is 21.5",
+			events.Fact{"event_type": "syslog", "facility": 1, "severity": 5, "message": "temperature is 21.5"},
+			"",
+		},
+		{
+			"malformed RFC 5424 is kept as RFC 3164",
+			"<14>1 2003-10-11T22:14:15.003Z host [broken",
+			events.Fact{"event_type": "syslog", "facility": 1, "severity": 6, "message": "1 2003-10-11T22:14:15.003Z host [broken"},
+			"",
+		},
+		{
+			"without PRI",
+			"just a message",
+			nil,
+			"syslog message must start with <PRI>",
+		},
+		{
+			"invalid PRI",
+			"<192>1 - - - - - -",
+			nil,
+			"syslog message PRI [192] is invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := parseSyslog(tt.input)
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,384 @@
+package udp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ksensehq/eventnative/appstatus"
+	"github.com/ksensehq/eventnative/counters"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/memlimit"
+	"github.com/ksensehq/eventnative/routing"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	JSONFormat   = "json"
+	SyslogFormat = "syslog"
+
+	//the largest UDP payload
+	maxDatagramSize = 65535
+	//listener queue name is udp-<listener name>
+	queuePrefix = "udp-"
+
+	eventnKey   = "eventn_ctx"
+	sourceIPKey = "source_ip"
+	srcKey      = "src"
+	srcValue    = "udp"
+)
+
+//Instance is nil if there aren't UDP listeners
+var Instance *Listeners
+
+//Config dto for deserialized server.udp config
+type Config struct {
+	Listeners []*ListenerConfig `mapstructure:"listeners"`
+}
+
+//ListenerConfig dto for deserialized server.udp.listeners item
+//address: host:port to listen on (e.g. :5140)
+//token: events of the listener are consumed by destinations of the token as if they were sent via HTTP API with it
+//format: json (one or several JSON objects in a datagram) or syslog (RFC 5424 or RFC 3164 message in a datagram). Default: json
+type ListenerConfig struct {
+	Name    string `mapstructure:"name"`
+	Address string `mapstructure:"address"`
+	Token   string `mapstructure:"token"`
+	Format  string `mapstructure:"format"`
+}
+
+//Validate required fields in ListenerConfig and set default values
+func (lc *ListenerConfig) Validate() error {
+	if lc.Name == "" {
+		return errors.New("server.udp listener name is required parameter")
+	}
+	if lc.Address == "" {
+		return fmt.Errorf("server.udp listener [%s] address is required parameter", lc.Name)
+	}
+	if lc.Token == "" {
+		return fmt.Errorf("server.udp listener [%s] token is required parameter", lc.Name)
+	}
+	if lc.Format == "" {
+		lc.Format = JSONFormat
+	}
+	if lc.Format != JSONFormat && lc.Format != SyslogFormat {
+		return fmt.Errorf("server.udp listener [%s] format [%s] is unknown. Supported: %s, %s", lc.Name, lc.Format, JSONFormat, SyslogFormat)
+	}
+
+	return nil
+}
+
+//Validate listeners: names must be unique and tokens must be authorized (if server has authorized tokens)
+func (c *Config) Validate(authorizedTokens map[string]bool) error {
+	names := map[string]bool{}
+	for _, listener := range c.Listeners {
+		if err := listener.Validate(); err != nil {
+			return err
+		}
+		if names[listener.Name] {
+			return fmt.Errorf("server.udp listener [%s] is configured more than once", listener.Name)
+		}
+		names[listener.Name] = true
+		if len(authorizedTokens) > 0 && !authorizedTokens[listener.Token] {
+			return fmt.Errorf("server.udp listener [%s] token isn't an authorized token", listener.Name)
+		}
+	}
+
+	return nil
+}
+
+//Listeners is a set of started UDP listeners
+type Listeners struct {
+	listeners []*Listener
+}
+
+//Init validate config and create global Listeners instance with started listeners
+//intake: validation and enrichment of events (checks are the same as HTTP API ones, see NewPreprocessor)
+//newQueue return persistent queue by name: events are buffered there between receiving and consuming
+func Init(config *Config, authorizedTokens map[string]bool, consumers events.TokenizedConsumers, intake *events.Intake, eventsCache *events.Cache,
+	newQueue func(name string) (*events.PersistentQueue, error)) error {
+	if config == nil || len(config.Listeners) == 0 {
+		return nil
+	}
+
+	if err := config.Validate(authorizedTokens); err != nil {
+		return err
+	}
+
+	instance := &Listeners{}
+	for _, listenerConfig := range config.Listeners {
+		queue, err := newQueue(queuePrefix + listenerConfig.Name)
+		if err != nil {
+			instance.Close()
+			return err
+		}
+
+		listener, err := NewListener(listenerConfig, queue, consumers, intake, eventsCache)
+		if err != nil {
+			queue.Close()
+			instance.Close()
+			return err
+		}
+
+		log.Printf("UDP listener [%s] (%s) has been started on %s", listenerConfig.Name, listenerConfig.Format, listener.Addr())
+		instance.listeners = append(instance.listeners, listener)
+	}
+
+	Instance = instance
+	return nil
+}
+
+//Close all listeners
+func (l *Listeners) Close() error {
+	for _, listener := range l.listeners {
+		if err := listener.Close(); err != nil {
+			log.Printf("Error closing UDP listener [%s]: %v", listener.name, err)
+		}
+	}
+
+	return nil
+}
+
+//Listener receives events from edge devices in UDP datagrams (without acknowledgements and retries).
+//Received events are checked and enriched with events.Intake as events of HTTP API (datagrams are dropped while memory
+//limit load shedding is turned on), are put into the persistent queue right away and are consumed from it
+//in the same way as events of HTTP API: by the listener token destinations with routing
+type Listener struct {
+	name        string
+	token       string
+	format      string
+	conn        net.PacketConn
+	queue       *events.PersistentQueue
+	consumers   events.TokenizedConsumers
+	intake      *events.Intake
+	eventsCache *events.Cache
+
+	closed int32
+	wg     sync.WaitGroup
+}
+
+//NewListener return Listener with started receiving and consuming goroutines
+//eventsCache is optional: last events are kept for admin API
+func NewListener(config *ListenerConfig, queue *events.PersistentQueue, consumers events.TokenizedConsumers, intake *events.Intake,
+	eventsCache *events.Cache) (*Listener, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenPacket("udp", config.Address)
+	if err != nil {
+		return nil, fmt.Errorf("Error starting server.udp listener [%s] on %s: %v", config.Name, config.Address, err)
+	}
+
+	l := &Listener{
+		name:        config.Name,
+		token:       config.Token,
+		format:      config.Format,
+		conn:        conn,
+		queue:       queue,
+		consumers:   consumers,
+		intake:      intake,
+		eventsCache: eventsCache,
+	}
+
+	l.wg.Add(2)
+	go l.receive()
+	go l.consume()
+
+	return l, nil
+}
+
+//Addr return address which the listener is listening on
+func (l *Listener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+//parse datagrams and put events into the queue
+func (l *Listener) receive() {
+	defer l.wg.Done()
+
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := l.conn.ReadFrom(buf)
+		if err != nil {
+			if atomic.LoadInt32(&l.closed) == 1 {
+				return
+			}
+			log.Printf("Error reading datagram of UDP listener [%s]: %v", l.name, err)
+			continue
+		}
+
+		//there is no way to ask the sender to retry later
+		if memlimit.Shedding() {
+			counters.ShedRequests(1)
+			continue
+		}
+
+		facts, sizes, err := parse(l.format, buf[:n])
+		if err != nil {
+			counters.InvalidEvents(l.token, 1)
+			log.Printf("Error parsing datagram of UDP listener [%s] from %s: %v", l.name, addr, err)
+			continue
+		}
+
+		receivedAt := time.Now().UTC()
+		request := senderRequest(addr)
+		for i, fact := range facts {
+			processed, pass, err := l.intake.Accept(l.token, fact, sizes[i], request, receivedAt)
+			if err != nil {
+				log.Printf("Event of UDP listener [%s] from %s is skipped: %v", l.name, addr, err)
+				continue
+			}
+			if !pass {
+				continue
+			}
+
+			if err := l.queue.Enqueue(processed); err != nil {
+				log.Printf("Error putting event of UDP listener [%s] into the queue: %v", l.name, err)
+				continue
+			}
+			counters.AcceptedEvents(l.token, 1)
+		}
+	}
+}
+
+//return request of datagram sender for events.Preprocessor: the sender ip is X-Real-IP header and remote address
+func senderRequest(addr net.Addr) *http.Request {
+	request := &http.Request{RemoteAddr: addr.String(), Header: http.Header{}}
+	if udpAddr, ok := addr.(*net.UDPAddr); ok {
+		request.Header.Set("X-Real-IP", udpAddr.IP.String())
+	}
+
+	return request
+}
+
+//preprocessor put sender ip into eventn_ctx.source_ip and src field and preprocess events as client (c2s) ones
+type preprocessor struct {
+	c2s events.Preprocessor
+}
+
+//NewPreprocessor return events.Preprocessor of UDP listeners events: geo is resolved from the sender ip
+func NewPreprocessor() events.Preprocessor {
+	return &preprocessor{c2s: events.NewC2SPreprocessor()}
+}
+
+//Preprocess put sender ip (X-Real-IP header of senderRequest) into eventn_ctx.source_ip and src field
+//and preprocess fact with C2SPreprocessor
+func (p *preprocessor) Preprocess(fact events.Fact, r *http.Request) (events.Fact, error) {
+	if fact == nil {
+		return nil, errors.New("Input fact can't be nil")
+	}
+
+	eventCtx, ok := fact[eventnKey].(map[string]interface{})
+	if !ok {
+		eventCtx = map[string]interface{}{}
+		fact[eventnKey] = eventCtx
+	}
+	if ip := r.Header.Get("X-Real-IP"); ip != "" {
+		eventCtx[sourceIPKey] = ip
+	}
+	fact[srcKey] = srcValue
+
+	return p.c2s.Preprocess(fact, r)
+}
+
+//pass queued events to the token consumers
+func (l *Listener) consume() {
+	defer l.wg.Done()
+
+	for {
+		if appstatus.Instance.Idle {
+			return
+		}
+
+		fact, err := l.queue.DequeueBlock()
+		if err != nil {
+			if err == events.ErrQueueClosed {
+				return
+			}
+			log.Printf("Error reading event from UDP listener [%s] queue: %v", l.name, err)
+			continue
+		}
+
+		if l.eventsCache != nil {
+			l.eventsCache.Put(l.token, fact)
+		}
+
+		//events of the drop route aren't written into event log files and aren't consumed by stream destinations
+		if routing.Drop(fact) {
+			continue
+		}
+		routing.CountArms(fact)
+
+		consumers := l.consumers.Consumers(l.token)
+		if len(consumers) == 0 {
+			log.Printf("UDP listener [%s] token doesn't have destinations. Event is skipped", l.name)
+			continue
+		}
+		for _, consumer := range consumers {
+			consumer.Consume(fact)
+		}
+	}
+}
+
+//Close stop receiving datagrams and close the queue: events which haven't been consumed are kept there
+func (l *Listener) Close() error {
+	if !atomic.CompareAndSwapInt32(&l.closed, 0, 1) {
+		return nil
+	}
+
+	err := l.conn.Close()
+	if qErr := l.queue.Close(); qErr != nil && err == nil {
+		err = qErr
+	}
+	l.wg.Wait()
+
+	return err
+}
+
+//return events of the datagram and their raw sizes (large events policy is applied to every event separately)
+func parse(format string, datagram []byte) ([]events.Fact, []int, error) {
+	if format == SyslogFormat {
+		fact, err := parseSyslog(string(datagram))
+		if err != nil {
+			return nil, nil, err
+		}
+		return []events.Fact{fact}, []int{len(datagram)}, nil
+	}
+
+	return parseJSON(datagram)
+}
+
+//return objects of the datagram: one object or several whitespace (e.g. new line) separated objects
+func parseJSON(datagram []byte) ([]events.Fact, []int, error) {
+	decoder := json.NewDecoder(bytes.NewReader(datagram))
+	var facts []events.Fact
+	var sizes []int
+	var offset int64
+	for {
+		fact := events.Fact{}
+		if err := decoder.Decode(&fact); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, fmt.Errorf("datagram must contain JSON objects: %v", err)
+		}
+		if fact == nil {
+			return nil, nil, errors.New("datagram must contain JSON objects: null isn't an object")
+		}
+		facts = append(facts, fact)
+		sizes = append(sizes, int(decoder.InputOffset()-offset))
+		offset = decoder.InputOffset()
+	}
+	if len(facts) == 0 {
+		return nil, nil, errors.New("datagram doesn't contain events")
+	}
+
+	return facts, sizes, nil
+}
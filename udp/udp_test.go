@@ -0,0 +1,231 @@
+package udp
+
+import (
+	"errors"
+	"github.com/ksensehq/eventnative/events"
+	"github.com/ksensehq/eventnative/timestamp"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+)
+
+//in memory QueueBackend
+type memoryQueue struct {
+	facts  chan []byte
+	closed chan struct{}
+}
+
+func newMemoryQueue() *memoryQueue {
+	return &memoryQueue{facts: make(chan []byte, 100), closed: make(chan struct{})}
+}
+
+func (mq *memoryQueue) Enqueue(factBytes []byte) error {
+	mq.facts <- factBytes
+	return nil
+}
+
+func (mq *memoryQueue) DequeueBlock() ([]byte, events.AckFunc, error) {
+	select {
+	case b := <-mq.facts:
+		return b, events.NoAck, nil
+	case <-mq.closed:
+		return nil, nil, errors.New("closed")
+	}
+}
+
+func (mq *memoryQueue) Size() int {
+	return len(mq.facts)
+}
+
+func (mq *memoryQueue) Close() error {
+	close(mq.closed)
+	return nil
+}
+
+type consumerMock struct {
+	mutex sync.Mutex
+	facts []events.Fact
+}
+
+func (cm *consumerMock) Consume(fact events.Fact) {
+	cm.mutex.Lock()
+	cm.facts = append(cm.facts, fact)
+	cm.mutex.Unlock()
+}
+
+func (cm *consumerMock) Close() error {
+	return nil
+}
+
+func (cm *consumerMock) consumed() []events.Fact {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	return append([]events.Fact{}, cm.facts...)
+}
+
+//C2SPreprocessor mock: geo and user agent aren't resolved
+type c2sPreprocessorMock struct{}
+
+func (c2spm *c2sPreprocessorMock) Preprocess(fact events.Fact, r *http.Request) (events.Fact, error) {
+	return fact, nil
+}
+
+func newTestIntake(schemas *events.TokenSchemas) *events.Intake {
+	return events.NewIntake(&preprocessor{c2s: &c2sPreprocessorMock{}}, nil, nil, nil, schemas)
+}
+
+//send datagrams to the listener and return consumed events after count events have been consumed
+func sendDatagrams(t *testing.T, listener *Listener, consumer *consumerMock, datagrams []string, count int) []events.Fact {
+	conn, err := net.Dial("udp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	for _, datagram := range datagrams {
+		_, err := conn.Write([]byte(datagram))
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool { return len(consumer.consumed()) == count }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	consumed := consumer.consumed()
+	require.Len(t, consumed, count)
+
+	return consumed
+}
+
+func TestListener(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		datagrams []string
+		expected  []events.Fact
+	}{
+		{
+			"json",
+			JSONFormat,
+			[]string{`{"event_type":"reading","temperature":21.5}`, `not json`, "{\"event_type\":\"boot\"}\n{\"event_type\":\"reading\",\"eventn_ctx\":{\"event_id\":\"1\"}}\n"},
+			[]events.Fact{
+				{"event_type": "reading", "temperature": 21.5},
+				{"event_type": "boot"},
+				{"event_type": "reading", "eventn_ctx": map[string]interface{}{"event_id": "1"}},
+			},
+		},
+		{
+			"syslog",
+			SyslogFormat,
+			[]string{"<13>temperature is 21.5\n", "no PRI"},
+			[]events.Fact{
+				{"event_type": "syslog", "facility": float64(1), "severity": float64(5), "message": "temperature is 21.5"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer := &consumerMock{}
+			listener, err := NewListener(&ListenerConfig{Name: tt.name, Address: "127.0.0.1:0", Token: "token1", Format: tt.format},
+				events.NewQueue(tt.name, newMemoryQueue()), events.ConsumersByToken{"token1": {consumer}}, newTestIntake(nil), nil)
+			require.NoError(t, err)
+			defer listener.Close()
+
+			consumed := sendDatagrams(t, listener, consumer, tt.datagrams, len(tt.expected))
+			for i, fact := range consumed {
+				require.Equal(t, "token1", fact["api_key"])
+				require.Equal(t, "udp", fact[srcKey])
+				_, err := time.Parse(timestamp.Layout, fact[timestamp.Key].(string))
+				require.NoError(t, err)
+				eventCtx := fact[eventnKey].(map[string]interface{})
+				require.Equal(t, "127.0.0.1", eventCtx[sourceIPKey])
+
+				delete(fact, "api_key")
+				delete(fact, srcKey)
+				delete(fact, timestamp.Key)
+				delete(eventCtx, sourceIPKey)
+				if len(eventCtx) == 0 {
+					delete(fact, eventnKey)
+				}
+				require.Equal(t, tt.expected[i], fact)
+			}
+		})
+	}
+}
+
+func TestListenerJSONSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "udp_json_schemas")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	schemaPath := path.Join(dir, "reading.json")
+	require.NoError(t, ioutil.WriteFile(schemaPath, []byte(`{"type": "object", "required": ["event_type"]}`), 0644))
+	schemas, err := events.NewTokenSchemas([]*events.JSONSchemaConfig{{APIKeys: []string{"token1"}, Schema: schemaPath}})
+	require.NoError(t, err)
+
+	consumer := &consumerMock{}
+	listener, err := NewListener(&ListenerConfig{Name: "sensors", Address: "127.0.0.1:0", Token: "token1"},
+		events.NewQueue("sensors", newMemoryQueue()), events.ConsumersByToken{"token1": {consumer}}, newTestIntake(schemas), nil)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	//events are validated before preprocessing (eventn_ctx.source_ip doesn't make invalid event valid) as HTTP API ones
+	consumed := sendDatagrams(t, listener, consumer, []string{`{"temperature":21.5}`, "{\"temperature\":22}\n{\"event_type\":\"reading\",\"temperature\":22}"}, 1)
+	require.Equal(t, "reading", consumed[0]["event_type"])
+	require.Equal(t, 22.0, consumed[0]["temperature"])
+}
+
+func TestParseJSONSizes(t *testing.T) {
+	facts, sizes, err := parseJSON([]byte("{\"event_type\":\"boot\"}\n{\"event_type\":\"reading\"}\n"))
+	require.NoError(t, err)
+	require.Len(t, facts, 2)
+	//separating whitespace is counted in the next object size
+	require.Equal(t, []int{21, 25}, sizes)
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		listeners     []*ListenerConfig
+		expectedError string
+	}{
+		{
+			"ok",
+			[]*ListenerConfig{{Name: "sensors", Address: ":5140", Token: "token1"}, {Name: "routers", Address: ":5514", Token: "token1", Format: "syslog"}},
+			"",
+		},
+		{
+			"without address",
+			[]*ListenerConfig{{Name: "sensors", Token: "token1"}},
+			"server.udp listener [sensors] address is required parameter",
+		},
+		{
+			"unknown format",
+			[]*ListenerConfig{{Name: "sensors", Address: ":5140", Token: "token1", Format: "csv"}},
+			"server.udp listener [sensors] format [csv] is unknown. Supported: json, syslog",
+		},
+		{
+			"duplicate name",
+			[]*ListenerConfig{{Name: "sensors", Address: ":5140", Token: "token1"}, {Name: "sensors", Address: ":5141", Token: "token1"}},
+			"server.udp listener [sensors] is configured more than once",
+		},
+		{
+			"unauthorized token",
+			[]*ListenerConfig{{Name: "sensors", Address: ":5140", Token: "token2"}},
+			"server.udp listener [sensors] token isn't an authorized token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Config{Listeners: tt.listeners}).Validate(map[string]bool{"token1": true})
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, JSONFormat, tt.listeners[0].Format)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package watermarks
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+var instance = newWatermarks()
+
+//Watermark is the max event time (_timestamp or its destination name) of events which have been committed into destination table
+//since the server start: downstream schedulers can wait until data through some time has landed
+//note: events which are older than the watermark can still arrive later (e.g. from mobile clients with offline queues)
+type Watermark struct {
+	Destination string    `json:"destination"`
+	Table       string    `json:"table"`
+	EventTime   time.Time `json:"event_time"`
+	CommittedAt time.Time `json:"committed_at"`
+}
+
+type watermarks struct {
+	mutex sync.RWMutex
+	//destination -> table -> watermark
+	destinations map[string]map[string]*Watermark
+}
+
+func newWatermarks() *watermarks {
+	return &watermarks{destinations: map[string]map[string]*Watermark{}}
+}
+
+//Commit move the watermark of the destination table to eventTime if it is after the current one
+//zero eventTime (event doesn't have timestamp) is ignored
+func Commit(destination, table string, eventTime time.Time) {
+	if eventTime.IsZero() {
+		return
+	}
+
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+
+	tables, ok := instance.destinations[destination]
+	if !ok {
+		tables = map[string]*Watermark{}
+		instance.destinations[destination] = tables
+	}
+
+	eventTime = eventTime.UTC()
+	watermark, ok := tables[table]
+	if !ok {
+		watermark = &Watermark{Destination: destination, Table: table, EventTime: eventTime}
+		tables[table] = watermark
+	} else if eventTime.After(watermark.EventTime) {
+		watermark.EventTime = eventTime
+	}
+	watermark.CommittedAt = time.Now().UTC()
+}
+
+//List return copies of watermarks sorted by destination and table and filtered by them (if not empty)
+func List(destination, table string) []*Watermark {
+	instance.mutex.RLock()
+	defer instance.mutex.RUnlock()
+
+	result := []*Watermark{}
+	for name, tables := range instance.destinations {
+		if destination != "" && name != destination {
+			continue
+		}
+		for tableName, watermark := range tables {
+			if table != "" && tableName != table {
+				continue
+			}
+			copied := *watermark
+			result = append(result, &copied)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Destination != result[j].Destination {
+			return result[i].Destination < result[j].Destination
+		}
+		return result[i].Table < result[j].Table
+	})
+
+	return result
+}
+
+//Landed return true if there is at least one watermark and all watermarks are not before through time
+func Landed(list []*Watermark, through time.Time) bool {
+	if len(list) == 0 {
+		return false
+	}
+
+	for _, watermark := range list {
+		if watermark.EventTime.Before(through) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,32 @@
+package watermarks
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestWatermarks(t *testing.T) {
+	instance = newWatermarks()
+
+	t1 := time.Date(2020, 12, 1, 13, 0, 0, 0, time.UTC)
+	t2 := time.Date(2020, 12, 1, 14, 0, 0, 0, time.UTC)
+	Commit("pg", "events", t2)
+	Commit("pg", "events", t1)
+	Commit("pg", "users", t1)
+	Commit("ch", "events", t2)
+	Commit("ch", "events", time.Time{})
+	Commit("ch", "users", time.Time{})
+
+	list := List("", "")
+	require.Len(t, list, 3)
+	require.Equal(t, []string{"ch.events", "pg.events", "pg.users"}, []string{list[0].Destination + "." + list[0].Table,
+		list[1].Destination + "." + list[1].Table, list[2].Destination + "." + list[2].Table})
+	require.Equal(t, t2, list[1].EventTime)
+	require.False(t, list[1].CommittedAt.IsZero())
+
+	require.True(t, Landed(List("", "events"), t2))
+	require.False(t, Landed(List("pg", ""), t2))
+	require.True(t, Landed(List("pg", ""), t1))
+	require.False(t, Landed(List("unknown", ""), t1))
+}
@@ -109,6 +109,7 @@ export const initTracker = (opts?: TrackerOptions, plugins: TrackerPlugin[] = []
     const url = `${trackingHost}/api/v1/event?token=${apiKey}`;
     req.open('POST', url);
     req.setRequestHeader("Content-Type", "application/json");
+    json.sent_at = reformatDate(new Date().toISOString());
     req.send(JSON.stringify(json))
     logger: logger.debug('sending json', json);
   }
@@ -45,6 +45,7 @@ export type Event = {
   src: string
   event_type: string
   eventn_ctx: EventCtx
+  sent_at?: string
 }
 
 export type EventnEvent = Event & {
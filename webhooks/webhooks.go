@@ -0,0 +1,275 @@
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//Lifecycle event types
+const (
+	DestinationCreated = "destination_created"
+	DestinationFailed  = "destination_failed"
+	ColumnsAdded       = "columns_added"
+	FileLoaded         = "file_loaded"
+	QueueThreshold     = "queue_threshold"
+	DDLRequired        = "ddl_required"
+
+	defaultTimeout  = 10 * time.Second
+	requestRetries  = 3
+	retryWait       = time.Second
+	eventsQueueSize = 1000
+)
+
+var (
+	eventTypes = map[string]bool{
+		DestinationCreated: true,
+		DestinationFailed:  true,
+		ColumnsAdded:       true,
+		FileLoaded:         true,
+		QueueThreshold:     true,
+		DDLRequired:        true,
+	}
+
+	//Instance is nil if webhooks aren't configured
+	Instance *Dispatcher
+)
+
+//Config dto for deserialized webhooks config
+type Config struct {
+	QueueThreshold int          `mapstructure:"queue_threshold"`
+	Hooks          []HookConfig `mapstructure:"hooks"`
+}
+
+//HookConfig dto for deserialized one webhook config
+//events: lifecycle event types which are sent to the url. All events are sent if empty
+type HookConfig struct {
+	URL     string            `mapstructure:"url"`
+	Events  []string          `mapstructure:"events"`
+	Headers map[string]string `mapstructure:"headers"`
+	Timeout time.Duration     `mapstructure:"timeout"`
+}
+
+//Validate required fields in Config and set default values
+func (c *Config) Validate() error {
+	if c.QueueThreshold < 0 {
+		return errors.New("webhooks queue_threshold can't be negative")
+	}
+	for i := range c.Hooks {
+		hook := &c.Hooks[i]
+		if hook.URL == "" {
+			return errors.New("webhook url is required parameter")
+		}
+		for _, eventType := range hook.Events {
+			if !eventTypes[eventType] {
+				return fmt.Errorf("Unknown webhook event type: %s. Available types: [%s, %s, %s, %s, %s, %s]", eventType,
+					DestinationCreated, DestinationFailed, ColumnsAdded, FileLoaded, QueueThreshold, DDLRequired)
+			}
+		}
+		if hook.Timeout <= 0 {
+			hook.Timeout = defaultTimeout
+		}
+	}
+
+	return nil
+}
+
+//Event is a lifecycle event webhook request body
+type Event struct {
+	Type      string                 `json:"event"`
+	Server    string                 `json:"server"`
+	Timestamp time.Time              `json:"timestamp"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+//Dispatcher sends lifecycle events to configured webhooks asynchronously (events are dropped if queue is full)
+type Dispatcher struct {
+	serverName     string
+	queueThreshold int
+	hooks          []*hook
+	events         chan *Event
+
+	closed    chan bool
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+type hook struct {
+	url     string
+	events  map[string]bool
+	headers map[string]string
+	client  *http.Client
+}
+
+//Init validate config and create global Dispatcher instance with started sending goroutine
+func Init(config *Config, serverName string) error {
+	if config == nil || len(config.Hooks) == 0 {
+		return nil
+	}
+
+	dispatcher, err := NewDispatcher(config, serverName)
+	if err != nil {
+		return err
+	}
+
+	Instance = dispatcher
+	return nil
+}
+
+//NewDispatcher return Dispatcher and start sending goroutine
+func NewDispatcher(config *Config, serverName string) (*Dispatcher, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	var hooks []*hook
+	for _, hookConfig := range config.Hooks {
+		events := map[string]bool{}
+		for _, eventType := range hookConfig.Events {
+			events[eventType] = true
+		}
+		hooks = append(hooks, &hook{
+			url:     hookConfig.URL,
+			events:  events,
+			headers: hookConfig.Headers,
+			client:  &http.Client{Timeout: hookConfig.Timeout},
+		})
+	}
+
+	d := &Dispatcher{
+		serverName:     serverName,
+		queueThreshold: config.QueueThreshold,
+		hooks:          hooks,
+		events:         make(chan *Event, eventsQueueSize),
+		closed:         make(chan bool),
+	}
+	d.start()
+
+	return d, nil
+}
+
+//Fire put lifecycle event into global Dispatcher (if it is configured)
+func Fire(eventType string, data map[string]interface{}) {
+	if Instance != nil {
+		Instance.Fire(eventType, data)
+	}
+}
+
+//GetQueueThreshold return global Dispatcher queue size threshold or 0 if it isn't configured
+func GetQueueThreshold() int {
+	if Instance == nil {
+		return 0
+	}
+
+	return Instance.queueThreshold
+}
+
+//Fire put lifecycle event into sending queue
+func (d *Dispatcher) Fire(eventType string, data map[string]interface{}) {
+	event := &Event{Type: eventType, Server: d.serverName, Timestamp: time.Now().UTC(), Data: data}
+	select {
+	case d.events <- event:
+	default:
+		log.Printf("Webhooks queue is full. Event %s %v will be skipped", eventType, data)
+	}
+}
+
+//Close stop sending goroutine after sending of already queued events
+func (d *Dispatcher) Close() error {
+	d.closeOnce.Do(func() {
+		close(d.closed)
+	})
+	d.wg.Wait()
+
+	return nil
+}
+
+func (d *Dispatcher) start() {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		for {
+			select {
+			case event := <-d.events:
+				d.send(event)
+			case <-d.closed:
+				//send queued events
+				for {
+					select {
+					case event := <-d.events:
+						d.send(event)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+//send event to all hooks which are subscribed on event type
+func (d *Dispatcher) send(event *Event) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error marshaling webhook event %s: %v", event.Type, err)
+		return
+	}
+
+	for _, h := range d.hooks {
+		if len(h.events) > 0 && !h.events[event.Type] {
+			continue
+		}
+
+		if err := h.send(body); err != nil {
+			log.Printf("Error sending webhook event %s to %s: %v", event.Type, h.url, err)
+		}
+	}
+}
+
+//send POST request with retries on network errors and 5xx responses
+func (h *hook) send(body []byte) (err error) {
+	for i := 0; i < requestRetries; i++ {
+		if i > 0 {
+			time.Sleep(retryWait)
+		}
+
+		var retry bool
+		retry, err = h.doRequest(body)
+		if !retry {
+			return
+		}
+	}
+
+	return
+}
+
+func (h *hook) doRequest(body []byte) (bool, error) {
+	request, err := http.NewRequest(http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for name, value := range h.headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := h.client.Do(request)
+	if err != nil {
+		return true, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusInternalServerError {
+		return true, fmt.Errorf("response code: %d", response.StatusCode)
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		return false, fmt.Errorf("response code: %d", response.StatusCode)
+	}
+
+	return false, nil
+}
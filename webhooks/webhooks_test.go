@@ -0,0 +1,55 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestDispatcher(t *testing.T) {
+	var mutex sync.Mutex
+	received := map[string][]*Event{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		event := &Event{}
+		require.NoError(t, json.Unmarshal(body, event))
+		require.Equal(t, "secret", r.Header.Get("X-Token"))
+
+		mutex.Lock()
+		received[r.URL.Path] = append(received[r.URL.Path], event)
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	dispatcher, err := NewDispatcher(&Config{Hooks: []HookConfig{
+		{URL: server.URL + "/all", Headers: map[string]string{"X-Token": "secret"}},
+		{URL: server.URL + "/failed", Events: []string{DestinationFailed}, Headers: map[string]string{"X-Token": "secret"}},
+	}}, "test-server")
+	require.NoError(t, err)
+
+	dispatcher.Fire(DestinationCreated, map[string]interface{}{"destination": "d1"})
+	dispatcher.Fire(DestinationFailed, map[string]interface{}{"destination": "d2"})
+	require.NoError(t, dispatcher.Close())
+
+	require.Equal(t, 2, len(received["/all"]))
+	require.Equal(t, DestinationCreated, received["/all"][0].Type)
+	require.Equal(t, "test-server", received["/all"][0].Server)
+	require.Equal(t, "d1", received["/all"][0].Data["destination"])
+
+	require.Equal(t, 1, len(received["/failed"]))
+	require.Equal(t, DestinationFailed, received["/failed"][0].Type)
+	require.Equal(t, "d2", received["/failed"][0].Data["destination"])
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.EqualError(t, (&Config{Hooks: []HookConfig{{}}}).Validate(), "webhook url is required parameter")
+	require.EqualError(t, (&Config{Hooks: []HookConfig{{URL: "http://localhost", Events: []string{"unknown"}}}}).Validate(),
+		"Unknown webhook event type: unknown. Available types: [destination_created, destination_failed, columns_added, file_loaded, queue_threshold, ddl_required]")
+	require.NoError(t, (&Config{Hooks: []HookConfig{{URL: "http://localhost", Events: []string{FileLoaded}}}}).Validate())
+}